@@ -0,0 +1,49 @@
+// +build component
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestAuthorizationServerMetadata(t *testing.T) {
+	resp, err := http.Get("http://simple-jwt-provider/.well-known/oauth-authorization-server")
+	if err != nil {
+		t.Fatalf("Failed to request authorization server metadata cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var metadata struct {
+		Issuer              string   `json:"issuer"`
+		JWKSURI             string   `json:"jwks_uri"`
+		TokenEndpoint       string   `json:"token_endpoint"`
+		GrantTypesSupported []string `json:"grant_types_supported"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&metadata)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %s", err)
+	}
+
+	if metadata.Issuer != "<issuer>" {
+		t.Errorf("Unexpected issuer. Expected: %q, Given: %q", "<issuer>", metadata.Issuer)
+	}
+
+	if metadata.JWKSURI != "http://simple-jwt-provider/.well-known/jwks.json" {
+		t.Errorf("Unexpected jwks uri. Expected: %q, Given: %q", "http://simple-jwt-provider/.well-known/jwks.json", metadata.JWKSURI)
+	}
+
+	if metadata.TokenEndpoint != "http://simple-jwt-provider/oauth2/token" {
+		t.Errorf("Unexpected token endpoint. Expected: %q, Given: %q", "http://simple-jwt-provider/oauth2/token", metadata.TokenEndpoint)
+	}
+
+	if !reflect.DeepEqual(metadata.GrantTypesSupported, []string{"password", "refresh_token"}) {
+		t.Errorf("Unexpected grant types. Expected: %q, Given: %q", []string{"password", "refresh_token"}, metadata.GrantTypesSupported)
+	}
+}
@@ -4,21 +4,29 @@ import (
 	"errors"
 	"fmt"
 	"github.com/ardanlabs/conf"
+	"github.com/leberKleber/simple-jwt-provider/internal"
+	"net"
 	"os"
+	"strings"
 	"time"
 )
 
 var confUsage = conf.Usage
 
 type config struct {
-	LogLevel      string `conf:"env:LOG_LEVEL,help:Log-Level can be TRACE DEBUG INFO WARN ERROR FATAL or PANIC,default:INFO"`
-	ServerAddress string `conf:"env:SERVER_ADDRESS,help:Server-address network-interface to bind on e.g.: '127.0.0.1:8080',default:0.0.0.0:80"`
-	JWT           struct {
-		Lifetime   time.Duration `conf:"env:JWT_LIFETIME,help:Lifetime of JWT,default:4h"`
-		PrivateKey string        `conf:"env:JWT_PRIVATE_KEY,help:JWT PrivateKey ECDSA512,required,noprint"`
-		Audience   string        `conf:"env:JWT_AUDIENCE,help:Audience private claim which will be applied in each JWT"`
-		Issuer     string        `conf:"env:JWT_ISSUER,help:Issuer private claim which will be applied in each JWT"`
-		Subject    string        `conf:"env:JWT_SUBJECT,help:Subject private claim which will be applied in each JWT"`
+	LogLevel       string   `conf:"env:LOG_LEVEL,help:Log-Level can be TRACE DEBUG INFO WARN ERROR FATAL or PANIC,default:INFO"`
+	ServerAddress  string   `conf:"env:SERVER_ADDRESS,help:Server-address network-interface to bind on e.g.: '127.0.0.1:8080',default:0.0.0.0:80"`
+	TrustedProxies []string `conf:"env:TRUSTED_PROXIES,help:';' separated list of ips or cidrs of proxies whose X-Forwarded-For header will be used to determine the client ip e.g. 10.0.0.0/8. Empty ignores the header"`
+	JWT            struct {
+		Lifetime           time.Duration `conf:"env:JWT_LIFETIME,help:Lifetime of JWT,default:4h"`
+		RefreshLifetime    time.Duration `conf:"env:JWT_REFRESH_LIFETIME,help:Lifetime of refresh-tokens,default:168h"`
+		PrivateKey         string        `conf:"env:JWT_PRIVATE_KEY,help:JWT PrivateKey (ECDSA / RSA / Ed25519) as PEM or as path to a PEM file prefixed with 'file:',required,noprint"`
+		Algorithm          string        `conf:"env:JWT_ALGORITHM,help:JWT signing algorithm e.g. RS256 or PS256 for RSA keys. Detected from the private-key when empty"`
+		PreviousKeys       []string      `conf:"env:JWT_PREVIOUS_KEYS,help:';' separated list of previous JWT keys which are only used for validation as PEM or as path to a PEM file prefixed with 'file:',noprint"`
+		KeysReloadInterval time.Duration `conf:"env:JWT_KEYS_RELOAD_INTERVAL,help:Interval to reload all JWT keys from their sources. 0 disables the periodic reload. Keys will always be reloaded on SIGHUP,default:0s"`
+		Audience           string        `conf:"env:JWT_AUDIENCE,help:Audience private claim which will be applied in each JWT"`
+		Issuer             string        `conf:"env:JWT_ISSUER,help:Issuer private claim which will be applied in each JWT"`
+		Subject            string        `conf:"env:JWT_SUBJECT,help:Subject private claim which will be applied in each JWT"`
 	}
 	Database struct {
 		Type string `conf:"env:DATABASE_TYPE,help:Database type. Currently supported postgres and sqlite,required"`
@@ -29,6 +37,77 @@ type config struct {
 		Username string `conf:"env:ADMIN_API_USERNAME,help:Basic Auth Username if enable-admin-api = true"`
 		Password string `conf:"env:ADMIN_API_PASSWORD,help:Basic Auth Password if enable-admin-api = true when is bcrypted prefix with 'bcrypt',noprint"`
 	}
+	Introspection struct {
+		Clients []string `conf:"env:INTROSPECTION_CLIENTS,help:';' separated list of 'client-id:secret' pairs which are allowed to introspect tokens. Secrets can be bcrypted with prefix 'bcrypt:',noprint"`
+	}
+	Cleanup struct {
+		Interval  time.Duration `conf:"env:CLEANUP_INTERVAL,help:Interval to purge expired tokens and unverified users from the database. 0 disables the cleanup,default:1h"`
+		BatchSize int           `conf:"env:CLEANUP_BATCH_SIZE,help:Maximum number of tokens or users which will be deleted at once,default:1000"`
+	}
+	Lockout struct {
+		MaxFailures int           `conf:"env:LOCKOUT_MAX_FAILURES,help:Number of failed logins of an email or client ip after which their logins will be locked. 0 disables the lockout,default:5"`
+		Duration    time.Duration `conf:"env:LOCKOUT_DURATION,help:Duration of the first lockout. Each further lockout doubles the duration,default:1m"`
+		MaxDuration time.Duration `conf:"env:LOCKOUT_MAX_DURATION,help:Maximum duration of a lockout. Failed logins will be forgotten after this duration,default:24h"`
+	}
+	RateLimit struct {
+		Requests int           `conf:"env:RATE_LIMIT_REQUESTS,help:Number of requests per client ip and email to the oauth2 token / login / refresh and password-reset-request endpoints within the rate-limit-interval. 0 disables the rate limit,default:60"`
+		Interval time.Duration `conf:"env:RATE_LIMIT_INTERVAL,help:Interval in which the rate-limit-requests are allowed,default:1m"`
+	}
+	Password struct {
+		HashAlgorithm string `conf:"env:PASSWORD_HASH_ALGORITHM,help:Algorithm to hash passwords. Currently supported argon2id / scrypt and bcrypt. Passwords with other algorithms or parameters will be rehashed on login,default:argon2id"`
+		BcryptCost    int    `conf:"env:PASSWORD_BCRYPT_COST,help:Cost of bcrypt hashes,default:12"`
+		Argon2id      struct {
+			Memory      uint32 `conf:"env:PASSWORD_ARGON2ID_MEMORY,help:Memory of argon2id hashes in KiB,default:65536"`
+			Iterations  uint32 `conf:"env:PASSWORD_ARGON2ID_ITERATIONS,help:Number of iterations of argon2id hashes,default:3"`
+			Parallelism uint8  `conf:"env:PASSWORD_ARGON2ID_PARALLELISM,help:Number of threads of argon2id hashes,default:2"`
+		}
+		Scrypt struct {
+			CostExponent int `conf:"env:PASSWORD_SCRYPT_COST_EXPONENT,help:Cost of scrypt hashes as exponent of 2,default:15"`
+			BlockSize    int `conf:"env:PASSWORD_SCRYPT_BLOCK_SIZE,help:Block size of scrypt hashes,default:8"`
+			Parallelism  int `conf:"env:PASSWORD_SCRYPT_PARALLELISM,help:Parallelism of scrypt hashes,default:1"`
+		}
+	}
+	PasswordPolicy struct {
+		MinLength      int  `conf:"env:PASSWORD_POLICY_MIN_LENGTH,help:Minimum number of characters of new passwords. 0 disables the rule,default:0"`
+		MaxLength      int  `conf:"env:PASSWORD_POLICY_MAX_LENGTH,help:Maximum number of characters of new passwords. 0 disables the rule,default:0"`
+		RequireUpper   bool `conf:"env:PASSWORD_POLICY_REQUIRE_UPPER,help:New passwords must contain an uppercase letter,default:false"`
+		RequireLower   bool `conf:"env:PASSWORD_POLICY_REQUIRE_LOWER,help:New passwords must contain a lowercase letter,default:false"`
+		RequireDigit   bool `conf:"env:PASSWORD_POLICY_REQUIRE_DIGIT,help:New passwords must contain a digit,default:false"`
+		RequireSpecial bool `conf:"env:PASSWORD_POLICY_REQUIRE_SPECIAL,help:New passwords must contain a special character,default:false"`
+		DisallowEMail  bool `conf:"env:PASSWORD_POLICY_DISALLOW_EMAIL,help:New passwords must not contain the email of the user,default:false"`
+		DisallowCommon bool `conf:"env:PASSWORD_POLICY_DISALLOW_COMMON,help:New passwords must not be on the built-in list of common passwords,default:false"`
+		HistorySize    int  `conf:"env:PASSWORD_POLICY_HISTORY_SIZE,help:Number of the last passwords (including the current one) which must not be reused on password reset and change. 0 disables the password history,default:0"`
+	}
+	BreachedPasswords struct {
+		Path string `conf:"env:BREACHED_PASSWORDS_PATH,help:Path to a local HIBP-style dataset of SHA-1 hashes of breached passwords. Either a file with 'HASH:COUNT' lines or a directory of range files. Empty disables the check"`
+		Mode string `conf:"env:BREACHED_PASSWORDS_MODE,help:How breached passwords will be handled. 'reject' rejects them and 'warn' accepts them but flags the user,default:reject"`
+	}
+	PasswordReset struct {
+		TokenLifetime time.Duration `conf:"env:PASSWORD_RESET_TOKEN_LIFETIME,help:Lifetime of password-reset-tokens,default:24h"`
+	}
+	MagicLink struct {
+		Enable        bool          `conf:"env:MAGIC_LINK_ENABLE,help:Enable passwordless logins via magic links. Requires the magic-link mail template (true / false),default:false"`
+		TokenLifetime time.Duration `conf:"env:MAGIC_LINK_TOKEN_LIFETIME,help:Lifetime of magic-link-tokens which are sent via email for passwordless logins,default:15m"`
+	}
+	Registration struct {
+		Enable                    bool          `conf:"env:REGISTRATION_ENABLE,help:Enable the self-service registration of users via /v1/auth/register. Requires the email-verification mail template (true / false),default:false"`
+		AllowedDomains            []string      `conf:"env:REGISTRATION_ALLOWED_DOMAINS,help:';' separated list of email domains which are allowed to register e.g. example.com. Empty allows all domains"`
+		VerificationTokenLifetime time.Duration `conf:"env:REGISTRATION_VERIFICATION_TOKEN_LIFETIME,help:Lifetime of email-verification-tokens which are sent on registration,default:24h"`
+	}
+	MFA struct {
+		TokenLifetime       time.Duration `conf:"env:MFA_TOKEN_LIFETIME,help:Lifetime of mfa-tokens which are issued on login of users with multi-factor authentication,default:5m"`
+		MaxAttempts         int           `conf:"env:MFA_MAX_ATTEMPTS,help:Number of incorrect codes after which an mfa-token will be invalidated,default:5"`
+		TOTPIssuer          string        `conf:"env:MFA_TOTP_ISSUER,help:Issuer which will be shown in authenticator apps,default:simple-jwt-provider"`
+		EMailOTPEnable      bool          `conf:"env:MFA_EMAIL_OTP_ENABLE,help:Enable one-time codes via email as second factor. Requires the mfa-code mail template (true / false),default:false"`
+		EMailOTPLifetime    time.Duration `conf:"env:MFA_EMAIL_OTP_LIFETIME,help:Lifetime of one-time codes which are sent via email,default:10m"`
+		EMailOTPMaxAttempts int           `conf:"env:MFA_EMAIL_OTP_MAX_ATTEMPTS,help:Number of incorrect attempts after which a one-time code sent via email will be invalidated,default:5"`
+	}
+	WebAuthn struct {
+		RPID    string        `conf:"env:WEBAUTHN_RP_ID,help:Relying party id of passkey logins which is the domain of the service e.g. example.com. Empty disables WebAuthn"`
+		RPName  string        `conf:"env:WEBAUTHN_RP_NAME,help:Relying party name which will be shown by authenticators,default:simple-jwt-provider"`
+		Origins []string      `conf:"env:WEBAUTHN_ORIGINS,help:';' separated list of web origins from which WebAuthn ceremonies are accepted e.g. https://login.example.com"`
+		Timeout time.Duration `conf:"env:WEBAUTHN_TIMEOUT,help:Time in which WebAuthn registrations and logins have to be completed,default:5m"`
+	}
 	Mail struct {
 		TemplatesFolderPath string `conf:"env:MAIL_TEMPLATES_FOLDER_PATH,help:Path to mail-templates folder,default:/mail-templates"`
 		SMTPHost            string `conf:"env:MAIL_SMTP_HOST,help:SMTP host to connect to,required"`
@@ -58,5 +137,88 @@ func newConfig() (config, error) {
 		return cfg, errors.New("admin-api-password and admin-api-username must be set if api has been enabled")
 	}
 
+	if cfg.Cleanup.BatchSize < 1 {
+		return cfg, errors.New("cleanup-batch-size must be greater than 0")
+	}
+
+	if cfg.RateLimit.Requests > 0 && cfg.RateLimit.Interval <= 0 {
+		return cfg, errors.New("rate-limit-interval must be greater than 0")
+	}
+
+	if cfg.PasswordPolicy.MaxLength > 0 && cfg.PasswordPolicy.MinLength > cfg.PasswordPolicy.MaxLength {
+		return cfg, errors.New("password-policy-min-length must not be greater than password-policy-max-length")
+	}
+
+	if cfg.MFA.MaxAttempts < 1 {
+		return cfg, errors.New("mfa-max-attempts must be greater than 0")
+	}
+
+	if cfg.MFA.EMailOTPMaxAttempts < 1 {
+		return cfg, errors.New("mfa-email-otp-max-attempts must be greater than 0")
+	}
+
+	if cfg.WebAuthn.RPID != "" && len(cfg.WebAuthn.Origins) == 0 {
+		return cfg, errors.New("webauthn-origins must be set if webauthn-rp-id has been set")
+	}
+
+	if cfg.BreachedPasswords.Mode != internal.BreachedPasswordModeReject && cfg.BreachedPasswords.Mode != internal.BreachedPasswordModeWarn {
+		return cfg, errors.New("breached-passwords-mode must be 'reject' or 'warn'")
+	}
+
+	for _, proxy := range cfg.TrustedProxies {
+		if _, err := parseTrustedProxy(proxy); err != nil {
+			return cfg, errors.New("trusted-proxies must be formatted as ip or cidr")
+		}
+	}
+
+	for _, client := range cfg.Introspection.Clients {
+		if !strings.Contains(client, ":") {
+			return cfg, errors.New("introspection-clients must be formatted as 'client-id:secret'")
+		}
+	}
+
 	return cfg, nil
 }
+
+// introspectionClients returns the configured introspection clients mapped by their client-id
+func (c config) introspectionClients() map[string]string {
+	clients := map[string]string{}
+	for _, client := range c.Introspection.Clients {
+		parts := strings.SplitN(client, ":", 2)
+		clients[parts[0]] = parts[1]
+	}
+
+	return clients
+}
+
+// trustedProxies returns the configured trusted proxies as networks
+func (c config) trustedProxies() []*net.IPNet {
+	var proxies []*net.IPNet
+	for _, proxy := range c.TrustedProxies {
+		network, err := parseTrustedProxy(proxy)
+		if err == nil {
+			proxies = append(proxies, network)
+		}
+	}
+
+	return proxies
+}
+
+// parseTrustedProxy parses the given ip or cidr into a network. A single ip will be parsed into a network containing
+// only this ip.
+func parseTrustedProxy(proxy string) (*net.IPNet, error) {
+	proxy = strings.TrimSpace(proxy)
+	if !strings.Contains(proxy, "/") {
+		ip := net.ParseIP(proxy)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid ip %q", proxy)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+
+	_, network, err := net.ParseCIDR(proxy)
+	return network, err
+}
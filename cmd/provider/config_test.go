@@ -3,16 +3,33 @@ package main
 import (
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestNewConfig(t *testing.T) {
 	serverAddress := "leberKleber.io"
 	setEnv(t, "SJP_SERVER_ADDRESS", serverAddress)
+	_, expectedTrustedProxyNetwork, _ := net.ParseCIDR("10.0.0.0/8")
+	expectedTrustedProxies := []*net.IPNet{expectedTrustedProxyNetwork, {IP: net.IPv4(192, 168, 0, 1).To4(), Mask: net.CIDRMask(32, 32)}}
+	trustedProxies := "10.0.0.0/8;192.168.0.1"
+	setEnv(t, "SJP_TRUSTED_PROXIES", trustedProxies)
+	expectedJWTRefreshLifetime := 72 * time.Hour
+	jwtRefreshLifetime := "72h"
+	setEnv(t, "SJP_JWT_REFRESH_LIFETIME", jwtRefreshLifetime)
 	jwtPrivateKey := "myJWTKey"
 	setEnv(t, "SJP_JWT_PRIVATE_KEY", jwtPrivateKey)
+	jwtAlgorithm := "PS256"
+	setEnv(t, "SJP_JWT_ALGORITHM", jwtAlgorithm)
+	expectedJWTPreviousKeys := []string{"myPreviousKey1", "file:/my/previous/key2"}
+	jwtPreviousKeys := "myPreviousKey1;file:/my/previous/key2"
+	setEnv(t, "SJP_JWT_PREVIOUS_KEYS", jwtPreviousKeys)
+	expectedJWTKeysReloadInterval := 5 * time.Minute
+	jwtKeysReloadInterval := "5m"
+	setEnv(t, "SJP_JWT_KEYS_RELOAD_INTERVAL", jwtKeysReloadInterval)
 	jwtAudience := "myJWTAudience"
 	setEnv(t, "SJP_JWT_AUDIENCE", jwtAudience)
 	jwtIssuer := "myJWTIssuer"
@@ -30,6 +47,92 @@ func TestNewConfig(t *testing.T) {
 	setEnv(t, "SJP_ADMIN_API_USERNAME", adminAPIUsername)
 	adminAPIPassword := "myAdminAPIPassword"
 	setEnv(t, "SJP_ADMIN_API_PASSWORD", adminAPIPassword)
+	expectedIntrospectionClients := map[string]string{"client-a": "secret-a", "client-b": "bcrypt:$2y$12$hash"}
+	introspectionClients := "client-a:secret-a;client-b:bcrypt:$2y$12$hash"
+	setEnv(t, "SJP_INTROSPECTION_CLIENTS", introspectionClients)
+	expectedCleanupInterval := 10 * time.Minute
+	cleanupInterval := "10m"
+	setEnv(t, "SJP_CLEANUP_INTERVAL", cleanupInterval)
+	expectedCleanupBatchSize := 50
+	cleanupBatchSize := "50"
+	setEnv(t, "SJP_CLEANUP_BATCH_SIZE", cleanupBatchSize)
+	expectedLockoutMaxFailures := 3
+	lockoutMaxFailures := "3"
+	setEnv(t, "SJP_LOCKOUT_MAX_FAILURES", lockoutMaxFailures)
+	expectedLockoutDuration := 5 * time.Minute
+	lockoutDuration := "5m"
+	setEnv(t, "SJP_LOCKOUT_DURATION", lockoutDuration)
+	expectedLockoutMaxDuration := 12 * time.Hour
+	lockoutMaxDuration := "12h"
+	setEnv(t, "SJP_LOCKOUT_MAX_DURATION", lockoutMaxDuration)
+	expectedRateLimitRequests := 20
+	rateLimitRequests := "20"
+	setEnv(t, "SJP_RATE_LIMIT_REQUESTS", rateLimitRequests)
+	expectedRateLimitInterval := 30 * time.Second
+	rateLimitInterval := "30s"
+	setEnv(t, "SJP_RATE_LIMIT_INTERVAL", rateLimitInterval)
+	expectedPasswordHashAlgorithm := "scrypt"
+	setEnv(t, "SJP_PASSWORD_HASH_ALGORITHM", expectedPasswordHashAlgorithm)
+	expectedPasswordBcryptCost := 10
+	setEnv(t, "SJP_PASSWORD_BCRYPT_COST", "10")
+	expectedPasswordArgon2idMemory := uint32(32768)
+	setEnv(t, "SJP_PASSWORD_ARGON2ID_MEMORY", "32768")
+	expectedPasswordArgon2idIterations := uint32(4)
+	setEnv(t, "SJP_PASSWORD_ARGON2ID_ITERATIONS", "4")
+	expectedPasswordArgon2idParallelism := uint8(1)
+	setEnv(t, "SJP_PASSWORD_ARGON2ID_PARALLELISM", "1")
+	expectedPasswordScryptCostExponent := 16
+	setEnv(t, "SJP_PASSWORD_SCRYPT_COST_EXPONENT", "16")
+	expectedPasswordScryptBlockSize := 4
+	setEnv(t, "SJP_PASSWORD_SCRYPT_BLOCK_SIZE", "4")
+	expectedPasswordScryptParallelism := 2
+	setEnv(t, "SJP_PASSWORD_SCRYPT_PARALLELISM", "2")
+	expectedPasswordPolicyMinLength := 12
+	setEnv(t, "SJP_PASSWORD_POLICY_MIN_LENGTH", "12")
+	expectedPasswordPolicyMaxLength := 64
+	setEnv(t, "SJP_PASSWORD_POLICY_MAX_LENGTH", "64")
+	setEnv(t, "SJP_PASSWORD_POLICY_REQUIRE_UPPER", "true")
+	setEnv(t, "SJP_PASSWORD_POLICY_REQUIRE_LOWER", "true")
+	setEnv(t, "SJP_PASSWORD_POLICY_REQUIRE_DIGIT", "true")
+	setEnv(t, "SJP_PASSWORD_POLICY_REQUIRE_SPECIAL", "true")
+	setEnv(t, "SJP_PASSWORD_POLICY_DISALLOW_EMAIL", "false")
+	setEnv(t, "SJP_PASSWORD_POLICY_DISALLOW_COMMON", "false")
+	expectedPasswordPolicyHistorySize := 3
+	setEnv(t, "SJP_PASSWORD_POLICY_HISTORY_SIZE", "3")
+	breachedPasswordsPath := "/pwned-passwords"
+	setEnv(t, "SJP_BREACHED_PASSWORDS_PATH", breachedPasswordsPath)
+	breachedPasswordsMode := "warn"
+	setEnv(t, "SJP_BREACHED_PASSWORDS_MODE", breachedPasswordsMode)
+	expectedPasswordResetTokenLifetime := 30 * time.Minute
+	passwordResetTokenLifetime := "30m"
+	setEnv(t, "SJP_PASSWORD_RESET_TOKEN_LIFETIME", passwordResetTokenLifetime)
+	setEnv(t, "SJP_MAGIC_LINK_ENABLE", "true")
+	expectedMagicLinkTokenLifetime := 5 * time.Minute
+	setEnv(t, "SJP_MAGIC_LINK_TOKEN_LIFETIME", "5m")
+	setEnv(t, "SJP_REGISTRATION_ENABLE", "true")
+	expectedRegistrationAllowedDomains := []string{"leberkleber.io", "example.com"}
+	setEnv(t, "SJP_REGISTRATION_ALLOWED_DOMAINS", "leberkleber.io;example.com")
+	expectedRegistrationVerificationTokenLifetime := 48 * time.Hour
+	setEnv(t, "SJP_REGISTRATION_VERIFICATION_TOKEN_LIFETIME", "48h")
+	expectedMFATokenLifetime := 10 * time.Minute
+	setEnv(t, "SJP_MFA_TOKEN_LIFETIME", "10m")
+	expectedMFAMaxAttempts := 4
+	setEnv(t, "SJP_MFA_MAX_ATTEMPTS", "4")
+	mfaTOTPIssuer := "myTOTPIssuer"
+	setEnv(t, "SJP_MFA_TOTP_ISSUER", mfaTOTPIssuer)
+	setEnv(t, "SJP_MFA_EMAIL_OTP_ENABLE", "true")
+	expectedMFAEMailOTPLifetime := 15 * time.Minute
+	setEnv(t, "SJP_MFA_EMAIL_OTP_LIFETIME", "15m")
+	expectedMFAEMailOTPMaxAttempts := 3
+	setEnv(t, "SJP_MFA_EMAIL_OTP_MAX_ATTEMPTS", "3")
+	webAuthnRPID := "leberkleber.io"
+	setEnv(t, "SJP_WEBAUTHN_RP_ID", webAuthnRPID)
+	webAuthnRPName := "myWebAuthnRPName"
+	setEnv(t, "SJP_WEBAUTHN_RP_NAME", webAuthnRPName)
+	expectedWebAuthnOrigins := []string{"https://leberkleber.io", "https://login.leberkleber.io"}
+	setEnv(t, "SJP_WEBAUTHN_ORIGINS", "https://leberkleber.io;https://login.leberkleber.io")
+	expectedWebAuthnTimeout := 2 * time.Minute
+	setEnv(t, "SJP_WEBAUTHN_TIMEOUT", "2m")
 	mailTemplatesFolderPath := "myAdminAPIMailTemplatesFolderPath"
 	setEnv(t, "SJP_MAIL_TEMPLATES_FOLDER_PATH", mailTemplatesFolderPath)
 	mailSMTPHost := "myMailSMTPHost"
@@ -53,7 +156,11 @@ func TestNewConfig(t *testing.T) {
 	}
 
 	fieldEqual(t, "serverAddress", cfg.ServerAddress, serverAddress)
+	fieldEqual(t, "jwt>refreshLifetime", cfg.JWT.RefreshLifetime, expectedJWTRefreshLifetime)
 	fieldEqual(t, "jwt>privateKey", cfg.JWT.PrivateKey, jwtPrivateKey)
+	fieldEqual(t, "jwt>algorithm", cfg.JWT.Algorithm, jwtAlgorithm)
+	fieldEqual(t, "jwt>previousKeys", cfg.JWT.PreviousKeys, expectedJWTPreviousKeys)
+	fieldEqual(t, "jwt>keysReloadInterval", cfg.JWT.KeysReloadInterval, expectedJWTKeysReloadInterval)
 	fieldEqual(t, "jwt>audience", cfg.JWT.Audience, jwtAudience)
 	fieldEqual(t, "jwt>issuer", cfg.JWT.Issuer, jwtIssuer)
 	fieldEqual(t, "jwt>subject", cfg.JWT.Subject, jwtSubject)
@@ -63,6 +170,50 @@ func TestNewConfig(t *testing.T) {
 	fieldEqual(t, "adminAPI>enable", cfg.AdminAPI.Enable, expectedAdminAPIEnable)
 	fieldEqual(t, "adminAPI>username", cfg.AdminAPI.Username, adminAPIUsername)
 	fieldEqual(t, "adminAPI>password", cfg.AdminAPI.Password, adminAPIPassword)
+	fieldEqual(t, "trustedProxies", cfg.trustedProxies(), expectedTrustedProxies)
+	fieldEqual(t, "introspection>clients", cfg.introspectionClients(), expectedIntrospectionClients)
+	fieldEqual(t, "cleanup>interval", cfg.Cleanup.Interval, expectedCleanupInterval)
+	fieldEqual(t, "cleanup>batchSize", cfg.Cleanup.BatchSize, expectedCleanupBatchSize)
+	fieldEqual(t, "lockout>maxFailures", cfg.Lockout.MaxFailures, expectedLockoutMaxFailures)
+	fieldEqual(t, "lockout>duration", cfg.Lockout.Duration, expectedLockoutDuration)
+	fieldEqual(t, "lockout>maxDuration", cfg.Lockout.MaxDuration, expectedLockoutMaxDuration)
+	fieldEqual(t, "rateLimit>requests", cfg.RateLimit.Requests, expectedRateLimitRequests)
+	fieldEqual(t, "rateLimit>interval", cfg.RateLimit.Interval, expectedRateLimitInterval)
+	fieldEqual(t, "password>hashAlgorithm", cfg.Password.HashAlgorithm, expectedPasswordHashAlgorithm)
+	fieldEqual(t, "password>bcryptCost", cfg.Password.BcryptCost, expectedPasswordBcryptCost)
+	fieldEqual(t, "password>argon2id>memory", cfg.Password.Argon2id.Memory, expectedPasswordArgon2idMemory)
+	fieldEqual(t, "password>argon2id>iterations", cfg.Password.Argon2id.Iterations, expectedPasswordArgon2idIterations)
+	fieldEqual(t, "password>argon2id>parallelism", cfg.Password.Argon2id.Parallelism, expectedPasswordArgon2idParallelism)
+	fieldEqual(t, "password>scrypt>costExponent", cfg.Password.Scrypt.CostExponent, expectedPasswordScryptCostExponent)
+	fieldEqual(t, "password>scrypt>blockSize", cfg.Password.Scrypt.BlockSize, expectedPasswordScryptBlockSize)
+	fieldEqual(t, "password>scrypt>parallelism", cfg.Password.Scrypt.Parallelism, expectedPasswordScryptParallelism)
+	fieldEqual(t, "passwordPolicy>minLength", cfg.PasswordPolicy.MinLength, expectedPasswordPolicyMinLength)
+	fieldEqual(t, "passwordPolicy>maxLength", cfg.PasswordPolicy.MaxLength, expectedPasswordPolicyMaxLength)
+	fieldEqual(t, "passwordPolicy>requireUpper", cfg.PasswordPolicy.RequireUpper, true)
+	fieldEqual(t, "passwordPolicy>requireLower", cfg.PasswordPolicy.RequireLower, true)
+	fieldEqual(t, "passwordPolicy>requireDigit", cfg.PasswordPolicy.RequireDigit, true)
+	fieldEqual(t, "passwordPolicy>requireSpecial", cfg.PasswordPolicy.RequireSpecial, true)
+	fieldEqual(t, "passwordPolicy>disallowEMail", cfg.PasswordPolicy.DisallowEMail, false)
+	fieldEqual(t, "passwordPolicy>disallowCommon", cfg.PasswordPolicy.DisallowCommon, false)
+	fieldEqual(t, "passwordPolicy>historySize", cfg.PasswordPolicy.HistorySize, expectedPasswordPolicyHistorySize)
+	fieldEqual(t, "breachedPasswords>path", cfg.BreachedPasswords.Path, breachedPasswordsPath)
+	fieldEqual(t, "breachedPasswords>mode", cfg.BreachedPasswords.Mode, breachedPasswordsMode)
+	fieldEqual(t, "passwordReset>tokenLifetime", cfg.PasswordReset.TokenLifetime, expectedPasswordResetTokenLifetime)
+	fieldEqual(t, "magicLink>enable", cfg.MagicLink.Enable, true)
+	fieldEqual(t, "magicLink>tokenLifetime", cfg.MagicLink.TokenLifetime, expectedMagicLinkTokenLifetime)
+	fieldEqual(t, "registration>enable", cfg.Registration.Enable, true)
+	fieldEqual(t, "registration>allowedDomains", cfg.Registration.AllowedDomains, expectedRegistrationAllowedDomains)
+	fieldEqual(t, "registration>verificationTokenLifetime", cfg.Registration.VerificationTokenLifetime, expectedRegistrationVerificationTokenLifetime)
+	fieldEqual(t, "mfa>tokenLifetime", cfg.MFA.TokenLifetime, expectedMFATokenLifetime)
+	fieldEqual(t, "mfa>maxAttempts", cfg.MFA.MaxAttempts, expectedMFAMaxAttempts)
+	fieldEqual(t, "mfa>totpIssuer", cfg.MFA.TOTPIssuer, mfaTOTPIssuer)
+	fieldEqual(t, "mfa>emailOTPEnable", cfg.MFA.EMailOTPEnable, true)
+	fieldEqual(t, "mfa>emailOTPLifetime", cfg.MFA.EMailOTPLifetime, expectedMFAEMailOTPLifetime)
+	fieldEqual(t, "mfa>emailOTPMaxAttempts", cfg.MFA.EMailOTPMaxAttempts, expectedMFAEMailOTPMaxAttempts)
+	fieldEqual(t, "webAuthn>rpID", cfg.WebAuthn.RPID, webAuthnRPID)
+	fieldEqual(t, "webAuthn>rpName", cfg.WebAuthn.RPName, webAuthnRPName)
+	fieldEqual(t, "webAuthn>origins", cfg.WebAuthn.Origins, expectedWebAuthnOrigins)
+	fieldEqual(t, "webAuthn>timeout", cfg.WebAuthn.Timeout, expectedWebAuthnTimeout)
 	fieldEqual(t, "mail>templatesFolderPath", cfg.Mail.TemplatesFolderPath, mailTemplatesFolderPath)
 	fieldEqual(t, "mail>smtpHost", cfg.Mail.SMTPHost, mailSMTPHost)
 	fieldEqual(t, "mail>smtpPort", cfg.Mail.SMTPPort, expectedMailSMTPPort)
@@ -116,6 +267,187 @@ func TestNewConfigWithAdminAPIConstraint(t *testing.T) {
 	cleanupEnvs(t)
 }
 
+func TestNewConfigWithInvalidIntrospectionClients(t *testing.T) {
+	cleanupEnvs(t)
+
+	setEnv(t, "SJP_JWT_PRIVATE_KEY", "myJWTKey")
+	setEnv(t, "SJP_DATABASE_DSN", "myDSN")
+	setEnv(t, "SJP_DATABASE_TYPE", "myType")
+	setEnv(t, "SJP_MAIL_SMTP_HOST", "myMailSMTPHost")
+	setEnv(t, "SJP_MAIL_SMTP_USERNAME", "myMailSMTPUsername")
+	setEnv(t, "SJP_MAIL_SMTP_PASSWORD", "myMailSMTPPassword")
+	setEnv(t, "SJP_INTROSPECTION_CLIENTS", "client-a:secret-a;client-b")
+
+	_, err := newConfig()
+	expectedError := errors.New("introspection-clients must be formatted as 'client-id:secret'")
+	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
+		t.Fatalf("returned error is not as expected. Expected:\n%s\nGiven:\n%s", expectedError, err)
+	}
+
+	cleanupEnvs(t)
+}
+
+func TestNewConfigWithInvalidTrustedProxies(t *testing.T) {
+	cleanupEnvs(t)
+
+	setEnv(t, "SJP_JWT_PRIVATE_KEY", "myJWTKey")
+	setEnv(t, "SJP_DATABASE_DSN", "myDSN")
+	setEnv(t, "SJP_DATABASE_TYPE", "myType")
+	setEnv(t, "SJP_MAIL_SMTP_HOST", "myMailSMTPHost")
+	setEnv(t, "SJP_MAIL_SMTP_USERNAME", "myMailSMTPUsername")
+	setEnv(t, "SJP_MAIL_SMTP_PASSWORD", "myMailSMTPPassword")
+	setEnv(t, "SJP_TRUSTED_PROXIES", "10.0.0.0/8;proxy.local")
+
+	_, err := newConfig()
+	expectedError := errors.New("trusted-proxies must be formatted as ip or cidr")
+	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
+		t.Fatalf("returned error is not as expected. Expected:\n%s\nGiven:\n%s", expectedError, err)
+	}
+
+	cleanupEnvs(t)
+}
+
+func TestNewConfigWithInvalidCleanupBatchSize(t *testing.T) {
+	cleanupEnvs(t)
+
+	setEnv(t, "SJP_JWT_PRIVATE_KEY", "myJWTKey")
+	setEnv(t, "SJP_DATABASE_DSN", "myDSN")
+	setEnv(t, "SJP_DATABASE_TYPE", "myType")
+	setEnv(t, "SJP_MAIL_SMTP_HOST", "myMailSMTPHost")
+	setEnv(t, "SJP_MAIL_SMTP_USERNAME", "myMailSMTPUsername")
+	setEnv(t, "SJP_MAIL_SMTP_PASSWORD", "myMailSMTPPassword")
+	setEnv(t, "SJP_CLEANUP_BATCH_SIZE", "0")
+
+	_, err := newConfig()
+	expectedError := errors.New("cleanup-batch-size must be greater than 0")
+	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
+		t.Fatalf("returned error is not as expected. Expected:\n%s\nGiven:\n%s", expectedError, err)
+	}
+
+	cleanupEnvs(t)
+}
+
+func TestNewConfigWithInvalidRateLimitInterval(t *testing.T) {
+	cleanupEnvs(t)
+
+	setEnv(t, "SJP_JWT_PRIVATE_KEY", "myJWTKey")
+	setEnv(t, "SJP_DATABASE_DSN", "myDSN")
+	setEnv(t, "SJP_DATABASE_TYPE", "myType")
+	setEnv(t, "SJP_MAIL_SMTP_HOST", "myMailSMTPHost")
+	setEnv(t, "SJP_MAIL_SMTP_USERNAME", "myMailSMTPUsername")
+	setEnv(t, "SJP_MAIL_SMTP_PASSWORD", "myMailSMTPPassword")
+	setEnv(t, "SJP_RATE_LIMIT_INTERVAL", "0s")
+
+	_, err := newConfig()
+	expectedError := errors.New("rate-limit-interval must be greater than 0")
+	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
+		t.Fatalf("returned error is not as expected. Expected:\n%s\nGiven:\n%s", expectedError, err)
+	}
+
+	cleanupEnvs(t)
+}
+
+func TestNewConfigWithInvalidPasswordPolicyLength(t *testing.T) {
+	cleanupEnvs(t)
+
+	setEnv(t, "SJP_JWT_PRIVATE_KEY", "myJWTKey")
+	setEnv(t, "SJP_DATABASE_DSN", "myDSN")
+	setEnv(t, "SJP_DATABASE_TYPE", "myType")
+	setEnv(t, "SJP_MAIL_SMTP_HOST", "myMailSMTPHost")
+	setEnv(t, "SJP_MAIL_SMTP_USERNAME", "myMailSMTPUsername")
+	setEnv(t, "SJP_MAIL_SMTP_PASSWORD", "myMailSMTPPassword")
+	setEnv(t, "SJP_PASSWORD_POLICY_MIN_LENGTH", "16")
+	setEnv(t, "SJP_PASSWORD_POLICY_MAX_LENGTH", "8")
+
+	_, err := newConfig()
+	expectedError := errors.New("password-policy-min-length must not be greater than password-policy-max-length")
+	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
+		t.Fatalf("returned error is not as expected. Expected:\n%s\nGiven:\n%s", expectedError, err)
+	}
+
+	cleanupEnvs(t)
+}
+
+func TestNewConfigWithInvalidMFAMaxAttempts(t *testing.T) {
+	cleanupEnvs(t)
+
+	setEnv(t, "SJP_JWT_PRIVATE_KEY", "myJWTKey")
+	setEnv(t, "SJP_DATABASE_DSN", "myDSN")
+	setEnv(t, "SJP_DATABASE_TYPE", "myType")
+	setEnv(t, "SJP_MAIL_SMTP_HOST", "myMailSMTPHost")
+	setEnv(t, "SJP_MAIL_SMTP_USERNAME", "myMailSMTPUsername")
+	setEnv(t, "SJP_MAIL_SMTP_PASSWORD", "myMailSMTPPassword")
+	setEnv(t, "SJP_MFA_MAX_ATTEMPTS", "0")
+
+	_, err := newConfig()
+	expectedError := errors.New("mfa-max-attempts must be greater than 0")
+	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
+		t.Fatalf("returned error is not as expected. Expected:\n%s\nGiven:\n%s", expectedError, err)
+	}
+
+	cleanupEnvs(t)
+}
+
+func TestNewConfigWithInvalidMFAEMailOTPMaxAttempts(t *testing.T) {
+	cleanupEnvs(t)
+
+	setEnv(t, "SJP_JWT_PRIVATE_KEY", "myJWTKey")
+	setEnv(t, "SJP_DATABASE_DSN", "myDSN")
+	setEnv(t, "SJP_DATABASE_TYPE", "myType")
+	setEnv(t, "SJP_MAIL_SMTP_HOST", "myMailSMTPHost")
+	setEnv(t, "SJP_MAIL_SMTP_USERNAME", "myMailSMTPUsername")
+	setEnv(t, "SJP_MAIL_SMTP_PASSWORD", "myMailSMTPPassword")
+	setEnv(t, "SJP_MFA_EMAIL_OTP_MAX_ATTEMPTS", "0")
+
+	_, err := newConfig()
+	expectedError := errors.New("mfa-email-otp-max-attempts must be greater than 0")
+	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
+		t.Fatalf("returned error is not as expected. Expected:\n%s\nGiven:\n%s", expectedError, err)
+	}
+
+	cleanupEnvs(t)
+}
+
+func TestNewConfigWithWebAuthnWithoutOrigins(t *testing.T) {
+	cleanupEnvs(t)
+
+	setEnv(t, "SJP_JWT_PRIVATE_KEY", "myJWTKey")
+	setEnv(t, "SJP_DATABASE_DSN", "myDSN")
+	setEnv(t, "SJP_DATABASE_TYPE", "myType")
+	setEnv(t, "SJP_MAIL_SMTP_HOST", "myMailSMTPHost")
+	setEnv(t, "SJP_MAIL_SMTP_USERNAME", "myMailSMTPUsername")
+	setEnv(t, "SJP_MAIL_SMTP_PASSWORD", "myMailSMTPPassword")
+	setEnv(t, "SJP_WEBAUTHN_RP_ID", "leberkleber.io")
+
+	_, err := newConfig()
+	expectedError := errors.New("webauthn-origins must be set if webauthn-rp-id has been set")
+	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
+		t.Fatalf("returned error is not as expected. Expected:\n%s\nGiven:\n%s", expectedError, err)
+	}
+
+	cleanupEnvs(t)
+}
+
+func TestNewConfigWithInvalidBreachedPasswordsMode(t *testing.T) {
+	cleanupEnvs(t)
+
+	setEnv(t, "SJP_JWT_PRIVATE_KEY", "myJWTKey")
+	setEnv(t, "SJP_DATABASE_DSN", "myDSN")
+	setEnv(t, "SJP_DATABASE_TYPE", "myType")
+	setEnv(t, "SJP_MAIL_SMTP_HOST", "myMailSMTPHost")
+	setEnv(t, "SJP_MAIL_SMTP_USERNAME", "myMailSMTPUsername")
+	setEnv(t, "SJP_MAIL_SMTP_PASSWORD", "myMailSMTPPassword")
+	setEnv(t, "SJP_BREACHED_PASSWORDS_MODE", "ignore")
+
+	_, err := newConfig()
+	expectedError := errors.New("breached-passwords-mode must be 'reject' or 'warn'")
+	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
+		t.Fatalf("returned error is not as expected. Expected:\n%s\nGiven:\n%s", expectedError, err)
+	}
+
+	cleanupEnvs(t)
+}
+
 func TestNewConfigCfgLibErrorHandling(t *testing.T) {
 	cleanupEnvs(t)
 
@@ -172,12 +504,59 @@ func fieldEqual(t *testing.T, name string, cfgValue, expectedValue interface{})
 
 func cleanupEnvs(t *testing.T) {
 	unsetEnv(t, "SJP_SERVER_ADDRESS")
+	unsetEnv(t, "SJP_TRUSTED_PROXIES")
+	unsetEnv(t, "SJP_JWT_REFRESH_LIFETIME")
 	unsetEnv(t, "SJP_JWT_PRIVATE_KEY")
+	unsetEnv(t, "SJP_JWT_ALGORITHM")
+	unsetEnv(t, "SJP_JWT_PREVIOUS_KEYS")
+	unsetEnv(t, "SJP_JWT_KEYS_RELOAD_INTERVAL")
 	unsetEnv(t, "SJP_JWT_AUDIENCE")
 	unsetEnv(t, "SJP_JWT_ISSUER")
 	unsetEnv(t, "SJP_JWT_SUBJECT")
 	unsetEnv(t, "SJP_DATABASE_DSN")
 	unsetEnv(t, "SJP_DATABASE_TYPE")
+	unsetEnv(t, "SJP_CLEANUP_INTERVAL")
+	unsetEnv(t, "SJP_CLEANUP_BATCH_SIZE")
+	unsetEnv(t, "SJP_LOCKOUT_MAX_FAILURES")
+	unsetEnv(t, "SJP_LOCKOUT_DURATION")
+	unsetEnv(t, "SJP_LOCKOUT_MAX_DURATION")
+	unsetEnv(t, "SJP_RATE_LIMIT_REQUESTS")
+	unsetEnv(t, "SJP_RATE_LIMIT_INTERVAL")
+	unsetEnv(t, "SJP_PASSWORD_HASH_ALGORITHM")
+	unsetEnv(t, "SJP_PASSWORD_BCRYPT_COST")
+	unsetEnv(t, "SJP_PASSWORD_ARGON2ID_MEMORY")
+	unsetEnv(t, "SJP_PASSWORD_ARGON2ID_ITERATIONS")
+	unsetEnv(t, "SJP_PASSWORD_ARGON2ID_PARALLELISM")
+	unsetEnv(t, "SJP_PASSWORD_SCRYPT_COST_EXPONENT")
+	unsetEnv(t, "SJP_PASSWORD_SCRYPT_BLOCK_SIZE")
+	unsetEnv(t, "SJP_PASSWORD_SCRYPT_PARALLELISM")
+	unsetEnv(t, "SJP_PASSWORD_POLICY_MIN_LENGTH")
+	unsetEnv(t, "SJP_PASSWORD_POLICY_MAX_LENGTH")
+	unsetEnv(t, "SJP_PASSWORD_POLICY_REQUIRE_UPPER")
+	unsetEnv(t, "SJP_PASSWORD_POLICY_REQUIRE_LOWER")
+	unsetEnv(t, "SJP_PASSWORD_POLICY_REQUIRE_DIGIT")
+	unsetEnv(t, "SJP_PASSWORD_POLICY_REQUIRE_SPECIAL")
+	unsetEnv(t, "SJP_PASSWORD_POLICY_DISALLOW_EMAIL")
+	unsetEnv(t, "SJP_PASSWORD_POLICY_DISALLOW_COMMON")
+	unsetEnv(t, "SJP_PASSWORD_POLICY_HISTORY_SIZE")
+	unsetEnv(t, "SJP_BREACHED_PASSWORDS_PATH")
+	unsetEnv(t, "SJP_BREACHED_PASSWORDS_MODE")
+	unsetEnv(t, "SJP_PASSWORD_RESET_TOKEN_LIFETIME")
+	unsetEnv(t, "SJP_MAGIC_LINK_ENABLE")
+	unsetEnv(t, "SJP_MAGIC_LINK_TOKEN_LIFETIME")
+	unsetEnv(t, "SJP_REGISTRATION_ENABLE")
+	unsetEnv(t, "SJP_REGISTRATION_ALLOWED_DOMAINS")
+	unsetEnv(t, "SJP_REGISTRATION_VERIFICATION_TOKEN_LIFETIME")
+	unsetEnv(t, "SJP_MFA_TOKEN_LIFETIME")
+	unsetEnv(t, "SJP_MFA_MAX_ATTEMPTS")
+	unsetEnv(t, "SJP_MFA_TOTP_ISSUER")
+	unsetEnv(t, "SJP_MFA_EMAIL_OTP_ENABLE")
+	unsetEnv(t, "SJP_MFA_EMAIL_OTP_LIFETIME")
+	unsetEnv(t, "SJP_MFA_EMAIL_OTP_MAX_ATTEMPTS")
+	unsetEnv(t, "SJP_WEBAUTHN_RP_ID")
+	unsetEnv(t, "SJP_WEBAUTHN_RP_NAME")
+	unsetEnv(t, "SJP_WEBAUTHN_ORIGINS")
+	unsetEnv(t, "SJP_WEBAUTHN_TIMEOUT")
 	unsetEnv(t, "SJP_MAIL_TEMPLATES_FOLDER_PATH")
 	unsetEnv(t, "SJP_MAIL_SMTP_HOST")
 	unsetEnv(t, "SJP_MAIL_SMTP_PORT")
@@ -188,4 +567,5 @@ func cleanupEnvs(t *testing.T) {
 	unsetEnv(t, "SJP_ADMIN_API_ENABLE")
 	unsetEnv(t, "SJP_ADMIN_API_USERNAME")
 	unsetEnv(t, "SJP_ADMIN_API_PASSWORD")
+	unsetEnv(t, "SJP_INTROSPECTION_CLIENTS")
 }
@@ -0,0 +1,99 @@
+// +build component
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestEMailOTP(t *testing.T) {
+	email := "email_otp_test@leberkleber.io"
+	password := "s3cr3t"
+
+	createUser(t, email, password)
+	accessToken, _, _ := loginUser(t, email, password)
+
+	enrollEMailOTP(t, accessToken)
+	code := findEMailOTP(t, email, "")
+	confirmEMailOTP(t, accessToken, code, http.StatusNoContent)
+
+	mfaToken := loginUserWithMFA(t, email, password)
+	newCode := findEMailOTP(t, email, code)
+	verifyMFA(t, email, mfaToken, fmt.Sprintf(`"code": %q`, code), http.StatusUnauthorized)
+	verifyMFA(t, email, mfaToken, fmt.Sprintf(`"code": %q`, newCode), http.StatusOK)
+
+	disableMFA(t, email)
+}
+
+func enrollEMailOTP(t *testing.T, accessToken string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, "http://simple-jwt-provider/v1/auth/mfa/email", nil)
+	if err != nil {
+		t.Fatalf("Failed to create http request")
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to enroll email one-time codes cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusAccepted, resp.StatusCode)
+	}
+}
+
+func confirmEMailOTP(t *testing.T, accessToken, code string, expectedStatusCode int) {
+	t.Helper()
+	req, err := http.NewRequest(
+		http.MethodPost,
+		"http://simple-jwt-provider/v1/auth/mfa/email/confirm",
+		bytes.NewReader([]byte(fmt.Sprintf(`{"code": %q}`, code))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create http request")
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to confirm email one-time codes cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatusCode {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", expectedStatusCode, resp.StatusCode)
+	}
+}
+
+// findEMailOTP returns the code of the latest mfa-code mail of the given email which differs from previousCode
+func findEMailOTP(t *testing.T, email, previousCode string) string {
+	t.Helper()
+	reg := regexp.MustCompile(`\n([0-9]{6})\r?\n`)
+
+	// mails will be sent asynchronously
+	for i := 0; i < 10; i++ {
+		if i > 0 {
+			time.Sleep(500 * time.Millisecond)
+		}
+
+		respMail, found := findMail(t, email)
+		if !found {
+			continue
+		}
+
+		res := reg.FindStringSubmatch(respMail.Data)
+		if len(res) == 2 && res[1] != previousCode {
+			return res[1]
+		}
+	}
+
+	t.Fatal("could not find mail with one-time code")
+	return ""
+}
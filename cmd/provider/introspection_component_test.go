@@ -0,0 +1,101 @@
+// +build component
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestIntrospection(t *testing.T) {
+	email := "introspection_test@leberkleber.io"
+	password := "s3cr3t"
+
+	createUser(t, email, password)
+	accessToken, refreshToken, authorized := loginUser(t, email, password)
+	if !authorized {
+		t.Fatal("could not login user")
+	}
+
+	if !introspectToken(t, accessToken)["active"].(bool) {
+		t.Error("access-token should be active")
+	}
+
+	refreshTokenClaims := introspectToken(t, refreshToken)
+	if !refreshTokenClaims["active"].(bool) {
+		t.Error("refresh-token should be active")
+	}
+
+	if refreshTokenClaims["username"] != email {
+		t.Errorf("Unexpected username. Expected: %q, Given: %q", email, refreshTokenClaims["username"])
+	}
+
+	_, newRefreshToken := refresh(t, refreshToken)
+	if newRefreshToken == "" {
+		t.Fatal("could not refresh tokens")
+	}
+
+	if introspectToken(t, refreshToken)["active"].(bool) {
+		t.Error("used refresh-token should not be active")
+	}
+
+	if introspectToken(t, "invalid")["active"].(bool) {
+		t.Error("invalid token should not be active")
+	}
+}
+
+func introspectToken(t *testing.T, token string) map[string]interface{} {
+	t.Helper()
+	req, err := http.NewRequest(
+		http.MethodPost,
+		"http://simple-jwt-provider/v1/auth/introspect",
+		strings.NewReader(url.Values{"token": {token}}.Encode()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create http request")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("username", "password")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to introspect token cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var respBody map[string]interface{}
+	err = json.NewDecoder(resp.Body).Decode(&respBody)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %s", err)
+	}
+
+	return respBody
+}
+
+func TestIntrospectionOfRevokedAccessToken(t *testing.T) {
+	email := "introspection_revoked_test@leberkleber.io"
+	password := "s3cr3t"
+
+	createUser(t, email, password)
+	accessToken, _, authorized := loginUser(t, email, password)
+	if !authorized {
+		t.Fatal("could not login user")
+	}
+
+	resp, err := http.PostForm("http://simple-jwt-provider/v1/auth/revoke", url.Values{"token": {accessToken}})
+	if err != nil {
+		t.Fatalf("Failed to revoke token cause: %s", err)
+	}
+	resp.Body.Close()
+
+	if introspectToken(t, accessToken)["active"].(bool) {
+		t.Error("revoked access-token should not be active")
+	}
+}
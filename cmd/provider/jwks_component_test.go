@@ -0,0 +1,78 @@
+// +build component
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"math/big"
+	"net/http"
+	"testing"
+)
+
+func TestJWKS(t *testing.T) {
+	email := "jwks_test@leberkleber.io"
+	password := "s3cr3t"
+
+	createUser(t, email, password)
+	accessToken, _, authorized := loginUser(t, email, password)
+	if !authorized {
+		t.Fatal("could not login user")
+	}
+
+	resp, err := http.Get("http://simple-jwt-provider/.well-known/jwks.json")
+	if err != nil {
+		t.Fatalf("Failed to request jwks cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&jwks)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %s", err)
+	}
+
+	_, err = jwt.Parse(accessToken, func(token *jwt.Token) (interface{}, error) {
+		for _, key := range jwks.Keys {
+			if key.Kid != token.Header["kid"] {
+				continue
+			}
+
+			x, err := base64.RawURLEncoding.DecodeString(key.X)
+			if err != nil {
+				return nil, err
+			}
+
+			y, err := base64.RawURLEncoding.DecodeString(key.Y)
+			if err != nil {
+				return nil, err
+			}
+
+			return &ecdsa.PublicKey{
+				Curve: elliptic.P521(),
+				X:     new(big.Int).SetBytes(x),
+				Y:     new(big.Int).SetBytes(y),
+			}, nil
+		}
+
+		return nil, fmt.Errorf("no key with kid %q found", token.Header["kid"])
+	})
+	if err != nil {
+		t.Fatalf("Failed to verify access-token with jwks: %s", err)
+	}
+}
@@ -0,0 +1,80 @@
+// +build component
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestLoginLockout(t *testing.T) {
+	email := "lockout_test@leberkleber.io"
+	password := "s3cr3t"
+
+	createUser(t, email, password)
+
+	// dedicated client ips to not lock the ip of the component tests
+	for i := 0; i < 5; i++ {
+		if status := loginFromClient(t, "203.0.113.1", email, "wrong"); status != http.StatusUnauthorized {
+			t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusUnauthorized, status)
+		}
+	}
+
+	if status := loginFromClient(t, "203.0.113.2", email, password); status != http.StatusLocked {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusLocked, status)
+	}
+
+	unlockUser(t, email)
+
+	if status := loginFromClient(t, "203.0.113.2", email, password); status != http.StatusOK {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusOK, status)
+	}
+}
+
+func loginFromClient(t *testing.T, clientIP, email, password string) int {
+	t.Helper()
+	req, err := http.NewRequest(
+		http.MethodPost,
+		"http://simple-jwt-provider/v1/auth/login",
+		bytes.NewReader([]byte(fmt.Sprintf(`{"email": %q, "password": %q}`, email, password))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create http request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-For", clientIP)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to login cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+func unlockUser(t *testing.T, email string) {
+	t.Helper()
+	req, err := http.NewRequest(
+		http.MethodDelete,
+		fmt.Sprintf("http://simple-jwt-provider/v1/admin/users/%s/lockout", url.QueryEscape(email)),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("Failed to create http request")
+	}
+	req.SetBasicAuth("username", "password")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to unlock user cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusNoContent, resp.StatusCode)
+	}
+}
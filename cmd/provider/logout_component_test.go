@@ -0,0 +1,94 @@
+// +build component
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestLogout(t *testing.T) {
+	email := "logout_test@leberkleber.io"
+	password := "s3cr3t"
+
+	createUser(t, email, password)
+	_, refreshToken, authorized := loginUser(t, email, password)
+	if !authorized {
+		t.Fatal("could not login user")
+	}
+
+	logout(t, "/v1/auth/logout", refreshToken, http.StatusNoContent)
+	logout(t, "/v1/auth/logout", refreshToken, http.StatusUnauthorized)
+
+	if _, newRefreshToken := refresh(t, refreshToken); newRefreshToken != "" {
+		t.Error("logged out refresh-token should not be usable for refresh")
+	}
+}
+
+func TestLogoutEverywhere(t *testing.T) {
+	email := "logout_everywhere_test@leberkleber.io"
+	password := "s3cr3t"
+
+	createUser(t, email, password)
+	_, refreshToken1, authorized := loginUser(t, email, password)
+	if !authorized {
+		t.Fatal("could not login user")
+	}
+	_, refreshToken2, authorized := loginUser(t, email, password)
+	if !authorized {
+		t.Fatal("could not login user")
+	}
+
+	logout(t, "/v1/auth/logout-everywhere", refreshToken1, http.StatusNoContent)
+
+	if _, newRefreshToken := refresh(t, refreshToken2); newRefreshToken != "" {
+		t.Error("refresh-tokens of other sessions should not be usable for refresh")
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	email := "revoke_test@leberkleber.io"
+	password := "s3cr3t"
+
+	createUser(t, email, password)
+	_, refreshToken, authorized := loginUser(t, email, password)
+	if !authorized {
+		t.Fatal("could not login user")
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := http.PostForm("http://simple-jwt-provider/v1/auth/revoke", url.Values{"token": {refreshToken}})
+		if err != nil {
+			t.Fatalf("Failed to revoke token cause: %s", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusOK, resp.StatusCode)
+		}
+	}
+
+	if _, newRefreshToken := refresh(t, refreshToken); newRefreshToken != "" {
+		t.Error("revoked refresh-token should not be usable for refresh")
+	}
+}
+
+func logout(t *testing.T, path, refreshToken string, expectedStatusCode int) {
+	t.Helper()
+	resp, err := http.Post(
+		"http://simple-jwt-provider"+path,
+		"application/json",
+		strings.NewReader(fmt.Sprintf(`{"refresh_token": %q}`, refreshToken)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to logout cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatusCode {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", expectedStatusCode, resp.StatusCode)
+	}
+}
@@ -0,0 +1,101 @@
+// +build component
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestMagicLink(t *testing.T) {
+	email := "magic_link_test@leberkleber.io"
+	password := "s3cr3t"
+
+	createUser(t, email, password)
+	createMagicLink(t, email)
+	token := findMagicLinkToken(t, email)
+
+	redeemMagicLink(t, email, token, http.StatusOK)
+	// magic-links can only be redeemed once
+	redeemMagicLink(t, email, token, http.StatusUnauthorized)
+}
+
+func createMagicLink(t *testing.T, email string) {
+	t.Helper()
+	resp, err := http.Post(
+		"http://simple-jwt-provider/v1/auth/magic-link",
+		"application/json",
+		bytes.NewReader([]byte(fmt.Sprintf(`{"email": %q}`, email))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create magic-link cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusCreated, resp.StatusCode)
+	}
+}
+
+func findMagicLinkToken(t *testing.T, email string) string {
+	t.Helper()
+	// mails will be sent asynchronously
+	var respMail MailhogResponseItemRaw
+	respMailFound := false
+	for i := 0; i < 10 && !respMailFound; i++ {
+		if i > 0 {
+			time.Sleep(500 * time.Millisecond)
+		}
+		respMail, respMailFound = findMail(t, email)
+	}
+
+	if !respMailFound {
+		t.Fatal("could not find mail body")
+	}
+
+	res := regexp.MustCompile("([a-f0-9]{64})").FindString(respMail.Data)
+	if res == "" {
+		t.Fatalf("no magic-link token found. Mail content %q", respMail.Data)
+	}
+
+	return res
+}
+
+func redeemMagicLink(t *testing.T, email, token string, expectedStatusCode int) {
+	t.Helper()
+	resp, err := http.Post(
+		"http://simple-jwt-provider/v1/auth/magic-link/redeem",
+		"application/json",
+		bytes.NewReader([]byte(fmt.Sprintf(`{"email": %q, "magic_link_token": %q}`, email, token))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to redeem magic-link cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatusCode {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", expectedStatusCode, resp.StatusCode)
+	}
+
+	if expectedStatusCode != http.StatusOK {
+		return
+	}
+
+	var tokens struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&tokens)
+	if err != nil {
+		t.Fatalf("Failed to decode response body: %s", err)
+	}
+
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Errorf("Response does not contain access and refresh token: %#v", tokens)
+	}
+}
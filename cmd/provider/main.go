@@ -3,12 +3,19 @@ package main
 import (
 	"github.com/ardanlabs/conf"
 	"github.com/leberKleber/simple-jwt-provider/internal"
+	"github.com/leberKleber/simple-jwt-provider/internal/breach"
 	"github.com/leberKleber/simple-jwt-provider/internal/jwt"
 	"github.com/leberKleber/simple-jwt-provider/internal/mailer"
+	"github.com/leberKleber/simple-jwt-provider/internal/password"
 	"github.com/leberKleber/simple-jwt-provider/internal/storage"
 	"github.com/leberKleber/simple-jwt-provider/internal/web"
+	"github.com/leberKleber/simple-jwt-provider/internal/webauthn"
 	"github.com/sirupsen/logrus"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	// database migration
 	_ "github.com/golang-migrate/migrate/v4/source/file"
@@ -41,10 +48,11 @@ func main() {
 		logrus.WithError(err).Fatal("Could not create storage")
 	}
 
-	jwtGenerator, err := jwt.NewProvider(cfg.JWT.PrivateKey, cfg.JWT.Lifetime, cfg.JWT.Audience, cfg.JWT.Issuer, cfg.JWT.Subject)
+	jwtGenerator, err := jwt.NewProvider(cfg.JWT.PrivateKey, cfg.JWT.PreviousKeys, cfg.JWT.Algorithm, cfg.JWT.Lifetime, cfg.JWT.RefreshLifetime, cfg.JWT.Audience, cfg.JWT.Issuer, cfg.JWT.Subject)
 	if err != nil {
 		logrus.WithError(err).Fatal("Failed to create jwt generator")
 	}
+	go reloadJWTKeys(jwtGenerator, cfg.JWT.KeysReloadInterval)
 
 	m, err := mailer.New(cfg.Mail.TemplatesFolderPath,
 		cfg.Mail.SMTPUsername,
@@ -53,16 +61,142 @@ func main() {
 		cfg.Mail.SMTPPort,
 		cfg.Mail.TLS.InsecureSkipVerify,
 		cfg.Mail.TLS.ServerName,
+		mailer.Templates{
+			MFACode:           cfg.MFA.EMailOTPEnable,
+			MagicLink:         cfg.MagicLink.Enable,
+			EMailVerification: cfg.Registration.Enable,
+		},
 	)
 	if err != nil {
 		logrus.WithError(err).Fatal("Failed to create mailer")
 	}
 
-	provider := &internal.Provider{Storage: s, JWTProvider: jwtGenerator, Mailer: m}
-	server := web.NewServer(provider, cfg.AdminAPI.Enable, cfg.AdminAPI.Username, cfg.AdminAPI.Password)
+	passwordHasher, err := password.NewHasher(
+		cfg.Password.HashAlgorithm,
+		cfg.Password.BcryptCost,
+		password.Argon2idParams{
+			Memory:      cfg.Password.Argon2id.Memory,
+			Iterations:  cfg.Password.Argon2id.Iterations,
+			Parallelism: cfg.Password.Argon2id.Parallelism,
+		},
+		password.ScryptParams{
+			CostExponent: cfg.Password.Scrypt.CostExponent,
+			BlockSize:    cfg.Password.Scrypt.BlockSize,
+			Parallelism:  cfg.Password.Scrypt.Parallelism,
+		},
+	)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create password hasher")
+	}
+
+	provider := &internal.Provider{
+		Storage:        s,
+		JWTProvider:    jwtGenerator,
+		Mailer:         m,
+		PasswordHasher: passwordHasher,
+		PasswordPolicy: internal.PasswordPolicy{
+			MinLength:      cfg.PasswordPolicy.MinLength,
+			MaxLength:      cfg.PasswordPolicy.MaxLength,
+			RequireUpper:   cfg.PasswordPolicy.RequireUpper,
+			RequireLower:   cfg.PasswordPolicy.RequireLower,
+			RequireDigit:   cfg.PasswordPolicy.RequireDigit,
+			RequireSpecial: cfg.PasswordPolicy.RequireSpecial,
+			DisallowEMail:  cfg.PasswordPolicy.DisallowEMail,
+			DisallowCommon: cfg.PasswordPolicy.DisallowCommon,
+		},
+		BreachedPasswordMode: cfg.BreachedPasswords.Mode,
+		PasswordHistorySize:  cfg.PasswordPolicy.HistorySize,
+		RefreshTokenLifetime: cfg.JWT.RefreshLifetime,
+		ResetTokenLifetime:   cfg.PasswordReset.TokenLifetime,
+		MagicLinkEnabled:     cfg.MagicLink.Enable,
+		MagicLinkLifetime:    cfg.MagicLink.TokenLifetime,
+		MFATokenLifetime:     cfg.MFA.TokenLifetime,
+		MFAMaxAttempts:       cfg.MFA.MaxAttempts,
+		TOTPIssuer:           cfg.MFA.TOTPIssuer,
+		EMailOTPEnabled:      cfg.MFA.EMailOTPEnable,
+		EMailOTPLifetime:     cfg.MFA.EMailOTPLifetime,
+		EMailOTPMaxAttempts:  cfg.MFA.EMailOTPMaxAttempts,
+		WebAuthn: webauthn.RelyingParty{
+			ID:      cfg.WebAuthn.RPID,
+			Name:    cfg.WebAuthn.RPName,
+			Origins: cfg.WebAuthn.Origins,
+		},
+		WebAuthnTimeout: cfg.WebAuthn.Timeout,
+		Registration: internal.Registration{
+			Enabled:                   cfg.Registration.Enable,
+			AllowedDomains:            cfg.Registration.AllowedDomains,
+			VerificationTokenLifetime: cfg.Registration.VerificationTokenLifetime,
+		},
+		LoginLockout: internal.LoginLockout{
+			MaxFailures: cfg.Lockout.MaxFailures,
+			Duration:    cfg.Lockout.Duration,
+			MaxDuration: cfg.Lockout.MaxDuration,
+		},
+	}
+
+	if cfg.BreachedPasswords.Path != "" {
+		breachedPasswords, err := breach.Load(cfg.BreachedPasswords.Path)
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to load breached passwords")
+		}
+		provider.BreachedPasswords = breachedPasswords
+	}
+
+	go purgeExpired(provider, cfg.Cleanup.Interval, cfg.Cleanup.BatchSize)
+
+	server := web.NewServer(provider, cfg.AdminAPI.Enable, cfg.AdminAPI.Username, cfg.AdminAPI.Password, cfg.introspectionClients(), cfg.RateLimit.Requests, cfg.RateLimit.Interval, cfg.trustedProxies())
 
 	err = server.ListenAndServe(cfg.ServerAddress)
 	if err != nil && err != http.ErrServerClosed {
 		logrus.WithError(err).Fatal("Failed to run server")
 	}
 }
+
+// reloadJWTKeys reloads all jwt keys on SIGHUP and, when interval is greater than 0, periodically
+func reloadJWTKeys(p *jwt.Provider, interval time.Duration) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		tick = time.NewTicker(interval).C
+	}
+
+	for {
+		select {
+		case <-sighup:
+		case <-tick:
+		}
+
+		err := p.ReloadKeys()
+		if err != nil {
+			logrus.WithError(err).Error("Failed to reload jwt keys")
+			continue
+		}
+		logrus.Info("Reloaded jwt keys")
+	}
+}
+
+// purgeExpired periodically deletes expired tokens and unverified users from the database. An interval of 0 disables
+// the cleanup.
+func purgeExpired(p *internal.Provider, interval time.Duration, batchSize int) {
+	if interval <= 0 {
+		return
+	}
+
+	for range time.NewTicker(interval).C {
+		purged, err := p.PurgeExpiredTokens(batchSize)
+		if err != nil {
+			logrus.WithError(err).WithField("purged", purged).Error("Failed to purge expired tokens")
+		} else {
+			logrus.WithField("purged", purged).Info("Purged expired tokens")
+		}
+
+		purged, err = p.PurgeUnverifiedUsers(batchSize)
+		if err != nil {
+			logrus.WithError(err).WithField("purged", purged).Error("Failed to purge unverified users")
+			continue
+		}
+		logrus.WithField("purged", purged).Info("Purged unverified users")
+	}
+}
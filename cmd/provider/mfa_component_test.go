@@ -0,0 +1,176 @@
+// +build component
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/totp"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTOTP(t *testing.T) {
+	email := "totp_test@leberkleber.io"
+	password := "s3cr3t"
+
+	createUser(t, email, password)
+	accessToken, _, _ := loginUser(t, email, password)
+
+	secret := enrollTOTP(t, accessToken, password)
+	code, err := totp.Code(secret, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to generate totp code: %s", err)
+	}
+	recoveryCodes := confirmTOTP(t, accessToken, code)
+	if len(recoveryCodes) == 0 {
+		t.Fatal("Expected recovery codes")
+	}
+
+	mfaToken := loginUserWithMFA(t, email, password)
+	// the totp code of the current time step has already been used on confirmation
+	verifyMFA(t, email, mfaToken, fmt.Sprintf(`"code": %q`, code), http.StatusUnauthorized)
+	verifyMFA(t, email, mfaToken, fmt.Sprintf(`"recovery_code": %q`, recoveryCodes[0]), http.StatusOK)
+
+	// recovery codes and mfa-tokens can only be used once
+	verifyMFA(t, email, mfaToken, fmt.Sprintf(`"recovery_code": %q`, recoveryCodes[1]), http.StatusUnauthorized)
+	mfaToken = loginUserWithMFA(t, email, password)
+	verifyMFA(t, email, mfaToken, fmt.Sprintf(`"recovery_code": %q`, recoveryCodes[0]), http.StatusUnauthorized)
+
+	disableMFA(t, email)
+	_, _, ok := loginUser(t, email, password)
+	if !ok {
+		t.Fatal("Login should be possible without mfa after it has been disabled")
+	}
+}
+
+func enrollTOTP(t *testing.T, accessToken, password string) string {
+	t.Helper()
+	req, err := http.NewRequest(
+		http.MethodPost,
+		"http://simple-jwt-provider/v1/auth/mfa/totp",
+		bytes.NewReader([]byte(fmt.Sprintf(`{"password": %q}`, password))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create http request")
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to enroll totp cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusOK, resp.StatusCode)
+	}
+
+	responseBody := struct {
+		Secret string `json:"secret"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&responseBody)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %s", err)
+	}
+
+	return responseBody.Secret
+}
+
+func confirmTOTP(t *testing.T, accessToken, code string) []string {
+	t.Helper()
+	req, err := http.NewRequest(
+		http.MethodPost,
+		"http://simple-jwt-provider/v1/auth/mfa/totp/confirm",
+		bytes.NewReader([]byte(fmt.Sprintf(`{"code": %q}`, code))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create http request")
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to confirm totp cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusOK, resp.StatusCode)
+	}
+
+	responseBody := struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&responseBody)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %s", err)
+	}
+
+	return responseBody.RecoveryCodes
+}
+
+func loginUserWithMFA(t *testing.T, email, password string) string {
+	t.Helper()
+	resp, err := http.Post(
+		"http://simple-jwt-provider/v1/auth/login",
+		"application/json",
+		bytes.NewReader([]byte(fmt.Sprintf(`{"email": %q, "password": %q}`, email, password))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to login cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusForbidden, resp.StatusCode)
+	}
+
+	responseBody := struct {
+		MFAToken string `json:"mfa_token"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&responseBody)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %s", err)
+	}
+
+	return responseBody.MFAToken
+}
+
+func verifyMFA(t *testing.T, email, mfaToken, codeField string, expectedStatusCode int) {
+	t.Helper()
+	resp, err := http.Post(
+		"http://simple-jwt-provider/v1/auth/mfa/verify",
+		"application/json",
+		bytes.NewReader([]byte(fmt.Sprintf(`{"email": %q, "mfa_token": %q, %s}`, email, mfaToken, codeField))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to verify mfa cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatusCode {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", expectedStatusCode, resp.StatusCode)
+	}
+}
+
+func disableMFA(t *testing.T, email string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("http://simple-jwt-provider/v1/admin/users/%s/mfa", email), nil)
+	if err != nil {
+		t.Fatalf("Failed to create http request")
+	}
+	req.SetBasicAuth("username", "password")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to disable mfa cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusNoContent, resp.StatusCode)
+	}
+}
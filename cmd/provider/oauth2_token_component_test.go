@@ -0,0 +1,57 @@
+// +build component
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+type oauth2TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Error        string `json:"error"`
+}
+
+func TestOAuth2Token(t *testing.T) {
+	email := "oauth2_token_test@leberkleber.io"
+	password := "s3cr3t"
+
+	createUser(t, email, password)
+
+	resp := requestOAuth2Token(t, url.Values{"grant_type": {"password"}, "username": {email}, "password": {"wrong"}})
+	if resp.Error != "invalid_grant" {
+		t.Errorf("Unexpected error. Expected: %q, Given: %q", "invalid_grant", resp.Error)
+	}
+
+	resp = requestOAuth2Token(t, url.Values{"grant_type": {"password"}, "username": {email}, "password": {password}})
+	if resp.AccessToken == "" || resp.RefreshToken == "" || resp.TokenType != "Bearer" || resp.ExpiresIn <= 0 {
+		t.Fatalf("Unexpected password grant response: %#v", resp)
+	}
+
+	resp = requestOAuth2Token(t, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {resp.RefreshToken}})
+	if resp.AccessToken == "" || resp.RefreshToken == "" || resp.TokenType != "Bearer" {
+		t.Fatalf("Unexpected refresh_token grant response: %#v", resp)
+	}
+}
+
+func requestOAuth2Token(t *testing.T, form url.Values) oauth2TokenResponse {
+	t.Helper()
+	resp, err := http.PostForm("http://simple-jwt-provider/oauth2/token", form)
+	if err != nil {
+		t.Fatalf("Failed to request token cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	var tokenResponse oauth2TokenResponse
+	err = json.NewDecoder(resp.Body).Decode(&tokenResponse)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %s", err)
+	}
+
+	return tokenResponse
+}
@@ -0,0 +1,45 @@
+// +build component
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestOpenIDConfiguration(t *testing.T) {
+	resp, err := http.Get("http://simple-jwt-provider/.well-known/openid-configuration")
+	if err != nil {
+		t.Fatalf("Failed to request openid-configuration cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var cfg struct {
+		Issuer                           string   `json:"issuer"`
+		JWKSURI                          string   `json:"jwks_uri"`
+		GrantTypesSupported              []string `json:"grant_types_supported"`
+		IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&cfg)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %s", err)
+	}
+
+	if cfg.Issuer != "<issuer>" {
+		t.Errorf("Unexpected issuer. Expected: %q, Given: %q", "<issuer>", cfg.Issuer)
+	}
+
+	if !reflect.DeepEqual(cfg.IDTokenSigningAlgValuesSupported, []string{"ES512"}) {
+		t.Errorf("Unexpected signing algorithms. Expected: %q, Given: %q", []string{"ES512"}, cfg.IDTokenSigningAlgValuesSupported)
+	}
+
+	if !reflect.DeepEqual(cfg.GrantTypesSupported, []string{"password", "refresh_token"}) {
+		t.Errorf("Unexpected grant types. Expected: %q, Given: %q", []string{"password", "refresh_token"}, cfg.GrantTypesSupported)
+	}
+}
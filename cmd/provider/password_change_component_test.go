@@ -0,0 +1,49 @@
+// +build component
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestChangePassword(t *testing.T) {
+	email := "change_password_test@leberkleber.io"
+	password := "s3cr3t"
+	newPassword := "t3rc3s"
+
+	createUser(t, email, password)
+	accessToken, _, _ := loginUser(t, email, password)
+
+	changePassword(t, accessToken, password, newPassword, http.StatusNoContent)
+	// reusing one of the last passwords is not allowed
+	changePassword(t, accessToken, newPassword, password, http.StatusBadRequest)
+	changePassword(t, accessToken, "wrong", "n3wS3cr3t", http.StatusForbidden)
+
+	loginUser(t, email, newPassword)
+}
+
+func changePassword(t *testing.T, accessToken, password, newPassword string, expectedStatusCode int) {
+	t.Helper()
+	req, err := http.NewRequest(
+		http.MethodPost,
+		"http://simple-jwt-provider/v1/auth/password-change",
+		bytes.NewReader([]byte(fmt.Sprintf(`{"password": %q, "new_password": %q}`, password, newPassword))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create http request")
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to change password cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatusCode {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", expectedStatusCode, resp.StatusCode)
+	}
+}
@@ -0,0 +1,49 @@
+// +build component
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCreateUserViolatingPasswordPolicy(t *testing.T) {
+	email := "password_policy_test@leberkleber.io"
+
+	req, err := http.NewRequest(
+		http.MethodPost,
+		"http://simple-jwt-provider/v1/admin/users",
+		bytes.NewReader([]byte(fmt.Sprintf(`{"email": %q, "password": %q}`, email, email))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create http request")
+	}
+	req.SetBasicAuth("username", "password")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to create user cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	responseBody := struct {
+		Violations []struct {
+			Rule string `json:"rule"`
+		} `json:"violations"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&responseBody)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %s", err)
+	}
+
+	if len(responseBody.Violations) != 1 || responseBody.Violations[0].Rule != "email" {
+		t.Errorf("Violations are not as expected. Expected: [email], Given: %v", responseBody.Violations)
+	}
+}
@@ -31,6 +31,31 @@ func TestRefreshPassword(t *testing.T) {
 	validateJWT(t, newRefreshToken)
 }
 
+func TestRefreshWithReusedToken(t *testing.T) {
+	email := "refresh_reuse_test@leberkleber.io"
+	password := "s3cr3t"
+
+	createUser(t, email, password)
+	_, refreshToken, authorized := loginUser(t, email, password)
+	if !authorized {
+		t.Fatal("failed to auth user")
+	}
+
+	_, newRefreshToken := refresh(t, refreshToken)
+	if newRefreshToken == "" {
+		t.Fatal("failed to refresh")
+	}
+
+	// replay of the already consumed refresh-token revokes the whole token family
+	if accessToken, _ := refresh(t, refreshToken); accessToken != "" {
+		t.Fatal("reused refresh-token should not be accepted")
+	}
+
+	if accessToken, _ := refresh(t, newRefreshToken); accessToken != "" {
+		t.Fatal("refresh-token of a revoked family should not be accepted")
+	}
+}
+
 func refresh(t *testing.T, refreshToken string) (string, string) {
 	t.Helper()
 	resp, err := http.Post(
@@ -0,0 +1,103 @@
+// +build component
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestRegistration(t *testing.T) {
+	email := "registration_test@leberkleber.io"
+	password := "s3cr3t"
+
+	registerUser(t, "registration_test@not-allowed.io", password, http.StatusForbidden)
+	registerUser(t, email, password, http.StatusCreated)
+	loginUnverifiedUser(t, email, password)
+
+	token := findEMailVerificationToken(t, email)
+	verifyEMail(t, email, token, http.StatusNoContent)
+	// verification-tokens can only be used once
+	verifyEMail(t, email, token, http.StatusBadRequest)
+
+	loginUser(t, email, password)
+}
+
+func registerUser(t *testing.T, email, password string, expectedStatusCode int) {
+	t.Helper()
+	resp, err := http.Post(
+		"http://simple-jwt-provider/v1/auth/register",
+		"application/json",
+		bytes.NewReader([]byte(fmt.Sprintf(`{"email": %q, "password": %q}`, email, password))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to register cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatusCode {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", expectedStatusCode, resp.StatusCode)
+	}
+}
+
+func loginUnverifiedUser(t *testing.T, email, password string) {
+	t.Helper()
+	resp, err := http.Post(
+		"http://simple-jwt-provider/v1/auth/login",
+		"application/json",
+		bytes.NewReader([]byte(fmt.Sprintf(`{"email": %q, "password": %q}`, email, password))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to login cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusForbidden, resp.StatusCode)
+	}
+}
+
+func findEMailVerificationToken(t *testing.T, email string) string {
+	t.Helper()
+	// mails will be sent asynchronously
+	var respMail MailhogResponseItemRaw
+	respMailFound := false
+	for i := 0; i < 10 && !respMailFound; i++ {
+		if i > 0 {
+			time.Sleep(500 * time.Millisecond)
+		}
+		respMail, respMailFound = findMail(t, email)
+	}
+
+	if !respMailFound {
+		t.Fatal("could not find mail body")
+	}
+
+	res := regexp.MustCompile("([a-f0-9]{64})").FindString(respMail.Data)
+	if res == "" {
+		t.Fatalf("no email-verification token found. Mail content %q", respMail.Data)
+	}
+
+	return res
+}
+
+func verifyEMail(t *testing.T, email, token string, expectedStatusCode int) {
+	t.Helper()
+	resp, err := http.Post(
+		"http://simple-jwt-provider/v1/auth/verify-email",
+		"application/json",
+		bytes.NewReader([]byte(fmt.Sprintf(`{"email": %q, "verification_token": %q}`, email, token))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to verify email cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatusCode {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", expectedStatusCode, resp.StatusCode)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestResetPassword(t *testing.T) {
@@ -26,33 +27,14 @@ func TestResetPassword(t *testing.T) {
 }
 
 func findPasswordResetTokenFromMailAndVerifyContent(t *testing.T, email string) string {
-	resp, err := http.Get("http://mail-server:8025/api/v2/messages")
-	if err != nil {
-		t.Fatalf("Failed to login cause: %s", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusOK, resp.StatusCode)
-	}
-
-	var mailhogRes MailhogResponse
-
-	defer resp.Body.Close()
-	err = json.NewDecoder(resp.Body).Decode(&mailhogRes)
-	if err != nil {
-		t.Fatalf("failed to encode smtp-server api-response: %s", err)
-	}
-
+	// mails will be sent asynchronously
 	var respMail MailhogResponseItemRaw
 	respMailFound := false
-	for _, r := range mailhogRes.Items {
-		for i := range r.Raw.To {
-			if r.Raw.To[i] == email {
-				respMail = r.Raw
-				respMailFound = true
-				break
-			}
+	for i := 0; i < 10 && !respMailFound; i++ {
+		if i > 0 {
+			time.Sleep(500 * time.Millisecond)
 		}
+		respMail, respMailFound = findMail(t, email)
 	}
 
 	if !respMailFound {
@@ -77,6 +59,35 @@ func findPasswordResetTokenFromMailAndVerifyContent(t *testing.T, email string)
 	return string(res)
 }
 
+func findMail(t *testing.T, email string) (MailhogResponseItemRaw, bool) {
+	resp, err := http.Get("http://mail-server:8025/api/v2/messages")
+	if err != nil {
+		t.Fatalf("Failed to login cause: %s", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var mailhogRes MailhogResponse
+
+	defer resp.Body.Close()
+	err = json.NewDecoder(resp.Body).Decode(&mailhogRes)
+	if err != nil {
+		t.Fatalf("failed to encode smtp-server api-response: %s", err)
+	}
+
+	for _, r := range mailhogRes.Items {
+		for i := range r.Raw.To {
+			if r.Raw.To[i] == email {
+				return r.Raw, true
+			}
+		}
+	}
+
+	return MailhogResponseItemRaw{}, false
+}
+
 func createPasswordResetRequest(t *testing.T, email string) {
 	resp, err := http.Post(
 		"http://simple-jwt-provider/v1/auth/password-reset-request",
@@ -0,0 +1,98 @@
+// +build component
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+type session struct {
+	ID        string `json:"id"`
+	UserAgent string `json:"user_agent"`
+	ClientIP  string `json:"client_ip"`
+}
+
+func TestSessions(t *testing.T) {
+	email := "sessions_test@leberkleber.io"
+	password := "s3cr3t"
+
+	createUser(t, email, password)
+	accessToken, refreshToken, authorized := loginUser(t, email, password)
+	if !authorized {
+		t.Fatal("could not login user")
+	}
+
+	ownSessions := listSessions(t, "/v1/auth/sessions", func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	})
+	if len(ownSessions) != 1 || ownSessions[0].ID == "" {
+		t.Fatalf("unexpected own sessions: %#v", ownSessions)
+	}
+
+	// refreshing keeps the session
+	_, refreshToken = refresh(t, refreshToken)
+
+	sessions := listSessions(t, fmt.Sprintf("/v1/admin/users/%s/sessions", url.PathEscape(email)), func(req *http.Request) {
+		req.SetBasicAuth("username", "password")
+	})
+	if len(sessions) != 1 || sessions[0].ID != ownSessions[0].ID {
+		t.Fatalf("unexpected sessions: %#v", sessions)
+	}
+
+	req, err := http.NewRequest(
+		http.MethodDelete,
+		fmt.Sprintf("http://simple-jwt-provider/v1/admin/users/%s/sessions/%s", url.PathEscape(email), url.PathEscape(sessions[0].ID)),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("Failed to create http request")
+	}
+	req.SetBasicAuth("username", "password")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to delete session cause: %s", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusNoContent, resp.StatusCode)
+	}
+
+	if _, newRefreshToken := refresh(t, refreshToken); newRefreshToken != "" {
+		t.Error("refresh-token of a deleted session should not be usable for refresh")
+	}
+}
+
+func listSessions(t *testing.T, path string, authorize func(req *http.Request)) []session {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, "http://simple-jwt-provider"+path, nil)
+	if err != nil {
+		t.Fatalf("Failed to create http request")
+	}
+	authorize(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to list sessions cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var responseBody struct {
+		Sessions []session `json:"sessions"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&responseBody)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %s", err)
+	}
+
+	return responseBody.Sessions
+}
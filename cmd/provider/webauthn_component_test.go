@@ -0,0 +1,169 @@
+// +build component
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/webauthn"
+	"github.com/leberKleber/simple-jwt-provider/internal/webauthn/webauthntest"
+	"net/http"
+	"testing"
+)
+
+func TestWebAuthn(t *testing.T) {
+	email := "webauthn_test@leberkleber.io"
+	password := "s3cr3t"
+
+	createUser(t, email, password)
+	accessToken, _, _ := loginUser(t, email, password)
+
+	authenticator, err := webauthntest.NewAuthenticator("simple-jwt-provider", "http://simple-jwt-provider")
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %s", err)
+	}
+
+	challenge := webAuthnRegistrationChallenge(t, accessToken)
+	clientDataJSON, attestationObject, err := authenticator.Create(challenge)
+	if err != nil {
+		t.Fatalf("Failed to create credential: %s", err)
+	}
+	registerWebAuthnCredential(t, accessToken, clientDataJSON, attestationObject, http.StatusCreated)
+	// each challenge can only be answered once
+	registerWebAuthnCredential(t, accessToken, clientDataJSON, attestationObject, http.StatusBadRequest)
+
+	challenge = webAuthnLoginChallenge(t, email, authenticator.CredentialID())
+	clientDataJSON, authenticatorData, signature, err := authenticator.Get(challenge)
+	if err != nil {
+		t.Fatalf("Failed to get assertion: %s", err)
+	}
+	accessToken, refreshToken := loginWithWebAuthn(t, email, authenticator.CredentialID(), clientDataJSON, authenticatorData, signature, http.StatusOK)
+	if accessToken == "" || refreshToken == "" {
+		t.Fatalf("Access and refresh token must be issued. Given: %q, %q", accessToken, refreshToken)
+	}
+	loginWithWebAuthn(t, email, authenticator.CredentialID(), clientDataJSON, authenticatorData, signature, http.StatusUnauthorized)
+}
+
+func webAuthnRegistrationChallenge(t *testing.T, accessToken string) string {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, "http://simple-jwt-provider/v1/auth/webauthn/registration/options", nil)
+	if err != nil {
+		t.Fatalf("Failed to create http request")
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to begin webauthn registration cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var options webauthn.CreationOptions
+	err = json.NewDecoder(resp.Body).Decode(&options)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %s", err)
+	}
+
+	if options.RP.ID != "simple-jwt-provider" || options.User.Name != "webauthn_test@leberkleber.io" {
+		t.Fatalf("Options are not as expected: %#v", options)
+	}
+
+	return options.Challenge
+}
+
+func registerWebAuthnCredential(t *testing.T, accessToken string, clientDataJSON, attestationObject []byte, expectedStatusCode int) {
+	t.Helper()
+	req, err := http.NewRequest(
+		http.MethodPost,
+		"http://simple-jwt-provider/v1/auth/webauthn/registration",
+		bytes.NewReader([]byte(fmt.Sprintf(
+			`{"credential": {"response": {"clientDataJSON": %q, "attestationObject": %q}}}`,
+			webauthn.Encoding.EncodeToString(clientDataJSON),
+			webauthn.Encoding.EncodeToString(attestationObject),
+		))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create http request")
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to finish webauthn registration cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatusCode {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", expectedStatusCode, resp.StatusCode)
+	}
+}
+
+func webAuthnLoginChallenge(t *testing.T, email string, expectedCredentialID []byte) string {
+	t.Helper()
+	resp, err := http.Post(
+		"http://simple-jwt-provider/v1/auth/webauthn/login/options",
+		"application/json",
+		bytes.NewReader([]byte(fmt.Sprintf(`{"email": %q}`, email))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to begin webauthn login cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var options webauthn.RequestOptions
+	err = json.NewDecoder(resp.Body).Decode(&options)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %s", err)
+	}
+
+	expectedID := webauthn.Encoding.EncodeToString(expectedCredentialID)
+	if len(options.AllowCredentials) != 1 || options.AllowCredentials[0].ID != expectedID {
+		t.Fatalf("Allowed credentials are not as expected. Expected: %q, Given: %#v", expectedID, options.AllowCredentials)
+	}
+
+	return options.Challenge
+}
+
+func loginWithWebAuthn(t *testing.T, email string, credentialID, clientDataJSON, authenticatorData, signature []byte, expectedStatusCode int) (string, string) {
+	t.Helper()
+	resp, err := http.Post(
+		"http://simple-jwt-provider/v1/auth/webauthn/login",
+		"application/json",
+		bytes.NewReader([]byte(fmt.Sprintf(
+			`{"email": %q, "credential": {"rawId": %q, "response": {"clientDataJSON": %q, "authenticatorData": %q, "signature": %q}}}`,
+			email,
+			webauthn.Encoding.EncodeToString(credentialID),
+			webauthn.Encoding.EncodeToString(clientDataJSON),
+			webauthn.Encoding.EncodeToString(authenticatorData),
+			webauthn.Encoding.EncodeToString(signature),
+		))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to finish webauthn login cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatusCode {
+		t.Fatalf("Invalid response status code. Expected: %d, Given: %d", expectedStatusCode, resp.StatusCode)
+	}
+
+	responseBody := struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&responseBody)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %s", err)
+	}
+
+	return responseBody.AccessToken, responseBody.RefreshToken
+}
@@ -4,13 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"github.com/leberKleber/simple-jwt-provider/internal/storage"
-	"golang.org/x/crypto/bcrypt"
 )
 
 const blankedPassword = "**********"
 
-var bcryptCost = 12
-
 // ErrUserAlreadyExists returned when given user already exists
 var ErrUserAlreadyExists = errors.New("user already exists")
 
@@ -19,20 +16,34 @@ type User struct {
 	EMail    string
 	Password string
 	Claims   map[string]interface{}
+	// PasswordBreached is true when the password has been found in a data breach
+	PasswordBreached bool
 }
 
 // CreateUser creates new user with given email, password and claims.
 // return ErrUserAlreadyExists when user already exists
+// return PasswordPolicyError when the password violates the password policy or has been breached
 func (p Provider) CreateUser(user User) error {
-	bcryptedPassword, err := bcryptPassword(user.Password)
+	err := p.PasswordPolicy.Validate(user.EMail, user.Password)
 	if err != nil {
-		return fmt.Errorf("failed to bcrypt password: %w", err)
+		return err
+	}
+
+	breached, err := p.checkBreachedPassword(user.EMail, user.Password)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := p.PasswordHasher.Hash(user.Password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
 	}
 
 	err = p.Storage.CreateUser(storage.User{
-		EMail:    user.EMail,
-		Password: bcryptedPassword,
-		Claims:   user.Claims,
+		EMail:            user.EMail,
+		Password:         hashedPassword,
+		Claims:           user.Claims,
+		PasswordBreached: breached,
 	})
 	if err != nil {
 		if errors.Is(err, storage.ErrUserAlreadyExists) {
@@ -57,14 +68,16 @@ func (p Provider) GetUser(email string) (User, error) {
 	}
 
 	return User{
-		EMail:    user.EMail,
-		Password: blankedPassword,
-		Claims:   user.Claims,
+		EMail:            user.EMail,
+		Password:         blankedPassword,
+		Claims:           user.Claims,
+		PasswordBreached: user.PasswordBreached,
 	}, nil
 }
 
 // UpdateUser updates user with given email.
 // return ErrUserNotFound when user does not exist
+// return PasswordPolicyError when the new password violates the password policy or has been breached
 func (p Provider) UpdateUser(email string, user User) (User, error) {
 	dbUser, err := p.Storage.User(email)
 	if err != nil {
@@ -76,11 +89,27 @@ func (p Provider) UpdateUser(email string, user User) (User, error) {
 	}
 
 	if user.Password != "" {
-		bcryptedPassword, err := bcryptPassword(user.Password)
+		err = p.PasswordPolicy.Validate(email, user.Password)
+		if err != nil {
+			return User{}, err
+		}
+
+		breached, err := p.checkBreachedPassword(email, user.Password)
 		if err != nil {
-			return User{}, fmt.Errorf("failed to bcrypt new password: %w", err)
+			return User{}, err
 		}
-		dbUser.Password = bcryptedPassword
+
+		hashedPassword, err := p.PasswordHasher.Hash(user.Password)
+		if err != nil {
+			return User{}, fmt.Errorf("failed to hash new password: %w", err)
+		}
+
+		err = p.recordPasswordHistory(dbUser)
+		if err != nil {
+			return User{}, err
+		}
+		dbUser.Password = hashedPassword
+		dbUser.PasswordBreached = breached
 	}
 
 	if user.Claims != nil {
@@ -97,9 +126,10 @@ func (p Provider) UpdateUser(email string, user User) (User, error) {
 	}
 
 	return User{
-		EMail:    dbUser.EMail,
-		Password: blankedPassword,
-		Claims:   dbUser.Claims,
+		EMail:            dbUser.EMail,
+		Password:         blankedPassword,
+		Claims:           dbUser.Claims,
+		PasswordBreached: dbUser.PasswordBreached,
 	}, nil
 }
 
@@ -118,7 +148,3 @@ func (p Provider) DeleteUser(email string) error {
 
 	return nil
 }
-
-var bcryptPassword = func(password string) ([]byte, error) {
-	return bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
-}
@@ -11,13 +11,12 @@ import (
 
 func TestProvider_CreateUser(t *testing.T) {
 	tests := []struct {
-		name                   string
-		givenUser              User
-		bcryptPasswordError    error
-		bcryptPasswordPassword []byte
-		dbExpectedUser         storage.User // password not encrypted
-		dbReturnError          error
-		expectedError          error
+		name              string
+		givenUser         User
+		hashPasswordError error
+		dbExpectedUser    storage.User // password not encrypted
+		dbReturnError     error
+		expectedError     error
 	}{
 		{
 			name: "Happycase",
@@ -56,28 +55,30 @@ func TestProvider_CreateUser(t *testing.T) {
 			},
 			expectedError: errors.New(`failed to query user with email "test@test.test": my custom error. ALARM`),
 		}, {
-			name: "failed to bcrypt password",
+			name: "failed to hash password",
 			givenUser: User{
 				EMail:    "test@test.test",
 				Password: "s3cr3t",
 			},
-			bcryptPasswordError: errors.New("failed to bcrypt password"),
-			dbReturnError:       errors.New("my custom error. ALARM"),
-			expectedError:       errors.New(`failed to bcrypt password: failed to bcrypt password`),
+			hashPasswordError: errors.New("failed to hash password"),
+			dbReturnError:     errors.New("my custom error. ALARM"),
+			expectedError:     errors.New(`failed to hash password: failed to hash password`),
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.bcryptPasswordError != nil {
-				oldBcryptPassword := bcryptPassword
-				defer func() { bcryptPassword = oldBcryptPassword }()
-				bcryptPassword = func(password string) ([]byte, error) {
-					return tt.bcryptPasswordPassword, tt.bcryptPasswordError
+			var passwordHasher PasswordHasher = testPasswordHasher
+			if tt.hashPasswordError != nil {
+				passwordHasher = &PasswordHasherMock{
+					HashFunc: func(password string) ([]byte, error) {
+						return nil, tt.hashPasswordError
+					},
 				}
 			}
 			var givenDbUser storage.User
 			toTest := Provider{
+				PasswordHasher: passwordHasher,
 				Storage: &StorageMock{
 					CreateUserFunc: func(user storage.User) error {
 						givenDbUser = user
@@ -185,6 +186,7 @@ func TestProvider_UpdateUser_Happycase(t *testing.T) {
 	}
 	var dbUpdateUser storage.User
 	toTest := Provider{
+		PasswordHasher: testPasswordHasher,
 		Storage: &StorageMock{
 			UserFunc: func(email string) (storage.User, error) {
 				return dbUserToUpdate, nil
@@ -308,6 +310,7 @@ func TestProvider_UpdateUser_UnableToUpdateUser(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			userEMail := "test.test@test.test"
 			toTest := Provider{
+				PasswordHasher: testPasswordHasher,
 				Storage: &StorageMock{
 					UserFunc: func(_ string) (storage.User, error) {
 						return storage.User{
@@ -337,15 +340,14 @@ func TestProvider_UpdateUser_UnableToUpdateUser(t *testing.T) {
 	}
 }
 
-func TestProvider_UpdateUser_UnableToBcryptPassword(t *testing.T) {
-	oldBcryptPassword := bcryptPassword
-	defer func() { bcryptPassword = oldBcryptPassword }()
-	bcryptPassword = func(password string) ([]byte, error) {
-		return nil, errors.New("failed to bcryptPassword")
-	}
-
+func TestProvider_UpdateUser_UnableToHashPassword(t *testing.T) {
 	userEMail := "test.test@test.test"
 	toTest := Provider{
+		PasswordHasher: &PasswordHasherMock{
+			HashFunc: func(password string) ([]byte, error) {
+				return nil, errors.New("failed to hash password")
+			},
+		},
 		Storage: &StorageMock{
 			UserFunc: func(_ string) (storage.User, error) {
 				return storage.User{
@@ -369,7 +371,7 @@ func TestProvider_UpdateUser_UnableToBcryptPassword(t *testing.T) {
 		},
 	})
 
-	expectedErr := errors.New("failed to bcrypt new password: failed to bcryptPassword")
+	expectedErr := errors.New("failed to hash new password: failed to hash password")
 	if fmt.Sprint(err) != fmt.Sprint(expectedErr) {
 		t.Errorf("unexpected error. Expected:\n%q\nGiven:\n%q", expectedErr, err)
 	}
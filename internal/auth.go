@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"github.com/leberKleber/simple-jwt-provider/internal/storage"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"time"
 )
 
 // ErrIncorrectPassword returned when user authentication failed cause incorrect password
@@ -23,24 +25,61 @@ var ErrInvalidToken = errors.New("given token is invalid")
 // ErrTokenNotParsable returned when the give token is not parsable
 var ErrTokenNotParsable = errors.New("given token is not parsable")
 
-// Login checks email / password combination and return a new access and refresh token if correct.
+// Login checks email / password combination and return a new access and refresh token if correct. The given client
+// will be recorded as the client of the new session.
 // return ErrIncorrectPassword when password is incorrect
 // return ErrUserNotFound when user not found
-func (p Provider) Login(email, password string) (accessToken, refreshToken string, err error) {
+// return LockoutError when logins of the email or the client are locked cause of too many failed logins
+// return ErrEMailNotVerified when the user has registered itself and not verified the email yet
+// return MFARequiredError instead of tokens when the user has to pass the multi-factor authentication via VerifyMFA
+func (p Provider) Login(email, password string, client ClientInfo) (accessToken, refreshToken string, err error) {
+	err = p.checkLoginLockout(email, client)
+	if err != nil {
+		return "", "", err
+	}
+
 	u, err := p.Storage.User(email)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
-			return "", "", ErrUserNotFound
+			// comparing with a dummy hash takes as long as comparing with an up to date hash, so the response time does
+			// not reveal whether the user exists
+			p.PasswordHasher.CompareDummy(password)
+			return "", "", p.loginFailed(email, client, ErrUserNotFound)
 		}
 		return "", "", fmt.Errorf("failed to find user with email %q: %w", email, err)
 	}
 
-	err = bcrypt.CompareHashAndPassword(u.Password, []byte(password))
+	err = p.PasswordHasher.Compare(u.Password, password)
 	if err != nil {
-		return "", "", ErrIncorrectPassword
+		return "", "", p.loginFailed(email, client, ErrIncorrectPassword)
 	}
 
-	accessToken, err = p.JWTProvider.GenerateAccessToken(email, u.Claims)
+	if p.PasswordHasher.NeedsRehash(u.Password) {
+		p.rehashPassword(u, password)
+	}
+
+	if u.EMailUnverified {
+		return "", "", ErrEMailNotVerified
+	}
+
+	if len(mfaMethods(u)) > 0 {
+		// login failures will be reset when the mfa has been passed, otherwise the password would reset the failures
+		// of wrong mfa codes
+		return "", "", p.mfaRequired(u)
+	}
+
+	err = p.resetLoginFailures(email)
+	if err != nil {
+		return "", "", err
+	}
+
+	return p.issueTokens(email, u.Claims, client)
+}
+
+// issueTokens generates a new access and refresh token for the given user and persists the refresh-token as start of a
+// new session of the given client
+func (p Provider) issueTokens(email string, claims map[string]interface{}, client ClientInfo) (accessToken, refreshToken string, err error) {
+	accessToken, err = p.JWTProvider.GenerateAccessToken(email, claims)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate access-token: %w", err)
 	}
@@ -50,10 +89,16 @@ func (p Provider) Login(email, password string) (accessToken, refreshToken strin
 		return "", "", fmt.Errorf("failed to generate refresh-token: %w", err)
 	}
 
+	now := timeNow()
 	err = p.Storage.CreateToken(&storage.Token{
-		EMail: email,
-		Token: jwtID,
-		Type:  storage.TokenTypeRefresh,
+		EMail:      email,
+		Token:      jwtID,
+		Type:       storage.TokenTypeRefresh,
+		Family:     jwtID,
+		ExpiresAt:  now.Add(p.RefreshTokenLifetime),
+		LastUsedAt: now,
+		UserAgent:  client.UserAgent,
+		ClientIP:   client.IP,
 	})
 	if err != nil {
 		return "", "", fmt.Errorf("failed to persist refresh-token: %w", err)
@@ -62,52 +107,83 @@ func (p Provider) Login(email, password string) (accessToken, refreshToken strin
 	return accessToken, refreshToken, nil
 }
 
-// Refresh checks user and token validity and return a new access and refresh token if everything is valid
-// return ErrTokenNotParsable when the token is not parsable
-// return ErrInvalidToken when the token is not valid
-// return ErrUserNotFound when the referred user could not be found
-func (p Provider) Refresh(refreshToken string) (newAccessToken, newRefreshToken string, err error) {
-	isValid, claims, err := p.JWTProvider.IsTokenValid(refreshToken)
+// rehashPassword hashes the given password of the given user with the current hash algorithm and parameters. Only the
+// password will be updated and only when it has not been changed concurrently. Failures will only be logged, because
+// they must not prevent the login.
+func (p Provider) rehashPassword(u storage.User, password string) {
+	hashedPassword, err := p.PasswordHasher.Hash(password)
 	if err != nil {
-		return "", "", fmt.Errorf("%w: %s", ErrTokenNotParsable, err)
+		logrus.WithError(err).WithField("email", u.EMail).Error("Failed to rehash password")
+		return
 	}
 
-	if !isValid {
-		return "", "", ErrInvalidToken
+	err = p.Storage.UpdateUserPassword(u.EMail, u.Password, hashedPassword)
+	if err != nil {
+		logrus.WithError(err).WithField("email", u.EMail).Error("Failed to persist rehashed password")
 	}
+}
 
-	email, ok := claims["email"].(string)
-	if !ok {
-		return "", "", errors.New("email claim is not parsable as string")
+// resetLoginFailures forgets the failed logins of the given email after a successful login
+func (p Provider) resetLoginFailures(email string) error {
+	if p.LoginLockout.MaxFailures <= 0 {
+		return nil
 	}
 
-	tokenID, ok := claims["jit"].(string)
-	if !ok {
-		return "", "", errors.New("jit claim is not parsable as string")
+	err := p.Storage.ResetLoginFailures(emailLockoutKey(email))
+	if err != nil {
+		return fmt.Errorf("failed to reset login failures: %w", err)
 	}
 
-	//TODO do Storage.TokensByEMailAndToken and Storage.DeleteToken in transaction
-	tokens, err := p.Storage.TokensByEMailAndToken(email, tokenID)
+	return nil
+}
+
+// loginFailed registers the failed login and returns the given cause
+func (p Provider) loginFailed(email string, client ClientInfo, cause error) error {
+	err := p.registerLoginFailure(email, client)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to find refresh-tokens: %w", err)
+		return err
 	}
 
-	var t *storage.Token
-	for _, token := range tokens {
-		if token.Type == storage.TokenTypeRefresh {
-			// TODO check lifetime
-			t = &token
-			break
-		}
+	return cause
+}
+
+// verifyPassword checks the given password of the given user like Login, so incorrect passwords count as failed logins
+// of the user and the given client.
+// return ErrIncorrectPassword when the password is incorrect
+// return LockoutError when logins of the email or the client are locked cause of too many failed logins
+func (p Provider) verifyPassword(u storage.User, password string, client ClientInfo) error {
+	err := p.checkLoginLockout(u.EMail, client)
+	if err != nil {
+		return err
 	}
 
-	if t == nil {
-		return "", "", ErrNoValidTokenFound
+	err = p.PasswordHasher.Compare(u.Password, password)
+	if err != nil {
+		return p.loginFailed(u.EMail, client, ErrIncorrectPassword)
 	}
 
-	err = p.Storage.DeleteToken(t.ID)
+	return nil
+}
+
+// AccessTokenLifetime returns the lifetime of access-tokens issued by Login and Refresh
+func (p Provider) AccessTokenLifetime() time.Duration {
+	return p.JWTProvider.AccessTokenLifetime()
+}
+
+// Refresh checks user and token validity and return a new access and refresh token if everything is valid. The given
+// client will be recorded as the last client of the session.
+// return ErrTokenNotParsable when the token is not parsable
+// return ErrInvalidToken when the token is not valid
+// return ErrUserNotFound when the referred user could not be found
+func (p Provider) Refresh(refreshToken string, client ClientInfo) (newAccessToken, newRefreshToken string, err error) {
+	email, t, err := p.persistedRefreshToken(refreshToken)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to delete refresh-token: %w", err)
+		return "", "", err
+	}
+
+	err = p.consumeToken(t.ID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to consume refresh-token: %w", err)
 	}
 
 	u, err := p.Storage.User(email)
@@ -128,10 +204,18 @@ func (p Provider) Refresh(refreshToken string) (newAccessToken, newRefreshToken
 		return "", "", fmt.Errorf("failed to generate refresh-token: %w", err)
 	}
 
+	now := timeNow()
 	err = p.Storage.CreateToken(&storage.Token{
-		EMail: email,
-		Token: jwtID,
-		Type:  storage.TokenTypeRefresh,
+		// the creation time of the first token is the creation time of the session
+		Model:      gorm.Model{CreatedAt: t.CreatedAt},
+		EMail:      email,
+		Token:      jwtID,
+		Type:       storage.TokenTypeRefresh,
+		Family:     tokenFamily(t),
+		ExpiresAt:  now.Add(p.RefreshTokenLifetime),
+		LastUsedAt: now,
+		UserAgent:  client.UserAgent,
+		ClientIP:   client.IP,
 	})
 	if err != nil {
 		return "", "", fmt.Errorf("failed to persist refresh-token: %w", err)
@@ -140,7 +224,90 @@ func (p Provider) Refresh(refreshToken string) (newAccessToken, newRefreshToken
 	return newAccessToken, newRefreshToken, nil
 }
 
-// CreatePasswordResetRequest send a password-reset-request email to the give address.
+// persistedRefreshToken validates the given refresh-token and returns its email and persisted token.
+// return ErrTokenNotParsable when the token is not parsable
+// return ErrInvalidToken when the token is not valid
+// return ErrNoValidTokenFound when the token is not persisted (anymore). When the token has already been consumed its
+// whole family will be revoked.
+func (p Provider) persistedRefreshToken(refreshToken string) (string, storage.Token, error) {
+	isValid, claims, err := p.JWTProvider.IsTokenValid(refreshToken)
+	if err != nil {
+		return "", storage.Token{}, fmt.Errorf("%w: %s", ErrTokenNotParsable, err)
+	}
+
+	if !isValid {
+		return "", storage.Token{}, ErrInvalidToken
+	}
+
+	email, ok := claims["email"].(string)
+	if !ok {
+		return "", storage.Token{}, errors.New("email claim is not parsable as string")
+	}
+
+	tokenID, ok := claims["jit"].(string)
+	if !ok {
+		return "", storage.Token{}, errors.New("jit claim is not parsable as string")
+	}
+
+	tokens, err := p.Storage.TokensByEMailAndToken(email, tokenID)
+	if err != nil {
+		return "", storage.Token{}, fmt.Errorf("failed to find refresh-tokens: %w", err)
+	}
+
+	for _, token := range tokens {
+		if token.Type == storage.TokenTypeRefresh && !p.isExpired(token) {
+			return email, token, nil
+		}
+	}
+
+	err = p.revokeReusedTokenFamily(email, tokenID)
+	if err != nil {
+		return "", storage.Token{}, err
+	}
+
+	return "", storage.Token{}, ErrNoValidTokenFound
+}
+
+// revokeReusedTokenFamily revokes the whole family of the given refresh-token when it has already been consumed. A
+// consumed refresh-token which is presented again has been leaked, so its successors can not be trusted anymore.
+func (p Provider) revokeReusedTokenFamily(email, tokenID string) error {
+	tokens, err := p.Storage.ConsumedTokensByEMailAndToken(email, tokenID)
+	if err != nil {
+		return fmt.Errorf("failed to find consumed refresh-tokens: %w", err)
+	}
+
+	for _, token := range tokens {
+		if token.Type != storage.TokenTypeRefresh {
+			continue
+		}
+
+		family := tokenFamily(token)
+		err = p.Storage.DeleteTokensByFamily(family)
+		if err != nil {
+			return fmt.Errorf("failed to revoke refresh-token family %q: %w", family, err)
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"email":  email,
+			"family": family,
+		}).Warn("Security event: consumed refresh-token has been reused, revoked its token family")
+	}
+
+	return nil
+}
+
+// tokenFamily returns the family of the given token. Tokens persisted before families were introduced form their own
+// family.
+func tokenFamily(t storage.Token) string {
+	if t.Family == "" {
+		return t.Token
+	}
+
+	return t.Family
+}
+
+// CreatePasswordResetRequest send a password-reset-request email to the give address. The email will be sent
+// asynchronously, so the response time does not reveal whether the user exists.
 // return ErrUserNotFound when user does not exists
 func (p Provider) CreatePasswordResetRequest(email string) error {
 	u, err := p.Storage.User(email)
@@ -157,26 +324,43 @@ func (p Provider) CreatePasswordResetRequest(email string) error {
 	}
 
 	err = p.Storage.CreateToken(&storage.Token{
-		EMail: email,
-		Token: t,
-		Type:  storage.TokenTypeReset,
+		EMail:     email,
+		Token:     t,
+		Type:      storage.TokenTypeReset,
+		ExpiresAt: timeNow().Add(p.ResetTokenLifetime),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create password reset token for email %q: %w", email, err)
 	}
 
-	err = p.Mailer.SendPasswordResetRequestEMail(email, t, u.Claims)
-	if err != nil {
-		return fmt.Errorf("failed to send password reset email: %w", err)
-	}
+	sendAsync(func() {
+		err := p.Mailer.SendPasswordResetRequestEMail(email, t, u.Claims)
+		if err != nil {
+			logrus.WithError(err).WithField("email", email).Error("Failed to send password reset email")
+		}
+	})
 
 	return nil
 }
 
+var sendAsync = func(send func()) {
+	go send()
+}
+
 // ResetPassword resets the password of the given account if the reset token is correct.
 // return ErrNoValidTokenFound no valid token could be found
+// return PasswordPolicyError when the new password violates the password policy, has been breached or used before
 func (p *Provider) ResetPassword(email, resetToken, newPassword string) error {
-	//TODO do Storage.TokensByEMailAndToken and Storage.DeleteToken in transaction
+	err := p.PasswordPolicy.Validate(email, newPassword)
+	if err != nil {
+		return err
+	}
+
+	breached, err := p.checkBreachedPassword(email, newPassword)
+	if err != nil {
+		return err
+	}
+
 	tokens, err := p.Storage.TokensByEMailAndToken(email, resetToken)
 	if err != nil {
 		return fmt.Errorf("failed to find reset-tokens: %w", err)
@@ -184,8 +368,7 @@ func (p *Provider) ResetPassword(email, resetToken, newPassword string) error {
 
 	var t *storage.Token
 	for _, token := range tokens {
-		if token.Type == storage.TokenTypeReset {
-			// TODO check lifetime
+		if token.Type == storage.TokenTypeReset && !p.isExpired(token) {
 			t = &token
 			break
 		}
@@ -200,25 +383,69 @@ func (p *Provider) ResetPassword(email, resetToken, newPassword string) error {
 		return fmt.Errorf("failed to find user with email %q: %w", email, err)
 	}
 
-	securedPassword, err := bcryptPassword(newPassword)
+	err = p.checkPasswordHistory(u, newPassword)
 	if err != nil {
-		return fmt.Errorf("failed to bcrypt password: %w", err)
+		return err
 	}
-	u.Password = securedPassword
 
-	err = p.Storage.UpdateUser(u)
+	securedPassword, err := p.PasswordHasher.Hash(newPassword)
 	if err != nil {
-		return fmt.Errorf("failed to update user: %w", err)
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	err = p.consumeToken(t.ID)
+	if err != nil {
+		return fmt.Errorf("failed to consume reset-token: %w", err)
 	}
 
-	err = p.Storage.DeleteToken(t.ID)
+	err = p.recordPasswordHistory(u)
 	if err != nil {
-		return fmt.Errorf("failed to delete token: %w", err)
+		return err
+	}
+
+	err = p.Storage.SetUserPassword(email, securedPassword, breached)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
 	}
 
 	return nil
 }
 
+// consumeToken consumes the token with the given ID, so it can be redeemed only once.
+// return ErrNoValidTokenFound when the token has already been consumed concurrently
+func (p Provider) consumeToken(id uint) error {
+	err := p.Storage.ConsumeToken(id)
+	if errors.Is(err, storage.ErrTokenNotFound) {
+		return ErrNoValidTokenFound
+	}
+
+	return err
+}
+
+// isExpired checks whether the given token has been expired
+func (p Provider) isExpired(t storage.Token) bool {
+	return !timeNow().Before(p.tokenExpiry(t))
+}
+
+// tokenExpiry returns the expiry of the given token. Tokens without expiry have been persisted before expiries were
+// introduced and expire after the configured lifetime of their type, counted from their creation.
+func (p Provider) tokenExpiry(t storage.Token) time.Time {
+	if !t.ExpiresAt.IsZero() {
+		return t.ExpiresAt
+	}
+
+	return t.CreatedAt.Add(p.legacyTokenLifetimes()[t.Type])
+}
+
+// legacyTokenLifetimes returns the configured lifetimes of the token types which have been persisted before expiries
+// were introduced
+func (p Provider) legacyTokenLifetimes() map[string]time.Duration {
+	return map[string]time.Duration{
+		storage.TokenTypeRefresh: p.RefreshTokenLifetime,
+		storage.TokenTypeReset:   p.ResetTokenLifetime,
+	}
+}
+
 // generate 64 char long hex token  (32 bytes == 64 hex chars)
 var generateHEXToken = func() (string, error) {
 	b := make([]byte, 32)
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt"
+	"github.com/leberKleber/simple-jwt-provider/internal/password"
 	"github.com/leberKleber/simple-jwt-provider/internal/storage"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -13,9 +14,9 @@ import (
 	"time"
 )
 
-func TestProvider_Login(t *testing.T) {
-	bcryptCost = bcrypt.MinCost
+var testPasswordHasher, _ = password.NewHasher(password.AlgorithmBcrypt, bcrypt.MinCost, password.Argon2idParams{}, password.ScryptParams{})
 
+func TestProvider_Login(t *testing.T) {
 	tests := []struct {
 		name                      string
 		givenEMail                string
@@ -30,6 +31,7 @@ func TestProvider_Login(t *testing.T) {
 		generateRefreshTokenID    string
 		generateRefreshTokenError error
 		createTokenError          error
+		expectedTokenFamily       string
 		dbReturnError             error
 		dbReturnUser              storage.User
 	}{
@@ -40,8 +42,10 @@ func TestProvider_Login(t *testing.T) {
 			generatorExpectedEMail: "test@test.test",
 			generateAccessToken:    "myJWT",
 			generateRefreshToken:   "myRefreshJWT",
+			generateRefreshTokenID: "jwt-id",
 			expectedAccessToken:    "myJWT",
 			expectedRefreshToken:   "myRefreshJWT",
+			expectedTokenFamily:    "jwt-id",
 			dbReturnUser: storage.User{
 				Password: []byte("$2a$12$1v7O.pNLqugJjcePyxvUj.GK37YoAbJvSW/9bULSRmq5C4SkoU2OO"),
 				EMail:    "test@test.test",
@@ -96,6 +100,16 @@ func TestProvider_Login(t *testing.T) {
 				Password: []byte("$2a$12$1v7O.pNLqugJjcePyxvUj.GK37YoAbJvSW/9bULSRmq5C4SkoU2OO"),
 				EMail:    "test@test.test",
 			},
+		}, {
+			name:          "Unverified email",
+			givenEMail:    "test@test.test",
+			givenPassword: "password",
+			expectedError: ErrEMailNotVerified,
+			dbReturnUser: storage.User{
+				Password:        []byte("$2a$12$1v7O.pNLqugJjcePyxvUj.GK37YoAbJvSW/9bULSRmq5C4SkoU2OO"),
+				EMail:           "test@test.test",
+				EMailUnverified: true,
+			},
 		}, {
 			name:                   "Error while CreateToken",
 			givenEMail:             "test@test.test",
@@ -121,13 +135,19 @@ func TestProvider_Login(t *testing.T) {
 			var givenGenerateRefreshTokenEMail string
 			var givenGenerateAccessTokenEMail string
 			var givenGenerateAccessTokenUserClaims storage.Claims
+			var givenCreateToken storage.Token
 			toTest := Provider{
+				PasswordHasher: testPasswordHasher,
 				Storage: &StorageMock{
 					UserFunc: func(email string) (storage.User, error) {
 						givenStorageEMail = email
 						return tt.dbReturnUser, tt.dbReturnError
 					},
+					UpdateUserPasswordFunc: func(email string, oldHash []byte, newHash []byte) error {
+						return nil
+					},
 					CreateTokenFunc: func(t *storage.Token) error {
+						givenCreateToken = *t
 						return tt.createTokenError
 					},
 				},
@@ -144,7 +164,7 @@ func TestProvider_Login(t *testing.T) {
 				},
 			}
 
-			accessToken, refreshToken, err := toTest.Login(tt.givenEMail, tt.givenPassword)
+			accessToken, refreshToken, err := toTest.Login(tt.givenEMail, tt.givenPassword, ClientInfo{UserAgent: "my-agent", IP: "127.0.0.1"})
 			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
 				t.Fatalf("Processing error is not as expected: \nExpected:\n%s\nGiven:\n%s", tt.expectedError, err)
 			} else if err != nil {
@@ -174,14 +194,144 @@ func TestProvider_Login(t *testing.T) {
 			if givenGenerateRefreshTokenEMail != tt.generatorExpectedEMail {
 				t.Errorf("Generator.GenerateRefreshToken email ist not as expected: \nExpected:%s\nGiven:%s", tt.generatorExpectedEMail, givenGenerateRefreshTokenEMail)
 			}
+
+			if givenCreateToken.Family != tt.expectedTokenFamily {
+				t.Errorf("Storage.CreateToken family is not as expected: \nExpected:%s\nGiven:%s", tt.expectedTokenFamily, givenCreateToken.Family)
+			}
+
+			if givenCreateToken.UserAgent != "my-agent" || givenCreateToken.ClientIP != "127.0.0.1" {
+				t.Errorf("Storage.CreateToken client is not as expected: \nGiven:%q %q", givenCreateToken.UserAgent, givenCreateToken.ClientIP)
+			}
 		})
 	}
 
 }
 
-func TestProvider_Refresh(t *testing.T) {
-	bcryptCost = bcrypt.MinCost
+func TestProvider_Login_UnknownUserComparesDummyHash(t *testing.T) {
+	var comparedPassword string
+	toTest := Provider{
+		Storage: &StorageMock{
+			UserFunc: func(email string) (storage.User, error) {
+				return storage.User{}, storage.ErrUserNotFound
+			},
+		},
+		PasswordHasher: &PasswordHasherMock{
+			CompareDummyFunc: func(password string) {
+				comparedPassword = password
+			},
+		},
+	}
+
+	_, _, err := toTest.Login("not@existing.user", "password", ClientInfo{})
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("Processing error is not as expected: \nExpected:\n%s\nGiven:\n%s", ErrUserNotFound, err)
+	}
+
+	if comparedPassword != "password" {
+		t.Errorf("Password of unknown users should be compared with a dummy hash to take as long as for known users. Given: %q", comparedPassword)
+	}
+}
+
+func TestProvider_Login_RehashPassword(t *testing.T) {
+	tests := []struct {
+		name                    string
+		needsRehash             bool
+		hashError               error
+		updatePasswordError     error
+		expectedUpdatedPassword *updatedPassword
+	}{
+		{
+			name:                    "Up to date hash",
+			needsRehash:             false,
+			expectedUpdatedPassword: nil,
+		}, {
+			name:                    "Outdated hash",
+			needsRehash:             true,
+			expectedUpdatedPassword: &updatedPassword{email: "test@test.test", oldHash: "old-hash", newHash: "new-hash"},
+		}, {
+			name:                    "Outdated hash hashing fails",
+			needsRehash:             true,
+			hashError:               errors.New("unexpected error"),
+			expectedUpdatedPassword: nil,
+		}, {
+			name:                    "Outdated hash persisting fails",
+			needsRehash:             true,
+			updatePasswordError:     errors.New("unexpected error"),
+			expectedUpdatedPassword: &updatedPassword{email: "test@test.test", oldHash: "old-hash", newHash: "new-hash"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenUpdatedPassword *updatedPassword
+			toTest := Provider{
+				Storage: &StorageMock{
+					UserFunc: func(email string) (storage.User, error) {
+						return storage.User{Model: gorm.Model{ID: 3}, EMail: email, Password: []byte("old-hash")}, nil
+					},
+					UpdateUserPasswordFunc: func(email string, oldHash []byte, newHash []byte) error {
+						givenUpdatedPassword = &updatedPassword{email: email, oldHash: string(oldHash), newHash: string(newHash)}
+						return tt.updatePasswordError
+					},
+					CreateTokenFunc: func(t *storage.Token) error {
+						return nil
+					},
+				},
+				JWTProvider: &JWTProviderMock{
+					GenerateAccessTokenFunc: func(email string, userClaims map[string]interface{}) (string, error) {
+						return "myJWT", nil
+					},
+					GenerateRefreshTokenFunc: func(email string) (string, string, error) {
+						return "myRefreshJWT", "jwt-id", nil
+					},
+				},
+				PasswordHasher: &PasswordHasherMock{
+					CompareFunc: func(hash []byte, password string) error {
+						return nil
+					},
+					NeedsRehashFunc: func(hash []byte) bool {
+						return tt.needsRehash
+					},
+					HashFunc: func(password string) ([]byte, error) {
+						return []byte("new-hash"), tt.hashError
+					},
+				},
+			}
+
+			_, _, err := toTest.Login("test@test.test", "password", ClientInfo{})
+			if err != nil {
+				t.Fatalf("Rehashing should never fail the login: %s", err)
+			}
+
+			if !reflect.DeepEqual(givenUpdatedPassword, tt.expectedUpdatedPassword) {
+				t.Errorf("Updated password is not as expected: \nExpected:\n%#v\nGiven:\n%#v", tt.expectedUpdatedPassword, givenUpdatedPassword)
+			}
+		})
+	}
+}
+
+type updatedPassword struct {
+	email   string
+	oldHash string
+	newHash string
+}
+
+func TestProvider_AccessTokenLifetime(t *testing.T) {
+	toTest := Provider{
+		JWTProvider: &JWTProviderMock{
+			AccessTokenLifetimeFunc: func() time.Duration {
+				return 4 * time.Hour
+			},
+		},
+	}
+
+	lifetime := toTest.AccessTokenLifetime()
+	if lifetime != 4*time.Hour {
+		t.Errorf("Unexpected access-token lifetime. Expected: %s, Given: %s", 4*time.Hour, lifetime)
+	}
+}
 
+func TestProvider_Refresh(t *testing.T) {
 	tests := []struct {
 		name                            string
 		email                           string
@@ -192,6 +342,8 @@ func TestProvider_Refresh(t *testing.T) {
 		expectedRefreshToken            string
 		expectedTokenID                 uint
 		expectedJWTID                   string
+		expectedTokenFamily             string
+		expectedSessionCreatedAt        time.Time
 		generatorExpectedEMail          string
 		generateAccessToken             string
 		generateAccessTokenError        error
@@ -221,12 +373,14 @@ func TestProvider_Refresh(t *testing.T) {
 			isTokenValidClaims:     jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id"},
 			isTokenValidToken:      "givenRefreshToken",
 			tokensByEMailAndTokenFuncTokens: []storage.Token{
-				{Model: gorm.Model{ID: 1234}, EMail: "test.test@test.de", Type: storage.TokenTypeRefresh},
+				{Model: gorm.Model{ID: 1234, CreatedAt: time.Date(2021, 4, 19, 12, 0, 0, 0, time.UTC)}, EMail: "test.test@test.de", Type: storage.TokenTypeRefresh, ExpiresAt: time.Now().Add(time.Hour), Family: "family-id"},
 			},
-			expectedAccessToken:  "myJWT",
-			expectedRefreshToken: "myRefreshJWT",
-			expectedJWTID:        "jwt-id",
-			expectedTokenID:      1234,
+			expectedSessionCreatedAt: time.Date(2021, 4, 19, 12, 0, 0, 0, time.UTC),
+			expectedAccessToken:      "myJWT",
+			expectedRefreshToken:     "myRefreshJWT",
+			expectedJWTID:            "jwt-id",
+			expectedTokenID:          1234,
+			expectedTokenFamily:      "family-id",
 			dbReturnUser: storage.User{
 				EMail: "test@test.test",
 				Claims: map[string]interface{}{
@@ -242,7 +396,7 @@ func TestProvider_Refresh(t *testing.T) {
 			isTokenValidClaims:  jwt.MapClaims{"email": "not@existing.user", "jit": "jwt-id"},
 			isTokenValidToken:   "givenRefreshToken",
 			tokensByEMailAndTokenFuncTokens: []storage.Token{
-				{Model: gorm.Model{ID: 1234}, EMail: "test.test@test.de", Type: storage.TokenTypeRefresh},
+				{Model: gorm.Model{ID: 1234}, EMail: "test.test@test.de", Type: storage.TokenTypeRefresh, ExpiresAt: time.Now().Add(time.Hour)},
 			},
 			expectedError: ErrUserNotFound,
 			dbReturnError: storage.ErrUserNotFound,
@@ -254,7 +408,7 @@ func TestProvider_Refresh(t *testing.T) {
 			isTokenValidIsValid: true,
 			isTokenValidClaims:  jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id"},
 			tokensByEMailAndTokenFuncTokens: []storage.Token{
-				{Model: gorm.Model{ID: 1234}, EMail: "test.test@test.de", Type: storage.TokenTypeRefresh},
+				{Model: gorm.Model{ID: 1234}, EMail: "test.test@test.de", Type: storage.TokenTypeRefresh, ExpiresAt: time.Now().Add(time.Hour)},
 			},
 			expectedError: errors.New("failed to find user with email \"test@test.test\": unexpected error"),
 			dbReturnError: errors.New("unexpected error"),
@@ -272,7 +426,7 @@ func TestProvider_Refresh(t *testing.T) {
 			isTokenValidIsValid: true,
 			isTokenValidClaims:  jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id"},
 			tokensByEMailAndTokenFuncTokens: []storage.Token{
-				{Model: gorm.Model{ID: 1234}, EMail: "test.test@test.de", Type: storage.TokenTypeRefresh},
+				{Model: gorm.Model{ID: 1234}, EMail: "test.test@test.de", Type: storage.TokenTypeRefresh, ExpiresAt: time.Now().Add(time.Hour)},
 			},
 			generateAccessTokenError: errors.New("error 42"),
 			expectedError:            errors.New("failed to generate access-token: error 42"),
@@ -290,7 +444,7 @@ func TestProvider_Refresh(t *testing.T) {
 			isTokenValidIsValid: true,
 			isTokenValidClaims:  jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id"},
 			tokensByEMailAndTokenFuncTokens: []storage.Token{
-				{Model: gorm.Model{ID: 1234}, EMail: "test.test@test.de", Type: storage.TokenTypeRefresh},
+				{Model: gorm.Model{ID: 1234}, EMail: "test.test@test.de", Type: storage.TokenTypeRefresh, ExpiresAt: time.Now().Add(time.Hour)},
 			},
 			generateRefreshTokenError: errors.New("error 42"),
 			expectedError:             errors.New("failed to generate refresh-token: error 42"),
@@ -300,7 +454,7 @@ func TestProvider_Refresh(t *testing.T) {
 			givenPassword:     "wrongPassword",
 			isTokenValidErr:   errors.New("given token is not parsable"),
 			tokensByEMailAndTokenFuncTokens: []storage.Token{
-				{Model: gorm.Model{ID: 1234}, EMail: "test.test@test.de", Type: storage.TokenTypeRefresh},
+				{Model: gorm.Model{ID: 1234}, EMail: "test.test@test.de", Type: storage.TokenTypeRefresh, ExpiresAt: time.Now().Add(time.Hour)},
 			},
 			expectedError: errors.New("given token is not parsable: given token is not parsable"),
 			dbReturnUser: storage.User{
@@ -343,6 +497,16 @@ func TestProvider_Refresh(t *testing.T) {
 			isTokenValidClaims:              jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id"},
 			tokensByEMailAndTokenFuncTokens: []storage.Token{},
 			expectedError:                   ErrNoValidTokenFound,
+		}, {
+			name:                "Expired token",
+			email:               "test@test.test",
+			isTokenValidIsValid: true,
+			isTokenValidClaims:  jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id"},
+			tokensByEMailAndTokenFuncTokens: []storage.Token{
+				{Model: gorm.Model{ID: 1234}, EMail: "test.test@test.de", Type: storage.TokenTypeRefresh, ExpiresAt: time.Now().Add(-time.Minute)},
+			},
+			expectedJWTID: "jwt-id",
+			expectedError: ErrNoValidTokenFound,
 		}, {
 			name:                         "Error while TokensByEMailAndToken",
 			email:                        "test@test.test",
@@ -351,22 +515,32 @@ func TestProvider_Refresh(t *testing.T) {
 			tokensByEMailAndTokenFuncErr: errors.New("nope"),
 			expectedError:                errors.New("failed to find refresh-tokens: nope"),
 		}, {
-			name:                "Error while DeleteToken",
+			name:                "Error while ConsumeToken",
 			email:               "test@test.test",
 			isTokenValidIsValid: true,
 			isTokenValidClaims:  jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id"},
 			tokensByEMailAndTokenFuncTokens: []storage.Token{
-				{Model: gorm.Model{ID: 1234}, EMail: "test.test@test.de", Type: storage.TokenTypeRefresh},
+				{Model: gorm.Model{ID: 1234}, EMail: "test.test@test.de", Type: storage.TokenTypeRefresh, ExpiresAt: time.Now().Add(time.Hour)},
 			},
 			deleteTokenErr: errors.New("nope"),
-			expectedError:  errors.New("failed to delete refresh-token: nope"),
+			expectedError:  errors.New("failed to consume refresh-token: nope"),
+		}, {
+			name:                "Token consumed concurrently",
+			email:               "test@test.test",
+			isTokenValidIsValid: true,
+			isTokenValidClaims:  jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id"},
+			tokensByEMailAndTokenFuncTokens: []storage.Token{
+				{Model: gorm.Model{ID: 1234}, EMail: "test.test@test.de", Type: storage.TokenTypeRefresh, ExpiresAt: time.Now().Add(time.Hour)},
+			},
+			deleteTokenErr: storage.ErrTokenNotFound,
+			expectedError:  fmt.Errorf("failed to consume refresh-token: %w", ErrNoValidTokenFound),
 		}, {
 			name:                "Error while CreateToken",
 			email:               "test@test.test",
 			isTokenValidIsValid: true,
 			isTokenValidClaims:  jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id"},
 			tokensByEMailAndTokenFuncTokens: []storage.Token{
-				{Model: gorm.Model{ID: 1234}, EMail: "test.test@test.de", Type: storage.TokenTypeRefresh},
+				{Model: gorm.Model{ID: 1234}, EMail: "test.test@test.de", Type: storage.TokenTypeRefresh, ExpiresAt: time.Now().Add(time.Hour)},
 			},
 			createTokenErr: errors.New("nope"),
 			expectedError:  errors.New("failed to persist refresh-token: nope"),
@@ -383,6 +557,7 @@ func TestProvider_Refresh(t *testing.T) {
 			var givenTokensByEMailAndTokenEMail string
 			var givenTokensByEMailAndTokenToken string
 			var givenDeleteTokenID uint
+			var givenCreateToken storage.Token
 			toTest := Provider{
 				Storage: &StorageMock{
 					UserFunc: func(email string) (storage.User, error) {
@@ -394,11 +569,15 @@ func TestProvider_Refresh(t *testing.T) {
 						givenTokensByEMailAndTokenToken = token
 						return tt.tokensByEMailAndTokenFuncTokens, tt.tokensByEMailAndTokenFuncErr
 					},
-					DeleteTokenFunc: func(id uint) error {
+					ConsumeTokenFunc: func(id uint) error {
 						givenDeleteTokenID = id
 						return tt.deleteTokenErr
 					},
+					ConsumedTokensByEMailAndTokenFunc: func(email string, token string) ([]storage.Token, error) {
+						return nil, nil
+					},
 					CreateTokenFunc: func(t *storage.Token) error {
+						givenCreateToken = *t
 						return tt.createTokenErr
 					},
 				},
@@ -419,7 +598,7 @@ func TestProvider_Refresh(t *testing.T) {
 				},
 			}
 
-			accessToken, refreshToken, err := toTest.Refresh(tt.givenRefreshToken)
+			accessToken, refreshToken, err := toTest.Refresh(tt.givenRefreshToken, ClientInfo{UserAgent: "my-agent", IP: "127.0.0.1"})
 			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
 				t.Fatalf("Processing error is not as expected: \nExpected:\n%s\nGiven:\n%s", tt.expectedError, err)
 			} else if err != nil {
@@ -463,14 +642,120 @@ func TestProvider_Refresh(t *testing.T) {
 			}
 
 			if givenDeleteTokenID != tt.expectedTokenID {
-				t.Errorf("Storage.DeleteToken id is not as expected.\nExpected:%d\nGiven:%d", tt.expectedTokenID, givenDeleteTokenID)
+				t.Errorf("Storage.ConsumeToken id is not as expected.\nExpected:%d\nGiven:%d", tt.expectedTokenID, givenDeleteTokenID)
+			}
+
+			if givenCreateToken.Family != tt.expectedTokenFamily {
+				t.Errorf("Storage.CreateToken family is not as expected.\nExpected:%q\nGiven:%q", tt.expectedTokenFamily, givenCreateToken.Family)
+			}
+
+			if !givenCreateToken.CreatedAt.Equal(tt.expectedSessionCreatedAt) {
+				t.Errorf("Storage.CreateToken session creation time is not as expected.\nExpected:%s\nGiven:%s", tt.expectedSessionCreatedAt, givenCreateToken.CreatedAt)
+			}
+
+			if givenCreateToken.UserAgent != "my-agent" || givenCreateToken.ClientIP != "127.0.0.1" {
+				t.Errorf("Storage.CreateToken client is not as expected.\nGiven:%q %q", givenCreateToken.UserAgent, givenCreateToken.ClientIP)
 			}
 		})
 	}
 
 }
 
+func TestProvider_Refresh_ReusedToken(t *testing.T) {
+	tests := []struct {
+		name                    string
+		consumedTokens          []storage.Token
+		consumedTokensErr       error
+		deleteTokensByFamilyErr error
+		expectedRevokedFamilies []string
+		expectedError           error
+	}{
+		{
+			name:                    "Consumed refresh-token",
+			consumedTokens:          []storage.Token{{Model: gorm.Model{ID: 1234}, Token: "jwt-id", Type: storage.TokenTypeRefresh, Family: "family-id"}},
+			expectedRevokedFamilies: []string{"family-id"},
+			expectedError:           ErrNoValidTokenFound,
+		}, {
+			name:                    "Consumed refresh-token without family",
+			consumedTokens:          []storage.Token{{Model: gorm.Model{ID: 1234}, Token: "jwt-id", Type: storage.TokenTypeRefresh}},
+			expectedRevokedFamilies: []string{"jwt-id"},
+			expectedError:           ErrNoValidTokenFound,
+		}, {
+			name:           "Consumed token of other type",
+			consumedTokens: []storage.Token{{Model: gorm.Model{ID: 1234}, Token: "jwt-id", Type: storage.TokenTypeReset}},
+			expectedError:  ErrNoValidTokenFound,
+		}, {
+			name:          "Unknown token",
+			expectedError: ErrNoValidTokenFound,
+		}, {
+			name:              "Error while ConsumedTokensByEMailAndToken",
+			consumedTokensErr: errors.New("nope"),
+			expectedError:     errors.New("failed to find consumed refresh-tokens: nope"),
+		}, {
+			name:                    "Error while DeleteTokensByFamily",
+			consumedTokens:          []storage.Token{{Model: gorm.Model{ID: 1234}, Token: "jwt-id", Type: storage.TokenTypeRefresh, Family: "family-id"}},
+			deleteTokensByFamilyErr: errors.New("nope"),
+			expectedRevokedFamilies: []string{"family-id"},
+			expectedError:           errors.New("failed to revoke refresh-token family \"family-id\": nope"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenConsumedEMail, givenConsumedToken string
+			var revokedFamilies []string
+
+			toTest := Provider{
+				JWTProvider: &JWTProviderMock{
+					IsTokenValidFunc: func(token string) (bool, jwt.MapClaims, error) {
+						return true, jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "typ": "refresh"}, nil
+					},
+				},
+				Storage: &StorageMock{
+					TokensByEMailAndTokenFunc: func(email string, token string) ([]storage.Token, error) {
+						return nil, nil
+					},
+					ConsumedTokensByEMailAndTokenFunc: func(email string, token string) ([]storage.Token, error) {
+						givenConsumedEMail = email
+						givenConsumedToken = token
+						return tt.consumedTokens, tt.consumedTokensErr
+					},
+					DeleteTokensByFamilyFunc: func(family string) error {
+						revokedFamilies = append(revokedFamilies, family)
+						return tt.deleteTokensByFamilyErr
+					},
+				},
+			}
+
+			_, _, err := toTest.Refresh("givenRefreshToken", ClientInfo{})
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:\n%s\nGiven:\n%s", tt.expectedError, err)
+			}
+
+			if givenConsumedEMail != "test@test.test" || givenConsumedToken != "jwt-id" {
+				t.Errorf("Storage.ConsumedTokensByEMailAndToken called with unexpected args: %q, %q", givenConsumedEMail, givenConsumedToken)
+			}
+
+			if !reflect.DeepEqual(revokedFamilies, tt.expectedRevokedFamilies) {
+				t.Errorf("Revoked families are not as expected: \nExpected:%#v\nGiven:%#v", tt.expectedRevokedFamilies, revokedFamilies)
+			}
+		})
+	}
+}
+
 func TestProvider_CreatePasswordResetRequest(t *testing.T) {
+	now := time.Date(2021, 4, 19, 12, 0, 0, 0, time.UTC)
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	timeNow = func() time.Time {
+		return now
+	}
+	oldSendAsync := sendAsync
+	defer func() { sendAsync = oldSendAsync }()
+	sendAsync = func(send func()) {
+		send()
+	}
+
 	tests := []struct {
 		name                      string
 		givenEMail                string
@@ -493,6 +778,7 @@ func TestProvider_CreatePasswordResetRequest(t *testing.T) {
 				Model: gorm.Model{
 					ID: 0,
 				},
+				ExpiresAt: now.Add(24 * time.Hour),
 			},
 			expectedError: nil,
 		}, {
@@ -516,12 +802,13 @@ func TestProvider_CreatePasswordResetRequest(t *testing.T) {
 				Model: gorm.Model{
 					ID: 0,
 				},
+				ExpiresAt: now.Add(24 * time.Hour),
 			},
 		}, {
 			name:                  "Mailer error",
 			givenEMail:            "test.test@test",
 			mailerError:           errors.New("random error"),
-			expectedError:         errors.New("failed to send password reset email: random error"),
+			expectedError:         nil,
 			expectedMailRecipient: "test.test@test",
 			dbExpectedToken: storage.Token{
 				Type:  "reset",
@@ -529,6 +816,7 @@ func TestProvider_CreatePasswordResetRequest(t *testing.T) {
 				Model: gorm.Model{
 					ID: 0,
 				},
+				ExpiresAt: now.Add(24 * time.Hour),
 			},
 		}, {
 			name:                  "Unable to generate HEX token",
@@ -554,6 +842,7 @@ func TestProvider_CreatePasswordResetRequest(t *testing.T) {
 			var mailerRecipient string
 			var mailerPasswordResetToken string
 			toTest := Provider{
+				ResetTokenLifetime: 24 * time.Hour,
 				Storage: &StorageMock{
 					UserFunc: func(email string) (storage.User, error) {
 						storageUserEMail = email
@@ -606,20 +895,18 @@ func TestProvider_CreatePasswordResetRequest(t *testing.T) {
 }
 
 func TestProvider_ResetPassword(t *testing.T) {
-	bcryptCost = bcrypt.MinCost
-
 	tests := []struct {
 		name                string
 		givenEMail          string
 		givenResetToken     string
 		givenNewPassword    string
-		bcryptPasswordError error
+		hashPasswordError   error
 		dbToken             []storage.Token
 		dbTokenError        error
 		dbUser              storage.User
 		dbUserError         error
 		dbUpdateUserError   error
-		dbDeleteTokenError  error
+		dbConsumeTokenError error
 		expectedError       error
 	}{
 		{
@@ -628,7 +915,7 @@ func TestProvider_ResetPassword(t *testing.T) {
 			givenResetToken:  "resetToken",
 			givenEMail:       "email",
 			dbToken: []storage.Token{
-				{Model: gorm.Model{ID: 4, CreatedAt: time.Now()}, Token: "myToken1", Type: "reset", EMail: "email"},
+				{Model: gorm.Model{ID: 4, CreatedAt: time.Now()}, Token: "myToken1", Type: "reset", ExpiresAt: time.Now().Add(time.Hour), EMail: "email"},
 				{Model: gorm.Model{ID: 5, CreatedAt: time.Now()}, Token: "myToken2", Type: "other", EMail: "email"},
 			},
 		},
@@ -640,6 +927,16 @@ func TestProvider_ResetPassword(t *testing.T) {
 			expectedError:    ErrNoValidTokenFound,
 			dbToken:          []storage.Token{},
 		},
+		{
+			name:             "Expired token",
+			givenNewPassword: "newPassword",
+			givenResetToken:  "resetToken",
+			givenEMail:       "email",
+			expectedError:    ErrNoValidTokenFound,
+			dbToken: []storage.Token{
+				{Model: gorm.Model{ID: 4}, Token: "myToken1", Type: "reset", EMail: "email", ExpiresAt: time.Now().Add(-time.Minute)},
+			},
+		},
 		{
 			name:             "Error while find tokens",
 			givenNewPassword: "newPassword",
@@ -655,7 +952,7 @@ func TestProvider_ResetPassword(t *testing.T) {
 			givenResetToken:  "resetToken",
 			givenEMail:       "email",
 			dbToken: []storage.Token{
-				{Model: gorm.Model{ID: 4, CreatedAt: time.Now()}, Token: "myToken1", Type: "reset", EMail: "email"},
+				{Model: gorm.Model{ID: 4, CreatedAt: time.Now()}, Token: "myToken1", Type: "reset", ExpiresAt: time.Now().Add(time.Hour), EMail: "email"},
 				{Model: gorm.Model{ID: 5, CreatedAt: time.Now()}, Token: "myToken2", Type: "other", EMail: "email"},
 			},
 			dbUserError:   errors.New("unexpected error"),
@@ -667,49 +964,56 @@ func TestProvider_ResetPassword(t *testing.T) {
 			givenResetToken:  "resetToken",
 			givenEMail:       "email",
 			dbToken: []storage.Token{
-				{Model: gorm.Model{ID: 4, CreatedAt: time.Now()}, Token: "myToken1", Type: "reset", EMail: "email"},
+				{Model: gorm.Model{ID: 4, CreatedAt: time.Now()}, Token: "myToken1", Type: "reset", ExpiresAt: time.Now().Add(time.Hour), EMail: "email"},
 				{Model: gorm.Model{ID: 5, CreatedAt: time.Now()}, Token: "myToken2", Type: "other", EMail: "email"},
 			},
 			dbUpdateUserError: errors.New("unexpected error"),
 			expectedError:     errors.New("failed to update user: unexpected error"),
 		},
 		{
-			name:             "Error while delete token",
+			name:             "Error while consume token",
 			givenNewPassword: "newPassword",
 			givenResetToken:  "resetToken",
 			givenEMail:       "email",
 			dbToken: []storage.Token{
-				{Model: gorm.Model{ID: 4, CreatedAt: time.Now()}, Token: "myToken1", Type: "reset", EMail: "email"},
+				{Model: gorm.Model{ID: 4, CreatedAt: time.Now()}, Token: "myToken1", Type: "reset", ExpiresAt: time.Now().Add(time.Hour), EMail: "email"},
 				{Model: gorm.Model{ID: 5, CreatedAt: time.Now()}, Token: "myToken2", Type: "other", EMail: "email"},
 			},
-			dbDeleteTokenError: errors.New("unexpected error"),
-			expectedError:      errors.New("failed to delete token: unexpected error"),
+			dbConsumeTokenError: errors.New("unexpected error"),
+			expectedError:       errors.New("failed to consume reset-token: unexpected error"),
+		},
+		{
+			name:             "Token consumed concurrently",
+			givenNewPassword: "newPassword",
+			givenResetToken:  "resetToken",
+			givenEMail:       "email",
+			dbToken: []storage.Token{
+				{Model: gorm.Model{ID: 4, CreatedAt: time.Now()}, Token: "myToken1", Type: "reset", ExpiresAt: time.Now().Add(time.Hour), EMail: "email"},
+			},
+			dbConsumeTokenError: storage.ErrTokenNotFound,
+			expectedError:       fmt.Errorf("failed to consume reset-token: %w", ErrNoValidTokenFound),
 		},
 		{
-			name:                "Error bcrypt password",
-			givenNewPassword:    "newPassword",
-			givenResetToken:     "resetToken",
-			givenEMail:          "email",
-			bcryptPasswordError: errors.New("something went wrong"),
+			name:              "Error hash password",
+			givenNewPassword:  "newPassword",
+			givenResetToken:   "resetToken",
+			givenEMail:        "email",
+			hashPasswordError: errors.New("something went wrong"),
 			dbToken: []storage.Token{
-				{Model: gorm.Model{ID: 4, CreatedAt: time.Now()}, Token: "myToken1", Type: "reset", EMail: "email"},
+				{Model: gorm.Model{ID: 4, CreatedAt: time.Now()}, Token: "myToken1", Type: "reset", ExpiresAt: time.Now().Add(time.Hour), EMail: "email"},
 			},
-			expectedError: errors.New("failed to bcrypt password: something went wrong"),
+			expectedError: errors.New("failed to hash password: something went wrong"),
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.bcryptPasswordError != nil {
-				oldBcryptPassword := bcryptPassword
-				defer func() { bcryptPassword = oldBcryptPassword }()
-
-				bcryptPassword = func(password string) ([]byte, error) {
-					return nil, tt.bcryptPasswordError
-				}
-			}
-
 			toTest := Provider{
+				PasswordHasher: &PasswordHasherMock{
+					HashFunc: func(password string) ([]byte, error) {
+						return []byte("hash"), tt.hashPasswordError
+					},
+				},
 				Storage: &StorageMock{
 					TokensByEMailAndTokenFunc: func(email string, token string) ([]storage.Token, error) {
 						return tt.dbToken, tt.dbTokenError
@@ -717,11 +1021,11 @@ func TestProvider_ResetPassword(t *testing.T) {
 					UserFunc: func(email string) (storage.User, error) {
 						return tt.dbUser, tt.dbUserError
 					},
-					UpdateUserFunc: func(user storage.User) error {
+					SetUserPasswordFunc: func(email string, password []byte, breached bool) error {
 						return tt.dbUpdateUserError
 					},
-					DeleteTokenFunc: func(id uint) error {
-						return tt.dbDeleteTokenError
+					ConsumeTokenFunc: func(id uint) error {
+						return tt.dbConsumeTokenError
 					},
 				},
 			}
@@ -733,3 +1037,55 @@ func TestProvider_ResetPassword(t *testing.T) {
 		})
 	}
 }
+
+func TestProvider_isExpired(t *testing.T) {
+	now := time.Date(2021, 4, 19, 12, 0, 0, 0, time.UTC)
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	timeNow = func() time.Time {
+		return now
+	}
+
+	tests := []struct {
+		name            string
+		token           storage.Token
+		expectedExpired bool
+	}{
+		{
+			name:  "Token with expiry",
+			token: storage.Token{Type: storage.TokenTypeMFA, ExpiresAt: now.Add(time.Minute)},
+		}, {
+			name:            "Expired token",
+			token:           storage.Token{Type: storage.TokenTypeMFA, ExpiresAt: now},
+			expectedExpired: true,
+		}, {
+			name:  "Legacy refresh-token",
+			token: storage.Token{Model: gorm.Model{CreatedAt: now.Add(-time.Hour)}, Type: storage.TokenTypeRefresh},
+		}, {
+			name:            "Expired legacy refresh-token",
+			token:           storage.Token{Model: gorm.Model{CreatedAt: now.Add(-3 * time.Hour)}, Type: storage.TokenTypeRefresh},
+			expectedExpired: true,
+		}, {
+			name:  "Legacy reset-token",
+			token: storage.Token{Model: gorm.Model{CreatedAt: now.Add(-time.Minute)}, Type: storage.TokenTypeReset},
+		}, {
+			name:            "Expired legacy reset-token",
+			token:           storage.Token{Model: gorm.Model{CreatedAt: now.Add(-time.Hour)}, Type: storage.TokenTypeReset},
+			expectedExpired: true,
+		}, {
+			name:            "Token of other type without expiry",
+			token:           storage.Token{Model: gorm.Model{CreatedAt: now}, Type: storage.TokenTypeMFA},
+			expectedExpired: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toTest := Provider{RefreshTokenLifetime: 2 * time.Hour, ResetTokenLifetime: 30 * time.Minute}
+
+			if expired := toTest.isExpired(tt.token); expired != tt.expectedExpired {
+				t.Errorf("Unexpected expiry. Expected: %t, Given: %t", tt.expectedExpired, expired)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package breach
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const prefixLength = 5
+
+// Dataset looks up passwords in a local dataset of SHA-1 hashes of breached passwords like the one of
+// haveibeenpwned.com. Passwords will never be sent over the network.
+type Dataset struct {
+	rangesDir string
+	hashes    map[[sha1.Size]byte]struct{}
+}
+
+// Load loads the dataset at the given path.
+// A directory is expected to contain range files named by the first 5 hex chars of the hashes (optionally with a
+// '.txt' extension) which contain 'SUFFIX:COUNT' lines. Range files will be read on each lookup, so only the requested
+// range will be touched (k-anonymity model).
+// A file is expected to contain 'HASH:COUNT' lines and will be loaded into memory.
+// Entries with a count of 0 are treated as padding and will be ignored.
+func Load(path string) (*Dataset, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat dataset: %w", err)
+	}
+
+	if info.IsDir() {
+		return &Dataset{rangesDir: path}, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer f.Close()
+
+	hashes := map[[sha1.Size]byte]struct{}{}
+	err = scanEntries(f, func(hexHash string) error {
+		var hash [sha1.Size]byte
+		if hex.DecodedLen(len(hexHash)) != sha1.Size {
+			return errors.New("hash must be 40 hex chars long")
+		}
+		_, err := hex.Decode(hash[:], []byte(hexHash))
+		if err != nil {
+			return err
+		}
+
+		hashes[hash] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+
+	return &Dataset{hashes: hashes}, nil
+}
+
+// IsBreached checks whether the SHA-1 hash of the given password is contained in the dataset
+func (d *Dataset) IsBreached(password string) (bool, error) {
+	hash := sha1.Sum([]byte(password))
+	if d.rangesDir == "" {
+		_, ok := d.hashes[hash]
+		return ok, nil
+	}
+
+	hexHash := strings.ToUpper(hex.EncodeToString(hash[:]))
+	prefix, suffix := hexHash[:prefixLength], hexHash[prefixLength:]
+
+	f, err := openRange(d.rangesDir, prefix)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to open range %q: %w", prefix, err)
+	}
+	defer f.Close()
+
+	var found bool
+	err = scanEntries(f, func(entrySuffix string) error {
+		found = found || strings.EqualFold(entrySuffix, suffix)
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to read range %q: %w", prefix, err)
+	}
+
+	return found, nil
+}
+
+// openRange opens the range file of the given prefix with or without '.txt' extension
+func openRange(dir, prefix string) (*os.File, error) {
+	f, err := os.Open(filepath.Join(dir, prefix+".txt"))
+	if os.IsNotExist(err) {
+		return os.Open(filepath.Join(dir, prefix))
+	}
+
+	return f, err
+}
+
+// scanEntries calls fn with the hash (or hash suffix) of each 'HASH:COUNT' line of the given reader. Empty lines and
+// padding entries with a count of 0 will be skipped.
+func scanEntries(r io.Reader, fn func(hash string) error) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) == 2 && strings.TrimSpace(parts[1]) == "0" {
+			continue
+		}
+
+		err := fn(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return fmt.Errorf("invalid entry in line %d: %w", line, err)
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,95 @@
+package breach
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// SHA-1 hashes of "password" and "s3cr3t"
+const (
+	passwordHash = "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8"
+	s3cr3tHash   = "25AB86BED149CA6CA9C1C0D5DB7C9A91388DDEAB"
+)
+
+func TestDataset_IsBreached(t *testing.T) {
+	rangesDir := t.TempDir()
+	writeFile(t, filepath.Join(rangesDir, "5BAA6.txt"), "003D68EB55068C33ACE09247EE4C639306B:3\r\n"+passwordHash[5:]+":9545824\r\n")
+	// padding entry of s3cr3t
+	writeFile(t, filepath.Join(rangesDir, "25AB8"), s3cr3tHash[5:]+":0\n")
+
+	hashesFile := filepath.Join(t.TempDir(), "hashes.txt")
+	writeFile(t, hashesFile, "\n"+passwordHash+":9545824\n"+s3cr3tHash+":0\n")
+
+	tests := []struct {
+		name             string
+		path             string
+		password         string
+		expectedBreached bool
+	}{
+		{name: "Ranges: breached", path: rangesDir, password: "password", expectedBreached: true},
+		{name: "Ranges: padding entry", path: rangesDir, password: "s3cr3t", expectedBreached: false},
+		{name: "Ranges: missing range", path: rangesDir, password: "S3cure-Pässword", expectedBreached: false},
+		{name: "File: breached", path: hashesFile, password: "password", expectedBreached: true},
+		{name: "File: padding entry", path: hashesFile, password: "s3cr3t", expectedBreached: false},
+		{name: "File: not breached", path: hashesFile, password: "S3cure-Pässword", expectedBreached: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toTest, err := Load(tt.path)
+			if err != nil {
+				t.Fatalf("Failed to load dataset: %s", err)
+			}
+
+			breached, err := toTest.IsBreached(tt.password)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if breached != tt.expectedBreached {
+				t.Errorf("Breached is not as expected. Expected: %t, Given: %t", tt.expectedBreached, breached)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	invalidFile := filepath.Join(dir, "invalid.txt")
+	writeFile(t, invalidFile, passwordHash+":1\n5BAA61E4:1\n")
+
+	tests := []struct {
+		name          string
+		path          string
+		expectedError error
+	}{
+		{
+			name:          "Missing dataset",
+			path:          filepath.Join(dir, "missing.txt"),
+			expectedError: fmt.Errorf("failed to stat dataset: stat %s: no such file or directory", filepath.Join(dir, "missing.txt")),
+		}, {
+			name:          "Invalid hash",
+			path:          invalidFile,
+			expectedError: fmt.Errorf("failed to read dataset: invalid entry in line 2: hash must be 40 hex chars long"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.path)
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:\n%s\nGiven:\n%s", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	err := ioutil.WriteFile(path, []byte(content), os.ModePerm)
+	if err != nil {
+		t.Fatalf("Failed to write %q: %s", path, err)
+	}
+}
@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// BreachedPasswordModeReject rejects breached passwords as violation of the password policy
+	BreachedPasswordModeReject = "reject"
+	// BreachedPasswordModeWarn accepts breached passwords but flags the user
+	BreachedPasswordModeWarn = "warn"
+)
+
+// checkBreachedPassword checks the new password of the user with the given email against the breached passwords. It
+// returns whether the user has to be flagged because of a breached password.
+// return PasswordPolicyError when the password has been breached and breached passwords will be rejected
+func (p Provider) checkBreachedPassword(email, password string) (bool, error) {
+	if p.BreachedPasswords == nil {
+		return false, nil
+	}
+
+	breached, err := p.BreachedPasswords.IsBreached(password)
+	if err != nil {
+		return false, fmt.Errorf("failed to check for breached password: %w", err)
+	}
+
+	if !breached {
+		return false, nil
+	}
+
+	if p.BreachedPasswordMode == BreachedPasswordModeWarn {
+		logrus.WithField("email", email).Warn("New password has been found in a data breach")
+		return true, nil
+	}
+
+	return false, PasswordPolicyError{Violations: []PasswordPolicyViolation{
+		{Rule: "breached", Message: "password has been found in a data breach"},
+	}}
+}
@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestProvider_checkBreachedPassword(t *testing.T) {
+	tests := []struct {
+		name              string
+		breachedPasswords BreachedPasswords
+		mode              string
+		expectedFlag      bool
+		expectedError     error
+	}{
+		{
+			name: "No breached passwords configured",
+		}, {
+			name:              "Not breached",
+			breachedPasswords: breachedPasswords(false, nil),
+		}, {
+			name:              "Breached: reject by default",
+			breachedPasswords: breachedPasswords(true, nil),
+			expectedError:     errors.New("password violates the password policy: breached"),
+		}, {
+			name:              "Breached: reject",
+			breachedPasswords: breachedPasswords(true, nil),
+			mode:              BreachedPasswordModeReject,
+			expectedError:     errors.New("password violates the password policy: breached"),
+		}, {
+			name:              "Breached: warn",
+			breachedPasswords: breachedPasswords(true, nil),
+			mode:              BreachedPasswordModeWarn,
+			expectedFlag:      true,
+		}, {
+			name:              "Dataset error",
+			breachedPasswords: breachedPasswords(false, errors.New("nope")),
+			expectedError:     errors.New("failed to check for breached password: nope"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toTest := Provider{BreachedPasswords: tt.breachedPasswords, BreachedPasswordMode: tt.mode}
+
+			flag, err := toTest.checkBreachedPassword("test@test.test", "password")
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:%s\nGiven:%s", tt.expectedError, err)
+			}
+
+			if flag != tt.expectedFlag {
+				t.Errorf("Flag is not as expected. Expected: %t, Given: %t", tt.expectedFlag, flag)
+			}
+		})
+	}
+}
+
+func TestProvider_BreachedPasswordIsFlagged(t *testing.T) {
+	var updatedUsers, createdUsers []storage.User
+	var passwordResetBreached bool
+	toTest := Provider{
+		BreachedPasswords:    breachedPasswords(true, nil),
+		BreachedPasswordMode: BreachedPasswordModeWarn,
+		PasswordHasher:       testPasswordHasher,
+		Storage: &StorageMock{
+			CreateUserFunc: func(user storage.User) error {
+				createdUsers = append(createdUsers, user)
+				return nil
+			},
+			UserFunc: func(email string) (storage.User, error) {
+				return storage.User{EMail: email}, nil
+			},
+			UpdateUserFunc: func(user storage.User) error {
+				updatedUsers = append(updatedUsers, user)
+				return nil
+			},
+			SetUserPasswordFunc: func(email string, password []byte, breached bool) error {
+				passwordResetBreached = breached
+				return nil
+			},
+			TokensByEMailAndTokenFunc: func(email, token string) ([]storage.Token, error) {
+				return []storage.Token{{Type: storage.TokenTypeReset, ExpiresAt: time.Now().Add(time.Hour)}}, nil
+			},
+			ConsumeTokenFunc: func(id uint) error {
+				return nil
+			},
+		},
+	}
+
+	err := toTest.CreateUser(User{EMail: "test@test.test", Password: "password"})
+	if err != nil {
+		t.Fatalf("Failed to create user: %s", err)
+	}
+
+	user, err := toTest.UpdateUser("test@test.test", User{Password: "password"})
+	if err != nil {
+		t.Fatalf("Failed to update user: %s", err)
+	}
+	if !user.PasswordBreached {
+		t.Error("Updated user should be flagged")
+	}
+
+	err = toTest.ResetPassword("test@test.test", "reset-token", "password")
+	if err != nil {
+		t.Fatalf("Failed to reset password: %s", err)
+	}
+
+	if len(createdUsers) != 1 || !createdUsers[0].PasswordBreached {
+		t.Errorf("Created user should be flagged: %#v", createdUsers)
+	}
+	if len(updatedUsers) != 1 || !updatedUsers[0].PasswordBreached {
+		t.Errorf("Updated user should be flagged: %#v", updatedUsers)
+	}
+	if !passwordResetBreached {
+		t.Error("Reset password should be flagged")
+	}
+}
+
+func breachedPasswords(breached bool, err error) *BreachedPasswordsMock {
+	return &BreachedPasswordsMock{
+		IsBreachedFunc: func(password string) (bool, error) {
+			return breached, err
+		},
+	}
+}
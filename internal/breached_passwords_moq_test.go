@@ -0,0 +1,73 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package internal
+
+import (
+	"sync"
+)
+
+// Ensure, that BreachedPasswordsMock does implement BreachedPasswords.
+// If this is not the case, regenerate this file with moq.
+var _ BreachedPasswords = &BreachedPasswordsMock{}
+
+// BreachedPasswordsMock is a mock implementation of BreachedPasswords.
+//
+// 	func TestSomethingThatUsesBreachedPasswords(t *testing.T) {
+//
+// 		// make and configure a mocked BreachedPasswords
+// 		mockedBreachedPasswords := &BreachedPasswordsMock{
+// 			IsBreachedFunc: func(password string) (bool, error) {
+// 				panic("mock out the IsBreached method")
+// 			},
+// 		}
+//
+// 		// use mockedBreachedPasswords in code that requires BreachedPasswords
+// 		// and then make assertions.
+//
+// 	}
+type BreachedPasswordsMock struct {
+	// IsBreachedFunc mocks the IsBreached method.
+	IsBreachedFunc func(password string) (bool, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// IsBreached holds details about calls to the IsBreached method.
+		IsBreached []struct {
+			// Password is the password argument value.
+			Password string
+		}
+	}
+	lockIsBreached sync.RWMutex
+}
+
+// IsBreached calls IsBreachedFunc.
+func (mock *BreachedPasswordsMock) IsBreached(password string) (bool, error) {
+	if mock.IsBreachedFunc == nil {
+		panic("BreachedPasswordsMock.IsBreachedFunc: method is nil but BreachedPasswords.IsBreached was just called")
+	}
+	callInfo := struct {
+		Password string
+	}{
+		Password: password,
+	}
+	mock.lockIsBreached.Lock()
+	mock.calls.IsBreached = append(mock.calls.IsBreached, callInfo)
+	mock.lockIsBreached.Unlock()
+	return mock.IsBreachedFunc(password)
+}
+
+// IsBreachedCalls gets all the calls that were made to IsBreached.
+// Check the length with:
+//     len(mockedBreachedPasswords.IsBreachedCalls())
+func (mock *BreachedPasswordsMock) IsBreachedCalls() []struct {
+	Password string
+} {
+	var calls []struct {
+		Password string
+	}
+	mock.lockIsBreached.RLock()
+	calls = mock.calls.IsBreached
+	mock.lockIsBreached.RUnlock()
+	return calls
+}
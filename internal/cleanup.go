@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PurgeExpiredTokens permanently deletes all expired tokens in batches of the given size and returns the number of
+// deleted tokens. Tokens without expiry expire after the configured lifetime of their type as checked by isExpired.
+func (p Provider) PurgeExpiredTokens(batchSize int) (int64, error) {
+	if batchSize < 1 {
+		return 0, errors.New("batch size must be greater than 0")
+	}
+
+	now := timeNow()
+	var purged int64
+	for {
+		deleted, err := p.Storage.DeleteExpiredTokens(now, p.legacyTokenLifetimes(), batchSize)
+		purged += deleted
+		if err != nil {
+			return purged, fmt.Errorf("failed to delete expired tokens: %w", err)
+		}
+
+		if deleted < int64(batchSize) {
+			return purged, nil
+		}
+	}
+}
+
+// PurgeUnverifiedUsers permanently deletes all self-registered users in batches of the given size whose email has not
+// been verified within the lifetime of their email-verification-token and returns the number of deleted users.
+func (p Provider) PurgeUnverifiedUsers(batchSize int) (int64, error) {
+	if batchSize < 1 {
+		return 0, errors.New("batch size must be greater than 0")
+	}
+
+	before := timeNow().Add(-p.Registration.VerificationTokenLifetime)
+	var purged int64
+	for {
+		deleted, err := p.Storage.DeleteUnverifiedUsers(before, batchSize)
+		purged += deleted
+		if err != nil {
+			return purged, fmt.Errorf("failed to delete unverified users: %w", err)
+		}
+
+		if deleted < int64(batchSize) {
+			return purged, nil
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProvider_PurgeExpiredTokens(t *testing.T) {
+	now := time.Date(2021, 4, 19, 12, 0, 0, 0, time.UTC)
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	timeNow = func() time.Time {
+		return now
+	}
+	expectedLegacyLifetimes := map[string]time.Duration{storage.TokenTypeRefresh: 2 * time.Hour, storage.TokenTypeReset: time.Hour}
+
+	tests := []struct {
+		name           string
+		batchSize      int
+		deleted        []int64
+		deleteErr      error
+		expectedCalls  int
+		expectedPurged int64
+		expectedError  error
+	}{
+		{
+			name:           "Single batch",
+			batchSize:      10,
+			deleted:        []int64{3},
+			expectedCalls:  1,
+			expectedPurged: 3,
+		}, {
+			name:           "Multiple batches",
+			batchSize:      10,
+			deleted:        []int64{10, 10, 0},
+			expectedCalls:  3,
+			expectedPurged: 20,
+		}, {
+			name:           "Nothing to purge",
+			batchSize:      10,
+			deleted:        []int64{0},
+			expectedCalls:  1,
+			expectedPurged: 0,
+		}, {
+			name:          "Invalid batch size",
+			batchSize:     0,
+			expectedError: errors.New("batch size must be greater than 0"),
+		}, {
+			name:          "Failed to delete tokens",
+			batchSize:     10,
+			deleted:       []int64{0},
+			deleteErr:     errors.New("nope"),
+			expectedCalls: 1,
+			expectedError: errors.New("failed to delete expired tokens: nope"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+
+			toTest := Provider{
+				RefreshTokenLifetime: 2 * time.Hour,
+				ResetTokenLifetime:   time.Hour,
+				Storage: &StorageMock{
+					DeleteExpiredTokensFunc: func(before time.Time, legacyLifetimes map[string]time.Duration, limit int) (int64, error) {
+						if !before.Equal(now) {
+							t.Errorf("Unexpected purge time. Expected: %s, Given: %s", now, before)
+						}
+						if !reflect.DeepEqual(legacyLifetimes, expectedLegacyLifetimes) {
+							t.Errorf("Unexpected legacy lifetimes. Expected: %v, Given: %v", expectedLegacyLifetimes, legacyLifetimes)
+						}
+						if limit != tt.batchSize {
+							t.Errorf("Unexpected batch size. Expected: %d, Given: %d", tt.batchSize, limit)
+						}
+
+						deleted := tt.deleted[calls]
+						calls++
+						return deleted, tt.deleteErr
+					},
+				},
+			}
+
+			purged, err := toTest.PurgeExpiredTokens(tt.batchSize)
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Unexpected error. Expected: %q, Given: %q", tt.expectedError, err)
+			}
+
+			if purged != tt.expectedPurged {
+				t.Errorf("Unexpected number of purged tokens. Expected: %d, Given: %d", tt.expectedPurged, purged)
+			}
+
+			if calls != tt.expectedCalls {
+				t.Errorf("Unexpected number of batches. Expected: %d, Given: %d", tt.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestProvider_PurgeUnverifiedUsers(t *testing.T) {
+	now := time.Date(2021, 4, 19, 12, 0, 0, 0, time.UTC)
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	timeNow = func() time.Time {
+		return now
+	}
+
+	tests := []struct {
+		name           string
+		batchSize      int
+		deleted        []int64
+		deleteErr      error
+		expectedCalls  int
+		expectedPurged int64
+		expectedError  error
+	}{
+		{
+			name:           "Single batch",
+			batchSize:      10,
+			deleted:        []int64{3},
+			expectedCalls:  1,
+			expectedPurged: 3,
+		}, {
+			name:           "Multiple batches",
+			batchSize:      10,
+			deleted:        []int64{10, 10, 0},
+			expectedCalls:  3,
+			expectedPurged: 20,
+		}, {
+			name:          "Invalid batch size",
+			batchSize:     0,
+			expectedError: errors.New("batch size must be greater than 0"),
+		}, {
+			name:          "Failed to delete users",
+			batchSize:     10,
+			deleted:       []int64{0},
+			deleteErr:     errors.New("nope"),
+			expectedCalls: 1,
+			expectedError: errors.New("failed to delete unverified users: nope"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+
+			toTest := Provider{
+				Registration: Registration{VerificationTokenLifetime: time.Hour},
+				Storage: &StorageMock{
+					DeleteUnverifiedUsersFunc: func(before time.Time, limit int) (int64, error) {
+						if !before.Equal(now.Add(-time.Hour)) {
+							t.Errorf("Unexpected purge time. Expected: %s, Given: %s", now.Add(-time.Hour), before)
+						}
+						if limit != tt.batchSize {
+							t.Errorf("Unexpected batch size. Expected: %d, Given: %d", tt.batchSize, limit)
+						}
+
+						deleted := tt.deleted[calls]
+						calls++
+						return deleted, tt.deleteErr
+					},
+				},
+			}
+
+			purged, err := toTest.PurgeUnverifiedUsers(tt.batchSize)
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Unexpected error. Expected: %q, Given: %q", tt.expectedError, err)
+			}
+
+			if purged != tt.expectedPurged {
+				t.Errorf("Unexpected number of purged users. Expected: %d, Given: %d", tt.expectedPurged, purged)
+			}
+
+			if calls != tt.expectedCalls {
+				t.Errorf("Unexpected number of batches. Expected: %d, Given: %d", tt.expectedCalls, calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package internal
+
+// commonPasswords contains frequently used and leaked passwords in lower case which will be rejected by a
+// PasswordPolicy with DisallowCommon
+var commonPasswords = map[string]struct{}{
+	"123456": {}, "password": {}, "12345678": {}, "qwerty": {}, "123456789": {}, "12345": {}, "1234": {},
+	"111111": {}, "1234567": {}, "dragon": {}, "123123": {}, "baseball": {}, "abc123": {}, "football": {},
+	"monkey": {}, "letmein": {}, "696969": {}, "shadow": {}, "master": {}, "666666": {}, "qwertyuiop": {},
+	"123321": {}, "mustang": {}, "1234567890": {}, "michael": {}, "654321": {}, "superman": {}, "1qaz2wsx": {},
+	"7777777": {}, "121212": {}, "000000": {}, "qazwsx": {}, "123qwe": {}, "killer": {}, "trustno1": {},
+	"jordan": {}, "jennifer": {}, "zxcvbnm": {}, "asdfgh": {}, "hunter": {}, "buster": {}, "soccer": {},
+	"harley": {}, "batman": {}, "andrew": {}, "tigger": {}, "sunshine": {}, "iloveyou": {}, "2000": {},
+	"charlie": {}, "robert": {}, "thomas": {}, "hockey": {}, "ranger": {}, "daniel": {}, "starwars": {},
+	"klaster": {}, "112233": {}, "george": {}, "computer": {}, "michelle": {}, "jessica": {}, "pepper": {},
+	"1111": {}, "zxcvbn": {}, "555555": {}, "11111111": {}, "131313": {}, "freedom": {}, "777777": {}, "pass": {},
+	"maggie": {}, "159753": {}, "aaaaaa": {}, "ginger": {}, "princess": {}, "joshua": {}, "cheese": {},
+	"amanda": {}, "summer": {}, "love": {}, "ashley": {}, "nicole": {}, "chelsea": {}, "biteme": {}, "matthew": {},
+	"access": {}, "yankees": {}, "987654321": {}, "dallas": {}, "austin": {}, "thunder": {}, "taylor": {},
+	"matrix": {}, "mobilemail": {}, "mom": {}, "monitor": {}, "monitoring": {}, "montana": {}, "moon": {},
+	"moscow": {}, "welcome": {}, "welcome1": {}, "password1": {}, "password123": {}, "admin": {}, "admin123": {},
+	"administrator": {}, "root": {}, "toor": {}, "login": {}, "changeme": {}, "secret": {}, "s3cr3t": {},
+	"passw0rd": {}, "p@ssw0rd": {}, "p@ssword": {}, "qwerty123": {}, "qwerty1": {}, "1q2w3e4r": {},
+	"1q2w3e4r5t": {}, "1q2w3e": {}, "123abc": {}, "abcd1234": {}, "abcdef": {}, "abcdefg": {}, "iloveyou1": {},
+	"lovely": {}, "flower": {}, "hello": {}, "hello123": {}, "hottie": {}, "loveme": {}, "zaq12wsx": {},
+	"whatever": {}, "donald": {}, "football1": {}, "baseball1": {}, "starwars1": {}, "test": {}, "test123": {},
+	"testing": {}, "guest": {}, "default": {}, "user": {}, "demo": {}, "sample": {}, "88888888": {},
+	"87654321": {}, "00000000": {}, "qwertyui": {}, "asdfghjkl": {}, "q1w2e3r4": {}, "superman1": {},
+	"princess1": {}, "sunshine1": {}, "shadow1": {}, "master1": {}, "dragon1": {}, "monkey1": {}, "letmein1": {},
+	"trustno1!": {}, "123456a": {}, "a123456": {}, "123456789a": {}, "1234qwer": {}, "qwer1234": {}, "google": {},
+	"samsung": {}, "apple": {}, "apple123": {}, "microsoft": {}, "linux": {}, "ubuntu": {},
+}
+
+// isCommonPassword checks whether the given lower case password is a common password
+func isCommonPassword(password string) bool {
+	_, found := commonPasswords[password]
+	return found
+}
@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"github.com/sirupsen/logrus"
+	"math/big"
+)
+
+// MFAMethodEMail identifies one-time codes which are sent via email as multi-factor authentication method
+const MFAMethodEMail = "email"
+
+const emailOTPDigits = 6
+
+// ErrEMailOTPDisabled returned when one-time codes via email have not been enabled
+var ErrEMailOTPDisabled = errors.New("email one-time codes disabled")
+
+// EnrollEMailOTP sends a one-time code to the email of the user of the given access-token. The code has to be
+// confirmed via ConfirmEMailOTP before one-time codes via email will be required on login.
+// return ErrEMailOTPDisabled when one-time codes via email have not been enabled
+// return ErrInvalidToken when the token is not an active access-token or its user does not exist anymore
+// return ErrMFAAlreadyEnabled when the user has already enabled one-time codes via email
+func (p Provider) EnrollEMailOTP(accessToken string) error {
+	if !p.EMailOTPEnabled {
+		return ErrEMailOTPDisabled
+	}
+
+	u, err := p.accessTokenUser(accessToken)
+	if err != nil {
+		return err
+	}
+
+	if u.EMailOTPEnabled {
+		return ErrMFAAlreadyEnabled
+	}
+
+	return p.sendEMailOTP(u)
+}
+
+// ConfirmEMailOTP enables one-time codes via email as second factor for the user of the given access-token when the
+// given code is the one which has been sent by EnrollEMailOTP.
+// return ErrEMailOTPDisabled when one-time codes via email have not been enabled
+// return ErrInvalidToken when the token is not an active access-token or its user does not exist anymore
+// return ErrMFAAlreadyEnabled when the user has already enabled one-time codes via email
+// return ErrIncorrectMFACode when the code is incorrect, expired or has been attempted too often
+func (p Provider) ConfirmEMailOTP(accessToken, code string) error {
+	if !p.EMailOTPEnabled {
+		return ErrEMailOTPDisabled
+	}
+
+	u, err := p.accessTokenUser(accessToken)
+	if err != nil {
+		return err
+	}
+
+	if u.EMailOTPEnabled {
+		return ErrMFAAlreadyEnabled
+	}
+
+	valid, err := p.verifyEMailOTP(u.EMail, code)
+	if err != nil {
+		return err
+	}
+
+	if !valid {
+		return ErrIncorrectMFACode
+	}
+
+	err = p.Storage.EnableUserEMailOTP(u.EMail)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return nil
+}
+
+// sendEMailOTP replaces all pending one-time codes of the given user with a new one and sends it asynchronously via
+// email
+func (p Provider) sendEMailOTP(u storage.User) error {
+	code, err := generateEMailOTP()
+	if err != nil {
+		return fmt.Errorf("failed to generate email one-time code: %w", err)
+	}
+
+	err = p.Storage.DeleteTokensByEMailAndType(u.EMail, storage.TokenTypeEMailOTP)
+	if err != nil {
+		return fmt.Errorf("failed to delete previous email one-time codes: %w", err)
+	}
+
+	err = p.Storage.CreateToken(&storage.Token{
+		EMail:     u.EMail,
+		Token:     code,
+		Type:      storage.TokenTypeEMailOTP,
+		ExpiresAt: timeNow().Add(p.EMailOTPLifetime),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to persist email one-time code: %w", err)
+	}
+
+	sendAsync(func() {
+		err := p.Mailer.SendMFACodeEMail(u.EMail, code, u.Claims)
+		if err != nil {
+			logrus.WithError(err).WithField("email", u.EMail).Error("Failed to send mfa code email")
+		}
+	})
+
+	return nil
+}
+
+// verifyEMailOTP checks the given code against the pending one-time code of the given email and consumes it when it is
+// correct. Incorrect codes count as attempt, after EMailOTPMaxAttempts the pending code will be invalidated.
+func (p Provider) verifyEMailOTP(email, code string) (bool, error) {
+	tokens, err := p.Storage.TokensByEMailAndType(email, storage.TokenTypeEMailOTP)
+	if err != nil {
+		return false, fmt.Errorf("failed to find email one-time codes: %w", err)
+	}
+
+	var t *storage.Token
+	for i := len(tokens) - 1; i >= 0; i-- {
+		if !p.isExpired(tokens[i]) {
+			t = &tokens[i]
+			break
+		}
+	}
+
+	if t == nil {
+		return false, nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(t.Token), []byte(code)) != 1 {
+		attempts, err := p.Storage.RegisterTokenAttempt(t.ID)
+		if err != nil && !errors.Is(err, storage.ErrTokenNotFound) {
+			return false, fmt.Errorf("failed to register email one-time code attempt: %w", err)
+		}
+
+		if err == nil && p.EMailOTPMaxAttempts > 0 && attempts >= p.EMailOTPMaxAttempts {
+			err = p.Storage.ConsumeToken(t.ID)
+			if err != nil && !errors.Is(err, storage.ErrTokenNotFound) {
+				return false, fmt.Errorf("failed to invalidate email one-time code: %w", err)
+			}
+		}
+
+		return false, nil
+	}
+
+	err = p.Storage.ConsumeToken(t.ID)
+	if errors.Is(err, storage.ErrTokenNotFound) {
+		// the code has been used concurrently
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to consume email one-time code: %w", err)
+	}
+
+	return true, nil
+}
+
+// generateEMailOTP generates a random numeric one-time code
+var generateEMailOTP = func() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < emailOTPDigits; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%0*d", emailOTPDigits, n), nil
+}
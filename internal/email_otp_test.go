@@ -0,0 +1,298 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestProvider_Login_EMailOTPRequired(t *testing.T) {
+	oldGenerateHEXToken := generateHEXToken
+	oldGenerateEMailOTP := generateEMailOTP
+	oldSendAsync := sendAsync
+	defer func() {
+		generateHEXToken = oldGenerateHEXToken
+		generateEMailOTP = oldGenerateEMailOTP
+		sendAsync = oldSendAsync
+	}()
+	generateHEXToken = func() (string, error) {
+		return "mfa-token", nil
+	}
+	generateEMailOTP = func() (string, error) {
+		return "123456", nil
+	}
+	sendAsync = func(send func()) {
+		send()
+	}
+
+	passwordHash, _ := testPasswordHasher.Hash("password")
+	var createdTokens []storage.Token
+	var sentCode string
+	toTest := Provider{
+		PasswordHasher:   testPasswordHasher,
+		MFATokenLifetime: 5 * time.Minute,
+		EMailOTPEnabled:  true,
+		EMailOTPLifetime: 10 * time.Minute,
+		LoginLockout:     LoginLockout{MaxFailures: 5, Duration: time.Minute, MaxDuration: time.Hour},
+		Storage: &StorageMock{
+			LoginFailureFunc: func(key string) (storage.LoginFailure, error) {
+				return storage.LoginFailure{}, nil
+			},
+			UserFunc: func(email string) (storage.User, error) {
+				return storage.User{EMail: email, Password: passwordHash, EMailOTPEnabled: true}, nil
+			},
+			CreateTokenFunc: func(t *storage.Token) error {
+				createdTokens = append(createdTokens, *t)
+				return nil
+			},
+			DeleteTokensByEMailAndTypeFunc: func(email, tokenType string) error {
+				return nil
+			},
+		},
+		Mailer: &MailerMock{
+			SendMFACodeEMailFunc: func(recipient, code string, claims map[string]interface{}) error {
+				sentCode = code
+				return nil
+			},
+		},
+	}
+
+	_, _, err := toTest.Login("test@test.test", "password", ClientInfo{})
+
+	var mfaErr MFARequiredError
+	if !errors.As(err, &mfaErr) {
+		t.Fatalf("Processing error should be a MFARequiredError but was: %#v", err)
+	}
+
+	expectedErr := MFARequiredError{Token: "mfa-token", Methods: []string{MFAMethodEMail}}
+	if !reflect.DeepEqual(mfaErr, expectedErr) {
+		t.Errorf("MFARequiredError is not as expected: \nExpected:%#v\nGiven:%#v", expectedErr, mfaErr)
+	}
+
+	if len(createdTokens) != 2 || createdTokens[1].Type != storage.TokenTypeEMailOTP || createdTokens[1].Token != "123456" {
+		t.Errorf("Persisted tokens are not as expected: %#v", createdTokens)
+	}
+
+	if sentCode != "123456" {
+		t.Errorf("Sent code is not as expected. Expected: %q, Given: %q", "123456", sentCode)
+	}
+}
+
+func TestProvider_EnrollEMailOTP(t *testing.T) {
+	oldGenerateEMailOTP := generateEMailOTP
+	oldSendAsync := sendAsync
+	oldTimeNow := timeNow
+	defer func() {
+		generateEMailOTP = oldGenerateEMailOTP
+		sendAsync = oldSendAsync
+		timeNow = oldTimeNow
+	}()
+	generateEMailOTP = func() (string, error) {
+		return "123456", nil
+	}
+	sendAsync = func(send func()) {
+		send()
+	}
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+
+	tests := []struct {
+		name          string
+		disabled      bool
+		user          storage.User
+		expectedError error
+		expectedSent  bool
+	}{
+		{
+			name:         "Happycase",
+			user:         storage.User{EMail: "test@test.test"},
+			expectedSent: true,
+		}, {
+			name:          "Already enabled",
+			user:          storage.User{EMail: "test@test.test", EMailOTPEnabled: true},
+			expectedError: ErrMFAAlreadyEnabled,
+		}, {
+			name:          "Disabled",
+			disabled:      true,
+			user:          storage.User{EMail: "test@test.test"},
+			expectedError: ErrEMailOTPDisabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var createdToken *storage.Token
+			var deletedTokenType, sentCode string
+			toTest := Provider{
+				EMailOTPEnabled:  !tt.disabled,
+				EMailOTPLifetime: 10 * time.Minute,
+				JWTProvider:      validAccessTokenJWTProvider(),
+				Storage: &StorageMock{
+					IsTokenRevokedFunc: func(jit string) (bool, error) {
+						return false, nil
+					},
+					UserFunc: func(email string) (storage.User, error) {
+						return tt.user, nil
+					},
+					DeleteTokensByEMailAndTypeFunc: func(email, tokenType string) error {
+						deletedTokenType = tokenType
+						return nil
+					},
+					CreateTokenFunc: func(t *storage.Token) error {
+						createdToken = t
+						return nil
+					},
+				},
+				Mailer: &MailerMock{
+					SendMFACodeEMailFunc: func(recipient, code string, claims map[string]interface{}) error {
+						sentCode = code
+						return nil
+					},
+				},
+			}
+
+			err := toTest.EnrollEMailOTP("access-token")
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:%s\nGiven:%s", tt.expectedError, err)
+			}
+
+			if !tt.expectedSent {
+				return
+			}
+
+			if deletedTokenType != storage.TokenTypeEMailOTP {
+				t.Errorf("Pending email one-time codes should have been deleted. Deleted type: %q", deletedTokenType)
+			}
+
+			expectedToken := &storage.Token{EMail: "test@test.test", Token: "123456", Type: storage.TokenTypeEMailOTP, ExpiresAt: now.Add(10 * time.Minute)}
+			if !reflect.DeepEqual(createdToken, expectedToken) {
+				t.Errorf("Persisted token is not as expected: \nExpected:%#v\nGiven:%#v", expectedToken, createdToken)
+			}
+
+			if sentCode != "123456" {
+				t.Errorf("Sent code is not as expected. Expected: %q, Given: %q", "123456", sentCode)
+			}
+		})
+	}
+}
+
+func TestProvider_ConfirmEMailOTP(t *testing.T) {
+	validCode := storage.Token{Type: storage.TokenTypeEMailOTP, Token: "123456", ExpiresAt: time.Now().Add(time.Minute)}
+	validCode.ID = 42
+
+	tests := []struct {
+		name             string
+		disabled         bool
+		user             storage.User
+		tokens           []storage.Token
+		code             string
+		attempts         int
+		expectedError    error
+		expectedConsumed bool
+		expectedEnabled  bool
+	}{
+		{
+			name:             "Happycase",
+			user:             storage.User{EMail: "test@test.test"},
+			tokens:           []storage.Token{validCode},
+			code:             "123456",
+			expectedConsumed: true,
+			expectedEnabled:  true,
+		}, {
+			name:          "Already enabled",
+			user:          storage.User{EMail: "test@test.test", EMailOTPEnabled: true},
+			tokens:        []storage.Token{validCode},
+			code:          "123456",
+			expectedError: ErrMFAAlreadyEnabled,
+		}, {
+			name:          "No pending code",
+			user:          storage.User{EMail: "test@test.test"},
+			code:          "123456",
+			expectedError: ErrIncorrectMFACode,
+		}, {
+			name:          "Incorrect code",
+			user:          storage.User{EMail: "test@test.test"},
+			tokens:        []storage.Token{validCode},
+			code:          "654321",
+			attempts:      1,
+			expectedError: ErrIncorrectMFACode,
+		}, {
+			name:             "Incorrect code with too many attempts",
+			user:             storage.User{EMail: "test@test.test"},
+			tokens:           []storage.Token{validCode},
+			code:             "654321",
+			attempts:         3,
+			expectedError:    ErrIncorrectMFACode,
+			expectedConsumed: true,
+		}, {
+			name:          "Disabled",
+			disabled:      true,
+			user:          storage.User{EMail: "test@test.test"},
+			tokens:        []storage.Token{validCode},
+			code:          "123456",
+			expectedError: ErrEMailOTPDisabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var consumedID uint
+			var enabled bool
+			toTest := Provider{
+				EMailOTPEnabled:     !tt.disabled,
+				EMailOTPMaxAttempts: 3,
+				JWTProvider:         validAccessTokenJWTProvider(),
+				Storage: &StorageMock{
+					IsTokenRevokedFunc: func(jit string) (bool, error) {
+						return false, nil
+					},
+					UserFunc: func(email string) (storage.User, error) {
+						return tt.user, nil
+					},
+					TokensByEMailAndTypeFunc: func(email, tokenType string) ([]storage.Token, error) {
+						return tt.tokens, nil
+					},
+					RegisterTokenAttemptFunc: func(id uint) (int, error) {
+						return tt.attempts, nil
+					},
+					ConsumeTokenFunc: func(id uint) error {
+						consumedID = id
+						return nil
+					},
+					EnableUserEMailOTPFunc: func(email string) error {
+						enabled = email == tt.user.EMail
+						return nil
+					},
+				},
+			}
+
+			err := toTest.ConfirmEMailOTP("access-token", tt.code)
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:%s\nGiven:%s", tt.expectedError, err)
+			}
+
+			if (consumedID == 42) != tt.expectedConsumed {
+				t.Errorf("Code consumption is not as expected. Expected: %t, Given: %t", tt.expectedConsumed, consumedID == 42)
+			}
+
+			if enabled != tt.expectedEnabled {
+				t.Errorf("Email one-time codes enabled is not as expected. Expected: %t, Given: %t", tt.expectedEnabled, enabled)
+			}
+		})
+	}
+}
+
+func TestGenerateEMailOTP(t *testing.T) {
+	code, err := generateEMailOTP()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if !regexp.MustCompile(`^[0-9]{6}$`).MatchString(code) {
+		t.Errorf("Code should consist of 6 digits. Given: %q", code)
+	}
+}
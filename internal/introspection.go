@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+)
+
+// accessTokenType is the value of the 'typ' claim of each access-token
+const accessTokenType = "access"
+
+// Introspect checks whether the given access- or refresh-token is active and returns its claims. Refresh-tokens are
+// only active as long as they are persisted, so used refresh-tokens are inactive even if their signature is valid.
+// Access-tokens are inactive when they have been revoked. Tokens without 'typ' claim have been issued before access-tokens
+// got one, they are only active as long as they are persisted refresh-tokens.
+// Inactive tokens will be reported via active = false and not as error.
+func (p Provider) Introspect(token string) (active bool, claims map[string]interface{}, err error) {
+	isValid, tokenClaims, err := p.JWTProvider.IsTokenValid(token)
+	if err != nil || !isValid {
+		return false, nil, nil
+	}
+
+	switch tokenClaims["typ"] {
+	case accessTokenType:
+		tokenID, _ := tokenClaims["jit"].(string)
+
+		revoked, err := p.Storage.IsTokenRevoked(tokenID)
+		if err != nil {
+			return false, nil, fmt.Errorf("failed to check revocation of access-token: %w", err)
+		}
+
+		if revoked {
+			return false, nil, nil
+		}
+	case storage.TokenTypeRefresh, nil:
+		email, _ := tokenClaims["email"].(string)
+		tokenID, _ := tokenClaims["jit"].(string)
+
+		tokens, err := p.Storage.TokensByEMailAndToken(email, tokenID)
+		if err != nil {
+			return false, nil, fmt.Errorf("failed to find refresh-tokens: %w", err)
+		}
+
+		if !containsTokenType(tokens, storage.TokenTypeRefresh) {
+			return false, nil, nil
+		}
+
+		// legacy refresh-tokens must not be accepted as access-tokens
+		tokenClaims["typ"] = storage.TokenTypeRefresh
+	default:
+		return false, nil, nil
+	}
+
+	return true, tokenClaims, nil
+}
+
+// accessTokenEMail returns the email of the user of the given access-token.
+// return ErrInvalidToken when the token is not an active access-token
+func (p Provider) accessTokenEMail(accessToken string) (string, error) {
+	active, claims, err := p.Introspect(accessToken)
+	if err != nil {
+		return "", err
+	}
+
+	if !active || claims["typ"] != accessTokenType {
+		return "", ErrInvalidToken
+	}
+
+	email, ok := claims["email"].(string)
+	if !ok {
+		return "", errors.New("email claim is not parsable as string")
+	}
+
+	return email, nil
+}
+
+func containsTokenType(tokens []storage.Token, tokenType string) bool {
+	for _, t := range tokens {
+		if t.Type == tokenType {
+			return true
+		}
+	}
+
+	return false
+}
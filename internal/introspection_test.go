@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"reflect"
+	"testing"
+)
+
+func TestProvider_Introspect(t *testing.T) {
+	tests := []struct {
+		name                          string
+		isTokenValidIsValid           bool
+		isTokenValidClaims            jwt.MapClaims
+		isTokenValidErr               error
+		tokensByEMailAndTokenTokens   []storage.Token
+		tokensByEMailAndTokenErr      error
+		isTokenRevoked                bool
+		isTokenRevokedErr             error
+		expectedTokensByEMailAndToken bool
+		expectedActive                bool
+		expectedClaims                map[string]interface{}
+		expectedError                 error
+	}{
+		{
+			name:                "Active access-token",
+			isTokenValidIsValid: true,
+			isTokenValidClaims:  jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "typ": "access"},
+			expectedActive:      true,
+			expectedClaims:      map[string]interface{}{"email": "test@test.test", "jit": "jwt-id", "typ": "access"},
+		}, {
+			name:                "Revoked access-token",
+			isTokenValidIsValid: true,
+			isTokenValidClaims:  jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "typ": "access"},
+			isTokenRevoked:      true,
+		}, {
+			name:                "Failed to check revocation",
+			isTokenValidIsValid: true,
+			isTokenValidClaims:  jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "typ": "access"},
+			isTokenRevokedErr:   errors.New("nope"),
+			expectedError:       errors.New("failed to check revocation of access-token: nope"),
+		}, {
+			name:                          "Active refresh-token",
+			isTokenValidIsValid:           true,
+			isTokenValidClaims:            jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "typ": "refresh"},
+			tokensByEMailAndTokenTokens:   []storage.Token{{EMail: "test@test.test", Token: "jwt-id", Type: storage.TokenTypeRefresh}},
+			expectedTokensByEMailAndToken: true,
+			expectedActive:                true,
+			expectedClaims:                map[string]interface{}{"email": "test@test.test", "jit": "jwt-id", "typ": "refresh"},
+		}, {
+			name:                          "Used refresh-token",
+			isTokenValidIsValid:           true,
+			isTokenValidClaims:            jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "typ": "refresh"},
+			tokensByEMailAndTokenTokens:   []storage.Token{{EMail: "test@test.test", Token: "jwt-id", Type: storage.TokenTypeReset}},
+			expectedTokensByEMailAndToken: true,
+		}, {
+			name:                          "Active legacy refresh-token without typ",
+			isTokenValidIsValid:           true,
+			isTokenValidClaims:            jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id"},
+			tokensByEMailAndTokenTokens:   []storage.Token{{EMail: "test@test.test", Token: "jwt-id", Type: storage.TokenTypeRefresh}},
+			expectedTokensByEMailAndToken: true,
+			expectedActive:                true,
+			expectedClaims:                map[string]interface{}{"email": "test@test.test", "jit": "jwt-id", "typ": "refresh"},
+		}, {
+			name:                          "Legacy token without typ which is not persisted",
+			isTokenValidIsValid:           true,
+			isTokenValidClaims:            jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id"},
+			expectedTokensByEMailAndToken: true,
+		}, {
+			name:                "Token with unknown typ",
+			isTokenValidIsValid: true,
+			isTokenValidClaims:  jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "typ": "mfa"},
+		}, {
+			name:            "Invalid token",
+			isTokenValidErr: errors.New("signature is invalid"),
+		}, {
+			name:                "Expired token",
+			isTokenValidIsValid: false,
+		}, {
+			name:                          "Storage error",
+			isTokenValidIsValid:           true,
+			isTokenValidClaims:            jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "typ": "refresh"},
+			tokensByEMailAndTokenErr:      errors.New("nope"),
+			expectedTokensByEMailAndToken: true,
+			expectedError:                 errors.New("failed to find refresh-tokens: nope"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokensByEMailAndTokenCalled := false
+
+			toTest := Provider{
+				JWTProvider: &JWTProviderMock{
+					IsTokenValidFunc: func(token string) (bool, jwt.MapClaims, error) {
+						if token != "myToken" {
+							t.Errorf("unexpected token. Expected: %q, Given: %q", "myToken", token)
+						}
+						return tt.isTokenValidIsValid, tt.isTokenValidClaims, tt.isTokenValidErr
+					},
+				},
+				Storage: &StorageMock{
+					TokensByEMailAndTokenFunc: func(email string, token string) ([]storage.Token, error) {
+						tokensByEMailAndTokenCalled = true
+						if email != "test@test.test" || token != "jwt-id" {
+							t.Errorf("unexpected token query. Given email: %q, token: %q", email, token)
+						}
+						return tt.tokensByEMailAndTokenTokens, tt.tokensByEMailAndTokenErr
+					},
+					IsTokenRevokedFunc: func(jit string) (bool, error) {
+						if jit != "jwt-id" {
+							t.Errorf("unexpected jit. Expected: %q, Given: %q", "jwt-id", jit)
+						}
+						return tt.isTokenRevoked, tt.isTokenRevokedErr
+					},
+				},
+			}
+
+			active, claims, err := toTest.Introspect("myToken")
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Unexpected error. Expected: %q, Given: %q", tt.expectedError, err)
+			}
+
+			if active != tt.expectedActive {
+				t.Errorf("Unexpected active. Expected: %t, Given: %t", tt.expectedActive, active)
+			}
+
+			if tt.expectedClaims != nil && !reflect.DeepEqual(map[string]interface{}(claims), tt.expectedClaims) {
+				t.Errorf("Unexpected claims. Expected: %#v, Given: %#v", tt.expectedClaims, claims)
+			}
+
+			if tokensByEMailAndTokenCalled != tt.expectedTokensByEMailAndToken {
+				t.Errorf("Unexpected TokensByEMailAndToken call. Expected: %t, Given: %t", tt.expectedTokensByEMailAndToken, tokensByEMailAndTokenCalled)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package jwk
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// Key is the public representation of a signing key as described in https://tools.ietf.org/html/rfc7517
+type Key struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// Set is a set of keys as described in https://tools.ietf.org/html/rfc7517#section-5
+type Set struct {
+	Keys []Key `json:"keys"`
+}
+
+// New builds the public signature Key of the given public key. The kid is the JWK thumbprint
+// (https://tools.ietf.org/html/rfc7638) of the key, so it stays stable as long as the key does not change.
+func New(publicKey crypto.PublicKey, alg string) (Key, error) {
+	var key Key
+	var thumbprintMembers interface{}
+
+	switch pub := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		params := pub.Curve.Params()
+		size := (params.BitSize + 7) / 8
+
+		key = Key{
+			Kty: "EC",
+			Crv: params.Name,
+			X:   base64.RawURLEncoding.EncodeToString(padLeft(pub.X.Bytes(), size)),
+			Y:   base64.RawURLEncoding.EncodeToString(padLeft(pub.Y.Bytes(), size)),
+		}
+
+		// required members in lexicographic order, see https://tools.ietf.org/html/rfc7638#section-3.2
+		thumbprintMembers = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{Crv: key.Crv, Kty: key.Kty, X: key.X, Y: key.Y}
+	case *rsa.PublicKey:
+		key = Key{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}
+
+		thumbprintMembers = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{E: key.E, Kty: key.Kty, N: key.N}
+	case ed25519.PublicKey:
+		// see https://tools.ietf.org/html/rfc8037#section-2
+		key = Key{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}
+
+		thumbprintMembers = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{Crv: key.Crv, Kty: key.Kty, X: key.X}
+	default:
+		return Key{}, fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+
+	thumbprintInput, err := json.Marshal(thumbprintMembers)
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to build thumbprint: %w", err)
+	}
+
+	thumbprint := sha256.Sum256(thumbprintInput)
+	key.Kid = base64.RawURLEncoding.EncodeToString(thumbprint[:])
+	key.Use = "sig"
+	key.Alg = alg
+
+	return key, nil
+}
+
+func padLeft(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded
+}
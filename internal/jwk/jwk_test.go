@@ -0,0 +1,153 @@
+package jwk
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+var ecdsaPubKey = `-----BEGIN PUBLIC KEY-----
+MIGbMBAGByqGSM49AgEGBSuBBAAjA4GGAAQBQSa/dFpXRqz6aQQmx6sNpxl3mn8Z
+0o+qgfgOxPAPxu+JppsCGqrX/6SeUI6kz3AFVABGBU8/9Ejzt7Ty9WJt1dEB+035
+03+xLnmmyaj3bEhkerr229mDgPb8uDlPEl6f/Wv+Ma/eIIloCo8WJAe8YsviImbF
+hAV1NK8+62/iMCfNj30=
+-----END PUBLIC KEY-----
+`
+
+func TestNew_ECDSA(t *testing.T) {
+	pubKey := decodePubKey(t, ecdsaPubKey).(*ecdsa.PublicKey)
+
+	key, err := New(pubKey, "ES512")
+	if err != nil {
+		t.Fatalf("failed to build jwk: %s", err)
+	}
+
+	if key.Kty != "EC" || key.Crv != "P-521" || key.Alg != "ES512" || key.Use != "sig" {
+		t.Errorf("unexpected jwk metadata: %#v", key)
+	}
+
+	expectedKid := "INfVytcYGpdUZOXdTXJICJDn6mgY8bTBBuTkM6I8x90"
+	if key.Kid != expectedKid {
+		t.Errorf("unexpected kid. Expected: %q, Given: %q", expectedKid, key.Kid)
+	}
+
+	if x := decodeBase64URLInt(t, key.X); x.Cmp(pubKey.X) != 0 {
+		t.Errorf("unexpected x coordinate. Expected: %s, Given: %s", pubKey.X, x)
+	}
+
+	if y := decodeBase64URLInt(t, key.Y); y.Cmp(pubKey.Y) != 0 {
+		t.Errorf("unexpected y coordinate. Expected: %s, Given: %s", pubKey.Y, y)
+	}
+}
+
+func TestNew_RSA(t *testing.T) {
+	// example key of https://tools.ietf.org/html/rfc7638#section-3.1
+	n := "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"
+	pubKey := &rsa.PublicKey{N: decodeBase64URLInt(t, n), E: 65537}
+
+	key, err := New(pubKey, "RS256")
+	if err != nil {
+		t.Fatalf("failed to build jwk: %s", err)
+	}
+
+	expectedKey := Key{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: "RS256",
+		Kid: "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs",
+		N:   n,
+		E:   "AQAB",
+	}
+	if !reflect.DeepEqual(key, expectedKey) {
+		t.Errorf("unexpected jwk. Expected:\n%#v\nGiven:\n%#v", expectedKey, key)
+	}
+}
+
+func TestNew_Ed25519(t *testing.T) {
+	// example key of https://tools.ietf.org/html/rfc8037#appendix-A.3
+	x := "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"
+	pubKey, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil {
+		t.Fatalf("failed to decode public key: %s", err)
+	}
+
+	key, err := New(ed25519.PublicKey(pubKey), "EdDSA")
+	if err != nil {
+		t.Fatalf("failed to build jwk: %s", err)
+	}
+
+	expectedKey := Key{
+		Kty: "OKP",
+		Use: "sig",
+		Alg: "EdDSA",
+		Kid: "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k",
+		Crv: "Ed25519",
+		X:   x,
+	}
+	if !reflect.DeepEqual(key, expectedKey) {
+		t.Errorf("unexpected jwk. Expected:\n%#v\nGiven:\n%#v", expectedKey, key)
+	}
+}
+
+func TestNew_UnsupportedKey(t *testing.T) {
+	_, err := New("no key", "none")
+
+	expectedError := errors.New("unsupported public key type string")
+	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
+		t.Errorf("Unexpected error. Expected: %q, Given: %q", expectedError, err)
+	}
+}
+
+func TestPadLeft(t *testing.T) {
+	tests := []struct {
+		name     string
+		given    []byte
+		size     int
+		expected []byte
+	}{
+		{name: "shorter", given: []byte{1, 2}, size: 4, expected: []byte{0, 0, 1, 2}},
+		{name: "exact", given: []byte{1, 2}, size: 2, expected: []byte{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			given := padLeft(tt.given, tt.size)
+			if !reflect.DeepEqual(given, tt.expected) {
+				t.Errorf("unexpected result. Expected: %v, Given: %v", tt.expected, given)
+			}
+		})
+	}
+}
+
+func decodePubKey(t *testing.T, pemEncodedPub string) interface{} {
+	t.Helper()
+	block, _ := pem.Decode([]byte(pemEncodedPub))
+	if block == nil {
+		t.Fatal("no valid public key found")
+	}
+
+	pubKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse public key: %s", err)
+	}
+
+	return pubKey
+}
+
+func decodeBase64URLInt(t *testing.T, s string) *big.Int {
+	t.Helper()
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatalf("failed to decode %q: %s", s, err)
+	}
+
+	return new(big.Int).SetBytes(b)
+}
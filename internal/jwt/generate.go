@@ -10,9 +10,13 @@ import (
 var timeNow = time.Now
 var uuidNewRandom = uuid.NewRandom
 
-const refreshTokenLifetime = 7 * 24 * time.Hour
+// accessTokenType is the value of the 'typ' claim of each access-token
+const accessTokenType = "access"
 
-// GenerateAccessToken generates a valid access-jwt based on the Provider.privateKey. The jwt is issued to the given email and enriched
+// refreshTokenType is the value of the 'typ' claim of each refresh-token
+const refreshTokenType = "refresh"
+
+// GenerateAccessToken generates a valid access-jwt based on the current signing key. The jwt is issued to the given email and enriched
 // with the given claims.
 // 'userClaims' can be contain all json compatible types
 func (p Provider) GenerateAccessToken(email string, userClaims map[string]interface{}) (string, error) {
@@ -39,7 +43,10 @@ func (p Provider) GenerateAccessToken(email string, userClaims map[string]interf
 	// public claims by https://www.iana.org/assignments/jwt/jwt.xhtml#claims
 	claims["email"] = email // Preferred e-mail address
 
-	token, err := jwt.NewWithClaims(p.signingMethod, claims).SignedString(p.privateKey)
+	// private claims
+	claims["typ"] = accessTokenType // distinguishes access- from refresh-tokens
+
+	token, err := p.signToken(claims)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign access-token: %w", err)
 	}
@@ -47,7 +54,7 @@ func (p Provider) GenerateAccessToken(email string, userClaims map[string]interf
 	return token, nil
 }
 
-// GenerateRefreshToken generates a valid refresh-jwt based on the Provider.privateKey. The jwt is issued to the given email.
+// GenerateRefreshToken generates a valid refresh-jwt based on the current signing key. The jwt is issued to the given email.
 func (p Provider) GenerateRefreshToken(email string) (string, string, error) {
 	now := timeNow()
 	jwtID, err := uuidNewRandom()
@@ -58,21 +65,35 @@ func (p Provider) GenerateRefreshToken(email string) (string, string, error) {
 	claims := jwt.MapClaims{}
 
 	// standard claims by https://tools.ietf.org/html/rfc7519#section-4.1
-	claims["aud"] = p.privateClaims.audience             //Audience
-	claims["exp"] = now.Add(refreshTokenLifetime).Unix() //ExpiresAt
-	claims["jit"] = jwtID.String()                       //Id
-	claims["iat"] = now.Unix()                           //IssuedAt
-	claims["iss"] = p.privateClaims.issuer               //Issuer
-	claims["nbf"] = now.Unix()                           //NotBefore
-	claims["sub"] = p.privateClaims.subject              //Subject
+	claims["aud"] = p.privateClaims.audience               //Audience
+	claims["exp"] = now.Add(p.refreshTokenLifetime).Unix() //ExpiresAt
+	claims["jit"] = jwtID.String()                         //Id
+	claims["iat"] = now.Unix()                             //IssuedAt
+	claims["iss"] = p.privateClaims.issuer                 //Issuer
+	claims["nbf"] = now.Unix()                             //NotBefore
+	claims["sub"] = p.privateClaims.subject                //Subject
 
 	// public claims by https://www.iana.org/assignments/jwt/jwt.xhtml#claims
 	claims["email"] = email // Preferred e-mail address
 
-	token, err := jwt.NewWithClaims(p.signingMethod, claims).SignedString(p.privateKey)
+	// private claims
+	claims["typ"] = refreshTokenType // distinguishes refresh- from access-tokens
+
+	token, err := p.signToken(claims)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to sign refresh-token: %w", err)
 	}
 
 	return token, jwtID.String(), nil
 }
+
+// signToken signs a token with the given claims by the current key. The kid header refers to the
+// signing key in Provider.JWKS.
+func (p Provider) signToken(claims jwt.MapClaims) (string, error) {
+	k := p.keys.signingKey()
+
+	token := jwt.NewWithClaims(k.signingMethod, claims)
+	token.Header["kid"] = k.jwk.Kid
+
+	return token.SignedString(k.privateKey)
+}
@@ -13,12 +13,12 @@ import (
 )
 
 func TestGenerator_GenerateAccessToken(t *testing.T) {
-	g, err := NewProvider(jwtPrvKey, 4*time.Hour, "audience", "issuer", "subject")
+	g, err := NewProvider(jwtPrvKey, nil, "", 4*time.Hour, 7*24*time.Hour, "audience", "issuer", "subject")
 	if err != nil {
 		t.Fatalf("failed to crreate new generator: %s", err)
 	}
 
-	generatedJWT, err := g.GenerateAccessToken("myMailAddress", map[string]interface{}{"myCustomClaim": "mialc"})
+	generatedJWT, err := g.GenerateAccessToken("myMailAddress", map[string]interface{}{"myCustomClaim": "mialc", "typ": "refresh"})
 	if err != nil {
 		t.Fatalf("failed to generate jwt: %s", err)
 	}
@@ -63,6 +63,15 @@ func TestGenerator_GenerateAccessToken(t *testing.T) {
 	if claims["email"] != expectedJWTEMail {
 		t.Errorf("unexpected email-privateClaim value. Expected: %q. Given: %q", expectedJWTEMail, claims["email"])
 	}
+
+	if claims["typ"] != "access" {
+		t.Errorf("unexpected typ-privateClaim value. Expected: %q. Given: %q", "access", claims["typ"])
+	}
+
+	expectedKid := g.JWKS().Keys[0].Kid
+	if kid := tokenHeader(t, generatedJWT)["kid"]; kid != expectedKid {
+		t.Errorf("unexpected kid-header value. Expected: %q. Given: %q", expectedKid, kid)
+	}
 }
 
 func TestGenerator_GenerateAccessToken_FailedToGenerateUUID(t *testing.T) {
@@ -82,7 +91,7 @@ func TestGenerator_GenerateAccessToken_FailedToGenerateUUID(t *testing.T) {
 }
 
 func TestGenerator_GenerateAccessToken_FailedToSignToken(t *testing.T) {
-	p, err := NewProvider(jwtPrvKey, 4*time.Hour, "audience", "issuer", "subject")
+	p, err := NewProvider(jwtPrvKey, nil, "", 4*time.Hour, 7*24*time.Hour, "audience", "issuer", "subject")
 	if err != nil {
 		t.Fatalf("failed to crreate new generator: %s", err)
 	}
@@ -98,7 +107,7 @@ func TestGenerator_GenerateAccessToken_FailedToSignToken(t *testing.T) {
 }
 
 func TestGenerator_GenerateRefreshToken(t *testing.T) {
-	g, err := NewProvider(jwtPrvKey, 4*time.Hour, "audience", "issuer", "subject")
+	g, err := NewProvider(jwtPrvKey, nil, "", 4*time.Hour, 7*24*time.Hour, "audience", "issuer", "subject")
 	if err != nil {
 		t.Fatalf("failed to crreate new generator: %s", err)
 	}
@@ -147,6 +156,19 @@ func TestGenerator_GenerateRefreshToken(t *testing.T) {
 	if claims["email"] != expectedJWTEMail {
 		t.Errorf("unexpected email-privateClaim value. Expected: %q. Given: %q", expectedJWTEMail, claims["email"])
 	}
+
+	if lifetime := claims["exp"].(float64) - claims["iat"].(float64); lifetime != (7 * 24 * time.Hour).Seconds() {
+		t.Errorf("unexpected refresh-token lifetime. Expected: %v. Given: %v", (7 * 24 * time.Hour).Seconds(), lifetime)
+	}
+
+	if claims["typ"] != "refresh" {
+		t.Errorf("unexpected typ-privateClaim value. Expected: %q. Given: %q", "refresh", claims["typ"])
+	}
+
+	expectedKid := g.JWKS().Keys[0].Kid
+	if kid := tokenHeader(t, generatedJWT)["kid"]; kid != expectedKid {
+		t.Errorf("unexpected kid-header value. Expected: %q. Given: %q", expectedKid, kid)
+	}
 }
 
 func TestGenerator_GenerateRefreshToken_FailedToGenerateUUID(t *testing.T) {
@@ -189,6 +211,15 @@ func validateJWT(t *testing.T, tokenString string) jwt.MapClaims {
 	return claims
 }
 
+func tokenHeader(t *testing.T, tokenString string) map[string]interface{} {
+	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("Failed to parse jwt: %s", err)
+	}
+
+	return token.Header
+}
+
 func decodeECDSAPubKey(pemEncodedPub string) (*ecdsa.PublicKey, error) {
 	blockPub, _ := pem.Decode([]byte(pemEncodedPub))
 	if blockPub == nil {
@@ -0,0 +1,280 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"github.com/leberKleber/simple-jwt-provider/internal/jwk"
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+// keySourceFilePrefix marks a key source as path to a PEM file instead of the PEM encoded key itself
+const keySourceFilePrefix = "file:"
+
+var errNoValidKey = errors.New("no valid PEM encoded key found")
+
+var ioutilReadFile = ioutil.ReadFile
+var x509ParseECPrivateKey = x509.ParseECPrivateKey
+
+// key is a parsed key of a keyRing. privateKey is nil for verification-only keys.
+type key struct {
+	jwk           jwk.Key
+	signingMethod jwt.SigningMethod
+	privateKey    interface{}
+	publicKey     interface{}
+}
+
+// keyRing holds the current signing key and all previous keys which are still accepted for token validation.
+// All keys will be (re)loaded from their sources via load.
+type keyRing struct {
+	privateKeySource   string
+	previousKeySources []string
+	algorithm          string
+
+	mu      sync.RWMutex
+	current key
+	keys    []key
+}
+
+func newKeyRing(privateKeySource string, previousKeySources []string, algorithm string) (*keyRing, error) {
+	r := &keyRing{
+		privateKeySource:   privateKeySource,
+		previousKeySources: previousKeySources,
+		algorithm:          algorithm,
+	}
+
+	err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// load (re)loads all keys from their sources. The key ring stays untouched when one of the keys could not be loaded.
+func (r *keyRing) load() error {
+	current, err := loadKey(r.privateKeySource, r.algorithm)
+	if errors.Is(err, errNoValidKey) || (err == nil && current.privateKey == nil) {
+		return errors.New("no valid private key found")
+	} else if err != nil {
+		return err
+	}
+
+	if r.algorithm != "" && current.signingMethod.Alg() != r.algorithm {
+		return fmt.Errorf("algorithm %q is not supported by the private-key", r.algorithm)
+	}
+
+	keys := []key{current}
+	for i, source := range r.previousKeySources {
+		if strings.TrimSpace(source) == "" {
+			continue
+		}
+
+		previous, err := loadKey(source, r.algorithm)
+		if err != nil {
+			return fmt.Errorf("failed to load previous key %d: %w", i, err)
+		}
+
+		if containsKid(keys, previous.jwk.Kid) {
+			continue
+		}
+
+		// previous keys are only used to verify tokens
+		previous.privateKey = nil
+		keys = append(keys, previous)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.current = current
+	r.keys = keys
+
+	return nil
+}
+
+// signingKey returns the current key which should be used to sign new tokens
+func (r *keyRing) signingKey() key {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.current
+}
+
+// verificationKey returns the key identified by kid. Tokens without kid have been signed before kid were introduced
+// and will be verified with the current key.
+func (r *keyRing) verificationKey(kid string) (key, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if kid == "" {
+		return r.current, true
+	}
+
+	for _, k := range r.keys {
+		if k.jwk.Kid == kid {
+			return k, true
+		}
+	}
+
+	return key{}, false
+}
+
+// jwks returns the public part of all keys, starting with the current one
+func (r *keyRing) jwks() jwk.Set {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	set := jwk.Set{Keys: make([]jwk.Key, 0, len(r.keys))}
+	for _, k := range r.keys {
+		set.Keys = append(set.Keys, k.jwk)
+	}
+
+	return set
+}
+
+// algorithms returns the distinct signing algorithms of all keys, starting with the one of the current key
+func (r *keyRing) algorithms() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var algs []string
+	seen := map[string]bool{}
+	for _, k := range r.keys {
+		alg := k.signingMethod.Alg()
+		if seen[alg] {
+			continue
+		}
+		seen[alg] = true
+		algs = append(algs, alg)
+	}
+
+	return algs
+}
+
+func containsKid(keys []key, kid string) bool {
+	for _, k := range keys {
+		if k.jwk.Kid == kid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadKey loads a PEM encoded private or public key. The source can be the key itself or a path to a file which
+// contains the key prefixed with 'file:'. Supported are ECDSA (SEC 1 / PKCS#8), RSA (PKCS#1 / PKCS#8) and Ed25519
+// (PKCS#8) private keys and PKIX public keys. The signing method will be chosen by the given algorithm or, when empty or
+// incompatible with the key type, detected from the key.
+func loadKey(source, algorithm string) (key, error) {
+	if strings.HasPrefix(source, keySourceFilePrefix) {
+		path := strings.TrimPrefix(source, keySourceFilePrefix)
+		content, err := ioutilReadFile(path)
+		if err != nil {
+			return key{}, fmt.Errorf("failed to read key file %q: %w", path, err)
+		}
+		source = string(content)
+	}
+
+	source = strings.Replace(source, `\n`, "\n", -1) //TODO fix me (needed for start via ide)
+	block, _ := pem.Decode([]byte(source))
+	if block == nil {
+		return key{}, errNoValidKey
+	}
+
+	var k key
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY":
+		k.publicKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return key{}, fmt.Errorf("failed to parse public-key: %w", err)
+		}
+	case "EC PRIVATE KEY":
+		k.privateKey, err = x509ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		k.privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		k.privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return key{}, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return key{}, fmt.Errorf("failed to parse private-key: %w", err)
+	}
+
+	if k.privateKey != nil {
+		k.publicKey = publicKeyOf(k.privateKey)
+	}
+
+	k.signingMethod, err = signingMethodFor(k.publicKey, algorithm)
+	if err != nil {
+		return key{}, err
+	}
+
+	publicJWK, err := jwk.New(k.publicKey, k.signingMethod.Alg())
+	if err != nil {
+		return key{}, fmt.Errorf("failed to build jwk: %w", err)
+	}
+	k.jwk = publicJWK
+
+	return k, nil
+}
+
+func publicKeyOf(privateKey interface{}) interface{} {
+	switch pKey := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		return &pKey.PublicKey
+	case *rsa.PrivateKey:
+		return &pKey.PublicKey
+	case ed25519.PrivateKey:
+		return pKey.Public()
+	}
+
+	return nil
+}
+
+// signingMethodFor returns the signing method of the given algorithm when it is compatible with the key type, otherwise
+// the default signing method of the key type.
+func signingMethodFor(publicKey interface{}, algorithm string) (jwt.SigningMethod, error) {
+	var compatible []jwt.SigningMethod
+
+	switch pubKey := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		// ECDSA algorithms are bound to the curve, see https://tools.ietf.org/html/rfc7518#section-3.4
+		switch pubKey.Curve {
+		case elliptic.P256():
+			compatible = []jwt.SigningMethod{jwt.SigningMethodES256}
+		case elliptic.P384():
+			compatible = []jwt.SigningMethod{jwt.SigningMethodES384}
+		case elliptic.P521():
+			compatible = []jwt.SigningMethod{jwt.SigningMethodES512}
+		default:
+			return nil, fmt.Errorf("unsupported ecdsa curve %q", pubKey.Curve.Params().Name)
+		}
+	case *rsa.PublicKey:
+		compatible = []jwt.SigningMethod{
+			jwt.SigningMethodRS256, jwt.SigningMethodRS384, jwt.SigningMethodRS512,
+			jwt.SigningMethodPS256, jwt.SigningMethodPS384, jwt.SigningMethodPS512,
+		}
+	case ed25519.PublicKey:
+		compatible = []jwt.SigningMethod{SigningMethodEd25519}
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", publicKey)
+	}
+
+	for _, signingMethod := range compatible {
+		if signingMethod.Alg() == algorithm {
+			return signingMethod, nil
+		}
+	}
+
+	return compatible[0], nil
+}
@@ -0,0 +1,165 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNewProvider_Algorithms(t *testing.T) {
+	ecdsaP256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ecdsa key: %s", err)
+	}
+
+	ecdsaP384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ecdsa key: %s", err)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate rsa key: %s", err)
+	}
+
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ed25519 key: %s", err)
+	}
+
+	ecdsaP256SEC1, err := x509.MarshalECPrivateKey(ecdsaP256Key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %s", err)
+	}
+
+	tests := []struct {
+		name        string
+		privateKey  string
+		algorithm   string
+		expectedAlg string
+		expectedKty string
+		expectedErr error
+	}{
+		{
+			name:        "ECDSA P-256 SEC 1",
+			privateKey:  encodePEM("EC PRIVATE KEY", ecdsaP256SEC1),
+			expectedAlg: "ES256",
+			expectedKty: "EC",
+		}, {
+			name:        "ECDSA P-384 PKCS#8",
+			privateKey:  encodePEM("PRIVATE KEY", marshalPKCS8(t, ecdsaP384Key)),
+			expectedAlg: "ES384",
+			expectedKty: "EC",
+		}, {
+			name:        "RSA PKCS#1",
+			privateKey:  encodePEM("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)),
+			expectedAlg: "RS256",
+			expectedKty: "RSA",
+		}, {
+			name:        "RSA PKCS#8 with explicit algorithm",
+			privateKey:  encodePEM("PRIVATE KEY", marshalPKCS8(t, rsaKey)),
+			algorithm:   "PS384",
+			expectedAlg: "PS384",
+			expectedKty: "RSA",
+		}, {
+			name:        "Ed25519 PKCS#8",
+			privateKey:  encodePEM("PRIVATE KEY", marshalPKCS8(t, ed25519Key)),
+			algorithm:   "EdDSA",
+			expectedAlg: "EdDSA",
+			expectedKty: "OKP",
+		}, {
+			name:        "Algorithm not supported by key",
+			privateKey:  encodePEM("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)),
+			algorithm:   "ES512",
+			expectedErr: errors.New("algorithm \"ES512\" is not supported by the private-key"),
+		}, {
+			name:        "Unsupported PEM block",
+			privateKey:  encodePEM("CERTIFICATE", []byte("nope")),
+			expectedErr: errors.New("unsupported PEM block type \"CERTIFICATE\""),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewProvider(tt.privateKey, nil, tt.algorithm, time.Minute, 7*24*time.Hour, "audience", "issuer", "subject")
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedErr) {
+				t.Fatalf("Unexpected error. Expected: %q, Given: %q", tt.expectedErr, err)
+			} else if err != nil {
+				return
+			}
+
+			token, err := p.GenerateAccessToken("my.mail@test.de", nil)
+			if err != nil {
+				t.Fatalf("failed to generate token: %s", err)
+			}
+
+			if alg := tokenHeader(t, token)["alg"]; alg != tt.expectedAlg {
+				t.Errorf("unexpected alg header. Expected: %q, Given: %q", tt.expectedAlg, alg)
+			}
+
+			isValid, _, err := p.IsTokenValid(token)
+			if err != nil || !isValid {
+				t.Errorf("generated token should be valid. Error: %v", err)
+			}
+
+			jwks := p.JWKS()
+			if jwks.Keys[0].Alg != tt.expectedAlg || jwks.Keys[0].Kty != tt.expectedKty {
+				t.Errorf("unexpected jwk. Expected alg %q and kty %q, Given: %#v", tt.expectedAlg, tt.expectedKty, jwks.Keys[0])
+			}
+		})
+	}
+}
+
+func TestSigningMethodEdDSA(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ed25519 key: %s", err)
+	}
+
+	signature, err := SigningMethodEd25519.Sign("my.signing.string", privateKey)
+	if err != nil {
+		t.Fatalf("failed to sign: %s", err)
+	}
+
+	err = SigningMethodEd25519.Verify("my.signing.string", signature, publicKey)
+	if err != nil {
+		t.Errorf("signature should be valid: %s", err)
+	}
+
+	err = SigningMethodEd25519.Verify("other.signing.string", signature, publicKey)
+	if fmt.Sprint(err) != "signature is invalid" {
+		t.Errorf("Unexpected error. Expected: %q, Given: %q", "signature is invalid", err)
+	}
+
+	_, err = SigningMethodEd25519.Sign("my.signing.string", "no key")
+	if fmt.Sprint(err) != "key is of invalid type" {
+		t.Errorf("Unexpected error. Expected: %q, Given: %q", "key is of invalid type", err)
+	}
+
+	err = SigningMethodEd25519.Verify("my.signing.string", signature, "no key")
+	if fmt.Sprint(err) != "key is of invalid type" {
+		t.Errorf("Unexpected error. Expected: %q, Given: %q", "key is of invalid type", err)
+	}
+}
+
+func marshalPKCS8(t *testing.T, privateKey interface{}) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %s", err)
+	}
+
+	return der
+}
+
+func encodePEM(blockType string, der []byte) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}))
+}
@@ -0,0 +1,170 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewProvider_PreviousKeys(t *testing.T) {
+	oldProvider, err := NewProvider(jwtPrvKey, nil, "", time.Minute, 7*24*time.Hour, "audience", "issuer", "subject")
+	if err != nil {
+		t.Fatalf("failed to create provider: %s", err)
+	}
+
+	oldToken, _, err := oldProvider.GenerateRefreshToken("my.mail@test.de")
+	if err != nil {
+		t.Fatalf("failed to generate token: %s", err)
+	}
+
+	tests := []struct {
+		name         string
+		previousKeys []string
+	}{
+		{name: "previous private key", previousKeys: []string{jwtPrvKey}},
+		{name: "previous public key", previousKeys: []string{jwtPubKey}},
+		{name: "blank keys will be ignored", previousKeys: []string{"", jwtPubKey}},
+		{name: "duplicated keys will be ignored", previousKeys: []string{jwtPubKey, jwtPrvKey}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewProvider(generatePrivateKey(t), tt.previousKeys, "", time.Minute, 7*24*time.Hour, "audience", "issuer", "subject")
+			if err != nil {
+				t.Fatalf("failed to create provider: %s", err)
+			}
+
+			isValid, _, err := p.IsTokenValid(oldToken)
+			if err != nil || !isValid {
+				t.Errorf("token signed by previous key should be valid. Error: %v", err)
+			}
+
+			newToken, _, err := p.GenerateRefreshToken("my.mail@test.de")
+			if err != nil {
+				t.Fatalf("failed to generate token: %s", err)
+			}
+
+			_, _, err = oldProvider.IsTokenValid(newToken)
+			if err == nil {
+				t.Error("token signed by new key should not be valid for old provider")
+			}
+
+			jwks := p.JWKS()
+			if len(jwks.Keys) != 2 {
+				t.Fatalf("unexpected amount of keys. Expected: 2, Given: %d", len(jwks.Keys))
+			}
+
+			if jwks.Keys[1].Kid != oldProvider.JWKS().Keys[0].Kid {
+				t.Errorf("previous key should be published. Expected kid: %q, Given: %q", oldProvider.JWKS().Keys[0].Kid, jwks.Keys[1].Kid)
+			}
+		})
+	}
+}
+
+func TestNewProvider_InvalidPreviousKey(t *testing.T) {
+	_, err := NewProvider(jwtPrvKey, []string{"nope"}, "", time.Minute, 7*24*time.Hour, "audience", "issuer", "subject")
+
+	expectedError := errors.New("failed to load previous key 0: no valid PEM encoded key found")
+	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
+		t.Errorf("Unexpected error. Expected: %q, Given: %q", expectedError, err)
+	}
+}
+
+func TestNewProvider_PublicKeyAsPrivateKey(t *testing.T) {
+	_, err := NewProvider(jwtPubKey, nil, "", time.Minute, 7*24*time.Hour, "audience", "issuer", "subject")
+
+	expectedError := errors.New("no valid private key found")
+	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
+		t.Errorf("Unexpected error. Expected: %q, Given: %q", expectedError, err)
+	}
+}
+
+func TestNewProvider_KeyFileNotReadable(t *testing.T) {
+	oldIOUtilReadFile := ioutilReadFile
+	defer func() { ioutilReadFile = oldIOUtilReadFile }()
+
+	ioutilReadFile = func(filename string) ([]byte, error) {
+		return nil, errors.New("nope")
+	}
+
+	_, err := NewProvider("file:/my/key.pem", nil, "", time.Minute, 7*24*time.Hour, "audience", "issuer", "subject")
+
+	expectedError := errors.New("failed to read key file \"/my/key.pem\": nope")
+	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
+		t.Errorf("Unexpected error. Expected: %q, Given: %q", expectedError, err)
+	}
+}
+
+func TestProvider_ReloadKeys(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	writeFile(t, keyFile, jwtPrvKey)
+
+	p, err := NewProvider("file:"+keyFile, nil, "", time.Minute, 7*24*time.Hour, "audience", "issuer", "subject")
+	if err != nil {
+		t.Fatalf("failed to create provider: %s", err)
+	}
+	oldKid := p.JWKS().Keys[0].Kid
+
+	// broken key files will not be applied
+	writeFile(t, keyFile, "broken")
+	err = p.ReloadKeys()
+	expectedError := errors.New("failed to reload keys: no valid private key found")
+	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
+		t.Errorf("Unexpected error. Expected: %q, Given: %q", expectedError, err)
+	}
+
+	if kid := p.JWKS().Keys[0].Kid; kid != oldKid {
+		t.Errorf("keys should stay untouched on failed reload. Expected kid: %q, Given: %q", oldKid, kid)
+	}
+
+	writeFile(t, keyFile, generatePrivateKey(t))
+	err = p.ReloadKeys()
+	if err != nil {
+		t.Fatalf("failed to reload keys: %s", err)
+	}
+
+	token, _, err := p.GenerateRefreshToken("my.mail@test.de")
+	if err != nil {
+		t.Fatalf("failed to generate token: %s", err)
+	}
+
+	kid := tokenHeader(t, token)["kid"]
+	if kid == oldKid {
+		t.Error("token should be signed by reloaded key")
+	}
+
+	if kid != p.JWKS().Keys[0].Kid {
+		t.Errorf("unexpected kid. Expected: %q, Given: %q", p.JWKS().Keys[0].Kid, kid)
+	}
+}
+
+func generatePrivateKey(t *testing.T) string {
+	t.Helper()
+	pKey, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate private key: %s", err)
+	}
+
+	der, err := x509.MarshalECPrivateKey(pKey)
+	if err != nil {
+		t.Fatalf("failed to marshal private key: %s", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	err := ioutil.WriteFile(path, []byte(content), 0600)
+	if err != nil {
+		t.Fatalf("failed to write file %q: %s", path, err)
+	}
+}
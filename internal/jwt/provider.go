@@ -1,49 +1,40 @@
 package jwt
 
 import (
-	"crypto/ecdsa"
-	"crypto/x509"
-	"encoding/pem"
-	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt"
-	"reflect"
-	"strings"
+	"github.com/leberKleber/simple-jwt-provider/internal/jwk"
 	"time"
 )
 
-var x509ParseECPrivateKey = x509.ParseECPrivateKey
-
 // Provider should be created via NewProvider and creates JWTs via Generate with static and custom claims
 type Provider struct {
-	jwtLifetime   time.Duration
-	privateKey    *ecdsa.PrivateKey
-	signingMethod *jwt.SigningMethodECDSA
-	privateClaims struct {
+	jwtLifetime          time.Duration
+	refreshTokenLifetime time.Duration
+	keys                 *keyRing
+	privateClaims        struct {
 		audience string
 		issuer   string
 		subject  string
 	}
 }
 
-// NewProvider a Provider instance with the given jwt-configuration. Before instantiation the private key will be
-// checked and parsed
-func NewProvider(privateKey string, jwtLifetime time.Duration, jwtAudience, jwtIssuer, jwtSubject string) (*Provider, error) {
-	privateKey = strings.Replace(privateKey, `\n`, "\n", -1) //TODO fix me (needed for start via ide)
-	blockPrv, _ := pem.Decode([]byte(privateKey))
-	if blockPrv == nil {
-		return nil, errors.New("no valid private key found")
-	}
-
-	pKey, err := x509ParseECPrivateKey(blockPrv.Bytes)
+// NewProvider a Provider instance with the given jwt-configuration. Before instantiation the private key and all
+// previous keys will be checked and parsed. Keys can be given PEM encoded or as path to a PEM file prefixed with 'file:'.
+// The private key will be used to sign new tokens, previous keys are only used to validate tokens.
+// 'algorithm' is the JWA (https://tools.ietf.org/html/rfc7518#section-3.1) name of the signing algorithm e.g. ES512,
+// RS256 or EdDSA. When empty, the algorithm will be detected from the private key.
+// 'jwtLifetime' is the lifetime of access-tokens, 'refreshTokenLifetime' the one of refresh-tokens.
+func NewProvider(privateKey string, previousKeys []string, algorithm string, jwtLifetime, refreshTokenLifetime time.Duration, jwtAudience, jwtIssuer, jwtSubject string) (*Provider, error) {
+	keys, err := newKeyRing(privateKey, previousKeys, algorithm)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse private-key: %w", err)
+		return nil, err
 	}
 
 	return &Provider{
-		jwtLifetime:   jwtLifetime,
-		privateKey:    pKey,
-		signingMethod: jwt.SigningMethodES512,
+		jwtLifetime:          jwtLifetime,
+		refreshTokenLifetime: refreshTokenLifetime,
+		keys:                 keys,
 		privateClaims: struct {
 			audience string
 			issuer   string
@@ -53,17 +44,54 @@ func NewProvider(privateKey string, jwtLifetime time.Duration, jwtAudience, jwtI
 			issuer:   jwtIssuer,
 			subject:  jwtSubject,
 		},
-	}, err
+	}, nil
+}
+
+// ReloadKeys reloads the private key and all previous keys from their sources. When one of the keys could not be
+// loaded all keys stay untouched.
+func (p Provider) ReloadKeys() error {
+	err := p.keys.load()
+	if err != nil {
+		return fmt.Errorf("failed to reload keys: %w", err)
+	}
+
+	return nil
+}
+
+// JWKS returns the public keys which can be used to verify all tokens generated by this Provider
+func (p Provider) JWKS() jwk.Set {
+	return p.keys.jwks()
+}
+
+// AccessTokenLifetime returns the lifetime of each generated access-token
+func (p Provider) AccessTokenLifetime() time.Duration {
+	return p.jwtLifetime
+}
+
+// Issuer returns the configured issuer which will be applied in each token
+func (p Provider) Issuer() string {
+	return p.privateClaims.issuer
 }
 
-var checkSigningMethodKeyFunc = func(signingMethod jwt.SigningMethod, publicKey *ecdsa.PublicKey) jwt.Keyfunc {
+// SigningAlgorithms returns the algorithms of all keys which will be used to sign or validate tokens
+func (p Provider) SigningAlgorithms() []string {
+	return p.keys.algorithms()
+}
+
+var checkSigningMethodKeyFunc = func(keys *keyRing) jwt.Keyfunc {
 	return func(token *jwt.Token) (interface{}, error) {
-		tokenSigningMethod := reflect.TypeOf(token.Method)
-		expectedSigningMethod := reflect.TypeOf(signingMethod)
+		kid, _ := token.Header["kid"].(string)
+		k, found := keys.verificationKey(kid)
+		if !found {
+			return nil, fmt.Errorf("unknown kid %q", kid)
+		}
+
+		tokenSigningMethod := token.Method.Alg()
+		expectedSigningMethod := k.signingMethod.Alg()
 		if tokenSigningMethod != expectedSigningMethod {
 			return nil, fmt.Errorf("unexpected signing method %q, expected: %q", tokenSigningMethod, expectedSigningMethod)
 		}
 
-		return publicKey, nil
+		return k.publicKey, nil
 	}
 }
@@ -2,10 +2,15 @@ package jwt
 
 import (
 	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt"
+	"github.com/leberKleber/simple-jwt-provider/internal/jwk"
 	"math/big"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -26,7 +31,7 @@ r94giWgKjxYkB7xiy+IiZsWEBXU0rz7rb+IwJ82PfQ==
 -----END EC PRIVATE KEY-----`
 
 func TestNewGenerator_WithoutPrivateKey(t *testing.T) {
-	_, err := NewProvider("", 4*time.Hour, "audience", "issuer", "subject")
+	_, err := NewProvider("", nil, "", 4*time.Hour, 7*24*time.Hour, "audience", "issuer", "subject")
 
 	expectedError := errors.New("no valid private key found")
 	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
@@ -42,7 +47,7 @@ func TestNewGenerator_InvalidPrivateKey(t *testing.T) {
 		return nil, errors.New("errrooooooorrrr")
 	}
 
-	_, err := NewProvider(jwtPrvKey, 4*time.Hour, "audience", "issuer", "subject")
+	_, err := NewProvider(jwtPrvKey, nil, "", 4*time.Hour, 7*24*time.Hour, "audience", "issuer", "subject")
 
 	expectedError := errors.New("failed to parse private-key: errrooooooorrrr")
 	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
@@ -51,37 +56,72 @@ func TestNewGenerator_InvalidPrivateKey(t *testing.T) {
 }
 
 func TestCheckSigningMethodKeyFunc(t *testing.T) {
+	currentKey := key{
+		jwk:           jwk.Key{Kid: "current"},
+		signingMethod: jwt.SigningMethodES512,
+		publicKey:     &ecdsa.PublicKey{X: big.NewInt(555), Y: big.NewInt(666)},
+	}
+	previousKey := key{
+		jwk:           jwk.Key{Kid: "previous"},
+		signingMethod: jwt.SigningMethodES512,
+		publicKey:     &ecdsa.PublicKey{X: big.NewInt(777), Y: big.NewInt(888)},
+	}
+	keys := &keyRing{current: currentKey, keys: []key{currentKey, previousKey}}
+
 	tests := []struct {
-		name               string
-		givenSigningMethod jwt.SigningMethod
-		givenPublicKey     *ecdsa.PublicKey
-		givenToken         *jwt.Token
-		expectedResponse   interface{}
-		expectedErr        error
+		name             string
+		givenToken       *jwt.Token
+		expectedResponse interface{}
+		expectedErr      error
 	}{
 		{
-			name:               "Happycase",
-			givenSigningMethod: jwt.SigningMethodES512,
-			givenPublicKey:     &ecdsa.PublicKey{X: big.NewInt(555), Y: big.NewInt(666)},
+			name: "Happycase",
 			givenToken: &jwt.Token{
+				Header: map[string]interface{}{"kid": "current"},
 				Method: jwt.SigningMethodES512,
 			},
 			expectedResponse: &ecdsa.PublicKey{X: big.NewInt(555), Y: big.NewInt(666)},
 		}, {
-			name:               "Unexpected signing method",
-			givenSigningMethod: jwt.SigningMethodES512,
-			givenPublicKey:     &ecdsa.PublicKey{X: big.NewInt(555), Y: big.NewInt(666)},
+			name: "Previous key",
 			givenToken: &jwt.Token{
-				Method: jwt.SigningMethodPS256,
+				Header: map[string]interface{}{"kid": "previous"},
+				Method: jwt.SigningMethodES512,
+			},
+			expectedResponse: &ecdsa.PublicKey{X: big.NewInt(777), Y: big.NewInt(888)},
+		}, {
+			name: "Without kid",
+			givenToken: &jwt.Token{
+				Header: map[string]interface{}{},
+				Method: jwt.SigningMethodES512,
 			},
-			expectedErr:      errors.New("unexpected signing method \"*jwt.SigningMethodRSAPSS\", expected: \"*jwt.SigningMethodECDSA\""),
 			expectedResponse: &ecdsa.PublicKey{X: big.NewInt(555), Y: big.NewInt(666)},
+		}, {
+			name: "Unknown kid",
+			givenToken: &jwt.Token{
+				Header: map[string]interface{}{"kid": "unknown"},
+				Method: jwt.SigningMethodES512,
+			},
+			expectedErr: errors.New("unknown kid \"unknown\""),
+		}, {
+			name: "Unexpected signing method",
+			givenToken: &jwt.Token{
+				Header: map[string]interface{}{"kid": "current"},
+				Method: jwt.SigningMethodPS256,
+			},
+			expectedErr: errors.New("unexpected signing method \"PS256\", expected: \"ES512\""),
+		}, {
+			name: "Unexpected algorithm of same signing method family",
+			givenToken: &jwt.Token{
+				Header: map[string]interface{}{"kid": "current"},
+				Method: jwt.SigningMethodES256,
+			},
+			expectedErr: errors.New("unexpected signing method \"ES256\", expected: \"ES512\""),
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			jwtKeyFunc := checkSigningMethodKeyFunc(tt.givenSigningMethod, tt.givenPublicKey)
+			jwtKeyFunc := checkSigningMethodKeyFunc(keys)
 
 			resp, err := jwtKeyFunc(tt.givenToken)
 			expectedResponseAsString := fmt.Sprint(tt.expectedResponse)
@@ -99,3 +139,50 @@ func TestCheckSigningMethodKeyFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestProvider_JWKS(t *testing.T) {
+	p, err := NewProvider(jwtPrvKey, nil, "", 4*time.Hour, 7*24*time.Hour, "audience", "issuer", "subject")
+	if err != nil {
+		t.Fatalf("failed to create new provider: %s", err)
+	}
+
+	jwks := p.JWKS()
+	if len(jwks.Keys) != 1 {
+		t.Fatalf("unexpected amount of keys. Expected: 1, Given: %d", len(jwks.Keys))
+	}
+
+	expectedKid := "INfVytcYGpdUZOXdTXJICJDn6mgY8bTBBuTkM6I8x90"
+	if jwks.Keys[0].Kid != expectedKid {
+		t.Errorf("unexpected kid. Expected: %q, Given: %q", expectedKid, jwks.Keys[0].Kid)
+	}
+
+	expectedAlg := "ES512"
+	if jwks.Keys[0].Alg != expectedAlg {
+		t.Errorf("unexpected alg. Expected: %q, Given: %q", expectedAlg, jwks.Keys[0].Alg)
+	}
+}
+
+func TestProvider_Getters(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate rsa key: %s", err)
+	}
+
+	p, err := NewProvider(jwtPrvKey, []string{generatePrivateKey(t), encodePEM("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))}, "", 4*time.Hour, 7*24*time.Hour, "audience", "issuer", "subject")
+	if err != nil {
+		t.Fatalf("failed to create new provider: %s", err)
+	}
+
+	expectedAlgorithms := []string{"ES512", "RS256"}
+	if algorithms := p.SigningAlgorithms(); !reflect.DeepEqual(algorithms, expectedAlgorithms) {
+		t.Errorf("unexpected algorithms. Expected: %q, Given: %q", expectedAlgorithms, algorithms)
+	}
+
+	if lifetime := p.AccessTokenLifetime(); lifetime != 4*time.Hour {
+		t.Errorf("unexpected access-token lifetime. Expected: %s, Given: %s", 4*time.Hour, lifetime)
+	}
+
+	if issuer := p.Issuer(); issuer != "issuer" {
+		t.Errorf("unexpected issuer. Expected: %q, Given: %q", "issuer", issuer)
+	}
+}
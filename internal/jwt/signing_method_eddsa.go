@@ -0,0 +1,53 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"github.com/golang-jwt/jwt"
+)
+
+// SigningMethodEdDSA implements the EdDSA signing method (https://tools.ietf.org/html/rfc8037#section-3.1) with
+// Ed25519 keys, which is not supported by github.com/golang-jwt/jwt
+type SigningMethodEdDSA struct{}
+
+// SigningMethodEd25519 is registered as signing method for alg EdDSA
+var SigningMethodEd25519 = &SigningMethodEdDSA{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEd25519.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEd25519
+	})
+}
+
+// Alg returns the alg identifier of this signing method
+func (m *SigningMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+// Verify verifies the given signature of signingString. key must be an ed25519.PublicKey.
+func (m *SigningMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+
+	return nil
+}
+
+// Sign signs signingString and returns the encoded signature. key must be an ed25519.PrivateKey.
+func (m *SigningMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
@@ -8,11 +8,11 @@ import (
 
 var parseFunc = jwt.ParseWithClaims
 
-// IsTokenValid validates the given token with the in NewProvider configured privateKey.PublicKeys and return
+// IsTokenValid validates the given token with the in NewProvider configured keys (selected by kid) and return
 // isValid indicator, token-claims (when token is valid) and an error when present
 // return
 func (p Provider) IsTokenValid(tokenAsString string) (isValid bool, claims jwt.MapClaims, err error) {
-	token, err := parseFunc(tokenAsString, &claims, checkSigningMethodKeyFunc(p.signingMethod, &p.privateKey.PublicKey))
+	token, err := parseFunc(tokenAsString, &claims, checkSigningMethodKeyFunc(p.keys))
 	if err != nil {
 		return false, nil, fmt.Errorf("failed to parse token: %w", err)
 	}
@@ -1,7 +1,6 @@
 package jwt
 
 import (
-	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt"
@@ -64,7 +63,7 @@ func TestProvider_Error_IsTokenValid(t *testing.T) {
 				return tt.parseFuncToken, tt.parseFuncErr
 			}
 
-			isValid, claims, err := Provider{privateKey: &ecdsa.PrivateKey{}}.IsTokenValid(tt.givenToken)
+			isValid, claims, err := Provider{keys: &keyRing{}}.IsTokenValid(tt.givenToken)
 			if fmt.Sprint(err) != fmt.Sprint(tt.expectedErr) {
 				t.Fatalf("Unexpected error. Expected: %q. Given: %q", tt.expectedErr, err)
 			} else if err != nil {
@@ -85,7 +84,7 @@ func TestProvider_Error_IsTokenValid(t *testing.T) {
 func TestProvider_IsTokenValid(t *testing.T) {
 	email := "my.mail@test.de"
 
-	provider, err := NewProvider(jwtPrvKey, time.Minute, "audience", "issuer", "subject")
+	provider, err := NewProvider(jwtPrvKey, nil, "", time.Minute, 7*24*time.Hour, "audience", "issuer", "subject")
 	if err != nil {
 		t.Fatal("failed to create provider", err)
 	}
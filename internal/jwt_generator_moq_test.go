@@ -5,7 +5,9 @@ package internal
 
 import (
 	"github.com/golang-jwt/jwt"
+	"github.com/leberKleber/simple-jwt-provider/internal/jwk"
 	"sync"
+	"time"
 )
 
 // Ensure, that JWTProviderMock does implement JWTProvider.
@@ -18,6 +20,9 @@ var _ JWTProvider = &JWTProviderMock{}
 //
 // 		// make and configure a mocked JWTProvider
 // 		mockedJWTProvider := &JWTProviderMock{
+// 			AccessTokenLifetimeFunc: func() time.Duration {
+// 				panic("mock out the AccessTokenLifetime method")
+// 			},
 // 			GenerateAccessTokenFunc: func(email string, userClaims map[string]interface{}) (string, error) {
 // 				panic("mock out the GenerateAccessToken method")
 // 			},
@@ -27,6 +32,15 @@ var _ JWTProvider = &JWTProviderMock{}
 // 			IsTokenValidFunc: func(token string) (bool, jwt.MapClaims, error) {
 // 				panic("mock out the IsTokenValid method")
 // 			},
+// 			IssuerFunc: func() string {
+// 				panic("mock out the Issuer method")
+// 			},
+// 			JWKSFunc: func() jwk.Set {
+// 				panic("mock out the JWKS method")
+// 			},
+// 			SigningAlgorithmsFunc: func() []string {
+// 				panic("mock out the SigningAlgorithms method")
+// 			},
 // 		}
 //
 // 		// use mockedJWTProvider in code that requires JWTProvider
@@ -34,6 +48,9 @@ var _ JWTProvider = &JWTProviderMock{}
 //
 // 	}
 type JWTProviderMock struct {
+	// AccessTokenLifetimeFunc mocks the AccessTokenLifetime method.
+	AccessTokenLifetimeFunc func() time.Duration
+
 	// GenerateAccessTokenFunc mocks the GenerateAccessToken method.
 	GenerateAccessTokenFunc func(email string, userClaims map[string]interface{}) (string, error)
 
@@ -43,8 +60,20 @@ type JWTProviderMock struct {
 	// IsTokenValidFunc mocks the IsTokenValid method.
 	IsTokenValidFunc func(token string) (bool, jwt.MapClaims, error)
 
+	// IssuerFunc mocks the Issuer method.
+	IssuerFunc func() string
+
+	// JWKSFunc mocks the JWKS method.
+	JWKSFunc func() jwk.Set
+
+	// SigningAlgorithmsFunc mocks the SigningAlgorithms method.
+	SigningAlgorithmsFunc func() []string
+
 	// calls tracks calls to the methods.
 	calls struct {
+		// AccessTokenLifetime holds details about calls to the AccessTokenLifetime method.
+		AccessTokenLifetime []struct {
+		}
 		// GenerateAccessToken holds details about calls to the GenerateAccessToken method.
 		GenerateAccessToken []struct {
 			// Email is the email argument value.
@@ -62,10 +91,49 @@ type JWTProviderMock struct {
 			// Token is the token argument value.
 			Token string
 		}
+		// Issuer holds details about calls to the Issuer method.
+		Issuer []struct {
+		}
+		// JWKS holds details about calls to the JWKS method.
+		JWKS []struct {
+		}
+		// SigningAlgorithms holds details about calls to the SigningAlgorithms method.
+		SigningAlgorithms []struct {
+		}
 	}
+	lockAccessTokenLifetime  sync.RWMutex
 	lockGenerateAccessToken  sync.RWMutex
 	lockGenerateRefreshToken sync.RWMutex
 	lockIsTokenValid         sync.RWMutex
+	lockIssuer               sync.RWMutex
+	lockJWKS                 sync.RWMutex
+	lockSigningAlgorithms    sync.RWMutex
+}
+
+// AccessTokenLifetime calls AccessTokenLifetimeFunc.
+func (mock *JWTProviderMock) AccessTokenLifetime() time.Duration {
+	if mock.AccessTokenLifetimeFunc == nil {
+		panic("JWTProviderMock.AccessTokenLifetimeFunc: method is nil but JWTProvider.AccessTokenLifetime was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockAccessTokenLifetime.Lock()
+	mock.calls.AccessTokenLifetime = append(mock.calls.AccessTokenLifetime, callInfo)
+	mock.lockAccessTokenLifetime.Unlock()
+	return mock.AccessTokenLifetimeFunc()
+}
+
+// AccessTokenLifetimeCalls gets all the calls that were made to AccessTokenLifetime.
+// Check the length with:
+//     len(mockedJWTProvider.AccessTokenLifetimeCalls())
+func (mock *JWTProviderMock) AccessTokenLifetimeCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockAccessTokenLifetime.RLock()
+	calls = mock.calls.AccessTokenLifetime
+	mock.lockAccessTokenLifetime.RUnlock()
+	return calls
 }
 
 // GenerateAccessToken calls GenerateAccessTokenFunc.
@@ -164,3 +232,81 @@ func (mock *JWTProviderMock) IsTokenValidCalls() []struct {
 	mock.lockIsTokenValid.RUnlock()
 	return calls
 }
+
+// Issuer calls IssuerFunc.
+func (mock *JWTProviderMock) Issuer() string {
+	if mock.IssuerFunc == nil {
+		panic("JWTProviderMock.IssuerFunc: method is nil but JWTProvider.Issuer was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockIssuer.Lock()
+	mock.calls.Issuer = append(mock.calls.Issuer, callInfo)
+	mock.lockIssuer.Unlock()
+	return mock.IssuerFunc()
+}
+
+// IssuerCalls gets all the calls that were made to Issuer.
+// Check the length with:
+//     len(mockedJWTProvider.IssuerCalls())
+func (mock *JWTProviderMock) IssuerCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockIssuer.RLock()
+	calls = mock.calls.Issuer
+	mock.lockIssuer.RUnlock()
+	return calls
+}
+
+// JWKS calls JWKSFunc.
+func (mock *JWTProviderMock) JWKS() jwk.Set {
+	if mock.JWKSFunc == nil {
+		panic("JWTProviderMock.JWKSFunc: method is nil but JWTProvider.JWKS was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockJWKS.Lock()
+	mock.calls.JWKS = append(mock.calls.JWKS, callInfo)
+	mock.lockJWKS.Unlock()
+	return mock.JWKSFunc()
+}
+
+// JWKSCalls gets all the calls that were made to JWKS.
+// Check the length with:
+//     len(mockedJWTProvider.JWKSCalls())
+func (mock *JWTProviderMock) JWKSCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockJWKS.RLock()
+	calls = mock.calls.JWKS
+	mock.lockJWKS.RUnlock()
+	return calls
+}
+
+// SigningAlgorithms calls SigningAlgorithmsFunc.
+func (mock *JWTProviderMock) SigningAlgorithms() []string {
+	if mock.SigningAlgorithmsFunc == nil {
+		panic("JWTProviderMock.SigningAlgorithmsFunc: method is nil but JWTProvider.SigningAlgorithms was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockSigningAlgorithms.Lock()
+	mock.calls.SigningAlgorithms = append(mock.calls.SigningAlgorithms, callInfo)
+	mock.lockSigningAlgorithms.Unlock()
+	return mock.SigningAlgorithmsFunc()
+}
+
+// SigningAlgorithmsCalls gets all the calls that were made to SigningAlgorithms.
+// Check the length with:
+//     len(mockedJWTProvider.SigningAlgorithmsCalls())
+func (mock *JWTProviderMock) SigningAlgorithmsCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockSigningAlgorithms.RLock()
+	calls = mock.calls.SigningAlgorithms
+	mock.lockSigningAlgorithms.RUnlock()
+	return calls
+}
@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrAccountLocked returned when logins of the requested account are locked cause of too many failed logins
+var ErrAccountLocked = errors.New("account is locked")
+
+// ErrTooManyLoginAttempts returned when logins of the requesting client are locked cause of too many failed logins
+var ErrTooManyLoginAttempts = errors.New("too many login attempts")
+
+// LockoutError is returned when a login has been locked. It wraps ErrAccountLocked or ErrTooManyLoginAttempts.
+type LockoutError struct {
+	Err   error
+	Until time.Time
+}
+
+func (e LockoutError) Error() string {
+	return fmt.Sprintf("%s until %s", e.Err, e.Until.Format(time.RFC3339))
+}
+
+// Unwrap returns the wrapped ErrAccountLocked or ErrTooManyLoginAttempts
+func (e LockoutError) Unwrap() error {
+	return e.Err
+}
+
+// LoginLockout configures the lockout of logins. After MaxFailures failed logins of an email or a client ip, their
+// logins will be locked for Duration. Each further MaxFailures failed logins double the duration up to MaxDuration.
+// Failed logins will be forgotten after MaxDuration. MaxFailures = 0 disables the lockout.
+type LoginLockout struct {
+	MaxFailures int
+	Duration    time.Duration
+	MaxDuration time.Duration
+}
+
+// UnlockUser forgets all failed logins of the user with the given email, so the user can login again
+func (p Provider) UnlockUser(email string) error {
+	err := p.Storage.ResetLoginFailures(emailLockoutKey(email))
+	if err != nil {
+		return fmt.Errorf("failed to unlock user with email %q: %w", email, err)
+	}
+
+	return nil
+}
+
+// checkLoginLockout returns a LockoutError when logins of the given email or client are locked
+func (p Provider) checkLoginLockout(email string, client ClientInfo) error {
+	if p.LoginLockout.MaxFailures <= 0 {
+		return nil
+	}
+
+	for _, lock := range p.lockoutKeys(email, client) {
+		f, err := p.Storage.LoginFailure(lock.key)
+		if err != nil {
+			return fmt.Errorf("failed to check login lockout: %w", err)
+		}
+
+		if timeNow().Before(f.LockedUntil) {
+			return LockoutError{Err: lock.err, Until: f.LockedUntil}
+		}
+	}
+
+	return nil
+}
+
+// registerLoginFailure registers a failed login of the given email and client and locks them when they exceeded the
+// maximum number of failed logins
+func (p Provider) registerLoginFailure(email string, client ClientInfo) error {
+	if p.LoginLockout.MaxFailures <= 0 {
+		return nil
+	}
+
+	now := timeNow()
+	for _, lock := range p.lockoutKeys(email, client) {
+		f, err := p.Storage.RegisterLoginFailure(lock.key, now.Add(-p.LoginLockout.MaxDuration))
+		if err != nil {
+			return fmt.Errorf("failed to register login failure: %w", err)
+		}
+
+		if f.Failures%p.LoginLockout.MaxFailures != 0 {
+			continue
+		}
+
+		err = p.Storage.LockLogin(lock.key, now.Add(p.lockoutDuration(f.Failures)))
+		if err != nil {
+			return fmt.Errorf("failed to lock login: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// lockoutDuration returns the escalating lockout duration after the given number of failures
+func (p Provider) lockoutDuration(failures int) time.Duration {
+	d := p.LoginLockout.Duration
+	for i := failures / p.LoginLockout.MaxFailures; i > 1 && d < p.LoginLockout.MaxDuration; i-- {
+		d *= 2
+	}
+
+	if d > p.LoginLockout.MaxDuration {
+		return p.LoginLockout.MaxDuration
+	}
+
+	return d
+}
+
+type lockoutKey struct {
+	key string
+	err error
+}
+
+func (p Provider) lockoutKeys(email string, client ClientInfo) []lockoutKey {
+	keys := []lockoutKey{{key: emailLockoutKey(email), err: ErrAccountLocked}}
+	if client.IP != "" {
+		keys = append(keys, lockoutKey{key: "ip:" + client.IP, err: ErrTooManyLoginAttempts})
+	}
+
+	return keys
+}
+
+// emailLockoutKey returns the lockout key of the given email. The email will be normalized, so variants of the same
+// email share their failed logins.
+func emailLockoutKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
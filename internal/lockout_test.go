@@ -0,0 +1,255 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"golang.org/x/crypto/bcrypt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProvider_Login_Lockout(t *testing.T) {
+	now := time.Date(2021, 4, 19, 12, 0, 0, 0, time.UTC)
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	timeNow = func() time.Time {
+		return now
+	}
+
+	password, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Failed to bcrypt password: %s", err)
+	}
+
+	tests := []struct {
+		name                   string
+		givenPassword          string
+		client                 ClientInfo
+		loginFailures          map[string]storage.LoginFailure
+		registeredFailures     map[string]int
+		userErr                error
+		expectedRegistered     []string
+		expectedLocks          map[string]time.Time
+		expectedReset          []string
+		expectedError          error
+		expectedLockedUntil    time.Time
+		expectedWrappedLockErr error
+	}{
+		{
+			name:          "Successful login resets failures of email",
+			givenPassword: "password",
+			client:        ClientInfo{IP: "127.0.0.1"},
+			expectedReset: []string{"email:test@test.test"},
+		}, {
+			name:                   "Locked account",
+			givenPassword:          "password",
+			client:                 ClientInfo{IP: "127.0.0.1"},
+			loginFailures:          map[string]storage.LoginFailure{"email:test@test.test": {Failures: 5, LockedUntil: now.Add(time.Minute)}},
+			expectedError:          LockoutError{Err: ErrAccountLocked, Until: now.Add(time.Minute)},
+			expectedWrappedLockErr: ErrAccountLocked,
+			expectedLockedUntil:    now.Add(time.Minute),
+		}, {
+			name:                   "Locked client",
+			givenPassword:          "password",
+			client:                 ClientInfo{IP: "127.0.0.1"},
+			loginFailures:          map[string]storage.LoginFailure{"ip:127.0.0.1": {Failures: 5, LockedUntil: now.Add(time.Minute)}},
+			expectedError:          LockoutError{Err: ErrTooManyLoginAttempts, Until: now.Add(time.Minute)},
+			expectedWrappedLockErr: ErrTooManyLoginAttempts,
+			expectedLockedUntil:    now.Add(time.Minute),
+		}, {
+			name:          "Expired lock",
+			givenPassword: "password",
+			loginFailures: map[string]storage.LoginFailure{"email:test@test.test": {Failures: 5, LockedUntil: now}},
+			expectedReset: []string{"email:test@test.test"},
+		}, {
+			name:               "Incorrect password",
+			givenPassword:      "nope",
+			client:             ClientInfo{IP: "127.0.0.1"},
+			registeredFailures: map[string]int{"email:test@test.test": 1, "ip:127.0.0.1": 1},
+			expectedRegistered: []string{"email:test@test.test", "ip:127.0.0.1"},
+			expectedError:      ErrIncorrectPassword,
+		}, {
+			name:               "Unknown user",
+			givenPassword:      "password",
+			userErr:            storage.ErrUserNotFound,
+			registeredFailures: map[string]int{"email:test@test.test": 1},
+			expectedRegistered: []string{"email:test@test.test"},
+			expectedError:      ErrUserNotFound,
+		}, {
+			name:               "Incorrect password locks after max failures",
+			givenPassword:      "nope",
+			client:             ClientInfo{IP: "127.0.0.1"},
+			registeredFailures: map[string]int{"email:test@test.test": 5, "ip:127.0.0.1": 10},
+			expectedRegistered: []string{"email:test@test.test", "ip:127.0.0.1"},
+			expectedLocks: map[string]time.Time{
+				"email:test@test.test": now.Add(time.Minute),
+				"ip:127.0.0.1":         now.Add(2 * time.Minute),
+			},
+			expectedError: ErrIncorrectPassword,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var registered, reset []string
+			locks := map[string]time.Time{}
+
+			toTest := Provider{
+				PasswordHasher: testPasswordHasher,
+				LoginLockout:   LoginLockout{MaxFailures: 5, Duration: time.Minute, MaxDuration: time.Hour},
+				Storage: &StorageMock{
+					LoginFailureFunc: func(key string) (storage.LoginFailure, error) {
+						f := tt.loginFailures[key]
+						f.Key = key
+						return f, nil
+					},
+					RegisterLoginFailureFunc: func(key string, forgetBefore time.Time) (storage.LoginFailure, error) {
+						if !forgetBefore.Equal(now.Add(-time.Hour)) {
+							t.Errorf("Unexpected forgetBefore. Expected: %s, Given: %s", now.Add(-time.Hour), forgetBefore)
+						}
+						registered = append(registered, key)
+						return storage.LoginFailure{Key: key, Failures: tt.registeredFailures[key]}, nil
+					},
+					LockLoginFunc: func(key string, until time.Time) error {
+						locks[key] = until
+						return nil
+					},
+					ResetLoginFailuresFunc: func(key string) error {
+						reset = append(reset, key)
+						return nil
+					},
+					UserFunc: func(email string) (storage.User, error) {
+						return storage.User{EMail: email, Password: password}, tt.userErr
+					},
+					CreateTokenFunc: func(t *storage.Token) error {
+						return nil
+					},
+				},
+				JWTProvider: &JWTProviderMock{
+					GenerateAccessTokenFunc: func(email string, userClaims map[string]interface{}) (string, error) {
+						return "myJWT", nil
+					},
+					GenerateRefreshTokenFunc: func(email string) (string, string, error) {
+						return "myRefreshJWT", "jwt-id", nil
+					},
+				},
+			}
+
+			_, _, err := toTest.Login("test@test.test", tt.givenPassword, tt.client)
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Unexpected error. Expected: %q, Given: %q", tt.expectedError, err)
+			}
+
+			if tt.expectedWrappedLockErr != nil {
+				var lockoutErr LockoutError
+				if !errors.As(err, &lockoutErr) || !errors.Is(err, tt.expectedWrappedLockErr) {
+					t.Fatalf("Expected LockoutError wrapping %q. Given: %#v", tt.expectedWrappedLockErr, err)
+				}
+				if !lockoutErr.Until.Equal(tt.expectedLockedUntil) {
+					t.Errorf("Unexpected lock. Expected: %s, Given: %s", tt.expectedLockedUntil, lockoutErr.Until)
+				}
+			}
+
+			if !reflect.DeepEqual(registered, tt.expectedRegistered) {
+				t.Errorf("Unexpected registered failures. Expected: %#v, Given: %#v", tt.expectedRegistered, registered)
+			}
+
+			if tt.expectedLocks == nil {
+				tt.expectedLocks = map[string]time.Time{}
+			}
+			if !reflect.DeepEqual(locks, tt.expectedLocks) {
+				t.Errorf("Unexpected locks. Expected: %#v, Given: %#v", tt.expectedLocks, locks)
+			}
+
+			if !reflect.DeepEqual(reset, tt.expectedReset) {
+				t.Errorf("Unexpected reset failures. Expected: %#v, Given: %#v", tt.expectedReset, reset)
+			}
+		})
+	}
+}
+
+func TestProvider_lockoutDuration(t *testing.T) {
+	toTest := Provider{LoginLockout: LoginLockout{MaxFailures: 5, Duration: time.Minute, MaxDuration: 10 * time.Minute}}
+
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{failures: 5, expected: time.Minute},
+		{failures: 10, expected: 2 * time.Minute},
+		{failures: 15, expected: 4 * time.Minute},
+		{failures: 20, expected: 8 * time.Minute},
+		{failures: 25, expected: 10 * time.Minute},
+		{failures: 500, expected: 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.failures), func(t *testing.T) {
+			given := toTest.lockoutDuration(tt.failures)
+			if given != tt.expected {
+				t.Errorf("Unexpected lockout duration. Expected: %s, Given: %s", tt.expected, given)
+			}
+		})
+	}
+}
+
+func TestProvider_UnlockUser(t *testing.T) {
+	tests := []struct {
+		name          string
+		resetErr      error
+		expectedError error
+	}{
+		{
+			name: "Happycase",
+		}, {
+			name:          "Unexpected db error",
+			resetErr:      errors.New("nope"),
+			expectedError: errors.New("failed to unlock user with email \"test@test.test\": nope"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenKey string
+
+			toTest := Provider{
+				Storage: &StorageMock{
+					ResetLoginFailuresFunc: func(key string) error {
+						givenKey = key
+						return tt.resetErr
+					},
+				},
+			}
+
+			err := toTest.UnlockUser("test@test.test")
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Unexpected error. Expected: %q, Given: %q", tt.expectedError, err)
+			}
+
+			if givenKey != "email:test@test.test" {
+				t.Errorf("Unexpected reset key. Expected: %q, Given: %q", "email:test@test.test", givenKey)
+			}
+		})
+	}
+}
+
+func TestEMailLockoutKey(t *testing.T) {
+	tests := []struct {
+		email       string
+		expectedKey string
+	}{
+		{email: "test@test.test", expectedKey: "email:test@test.test"},
+		{email: "Test@Test.TEST", expectedKey: "email:test@test.test"},
+		{email: " test@test.test\t", expectedKey: "email:test@test.test"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			if key := emailLockoutKey(tt.email); key != tt.expectedKey {
+				t.Errorf("Unexpected key. Expected: %q, Given: %q", tt.expectedKey, key)
+			}
+		})
+	}
+}
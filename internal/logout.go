@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+)
+
+// Logout ends the session of the given refresh-token, so it can no longer be used to refresh.
+// return ErrTokenNotParsable when the token is not parsable
+// return ErrInvalidToken when the token is not valid
+// return ErrNoValidTokenFound when the token has already been used or logged out
+func (p Provider) Logout(refreshToken string) error {
+	_, t, err := p.persistedRefreshToken(refreshToken)
+	if err != nil {
+		return err
+	}
+
+	err = p.consumeToken(t.ID)
+	if err != nil {
+		return fmt.Errorf("failed to consume refresh-token: %w", err)
+	}
+
+	return nil
+}
+
+// LogoutEverywhere ends all sessions of the user of the given refresh-token by deleting all of its refresh-tokens.
+// return ErrTokenNotParsable when the token is not parsable
+// return ErrInvalidToken when the token is not valid
+// return ErrNoValidTokenFound when the token has already been used or logged out
+func (p Provider) LogoutEverywhere(refreshToken string) error {
+	email, _, err := p.persistedRefreshToken(refreshToken)
+	if err != nil {
+		return err
+	}
+
+	err = p.Storage.DeleteTokensByEMailAndType(email, storage.TokenTypeRefresh)
+	if err != nil {
+		return fmt.Errorf("failed to delete refresh-tokens of %q: %w", email, err)
+	}
+
+	return nil
+}
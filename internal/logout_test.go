@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+func TestProvider_Logout(t *testing.T) {
+	tests := []struct {
+		name                string
+		isTokenValidIsValid bool
+		isTokenValidClaims  jwt.MapClaims
+		isTokenValidErr     error
+		tokens              []storage.Token
+		deleteTokenErr      error
+		expectedDeletedID   uint
+		expectedError       error
+	}{
+		{
+			name:                "Happycase",
+			isTokenValidIsValid: true,
+			isTokenValidClaims:  jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "typ": "refresh"},
+			tokens:              []storage.Token{{Model: gorm.Model{ID: 42}, Type: storage.TokenTypeRefresh, ExpiresAt: time.Now().Add(time.Hour)}},
+			expectedDeletedID:   42,
+		}, {
+			name:            "Token not parsable",
+			isTokenValidErr: errors.New("nope"),
+			expectedError:   fmt.Errorf("%w: nope", ErrTokenNotParsable),
+		}, {
+			name:                "Token invalid",
+			isTokenValidIsValid: false,
+			expectedError:       ErrInvalidToken,
+		}, {
+			name:                "Token already used",
+			isTokenValidIsValid: true,
+			isTokenValidClaims:  jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "typ": "refresh"},
+			expectedError:       ErrNoValidTokenFound,
+		}, {
+			name:                "Failed to delete token",
+			isTokenValidIsValid: true,
+			isTokenValidClaims:  jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "typ": "refresh"},
+			tokens:              []storage.Token{{Model: gorm.Model{ID: 42}, Type: storage.TokenTypeRefresh, ExpiresAt: time.Now().Add(time.Hour)}},
+			deleteTokenErr:      errors.New("nope"),
+			expectedDeletedID:   42,
+			expectedError:       errors.New("failed to consume refresh-token: nope"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deletedID uint
+
+			toTest := Provider{
+				JWTProvider: &JWTProviderMock{
+					IsTokenValidFunc: func(token string) (bool, jwt.MapClaims, error) {
+						return tt.isTokenValidIsValid, tt.isTokenValidClaims, tt.isTokenValidErr
+					},
+				},
+				Storage: &StorageMock{
+					TokensByEMailAndTokenFunc: func(email string, token string) ([]storage.Token, error) {
+						return tt.tokens, nil
+					},
+					ConsumedTokensByEMailAndTokenFunc: func(email string, token string) ([]storage.Token, error) {
+						return nil, nil
+					},
+					ConsumeTokenFunc: func(id uint) error {
+						deletedID = id
+						return tt.deleteTokenErr
+					},
+				},
+			}
+
+			err := toTest.Logout("myRefreshToken")
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Unexpected error. Expected: %q, Given: %q", tt.expectedError, err)
+			}
+
+			if deletedID != tt.expectedDeletedID {
+				t.Errorf("Unexpected deleted token. Expected: %d, Given: %d", tt.expectedDeletedID, deletedID)
+			}
+		})
+	}
+}
+
+func TestProvider_LogoutEverywhere(t *testing.T) {
+	tests := []struct {
+		name                      string
+		tokens                    []storage.Token
+		deleteTokensErr           error
+		expectedDeleteTokensEMail string
+		expectedError             error
+	}{
+		{
+			name:                      "Happycase",
+			tokens:                    []storage.Token{{Model: gorm.Model{ID: 42}, Type: storage.TokenTypeRefresh, ExpiresAt: time.Now().Add(time.Hour)}},
+			expectedDeleteTokensEMail: "test@test.test",
+		}, {
+			name:          "Token already used",
+			expectedError: ErrNoValidTokenFound,
+		}, {
+			name:                      "Failed to delete tokens",
+			tokens:                    []storage.Token{{Model: gorm.Model{ID: 42}, Type: storage.TokenTypeRefresh, ExpiresAt: time.Now().Add(time.Hour)}},
+			deleteTokensErr:           errors.New("nope"),
+			expectedDeleteTokensEMail: "test@test.test",
+			expectedError:             errors.New("failed to delete refresh-tokens of \"test@test.test\": nope"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deleteTokensEMail, deleteTokensType string
+
+			toTest := Provider{
+				JWTProvider: &JWTProviderMock{
+					IsTokenValidFunc: func(token string) (bool, jwt.MapClaims, error) {
+						return true, jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "typ": "refresh"}, nil
+					},
+				},
+				Storage: &StorageMock{
+					TokensByEMailAndTokenFunc: func(email string, token string) ([]storage.Token, error) {
+						return tt.tokens, nil
+					},
+					ConsumedTokensByEMailAndTokenFunc: func(email string, token string) ([]storage.Token, error) {
+						return nil, nil
+					},
+					DeleteTokensByEMailAndTypeFunc: func(email string, tokenType string) error {
+						deleteTokensEMail = email
+						deleteTokensType = tokenType
+						return tt.deleteTokensErr
+					},
+				},
+			}
+
+			err := toTest.LogoutEverywhere("myRefreshToken")
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Unexpected error. Expected: %q, Given: %q", tt.expectedError, err)
+			}
+
+			if deleteTokensEMail != tt.expectedDeleteTokensEMail {
+				t.Errorf("Unexpected email of deleted tokens. Expected: %q, Given: %q", tt.expectedDeleteTokensEMail, deleteTokensEMail)
+			}
+
+			if tt.expectedDeleteTokensEMail != "" && deleteTokensType != storage.TokenTypeRefresh {
+				t.Errorf("Unexpected type of deleted tokens. Expected: %q, Given: %q", storage.TokenTypeRefresh, deleteTokensType)
+			}
+		})
+	}
+}
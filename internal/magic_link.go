@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrMagicLinkDisabled returned when logins via magic links have not been enabled
+var ErrMagicLinkDisabled = errors.New("magic-link disabled")
+
+// CreateMagicLink sends a magic-link email with a one-time login token to the given address. The email will be sent
+// asynchronously, so the response time does not reveal whether the user exists.
+// return ErrMagicLinkDisabled when logins via magic links have not been enabled
+// return ErrUserNotFound when user does not exists
+// return ErrEMailNotVerified when the user has registered itself and not verified the email yet
+func (p Provider) CreateMagicLink(email string) error {
+	if !p.MagicLinkEnabled {
+		return ErrMagicLinkDisabled
+	}
+
+	u, err := p.Storage.User(email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to find user with email %q: %w", email, err)
+	}
+
+	if u.EMailUnverified {
+		return ErrEMailNotVerified
+	}
+
+	t, err := generateHEXToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate magic-link token: %w", err)
+	}
+
+	err = p.Storage.CreateToken(&storage.Token{
+		EMail:     email,
+		Token:     t,
+		Type:      storage.TokenTypeMagicLink,
+		ExpiresAt: timeNow().Add(p.MagicLinkLifetime),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create magic-link token for email %q: %w", email, err)
+	}
+
+	sendAsync(func() {
+		err := p.Mailer.SendMagicLinkEMail(email, t, u.Claims)
+		if err != nil {
+			logrus.WithError(err).WithField("email", email).Error("Failed to send magic-link email")
+		}
+	})
+
+	return nil
+}
+
+// RedeemMagicLink consumes the given magic-link token and returns a new access and refresh token like Login. The given
+// client will be recorded as the client of the new session. Invalid tokens count as failed logins.
+// return ErrMagicLinkDisabled when logins via magic links have not been enabled
+// return ErrNoValidTokenFound when the token is unknown, expired or has already been redeemed
+// return LockoutError when logins of the email or the client are locked cause of too many failed logins
+// return MFARequiredError instead of tokens when the user has to pass the multi-factor authentication via VerifyMFA
+func (p Provider) RedeemMagicLink(email, magicLinkToken string, client ClientInfo) (accessToken, refreshToken string, err error) {
+	if !p.MagicLinkEnabled {
+		return "", "", ErrMagicLinkDisabled
+	}
+
+	err = p.checkLoginLockout(email, client)
+	if err != nil {
+		return "", "", err
+	}
+
+	tokens, err := p.Storage.TokensByEMailAndToken(email, magicLinkToken)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find magic-link tokens: %w", err)
+	}
+
+	var t *storage.Token
+	for i := range tokens {
+		if tokens[i].Type == storage.TokenTypeMagicLink && !p.isExpired(tokens[i]) {
+			t = &tokens[i]
+			break
+		}
+	}
+
+	if t == nil {
+		return "", "", p.loginFailed(email, client, ErrNoValidTokenFound)
+	}
+
+	err = p.consumeToken(t.ID)
+	if err != nil {
+		if errors.Is(err, ErrNoValidTokenFound) {
+			// the token has been redeemed concurrently
+			return "", "", p.loginFailed(email, client, err)
+		}
+		return "", "", fmt.Errorf("failed to consume magic-link token: %w", err)
+	}
+
+	u, err := p.Storage.User(email)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find user with email %q: %w", email, err)
+	}
+
+	if len(mfaMethods(u)) > 0 {
+		// the magic-link only proves the access to the mailbox, so it replaces the password but not the second factor
+		return "", "", p.mfaRequired(u)
+	}
+
+	err = p.resetLoginFailures(email)
+	if err != nil {
+		return "", "", err
+	}
+
+	return p.issueTokens(email, u.Claims, client)
+}
@@ -0,0 +1,304 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProvider_CreateMagicLink(t *testing.T) {
+	now := time.Date(2021, 4, 19, 12, 0, 0, 0, time.UTC)
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	timeNow = func() time.Time {
+		return now
+	}
+	oldSendAsync := sendAsync
+	defer func() { sendAsync = oldSendAsync }()
+	sendAsync = func(send func()) {
+		send()
+	}
+	oldGenerateHEXToken := generateHEXToken
+	defer func() { generateHEXToken = oldGenerateHEXToken }()
+
+	tests := []struct {
+		name                     string
+		disabled                 bool
+		givenEMail               string
+		dbUserReturnError        error
+		dbUserUnverified         bool
+		dbCreateTokenReturnError error
+		generateHEXTokenError    error
+		mailerError              error
+		expectedError            error
+		expectedToken            storage.Token
+		expectedMailRecipient    string
+		expectedMailToken        string
+	}{
+		{
+			name:       "Happycase",
+			givenEMail: "test@test.test",
+			expectedToken: storage.Token{
+				EMail:     "test@test.test",
+				Token:     "magic-link-token",
+				Type:      storage.TokenTypeMagicLink,
+				ExpiresAt: now.Add(15 * time.Minute),
+			},
+			expectedMailRecipient: "test@test.test",
+			expectedMailToken:     "magic-link-token",
+		}, {
+			name:              "User not found",
+			givenEMail:        "not@existing.user",
+			dbUserReturnError: storage.ErrUserNotFound,
+			expectedError:     ErrUserNotFound,
+		}, {
+			name:              "Unexpected db error while finding user",
+			givenEMail:        "test@test.test",
+			dbUserReturnError: errors.New("random error"),
+			expectedError:     errors.New("failed to find user with email \"test@test.test\": random error"),
+		}, {
+			name:             "Unverified email",
+			givenEMail:       "test@test.test",
+			dbUserUnverified: true,
+			expectedError:    ErrEMailNotVerified,
+		}, {
+			name:                  "Unable to generate HEX token",
+			givenEMail:            "test@test.test",
+			generateHEXTokenError: errors.New("random error"),
+			expectedError:         errors.New("failed to generate magic-link token: random error"),
+		}, {
+			name:                     "Unexpected db error while create token",
+			givenEMail:               "test@test.test",
+			dbCreateTokenReturnError: errors.New("random error"),
+			expectedError:            errors.New("failed to create magic-link token for email \"test@test.test\": random error"),
+			expectedToken: storage.Token{
+				EMail:     "test@test.test",
+				Token:     "magic-link-token",
+				Type:      storage.TokenTypeMagicLink,
+				ExpiresAt: now.Add(15 * time.Minute),
+			},
+		}, {
+			name:        "Mailer error",
+			givenEMail:  "test@test.test",
+			mailerError: errors.New("random error"),
+			expectedToken: storage.Token{
+				EMail:     "test@test.test",
+				Token:     "magic-link-token",
+				Type:      storage.TokenTypeMagicLink,
+				ExpiresAt: now.Add(15 * time.Minute),
+			},
+			expectedMailRecipient: "test@test.test",
+			expectedMailToken:     "magic-link-token",
+		}, {
+			name:          "Disabled",
+			disabled:      true,
+			givenEMail:    "test@test.test",
+			expectedError: ErrMagicLinkDisabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generateHEXToken = func() (string, error) {
+				return "magic-link-token", tt.generateHEXTokenError
+			}
+
+			var createdToken storage.Token
+			var mailRecipient, mailToken string
+			toTest := Provider{
+				MagicLinkEnabled:  !tt.disabled,
+				MagicLinkLifetime: 15 * time.Minute,
+				Storage: &StorageMock{
+					UserFunc: func(email string) (storage.User, error) {
+						return storage.User{EMail: email, EMailUnverified: tt.dbUserUnverified}, tt.dbUserReturnError
+					},
+					CreateTokenFunc: func(t *storage.Token) error {
+						createdToken = *t
+						return tt.dbCreateTokenReturnError
+					},
+				},
+				Mailer: &MailerMock{
+					SendMagicLinkEMailFunc: func(recipient string, magicLinkToken string, claims map[string]interface{}) error {
+						mailRecipient = recipient
+						mailToken = magicLinkToken
+						return tt.mailerError
+					},
+				},
+			}
+
+			err := toTest.CreateMagicLink(tt.givenEMail)
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:\n%s\nGiven:\n%s", tt.expectedError, err)
+			}
+
+			if !reflect.DeepEqual(createdToken, tt.expectedToken) {
+				t.Errorf("The storage token to create is not as expected: \nExpected:\n%#v\nGiven:\n%#v", tt.expectedToken, createdToken)
+			}
+
+			if mailRecipient != tt.expectedMailRecipient || mailToken != tt.expectedMailToken {
+				t.Errorf("The sent mail is not as expected. Expected: %q, %q Given: %q, %q", tt.expectedMailRecipient, tt.expectedMailToken, mailRecipient, mailToken)
+			}
+		})
+	}
+}
+
+func TestProvider_RedeemMagicLink(t *testing.T) {
+	validToken := storage.Token{EMail: "test@test.test", Token: "magic-link-token", Type: storage.TokenTypeMagicLink, ExpiresAt: time.Now().Add(time.Minute)}
+	validToken.ID = 42
+	expiredToken := validToken
+	expiredToken.ExpiresAt = time.Now().Add(-time.Minute)
+	resetToken := validToken
+	resetToken.Type = storage.TokenTypeReset
+
+	tests := []struct {
+		name              string
+		disabled          bool
+		tokens            []storage.Token
+		tokensErr         error
+		consumeTokenErr   error
+		user              storage.User
+		lockedUntil       time.Time
+		expectedError     error
+		expectedFailure   bool
+		expectedConsumed  uint
+		expectedTokenType string
+	}{
+		{
+			name:              "Happycase",
+			tokens:            []storage.Token{validToken},
+			user:              storage.User{EMail: "test@test.test"},
+			expectedConsumed:  42,
+			expectedTokenType: storage.TokenTypeRefresh,
+		}, {
+			name:            "Unknown token",
+			user:            storage.User{EMail: "test@test.test"},
+			expectedError:   ErrNoValidTokenFound,
+			expectedFailure: true,
+		}, {
+			name:            "Expired token",
+			tokens:          []storage.Token{expiredToken},
+			user:            storage.User{EMail: "test@test.test"},
+			expectedError:   ErrNoValidTokenFound,
+			expectedFailure: true,
+		}, {
+			name:            "Reset token",
+			tokens:          []storage.Token{resetToken},
+			user:            storage.User{EMail: "test@test.test"},
+			expectedError:   ErrNoValidTokenFound,
+			expectedFailure: true,
+		}, {
+			name:             "Token redeemed concurrently",
+			tokens:           []storage.Token{validToken},
+			consumeTokenErr:  storage.ErrTokenNotFound,
+			user:             storage.User{EMail: "test@test.test"},
+			expectedError:    ErrNoValidTokenFound,
+			expectedFailure:  true,
+			expectedConsumed: 42,
+		}, {
+			name:              "MFA required",
+			tokens:            []storage.Token{validToken},
+			user:              storage.User{EMail: "test@test.test", TOTPEnabled: true},
+			expectedError:     ErrMFARequired,
+			expectedConsumed:  42,
+			expectedTokenType: storage.TokenTypeMFA,
+		}, {
+			name:          "Locked",
+			tokens:        []storage.Token{validToken},
+			user:          storage.User{EMail: "test@test.test"},
+			lockedUntil:   time.Now().Add(time.Minute),
+			expectedError: ErrAccountLocked,
+		}, {
+			name:          "Unexpected db error while finding tokens",
+			tokensErr:     errors.New("random error"),
+			expectedError: errors.New("failed to find magic-link tokens: random error"),
+		}, {
+			name:          "Disabled",
+			disabled:      true,
+			tokens:        []storage.Token{validToken},
+			user:          storage.User{EMail: "test@test.test"},
+			expectedError: ErrMagicLinkDisabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var registeredFailures int
+			var consumedTokenID uint
+			var createdToken *storage.Token
+			toTest := Provider{
+				MagicLinkEnabled: !tt.disabled,
+				MFATokenLifetime: time.Minute,
+				LoginLockout:     LoginLockout{MaxFailures: 5, Duration: time.Minute, MaxDuration: time.Hour},
+				JWTProvider: &JWTProviderMock{
+					GenerateAccessTokenFunc: func(email string, userClaims map[string]interface{}) (string, error) {
+						return "access-token", nil
+					},
+					GenerateRefreshTokenFunc: func(email string) (string, string, error) {
+						return "refresh-token", "jwt-id", nil
+					},
+				},
+				Storage: &StorageMock{
+					LoginFailureFunc: func(key string) (storage.LoginFailure, error) {
+						return storage.LoginFailure{LockedUntil: tt.lockedUntil}, nil
+					},
+					RegisterLoginFailureFunc: func(key string, forgetBefore time.Time) (storage.LoginFailure, error) {
+						registeredFailures++
+						return storage.LoginFailure{Failures: 1}, nil
+					},
+					ResetLoginFailuresFunc: func(key string) error {
+						return nil
+					},
+					TokensByEMailAndTokenFunc: func(email, token string) ([]storage.Token, error) {
+						return tt.tokens, tt.tokensErr
+					},
+					ConsumeTokenFunc: func(id uint) error {
+						consumedTokenID = id
+						return tt.consumeTokenErr
+					},
+					UserFunc: func(email string) (storage.User, error) {
+						return tt.user, nil
+					},
+					CreateTokenFunc: func(t *storage.Token) error {
+						createdToken = t
+						return nil
+					},
+				},
+			}
+
+			accessToken, refreshToken, err := toTest.RedeemMagicLink("test@test.test", "magic-link-token", ClientInfo{IP: "127.0.0.1"})
+			if tt.expectedError != nil && !errors.Is(err, tt.expectedError) && fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:%s\nGiven:%s", tt.expectedError, err)
+			}
+			if tt.expectedError == nil && err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if tt.expectedFailure != (registeredFailures > 0) {
+				t.Errorf("Login failure registration is not as expected. Expected: %t, Given failures: %d", tt.expectedFailure, registeredFailures)
+			}
+
+			if consumedTokenID != tt.expectedConsumed {
+				t.Errorf("Consumed token is not as expected. Expected: %d, Given: %d", tt.expectedConsumed, consumedTokenID)
+			}
+
+			if tt.expectedTokenType == "" {
+				if createdToken != nil {
+					t.Errorf("Unexpected persisted token: %#v", createdToken)
+				}
+			} else if createdToken == nil || createdToken.Type != tt.expectedTokenType {
+				t.Errorf("Persisted token is not as expected. Expected type: %q, Given: %#v", tt.expectedTokenType, createdToken)
+			}
+
+			if tt.expectedError != nil {
+				return
+			}
+
+			if accessToken != "access-token" || refreshToken != "refresh-token" {
+				t.Errorf("Tokens are not as expected. Given: %q, %q", accessToken, refreshToken)
+			}
+		})
+	}
+}
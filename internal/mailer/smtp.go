@@ -36,9 +36,9 @@ var buildDialer = func(username string, password string, host string, port int,
 }
 
 // New creates a Mailer instance with the given smtp-configuration. Before instantiation a dial tests the configuration
-// and all available templates will be parsed.
+// and the password-reset template as well as all selected optional templates will be parsed.
 // 'tlsServerName' is only required if 'tlsInsecureSkipVerify' is false.
-func New(templatesFolderPath, username, password, host string, port int, tlsInsecureSkipVerify bool, tlsServerName string) (*Mailer, error) {
+func New(templatesFolderPath, username, password, host string, port int, tlsInsecureSkipVerify bool, tlsServerName string, optionalTemplates Templates) (*Mailer, error) {
 	d := buildDialer(username, password, host, port, tlsInsecureSkipVerify, tlsServerName)
 
 	//check connection and auth
@@ -53,11 +53,34 @@ func New(templatesFolderPath, username, password, host string, port int, tlsInse
 		return nil, fmt.Errorf("failed to load password-reset mailTemplate: %w", err)
 	}
 
+	templates := map[string]template{
+		passwordResetRequestTemplateName: pwRestTmpl,
+	}
+
+	if optionalTemplates.MFACode {
+		templates[mfaCodeTemplateName], err = loadTemplates(templatesFolderPath, mfaCodeTemplateName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load mfa-code mailTemplate: %w", err)
+		}
+	}
+
+	if optionalTemplates.MagicLink {
+		templates[magicLinkTemplateName], err = loadTemplates(templatesFolderPath, magicLinkTemplateName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load magic-link mailTemplate: %w", err)
+		}
+	}
+
+	if optionalTemplates.EMailVerification {
+		templates[emailVerificationTemplateName], err = loadTemplates(templatesFolderPath, emailVerificationTemplateName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load email-verification mailTemplate: %w", err)
+		}
+	}
+
 	return &Mailer{
-		dialer: d,
-		templates: map[string]template{
-			passwordResetRequestTemplateName: pwRestTmpl,
-		},
+		dialer:    d,
+		templates: templates,
 	}, nil
 }
 
@@ -74,14 +97,66 @@ func (m *Mailer) SendPasswordResetRequestEMail(recipient, passwordResetToken str
 		Claims:             claims,
 	}
 
-	tpl, found := m.templates[passwordResetRequestTemplateName]
+	return m.send(passwordResetRequestTemplateName, mailData)
+}
+
+// SendMFACodeEMail sends a mfa-code mail with a one-time code for the multi-factor authentication to the given
+// recipient. 'code' and 'claims' can be used in mail-templates.
+func (m *Mailer) SendMFACodeEMail(recipient, code string, claims map[string]interface{}) error {
+	mailData := struct {
+		Recipient string
+		Code      string
+		Claims    map[string]interface{}
+	}{
+		Recipient: recipient,
+		Code:      code,
+		Claims:    claims,
+	}
+
+	return m.send(mfaCodeTemplateName, mailData)
+}
+
+// SendMagicLinkEMail sends a magic-link mail with a one-time login token to the given recipient. 'magicLinkToken' and
+// 'claims' can be used in mail-templates.
+func (m *Mailer) SendMagicLinkEMail(recipient, magicLinkToken string, claims map[string]interface{}) error {
+	mailData := struct {
+		Recipient      string
+		MagicLinkToken string
+		Claims         map[string]interface{}
+	}{
+		Recipient:      recipient,
+		MagicLinkToken: magicLinkToken,
+		Claims:         claims,
+	}
+
+	return m.send(magicLinkTemplateName, mailData)
+}
+
+// SendEMailVerificationEMail sends an email-verification mail with the token to verify the email of a self-registered
+// user to the given recipient. 'verificationToken' and 'claims' can be used in mail-templates.
+func (m *Mailer) SendEMailVerificationEMail(recipient, verificationToken string, claims map[string]interface{}) error {
+	mailData := struct {
+		Recipient         string
+		VerificationToken string
+		Claims            map[string]interface{}
+	}{
+		Recipient:         recipient,
+		VerificationToken: verificationToken,
+		Claims:            claims,
+	}
+
+	return m.send(emailVerificationTemplateName, mailData)
+}
+
+func (m *Mailer) send(templateName string, mailData interface{}) error {
+	tpl, found := m.templates[templateName]
 	if !found {
-		return fmt.Errorf("could not found mailTemplate with name %q", passwordResetRequestTemplateName)
+		return fmt.Errorf("could not found mailTemplate with name %q", templateName)
 	}
 
 	msg, err := tpl.Render(mailData)
 	if err != nil {
-		return fmt.Errorf("failed to render mail-template %q: %w", passwordResetRequestTemplateName, err)
+		return fmt.Errorf("failed to render mail-template %q: %w", templateName, err)
 	}
 
 	err = m.dialer.DialAndSend(msg)
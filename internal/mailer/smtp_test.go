@@ -14,8 +14,9 @@ func TestNew(t *testing.T) {
 		name                    string
 		dialerDialSendCloser    mail.SendCloser
 		dialerDialErr           error
-		loadTemplatesTemplate   mailTemplate
 		loadTemplatesErr        error
+		loadTemplatesErrs       map[string]error
+		optionalTemplates       Templates
 		expectedErr             error
 		expectedMailerTemplates map[string]template
 	}{
@@ -24,8 +25,30 @@ func TestNew(t *testing.T) {
 			dialerDialSendCloser: &sendCloserMock{
 				CloseFunc: func() error { return nil },
 			},
-			loadTemplatesTemplate: mailTemplate{
-				name: "password-reset-request",
+			optionalTemplates: Templates{MFACode: true, MagicLink: true, EMailVerification: true},
+			expectedMailerTemplates: map[string]template{
+				"password-reset-request": mailTemplate{
+					name: "password-reset-request",
+				},
+				"mfa-code": mailTemplate{
+					name: "mfa-code",
+				},
+				"magic-link": mailTemplate{
+					name: "magic-link",
+				},
+				"email-verification": mailTemplate{
+					name: "email-verification",
+				},
+			},
+		}, {
+			name: "Disabled optional templates",
+			dialerDialSendCloser: &sendCloserMock{
+				CloseFunc: func() error { return nil },
+			},
+			loadTemplatesErrs: map[string]error{
+				"mfa-code":           errors.New("file not found"),
+				"magic-link":         errors.New("file not found"),
+				"email-verification": errors.New("file not found"),
 			},
 			expectedMailerTemplates: map[string]template{
 				"password-reset-request": mailTemplate{
@@ -43,6 +66,30 @@ func TestNew(t *testing.T) {
 			},
 			loadTemplatesErr: errors.New("angry file system: you're stupid peace of s*it"),
 			expectedErr:      errors.New("failed to load password-reset mailTemplate: angry file system: you're stupid peace of s*it"),
+		}, {
+			name: "Unable to load mfa-code templates",
+			dialerDialSendCloser: &sendCloserMock{
+				CloseFunc: func() error { return nil },
+			},
+			loadTemplatesErrs: map[string]error{"mfa-code": errors.New("file not found")},
+			optionalTemplates: Templates{MFACode: true},
+			expectedErr:       errors.New("failed to load mfa-code mailTemplate: file not found"),
+		}, {
+			name: "Unable to load magic-link templates",
+			dialerDialSendCloser: &sendCloserMock{
+				CloseFunc: func() error { return nil },
+			},
+			loadTemplatesErrs: map[string]error{"magic-link": errors.New("file not found")},
+			optionalTemplates: Templates{MagicLink: true},
+			expectedErr:       errors.New("failed to load magic-link mailTemplate: file not found"),
+		}, {
+			name: "Unable to load email-verification templates",
+			dialerDialSendCloser: &sendCloserMock{
+				CloseFunc: func() error { return nil },
+			},
+			loadTemplatesErrs: map[string]error{"email-verification": errors.New("file not found")},
+			optionalTemplates: Templates{EMailVerification: true},
+			expectedErr:       errors.New("failed to load email-verification mailTemplate: file not found"),
 		},
 	}
 	for _, tt := range tests {
@@ -76,15 +123,21 @@ func TestNew(t *testing.T) {
 					t.Errorf("unexpected loadTemplates.path. Given: %q, Expected: %q", path, givenTemplatesFolderPath)
 				}
 
-				expectedLoadedTemplateName := "password-reset-request"
-				if name != expectedLoadedTemplateName {
-					t.Errorf("unexpected loadTemplates.name. Given: %q, Expected: %q", name, expectedLoadedTemplateName)
+				if name != "password-reset-request" && name != "mfa-code" && name != "magic-link" && name != "email-verification" {
+					t.Errorf("unexpected loadTemplates.name. Given: %q", name)
 				}
 
-				return tt.loadTemplatesTemplate, tt.loadTemplatesErr
+				if tt.loadTemplatesErr != nil {
+					return mailTemplate{}, tt.loadTemplatesErr
+				}
+				if err, ok := tt.loadTemplatesErrs[name]; ok {
+					return mailTemplate{}, err
+				}
+
+				return mailTemplate{name: name}, nil
 			}
 
-			mailer, err := New(givenTemplatesFolderPath, givenUsername, givenPassword, givenHost, givenPort, givenTLSInsecureSkipVerify, givenTLSServerName)
+			mailer, err := New(givenTemplatesFolderPath, givenUsername, givenPassword, givenHost, givenPort, givenTLSInsecureSkipVerify, givenTLSServerName, tt.optionalTemplates)
 			if fmt.Sprint(err) != fmt.Sprint(tt.expectedErr) {
 				t.Fatalf("Unexpected error. Given:\n%q\nExpected:\n%q", err, tt.loadTemplatesErr)
 			} else if err != nil {
@@ -361,3 +414,153 @@ func TestBuildDialer(t *testing.T) {
 	assert.Equal(t, mailDialer.TLSConfig.InsecureSkipVerify, false, "dialer>TLSConfig.InsecureSkipVerify should be false")
 	assert.Equal(t, mailDialer.TLSConfig.ServerName, tlsServerName, "buildDialer.TLSConfig.ServerName is not as expected")
 }
+
+func TestMailer_SendMFACodeEMail(t *testing.T) {
+	givenClaims := map[string]interface{}{
+		"customClaim4711": 3,
+	}
+
+	codeMail := mail.NewMessage(mail.SetCharset("UTF-8"))
+	var mailsToSend []*mail.Message
+	dialer := &dialerMock{
+		DialAndSendFunc: func(msgs ...*mail.Message) error {
+			mailsToSend = msgs
+			return nil
+		},
+	}
+
+	var calledMailData interface{}
+	m := Mailer{
+		dialer: dialer,
+		templates: map[string]template{
+			"mfa-code": &templateMock{
+				RenderFunc: func(mailData interface{}) (*mail.Message, error) {
+					calledMailData = mailData
+					return codeMail, nil
+				},
+			},
+		},
+	}
+
+	err := m.SendMFACodeEMail(">recipient<", "123456", givenClaims)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	if !reflect.DeepEqual(mailsToSend, []*mail.Message{codeMail}) {
+		t.Errorf("The send mail(s) are not the rendered. Rendered: %#v. Send: %#v", codeMail, mailsToSend)
+	}
+
+	expectedMailData := struct {
+		Recipient string
+		Code      string
+		Claims    map[string]interface{}
+	}{
+		Recipient: ">recipient<",
+		Code:      "123456",
+		Claims:    givenClaims,
+	}
+	if !reflect.DeepEqual(expectedMailData, calledMailData) {
+		t.Errorf("called mail data are not as expected. Expected:\n%#v\nGiven:\n%#v", expectedMailData, calledMailData)
+	}
+}
+
+func TestMailer_SendMagicLinkEMail(t *testing.T) {
+	givenClaims := map[string]interface{}{
+		"customClaim4711": 3,
+	}
+
+	magicLinkMail := mail.NewMessage(mail.SetCharset("UTF-8"))
+	var mailsToSend []*mail.Message
+	dialer := &dialerMock{
+		DialAndSendFunc: func(msgs ...*mail.Message) error {
+			mailsToSend = msgs
+			return nil
+		},
+	}
+
+	var calledMailData interface{}
+	m := Mailer{
+		dialer: dialer,
+		templates: map[string]template{
+			"magic-link": &templateMock{
+				RenderFunc: func(mailData interface{}) (*mail.Message, error) {
+					calledMailData = mailData
+					return magicLinkMail, nil
+				},
+			},
+		},
+	}
+
+	err := m.SendMagicLinkEMail(">recipient<", ">magicLinkToken<", givenClaims)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	if !reflect.DeepEqual(mailsToSend, []*mail.Message{magicLinkMail}) {
+		t.Errorf("The send mail(s) are not the rendered. Rendered: %#v. Send: %#v", magicLinkMail, mailsToSend)
+	}
+
+	expectedMailData := struct {
+		Recipient      string
+		MagicLinkToken string
+		Claims         map[string]interface{}
+	}{
+		Recipient:      ">recipient<",
+		MagicLinkToken: ">magicLinkToken<",
+		Claims:         givenClaims,
+	}
+	if !reflect.DeepEqual(expectedMailData, calledMailData) {
+		t.Errorf("called mail data are not as expected. Expected:\n%#v\nGiven:\n%#v", expectedMailData, calledMailData)
+	}
+}
+
+func TestMailer_SendEMailVerificationEMail(t *testing.T) {
+	givenClaims := map[string]interface{}{
+		"customClaim4711": 3,
+	}
+
+	verificationMail := mail.NewMessage(mail.SetCharset("UTF-8"))
+	var mailsToSend []*mail.Message
+	dialer := &dialerMock{
+		DialAndSendFunc: func(msgs ...*mail.Message) error {
+			mailsToSend = msgs
+			return nil
+		},
+	}
+
+	var calledMailData interface{}
+	m := Mailer{
+		dialer: dialer,
+		templates: map[string]template{
+			"email-verification": &templateMock{
+				RenderFunc: func(mailData interface{}) (*mail.Message, error) {
+					calledMailData = mailData
+					return verificationMail, nil
+				},
+			},
+		},
+	}
+
+	err := m.SendEMailVerificationEMail(">recipient<", ">verificationToken<", givenClaims)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	if !reflect.DeepEqual(mailsToSend, []*mail.Message{verificationMail}) {
+		t.Errorf("The send mail(s) are not the rendered. Rendered: %#v. Send: %#v", verificationMail, mailsToSend)
+	}
+
+	expectedMailData := struct {
+		Recipient         string
+		VerificationToken string
+		Claims            map[string]interface{}
+	}{
+		Recipient:         ">recipient<",
+		VerificationToken: ">verificationToken<",
+		Claims:            givenClaims,
+	}
+	if !reflect.DeepEqual(expectedMailData, calledMailData) {
+		t.Errorf("called mail data are not as expected. Expected:\n%#v\nGiven:\n%#v", expectedMailData, calledMailData)
+	}
+}
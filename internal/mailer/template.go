@@ -11,6 +11,20 @@ import (
 )
 
 const passwordResetRequestTemplateName = "password-reset-request"
+const mfaCodeTemplateName = "mfa-code"
+const magicLinkTemplateName = "magic-link"
+const emailVerificationTemplateName = "email-verification"
+
+// Templates selects the mail templates of optional features which will be loaded by New. Templates of disabled
+// features do not have to be present in the templates folder.
+type Templates struct {
+	// MFACode is required to send one-time codes via email as second factor
+	MFACode bool
+	// MagicLink is required to send magic links for passwordless logins
+	MagicLink bool
+	// EMailVerification is required to verify the emails of self-registered users
+	EMailVerification bool
+}
 
 var htmlTemplateParseFiles = htmlTemplate.ParseFiles
 var textTemplateParseFiles = textTemplate.ParseFiles
@@ -17,6 +17,15 @@ var _ Mailer = &MailerMock{}
 //
 // 		// make and configure a mocked Mailer
 // 		mockedMailer := &MailerMock{
+// 			SendEMailVerificationEMailFunc: func(recipient string, verificationToken string, claims map[string]interface{}) error {
+// 				panic("mock out the SendEMailVerificationEMail method")
+// 			},
+// 			SendMFACodeEMailFunc: func(recipient string, code string, claims map[string]interface{}) error {
+// 				panic("mock out the SendMFACodeEMail method")
+// 			},
+// 			SendMagicLinkEMailFunc: func(recipient string, magicLinkToken string, claims map[string]interface{}) error {
+// 				panic("mock out the SendMagicLinkEMail method")
+// 			},
 // 			SendPasswordResetRequestEMailFunc: func(recipient string, passwordResetToken string, claims map[string]interface{}) error {
 // 				panic("mock out the SendPasswordResetRequestEMail method")
 // 			},
@@ -27,11 +36,47 @@ var _ Mailer = &MailerMock{}
 //
 // 	}
 type MailerMock struct {
+	// SendEMailVerificationEMailFunc mocks the SendEMailVerificationEMail method.
+	SendEMailVerificationEMailFunc func(recipient string, verificationToken string, claims map[string]interface{}) error
+
+	// SendMFACodeEMailFunc mocks the SendMFACodeEMail method.
+	SendMFACodeEMailFunc func(recipient string, code string, claims map[string]interface{}) error
+
+	// SendMagicLinkEMailFunc mocks the SendMagicLinkEMail method.
+	SendMagicLinkEMailFunc func(recipient string, magicLinkToken string, claims map[string]interface{}) error
+
 	// SendPasswordResetRequestEMailFunc mocks the SendPasswordResetRequestEMail method.
 	SendPasswordResetRequestEMailFunc func(recipient string, passwordResetToken string, claims map[string]interface{}) error
 
 	// calls tracks calls to the methods.
 	calls struct {
+		// SendEMailVerificationEMail holds details about calls to the SendEMailVerificationEMail method.
+		SendEMailVerificationEMail []struct {
+			// Recipient is the recipient argument value.
+			Recipient string
+			// VerificationToken is the verificationToken argument value.
+			VerificationToken string
+			// Claims is the claims argument value.
+			Claims map[string]interface{}
+		}
+		// SendMFACodeEMail holds details about calls to the SendMFACodeEMail method.
+		SendMFACodeEMail []struct {
+			// Recipient is the recipient argument value.
+			Recipient string
+			// Code is the code argument value.
+			Code string
+			// Claims is the claims argument value.
+			Claims map[string]interface{}
+		}
+		// SendMagicLinkEMail holds details about calls to the SendMagicLinkEMail method.
+		SendMagicLinkEMail []struct {
+			// Recipient is the recipient argument value.
+			Recipient string
+			// MagicLinkToken is the magicLinkToken argument value.
+			MagicLinkToken string
+			// Claims is the claims argument value.
+			Claims map[string]interface{}
+		}
 		// SendPasswordResetRequestEMail holds details about calls to the SendPasswordResetRequestEMail method.
 		SendPasswordResetRequestEMail []struct {
 			// Recipient is the recipient argument value.
@@ -42,9 +87,129 @@ type MailerMock struct {
 			Claims map[string]interface{}
 		}
 	}
+	lockSendEMailVerificationEMail    sync.RWMutex
+	lockSendMFACodeEMail              sync.RWMutex
+	lockSendMagicLinkEMail            sync.RWMutex
 	lockSendPasswordResetRequestEMail sync.RWMutex
 }
 
+// SendEMailVerificationEMail calls SendEMailVerificationEMailFunc.
+func (mock *MailerMock) SendEMailVerificationEMail(recipient string, verificationToken string, claims map[string]interface{}) error {
+	if mock.SendEMailVerificationEMailFunc == nil {
+		panic("MailerMock.SendEMailVerificationEMailFunc: method is nil but Mailer.SendEMailVerificationEMail was just called")
+	}
+	callInfo := struct {
+		Recipient         string
+		VerificationToken string
+		Claims            map[string]interface{}
+	}{
+		Recipient:         recipient,
+		VerificationToken: verificationToken,
+		Claims:            claims,
+	}
+	mock.lockSendEMailVerificationEMail.Lock()
+	mock.calls.SendEMailVerificationEMail = append(mock.calls.SendEMailVerificationEMail, callInfo)
+	mock.lockSendEMailVerificationEMail.Unlock()
+	return mock.SendEMailVerificationEMailFunc(recipient, verificationToken, claims)
+}
+
+// SendEMailVerificationEMailCalls gets all the calls that were made to SendEMailVerificationEMail.
+// Check the length with:
+//     len(mockedMailer.SendEMailVerificationEMailCalls())
+func (mock *MailerMock) SendEMailVerificationEMailCalls() []struct {
+	Recipient         string
+	VerificationToken string
+	Claims            map[string]interface{}
+} {
+	var calls []struct {
+		Recipient         string
+		VerificationToken string
+		Claims            map[string]interface{}
+	}
+	mock.lockSendEMailVerificationEMail.RLock()
+	calls = mock.calls.SendEMailVerificationEMail
+	mock.lockSendEMailVerificationEMail.RUnlock()
+	return calls
+}
+
+// SendMFACodeEMail calls SendMFACodeEMailFunc.
+func (mock *MailerMock) SendMFACodeEMail(recipient string, code string, claims map[string]interface{}) error {
+	if mock.SendMFACodeEMailFunc == nil {
+		panic("MailerMock.SendMFACodeEMailFunc: method is nil but Mailer.SendMFACodeEMail was just called")
+	}
+	callInfo := struct {
+		Recipient string
+		Code      string
+		Claims    map[string]interface{}
+	}{
+		Recipient: recipient,
+		Code:      code,
+		Claims:    claims,
+	}
+	mock.lockSendMFACodeEMail.Lock()
+	mock.calls.SendMFACodeEMail = append(mock.calls.SendMFACodeEMail, callInfo)
+	mock.lockSendMFACodeEMail.Unlock()
+	return mock.SendMFACodeEMailFunc(recipient, code, claims)
+}
+
+// SendMFACodeEMailCalls gets all the calls that were made to SendMFACodeEMail.
+// Check the length with:
+//     len(mockedMailer.SendMFACodeEMailCalls())
+func (mock *MailerMock) SendMFACodeEMailCalls() []struct {
+	Recipient string
+	Code      string
+	Claims    map[string]interface{}
+} {
+	var calls []struct {
+		Recipient string
+		Code      string
+		Claims    map[string]interface{}
+	}
+	mock.lockSendMFACodeEMail.RLock()
+	calls = mock.calls.SendMFACodeEMail
+	mock.lockSendMFACodeEMail.RUnlock()
+	return calls
+}
+
+// SendMagicLinkEMail calls SendMagicLinkEMailFunc.
+func (mock *MailerMock) SendMagicLinkEMail(recipient string, magicLinkToken string, claims map[string]interface{}) error {
+	if mock.SendMagicLinkEMailFunc == nil {
+		panic("MailerMock.SendMagicLinkEMailFunc: method is nil but Mailer.SendMagicLinkEMail was just called")
+	}
+	callInfo := struct {
+		Recipient      string
+		MagicLinkToken string
+		Claims         map[string]interface{}
+	}{
+		Recipient:      recipient,
+		MagicLinkToken: magicLinkToken,
+		Claims:         claims,
+	}
+	mock.lockSendMagicLinkEMail.Lock()
+	mock.calls.SendMagicLinkEMail = append(mock.calls.SendMagicLinkEMail, callInfo)
+	mock.lockSendMagicLinkEMail.Unlock()
+	return mock.SendMagicLinkEMailFunc(recipient, magicLinkToken, claims)
+}
+
+// SendMagicLinkEMailCalls gets all the calls that were made to SendMagicLinkEMail.
+// Check the length with:
+//     len(mockedMailer.SendMagicLinkEMailCalls())
+func (mock *MailerMock) SendMagicLinkEMailCalls() []struct {
+	Recipient      string
+	MagicLinkToken string
+	Claims         map[string]interface{}
+} {
+	var calls []struct {
+		Recipient      string
+		MagicLinkToken string
+		Claims         map[string]interface{}
+	}
+	mock.lockSendMagicLinkEMail.RLock()
+	calls = mock.calls.SendMagicLinkEMail
+	mock.lockSendMagicLinkEMail.RUnlock()
+	return calls
+}
+
 // SendPasswordResetRequestEMail calls SendPasswordResetRequestEMailFunc.
 func (mock *MailerMock) SendPasswordResetRequestEMail(recipient string, passwordResetToken string, claims map[string]interface{}) error {
 	if mock.SendPasswordResetRequestEMailFunc == nil {
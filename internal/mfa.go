@@ -0,0 +1,390 @@
+package internal
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"github.com/leberKleber/simple-jwt-provider/internal/totp"
+	"strings"
+)
+
+// MFAMethodTOTP identifies time-based one-time passwords (RFC 6238) as multi-factor authentication method
+const MFAMethodTOTP = "totp"
+
+const recoveryCodesCount = 10
+
+// ErrMFARequired returned when the user has to pass the multi-factor authentication to login
+var ErrMFARequired = errors.New("mfa required")
+
+// ErrMFAAlreadyEnabled returned when the multi-factor authentication of the user has already been enabled
+var ErrMFAAlreadyEnabled = errors.New("mfa already enabled")
+
+// ErrMFANotEnrolled returned when the multi-factor authentication of the user has not been enrolled yet
+var ErrMFANotEnrolled = errors.New("mfa not enrolled")
+
+// ErrIncorrectMFACode returned when the given mfa code or recovery code is incorrect
+var ErrIncorrectMFACode = errors.New("mfa code incorrect")
+
+// MFARequiredError is returned by Login instead of tokens when the user has to pass the multi-factor authentication.
+// Token is the mfa-token which has to be passed to VerifyMFA together with a code of one of the Methods. It wraps
+// ErrMFARequired.
+type MFARequiredError struct {
+	Token   string
+	Methods []string
+}
+
+func (e MFARequiredError) Error() string {
+	return ErrMFARequired.Error()
+}
+
+// Unwrap returns ErrMFARequired
+func (e MFARequiredError) Unwrap() error {
+	return ErrMFARequired
+}
+
+// TOTPEnrollment contains the secret of a new TOTP authenticator and its otpauth uri which can be shown as qr-code
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// EnrollTOTP generates a new TOTP secret for the user of the given access-token when the given current password is
+// correct, so a stolen access-token is not sufficient to replace the secret. Incorrect passwords count as failed logins
+// of the given client. The TOTP authenticator has to be confirmed via ConfirmTOTP before it will be required on login.
+// return ErrInvalidToken when the token is not an active access-token or its user does not exist anymore
+// return ErrIncorrectPassword when the current password is incorrect
+// return LockoutError when logins of the email or the client are locked cause of too many failed logins
+// return ErrMFAAlreadyEnabled when the user has already enabled TOTP
+func (p Provider) EnrollTOTP(accessToken, password string, client ClientInfo) (TOTPEnrollment, error) {
+	u, err := p.accessTokenUser(accessToken)
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+
+	err = p.verifyPassword(u, password, client)
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+
+	if u.TOTPEnabled {
+		return TOTPEnrollment{}, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return TOTPEnrollment{}, fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+
+	enrolled, err := p.Storage.EnrollUserTOTP(u.EMail, secret)
+	if err != nil {
+		return TOTPEnrollment{}, fmt.Errorf("failed to update user: %w", err)
+	}
+	if !enrolled {
+		// totp has been enabled concurrently
+		return TOTPEnrollment{}, ErrMFAAlreadyEnabled
+	}
+
+	return TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(p.TOTPIssuer, u.EMail, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables the enrolled TOTP authenticator of the user of the given access-token when the given code is
+// correct. The code proves the knowledge of the secret which has only been handed out by EnrollTOTP after the current
+// password has been verified. It returns new recovery codes which can be used once each instead of a TOTP code.
+// return ErrInvalidToken when the token is not an active access-token or its user does not exist anymore
+// return ErrMFANotEnrolled when TOTP has not been enrolled via EnrollTOTP
+// return ErrMFAAlreadyEnabled when the user has already enabled TOTP
+// return ErrIncorrectMFACode when the code is incorrect
+func (p Provider) ConfirmTOTP(accessToken, code string) ([]string, error) {
+	u, err := p.accessTokenUser(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	if u.TOTPSecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	step, valid, err := totp.Validate(u.TOTPSecret, code, timeNow(), 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate totp code: %w", err)
+	}
+
+	if !valid {
+		return nil, ErrIncorrectMFACode
+	}
+
+	recoveryCodes, hashedRecoveryCodes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	// totp will only be enabled when the secret has not been enrolled again or enabled in the meantime
+	enabled, err := p.Storage.EnableUserTOTP(u.EMail, u.TOTPSecret, step)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	if !enabled {
+		return nil, ErrIncorrectMFACode
+	}
+
+	err = p.Storage.ReplaceRecoveryCodes(u.EMail, hashedRecoveryCodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to persist recovery codes: %w", err)
+	}
+
+	return recoveryCodes, nil
+}
+
+// VerifyMFA completes the login which has been started via Login and returned a MFARequiredError. Either the current
+// code of one of the enabled methods or one of the recovery codes must be given. Failed verifications count as failed
+// logins and as attempts of the mfa-token, which will be invalidated after MFAMaxAttempts.
+// return ErrNoValidTokenFound when the mfa-token is invalid, expired or does not match the email
+// return ErrIncorrectMFACode when the code or recovery code is incorrect
+// return LockoutError when logins of the email or the client are locked cause of too many failed logins
+func (p Provider) VerifyMFA(email, mfaToken, code, recoveryCode string, client ClientInfo) (accessToken, refreshToken string, err error) {
+	err = p.checkLoginLockout(email, client)
+	if err != nil {
+		return "", "", err
+	}
+
+	tokens, err := p.Storage.TokensByEMailAndToken(email, mfaToken)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find mfa-tokens: %w", err)
+	}
+
+	var t *storage.Token
+	for _, token := range tokens {
+		if token.Type == storage.TokenTypeMFA && !p.isExpired(token) {
+			t = &token
+			break
+		}
+	}
+
+	if t == nil {
+		return "", "", ErrNoValidTokenFound
+	}
+
+	u, err := p.Storage.User(email)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find user with email %q: %w", email, err)
+	}
+
+	if len(mfaMethods(u)) == 0 {
+		// mfa has been disabled after the mfa-token has been issued
+		return "", "", ErrNoValidTokenFound
+	}
+
+	if recoveryCode != "" {
+		err = p.Storage.ConsumeRecoveryCode(email, hashRecoveryCode(recoveryCode))
+		if errors.Is(err, storage.ErrRecoveryCodeNotFound) {
+			return "", "", p.mfaFailed(email, *t, client)
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("failed to consume recovery code: %w", err)
+		}
+	} else {
+		valid, err := p.verifyMFACode(u, code)
+		if err != nil {
+			return "", "", err
+		}
+		if !valid {
+			return "", "", p.mfaFailed(email, *t, client)
+		}
+	}
+
+	if u.EMailOTPEnabled {
+		// one-time codes which have been sent for this login must not be usable anymore
+		err = p.Storage.DeleteTokensByEMailAndType(email, storage.TokenTypeEMailOTP)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to delete email one-time codes: %w", err)
+		}
+	}
+
+	err = p.consumeToken(t.ID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to consume mfa-token: %w", err)
+	}
+
+	err = p.resetLoginFailures(email)
+	if err != nil {
+		return "", "", err
+	}
+
+	return p.issueTokens(email, u.Claims, client)
+}
+
+// mfaFailed registers the incorrect code as attempt of the given mfa-token, which will be invalidated after
+// MFAMaxAttempts, and as failed login
+func (p Provider) mfaFailed(email string, t storage.Token, client ClientInfo) error {
+	attempts, err := p.Storage.RegisterTokenAttempt(t.ID)
+	if err != nil && !errors.Is(err, storage.ErrTokenNotFound) {
+		return fmt.Errorf("failed to register mfa-token attempt: %w", err)
+	}
+
+	if err == nil && p.MFAMaxAttempts > 0 && attempts >= p.MFAMaxAttempts {
+		err = p.Storage.ConsumeToken(t.ID)
+		if err != nil && !errors.Is(err, storage.ErrTokenNotFound) {
+			return fmt.Errorf("failed to invalidate mfa-token: %w", err)
+		}
+	}
+
+	return p.loginFailed(email, client, ErrIncorrectMFACode)
+}
+
+// DisableMFA disables all multi-factor authentication methods of the user with the given email and deletes its
+// recovery codes, e.g. when the user lost the authenticator.
+// return ErrUserNotFound when user does not exist
+func (p Provider) DisableMFA(email string) error {
+	_, err := p.Storage.User(email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+
+		return fmt.Errorf("failed to find user with email %q: %w", email, err)
+	}
+
+	err = p.Storage.ReplaceRecoveryCodes(email, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	err = p.Storage.DeleteTokensByEMailAndType(email, storage.TokenTypeEMailOTP)
+	if err != nil {
+		return fmt.Errorf("failed to delete email one-time codes: %w", err)
+	}
+
+	err = p.Storage.DisableUserMFA(email)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return nil
+}
+
+// verifyMFACode checks the given code against all enabled methods of the given user
+func (p Provider) verifyMFACode(u storage.User, code string) (bool, error) {
+	if u.TOTPEnabled {
+		step, valid, err := totp.Validate(u.TOTPSecret, code, timeNow(), u.TOTPLastStep)
+		if err != nil {
+			return false, fmt.Errorf("failed to validate totp code: %w", err)
+		}
+
+		if valid {
+			// the step will only be updated when it is later than the persisted one, so a code which has been used
+			// concurrently will be rejected
+			updated, err := p.Storage.UpdateTOTPLastStep(u.EMail, step)
+			if err != nil {
+				return false, fmt.Errorf("failed to persist last totp step: %w", err)
+			}
+
+			if updated {
+				return true, nil
+			}
+		}
+	}
+
+	if u.EMailOTPEnabled {
+		return p.verifyEMailOTP(u.EMail, code)
+	}
+
+	return false, nil
+}
+
+// mfaMethods returns the enabled multi-factor authentication methods of the given user
+func mfaMethods(u storage.User) []string {
+	var methods []string
+	if u.TOTPEnabled {
+		methods = append(methods, MFAMethodTOTP)
+	}
+	if u.EMailOTPEnabled {
+		methods = append(methods, MFAMethodEMail)
+	}
+
+	return methods
+}
+
+// mfaRequired persists a new mfa-token for the given user and returns it as MFARequiredError. When the user has enabled
+// one-time codes via email, a new code will be sent.
+func (p Provider) mfaRequired(u storage.User) error {
+	email := u.EMail
+	token, err := generateHEXToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate mfa-token: %w", err)
+	}
+
+	err = p.Storage.CreateToken(&storage.Token{
+		EMail:     email,
+		Token:     token,
+		Type:      storage.TokenTypeMFA,
+		ExpiresAt: timeNow().Add(p.MFATokenLifetime),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to persist mfa-token: %w", err)
+	}
+
+	if u.EMailOTPEnabled {
+		err = p.sendEMailOTP(u)
+		if err != nil {
+			return err
+		}
+	}
+
+	return MFARequiredError{Token: token, Methods: mfaMethods(u)}
+}
+
+// accessTokenUser returns the user of the given access-token.
+// return ErrInvalidToken when the token is not an active access-token or its user does not exist anymore
+func (p Provider) accessTokenUser(accessToken string) (storage.User, error) {
+	email, err := p.accessTokenEMail(accessToken)
+	if err != nil {
+		return storage.User{}, err
+	}
+
+	u, err := p.Storage.User(email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return storage.User{}, ErrInvalidToken
+		}
+
+		return storage.User{}, fmt.Errorf("failed to find user with email %q: %w", email, err)
+	}
+
+	return u, nil
+}
+
+// generateRecoveryCodes generates new recovery codes formatted as 'xxxx-xxxx' and their hashes which will be persisted
+var generateRecoveryCodes = func() ([]string, []storage.RecoveryCode, error) {
+	codes := make([]string, recoveryCodesCount)
+	hashedCodes := make([]storage.RecoveryCode, recoveryCodesCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		_, err := rand.Read(b)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+		codes[i] = code[:4] + "-" + code[4:]
+		hashedCodes[i] = storage.RecoveryCode{Code: hashRecoveryCode(codes[i])}
+	}
+
+	return codes, hashedCodes, nil
+}
+
+// hashRecoveryCode hashes the normalized recovery code. Recovery codes are random, so a fast hash is sufficient.
+func hashRecoveryCode(code string) []byte {
+	normalized := strings.ToLower(strings.Replace(strings.TrimSpace(code), "-", "", -1))
+	hash := sha256.Sum256([]byte(normalized))
+	return hash[:]
+}
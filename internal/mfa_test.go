@@ -0,0 +1,533 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"gorm.io/gorm"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// base32 encoded secret "12345678901234567890" of the RFC 6238 test vectors. At 1111111111 the code is 050471.
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+var testTOTPTime = time.Unix(1111111111, 0)
+
+func TestProvider_Login_MFARequired(t *testing.T) {
+	oldGenerateHEXToken := generateHEXToken
+	defer func() { generateHEXToken = oldGenerateHEXToken }()
+	generateHEXToken = func() (string, error) {
+		return "mfa-token", nil
+	}
+
+	passwordHash, _ := testPasswordHasher.Hash("password")
+	var createdToken *storage.Token
+	toTest := Provider{
+		PasswordHasher:   testPasswordHasher,
+		MFATokenLifetime: 5 * time.Minute,
+		LoginLockout:     LoginLockout{MaxFailures: 5, Duration: time.Minute, MaxDuration: time.Hour},
+		Storage: &StorageMock{
+			LoginFailureFunc: func(key string) (storage.LoginFailure, error) {
+				return storage.LoginFailure{}, nil
+			},
+			UserFunc: func(email string) (storage.User, error) {
+				return storage.User{EMail: email, Password: passwordHash, TOTPEnabled: true}, nil
+			},
+			CreateTokenFunc: func(t *storage.Token) error {
+				createdToken = t
+				return nil
+			},
+		},
+	}
+
+	_, _, err := toTest.Login("test@test.test", "password", ClientInfo{})
+
+	var mfaErr MFARequiredError
+	if !errors.As(err, &mfaErr) {
+		t.Fatalf("Processing error should be a MFARequiredError but was: %#v", err)
+	}
+
+	expectedErr := MFARequiredError{Token: "mfa-token", Methods: []string{MFAMethodTOTP}}
+	if !reflect.DeepEqual(mfaErr, expectedErr) {
+		t.Errorf("MFARequiredError is not as expected: \nExpected:%#v\nGiven:%#v", expectedErr, mfaErr)
+	}
+
+	if createdToken == nil || createdToken.Type != storage.TokenTypeMFA || createdToken.Token != "mfa-token" || createdToken.EMail != "test@test.test" {
+		t.Errorf("Persisted mfa-token is not as expected: %#v", createdToken)
+	}
+}
+
+func TestProvider_EnrollTOTP(t *testing.T) {
+	passwordHash, _ := testPasswordHasher.Hash("password")
+	tests := []struct {
+		name                 string
+		user                 storage.User
+		password             string
+		lockedUntil          time.Time
+		enabledConcurrently  bool
+		expectedError        error
+		expectedLoginFailure bool
+	}{
+		{
+			name:     "Happycase",
+			user:     storage.User{EMail: "test@test.test", Password: passwordHash},
+			password: "password",
+		}, {
+			name:                 "Incorrect password",
+			user:                 storage.User{EMail: "test@test.test", Password: passwordHash},
+			password:             "wrong",
+			expectedError:        ErrIncorrectPassword,
+			expectedLoginFailure: true,
+		}, {
+			name:          "Locked",
+			user:          storage.User{EMail: "test@test.test", Password: passwordHash},
+			password:      "password",
+			lockedUntil:   time.Now().Add(time.Minute),
+			expectedError: ErrAccountLocked,
+		}, {
+			name:          "Already enabled",
+			user:          storage.User{EMail: "test@test.test", Password: passwordHash, TOTPSecret: testTOTPSecret, TOTPEnabled: true},
+			password:      "password",
+			expectedError: ErrMFAAlreadyEnabled,
+		}, {
+			name:                "Enabled concurrently",
+			user:                storage.User{EMail: "test@test.test", Password: passwordHash},
+			password:            "password",
+			enabledConcurrently: true,
+			expectedError:       ErrMFAAlreadyEnabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var enrolledSecret string
+			var loginFailureRegistered bool
+			toTest := Provider{
+				TOTPIssuer:     "issuer",
+				PasswordHasher: testPasswordHasher,
+				LoginLockout:   LoginLockout{MaxFailures: 5, Duration: time.Minute, MaxDuration: time.Hour},
+				JWTProvider:    validAccessTokenJWTProvider(),
+				Storage: &StorageMock{
+					IsTokenRevokedFunc: func(jit string) (bool, error) {
+						return false, nil
+					},
+					LoginFailureFunc: func(key string) (storage.LoginFailure, error) {
+						return storage.LoginFailure{LockedUntil: tt.lockedUntil}, nil
+					},
+					RegisterLoginFailureFunc: func(key string, forgetBefore time.Time) (storage.LoginFailure, error) {
+						loginFailureRegistered = true
+						return storage.LoginFailure{Failures: 1}, nil
+					},
+					UserFunc: func(email string) (storage.User, error) {
+						return tt.user, nil
+					},
+					EnrollUserTOTPFunc: func(email, secret string) (bool, error) {
+						if tt.enabledConcurrently {
+							return false, nil
+						}
+						enrolledSecret = secret
+						return true, nil
+					},
+				},
+			}
+
+			enrollment, err := toTest.EnrollTOTP("myAccessToken", tt.password, ClientInfo{IP: "10.0.0.1"})
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:%s\nGiven:%s", tt.expectedError, err)
+			}
+
+			if loginFailureRegistered != tt.expectedLoginFailure {
+				t.Errorf("Login failure registered is not as expected. Expected: %t, Given: %t", tt.expectedLoginFailure, loginFailureRegistered)
+			}
+			if err != nil {
+				if enrolledSecret != "" {
+					t.Errorf("Totp secret must not be replaced: %s", enrolledSecret)
+				}
+				return
+			}
+
+			if enrolledSecret == "" || enrolledSecret != enrollment.Secret {
+				t.Errorf("Pending totp secret has not been persisted. Expected: %s, Given: %s", enrollment.Secret, enrolledSecret)
+			}
+			if !strings.HasPrefix(enrollment.URI, "otpauth://totp/issuer:test@test.test?") {
+				t.Errorf("URI is not as expected. Given: %s", enrollment.URI)
+			}
+		})
+	}
+}
+
+func TestProvider_ConfirmTOTP(t *testing.T) {
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	timeNow = func() time.Time { return testTOTPTime }
+
+	tests := []struct {
+		name                 string
+		user                 storage.User
+		code                 string
+		enrolledConcurrently bool
+		expectedError        error
+	}{
+		{
+			name: "Happycase",
+			user: storage.User{EMail: "test@test.test", TOTPSecret: testTOTPSecret},
+			code: "050471",
+		}, {
+			name:          "Not enrolled",
+			user:          storage.User{EMail: "test@test.test"},
+			code:          "050471",
+			expectedError: ErrMFANotEnrolled,
+		}, {
+			name:          "Already enabled",
+			user:          storage.User{EMail: "test@test.test", TOTPSecret: testTOTPSecret, TOTPEnabled: true},
+			code:          "050471",
+			expectedError: ErrMFAAlreadyEnabled,
+		}, {
+			name:          "Incorrect code",
+			user:          storage.User{EMail: "test@test.test", TOTPSecret: testTOTPSecret},
+			code:          "123456",
+			expectedError: ErrIncorrectMFACode,
+		}, {
+			name:                 "Enrolled again concurrently",
+			user:                 storage.User{EMail: "test@test.test", TOTPSecret: testTOTPSecret},
+			code:                 "050471",
+			enrolledConcurrently: true,
+			expectedError:        ErrIncorrectMFACode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var enabledStep int64
+			var persistedCodes []storage.RecoveryCode
+			toTest := Provider{
+				JWTProvider: validAccessTokenJWTProvider(),
+				Storage: &StorageMock{
+					IsTokenRevokedFunc: func(jit string) (bool, error) {
+						return false, nil
+					},
+					UserFunc: func(email string) (storage.User, error) {
+						return tt.user, nil
+					},
+					ReplaceRecoveryCodesFunc: func(email string, codes []storage.RecoveryCode) error {
+						persistedCodes = codes
+						return nil
+					},
+					EnableUserTOTPFunc: func(email, secret string, step int64) (bool, error) {
+						if tt.enrolledConcurrently || secret != tt.user.TOTPSecret {
+							return false, nil
+						}
+						enabledStep = step
+						return true, nil
+					},
+				},
+			}
+
+			recoveryCodes, err := toTest.ConfirmTOTP("myAccessToken", tt.code)
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:%s\nGiven:%s", tt.expectedError, err)
+			}
+			if err != nil {
+				if persistedCodes != nil {
+					t.Errorf("Recovery codes must not be replaced: %d", len(persistedCodes))
+				}
+				return
+			}
+
+			if enabledStep != testTOTPTime.Unix()/30 {
+				t.Errorf("Totp has not been enabled with the last accepted step. Expected: %d, Given: %d", testTOTPTime.Unix()/30, enabledStep)
+			}
+			if len(recoveryCodes) != recoveryCodesCount || len(persistedCodes) != recoveryCodesCount {
+				t.Fatalf("Unexpected number of recovery codes. Given: %d, persisted: %d", len(recoveryCodes), len(persistedCodes))
+			}
+			for i, code := range recoveryCodes {
+				if !bytes.Equal(persistedCodes[i].Code, hashRecoveryCode(code)) {
+					t.Errorf("Recovery code %q has not been persisted hashed", code)
+				}
+			}
+		})
+	}
+}
+
+func TestProvider_VerifyMFA(t *testing.T) {
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	timeNow = func() time.Time { return testTOTPTime }
+
+	validToken := storage.Token{Model: gorm.Model{ID: 7}, Type: storage.TokenTypeMFA, ExpiresAt: testTOTPTime.Add(time.Minute)}
+	enabledUser := storage.User{EMail: "test@test.test", TOTPSecret: testTOTPSecret, TOTPEnabled: true}
+	emailOTPUser := storage.User{EMail: "test@test.test", EMailOTPEnabled: true}
+	emailOTP := storage.Token{Type: storage.TokenTypeEMailOTP, Token: "654321", ExpiresAt: testTOTPTime.Add(time.Minute)}
+
+	tests := []struct {
+		name                 string
+		tokens               []storage.Token
+		emailOTPTokens       []storage.Token
+		user                 storage.User
+		code                 string
+		recoveryCode         string
+		consumeRecoveryErr   error
+		totpStepNotUpdated   bool
+		mfaTokenAttempts     int
+		expectedError        error
+		expectedLoginFailure bool
+		expectedTokens       bool
+		expectedMFAConsumed  bool
+	}{
+		{
+			name:           "Happycase TOTP",
+			tokens:         []storage.Token{validToken},
+			user:           enabledUser,
+			code:           "050471",
+			expectedTokens: true,
+		}, {
+			name:           "Happycase recovery code",
+			tokens:         []storage.Token{validToken},
+			user:           enabledUser,
+			recoveryCode:   "ABCD-EFGH",
+			expectedTokens: true,
+		}, {
+			name:                 "Incorrect code with too many attempts",
+			tokens:               []storage.Token{validToken},
+			user:                 enabledUser,
+			code:                 "123456",
+			mfaTokenAttempts:     3,
+			expectedError:        ErrIncorrectMFACode,
+			expectedLoginFailure: true,
+			expectedMFAConsumed:  true,
+		}, {
+			name:          "Invalid mfa-token",
+			tokens:        []storage.Token{{Type: storage.TokenTypeReset, ExpiresAt: testTOTPTime.Add(time.Minute)}},
+			user:          enabledUser,
+			code:          "050471",
+			expectedError: ErrNoValidTokenFound,
+		}, {
+			name:          "Expired mfa-token",
+			tokens:        []storage.Token{{Type: storage.TokenTypeMFA, ExpiresAt: testTOTPTime.Add(-time.Second)}},
+			user:          enabledUser,
+			code:          "050471",
+			expectedError: ErrNoValidTokenFound,
+		}, {
+			name:          "MFA has been disabled",
+			tokens:        []storage.Token{validToken},
+			user:          storage.User{EMail: "test@test.test"},
+			code:          "050471",
+			expectedError: ErrNoValidTokenFound,
+		}, {
+			name:                 "Incorrect code",
+			tokens:               []storage.Token{validToken},
+			user:                 enabledUser,
+			code:                 "123456",
+			expectedError:        ErrIncorrectMFACode,
+			expectedLoginFailure: true,
+		}, {
+			name:                 "Code already used",
+			tokens:               []storage.Token{validToken},
+			user:                 storage.User{EMail: "test@test.test", TOTPSecret: testTOTPSecret, TOTPEnabled: true, TOTPLastStep: testTOTPTime.Unix() / 30},
+			code:                 "050471",
+			expectedError:        ErrIncorrectMFACode,
+			expectedLoginFailure: true,
+		}, {
+			name:                 "Code used concurrently",
+			tokens:               []storage.Token{validToken},
+			user:                 enabledUser,
+			code:                 "050471",
+			totpStepNotUpdated:   true,
+			expectedError:        ErrIncorrectMFACode,
+			expectedLoginFailure: true,
+		}, {
+			name:                 "Incorrect recovery code",
+			tokens:               []storage.Token{validToken},
+			user:                 enabledUser,
+			recoveryCode:         "ABCD-EFGH",
+			consumeRecoveryErr:   storage.ErrRecoveryCodeNotFound,
+			expectedError:        ErrIncorrectMFACode,
+			expectedLoginFailure: true,
+		}, {
+			name:           "Happycase email code",
+			tokens:         []storage.Token{validToken},
+			emailOTPTokens: []storage.Token{emailOTP},
+			user:           emailOTPUser,
+			code:           "654321",
+			expectedTokens: true,
+		}, {
+			name:           "Happycase TOTP code with email enabled",
+			tokens:         []storage.Token{validToken},
+			emailOTPTokens: []storage.Token{emailOTP},
+			user:           storage.User{EMail: "test@test.test", TOTPSecret: testTOTPSecret, TOTPEnabled: true, EMailOTPEnabled: true},
+			code:           "050471",
+			expectedTokens: true,
+		}, {
+			name:                 "Incorrect email code",
+			tokens:               []storage.Token{validToken},
+			emailOTPTokens:       []storage.Token{emailOTP},
+			user:                 emailOTPUser,
+			code:                 "111111",
+			expectedError:        ErrIncorrectMFACode,
+			expectedLoginFailure: true,
+		}, {
+			name:                 "Expired email code",
+			tokens:               []storage.Token{validToken},
+			emailOTPTokens:       []storage.Token{{Type: storage.TokenTypeEMailOTP, Token: "654321", ExpiresAt: testTOTPTime.Add(-time.Second)}},
+			user:                 emailOTPUser,
+			code:                 "654321",
+			expectedError:        ErrIncorrectMFACode,
+			expectedLoginFailure: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var loginFailureRegistered, mfaTokenConsumed bool
+			var consumedRecoveryCode []byte
+			toTest := Provider{
+				MFAMaxAttempts: 3,
+				LoginLockout:   LoginLockout{MaxFailures: 5, Duration: time.Minute, MaxDuration: time.Hour},
+				JWTProvider: &JWTProviderMock{
+					GenerateAccessTokenFunc: func(email string, userClaims map[string]interface{}) (string, error) {
+						return "access-token", nil
+					},
+					GenerateRefreshTokenFunc: func(email string) (string, string, error) {
+						return "refresh-token", "jwt-id", nil
+					},
+				},
+				Storage: &StorageMock{
+					LoginFailureFunc: func(key string) (storage.LoginFailure, error) {
+						return storage.LoginFailure{}, nil
+					},
+					RegisterLoginFailureFunc: func(key string, forgetBefore time.Time) (storage.LoginFailure, error) {
+						loginFailureRegistered = true
+						return storage.LoginFailure{Failures: 1}, nil
+					},
+					ResetLoginFailuresFunc: func(key string) error {
+						return nil
+					},
+					TokensByEMailAndTokenFunc: func(email, token string) ([]storage.Token, error) {
+						return tt.tokens, nil
+					},
+					UserFunc: func(email string) (storage.User, error) {
+						return tt.user, nil
+					},
+					UpdateTOTPLastStepFunc: func(email string, step int64) (bool, error) {
+						return !tt.totpStepNotUpdated, nil
+					},
+					ConsumeRecoveryCodeFunc: func(email string, code []byte) error {
+						consumedRecoveryCode = code
+						return tt.consumeRecoveryErr
+					},
+					ConsumeTokenFunc: func(id uint) error {
+						if id == validToken.ID {
+							mfaTokenConsumed = true
+						}
+						return nil
+					},
+					CreateTokenFunc: func(t *storage.Token) error {
+						return nil
+					},
+					TokensByEMailAndTypeFunc: func(email, tokenType string) ([]storage.Token, error) {
+						return tt.emailOTPTokens, nil
+					},
+					RegisterTokenAttemptFunc: func(id uint) (int, error) {
+						if id == validToken.ID {
+							return tt.mfaTokenAttempts, nil
+						}
+						return 1, nil
+					},
+					DeleteTokensByEMailAndTypeFunc: func(email, tokenType string) error {
+						return nil
+					},
+				},
+			}
+
+			accessToken, refreshToken, err := toTest.VerifyMFA("test@test.test", "mfa-token", tt.code, tt.recoveryCode, ClientInfo{IP: "10.0.0.1"})
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:%s\nGiven:%s", tt.expectedError, err)
+			}
+
+			if loginFailureRegistered != tt.expectedLoginFailure {
+				t.Errorf("Login failure registered is not as expected. Expected: %t, Given: %t", tt.expectedLoginFailure, loginFailureRegistered)
+			}
+			if tt.recoveryCode != "" && tt.expectedError == nil && !bytes.Equal(consumedRecoveryCode, hashRecoveryCode("abcdefgh")) {
+				t.Errorf("Recovery code has not been consumed normalized and hashed")
+			}
+			if (accessToken == "access-token" && refreshToken == "refresh-token") != tt.expectedTokens {
+				t.Errorf("Tokens are not as expected. Given: %q, %q", accessToken, refreshToken)
+			}
+			if tt.expectedError != nil && mfaTokenConsumed != tt.expectedMFAConsumed {
+				t.Errorf("MFA-token consumed is not as expected. Expected: %t, Given: %t", tt.expectedMFAConsumed, mfaTokenConsumed)
+			}
+		})
+	}
+}
+
+func TestProvider_DisableMFA(t *testing.T) {
+	var disabledEMail string
+	var replacedCodes []storage.RecoveryCode
+	replaced := false
+	var deletedTokenType string
+	toTest := Provider{
+		Storage: &StorageMock{
+			UserFunc: func(email string) (storage.User, error) {
+				return storage.User{EMail: email, TOTPSecret: testTOTPSecret, TOTPEnabled: true, TOTPLastStep: 42, EMailOTPEnabled: true}, nil
+			},
+			ReplaceRecoveryCodesFunc: func(email string, codes []storage.RecoveryCode) error {
+				replaced = true
+				replacedCodes = codes
+				return nil
+			},
+			DeleteTokensByEMailAndTypeFunc: func(email, tokenType string) error {
+				deletedTokenType = tokenType
+				return nil
+			},
+			DisableUserMFAFunc: func(email string) error {
+				disabledEMail = email
+				return nil
+			},
+		},
+	}
+
+	err := toTest.DisableMFA("test@test.test")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if !replaced || len(replacedCodes) != 0 {
+		t.Errorf("Recovery codes should have been deleted. Given: %#v", replacedCodes)
+	}
+
+	if deletedTokenType != storage.TokenTypeEMailOTP {
+		t.Errorf("Pending email one-time codes should have been deleted. Deleted type: %q", deletedTokenType)
+	}
+
+	if disabledEMail != "test@test.test" {
+		t.Errorf("Mfa has not been disabled. Disabled email: %q", disabledEMail)
+	}
+}
+
+func TestProvider_DisableMFA_UserNotFound(t *testing.T) {
+	toTest := Provider{
+		Storage: &StorageMock{
+			UserFunc: func(email string) (storage.User, error) {
+				return storage.User{}, storage.ErrUserNotFound
+			},
+		},
+	}
+
+	err := toTest.DisableMFA("test@test.test")
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Processing error is not as expected: \nExpected:%s\nGiven:%s", ErrUserNotFound, err)
+	}
+}
+
+func validAccessTokenJWTProvider() *JWTProviderMock {
+	return &JWTProviderMock{
+		IsTokenValidFunc: func(token string) (bool, jwt.MapClaims, error) {
+			return true, jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "typ": "access"}, nil
+		},
+	}
+}
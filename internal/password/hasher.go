@@ -0,0 +1,278 @@
+package password
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+	"strings"
+)
+
+const (
+	// AlgorithmBcrypt hashes passwords with bcrypt in the modular crypt format e.g. $2a$12$...
+	AlgorithmBcrypt = "bcrypt"
+	// AlgorithmArgon2id hashes passwords with argon2id in the PHC string format e.g. $argon2id$v=19$m=65536,t=3,p=2$...
+	AlgorithmArgon2id = "argon2id"
+	// AlgorithmScrypt hashes passwords with scrypt in the PHC string format e.g. $scrypt$ln=15,r=8,p=1$...
+	AlgorithmScrypt = "scrypt"
+)
+
+const (
+	saltLength = 16
+	keyLength  = 32
+)
+
+// ErrMismatchedHashAndPassword returned when the password does not match the hash
+var ErrMismatchedHashAndPassword = errors.New("hash and password do not match")
+
+// ErrUnknownHashFormat returned when the hash has not been created by any supported algorithm
+var ErrUnknownHashFormat = errors.New("unknown hash format")
+
+var randRead = rand.Read
+
+// Argon2idParams configures the argon2id algorithm. Memory is given in KiB.
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// ScryptParams configures the scrypt algorithm. The cost parameter N is 2^CostExponent.
+type ScryptParams struct {
+	CostExponent int
+	BlockSize    int
+	Parallelism  int
+}
+
+// Hasher should be created via NewHasher and hashes passwords with the configured algorithm. It verifies hashes of all
+// supported algorithms, so stored hashes stay valid after the configuration has been changed.
+type Hasher struct {
+	algorithm  string
+	bcryptCost int
+	argon2id   Argon2idParams
+	scrypt     ScryptParams
+	// dummyHash is a hash of a random password with the configured algorithm and parameters, see CompareDummy
+	dummyHash []byte
+}
+
+// NewHasher returns a Hasher which hashes passwords with the given algorithm and its parameters. Parameters of other
+// algorithms are ignored.
+func NewHasher(algorithm string, bcryptCost int, argon2idParams Argon2idParams, scryptParams ScryptParams) (Hasher, error) {
+	h := Hasher{
+		algorithm:  algorithm,
+		bcryptCost: bcryptCost,
+		argon2id:   argon2idParams,
+		scrypt:     scryptParams,
+	}
+
+	switch algorithm {
+	case AlgorithmBcrypt:
+		if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+			return Hasher{}, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case AlgorithmArgon2id:
+		if argon2idParams.Memory == 0 || argon2idParams.Iterations == 0 || argon2idParams.Parallelism == 0 {
+			return Hasher{}, errors.New("argon2id memory, iterations and parallelism must be greater than 0")
+		}
+	case AlgorithmScrypt:
+		if scryptParams.CostExponent < 1 || scryptParams.CostExponent > 30 {
+			return Hasher{}, errors.New("scrypt cost exponent must be between 1 and 30")
+		}
+		if scryptParams.BlockSize < 1 || scryptParams.Parallelism < 1 || scryptParams.BlockSize*scryptParams.Parallelism >= 1<<30 {
+			return Hasher{}, errors.New("scrypt block size and parallelism must be greater than 0 and their product less than 2^30")
+		}
+	default:
+		return Hasher{}, fmt.Errorf("unsupported password hash algorithm %q", algorithm)
+	}
+
+	dummyPassword := make([]byte, saltLength)
+	_, err := randRead(dummyPassword)
+	if err != nil {
+		return Hasher{}, fmt.Errorf("failed to generate dummy password: %w", err)
+	}
+
+	h.dummyHash, err = h.Hash(string(dummyPassword))
+	if err != nil {
+		return Hasher{}, fmt.Errorf("failed to hash dummy password: %w", err)
+	}
+
+	return h, nil
+}
+
+// Hash hashes the given password with the configured algorithm and a random salt
+func (h Hasher) Hash(password string) ([]byte, error) {
+	if h.algorithm == AlgorithmBcrypt {
+		return bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+	}
+
+	salt := make([]byte, saltLength)
+	_, err := randRead(salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	if h.algorithm == AlgorithmArgon2id {
+		return encodeArgon2id(h.argon2id, salt, argon2.IDKey([]byte(password), salt, h.argon2id.Iterations, h.argon2id.Memory, h.argon2id.Parallelism, keyLength)), nil
+	}
+
+	key, err := scrypt.Key([]byte(password), salt, 1<<uint(h.scrypt.CostExponent), h.scrypt.BlockSize, h.scrypt.Parallelism, keyLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password with scrypt: %w", err)
+	}
+
+	return encodeScrypt(h.scrypt, salt, key), nil
+}
+
+// Compare compares the given hash of any supported algorithm with the given password.
+// return ErrMismatchedHashAndPassword when the password does not match the hash
+// return ErrUnknownHashFormat when the hash could not be parsed
+func (h Hasher) Compare(hash []byte, password string) error {
+	var key, expectedKey []byte
+	switch {
+	case isBcrypt(hash):
+		err := bcrypt.CompareHashAndPassword(hash, []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatchedHashAndPassword
+		}
+		return err
+	case bytes.HasPrefix(hash, []byte("$"+AlgorithmArgon2id+"$")):
+		params, salt, k, err := decodeArgon2id(string(hash))
+		if err != nil {
+			return err
+		}
+		expectedKey = k
+		key = argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(k)))
+	case bytes.HasPrefix(hash, []byte("$"+AlgorithmScrypt+"$")):
+		params, salt, k, err := decodeScrypt(string(hash))
+		if err != nil {
+			return err
+		}
+		expectedKey = k
+		key, err = scrypt.Key([]byte(password), salt, 1<<uint(params.CostExponent), params.BlockSize, params.Parallelism, len(k))
+		if err != nil {
+			return fmt.Errorf("failed to hash password with scrypt: %w", err)
+		}
+	default:
+		return ErrUnknownHashFormat
+	}
+
+	if subtle.ConstantTimeCompare(key, expectedKey) != 1 {
+		return ErrMismatchedHashAndPassword
+	}
+
+	return nil
+}
+
+// CompareDummy compares the given password with a hash of a random password which has been created with the configured
+// algorithm and parameters. It takes as long as Compare with an up to date hash, so it can be used to hide whether a
+// user exists.
+func (h Hasher) CompareDummy(password string) {
+	_ = h.Compare(h.dummyHash, password)
+}
+
+// NeedsRehash checks whether the given hash has been created with another algorithm or other parameters than the
+// configured ones
+func (h Hasher) NeedsRehash(hash []byte) bool {
+	switch h.algorithm {
+	case AlgorithmBcrypt:
+		if !isBcrypt(hash) {
+			return true
+		}
+		cost, err := bcrypt.Cost(hash)
+		return err != nil || cost != h.bcryptCost
+	case AlgorithmArgon2id:
+		params, _, _, err := decodeArgon2id(string(hash))
+		return err != nil || params != h.argon2id
+	case AlgorithmScrypt:
+		params, _, _, err := decodeScrypt(string(hash))
+		return err != nil || params != h.scrypt
+	}
+
+	return true
+}
+
+func isBcrypt(hash []byte) bool {
+	return bytes.HasPrefix(hash, []byte("$2"))
+}
+
+func encodeArgon2id(params Argon2idParams, salt, key []byte) []byte {
+	return []byte(fmt.Sprintf(
+		"$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		AlgorithmArgon2id,
+		argon2.Version,
+		params.Memory,
+		params.Iterations,
+		params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	))
+}
+
+func decodeArgon2id(hash string) (Argon2idParams, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return Argon2idParams{}, nil, nil, ErrUnknownHashFormat
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return Argon2idParams{}, nil, nil, fmt.Errorf("%w: unsupported argon2id version %q", ErrUnknownHashFormat, parts[2])
+	}
+
+	var params Argon2idParams
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil || params.Iterations == 0 || params.Parallelism == 0 {
+		return Argon2idParams{}, nil, nil, fmt.Errorf("%w: invalid argon2id parameters %q", ErrUnknownHashFormat, parts[3])
+	}
+
+	salt, key, err := decodeSaltAndKey(parts[4], parts[5])
+	return params, salt, key, err
+}
+
+func encodeScrypt(params ScryptParams, salt, key []byte) []byte {
+	return []byte(fmt.Sprintf(
+		"$%s$ln=%d,r=%d,p=%d$%s$%s",
+		AlgorithmScrypt,
+		params.CostExponent,
+		params.BlockSize,
+		params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	))
+}
+
+func decodeScrypt(hash string) (ScryptParams, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 || parts[1] != AlgorithmScrypt {
+		return ScryptParams{}, nil, nil, ErrUnknownHashFormat
+	}
+
+	var params ScryptParams
+	_, err := fmt.Sscanf(parts[2], "ln=%d,r=%d,p=%d", &params.CostExponent, &params.BlockSize, &params.Parallelism)
+	if err != nil || params.CostExponent < 1 || params.CostExponent > 30 {
+		return ScryptParams{}, nil, nil, fmt.Errorf("%w: invalid scrypt parameters %q", ErrUnknownHashFormat, parts[2])
+	}
+
+	salt, key, err := decodeSaltAndKey(parts[3], parts[4])
+	return params, salt, key, err
+}
+
+func decodeSaltAndKey(encodedSalt, encodedKey string) ([]byte, []byte, error) {
+	salt, err := base64.RawStdEncoding.DecodeString(encodedSalt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: invalid salt: %s", ErrUnknownHashFormat, err)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(encodedKey)
+	if err != nil || len(key) == 0 {
+		return nil, nil, fmt.Errorf("%w: invalid key", ErrUnknownHashFormat)
+	}
+
+	return salt, key, nil
+}
@@ -0,0 +1,202 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"testing"
+)
+
+var (
+	testArgon2idParams = Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1}
+	testScryptParams   = ScryptParams{CostExponent: 4, BlockSize: 8, Parallelism: 1}
+)
+
+func TestNewHasher(t *testing.T) {
+	tests := []struct {
+		name           string
+		algorithm      string
+		bcryptCost     int
+		argon2idParams Argon2idParams
+		scryptParams   ScryptParams
+		expectedError  error
+	}{
+		{
+			name:       "bcrypt",
+			algorithm:  AlgorithmBcrypt,
+			bcryptCost: bcrypt.MinCost,
+		}, {
+			name:           "argon2id",
+			algorithm:      AlgorithmArgon2id,
+			argon2idParams: testArgon2idParams,
+		}, {
+			name:         "scrypt",
+			algorithm:    AlgorithmScrypt,
+			scryptParams: testScryptParams,
+		}, {
+			name:          "Unsupported algorithm",
+			algorithm:     "md5",
+			expectedError: errors.New("unsupported password hash algorithm \"md5\""),
+		}, {
+			name:          "Invalid bcrypt cost",
+			algorithm:     AlgorithmBcrypt,
+			bcryptCost:    32,
+			expectedError: errors.New("bcrypt cost must be between 4 and 31"),
+		}, {
+			name:           "Invalid argon2id params",
+			algorithm:      AlgorithmArgon2id,
+			argon2idParams: Argon2idParams{Memory: 64, Iterations: 0, Parallelism: 1},
+			expectedError:  errors.New("argon2id memory, iterations and parallelism must be greater than 0"),
+		}, {
+			name:          "Invalid scrypt cost exponent",
+			algorithm:     AlgorithmScrypt,
+			scryptParams:  ScryptParams{CostExponent: 0, BlockSize: 8, Parallelism: 1},
+			expectedError: errors.New("scrypt cost exponent must be between 1 and 30"),
+		}, {
+			name:          "Invalid scrypt block size",
+			algorithm:     AlgorithmScrypt,
+			scryptParams:  ScryptParams{CostExponent: 15, BlockSize: 0, Parallelism: 1},
+			expectedError: errors.New("scrypt block size and parallelism must be greater than 0 and their product less than 2^30"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := NewHasher(tt.algorithm, tt.bcryptCost, tt.argon2idParams, tt.scryptParams)
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:\n%s\nGiven:\n%s", tt.expectedError, err)
+			}
+
+			// the dummy hash must take as long to compare as up to date hashes
+			if err == nil && h.NeedsRehash(h.dummyHash) {
+				t.Errorf("Dummy hash has not been created with the configured algorithm and parameters: %s", h.dummyHash)
+			}
+		})
+	}
+}
+
+func TestHasher_HashAndCompare(t *testing.T) {
+	tests := []struct {
+		name               string
+		algorithm          string
+		expectedHashPrefix string
+	}{
+		{name: "bcrypt", algorithm: AlgorithmBcrypt, expectedHashPrefix: "$2a$04$"},
+		{name: "argon2id", algorithm: AlgorithmArgon2id, expectedHashPrefix: "$argon2id$v=19$m=64,t=1,p=1$"},
+		{name: "scrypt", algorithm: AlgorithmScrypt, expectedHashPrefix: "$scrypt$ln=4,r=8,p=1$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toTest, err := NewHasher(tt.algorithm, bcrypt.MinCost, testArgon2idParams, testScryptParams)
+			if err != nil {
+				t.Fatalf("Failed to create hasher: %s", err)
+			}
+
+			hash, err := toTest.Hash("s3cr3t")
+			if err != nil {
+				t.Fatalf("Failed to hash password: %s", err)
+			}
+
+			if !strings.HasPrefix(string(hash), tt.expectedHashPrefix) {
+				t.Errorf("Hash is not as expected: \nExpected prefix:%s\nGiven:%s", tt.expectedHashPrefix, hash)
+			}
+
+			otherHash, err := toTest.Hash("s3cr3t")
+			if err != nil {
+				t.Fatalf("Failed to hash password: %s", err)
+			}
+			if string(hash) == string(otherHash) {
+				t.Errorf("Hashes of the same password should be salted differently: %s", hash)
+			}
+
+			err = toTest.Compare(hash, "s3cr3t")
+			if err != nil {
+				t.Errorf("Correct password should match the hash: %s", err)
+			}
+
+			err = toTest.Compare(hash, "wrong")
+			if !errors.Is(err, ErrMismatchedHashAndPassword) {
+				t.Errorf("Processing error is not as expected: \nExpected:\n%s\nGiven:\n%s", ErrMismatchedHashAndPassword, err)
+			}
+		})
+	}
+}
+
+func TestHasher_Compare(t *testing.T) {
+	// the hasher compares hashes of all supported algorithms independent of its configured algorithm
+	toTest, err := NewHasher(AlgorithmBcrypt, bcrypt.MinCost, Argon2idParams{}, ScryptParams{})
+	if err != nil {
+		t.Fatalf("Failed to create hasher: %s", err)
+	}
+
+	tests := []struct {
+		name          string
+		hash          string
+		expectedError error
+	}{
+		{
+			name: "bcrypt",
+			hash: "$2a$04$PrE3YgRQ0F.97KeveJgU3.E7PFYpjkmJLRfuYwbvyUTlzFPrdNN16",
+		}, {
+			name: "argon2id",
+			hash: "$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$Wb9DOLKUgwlL5fjad9tfCPU0SBAo0PEY/evJRhwtUR0",
+		}, {
+			name: "scrypt",
+			hash: "$scrypt$ln=4,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$5f/Vi+XRWGUNGScbsma6KJ4zLFIke/NJsrvr7lQLAyA",
+		}, {
+			name:          "Unknown format",
+			hash:          "$md5$salt$hash",
+			expectedError: ErrUnknownHashFormat,
+		}, {
+			name:          "Unsupported argon2 version",
+			hash:          "$argon2id$v=16$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$Wb9DOLKUgwlL5fjad9tfCPU0SBAo0PEY/evJRhwtUR0",
+			expectedError: errors.New("unknown hash format: unsupported argon2id version \"v=16\""),
+		}, {
+			name:          "Invalid scrypt parameters",
+			hash:          "$scrypt$ln=0,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$5f/Vi+XRWGUNGScbsma6KJ4zLFIke/NJsrvr7lQLAyA",
+			expectedError: errors.New("unknown hash format: invalid scrypt parameters \"ln=0,r=8,p=1\""),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := toTest.Compare([]byte(tt.hash), "password")
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:\n%s\nGiven:\n%s", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestHasher_NeedsRehash(t *testing.T) {
+	bcryptHasher, _ := NewHasher(AlgorithmBcrypt, bcrypt.MinCost, Argon2idParams{}, ScryptParams{})
+	argon2idHasher, _ := NewHasher(AlgorithmArgon2id, 0, testArgon2idParams, ScryptParams{})
+	scryptHasher, _ := NewHasher(AlgorithmScrypt, 0, Argon2idParams{}, testScryptParams)
+
+	tests := []struct {
+		name     string
+		hasher   Hasher
+		hash     string
+		expected bool
+	}{
+		{name: "bcrypt up to date", hasher: bcryptHasher, hash: "$2a$04$PrE3YgRQ0F.97KeveJgU3.E7PFYpjkmJLRfuYwbvyUTlzFPrdNN16", expected: false},
+		{name: "bcrypt other cost", hasher: bcryptHasher, hash: "$2a$12$1v7O.pNLqugJjcePyxvUj.GK37YoAbJvSW/9bULSRmq5C4SkoU2OO", expected: true},
+		{name: "bcrypt other algorithm", hasher: bcryptHasher, hash: "$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$Wb9DOLKUgwlL5fjad9tfCPU0SBAo0PEY/evJRhwtUR0", expected: true},
+		{name: "argon2id up to date", hasher: argon2idHasher, hash: "$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$Wb9DOLKUgwlL5fjad9tfCPU0SBAo0PEY/evJRhwtUR0", expected: false},
+		{name: "argon2id other params", hasher: argon2idHasher, hash: "$argon2id$v=19$m=128,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$Wb9DOLKUgwlL5fjad9tfCPU0SBAo0PEY/evJRhwtUR0", expected: true},
+		{name: "argon2id other algorithm", hasher: argon2idHasher, hash: "$2a$04$PrE3YgRQ0F.97KeveJgU3.E7PFYpjkmJLRfuYwbvyUTlzFPrdNN16", expected: true},
+		{name: "scrypt up to date", hasher: scryptHasher, hash: "$scrypt$ln=4,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$5f/Vi+XRWGUNGScbsma6KJ4zLFIke/NJsrvr7lQLAyA", expected: false},
+		{name: "scrypt other params", hasher: scryptHasher, hash: "$scrypt$ln=15,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$5f/Vi+XRWGUNGScbsma6KJ4zLFIke/NJsrvr7lQLAyA", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			needsRehash := tt.hasher.NeedsRehash([]byte(tt.hash))
+			if needsRehash != tt.expected {
+				t.Errorf("NeedsRehash is not as expected. Expected: %t, Given: %t", tt.expected, needsRehash)
+			}
+		})
+	}
+}
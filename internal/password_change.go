@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+)
+
+// ChangePassword changes the password of the user of the given access-token when the current password is correct.
+// return ErrInvalidToken when the token is not an active access-token or its user does not exist anymore
+// return ErrIncorrectPassword when the current password is incorrect, it counts as failed login
+// return LockoutError when logins of the email or the client are locked cause of too many failed logins
+// return PasswordPolicyError when the new password violates the password policy, has been breached or used before
+func (p Provider) ChangePassword(accessToken, currentPassword, newPassword string, client ClientInfo) error {
+	email, err := p.accessTokenEMail(accessToken)
+	if err != nil {
+		return err
+	}
+
+	u, err := p.Storage.User(email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return ErrInvalidToken
+		}
+
+		return fmt.Errorf("failed to find user with email %q: %w", email, err)
+	}
+
+	err = p.verifyPassword(u, currentPassword, client)
+	if err != nil {
+		return err
+	}
+
+	err = p.PasswordPolicy.Validate(email, newPassword)
+	if err != nil {
+		return err
+	}
+
+	breached, err := p.checkBreachedPassword(email, newPassword)
+	if err != nil {
+		return err
+	}
+
+	err = p.checkPasswordHistory(u, newPassword)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := p.PasswordHasher.Hash(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash new password: %w", err)
+	}
+
+	err = p.recordPasswordHistory(u)
+	if err != nil {
+		return err
+	}
+
+	err = p.Storage.SetUserPassword(email, hashedPassword, breached)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return nil
+}
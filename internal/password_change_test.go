@@ -0,0 +1,160 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestProvider_ChangePassword(t *testing.T) {
+	currentHash, _ := testPasswordHasher.Hash("current-password")
+
+	tests := []struct {
+		name                 string
+		isTokenValid         bool
+		userErr              error
+		currentPassword      string
+		newPassword          string
+		historySize          int
+		lockedUntil          time.Time
+		updateUserErr        error
+		expectedError        error
+		expectedLoginFailure bool
+		expectedUpdate       bool
+		expectedHistoryAdded bool
+	}{
+		{
+			name:                 "Happycase",
+			isTokenValid:         true,
+			currentPassword:      "current-password",
+			newPassword:          "new-password",
+			historySize:          3,
+			expectedUpdate:       true,
+			expectedHistoryAdded: true,
+		}, {
+			name:            "Invalid token",
+			currentPassword: "current-password",
+			newPassword:     "new-password",
+			expectedError:   ErrInvalidToken,
+		}, {
+			name:            "User does not exist anymore",
+			isTokenValid:    true,
+			userErr:         storage.ErrUserNotFound,
+			currentPassword: "current-password",
+			newPassword:     "new-password",
+			expectedError:   ErrInvalidToken,
+		}, {
+			name:            "Unable to find user",
+			isTokenValid:    true,
+			userErr:         errors.New("nope"),
+			currentPassword: "current-password",
+			newPassword:     "new-password",
+			expectedError:   errors.New("failed to find user with email \"test@test.test\": nope"),
+		}, {
+			name:                 "Incorrect current password",
+			isTokenValid:         true,
+			currentPassword:      "wrong-password",
+			newPassword:          "new-password",
+			expectedError:        ErrIncorrectPassword,
+			expectedLoginFailure: true,
+		}, {
+			name:            "Locked",
+			isTokenValid:    true,
+			currentPassword: "current-password",
+			newPassword:     "new-password",
+			lockedUntil:     time.Now().Add(time.Minute),
+			expectedError:   ErrAccountLocked,
+		}, {
+			name:            "Password policy violated",
+			isTokenValid:    true,
+			currentPassword: "current-password",
+			newPassword:     "short",
+			expectedError:   errors.New("password violates the password policy: min_length"),
+		}, {
+			name:            "Password reused",
+			isTokenValid:    true,
+			currentPassword: "current-password",
+			newPassword:     "current-password",
+			historySize:     1,
+			expectedError:   errors.New("password violates the password policy: history"),
+		}, {
+			name:                 "Unable to update user",
+			isTokenValid:         true,
+			currentPassword:      "current-password",
+			newPassword:          "new-password",
+			historySize:          3,
+			updateUserErr:        errors.New("nope"),
+			expectedError:        errors.New("failed to update user: nope"),
+			expectedUpdate:       true,
+			expectedHistoryAdded: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updatedPassword []byte
+			var historyAdded, loginFailureRegistered bool
+
+			toTest := Provider{
+				PasswordHasher:      testPasswordHasher,
+				PasswordPolicy:      PasswordPolicy{MinLength: 8},
+				PasswordHistorySize: tt.historySize,
+				LoginLockout:        LoginLockout{MaxFailures: 5, Duration: time.Minute, MaxDuration: time.Hour},
+				JWTProvider: &JWTProviderMock{
+					IsTokenValidFunc: func(token string) (bool, jwt.MapClaims, error) {
+						return tt.isTokenValid, jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "typ": "access"}, nil
+					},
+				},
+				Storage: &StorageMock{
+					IsTokenRevokedFunc: func(jit string) (bool, error) {
+						return false, nil
+					},
+					LoginFailureFunc: func(key string) (storage.LoginFailure, error) {
+						return storage.LoginFailure{LockedUntil: tt.lockedUntil}, nil
+					},
+					RegisterLoginFailureFunc: func(key string, forgetBefore time.Time) (storage.LoginFailure, error) {
+						loginFailureRegistered = true
+						return storage.LoginFailure{Failures: 1}, nil
+					},
+					UserFunc: func(email string) (storage.User, error) {
+						return storage.User{EMail: email, Password: currentHash}, tt.userErr
+					},
+					PasswordHistoryFunc: func(email string) ([]storage.PasswordHistory, error) {
+						return nil, nil
+					},
+					AddPasswordHistoryFunc: func(h storage.PasswordHistory, keep int) error {
+						historyAdded = string(h.Password) == string(currentHash)
+						return nil
+					},
+					SetUserPasswordFunc: func(email string, password []byte, breached bool) error {
+						updatedPassword = password
+						return tt.updateUserErr
+					},
+				},
+			}
+
+			err := toTest.ChangePassword("myAccessToken", tt.currentPassword, tt.newPassword, ClientInfo{IP: "127.0.0.1"})
+			if !errors.Is(err, tt.expectedError) && fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:%s\nGiven:%s", tt.expectedError, err)
+			}
+
+			if loginFailureRegistered != tt.expectedLoginFailure {
+				t.Errorf("Login failure has been registered is not as expected. Expected: %t, Given: %t", tt.expectedLoginFailure, loginFailureRegistered)
+			}
+
+			if historyAdded != tt.expectedHistoryAdded {
+				t.Errorf("Current password has been added to history is not as expected. Expected: %t, Given: %t", tt.expectedHistoryAdded, historyAdded)
+			}
+
+			if (updatedPassword != nil) != tt.expectedUpdate {
+				t.Fatalf("User has been updated is not as expected. Expected: %t, Given: %t", tt.expectedUpdate, updatedPassword != nil)
+			}
+			if updatedPassword != nil && testPasswordHasher.Compare(updatedPassword, tt.newPassword) != nil {
+				t.Errorf("Updated password does not match the new password")
+			}
+		})
+	}
+}
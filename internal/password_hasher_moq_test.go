@@ -0,0 +1,208 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package internal
+
+import (
+	"sync"
+)
+
+// Ensure, that PasswordHasherMock does implement PasswordHasher.
+// If this is not the case, regenerate this file with moq.
+var _ PasswordHasher = &PasswordHasherMock{}
+
+// PasswordHasherMock is a mock implementation of PasswordHasher.
+//
+// 	func TestSomethingThatUsesPasswordHasher(t *testing.T) {
+//
+// 		// make and configure a mocked PasswordHasher
+// 		mockedPasswordHasher := &PasswordHasherMock{
+// 			CompareFunc: func(hash []byte, password string) error {
+// 				panic("mock out the Compare method")
+// 			},
+// 			CompareDummyFunc: func(password string)  {
+// 				panic("mock out the CompareDummy method")
+// 			},
+// 			HashFunc: func(password string) ([]byte, error) {
+// 				panic("mock out the Hash method")
+// 			},
+// 			NeedsRehashFunc: func(hash []byte) bool {
+// 				panic("mock out the NeedsRehash method")
+// 			},
+// 		}
+//
+// 		// use mockedPasswordHasher in code that requires PasswordHasher
+// 		// and then make assertions.
+//
+// 	}
+type PasswordHasherMock struct {
+	// CompareFunc mocks the Compare method.
+	CompareFunc func(hash []byte, password string) error
+
+	// CompareDummyFunc mocks the CompareDummy method.
+	CompareDummyFunc func(password string)
+
+	// HashFunc mocks the Hash method.
+	HashFunc func(password string) ([]byte, error)
+
+	// NeedsRehashFunc mocks the NeedsRehash method.
+	NeedsRehashFunc func(hash []byte) bool
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Compare holds details about calls to the Compare method.
+		Compare []struct {
+			// Hash is the hash argument value.
+			Hash []byte
+			// Password is the password argument value.
+			Password string
+		}
+		// CompareDummy holds details about calls to the CompareDummy method.
+		CompareDummy []struct {
+			// Password is the password argument value.
+			Password string
+		}
+		// Hash holds details about calls to the Hash method.
+		Hash []struct {
+			// Password is the password argument value.
+			Password string
+		}
+		// NeedsRehash holds details about calls to the NeedsRehash method.
+		NeedsRehash []struct {
+			// Hash is the hash argument value.
+			Hash []byte
+		}
+	}
+	lockCompare      sync.RWMutex
+	lockCompareDummy sync.RWMutex
+	lockHash         sync.RWMutex
+	lockNeedsRehash  sync.RWMutex
+}
+
+// Compare calls CompareFunc.
+func (mock *PasswordHasherMock) Compare(hash []byte, password string) error {
+	if mock.CompareFunc == nil {
+		panic("PasswordHasherMock.CompareFunc: method is nil but PasswordHasher.Compare was just called")
+	}
+	callInfo := struct {
+		Hash     []byte
+		Password string
+	}{
+		Hash:     hash,
+		Password: password,
+	}
+	mock.lockCompare.Lock()
+	mock.calls.Compare = append(mock.calls.Compare, callInfo)
+	mock.lockCompare.Unlock()
+	return mock.CompareFunc(hash, password)
+}
+
+// CompareCalls gets all the calls that were made to Compare.
+// Check the length with:
+//     len(mockedPasswordHasher.CompareCalls())
+func (mock *PasswordHasherMock) CompareCalls() []struct {
+	Hash     []byte
+	Password string
+} {
+	var calls []struct {
+		Hash     []byte
+		Password string
+	}
+	mock.lockCompare.RLock()
+	calls = mock.calls.Compare
+	mock.lockCompare.RUnlock()
+	return calls
+}
+
+// CompareDummy calls CompareDummyFunc.
+func (mock *PasswordHasherMock) CompareDummy(password string) {
+	if mock.CompareDummyFunc == nil {
+		panic("PasswordHasherMock.CompareDummyFunc: method is nil but PasswordHasher.CompareDummy was just called")
+	}
+	callInfo := struct {
+		Password string
+	}{
+		Password: password,
+	}
+	mock.lockCompareDummy.Lock()
+	mock.calls.CompareDummy = append(mock.calls.CompareDummy, callInfo)
+	mock.lockCompareDummy.Unlock()
+	mock.CompareDummyFunc(password)
+}
+
+// CompareDummyCalls gets all the calls that were made to CompareDummy.
+// Check the length with:
+//     len(mockedPasswordHasher.CompareDummyCalls())
+func (mock *PasswordHasherMock) CompareDummyCalls() []struct {
+	Password string
+} {
+	var calls []struct {
+		Password string
+	}
+	mock.lockCompareDummy.RLock()
+	calls = mock.calls.CompareDummy
+	mock.lockCompareDummy.RUnlock()
+	return calls
+}
+
+// Hash calls HashFunc.
+func (mock *PasswordHasherMock) Hash(password string) ([]byte, error) {
+	if mock.HashFunc == nil {
+		panic("PasswordHasherMock.HashFunc: method is nil but PasswordHasher.Hash was just called")
+	}
+	callInfo := struct {
+		Password string
+	}{
+		Password: password,
+	}
+	mock.lockHash.Lock()
+	mock.calls.Hash = append(mock.calls.Hash, callInfo)
+	mock.lockHash.Unlock()
+	return mock.HashFunc(password)
+}
+
+// HashCalls gets all the calls that were made to Hash.
+// Check the length with:
+//     len(mockedPasswordHasher.HashCalls())
+func (mock *PasswordHasherMock) HashCalls() []struct {
+	Password string
+} {
+	var calls []struct {
+		Password string
+	}
+	mock.lockHash.RLock()
+	calls = mock.calls.Hash
+	mock.lockHash.RUnlock()
+	return calls
+}
+
+// NeedsRehash calls NeedsRehashFunc.
+func (mock *PasswordHasherMock) NeedsRehash(hash []byte) bool {
+	if mock.NeedsRehashFunc == nil {
+		panic("PasswordHasherMock.NeedsRehashFunc: method is nil but PasswordHasher.NeedsRehash was just called")
+	}
+	callInfo := struct {
+		Hash []byte
+	}{
+		Hash: hash,
+	}
+	mock.lockNeedsRehash.Lock()
+	mock.calls.NeedsRehash = append(mock.calls.NeedsRehash, callInfo)
+	mock.lockNeedsRehash.Unlock()
+	return mock.NeedsRehashFunc(hash)
+}
+
+// NeedsRehashCalls gets all the calls that were made to NeedsRehash.
+// Check the length with:
+//     len(mockedPasswordHasher.NeedsRehashCalls())
+func (mock *PasswordHasherMock) NeedsRehashCalls() []struct {
+	Hash []byte
+} {
+	var calls []struct {
+		Hash []byte
+	}
+	mock.lockNeedsRehash.RLock()
+	calls = mock.calls.NeedsRehash
+	mock.lockNeedsRehash.RUnlock()
+	return calls
+}
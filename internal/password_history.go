@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+)
+
+// checkPasswordHistory checks whether the given new password is one of the last PasswordHistorySize passwords of the
+// given user.
+// return PasswordPolicyError when the password has been used before
+func (p Provider) checkPasswordHistory(u storage.User, newPassword string) error {
+	if p.PasswordHistorySize <= 0 {
+		return nil
+	}
+
+	hashes := [][]byte{u.Password}
+	if p.PasswordHistorySize > 1 {
+		history, err := p.Storage.PasswordHistory(u.EMail)
+		if err != nil {
+			return fmt.Errorf("failed to find password history: %w", err)
+		}
+
+		for i := 0; i < len(history) && i < p.PasswordHistorySize-1; i++ {
+			hashes = append(hashes, history[i].Password)
+		}
+	}
+
+	for _, hash := range hashes {
+		if p.PasswordHasher.Compare(hash, newPassword) == nil {
+			return PasswordPolicyError{Violations: []PasswordPolicyViolation{{
+				Rule:    "history",
+				Message: fmt.Sprintf("password must not be one of the last %d passwords", p.PasswordHistorySize),
+			}}}
+		}
+	}
+
+	return nil
+}
+
+// recordPasswordHistory adds the current password of the given user to its password history before it will be
+// replaced. The current password is always part of the history, so only PasswordHistorySize - 1 previous passwords
+// will be kept.
+func (p Provider) recordPasswordHistory(u storage.User) error {
+	if p.PasswordHistorySize <= 1 || len(u.Password) == 0 {
+		return nil
+	}
+
+	err := p.Storage.AddPasswordHistory(storage.PasswordHistory{EMail: u.EMail, Password: u.Password}, p.PasswordHistorySize-1)
+	if err != nil {
+		return fmt.Errorf("failed to add password history: %w", err)
+	}
+
+	return nil
+}
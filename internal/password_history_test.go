@@ -0,0 +1,170 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestProvider_checkPasswordHistory(t *testing.T) {
+	currentHash, _ := testPasswordHasher.Hash("current")
+	previousHash, _ := testPasswordHasher.Hash("previous")
+	oldestHash, _ := testPasswordHasher.Hash("oldest")
+	history := []storage.PasswordHistory{{Password: previousHash}, {Password: oldestHash}}
+
+	tests := []struct {
+		name          string
+		historySize   int
+		newPassword   string
+		historyErr    error
+		expectedError error
+	}{
+		{
+			name:        "History disabled",
+			historySize: 0,
+			newPassword: "current",
+		}, {
+			name:          "Current password",
+			historySize:   1,
+			newPassword:   "current",
+			expectedError: errors.New("password violates the password policy: history"),
+		}, {
+			name:          "Previous password",
+			historySize:   2,
+			newPassword:   "previous",
+			expectedError: errors.New("password violates the password policy: history"),
+		}, {
+			name:        "Password older than history size",
+			historySize: 2,
+			newPassword: "oldest",
+		}, {
+			name:          "Oldest password within history size",
+			historySize:   3,
+			newPassword:   "oldest",
+			expectedError: errors.New("password violates the password policy: history"),
+		}, {
+			name:        "New password",
+			historySize: 3,
+			newPassword: "new",
+		}, {
+			name:          "Unable to find password history",
+			historySize:   3,
+			newPassword:   "new",
+			historyErr:    errors.New("nope"),
+			expectedError: errors.New("failed to find password history: nope"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toTest := Provider{
+				PasswordHistorySize: tt.historySize,
+				PasswordHasher:      testPasswordHasher,
+				Storage: &StorageMock{
+					PasswordHistoryFunc: func(email string) ([]storage.PasswordHistory, error) {
+						return history, tt.historyErr
+					},
+				},
+			}
+
+			err := toTest.checkPasswordHistory(storage.User{EMail: "test@test.test", Password: currentHash}, tt.newPassword)
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:%s\nGiven:%s", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestProvider_recordPasswordHistory(t *testing.T) {
+	tests := []struct {
+		name          string
+		historySize   int
+		addErr        error
+		expectedAdded bool
+		expectedKeep  int
+		expectedError error
+	}{
+		{
+			name:        "History disabled",
+			historySize: 0,
+		}, {
+			name:        "Only current password",
+			historySize: 1,
+		}, {
+			name:          "Happycase",
+			historySize:   5,
+			expectedAdded: true,
+			expectedKeep:  4,
+		}, {
+			name:          "Unable to add password history",
+			historySize:   5,
+			addErr:        errors.New("nope"),
+			expectedAdded: true,
+			expectedKeep:  4,
+			expectedError: errors.New("failed to add password history: nope"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var added *storage.PasswordHistory
+			var givenKeep int
+			toTest := Provider{
+				PasswordHistorySize: tt.historySize,
+				Storage: &StorageMock{
+					AddPasswordHistoryFunc: func(h storage.PasswordHistory, keep int) error {
+						added = &h
+						givenKeep = keep
+						return tt.addErr
+					},
+				},
+			}
+
+			err := toTest.recordPasswordHistory(storage.User{EMail: "test@test.test", Password: []byte("hash")})
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:%s\nGiven:%s", tt.expectedError, err)
+			}
+
+			if (added != nil) != tt.expectedAdded {
+				t.Fatalf("Password history has been added is not as expected. Expected: %t, Given: %#v", tt.expectedAdded, added)
+			}
+			if added != nil && (added.EMail != "test@test.test" || string(added.Password) != "hash" || givenKeep != tt.expectedKeep) {
+				t.Errorf("Added password history is not as expected. Given: %#v, keep: %d", added, givenKeep)
+			}
+		})
+	}
+}
+
+func TestProvider_ResetPassword_PasswordReused(t *testing.T) {
+	currentHash, _ := testPasswordHasher.Hash("current-password")
+	var tokenConsumed bool
+
+	toTest := Provider{
+		PasswordHasher:      testPasswordHasher,
+		PasswordHistorySize: 1,
+		Storage: &StorageMock{
+			TokensByEMailAndTokenFunc: func(email, token string) ([]storage.Token, error) {
+				return []storage.Token{{Type: storage.TokenTypeReset, ExpiresAt: time.Now().Add(time.Hour)}}, nil
+			},
+			UserFunc: func(email string) (storage.User, error) {
+				return storage.User{EMail: email, Password: currentHash}, nil
+			},
+			ConsumeTokenFunc: func(id uint) error {
+				tokenConsumed = true
+				return nil
+			},
+		},
+	}
+
+	err := toTest.ResetPassword("test@test.test", "reset-token", "current-password")
+	expectedError := errors.New("password violates the password policy: history")
+	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
+		t.Fatalf("Processing error is not as expected: \nExpected:%s\nGiven:%s", expectedError, err)
+	}
+
+	if tokenConsumed {
+		t.Error("Reset-token should not be consumed when the password has been rejected")
+	}
+}
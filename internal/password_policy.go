@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrPasswordPolicyViolated returned when a new password does not fulfill the password policy
+var ErrPasswordPolicyViolated = errors.New("password violates the password policy")
+
+// PasswordPolicy configures the rules new passwords have to fulfill. Zero values disable the corresponding rule.
+type PasswordPolicy struct {
+	MinLength      int
+	MaxLength      int
+	RequireUpper   bool
+	RequireLower   bool
+	RequireDigit   bool
+	RequireSpecial bool
+	DisallowEMail  bool
+	DisallowCommon bool
+}
+
+// PasswordPolicyViolation describes a violated rule of the password policy
+type PasswordPolicyViolation struct {
+	Rule    string
+	Message string
+}
+
+// PasswordPolicyError is returned when a new password violates the password policy. It wraps ErrPasswordPolicyViolated.
+type PasswordPolicyError struct {
+	Violations []PasswordPolicyViolation
+}
+
+func (e PasswordPolicyError) Error() string {
+	rules := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		rules[i] = v.Rule
+	}
+
+	return fmt.Sprintf("%s: %s", ErrPasswordPolicyViolated, strings.Join(rules, ", "))
+}
+
+// Unwrap returns ErrPasswordPolicyViolated
+func (e PasswordPolicyError) Unwrap() error {
+	return ErrPasswordPolicyViolated
+}
+
+// Validate checks the given password of the user with the given email against all rules of the policy.
+// return PasswordPolicyError listing all violated rules
+func (pp PasswordPolicy) Validate(email, password string) error {
+	var violations []PasswordPolicyViolation
+	violated := func(rule, message string) {
+		violations = append(violations, PasswordPolicyViolation{Rule: rule, Message: message})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if pp.MinLength > 0 && length < pp.MinLength {
+		violated("min_length", fmt.Sprintf("password must be at least %d characters long", pp.MinLength))
+	}
+	if pp.MaxLength > 0 && length > pp.MaxLength {
+		violated("max_length", fmt.Sprintf("password must be at most %d characters long", pp.MaxLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsLetter(r):
+			hasSpecial = true
+		}
+	}
+	if pp.RequireUpper && !hasUpper {
+		violated("upper", "password must contain an uppercase letter")
+	}
+	if pp.RequireLower && !hasLower {
+		violated("lower", "password must contain a lowercase letter")
+	}
+	if pp.RequireDigit && !hasDigit {
+		violated("digit", "password must contain a digit")
+	}
+	if pp.RequireSpecial && !hasSpecial {
+		violated("special", "password must contain a special character")
+	}
+
+	normalized := strings.ToLower(password)
+	if pp.DisallowEMail && containsEMail(normalized, strings.ToLower(email)) {
+		violated("email", "password must not contain the email")
+	}
+	if pp.DisallowCommon && isCommonPassword(normalized) {
+		violated("common", "password is too common")
+	}
+
+	if len(violations) > 0 {
+		return PasswordPolicyError{Violations: violations}
+	}
+
+	return nil
+}
+
+// containsEMail checks whether the given password contains the given email or equals its local part
+func containsEMail(password, email string) bool {
+	if email == "" {
+		return false
+	}
+
+	localPart := strings.SplitN(email, "@", 2)[0]
+	return strings.Contains(password, email) || password == localPart
+}
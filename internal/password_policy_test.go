@@ -0,0 +1,159 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"reflect"
+	"testing"
+)
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	strictPolicy := PasswordPolicy{
+		MinLength:      8,
+		MaxLength:      16,
+		RequireUpper:   true,
+		RequireLower:   true,
+		RequireDigit:   true,
+		RequireSpecial: true,
+		DisallowEMail:  true,
+		DisallowCommon: true,
+	}
+
+	tests := []struct {
+		name               string
+		policy             PasswordPolicy
+		email              string
+		password           string
+		expectedViolations []string
+	}{
+		{
+			name:     "Disabled policy",
+			policy:   PasswordPolicy{},
+			email:    "test@test.test",
+			password: "a",
+		}, {
+			name:     "Valid password",
+			policy:   strictPolicy,
+			email:    "test@test.test",
+			password: "S3cure-Pässword",
+		}, {
+			name:               "Too short",
+			policy:             strictPolicy,
+			email:              "test@test.test",
+			password:           "S3c-re",
+			expectedViolations: []string{"min_length"},
+		}, {
+			name:               "Too long",
+			policy:             strictPolicy,
+			email:              "test@test.test",
+			password:           "S3cure-Password-which-is-too-long",
+			expectedViolations: []string{"max_length"},
+		}, {
+			name:               "Length counted in characters",
+			policy:             PasswordPolicy{MaxLength: 4},
+			password:           "äöüß",
+			expectedViolations: nil,
+		}, {
+			name:               "Missing character classes",
+			policy:             strictPolicy,
+			email:              "test@test.test",
+			password:           "onlylowercase",
+			expectedViolations: []string{"upper", "digit", "special"},
+		}, {
+			name:               "Missing lowercase",
+			policy:             strictPolicy,
+			email:              "test@test.test",
+			password:           "UPPER-CASE-1",
+			expectedViolations: []string{"lower"},
+		}, {
+			name:               "Contains email",
+			policy:             PasswordPolicy{DisallowEMail: true},
+			email:              "Test@test.test",
+			password:           "test@test.test!",
+			expectedViolations: []string{"email"},
+		}, {
+			name:               "Equals local part of email",
+			policy:             PasswordPolicy{DisallowEMail: true},
+			email:              "my-name@test.test",
+			password:           "My-Name",
+			expectedViolations: []string{"email"},
+		}, {
+			name:               "Common password",
+			policy:             PasswordPolicy{DisallowCommon: true},
+			password:           "P@ssw0rd",
+			expectedViolations: []string{"common"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(tt.email, tt.password)
+			if tt.expectedViolations == nil {
+				if err != nil {
+					t.Fatalf("Password should be valid but was: %s", err)
+				}
+				return
+			}
+
+			var policyErr PasswordPolicyError
+			if !errors.As(err, &policyErr) {
+				t.Fatalf("Processing error should be a PasswordPolicyError but was: %#v", err)
+			}
+
+			if !errors.Is(err, ErrPasswordPolicyViolated) {
+				t.Errorf("Processing error should wrap ErrPasswordPolicyViolated")
+			}
+
+			var rules []string
+			for _, v := range policyErr.Violations {
+				rules = append(rules, v.Rule)
+				if v.Message == "" {
+					t.Errorf("Violation %q should have a message", v.Rule)
+				}
+			}
+			if !reflect.DeepEqual(rules, tt.expectedViolations) {
+				t.Errorf("Violated rules are not as expected: \nExpected:%v\nGiven:%v", tt.expectedViolations, rules)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyError_Error(t *testing.T) {
+	err := PasswordPolicyError{Violations: []PasswordPolicyViolation{
+		{Rule: "min_length", Message: "password must be at least 8 characters long"},
+		{Rule: "common", Message: "password is too common"},
+	}}
+
+	expected := "password violates the password policy: min_length, common"
+	if fmt.Sprint(err) != expected {
+		t.Errorf("Error is not as expected: \nExpected:%s\nGiven:%s", expected, err)
+	}
+}
+
+func TestProvider_PasswordPolicyIsEnforced(t *testing.T) {
+	toTest := Provider{
+		PasswordPolicy: PasswordPolicy{MinLength: 8},
+		Storage: &StorageMock{
+			UserFunc: func(email string) (storage.User, error) {
+				return storage.User{EMail: email}, nil
+			},
+		},
+	}
+	expectedError := errors.New("password violates the password policy: min_length")
+
+	err := toTest.CreateUser(User{EMail: "test@test.test", Password: "short"})
+	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
+		t.Errorf("CreateUser error is not as expected: \nExpected:%s\nGiven:%s", expectedError, err)
+	}
+
+	_, err = toTest.UpdateUser("test@test.test", User{Password: "short"})
+	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
+		t.Errorf("UpdateUser error is not as expected: \nExpected:%s\nGiven:%s", expectedError, err)
+	}
+
+	err = toTest.ResetPassword("test@test.test", "reset-token", "short")
+	if fmt.Sprint(err) != fmt.Sprint(expectedError) {
+		t.Errorf("ResetPassword error is not as expected: \nExpected:%s\nGiven:%s", expectedError, err)
+	}
+}
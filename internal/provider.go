@@ -2,7 +2,10 @@ package internal
 
 import (
 	"github.com/golang-jwt/jwt"
+	"github.com/leberKleber/simple-jwt-provider/internal/jwk"
 	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"github.com/leberKleber/simple-jwt-provider/internal/webauthn"
+	"time"
 )
 
 // Storage encapsulates storage.Storage to generate mocks
@@ -11,10 +14,41 @@ type Storage interface {
 	User(email string) (storage.User, error)
 	CreateUser(user storage.User) error
 	UpdateUser(user storage.User) error
+	UpdateUnverifiedUser(user storage.User) error
+	UpdateUserPassword(email string, oldHash, newHash []byte) error
+	UpdateTOTPLastStep(email string, step int64) (bool, error)
+	SetUserPassword(email string, password []byte, breached bool) error
+	VerifyUserEMail(email string) error
+	EnrollUserTOTP(email, secret string) (bool, error)
+	EnableUserTOTP(email, secret string, step int64) (bool, error)
+	EnableUserEMailOTP(email string) error
+	DisableUserMFA(email string) error
 	DeleteUser(email string) error
+	DeleteUnverifiedUsers(before time.Time, limit int) (int64, error)
 	CreateToken(t *storage.Token) error
 	TokensByEMailAndToken(email, token string) ([]storage.Token, error)
-	DeleteToken(id uint) error
+	TokensByEMailAndType(email, tokenType string) ([]storage.Token, error)
+	ConsumedTokensByEMailAndToken(email, token string) ([]storage.Token, error)
+	ConsumeToken(id uint) error
+	RegisterTokenAttempt(id uint) (int, error)
+	DeleteTokensByFamily(family string) error
+	DeleteTokensByEMailAndFamily(email, family string) error
+	DeleteTokensByEMailAndType(email, tokenType string) error
+	DeleteExpiredTokens(before time.Time, legacyLifetimes map[string]time.Duration, limit int) (int64, error)
+	RevokeToken(t storage.RevokedToken) error
+	IsTokenRevoked(jit string) (bool, error)
+	DeleteExpiredRevokedTokens(before time.Time) error
+	LoginFailure(key string) (storage.LoginFailure, error)
+	RegisterLoginFailure(key string, forgetBefore time.Time) (storage.LoginFailure, error)
+	LockLogin(key string, until time.Time) error
+	ResetLoginFailures(key string) error
+	PasswordHistory(email string) ([]storage.PasswordHistory, error)
+	AddPasswordHistory(h storage.PasswordHistory, keep int) error
+	ReplaceRecoveryCodes(email string, codes []storage.RecoveryCode) error
+	ConsumeRecoveryCode(email string, code []byte) error
+	CreateWebAuthnCredential(c *storage.WebAuthnCredential) error
+	WebAuthnCredentialsByEMail(email string) ([]storage.WebAuthnCredential, error)
+	UpdateWebAuthnCredentialUsage(id uint, signCount uint32, lastUsedAt time.Time) (bool, error)
 }
 
 // JWTProvider encapsulates jwt.Provider to generate mocks
@@ -23,12 +57,34 @@ type JWTProvider interface {
 	GenerateAccessToken(email string, userClaims map[string]interface{}) (string, error)
 	GenerateRefreshToken(email string) (string, string, error)
 	IsTokenValid(token string) (bool, jwt.MapClaims, error)
+	JWKS() jwk.Set
+	AccessTokenLifetime() time.Duration
+	Issuer() string
+	SigningAlgorithms() []string
 }
 
 // Mailer encapsulates mailer.Mailer to generate mocks
 //go:generate moq -out mailer_moq_test.go . Mailer
 type Mailer interface {
 	SendPasswordResetRequestEMail(recipient, passwordResetToken string, claims map[string]interface{}) error
+	SendMFACodeEMail(recipient, code string, claims map[string]interface{}) error
+	SendMagicLinkEMail(recipient, magicLinkToken string, claims map[string]interface{}) error
+	SendEMailVerificationEMail(recipient, verificationToken string, claims map[string]interface{}) error
+}
+
+// PasswordHasher encapsulates password.Hasher to generate mocks
+//go:generate moq -out password_hasher_moq_test.go . PasswordHasher
+type PasswordHasher interface {
+	Hash(password string) ([]byte, error)
+	Compare(hash []byte, password string) error
+	CompareDummy(password string)
+	NeedsRehash(hash []byte) bool
+}
+
+// BreachedPasswords encapsulates breach.Dataset to generate mocks
+//go:generate moq -out breached_passwords_moq_test.go . BreachedPasswords
+type BreachedPasswords interface {
+	IsBreached(password string) (bool, error)
 }
 
 // Provider provides all necessary interfaces for use in internal
@@ -36,4 +92,46 @@ type Provider struct {
 	Storage     Storage
 	JWTProvider JWTProvider
 	Mailer      Mailer
+	// PasswordHasher hashes new passwords and compares them with stored hashes
+	PasswordHasher PasswordHasher
+	// RefreshTokenLifetime is the lifetime of persisted refresh-tokens, it should match the lifetime of the refresh-jwt
+	RefreshTokenLifetime time.Duration
+	// ResetTokenLifetime is the lifetime of password-reset-tokens
+	ResetTokenLifetime time.Duration
+	// MagicLinkEnabled enables passwordless logins via magic links which are sent via email
+	MagicLinkEnabled bool
+	// MagicLinkLifetime is the lifetime of magic-link-tokens which are sent via email for passwordless logins
+	MagicLinkLifetime time.Duration
+	// PasswordPolicy configures the rules new passwords have to fulfill
+	PasswordPolicy PasswordPolicy
+	// BreachedPasswords will be consulted for new passwords when set
+	BreachedPasswords BreachedPasswords
+	// BreachedPasswordMode configures how breached passwords will be handled. See BreachedPasswordModeReject (default)
+	// and BreachedPasswordModeWarn
+	BreachedPasswordMode string
+	// PasswordHistorySize is the number of the last passwords (including the current one) which must not be reused on
+	// password reset and change. 0 disables the password history
+	PasswordHistorySize int
+	// MFATokenLifetime is the lifetime of mfa-tokens which are issued by Login when the user has to pass the multi-factor
+	// authentication
+	MFATokenLifetime time.Duration
+	// MFAMaxAttempts is the number of incorrect codes after which a mfa-token will be invalidated
+	MFAMaxAttempts int
+	// TOTPIssuer is the issuer of TOTP authenticators which will be shown in authenticator apps
+	TOTPIssuer string
+	// EMailOTPEnabled enables the enrollment of one-time codes via email as second factor
+	EMailOTPEnabled bool
+	// EMailOTPLifetime is the lifetime of one-time codes which are sent via email
+	EMailOTPLifetime time.Duration
+	// EMailOTPMaxAttempts is the number of incorrect attempts after which a one-time code sent via email will be
+	// invalidated
+	EMailOTPMaxAttempts int
+	// WebAuthn is the relying party of passkey logins. An empty ID disables WebAuthn
+	WebAuthn webauthn.RelyingParty
+	// WebAuthnTimeout is the time in which WebAuthn ceremonies have to be completed
+	WebAuthnTimeout time.Duration
+	// Registration configures the self-service registration of users
+	Registration Registration
+	// LoginLockout configures the lockout of logins after too many failed logins
+	LoginLockout LoginLockout
 }
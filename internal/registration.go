@@ -0,0 +1,194 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"github.com/sirupsen/logrus"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// ErrRegistrationDisabled returned when the self-service registration has not been enabled
+var ErrRegistrationDisabled = errors.New("registration disabled")
+
+// ErrInvalidEMail returned when the given email is not a valid email address
+var ErrInvalidEMail = errors.New("invalid email")
+
+// ErrEMailDomainNotAllowed returned when the domain of the given email is not allowed to register
+var ErrEMailDomainNotAllowed = errors.New("email domain not allowed")
+
+// ErrEMailNotVerified returned when a self-registered user has not verified the email yet
+var ErrEMailNotVerified = errors.New("email not verified")
+
+// Registration configures the self-service registration of users. Registered users have to verify their email before
+// they can login.
+type Registration struct {
+	Enabled bool
+	// AllowedDomains restricts the registration to emails of these domains. Empty allows all domains
+	AllowedDomains []string
+	// VerificationTokenLifetime is the lifetime of email-verification-tokens
+	VerificationTokenLifetime time.Duration
+}
+
+// domainAllowed checks whether the domain of the given email is allowed to register
+func (r Registration) domainAllowed(email string) bool {
+	if len(r.AllowedDomains) == 0 {
+		return true
+	}
+
+	domain := email[strings.LastIndex(email, "@")+1:]
+	for _, allowed := range r.AllowedDomains {
+		if strings.EqualFold(domain, allowed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Register creates a new unverified user with the given email and password and sends an email-verification email to
+// the given address. The user can login after the email has been verified via VerifyEMail. The email will be sent
+// asynchronously. Registering an unverified user again replaces its password and claims and its previous
+// email-verification tokens, as the former registration could have been made by somebody else.
+// return ErrRegistrationDisabled when the registration has not been enabled
+// return ErrInvalidEMail when the email is not a valid email address
+// return ErrEMailDomainNotAllowed when the domain of the email is not allowed to register
+// return PasswordPolicyError when the password violates the password policy or has been breached
+// return ErrUserAlreadyExists when user already exists
+func (p Provider) Register(email, password string) error {
+	if !p.Registration.Enabled {
+		return ErrRegistrationDisabled
+	}
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return ErrInvalidEMail
+	}
+
+	if !p.Registration.domainAllowed(email) {
+		return ErrEMailDomainNotAllowed
+	}
+
+	err = p.PasswordPolicy.Validate(email, password)
+	if err != nil {
+		return err
+	}
+
+	breached, err := p.checkBreachedPassword(email, password)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := p.PasswordHasher.Hash(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	err = p.Storage.CreateUser(storage.User{
+		EMail:            email,
+		Password:         hashedPassword,
+		PasswordBreached: breached,
+		EMailUnverified:  true,
+	})
+	if errors.Is(err, storage.ErrUserAlreadyExists) {
+		err = p.replaceUnverifiedUser(email, hashedPassword, breached)
+		if err != nil {
+			return err
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to create user with email %q: %w", email, err)
+	}
+
+	t, err := generateHEXToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate email-verification token: %w", err)
+	}
+
+	err = p.Storage.CreateToken(&storage.Token{
+		EMail:     email,
+		Token:     t,
+		Type:      storage.TokenTypeEMailVerification,
+		ExpiresAt: timeNow().Add(p.Registration.VerificationTokenLifetime),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create email-verification token for email %q: %w", email, err)
+	}
+
+	sendAsync(func() {
+		err := p.Mailer.SendEMailVerificationEMail(email, t, nil)
+		if err != nil {
+			logrus.WithError(err).WithField("email", email).Error("Failed to send email-verification email")
+		}
+	})
+
+	return nil
+}
+
+// replaceUnverifiedUser replaces the password and claims of the unverified user with the given email and deletes its
+// email-verification tokens, so only the latest registration can be verified.
+// return ErrUserAlreadyExists when the email of the user has already been verified
+func (p Provider) replaceUnverifiedUser(email string, hashedPassword []byte, breached bool) error {
+	u, err := p.Storage.User(email)
+	if err != nil {
+		return fmt.Errorf("failed to find user with email %q: %w", email, err)
+	}
+
+	if !u.EMailUnverified {
+		return ErrUserAlreadyExists
+	}
+
+	u.Password = hashedPassword
+	u.PasswordBreached = breached
+	u.Claims = nil
+	err = p.Storage.UpdateUnverifiedUser(u)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			// the email has been verified in the meantime
+			return ErrUserAlreadyExists
+		}
+		return fmt.Errorf("failed to update unverified user with email %q: %w", email, err)
+	}
+
+	err = p.Storage.DeleteTokensByEMailAndType(email, storage.TokenTypeEMailVerification)
+	if err != nil {
+		return fmt.Errorf("failed to delete email-verification tokens of email %q: %w", email, err)
+	}
+
+	return nil
+}
+
+// VerifyEMail verifies the email of the given self-registered user if the verification token is correct, so the user
+// can login.
+// return ErrNoValidTokenFound no valid token could be found
+func (p Provider) VerifyEMail(email, verificationToken string) error {
+	tokens, err := p.Storage.TokensByEMailAndToken(email, verificationToken)
+	if err != nil {
+		return fmt.Errorf("failed to find email-verification tokens: %w", err)
+	}
+
+	var t *storage.Token
+	for i := range tokens {
+		if tokens[i].Type == storage.TokenTypeEMailVerification && !p.isExpired(tokens[i]) {
+			t = &tokens[i]
+			break
+		}
+	}
+
+	if t == nil {
+		return ErrNoValidTokenFound
+	}
+
+	err = p.consumeToken(t.ID)
+	if err != nil {
+		return fmt.Errorf("failed to consume email-verification token: %w", err)
+	}
+
+	err = p.Storage.VerifyUserEMail(email)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,346 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProvider_Register(t *testing.T) {
+	now := time.Date(2021, 4, 19, 12, 0, 0, 0, time.UTC)
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	timeNow = func() time.Time {
+		return now
+	}
+	oldSendAsync := sendAsync
+	defer func() { sendAsync = oldSendAsync }()
+	sendAsync = func(send func()) {
+		send()
+	}
+	oldGenerateHEXToken := generateHEXToken
+	defer func() { generateHEXToken = oldGenerateHEXToken }()
+	generateHEXToken = func() (string, error) {
+		return "verification-token", nil
+	}
+
+	tests := []struct {
+		name                     string
+		givenEMail               string
+		givenPassword            string
+		registration             Registration
+		dbCreateUserError        error
+		dbUser                   storage.User
+		dbUpdateUserError        error
+		dbCreateTokenError       error
+		expectedError            error
+		expectedUser             storage.User
+		expectedUpdatedUser      storage.User
+		expectedTokensDeleted    bool
+		expectedToken            storage.Token
+		expectedMailRecipient    string
+		expectedVerificationMail string
+	}{
+		{
+			name:          "Happycase",
+			givenEMail:    "test@leberkleber.io",
+			givenPassword: "s3cr3t",
+			registration:  Registration{Enabled: true, VerificationTokenLifetime: time.Hour},
+			expectedUser: storage.User{
+				EMail:           "test@leberkleber.io",
+				Password:        []byte("hashed"),
+				EMailUnverified: true,
+			},
+			expectedToken: storage.Token{
+				EMail:     "test@leberkleber.io",
+				Token:     "verification-token",
+				Type:      storage.TokenTypeEMailVerification,
+				ExpiresAt: now.Add(time.Hour),
+			},
+			expectedMailRecipient:    "test@leberkleber.io",
+			expectedVerificationMail: "verification-token",
+		}, {
+			name:          "Allowed domain",
+			givenEMail:    "test@LeberKleber.io",
+			givenPassword: "s3cr3t",
+			registration:  Registration{Enabled: true, AllowedDomains: []string{"example.com", "leberkleber.io"}, VerificationTokenLifetime: time.Hour},
+			expectedUser: storage.User{
+				EMail:           "test@LeberKleber.io",
+				Password:        []byte("hashed"),
+				EMailUnverified: true,
+			},
+			expectedToken: storage.Token{
+				EMail:     "test@LeberKleber.io",
+				Token:     "verification-token",
+				Type:      storage.TokenTypeEMailVerification,
+				ExpiresAt: now.Add(time.Hour),
+			},
+			expectedMailRecipient:    "test@LeberKleber.io",
+			expectedVerificationMail: "verification-token",
+		}, {
+			name:          "Disabled",
+			givenEMail:    "test@leberkleber.io",
+			givenPassword: "s3cr3t",
+			expectedError: ErrRegistrationDisabled,
+		}, {
+			name:          "Invalid email",
+			givenEMail:    "Test <test@leberkleber.io>",
+			givenPassword: "s3cr3t",
+			registration:  Registration{Enabled: true},
+			expectedError: ErrInvalidEMail,
+		}, {
+			name:          "Domain not allowed",
+			givenEMail:    "test@leberkleber.io.example.com",
+			givenPassword: "s3cr3t",
+			registration:  Registration{Enabled: true, AllowedDomains: []string{"leberkleber.io"}},
+			expectedError: ErrEMailDomainNotAllowed,
+		}, {
+			name:          "Password policy violated",
+			givenEMail:    "test@leberkleber.io",
+			givenPassword: "",
+			registration:  Registration{Enabled: true},
+			expectedError: PasswordPolicyError{Violations: []PasswordPolicyViolation{{Rule: "min_length", Message: "password must be at least 1 characters long"}}},
+		}, {
+			name:              "User already exists",
+			givenEMail:        "test@leberkleber.io",
+			givenPassword:     "s3cr3t",
+			registration:      Registration{Enabled: true},
+			dbCreateUserError: storage.ErrUserAlreadyExists,
+			dbUser:            storage.User{EMail: "test@leberkleber.io", Password: []byte("other")},
+			expectedError:     ErrUserAlreadyExists,
+			expectedUser: storage.User{
+				EMail:           "test@leberkleber.io",
+				Password:        []byte("hashed"),
+				EMailUnverified: true,
+			},
+		}, {
+			name:              "Unverified user registers again",
+			givenEMail:        "test@leberkleber.io",
+			givenPassword:     "s3cr3t",
+			registration:      Registration{Enabled: true, VerificationTokenLifetime: time.Hour},
+			dbCreateUserError: storage.ErrUserAlreadyExists,
+			dbUser: storage.User{
+				EMail:            "test@leberkleber.io",
+				Password:         []byte("other"),
+				Claims:           storage.Claims{"role": "admin"},
+				PasswordBreached: true,
+				EMailUnverified:  true,
+			},
+			expectedUser: storage.User{
+				EMail:           "test@leberkleber.io",
+				Password:        []byte("hashed"),
+				EMailUnverified: true,
+			},
+			expectedUpdatedUser: storage.User{
+				EMail:           "test@leberkleber.io",
+				Password:        []byte("hashed"),
+				EMailUnverified: true,
+			},
+			expectedTokensDeleted: true,
+			expectedToken: storage.Token{
+				EMail:     "test@leberkleber.io",
+				Token:     "verification-token",
+				Type:      storage.TokenTypeEMailVerification,
+				ExpiresAt: now.Add(time.Hour),
+			},
+			expectedMailRecipient:    "test@leberkleber.io",
+			expectedVerificationMail: "verification-token",
+		}, {
+			name:              "Unverified user has been verified concurrently",
+			givenEMail:        "test@leberkleber.io",
+			givenPassword:     "s3cr3t",
+			registration:      Registration{Enabled: true, VerificationTokenLifetime: time.Hour},
+			dbCreateUserError: storage.ErrUserAlreadyExists,
+			dbUser:            storage.User{EMail: "test@leberkleber.io", Password: []byte("other"), EMailUnverified: true},
+			dbUpdateUserError: storage.ErrUserNotFound,
+			expectedError:     ErrUserAlreadyExists,
+			expectedUser: storage.User{
+				EMail:           "test@leberkleber.io",
+				Password:        []byte("hashed"),
+				EMailUnverified: true,
+			},
+			expectedUpdatedUser: storage.User{
+				EMail:           "test@leberkleber.io",
+				Password:        []byte("hashed"),
+				EMailUnverified: true,
+			},
+		}, {
+			name:               "Unexpected db error while create token",
+			givenEMail:         "test@leberkleber.io",
+			givenPassword:      "s3cr3t",
+			registration:       Registration{Enabled: true, VerificationTokenLifetime: time.Hour},
+			dbCreateTokenError: errors.New("random error"),
+			expectedError:      errors.New("failed to create email-verification token for email \"test@leberkleber.io\": random error"),
+			expectedUser: storage.User{
+				EMail:           "test@leberkleber.io",
+				Password:        []byte("hashed"),
+				EMailUnverified: true,
+			},
+			expectedToken: storage.Token{
+				EMail:     "test@leberkleber.io",
+				Token:     "verification-token",
+				Type:      storage.TokenTypeEMailVerification,
+				ExpiresAt: now.Add(time.Hour),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var createdUser, updatedUser storage.User
+			var createdToken storage.Token
+			var tokensDeleted bool
+			var mailRecipient, mailToken string
+			toTest := Provider{
+				Registration:   tt.registration,
+				PasswordPolicy: PasswordPolicy{MinLength: 1},
+				PasswordHasher: &PasswordHasherMock{
+					HashFunc: func(password string) ([]byte, error) {
+						return []byte("hashed"), nil
+					},
+				},
+				Storage: &StorageMock{
+					CreateUserFunc: func(user storage.User) error {
+						createdUser = user
+						return tt.dbCreateUserError
+					},
+					UserFunc: func(email string) (storage.User, error) {
+						return tt.dbUser, nil
+					},
+					UpdateUnverifiedUserFunc: func(user storage.User) error {
+						updatedUser = user
+						return tt.dbUpdateUserError
+					},
+					DeleteTokensByEMailAndTypeFunc: func(email string, tokenType string) error {
+						tokensDeleted = email == tt.givenEMail && tokenType == storage.TokenTypeEMailVerification
+						return nil
+					},
+					CreateTokenFunc: func(t *storage.Token) error {
+						createdToken = *t
+						return tt.dbCreateTokenError
+					},
+				},
+				Mailer: &MailerMock{
+					SendEMailVerificationEMailFunc: func(recipient string, verificationToken string, claims map[string]interface{}) error {
+						mailRecipient = recipient
+						mailToken = verificationToken
+						return nil
+					},
+				},
+			}
+
+			err := toTest.Register(tt.givenEMail, tt.givenPassword)
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:\n%s\nGiven:\n%s", tt.expectedError, err)
+			}
+
+			if !reflect.DeepEqual(createdUser, tt.expectedUser) {
+				t.Errorf("The storage user to create is not as expected: \nExpected:\n%#v\nGiven:\n%#v", tt.expectedUser, createdUser)
+			}
+
+			if !reflect.DeepEqual(updatedUser, tt.expectedUpdatedUser) {
+				t.Errorf("The storage user to update is not as expected: \nExpected:\n%#v\nGiven:\n%#v", tt.expectedUpdatedUser, updatedUser)
+			}
+
+			if tokensDeleted != tt.expectedTokensDeleted {
+				t.Errorf("Email-verification tokens have been deleted is not as expected. Expected: %t, Given: %t", tt.expectedTokensDeleted, tokensDeleted)
+			}
+
+			if !reflect.DeepEqual(createdToken, tt.expectedToken) {
+				t.Errorf("The storage token to create is not as expected: \nExpected:\n%#v\nGiven:\n%#v", tt.expectedToken, createdToken)
+			}
+
+			if mailRecipient != tt.expectedMailRecipient || mailToken != tt.expectedVerificationMail {
+				t.Errorf("The sent mail is not as expected. Expected: %q, %q Given: %q, %q", tt.expectedMailRecipient, tt.expectedVerificationMail, mailRecipient, mailToken)
+			}
+		})
+	}
+}
+
+func TestProvider_VerifyEMail(t *testing.T) {
+	validToken := storage.Token{EMail: "test@test.test", Token: "verification-token", Type: storage.TokenTypeEMailVerification, ExpiresAt: time.Now().Add(time.Minute)}
+	validToken.ID = 42
+	expiredToken := validToken
+	expiredToken.ExpiresAt = time.Now().Add(-time.Minute)
+	magicLinkToken := validToken
+	magicLinkToken.Type = storage.TokenTypeMagicLink
+
+	tests := []struct {
+		name             string
+		tokens           []storage.Token
+		consumeTokenErr  error
+		updateUserErr    error
+		expectedError    error
+		expectedConsumed uint
+		expectedVerified string
+	}{
+		{
+			name:             "Happycase",
+			tokens:           []storage.Token{validToken},
+			expectedConsumed: 42,
+			expectedVerified: "test@test.test",
+		}, {
+			name:          "Unknown token",
+			expectedError: ErrNoValidTokenFound,
+		}, {
+			name:          "Expired token",
+			tokens:        []storage.Token{expiredToken},
+			expectedError: ErrNoValidTokenFound,
+		}, {
+			name:          "Magic-link token",
+			tokens:        []storage.Token{magicLinkToken},
+			expectedError: ErrNoValidTokenFound,
+		}, {
+			name:             "Token consumed concurrently",
+			tokens:           []storage.Token{validToken},
+			consumeTokenErr:  storage.ErrTokenNotFound,
+			expectedError:    errors.New("failed to consume email-verification token: no valid token found"),
+			expectedConsumed: 42,
+		}, {
+			name:             "Unexpected db error while update user",
+			tokens:           []storage.Token{validToken},
+			updateUserErr:    errors.New("random error"),
+			expectedError:    errors.New("failed to update user: random error"),
+			expectedConsumed: 42,
+			expectedVerified: "test@test.test",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var consumedTokenID uint
+			var verifiedEMail string
+			toTest := Provider{
+				Storage: &StorageMock{
+					TokensByEMailAndTokenFunc: func(email, token string) ([]storage.Token, error) {
+						return tt.tokens, nil
+					},
+					ConsumeTokenFunc: func(id uint) error {
+						consumedTokenID = id
+						return tt.consumeTokenErr
+					},
+					VerifyUserEMailFunc: func(email string) error {
+						verifiedEMail = email
+						return tt.updateUserErr
+					},
+				},
+			}
+
+			err := toTest.VerifyEMail("test@test.test", "verification-token")
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:\n%s\nGiven:\n%s", tt.expectedError, err)
+			}
+
+			if consumedTokenID != tt.expectedConsumed {
+				t.Errorf("Consumed token is not as expected. Expected: %d, Given: %d", tt.expectedConsumed, consumedTokenID)
+			}
+
+			if verifiedEMail != tt.expectedVerified {
+				t.Errorf("The verified email is not as expected. Expected: %q, Given: %q", tt.expectedVerified, verifiedEMail)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"time"
+)
+
+var timeNow = time.Now
+
+// Revoke revokes the given token as described in https://tools.ietf.org/html/rfc7009#section-2.1. Refresh-tokens and
+// legacy tokens without 'typ' claim will be logged out, access-tokens will be added to the revocation list. Invalid or
+// already revoked tokens will be ignored.
+func (p Provider) Revoke(token string) error {
+	isValid, claims, err := p.JWTProvider.IsTokenValid(token)
+	if err != nil || !isValid {
+		return nil
+	}
+
+	if claims["typ"] == accessTokenType {
+		jit, _ := claims["jit"].(string)
+		exp, _ := claims["exp"].(float64)
+		if jit == "" {
+			return nil
+		}
+
+		return p.revokeAccessToken(jit, time.Unix(int64(exp), 0))
+	}
+
+	err = p.Logout(token)
+	if err != nil && !isInvalidRefreshTokenError(err) {
+		return err
+	}
+
+	return nil
+}
+
+// RevokeAccessTokenByID adds the access-token identified by the given jit to the revocation list. The token will be
+// listed for the maximum access-token lifetime because its real expiry is unknown.
+func (p Provider) RevokeAccessTokenByID(jit string) error {
+	return p.revokeAccessToken(jit, timeNow().Add(p.JWTProvider.AccessTokenLifetime()))
+}
+
+// revokeAccessToken adds the given jit to the revocation list until the token expires. Entries of tokens which have
+// expired in the meantime will be purged on the fly to keep the revocation list small.
+func (p Provider) revokeAccessToken(jit string, expiresAt time.Time) error {
+	err := p.Storage.DeleteExpiredRevokedTokens(timeNow())
+	if err != nil {
+		return fmt.Errorf("failed to purge expired revoked tokens: %w", err)
+	}
+
+	err = p.Storage.RevokeToken(storage.RevokedToken{JIT: jit, ExpiresAt: expiresAt})
+	if err != nil {
+		return fmt.Errorf("failed to revoke access-token: %w", err)
+	}
+
+	return nil
+}
+
+func isInvalidRefreshTokenError(err error) bool {
+	return errors.Is(err, ErrTokenNotParsable) || errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrNoValidTokenFound)
+}
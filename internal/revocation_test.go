@@ -0,0 +1,164 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"gorm.io/gorm"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProvider_Revoke(t *testing.T) {
+	now := time.Date(2021, 4, 19, 12, 0, 0, 0, time.UTC)
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	timeNow = func() time.Time {
+		return now
+	}
+
+	tests := []struct {
+		name                  string
+		isTokenValidClaims    jwt.MapClaims
+		isTokenValidErr       error
+		tokens                []storage.Token
+		deleteTokenErr        error
+		revokeTokenErr        error
+		deleteExpiredErr      error
+		expectedDeletedID     uint
+		expectedRevokedTokens []storage.RevokedToken
+		expectedError         error
+	}{
+		{
+			name:               "Refresh-token",
+			isTokenValidClaims: jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "typ": "refresh"},
+			tokens:             []storage.Token{{Model: gorm.Model{ID: 42}, Type: storage.TokenTypeRefresh, ExpiresAt: time.Now().Add(time.Hour)}},
+			expectedDeletedID:  42,
+		}, {
+			name:               "Already revoked refresh-token",
+			isTokenValidClaims: jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "typ": "refresh"},
+		}, {
+			name:                  "Access-token",
+			isTokenValidClaims:    jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "exp": float64(now.Add(time.Hour).Unix()), "typ": "access"},
+			expectedRevokedTokens: []storage.RevokedToken{{JIT: "jwt-id", ExpiresAt: time.Unix(now.Add(time.Hour).Unix(), 0)}},
+		}, {
+			name:               "Legacy refresh-token without typ",
+			isTokenValidClaims: jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id"},
+			tokens:             []storage.Token{{Model: gorm.Model{ID: 42}, Type: storage.TokenTypeRefresh, ExpiresAt: time.Now().Add(time.Hour)}},
+			expectedDeletedID:  42,
+		}, {
+			name:               "Access-token without jit",
+			isTokenValidClaims: jwt.MapClaims{"email": "test@test.test", "typ": "access"},
+		}, {
+			name:               "Failed to purge revoked access-tokens",
+			isTokenValidClaims: jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "exp": float64(now.Add(time.Hour).Unix()), "typ": "access"},
+			deleteExpiredErr:   errors.New("nope"),
+			expectedError:      errors.New("failed to purge expired revoked tokens: nope"),
+		}, {
+			name:                  "Failed to revoke access-token",
+			isTokenValidClaims:    jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "exp": float64(now.Add(time.Hour).Unix()), "typ": "access"},
+			revokeTokenErr:        errors.New("nope"),
+			expectedRevokedTokens: []storage.RevokedToken{{JIT: "jwt-id", ExpiresAt: time.Unix(now.Add(time.Hour).Unix(), 0)}},
+			expectedError:         errors.New("failed to revoke access-token: nope"),
+		}, {
+			name:            "Invalid token",
+			isTokenValidErr: errors.New("nope"),
+		}, {
+			name:               "Failed to delete token",
+			isTokenValidClaims: jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "typ": "refresh"},
+			tokens:             []storage.Token{{Model: gorm.Model{ID: 42}, Type: storage.TokenTypeRefresh, ExpiresAt: time.Now().Add(time.Hour)}},
+			deleteTokenErr:     errors.New("nope"),
+			expectedDeletedID:  42,
+			expectedError:      errors.New("failed to consume refresh-token: nope"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deletedID uint
+			var revokedTokens []storage.RevokedToken
+
+			toTest := Provider{
+				JWTProvider: &JWTProviderMock{
+					IsTokenValidFunc: func(token string) (bool, jwt.MapClaims, error) {
+						return tt.isTokenValidErr == nil, tt.isTokenValidClaims, tt.isTokenValidErr
+					},
+				},
+				Storage: &StorageMock{
+					TokensByEMailAndTokenFunc: func(email string, token string) ([]storage.Token, error) {
+						return tt.tokens, nil
+					},
+					ConsumedTokensByEMailAndTokenFunc: func(email string, token string) ([]storage.Token, error) {
+						return nil, nil
+					},
+					ConsumeTokenFunc: func(id uint) error {
+						deletedID = id
+						return tt.deleteTokenErr
+					},
+					DeleteExpiredRevokedTokensFunc: func(before time.Time) error {
+						if !before.Equal(now) {
+							t.Errorf("Unexpected purge time. Expected: %s, Given: %s", now, before)
+						}
+						return tt.deleteExpiredErr
+					},
+					RevokeTokenFunc: func(rt storage.RevokedToken) error {
+						revokedTokens = append(revokedTokens, rt)
+						return tt.revokeTokenErr
+					},
+				},
+			}
+
+			err := toTest.Revoke("myToken")
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Unexpected error. Expected: %q, Given: %q", tt.expectedError, err)
+			}
+
+			if deletedID != tt.expectedDeletedID {
+				t.Errorf("Unexpected deleted token. Expected: %d, Given: %d", tt.expectedDeletedID, deletedID)
+			}
+
+			if !reflect.DeepEqual(revokedTokens, tt.expectedRevokedTokens) {
+				t.Errorf("Unexpected revoked tokens. Expected: %#v, Given: %#v", tt.expectedRevokedTokens, revokedTokens)
+			}
+		})
+	}
+}
+
+func TestProvider_RevokeAccessTokenByID(t *testing.T) {
+	now := time.Date(2021, 4, 19, 12, 0, 0, 0, time.UTC)
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	timeNow = func() time.Time {
+		return now
+	}
+
+	var revokedToken storage.RevokedToken
+	toTest := Provider{
+		JWTProvider: &JWTProviderMock{
+			AccessTokenLifetimeFunc: func() time.Duration {
+				return 4 * time.Hour
+			},
+		},
+		Storage: &StorageMock{
+			DeleteExpiredRevokedTokensFunc: func(before time.Time) error {
+				return nil
+			},
+			RevokeTokenFunc: func(rt storage.RevokedToken) error {
+				revokedToken = rt
+				return nil
+			},
+		},
+	}
+
+	err := toTest.RevokeAccessTokenByID("jwt-id")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expectedRevokedToken := storage.RevokedToken{JIT: "jwt-id", ExpiresAt: now.Add(4 * time.Hour)}
+	if !reflect.DeepEqual(revokedToken, expectedRevokedToken) {
+		t.Errorf("Unexpected revoked token. Expected: %#v, Given: %#v", expectedRevokedToken, revokedToken)
+	}
+}
@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"time"
+)
+
+// ErrSessionNotFound returned when the requested session does not exist
+var ErrSessionNotFound = errors.New("session not found")
+
+// ClientInfo describes the client which uses a session
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// Session is the representation of a login session for use in internal. A session starts with a login and lasts as
+// long as its refresh-tokens are refreshed.
+type Session struct {
+	ID         string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+	UserAgent  string
+	ClientIP   string
+}
+
+// Sessions returns all active sessions of the user with the given email.
+// return ErrUserNotFound when user does not exist
+func (p Provider) Sessions(email string) ([]Session, error) {
+	_, err := p.Storage.User(email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+
+		return nil, fmt.Errorf("failed to find user with email %q: %w", email, err)
+	}
+
+	return p.activeSessions(email)
+}
+
+// SessionsByAccessToken returns all active sessions of the user of the given access-token.
+// return ErrInvalidToken when the token is not an active access-token
+func (p Provider) SessionsByAccessToken(accessToken string) ([]Session, error) {
+	email, err := p.accessTokenEMail(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.activeSessions(email)
+}
+
+// DeleteSession ends the session with the given id of the user with the given email. Its refresh-tokens can no longer
+// be used to refresh.
+// return ErrSessionNotFound when the user has no session with the given id
+func (p Provider) DeleteSession(email, id string) error {
+	err := p.Storage.DeleteTokensByEMailAndFamily(email, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrTokenNotFound) {
+			return ErrSessionNotFound
+		}
+
+		return fmt.Errorf("failed to delete session %q of %q: %w", id, email, err)
+	}
+
+	return nil
+}
+
+func (p Provider) activeSessions(email string) ([]Session, error) {
+	tokens, err := p.Storage.TokensByEMailAndType(email, storage.TokenTypeRefresh)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find refresh-tokens of %q: %w", email, err)
+	}
+
+	sessions := []Session{}
+	for _, t := range tokens {
+		if p.isExpired(t) {
+			continue
+		}
+
+		sessions = append(sessions, Session{
+			ID:         tokenFamily(t),
+			CreatedAt:  t.CreatedAt,
+			LastUsedAt: t.LastUsedAt,
+			ExpiresAt:  p.tokenExpiry(t),
+			UserAgent:  t.UserAgent,
+			ClientIP:   t.ClientIP,
+		})
+	}
+
+	return sessions, nil
+}
@@ -0,0 +1,239 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"gorm.io/gorm"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProvider_Sessions(t *testing.T) {
+	now := time.Date(2021, 4, 19, 12, 0, 0, 0, time.UTC)
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	timeNow = func() time.Time {
+		return now
+	}
+
+	tests := []struct {
+		name             string
+		userErr          error
+		tokens           []storage.Token
+		tokensErr        error
+		expectedSessions []Session
+		expectedError    error
+	}{
+		{
+			name: "Happycase",
+			tokens: []storage.Token{
+				{
+					Model:      gorm.Model{ID: 1, CreatedAt: now.Add(-2 * time.Hour)},
+					Token:      "jwt-id-1",
+					Family:     "family-1",
+					ExpiresAt:  now.Add(time.Hour),
+					LastUsedAt: now.Add(-time.Hour),
+					UserAgent:  "my-agent",
+					ClientIP:   "127.0.0.1",
+				}, {
+					Model:     gorm.Model{ID: 2, CreatedAt: now.Add(-3 * time.Hour)},
+					Token:     "jwt-id-2",
+					ExpiresAt: now.Add(2 * time.Hour),
+				}, {
+					Model:     gorm.Model{ID: 3},
+					Token:     "jwt-id-3",
+					Family:    "family-3",
+					ExpiresAt: now.Add(-time.Hour),
+				},
+			},
+			expectedSessions: []Session{
+				{
+					ID:         "family-1",
+					CreatedAt:  now.Add(-2 * time.Hour),
+					LastUsedAt: now.Add(-time.Hour),
+					ExpiresAt:  now.Add(time.Hour),
+					UserAgent:  "my-agent",
+					ClientIP:   "127.0.0.1",
+				}, {
+					ID:        "jwt-id-2",
+					CreatedAt: now.Add(-3 * time.Hour),
+					ExpiresAt: now.Add(2 * time.Hour),
+				},
+			},
+		}, {
+			name:             "Without sessions",
+			expectedSessions: []Session{},
+		}, {
+			name:          "User not found",
+			userErr:       storage.ErrUserNotFound,
+			expectedError: ErrUserNotFound,
+		}, {
+			name:          "Unexpected db error while finding user",
+			userErr:       errors.New("nope"),
+			expectedError: errors.New("failed to find user with email \"test@test.test\": nope"),
+		}, {
+			name:          "Unexpected db error while finding tokens",
+			tokensErr:     errors.New("nope"),
+			expectedError: errors.New("failed to find refresh-tokens of \"test@test.test\": nope"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenEMail, givenType string
+
+			toTest := Provider{
+				Storage: &StorageMock{
+					UserFunc: func(email string) (storage.User, error) {
+						return storage.User{EMail: email}, tt.userErr
+					},
+					TokensByEMailAndTypeFunc: func(email string, tokenType string) ([]storage.Token, error) {
+						givenEMail = email
+						givenType = tokenType
+						return tt.tokens, tt.tokensErr
+					},
+				},
+			}
+
+			sessions, err := toTest.Sessions("test@test.test")
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Unexpected error. Expected: %q, Given: %q", tt.expectedError, err)
+			} else if err != nil {
+				return
+			}
+
+			if givenEMail != "test@test.test" || givenType != storage.TokenTypeRefresh {
+				t.Errorf("Storage.TokensByEMailAndType called with unexpected args: %q, %q", givenEMail, givenType)
+			}
+
+			if !reflect.DeepEqual(sessions, tt.expectedSessions) {
+				t.Errorf("Unexpected sessions. Expected: %#v, Given: %#v", tt.expectedSessions, sessions)
+			}
+		})
+	}
+}
+
+func TestProvider_SessionsByAccessToken(t *testing.T) {
+	tests := []struct {
+		name               string
+		isTokenValid       bool
+		isTokenValidClaims jwt.MapClaims
+		isTokenRevoked     bool
+		expectedEMail      string
+		expectedError      error
+	}{
+		{
+			name:               "Happycase",
+			isTokenValid:       true,
+			isTokenValidClaims: jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "typ": "access"},
+			expectedEMail:      "test@test.test",
+		}, {
+			name:          "Invalid token",
+			expectedError: ErrInvalidToken,
+		}, {
+			name:               "Revoked token",
+			isTokenValid:       true,
+			isTokenValidClaims: jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "typ": "access"},
+			isTokenRevoked:     true,
+			expectedError:      ErrInvalidToken,
+		}, {
+			name:               "Refresh-token",
+			isTokenValid:       true,
+			isTokenValidClaims: jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id", "typ": "refresh"},
+			expectedError:      ErrInvalidToken,
+		}, {
+			name:               "Legacy refresh-token without typ",
+			isTokenValid:       true,
+			isTokenValidClaims: jwt.MapClaims{"email": "test@test.test", "jit": "jwt-id"},
+			expectedError:      ErrInvalidToken,
+		}, {
+			name:               "Email claim is not a string",
+			isTokenValid:       true,
+			isTokenValidClaims: jwt.MapClaims{"email": 42, "jit": "jwt-id", "typ": "access"},
+			expectedError:      errors.New("email claim is not parsable as string"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenEMail string
+
+			toTest := Provider{
+				JWTProvider: &JWTProviderMock{
+					IsTokenValidFunc: func(token string) (bool, jwt.MapClaims, error) {
+						return tt.isTokenValid, tt.isTokenValidClaims, nil
+					},
+				},
+				Storage: &StorageMock{
+					TokensByEMailAndTokenFunc: func(email string, token string) ([]storage.Token, error) {
+						return []storage.Token{{Type: storage.TokenTypeRefresh}}, nil
+					},
+					IsTokenRevokedFunc: func(jit string) (bool, error) {
+						return tt.isTokenRevoked, nil
+					},
+					TokensByEMailAndTypeFunc: func(email string, tokenType string) ([]storage.Token, error) {
+						givenEMail = email
+						return nil, nil
+					},
+				},
+			}
+
+			_, err := toTest.SessionsByAccessToken("myAccessToken")
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Unexpected error. Expected: %q, Given: %q", tt.expectedError, err)
+			}
+
+			if givenEMail != tt.expectedEMail {
+				t.Errorf("Sessions of unexpected user requested. Expected: %q, Given: %q", tt.expectedEMail, givenEMail)
+			}
+		})
+	}
+}
+
+func TestProvider_DeleteSession(t *testing.T) {
+	tests := []struct {
+		name          string
+		deleteErr     error
+		expectedError error
+	}{
+		{
+			name: "Happycase",
+		}, {
+			name:          "Session not found",
+			deleteErr:     storage.ErrTokenNotFound,
+			expectedError: ErrSessionNotFound,
+		}, {
+			name:          "Unexpected db error",
+			deleteErr:     errors.New("nope"),
+			expectedError: errors.New("failed to delete session \"family-id\" of \"test@test.test\": nope"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenEMail, givenFamily string
+
+			toTest := Provider{
+				Storage: &StorageMock{
+					DeleteTokensByEMailAndFamilyFunc: func(email string, family string) error {
+						givenEMail = email
+						givenFamily = family
+						return tt.deleteErr
+					},
+				},
+			}
+
+			err := toTest.DeleteSession("test@test.test", "family-id")
+			if fmt.Sprint(err) != fmt.Sprint(tt.expectedError) {
+				t.Fatalf("Unexpected error. Expected: %q, Given: %q", tt.expectedError, err)
+			}
+
+			if givenEMail != "test@test.test" || givenFamily != "family-id" {
+				t.Errorf("Storage.DeleteTokensByEMailAndFamily called with unexpected args: %q, %q", givenEMail, givenFamily)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// LoginFailure counts the failed logins of a key (e.g. an email or a client ip) and whether logins of this key are
+// locked.
+type LoginFailure struct {
+	ID          uint   `gorm:"primarykey"`
+	Key         string `gorm:"uniqueIndex:unique_login_failure_key"`
+	Failures    int
+	LockedUntil time.Time
+	UpdatedAt   time.Time
+}
+
+// LoginFailure returns the login failures of the given key. When there are none, an empty LoginFailure will be returned.
+func (s Storage) LoginFailure(key string) (LoginFailure, error) {
+	var f LoginFailure
+	err := s.db.Where(&LoginFailure{Key: key}).First(&f).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return LoginFailure{Key: key}, nil
+		}
+
+		return LoginFailure{}, fmt.Errorf("failed to query login failures: %w", err)
+	}
+
+	return f, nil
+}
+
+// RegisterLoginFailure increments the login failures of the given key atomically and returns the updated LoginFailure.
+// Failures which have been registered before forgetBefore will be forgotten.
+func (s Storage) RegisterLoginFailure(key string, forgetBefore time.Time) (LoginFailure, error) {
+	var f LoginFailure
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "key"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"failures":   gorm.Expr("CASE WHEN login_failures.updated_at < ? THEN 1 ELSE login_failures.failures + 1 END", forgetBefore),
+				"updated_at": gorm.Expr("?", time.Now()),
+			}),
+		}).Create(&LoginFailure{Key: key, Failures: 1}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where(&LoginFailure{Key: key}).First(&f).Error
+	})
+	if err != nil {
+		return LoginFailure{}, fmt.Errorf("failed to register login failure: %w", err)
+	}
+
+	return f, nil
+}
+
+// LockLogin locks logins of the given key until the given time
+func (s Storage) LockLogin(key string, until time.Time) error {
+	err := s.db.Model(&LoginFailure{}).Where(&LoginFailure{Key: key}).Update("locked_until", until).Error
+	if err != nil {
+		return fmt.Errorf("failed to lock login: %w", err)
+	}
+
+	return nil
+}
+
+// ResetLoginFailures forgets all login failures of the given key and unlocks it
+func (s Storage) ResetLoginFailures(key string) error {
+	err := s.db.Where(&LoginFailure{Key: key}).Delete(&LoginFailure{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to reset login failures: %w", err)
+	}
+
+	return nil
+}
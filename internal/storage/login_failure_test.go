@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStorage_RegisterLoginFailure(t *testing.T) {
+	s, err := New(dbTypeSQLite, filepath.Join(t.TempDir(), "storage.db")+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %s", err)
+	}
+
+	const failures = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, failures)
+	for i := 0; i < failures; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.RegisterLoginFailure("email:test@test.test", time.Now().Add(-time.Hour))
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+	}
+
+	f, err := s.LoginFailure("email:test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to query login failures: %s", err)
+	}
+
+	if f.Failures != failures {
+		t.Errorf("Unexpected number of failures. Expected: %d, Given: %d", failures, f.Failures)
+	}
+
+	f, err = s.RegisterLoginFailure("email:test@test.test", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to register login failure: %s", err)
+	}
+
+	if f.Failures != 1 {
+		t.Errorf("Outdated failures should be forgotten. Expected: %d, Given: %d", 1, f.Failures)
+	}
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+// PasswordHistory represents a previous password hash of a user
+type PasswordHistory struct {
+	ID        uint   `gorm:"primarykey"`
+	EMail     string `gorm:"index"`
+	Password  []byte
+	CreatedAt time.Time
+}
+
+// PasswordHistory returns the previous password hashes of the user with the given email. The newest comes first.
+func (s Storage) PasswordHistory(email string) ([]PasswordHistory, error) {
+	var history []PasswordHistory
+	err := s.db.Where(&PasswordHistory{EMail: email}).Order("id desc").Find(&history).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query password history: %w", err)
+	}
+
+	return history, nil
+}
+
+// AddPasswordHistory persists the given previous password hash and deletes all but the newest keep password hashes of
+// the same user in one transaction.
+func (s Storage) AddPasswordHistory(h PasswordHistory, keep int) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&h).Error
+		if err != nil {
+			return fmt.Errorf("failed to exec create password history stmt: %w", err)
+		}
+
+		var ids []uint
+		err = tx.Model(&PasswordHistory{}).Where(&PasswordHistory{EMail: h.EMail}).Order("id desc").Pluck("id", &ids).Error
+		if err != nil {
+			return fmt.Errorf("failed to query password history: %w", err)
+		}
+
+		if len(ids) <= keep {
+			return nil
+		}
+
+		err = tx.Delete(&PasswordHistory{}, ids[keep:]).Error
+		if err != nil {
+			return fmt.Errorf("failed to exec delete password history stmt: %w", err)
+		}
+
+		return nil
+	})
+}
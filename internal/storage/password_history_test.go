@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStorage_AddPasswordHistory(t *testing.T) {
+	s, err := New(dbTypeSQLite, filepath.Join(t.TempDir(), "storage.db"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %s", err)
+	}
+
+	for _, h := range []PasswordHistory{
+		{EMail: "test@test.test", Password: []byte("hash-1")},
+		{EMail: "other@test.test", Password: []byte("other-hash")},
+		{EMail: "test@test.test", Password: []byte("hash-2")},
+		{EMail: "test@test.test", Password: []byte("hash-3")},
+	} {
+		err = s.AddPasswordHistory(h, 2)
+		if err != nil {
+			t.Fatalf("Failed to add password history: %s", err)
+		}
+	}
+
+	history, err := s.PasswordHistory("test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to query password history: %s", err)
+	}
+
+	var hashes []string
+	for _, h := range history {
+		hashes = append(hashes, string(h.Password))
+	}
+	if len(hashes) != 2 || hashes[0] != "hash-3" || hashes[1] != "hash-2" {
+		t.Errorf("Password history is not as expected. Expected: [hash-3 hash-2], Given: %v", hashes)
+	}
+
+	otherHistory, err := s.PasswordHistory("other@test.test")
+	if err != nil {
+		t.Fatalf("Failed to query password history: %s", err)
+	}
+	if len(otherHistory) != 1 {
+		t.Errorf("Password history of other users should not be touched. Given: %#v", otherHistory)
+	}
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ErrRecoveryCodeNotFound returned when the requested recovery code does not exist or has already been used
+var ErrRecoveryCodeNotFound = errors.New("recovery code not found")
+
+// RecoveryCode represents a hashed one-time recovery code of a user which can be used instead of a second factor
+type RecoveryCode struct {
+	ID        uint   `gorm:"primarykey"`
+	EMail     string `gorm:"index"`
+	Code      []byte
+	CreatedAt time.Time
+}
+
+// ReplaceRecoveryCodes replaces all recovery codes of the user with the given email with the given codes in one
+// transaction. No codes delete all recovery codes of the user.
+func (s Storage) ReplaceRecoveryCodes(email string, codes []RecoveryCode) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Delete(&RecoveryCode{}, RecoveryCode{EMail: email}).Error
+		if err != nil {
+			return fmt.Errorf("failed to exec delete recovery codes stmt: %w", err)
+		}
+
+		if len(codes) == 0 {
+			return nil
+		}
+
+		for i := range codes {
+			codes[i].EMail = email
+		}
+
+		err = tx.Create(&codes).Error
+		if err != nil {
+			return fmt.Errorf("failed to exec create recovery codes stmt: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// ConsumeRecoveryCode deletes the given recovery code of the user with the given email, so it can be used only once.
+// return ErrRecoveryCodeNotFound when the user has no such recovery code (anymore)
+func (s Storage) ConsumeRecoveryCode(email string, code []byte) error {
+	res := s.db.Where("e_mail = ? AND code = ?", email, code).Delete(&RecoveryCode{})
+	if res.Error != nil {
+		return fmt.Errorf("failed to exec delete recovery code stmt: %w", res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return ErrRecoveryCodeNotFound
+	}
+
+	return nil
+}
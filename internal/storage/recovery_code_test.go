@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestStorage_RecoveryCodes(t *testing.T) {
+	s, err := New(dbTypeSQLite, filepath.Join(t.TempDir(), "storage.db"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %s", err)
+	}
+
+	err = s.ReplaceRecoveryCodes("test@test.test", []RecoveryCode{{Code: []byte("old")}})
+	if err != nil {
+		t.Fatalf("Failed to create recovery codes: %s", err)
+	}
+	err = s.ReplaceRecoveryCodes("other@test.test", []RecoveryCode{{Code: []byte("code-1")}})
+	if err != nil {
+		t.Fatalf("Failed to create recovery codes: %s", err)
+	}
+	err = s.ReplaceRecoveryCodes("test@test.test", []RecoveryCode{{Code: []byte("code-1")}, {Code: []byte("code-2")}})
+	if err != nil {
+		t.Fatalf("Failed to replace recovery codes: %s", err)
+	}
+
+	err = s.ConsumeRecoveryCode("test@test.test", []byte("old"))
+	if !errors.Is(err, ErrRecoveryCodeNotFound) {
+		t.Errorf("Replaced recovery code should not be found. Given: %v", err)
+	}
+
+	err = s.ConsumeRecoveryCode("test@test.test", []byte("code-1"))
+	if err != nil {
+		t.Fatalf("Failed to consume recovery code: %s", err)
+	}
+
+	err = s.ConsumeRecoveryCode("test@test.test", []byte("code-1"))
+	if !errors.Is(err, ErrRecoveryCodeNotFound) {
+		t.Errorf("Consumed recovery code should not be found again. Given: %v", err)
+	}
+
+	err = s.ConsumeRecoveryCode("other@test.test", []byte("code-1"))
+	if err != nil {
+		t.Errorf("Recovery code of other user should not be touched: %s", err)
+	}
+
+	err = s.ReplaceRecoveryCodes("test@test.test", nil)
+	if err != nil {
+		t.Fatalf("Failed to delete recovery codes: %s", err)
+	}
+	err = s.ConsumeRecoveryCode("test@test.test", []byte("code-2"))
+	if !errors.Is(err, ErrRecoveryCodeNotFound) {
+		t.Errorf("Deleted recovery code should not be found. Given: %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"fmt"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// RevokedToken represents a revoked access-token identified by its jit claim. It is only needed until the token would
+// have expired anyway.
+type RevokedToken struct {
+	ID        uint      `gorm:"primarykey"`
+	JIT       string    `gorm:"uniqueIndex:unique_jit"`
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time
+}
+
+// RevokeToken persists the given revoked token. Already revoked tokens will be ignored.
+func (s Storage) RevokeToken(t RevokedToken) error {
+	res := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&t)
+	if res.Error != nil {
+		return fmt.Errorf("failed to exec create revoked token: %w", res.Error)
+	}
+
+	return nil
+}
+
+// IsTokenRevoked checks whether the token identified by the given jit has been revoked
+func (s Storage) IsTokenRevoked(jit string) (bool, error) {
+	var count int64
+	err := s.db.Model(&RevokedToken{}).Where(&RevokedToken{JIT: jit}).Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to query revoked token: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// DeleteExpiredRevokedTokens deletes all revoked tokens which have been expired before the given time
+func (s Storage) DeleteExpiredRevokedTokens(before time.Time) error {
+	res := s.db.Where("expires_at < ?", before).Delete(&RevokedToken{})
+	if res.Error != nil {
+		return fmt.Errorf("failed to delete expired revoked tokens: %w", res.Error)
+	}
+
+	return nil
+}
@@ -30,7 +30,7 @@ func New(dbType, dsn string) (*Storage, error) {
 		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
 
-	err = db.AutoMigrate(User{}, Token{})
+	err = db.AutoMigrate(User{}, Token{}, RevokedToken{}, LoginFailure{}, PasswordHistory{}, RecoveryCode{}, WebAuthnCredential{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to auto-migrate persistence: %w", err)
 	}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"gorm.io/gorm"
+	"time"
 )
 
 // ErrTokenNotFound returned when no token could be found
@@ -15,12 +16,42 @@ const TokenTypeReset string = "reset"
 // TokenTypeRefresh identifies a token as refresh-token. Then it can only be used  for refresh
 const TokenTypeRefresh string = "refresh"
 
-// Token represent a persisted token
+// TokenTypeMFA identifies a token as mfa-token. Then it can only be used to pass the multi-factor authentication of a
+// login
+const TokenTypeMFA string = "mfa"
+
+// TokenTypeEMailOTP identifies a token as one-time code which has been sent via email. Then it can only be used as
+// second factor
+const TokenTypeEMailOTP string = "email-otp"
+
+// TokenTypeMagicLink identifies a token as magic-link-token which has been sent via email. Then it can only be used for
+// a passwordless login
+const TokenTypeMagicLink string = "magic-link"
+
+// TokenTypeEMailVerification identifies a token as email-verification-token which has been sent on registration. Then
+// it can only be used to verify the email of a self-registered user
+const TokenTypeEMailVerification string = "email-verification"
+
+// TokenTypeWebAuthnRegistration identifies a token as challenge of a WebAuthn registration ceremony
+const TokenTypeWebAuthnRegistration string = "webauthn-registration"
+
+// TokenTypeWebAuthnLogin identifies a token as challenge of a WebAuthn login ceremony
+const TokenTypeWebAuthnLogin string = "webauthn-login"
+
+// Token represent a persisted token. Refresh-tokens which arise from each other by refreshing share the same Family
+// and form a session. UserAgent, ClientIP and LastUsedAt describe the last usage of the session. Attempts counts the
+// failed attempts to redeem the token.
 type Token struct {
 	gorm.Model
-	EMail string
-	Token string
-	Type  string
+	EMail      string
+	Token      string
+	Type       string
+	Family     string `gorm:"index"`
+	ExpiresAt  time.Time
+	LastUsedAt time.Time
+	UserAgent  string
+	ClientIP   string
+	Attempts   int
 }
 
 // CreateToken persists the given token in database. EMail must match to a users email. ID will be set automatically.
@@ -46,12 +77,55 @@ func (s Storage) TokensByEMailAndToken(email, token string) ([]Token, error) {
 	return tokens, nil
 }
 
-// DeleteToken deletes token with the given ID.
-// return ErrTokenNotFound there is no token with the given ID
-func (s Storage) DeleteToken(id uint) error {
-	res := s.db.Delete(&Token{}, id)
+// TokensByEMailAndType finds all tokens of the given type which belong to the given email.
+func (s Storage) TokensByEMailAndType(email, tokenType string) ([]Token, error) {
+	var tokens []Token
+	res := s.db.Order("created_at").Find(&tokens, &Token{EMail: email, Type: tokenType})
+
+	if res.Error != nil {
+		return nil, fmt.Errorf("failed to exec select token stmt: %w", res.Error)
+	}
+
+	return tokens, nil
+}
+
+// ConsumedTokensByEMailAndToken finds all already deleted tokens which matches the given email and token.
+func (s Storage) ConsumedTokensByEMailAndToken(email, token string) ([]Token, error) {
+	var tokens []Token
+	res := s.db.Unscoped().Where("deleted_at IS NOT NULL").Find(&tokens, &Token{EMail: email, Token: token})
+
+	if res.Error != nil {
+		return nil, fmt.Errorf("failed to exec select consumed token stmt: %w", res.Error)
+	}
+
+	return tokens, nil
+}
+
+// DeleteTokensByFamily deletes all tokens of the given family. An empty family will be rejected, as tokens without
+// family do not belong together.
+func (s Storage) DeleteTokensByFamily(family string) error {
+	if family == "" {
+		return errors.New("family must not be empty")
+	}
+
+	res := s.db.Where("family = ?", family).Delete(&Token{})
+	if res.Error != nil {
+		return fmt.Errorf("failed to delete tokens of family: %w", res.Error)
+	}
+
+	return nil
+}
+
+// DeleteTokensByEMailAndFamily deletes all tokens of the given family which belong to the given email. Tokens persisted
+// before families were introduced form their own family identified by their token.
+// return ErrTokenNotFound when there are no such tokens
+func (s Storage) DeleteTokensByEMailAndFamily(email, family string) error {
+	res := s.db.
+		Where(&Token{EMail: email}).
+		Where("family = ? OR ((family IS NULL OR family = '') AND token = ?)", family, family).
+		Delete(&Token{})
 	if res.Error != nil {
-		return fmt.Errorf("failed to delete token: %w", res.Error)
+		return fmt.Errorf("failed to delete tokens of family: %w", res.Error)
 	}
 
 	if res.RowsAffected < 1 {
@@ -60,3 +134,82 @@ func (s Storage) DeleteToken(id uint) error {
 
 	return nil
 }
+
+// DeleteTokensByEMailAndType deletes all tokens of the given type which belong to the given email
+func (s Storage) DeleteTokensByEMailAndType(email, tokenType string) error {
+	res := s.db.Where(&Token{EMail: email, Type: tokenType}).Delete(&Token{})
+	if res.Error != nil {
+		return fmt.Errorf("failed to delete tokens: %w", res.Error)
+	}
+
+	return nil
+}
+
+// ConsumeToken consumes the token with the given ID, so it can not be used anymore. Consuming is atomic: the token will
+// only be deleted when it has not been consumed yet, so concurrent calls with the same ID can only succeed once.
+// return ErrTokenNotFound there is no (unconsumed) token with the given ID
+func (s Storage) ConsumeToken(id uint) error {
+	res := s.db.Where("deleted_at IS NULL").Delete(&Token{}, id)
+	if res.Error != nil {
+		return fmt.Errorf("failed to consume token: %w", res.Error)
+	}
+
+	if res.RowsAffected < 1 {
+		return ErrTokenNotFound
+	}
+
+	return nil
+}
+
+// RegisterTokenAttempt increments the failed attempts of the token with the given ID and returns the new number of
+// attempts.
+// return ErrTokenNotFound there is no (unconsumed) token with the given ID
+func (s Storage) RegisterTokenAttempt(id uint) (int, error) {
+	var attempts int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Token{}).Where("id = ? AND deleted_at IS NULL", id).UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+		if res.Error != nil {
+			return fmt.Errorf("failed to increment token attempts: %w", res.Error)
+		}
+
+		if res.RowsAffected < 1 {
+			return ErrTokenNotFound
+		}
+
+		var t Token
+		res = tx.Select("attempts").First(&t, id)
+		if res.Error != nil {
+			return fmt.Errorf("failed to select token attempts: %w", res.Error)
+		}
+
+		attempts = t.Attempts
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return attempts, nil
+}
+
+// DeleteExpiredTokens permanently deletes up to limit tokens which have been expired before the given time. Tokens
+// without expiry have been persisted before expiries were introduced, they expire after the lifetime of their type in
+// legacyLifetimes counted from their creation. Already deleted tokens without expiry are orphaned and will be deleted
+// as well. It returns the number of deleted tokens.
+func (s Storage) DeleteExpiredTokens(before time.Time, legacyLifetimes map[string]time.Duration, limit int) (int64, error) {
+	expired := s.db.Unscoped().
+		Model(&Token{}).
+		Select("id").
+		Where("expires_at < ?", before).
+		Or("expires_at IS NULL AND deleted_at IS NOT NULL")
+	for tokenType, lifetime := range legacyLifetimes {
+		expired = expired.Or("expires_at IS NULL AND type = ? AND created_at < ?", tokenType, before.Add(-lifetime))
+	}
+
+	res := s.db.Unscoped().Where("id IN (?)", expired.Limit(limit)).Delete(&Token{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired tokens: %w", res.Error)
+	}
+
+	return res.RowsAffected, nil
+}
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStorage_ConsumeToken(t *testing.T) {
+	s, err := New(dbTypeSQLite, filepath.Join(t.TempDir(), "storage.db")+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %s", err)
+	}
+
+	token := &Token{EMail: "test@test.test", Token: "jwt-id", Type: TokenTypeRefresh, ExpiresAt: time.Now().Add(time.Hour)}
+	err = s.CreateToken(token)
+	if err != nil {
+		t.Fatalf("Failed to create token: %s", err)
+	}
+
+	const consumers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, consumers)
+	for i := 0; i < consumers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- s.ConsumeToken(token.ID)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var consumed int
+	for err := range errs {
+		switch {
+		case err == nil:
+			consumed++
+		case !errors.Is(err, ErrTokenNotFound):
+			t.Errorf("Unexpected error: %s", err)
+		}
+	}
+
+	if consumed != 1 {
+		t.Errorf("Token has been consumed %d times. Expected: 1", consumed)
+	}
+
+	tokens, err := s.TokensByEMailAndToken(token.EMail, token.Token)
+	if err != nil {
+		t.Fatalf("Failed to find tokens: %s", err)
+	}
+
+	if len(tokens) != 0 {
+		t.Errorf("Consumed token should not be found anymore. Given: %#v", tokens)
+	}
+}
+
+func TestStorage_DeleteTokensByFamily(t *testing.T) {
+	s, err := New(dbTypeSQLite, filepath.Join(t.TempDir(), "storage.db"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %s", err)
+	}
+
+	for _, token := range []*Token{
+		{EMail: "test@test.test", Token: "jwt-id-1", Type: TokenTypeRefresh, Family: "family-1"},
+		{EMail: "test@test.test", Token: "jwt-id-2", Type: TokenTypeRefresh, Family: "family-1"},
+		{EMail: "test@test.test", Token: "jwt-id-3", Type: TokenTypeRefresh, Family: "family-2"},
+		{EMail: "test@test.test", Token: "reset-token", Type: TokenTypeReset},
+	} {
+		err = s.CreateToken(token)
+		if err != nil {
+			t.Fatalf("Failed to create token: %s", err)
+		}
+	}
+
+	err = s.DeleteTokensByFamily("")
+	if err == nil {
+		t.Error("Deleting tokens of an empty family should fail")
+	}
+
+	err = s.DeleteTokensByFamily("family-1")
+	if err != nil {
+		t.Fatalf("Failed to delete tokens of family: %s", err)
+	}
+
+	for token, expectedCount := range map[string]int{"jwt-id-1": 0, "jwt-id-2": 0, "jwt-id-3": 1, "reset-token": 1} {
+		tokens, err := s.TokensByEMailAndToken("test@test.test", token)
+		if err != nil {
+			t.Fatalf("Failed to find tokens: %s", err)
+		}
+
+		if len(tokens) != expectedCount {
+			t.Errorf("Unexpected number of tokens %q. Expected: %d, Given: %d", token, expectedCount, len(tokens))
+		}
+	}
+}
+
+func TestStorage_RegisterTokenAttempt(t *testing.T) {
+	s, err := New(dbTypeSQLite, filepath.Join(t.TempDir(), "storage.db"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %s", err)
+	}
+
+	token := &Token{EMail: "test@test.test", Token: "123456", Type: TokenTypeEMailOTP, ExpiresAt: time.Now().Add(time.Hour)}
+	err = s.CreateToken(token)
+	if err != nil {
+		t.Fatalf("Failed to create token: %s", err)
+	}
+
+	for expected := 1; expected <= 3; expected++ {
+		attempts, err := s.RegisterTokenAttempt(token.ID)
+		if err != nil {
+			t.Fatalf("Failed to register token attempt: %s", err)
+		}
+
+		if attempts != expected {
+			t.Errorf("Attempts are not as expected. Expected: %d, Given: %d", expected, attempts)
+		}
+	}
+
+	err = s.ConsumeToken(token.ID)
+	if err != nil {
+		t.Fatalf("Failed to consume token: %s", err)
+	}
+
+	_, err = s.RegisterTokenAttempt(token.ID)
+	if !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("Attempt of consumed token should return ErrTokenNotFound. Given: %v", err)
+	}
+}
+
+func TestStorage_DeleteExpiredTokens(t *testing.T) {
+	s, err := New(dbTypeSQLite, filepath.Join(t.TempDir(), "storage.db"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %s", err)
+	}
+
+	now := time.Now()
+	tokens := map[string]*Token{
+		"valid":                  {Type: TokenTypeRefresh, ExpiresAt: now.Add(time.Hour)},
+		"expired":                {Type: TokenTypeRefresh, ExpiresAt: now.Add(-time.Hour)},
+		"legacy refresh":         {Type: TokenTypeRefresh, Model: gorm.Model{CreatedAt: now.Add(-time.Hour)}},
+		"expired legacy refresh": {Type: TokenTypeRefresh, Model: gorm.Model{CreatedAt: now.Add(-3 * time.Hour)}},
+		"legacy reset":           {Type: TokenTypeReset, Model: gorm.Model{CreatedAt: now.Add(-time.Minute)}},
+		"expired legacy reset":   {Type: TokenTypeReset, Model: gorm.Model{CreatedAt: now.Add(-time.Hour)}},
+	}
+	expectedDeleted := map[string]bool{"expired": true, "expired legacy refresh": true, "expired legacy reset": true}
+
+	for name, token := range tokens {
+		token.EMail = "test@test.test"
+		token.Token = name
+		err = s.CreateToken(token)
+		if err != nil {
+			t.Fatalf("Failed to create token: %s", err)
+		}
+	}
+
+	// tokens without expiry have been persisted before the column existed
+	err = s.db.Model(&Token{}).Where("expires_at < ?", time.Date(2, 1, 1, 0, 0, 0, 0, time.UTC)).Update("expires_at", nil).Error
+	if err != nil {
+		t.Fatalf("Failed to clear token expiries: %s", err)
+	}
+
+	deleted, err := s.DeleteExpiredTokens(now, map[string]time.Duration{TokenTypeRefresh: 2 * time.Hour, TokenTypeReset: 30 * time.Minute}, 10)
+	if err != nil {
+		t.Fatalf("Failed to delete expired tokens: %s", err)
+	}
+
+	if deleted != int64(len(expectedDeleted)) {
+		t.Errorf("Unexpected number of deleted tokens. Expected: %d, Given: %d", len(expectedDeleted), deleted)
+	}
+
+	for name := range tokens {
+		found, err := s.TokensByEMailAndToken("test@test.test", name)
+		if err != nil {
+			t.Fatalf("Failed to find tokens: %s", err)
+		}
+
+		if (len(found) == 0) != expectedDeleted[name] {
+			t.Errorf("Unexpected deletion of token %q. Expected deleted: %t", name, expectedDeleted[name])
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/mattn/go-sqlite3"
 	"gorm.io/gorm"
 	"reflect"
+	"time"
 )
 
 // User represent a persisted user
@@ -15,8 +16,23 @@ type User struct {
 	EMail    string `gorm:"uniqueIndex:unique_email"`
 	Password []byte
 	Claims   Claims
+	// PasswordBreached is true when the password has been found in a data breach
+	PasswordBreached bool
+	// TOTPSecret is the base32 encoded secret of the TOTP authenticator. It is pending until TOTPEnabled is true.
+	TOTPSecret  string
+	TOTPEnabled bool
+	// TOTPLastStep is the time step of the last accepted TOTP code, so each code can only be used once
+	TOTPLastStep int64
+	// EMailOTPEnabled is true when one-time codes will be sent via email as second factor
+	EMailOTPEnabled bool
+	// EMailUnverified is true until a self-registered user has verified the email. Users created via the admin api are
+	// verified.
+	EMailUnverified bool
 }
 
+// notUpdatableUserColumns will be omitted by UpdateUser and UpdateUnverifiedUser
+var notUpdatableUserColumns = []string{"ID", "CreatedAt", "DeletedAt", "EMail", "TOTPSecret", "TOTPEnabled", "TOTPLastStep", "EMailOTPEnabled"}
+
 // ErrUserNotFound returned when requested user not found
 var ErrUserNotFound = errors.New("user not found")
 
@@ -62,10 +78,123 @@ func (s *Storage) User(email string) (User, error) {
 	return user, nil
 }
 
-// UpdateUser updates all properties (excluding email) from the given user which will be identified by email
+// UpdateUser updates all properties (excluding email and the mfa state) from the given user which will be identified by
+// its ID. Zero values will be updated as well. The mfa state has to be updated via the dedicated methods, so a user
+// which has been read before can not roll back e.g. the last accepted TOTP step.
 // return ErrUserNotFound when user not found
 func (s *Storage) UpdateUser(u User) error {
-	res := s.db.Updates(u)
+	res := s.db.Model(&u).Select("*").Omit(notUpdatableUserColumns...).Updates(u)
+	if res.Error != nil {
+		return fmt.Errorf("failed to exec update user stmt: %w", res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UpdateUnverifiedUser updates all properties (excluding email and the mfa state) like UpdateUser, but only as long as the email of the
+// user has not been verified.
+// return ErrUserNotFound when there is no unverified user with the ID of the given user
+func (s *Storage) UpdateUnverifiedUser(u User) error {
+	res := s.db.Model(&u).Where("e_mail_unverified = ?", true).Select("*").Omit(notUpdatableUserColumns...).Updates(u)
+	if res.Error != nil {
+		return fmt.Errorf("failed to exec update unverified user stmt: %w", res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UpdateUserPassword replaces the password hash of the user with the given email, but only as long as it is still the
+// given old hash. Nothing will be updated when the password has been changed in the meantime.
+func (s *Storage) UpdateUserPassword(email string, oldHash, newHash []byte) error {
+	err := s.db.Model(&User{}).Where("e_mail = ? AND password = ?", email, oldHash).Update("password", newHash).Error
+	if err != nil {
+		return fmt.Errorf("failed to exec update user password stmt: %w", err)
+	}
+
+	return nil
+}
+
+// SetUserPassword replaces the password hash and the breached flag of the user with the given email.
+// return ErrUserNotFound when user not found
+func (s *Storage) SetUserPassword(email string, password []byte, breached bool) error {
+	return s.updateUserColumns(email, map[string]interface{}{
+		"password":          password,
+		"password_breached": breached,
+	})
+}
+
+// VerifyUserEMail marks the email of the user with the given email as verified.
+// return ErrUserNotFound when user not found
+func (s *Storage) VerifyUserEMail(email string) error {
+	return s.updateUserColumns(email, map[string]interface{}{
+		"e_mail_unverified": false,
+	})
+}
+
+// EnrollUserTOTP stores the given pending TOTP secret of the user with the given email and resets the last accepted
+// step, but only as long as TOTP has not been enabled. It returns false when TOTP has already been enabled.
+func (s *Storage) EnrollUserTOTP(email, secret string) (bool, error) {
+	res := s.db.Model(&User{}).
+		Where("e_mail = ? AND totp_enabled = ?", email, false).
+		Updates(map[string]interface{}{
+			"totp_secret":    secret,
+			"totp_last_step": 0,
+		})
+	if res.Error != nil {
+		return false, fmt.Errorf("failed to exec enroll totp stmt: %w", res.Error)
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+// EnableUserTOTP enables TOTP of the user with the given email with the given step as last accepted step, but only as
+// long as the given secret is still the enrolled one and TOTP has not been enabled yet. It returns false otherwise, e.g.
+// when the secret has been enrolled again concurrently.
+func (s *Storage) EnableUserTOTP(email, secret string, step int64) (bool, error) {
+	res := s.db.Model(&User{}).
+		Where("e_mail = ? AND totp_secret = ? AND totp_enabled = ?", email, secret, false).
+		Updates(map[string]interface{}{
+			"totp_enabled":   true,
+			"totp_last_step": step,
+		})
+	if res.Error != nil {
+		return false, fmt.Errorf("failed to exec enable totp stmt: %w", res.Error)
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+// EnableUserEMailOTP enables one-time codes via email of the user with the given email.
+// return ErrUserNotFound when user not found
+func (s *Storage) EnableUserEMailOTP(email string) error {
+	return s.updateUserColumns(email, map[string]interface{}{
+		"e_mail_otp_enabled": true,
+	})
+}
+
+// DisableUserMFA disables and removes all second factors of the user with the given email.
+// return ErrUserNotFound when user not found
+func (s *Storage) DisableUserMFA(email string) error {
+	return s.updateUserColumns(email, map[string]interface{}{
+		"totp_secret":        "",
+		"totp_enabled":       false,
+		"totp_last_step":     0,
+		"e_mail_otp_enabled": false,
+	})
+}
+
+// updateUserColumns updates only the given columns of the user with the given email
+// return ErrUserNotFound when user not found
+func (s *Storage) updateUserColumns(email string, columns map[string]interface{}) error {
+	res := s.db.Model(&User{}).Where("e_mail = ?", email).Updates(columns)
 	if res.Error != nil {
 		return fmt.Errorf("failed to exec update user stmt: %w", res.Error)
 	}
@@ -77,16 +206,59 @@ func (s *Storage) UpdateUser(u User) error {
 	return nil
 }
 
-// DeleteUser deletes the user with the given email and all corresponding tokes in one transaction.
+// UpdateTOTPLastStep persists the given time step as last accepted TOTP code of the user with the given email, but only
+// when it is later than the persisted one. It returns false when the step or a later one has already been accepted, e.g.
+// when the same code has been used concurrently.
+func (s *Storage) UpdateTOTPLastStep(email string, step int64) (bool, error) {
+	res := s.db.Model(&User{}).Where("e_mail = ? AND totp_last_step < ?", email, step).Update("totp_last_step", step)
+	if res.Error != nil {
+		return false, fmt.Errorf("failed to exec update totp last step stmt: %w", res.Error)
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+// DeleteUnverifiedUsers permanently deletes up to limit users whose email has not been verified and who have not been
+// updated since the given time. It returns the number of deleted users.
+func (s *Storage) DeleteUnverifiedUsers(before time.Time, limit int) (int64, error) {
+	unverified := s.db.Model(&User{}).
+		Select("id").
+		Where("e_mail_unverified = ? AND updated_at < ?", true, before)
+
+	res := s.db.Unscoped().Where("id IN (?)", unverified.Limit(limit)).Delete(&User{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("failed to delete unverified users: %w", res.Error)
+	}
+
+	return res.RowsAffected, nil
+}
+
+// DeleteUser deletes the user with the given email, all corresponding tokes, its password history, recovery codes and
+// webauthn credentials in one transaction.
 // return ErrUserNotFound when user not found
 func (s *Storage) DeleteUser(email string) error {
 	err := s.db.Transaction(func(tx *gorm.DB) error {
-		err := s.db.Delete(&Token{}, Token{EMail: email}).Error
+		err := tx.Delete(&Token{}, Token{EMail: email}).Error
 		if err != nil {
 			return fmt.Errorf("failed to exec delete tokens from user stmt: %w", err)
 		}
 
-		res := s.db.Delete(&User{}, User{EMail: email})
+		err = tx.Delete(&PasswordHistory{}, PasswordHistory{EMail: email}).Error
+		if err != nil {
+			return fmt.Errorf("failed to exec delete password history from user stmt: %w", err)
+		}
+
+		err = tx.Delete(&RecoveryCode{}, RecoveryCode{EMail: email}).Error
+		if err != nil {
+			return fmt.Errorf("failed to exec delete recovery codes from user stmt: %w", err)
+		}
+
+		err = tx.Delete(&WebAuthnCredential{}, WebAuthnCredential{EMail: email}).Error
+		if err != nil {
+			return fmt.Errorf("failed to exec delete webauthn credentials from user stmt: %w", err)
+		}
+
+		res := tx.Delete(&User{}, User{EMail: email})
 		if res.Error != nil {
 			return fmt.Errorf("failed to exec delete user stmt: %w", res.Error)
 		}
@@ -0,0 +1,511 @@
+package storage
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStorage_UpdateUser(t *testing.T) {
+	s, err := New(dbTypeSQLite, filepath.Join(t.TempDir(), "storage.db"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %s", err)
+	}
+
+	err = s.CreateUser(User{EMail: "test@test.test", Password: []byte("hash"), Claims: Claims{"role": "admin"}, PasswordBreached: true})
+	if err != nil {
+		t.Fatalf("Failed to create user: %s", err)
+	}
+
+	u, err := s.User("test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to find user: %s", err)
+	}
+
+	u.Password = []byte("new-hash")
+	u.Claims = Claims{}
+	u.PasswordBreached = false
+	err = s.UpdateUser(u)
+	if err != nil {
+		t.Fatalf("Failed to update user: %s", err)
+	}
+
+	updated, err := s.User("test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to find updated user: %s", err)
+	}
+
+	if string(updated.Password) != "new-hash" {
+		t.Errorf("Password is not as expected. Expected: %q, Given: %q", "new-hash", updated.Password)
+	}
+	if !reflect.DeepEqual(updated.Claims, Claims{}) {
+		t.Errorf("Claims are not as expected. Expected: %#v, Given: %#v", Claims{}, updated.Claims)
+	}
+	if updated.PasswordBreached {
+		t.Error("PasswordBreached should have been reset")
+	}
+	if !updated.CreatedAt.Equal(u.CreatedAt) {
+		t.Errorf("CreatedAt should not have been changed. Expected: %s, Given: %s", u.CreatedAt, updated.CreatedAt)
+	}
+}
+
+func TestStorage_UpdateUser_KeepsMFAState(t *testing.T) {
+	s, err := New(dbTypeSQLite, filepath.Join(t.TempDir(), "storage.db"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %s", err)
+	}
+
+	err = s.CreateUser(User{EMail: "test@test.test", Password: []byte("hash")})
+	if err != nil {
+		t.Fatalf("Failed to create user: %s", err)
+	}
+
+	outdated, err := s.User("test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to find user: %s", err)
+	}
+
+	err = s.DisableUserMFA("test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to disable mfa: %s", err)
+	}
+	_, err = s.EnrollUserTOTP("test@test.test", "secret")
+	if err != nil {
+		t.Fatalf("Failed to enroll totp: %s", err)
+	}
+	_, err = s.EnableUserTOTP("test@test.test", "secret", 42)
+	if err != nil {
+		t.Fatalf("Failed to enable totp: %s", err)
+	}
+	err = s.EnableUserEMailOTP("test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to enable email otp: %s", err)
+	}
+
+	outdated.Password = []byte("new-hash")
+	err = s.UpdateUser(outdated)
+	if err != nil {
+		t.Fatalf("Failed to update user: %s", err)
+	}
+
+	updated, err := s.User("test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to find user: %s", err)
+	}
+
+	if string(updated.Password) != "new-hash" {
+		t.Errorf("Password is not as expected. Expected: %q, Given: %q", "new-hash", updated.Password)
+	}
+	if updated.TOTPSecret != "secret" || !updated.TOTPEnabled || updated.TOTPLastStep != 42 || !updated.EMailOTPEnabled {
+		t.Errorf("MFA state should not have been rolled back. Given: %#v", updated)
+	}
+}
+
+func TestStorage_SetUserPassword(t *testing.T) {
+	s, err := New(dbTypeSQLite, filepath.Join(t.TempDir(), "storage.db"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %s", err)
+	}
+
+	err = s.CreateUser(User{EMail: "test@test.test", Password: []byte("hash"), Claims: Claims{"role": "admin"}, TOTPLastStep: 42})
+	if err != nil {
+		t.Fatalf("Failed to create user: %s", err)
+	}
+
+	err = s.SetUserPassword("test@test.test", []byte("new-hash"), true)
+	if err != nil {
+		t.Fatalf("Failed to set password: %s", err)
+	}
+
+	u, err := s.User("test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to find user: %s", err)
+	}
+
+	if string(u.Password) != "new-hash" || !u.PasswordBreached {
+		t.Errorf("Password is not as expected. Given: %q, breached: %t", u.Password, u.PasswordBreached)
+	}
+	if !reflect.DeepEqual(u.Claims, Claims{"role": "admin"}) || u.TOTPLastStep != 42 {
+		t.Errorf("Other columns should not have been changed. Given: %#v", u)
+	}
+
+	err = s.SetUserPassword("unknown@test.test", []byte("new-hash"), false)
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Unexpected error. Expected: %q, Given: %q", ErrUserNotFound, err)
+	}
+}
+
+func TestStorage_VerifyUserEMail(t *testing.T) {
+	s, err := New(dbTypeSQLite, filepath.Join(t.TempDir(), "storage.db"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %s", err)
+	}
+
+	err = s.CreateUser(User{EMail: "test@test.test", Password: []byte("hash"), EMailUnverified: true})
+	if err != nil {
+		t.Fatalf("Failed to create user: %s", err)
+	}
+
+	err = s.VerifyUserEMail("test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to verify email: %s", err)
+	}
+
+	u, err := s.User("test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to find user: %s", err)
+	}
+
+	if u.EMailUnverified || string(u.Password) != "hash" {
+		t.Errorf("User is not as expected. Given: %#v", u)
+	}
+
+	err = s.VerifyUserEMail("unknown@test.test")
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Unexpected error. Expected: %q, Given: %q", ErrUserNotFound, err)
+	}
+}
+
+func TestStorage_UserTOTP(t *testing.T) {
+	s, err := New(dbTypeSQLite, filepath.Join(t.TempDir(), "storage.db"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %s", err)
+	}
+
+	err = s.CreateUser(User{EMail: "test@test.test", Password: []byte("hash"), TOTPLastStep: 7})
+	if err != nil {
+		t.Fatalf("Failed to create user: %s", err)
+	}
+
+	tests := []struct {
+		name             string
+		do               func() (bool, error)
+		expectedUpdated  bool
+		expectedSecret   string
+		expectedEnabled  bool
+		expectedLastStep int64
+	}{
+		{
+			name:            "Enroll",
+			do:              func() (bool, error) { return s.EnrollUserTOTP("test@test.test", "secret-1") },
+			expectedUpdated: true,
+			expectedSecret:  "secret-1",
+		}, {
+			name:            "Enroll again",
+			do:              func() (bool, error) { return s.EnrollUserTOTP("test@test.test", "secret-2") },
+			expectedUpdated: true,
+			expectedSecret:  "secret-2",
+		}, {
+			name:           "Enable replaced secret",
+			do:             func() (bool, error) { return s.EnableUserTOTP("test@test.test", "secret-1", 42) },
+			expectedSecret: "secret-2",
+		}, {
+			name:             "Enable",
+			do:               func() (bool, error) { return s.EnableUserTOTP("test@test.test", "secret-2", 42) },
+			expectedUpdated:  true,
+			expectedSecret:   "secret-2",
+			expectedEnabled:  true,
+			expectedLastStep: 42,
+		}, {
+			name:             "Enable again",
+			do:               func() (bool, error) { return s.EnableUserTOTP("test@test.test", "secret-2", 43) },
+			expectedSecret:   "secret-2",
+			expectedEnabled:  true,
+			expectedLastStep: 42,
+		}, {
+			name:             "Enroll when enabled",
+			do:               func() (bool, error) { return s.EnrollUserTOTP("test@test.test", "secret-3") },
+			expectedSecret:   "secret-2",
+			expectedEnabled:  true,
+			expectedLastStep: 42,
+		}, {
+			name: "Disable",
+			do: func() (bool, error) {
+				return true, s.DisableUserMFA("test@test.test")
+			},
+			expectedUpdated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := tt.do()
+			if err != nil {
+				t.Fatalf("Failed to update totp: %s", err)
+			}
+
+			if updated != tt.expectedUpdated {
+				t.Errorf("Updated is not as expected. Expected: %t, Given: %t", tt.expectedUpdated, updated)
+			}
+
+			u, err := s.User("test@test.test")
+			if err != nil {
+				t.Fatalf("Failed to find user: %s", err)
+			}
+
+			if u.TOTPSecret != tt.expectedSecret || u.TOTPEnabled != tt.expectedEnabled || u.TOTPLastStep != tt.expectedLastStep {
+				t.Errorf("TOTP state is not as expected. Expected: %q, %t, %d Given: %q, %t, %d", tt.expectedSecret, tt.expectedEnabled, tt.expectedLastStep, u.TOTPSecret, u.TOTPEnabled, u.TOTPLastStep)
+			}
+		})
+	}
+}
+
+func TestStorage_UserEMailOTP(t *testing.T) {
+	s, err := New(dbTypeSQLite, filepath.Join(t.TempDir(), "storage.db"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %s", err)
+	}
+
+	err = s.CreateUser(User{EMail: "test@test.test", Password: []byte("hash")})
+	if err != nil {
+		t.Fatalf("Failed to create user: %s", err)
+	}
+
+	err = s.EnableUserEMailOTP("test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to enable email otp: %s", err)
+	}
+
+	u, err := s.User("test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to find user: %s", err)
+	}
+	if !u.EMailOTPEnabled {
+		t.Error("Email otp should have been enabled")
+	}
+
+	err = s.DisableUserMFA("test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to disable mfa: %s", err)
+	}
+
+	u, err = s.User("test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to find user: %s", err)
+	}
+	if u.EMailOTPEnabled {
+		t.Error("Email otp should have been disabled")
+	}
+
+	err = s.EnableUserEMailOTP("unknown@test.test")
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Unexpected error. Expected: %q, Given: %q", ErrUserNotFound, err)
+	}
+}
+
+func TestStorage_UpdateUnverifiedUser(t *testing.T) {
+	s, err := New(dbTypeSQLite, filepath.Join(t.TempDir(), "storage.db"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %s", err)
+	}
+
+	for _, u := range []User{
+		{EMail: "unverified@test.test", Password: []byte("hash"), EMailUnverified: true},
+		{EMail: "verified@test.test", Password: []byte("hash")},
+	} {
+		err = s.CreateUser(u)
+		if err != nil {
+			t.Fatalf("Failed to create user: %s", err)
+		}
+	}
+
+	tests := []struct {
+		name             string
+		email            string
+		expectedError    error
+		expectedPassword string
+	}{
+		{name: "Unverified user", email: "unverified@test.test", expectedPassword: "new-hash"},
+		{name: "Verified user", email: "verified@test.test", expectedError: ErrUserNotFound, expectedPassword: "hash"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := s.User(tt.email)
+			if err != nil {
+				t.Fatalf("Failed to find user: %s", err)
+			}
+
+			u.Password = []byte("new-hash")
+			err = s.UpdateUnverifiedUser(u)
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("Unexpected error. Expected: %q, Given: %q", tt.expectedError, err)
+			}
+
+			updated, err := s.User(tt.email)
+			if err != nil {
+				t.Fatalf("Failed to find user: %s", err)
+			}
+
+			if string(updated.Password) != tt.expectedPassword {
+				t.Errorf("Password is not as expected. Expected: %q, Given: %q", tt.expectedPassword, updated.Password)
+			}
+		})
+	}
+}
+
+func TestStorage_DeleteUnverifiedUsers(t *testing.T) {
+	s, err := New(dbTypeSQLite, filepath.Join(t.TempDir(), "storage.db"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %s", err)
+	}
+
+	now := time.Now()
+	for _, u := range []User{
+		{EMail: "outdated-1@test.test", EMailUnverified: true, Model: gorm.Model{UpdatedAt: now.Add(-2 * time.Hour)}},
+		{EMail: "outdated-2@test.test", EMailUnverified: true, Model: gorm.Model{UpdatedAt: now.Add(-2 * time.Hour)}},
+		{EMail: "recent@test.test", EMailUnverified: true, Model: gorm.Model{UpdatedAt: now}},
+		{EMail: "verified@test.test", Model: gorm.Model{UpdatedAt: now.Add(-2 * time.Hour)}},
+	} {
+		err = s.CreateUser(u)
+		if err != nil {
+			t.Fatalf("Failed to create user: %s", err)
+		}
+	}
+
+	deleted, err := s.DeleteUnverifiedUsers(now.Add(-time.Hour), 1)
+	if err != nil {
+		t.Fatalf("Failed to delete unverified users: %s", err)
+	}
+	if deleted != 1 {
+		t.Errorf("Unexpected number of deleted users. Expected: 1, Given: %d", deleted)
+	}
+
+	deleted, err = s.DeleteUnverifiedUsers(now.Add(-time.Hour), 10)
+	if err != nil {
+		t.Fatalf("Failed to delete unverified users: %s", err)
+	}
+	if deleted != 1 {
+		t.Errorf("Unexpected number of deleted users. Expected: 1, Given: %d", deleted)
+	}
+
+	for email, expectedErr := range map[string]error{
+		"outdated-1@test.test": ErrUserNotFound,
+		"outdated-2@test.test": ErrUserNotFound,
+		"recent@test.test":     nil,
+		"verified@test.test":   nil,
+	} {
+		_, err = s.User(email)
+		if !errors.Is(err, expectedErr) {
+			t.Errorf("Unexpected error while finding user %q. Expected: %v, Given: %v", email, expectedErr, err)
+		}
+	}
+
+	err = s.CreateUser(User{EMail: "outdated-1@test.test"})
+	if err != nil {
+		t.Errorf("Email of deleted unverified user should be available again: %s", err)
+	}
+}
+
+func TestStorage_UpdateUserPassword(t *testing.T) {
+	s, err := New(dbTypeSQLite, filepath.Join(t.TempDir(), "storage.db"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %s", err)
+	}
+
+	err = s.CreateUser(User{EMail: "test@test.test", Password: []byte("hash"), Claims: Claims{"role": "admin"}})
+	if err != nil {
+		t.Fatalf("Failed to create user: %s", err)
+	}
+
+	err = s.UpdateUserPassword("test@test.test", []byte("outdated-hash"), []byte("rehash"))
+	if err != nil {
+		t.Fatalf("Failed to update password: %s", err)
+	}
+
+	u, err := s.User("test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to find user: %s", err)
+	}
+	if string(u.Password) != "hash" {
+		t.Errorf("Password which has been changed in the meantime should not be updated. Expected: %q, Given: %q", "hash", u.Password)
+	}
+
+	err = s.UpdateUserPassword("test@test.test", []byte("hash"), []byte("rehash"))
+	if err != nil {
+		t.Fatalf("Failed to update password: %s", err)
+	}
+
+	u, err = s.User("test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to find user: %s", err)
+	}
+	if string(u.Password) != "rehash" {
+		t.Errorf("Password is not as expected. Expected: %q, Given: %q", "rehash", u.Password)
+	}
+	if !reflect.DeepEqual(u.Claims, Claims{"role": "admin"}) {
+		t.Errorf("Claims should not have been changed. Expected: %#v, Given: %#v", Claims{"role": "admin"}, u.Claims)
+	}
+}
+
+func TestStorage_DeleteUser_RollsBackWhenUserNotFound(t *testing.T) {
+	s, err := New(dbTypeSQLite, filepath.Join(t.TempDir(), "storage.db"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %s", err)
+	}
+
+	err = s.CreateWebAuthnCredential(&WebAuthnCredential{EMail: "test@test.test", CredentialID: []byte("credential-1")})
+	if err != nil {
+		t.Fatalf("Failed to create webauthn credential: %s", err)
+	}
+
+	err = s.DeleteUser("test@test.test")
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("Processing error is not as expected: \nExpected:\n%s\nGiven:\n%s", ErrUserNotFound, err)
+	}
+
+	credentials, err := s.WebAuthnCredentialsByEMail("test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to find webauthn credentials: %s", err)
+	}
+	if len(credentials) != 1 {
+		t.Errorf("Deletion of webauthn credentials should have been rolled back. Given: %#v", credentials)
+	}
+}
+
+func TestStorage_UpdateTOTPLastStep(t *testing.T) {
+	s, err := New(dbTypeSQLite, filepath.Join(t.TempDir(), "storage.db"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %s", err)
+	}
+
+	err = s.CreateUser(User{EMail: "test@test.test", Password: []byte("hash"), TOTPLastStep: 41})
+	if err != nil {
+		t.Fatalf("Failed to create user: %s", err)
+	}
+
+	tests := []struct {
+		name             string
+		step             int64
+		expectedUpdated  bool
+		expectedLastStep int64
+	}{
+		{name: "Later step", step: 42, expectedUpdated: true, expectedLastStep: 42},
+		{name: "Same step", step: 42, expectedUpdated: false, expectedLastStep: 42},
+		{name: "Earlier step", step: 40, expectedUpdated: false, expectedLastStep: 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := s.UpdateTOTPLastStep("test@test.test", tt.step)
+			if err != nil {
+				t.Fatalf("Failed to update totp last step: %s", err)
+			}
+
+			if updated != tt.expectedUpdated {
+				t.Errorf("Updated is not as expected. Expected: %t, Given: %t", tt.expectedUpdated, updated)
+			}
+
+			u, err := s.User("test@test.test")
+			if err != nil {
+				t.Fatalf("Failed to find user: %s", err)
+			}
+			if u.TOTPLastStep != tt.expectedLastStep {
+				t.Errorf("Last step is not as expected. Expected: %d, Given: %d", tt.expectedLastStep, u.TOTPLastStep)
+			}
+		})
+	}
+}
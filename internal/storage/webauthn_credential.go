@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// WebAuthnCredential represents a registered WebAuthn public key credential (passkey) of a user. PublicKey is COSE_Key
+// encoded. SignCount is the last signature counter which has been reported by the authenticator.
+type WebAuthnCredential struct {
+	ID           uint   `gorm:"primarykey"`
+	EMail        string `gorm:"index"`
+	CredentialID []byte `gorm:"uniqueIndex"`
+	PublicKey    []byte
+	SignCount    uint32
+	CreatedAt    time.Time
+	LastUsedAt   time.Time
+}
+
+// CreateWebAuthnCredential persists the given credential. ID will be set automatically.
+func (s Storage) CreateWebAuthnCredential(c *WebAuthnCredential) error {
+	res := s.db.Create(c)
+	if res.Error != nil {
+		return fmt.Errorf("failed to exec create webauthn credential stmt: %w", res.Error)
+	}
+
+	return nil
+}
+
+// WebAuthnCredentialsByEMail finds all credentials of the user with the given email
+func (s Storage) WebAuthnCredentialsByEMail(email string) ([]WebAuthnCredential, error) {
+	var credentials []WebAuthnCredential
+	res := s.db.Order("created_at").Find(&credentials, &WebAuthnCredential{EMail: email})
+	if res.Error != nil {
+		return nil, fmt.Errorf("failed to exec select webauthn credentials stmt: %w", res.Error)
+	}
+
+	return credentials, nil
+}
+
+// UpdateWebAuthnCredentialUsage stores the signature counter and the time of the last usage of the credential with
+// the given id, but only when the counter is greater than the persisted one or the persisted one is 0 (authenticator
+// without counter). It returns false when the counter has already been reported, e.g. when the same assertion has been
+// used concurrently.
+func (s Storage) UpdateWebAuthnCredentialUsage(id uint, signCount uint32, lastUsedAt time.Time) (bool, error) {
+	res := s.db.Model(&WebAuthnCredential{}).
+		Where("id = ? AND (sign_count < ? OR sign_count = 0)", id, signCount).
+		Updates(map[string]interface{}{
+			"sign_count":   signCount,
+			"last_used_at": lastUsedAt,
+		})
+	if res.Error != nil {
+		return false, fmt.Errorf("failed to exec update webauthn credential stmt: %w", res.Error)
+	}
+
+	return res.RowsAffected > 0, nil
+}
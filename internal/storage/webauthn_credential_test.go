@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStorage_WebAuthnCredentials(t *testing.T) {
+	s, err := New(dbTypeSQLite, filepath.Join(t.TempDir(), "storage.db"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %s", err)
+	}
+
+	c := WebAuthnCredential{EMail: "test@test.test", CredentialID: []byte("credential-1"), PublicKey: []byte("key-1")}
+	err = s.CreateWebAuthnCredential(&c)
+	if err != nil {
+		t.Fatalf("Failed to create webauthn credential: %s", err)
+	}
+	err = s.CreateWebAuthnCredential(&WebAuthnCredential{EMail: "other@test.test", CredentialID: []byte("credential-2")})
+	if err != nil {
+		t.Fatalf("Failed to create webauthn credential: %s", err)
+	}
+
+	err = s.CreateWebAuthnCredential(&WebAuthnCredential{EMail: "other@test.test", CredentialID: []byte("credential-1")})
+	if err == nil {
+		t.Error("Credential ids must be unique")
+	}
+
+	lastUsedAt := time.Date(2021, 5, 6, 7, 8, 9, 0, time.UTC)
+	_, err = s.UpdateWebAuthnCredentialUsage(c.ID, 42, lastUsedAt)
+	if err != nil {
+		t.Fatalf("Failed to update webauthn credential: %s", err)
+	}
+
+	credentials, err := s.WebAuthnCredentialsByEMail("test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to find webauthn credentials: %s", err)
+	}
+	if len(credentials) != 1 {
+		t.Fatalf("Expected exactly one credential. Given: %d", len(credentials))
+	}
+
+	given := credentials[0]
+	if !reflect.DeepEqual(given.CredentialID, c.CredentialID) || !reflect.DeepEqual(given.PublicKey, c.PublicKey) {
+		t.Errorf("Credential is not as expected. Expected: %#v, Given: %#v", c, given)
+	}
+	if given.SignCount != 42 || !given.LastUsedAt.Equal(lastUsedAt) {
+		t.Errorf("Usage is not as expected. Given sign count: %d, last used at: %s", given.SignCount, given.LastUsedAt)
+	}
+
+	err = s.CreateUser(User{EMail: "test@test.test"})
+	if err != nil {
+		t.Fatalf("Failed to create user: %s", err)
+	}
+	err = s.DeleteUser("test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to delete user: %s", err)
+	}
+
+	credentials, err = s.WebAuthnCredentialsByEMail("test@test.test")
+	if err != nil {
+		t.Fatalf("Failed to find webauthn credentials: %s", err)
+	}
+	if len(credentials) != 0 {
+		t.Errorf("Credentials of deleted user should be deleted. Given: %d", len(credentials))
+	}
+}
+
+func TestStorage_UpdateWebAuthnCredentialUsage(t *testing.T) {
+	s, err := New(dbTypeSQLite, filepath.Join(t.TempDir(), "storage.db"))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %s", err)
+	}
+
+	withoutCounter := WebAuthnCredential{EMail: "test@test.test", CredentialID: []byte("credential-1")}
+	err = s.CreateWebAuthnCredential(&withoutCounter)
+	if err != nil {
+		t.Fatalf("Failed to create webauthn credential: %s", err)
+	}
+	withCounter := WebAuthnCredential{EMail: "test@test.test", CredentialID: []byte("credential-2"), SignCount: 41}
+	err = s.CreateWebAuthnCredential(&withCounter)
+	if err != nil {
+		t.Fatalf("Failed to create webauthn credential: %s", err)
+	}
+
+	tests := []struct {
+		name              string
+		id                uint
+		signCount         uint32
+		expectedUpdated   bool
+		expectedSignCount uint32
+	}{
+		{name: "Without counter", id: withoutCounter.ID, signCount: 0, expectedUpdated: true, expectedSignCount: 0},
+		{name: "Greater counter", id: withCounter.ID, signCount: 42, expectedUpdated: true, expectedSignCount: 42},
+		{name: "Same counter", id: withCounter.ID, signCount: 42, expectedUpdated: false, expectedSignCount: 42},
+		{name: "Lower counter", id: withCounter.ID, signCount: 40, expectedUpdated: false, expectedSignCount: 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := s.UpdateWebAuthnCredentialUsage(tt.id, tt.signCount, time.Now())
+			if err != nil {
+				t.Fatalf("Failed to update webauthn credential: %s", err)
+			}
+
+			if updated != tt.expectedUpdated {
+				t.Errorf("Updated is not as expected. Expected: %t, Given: %t", tt.expectedUpdated, updated)
+			}
+
+			credentials, err := s.WebAuthnCredentialsByEMail("test@test.test")
+			if err != nil {
+				t.Fatalf("Failed to find webauthn credentials: %s", err)
+			}
+			for _, c := range credentials {
+				if c.ID == tt.id && c.SignCount != tt.expectedSignCount {
+					t.Errorf("Sign count is not as expected. Expected: %d, Given: %d", tt.expectedSignCount, c.SignCount)
+				}
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ package internal
 import (
 	"github.com/leberKleber/simple-jwt-provider/internal/storage"
 	"sync"
+	"time"
 )
 
 // Ensure, that StorageMock does implement Storage.
@@ -18,27 +19,120 @@ var _ Storage = &StorageMock{}
 //
 // 		// make and configure a mocked Storage
 // 		mockedStorage := &StorageMock{
+// 			AddPasswordHistoryFunc: func(h storage.PasswordHistory, keep int) error {
+// 				panic("mock out the AddPasswordHistory method")
+// 			},
+// 			ConsumeRecoveryCodeFunc: func(email string, code []byte) error {
+// 				panic("mock out the ConsumeRecoveryCode method")
+// 			},
+// 			ConsumeTokenFunc: func(id uint) error {
+// 				panic("mock out the ConsumeToken method")
+// 			},
+// 			ConsumedTokensByEMailAndTokenFunc: func(email string, token string) ([]storage.Token, error) {
+// 				panic("mock out the ConsumedTokensByEMailAndToken method")
+// 			},
 // 			CreateTokenFunc: func(t *storage.Token) error {
 // 				panic("mock out the CreateToken method")
 // 			},
 // 			CreateUserFunc: func(user storage.User) error {
 // 				panic("mock out the CreateUser method")
 // 			},
-// 			DeleteTokenFunc: func(id uint) error {
-// 				panic("mock out the DeleteToken method")
+// 			CreateWebAuthnCredentialFunc: func(c *storage.WebAuthnCredential) error {
+// 				panic("mock out the CreateWebAuthnCredential method")
+// 			},
+// 			DeleteExpiredRevokedTokensFunc: func(before time.Time) error {
+// 				panic("mock out the DeleteExpiredRevokedTokens method")
+// 			},
+// 			DeleteExpiredTokensFunc: func(before time.Time, legacyLifetimes map[string]time.Duration, limit int) (int64, error) {
+// 				panic("mock out the DeleteExpiredTokens method")
+// 			},
+// 			DeleteTokensByEMailAndFamilyFunc: func(email string, family string) error {
+// 				panic("mock out the DeleteTokensByEMailAndFamily method")
+// 			},
+// 			DeleteTokensByEMailAndTypeFunc: func(email string, tokenType string) error {
+// 				panic("mock out the DeleteTokensByEMailAndType method")
+// 			},
+// 			DeleteTokensByFamilyFunc: func(family string) error {
+// 				panic("mock out the DeleteTokensByFamily method")
+// 			},
+// 			DeleteUnverifiedUsersFunc: func(before time.Time, limit int) (int64, error) {
+// 				panic("mock out the DeleteUnverifiedUsers method")
 // 			},
 // 			DeleteUserFunc: func(email string) error {
 // 				panic("mock out the DeleteUser method")
 // 			},
+// 			DisableUserMFAFunc: func(email string) error {
+// 				panic("mock out the DisableUserMFA method")
+// 			},
+// 			EnableUserEMailOTPFunc: func(email string) error {
+// 				panic("mock out the EnableUserEMailOTP method")
+// 			},
+// 			EnableUserTOTPFunc: func(email string, secret string, step int64) (bool, error) {
+// 				panic("mock out the EnableUserTOTP method")
+// 			},
+// 			EnrollUserTOTPFunc: func(email string, secret string) (bool, error) {
+// 				panic("mock out the EnrollUserTOTP method")
+// 			},
+// 			IsTokenRevokedFunc: func(jit string) (bool, error) {
+// 				panic("mock out the IsTokenRevoked method")
+// 			},
+// 			LockLoginFunc: func(key string, until time.Time) error {
+// 				panic("mock out the LockLogin method")
+// 			},
+// 			LoginFailureFunc: func(key string) (storage.LoginFailure, error) {
+// 				panic("mock out the LoginFailure method")
+// 			},
+// 			PasswordHistoryFunc: func(email string) ([]storage.PasswordHistory, error) {
+// 				panic("mock out the PasswordHistory method")
+// 			},
+// 			RegisterLoginFailureFunc: func(key string, forgetBefore time.Time) (storage.LoginFailure, error) {
+// 				panic("mock out the RegisterLoginFailure method")
+// 			},
+// 			RegisterTokenAttemptFunc: func(id uint) (int, error) {
+// 				panic("mock out the RegisterTokenAttempt method")
+// 			},
+// 			ReplaceRecoveryCodesFunc: func(email string, codes []storage.RecoveryCode) error {
+// 				panic("mock out the ReplaceRecoveryCodes method")
+// 			},
+// 			ResetLoginFailuresFunc: func(key string) error {
+// 				panic("mock out the ResetLoginFailures method")
+// 			},
+// 			RevokeTokenFunc: func(t storage.RevokedToken) error {
+// 				panic("mock out the RevokeToken method")
+// 			},
+// 			SetUserPasswordFunc: func(email string, password []byte, breached bool) error {
+// 				panic("mock out the SetUserPassword method")
+// 			},
 // 			TokensByEMailAndTokenFunc: func(email string, token string) ([]storage.Token, error) {
 // 				panic("mock out the TokensByEMailAndToken method")
 // 			},
+// 			TokensByEMailAndTypeFunc: func(email string, tokenType string) ([]storage.Token, error) {
+// 				panic("mock out the TokensByEMailAndType method")
+// 			},
+// 			UpdateTOTPLastStepFunc: func(email string, step int64) (bool, error) {
+// 				panic("mock out the UpdateTOTPLastStep method")
+// 			},
+// 			UpdateUnverifiedUserFunc: func(user storage.User) error {
+// 				panic("mock out the UpdateUnverifiedUser method")
+// 			},
 // 			UpdateUserFunc: func(user storage.User) error {
 // 				panic("mock out the UpdateUser method")
 // 			},
+// 			UpdateUserPasswordFunc: func(email string, oldHash []byte, newHash []byte) error {
+// 				panic("mock out the UpdateUserPassword method")
+// 			},
+// 			UpdateWebAuthnCredentialUsageFunc: func(id uint, signCount uint32, lastUsedAt time.Time) (bool, error) {
+// 				panic("mock out the UpdateWebAuthnCredentialUsage method")
+// 			},
 // 			UserFunc: func(email string) (storage.User, error) {
 // 				panic("mock out the User method")
 // 			},
+// 			VerifyUserEMailFunc: func(email string) error {
+// 				panic("mock out the VerifyUserEMail method")
+// 			},
+// 			WebAuthnCredentialsByEMailFunc: func(email string) ([]storage.WebAuthnCredential, error) {
+// 				panic("mock out the WebAuthnCredentialsByEMail method")
+// 			},
 // 		}
 //
 // 		// use mockedStorage in code that requires Storage
@@ -46,29 +140,148 @@ var _ Storage = &StorageMock{}
 //
 // 	}
 type StorageMock struct {
+	// AddPasswordHistoryFunc mocks the AddPasswordHistory method.
+	AddPasswordHistoryFunc func(h storage.PasswordHistory, keep int) error
+
+	// ConsumeRecoveryCodeFunc mocks the ConsumeRecoveryCode method.
+	ConsumeRecoveryCodeFunc func(email string, code []byte) error
+
+	// ConsumeTokenFunc mocks the ConsumeToken method.
+	ConsumeTokenFunc func(id uint) error
+
+	// ConsumedTokensByEMailAndTokenFunc mocks the ConsumedTokensByEMailAndToken method.
+	ConsumedTokensByEMailAndTokenFunc func(email string, token string) ([]storage.Token, error)
+
 	// CreateTokenFunc mocks the CreateToken method.
 	CreateTokenFunc func(t *storage.Token) error
 
 	// CreateUserFunc mocks the CreateUser method.
 	CreateUserFunc func(user storage.User) error
 
-	// DeleteTokenFunc mocks the DeleteToken method.
-	DeleteTokenFunc func(id uint) error
+	// CreateWebAuthnCredentialFunc mocks the CreateWebAuthnCredential method.
+	CreateWebAuthnCredentialFunc func(c *storage.WebAuthnCredential) error
+
+	// DeleteExpiredRevokedTokensFunc mocks the DeleteExpiredRevokedTokens method.
+	DeleteExpiredRevokedTokensFunc func(before time.Time) error
+
+	// DeleteExpiredTokensFunc mocks the DeleteExpiredTokens method.
+	DeleteExpiredTokensFunc func(before time.Time, legacyLifetimes map[string]time.Duration, limit int) (int64, error)
+
+	// DeleteTokensByEMailAndFamilyFunc mocks the DeleteTokensByEMailAndFamily method.
+	DeleteTokensByEMailAndFamilyFunc func(email string, family string) error
+
+	// DeleteTokensByEMailAndTypeFunc mocks the DeleteTokensByEMailAndType method.
+	DeleteTokensByEMailAndTypeFunc func(email string, tokenType string) error
+
+	// DeleteTokensByFamilyFunc mocks the DeleteTokensByFamily method.
+	DeleteTokensByFamilyFunc func(family string) error
+
+	// DeleteUnverifiedUsersFunc mocks the DeleteUnverifiedUsers method.
+	DeleteUnverifiedUsersFunc func(before time.Time, limit int) (int64, error)
 
 	// DeleteUserFunc mocks the DeleteUser method.
 	DeleteUserFunc func(email string) error
 
+	// DisableUserMFAFunc mocks the DisableUserMFA method.
+	DisableUserMFAFunc func(email string) error
+
+	// EnableUserEMailOTPFunc mocks the EnableUserEMailOTP method.
+	EnableUserEMailOTPFunc func(email string) error
+
+	// EnableUserTOTPFunc mocks the EnableUserTOTP method.
+	EnableUserTOTPFunc func(email string, secret string, step int64) (bool, error)
+
+	// EnrollUserTOTPFunc mocks the EnrollUserTOTP method.
+	EnrollUserTOTPFunc func(email string, secret string) (bool, error)
+
+	// IsTokenRevokedFunc mocks the IsTokenRevoked method.
+	IsTokenRevokedFunc func(jit string) (bool, error)
+
+	// LockLoginFunc mocks the LockLogin method.
+	LockLoginFunc func(key string, until time.Time) error
+
+	// LoginFailureFunc mocks the LoginFailure method.
+	LoginFailureFunc func(key string) (storage.LoginFailure, error)
+
+	// PasswordHistoryFunc mocks the PasswordHistory method.
+	PasswordHistoryFunc func(email string) ([]storage.PasswordHistory, error)
+
+	// RegisterLoginFailureFunc mocks the RegisterLoginFailure method.
+	RegisterLoginFailureFunc func(key string, forgetBefore time.Time) (storage.LoginFailure, error)
+
+	// RegisterTokenAttemptFunc mocks the RegisterTokenAttempt method.
+	RegisterTokenAttemptFunc func(id uint) (int, error)
+
+	// ReplaceRecoveryCodesFunc mocks the ReplaceRecoveryCodes method.
+	ReplaceRecoveryCodesFunc func(email string, codes []storage.RecoveryCode) error
+
+	// ResetLoginFailuresFunc mocks the ResetLoginFailures method.
+	ResetLoginFailuresFunc func(key string) error
+
+	// RevokeTokenFunc mocks the RevokeToken method.
+	RevokeTokenFunc func(t storage.RevokedToken) error
+
+	// SetUserPasswordFunc mocks the SetUserPassword method.
+	SetUserPasswordFunc func(email string, password []byte, breached bool) error
+
 	// TokensByEMailAndTokenFunc mocks the TokensByEMailAndToken method.
 	TokensByEMailAndTokenFunc func(email string, token string) ([]storage.Token, error)
 
+	// TokensByEMailAndTypeFunc mocks the TokensByEMailAndType method.
+	TokensByEMailAndTypeFunc func(email string, tokenType string) ([]storage.Token, error)
+
+	// UpdateTOTPLastStepFunc mocks the UpdateTOTPLastStep method.
+	UpdateTOTPLastStepFunc func(email string, step int64) (bool, error)
+
+	// UpdateUnverifiedUserFunc mocks the UpdateUnverifiedUser method.
+	UpdateUnverifiedUserFunc func(user storage.User) error
+
 	// UpdateUserFunc mocks the UpdateUser method.
 	UpdateUserFunc func(user storage.User) error
 
+	// UpdateUserPasswordFunc mocks the UpdateUserPassword method.
+	UpdateUserPasswordFunc func(email string, oldHash []byte, newHash []byte) error
+
+	// UpdateWebAuthnCredentialUsageFunc mocks the UpdateWebAuthnCredentialUsage method.
+	UpdateWebAuthnCredentialUsageFunc func(id uint, signCount uint32, lastUsedAt time.Time) (bool, error)
+
 	// UserFunc mocks the User method.
 	UserFunc func(email string) (storage.User, error)
 
+	// VerifyUserEMailFunc mocks the VerifyUserEMail method.
+	VerifyUserEMailFunc func(email string) error
+
+	// WebAuthnCredentialsByEMailFunc mocks the WebAuthnCredentialsByEMail method.
+	WebAuthnCredentialsByEMailFunc func(email string) ([]storage.WebAuthnCredential, error)
+
 	// calls tracks calls to the methods.
 	calls struct {
+		// AddPasswordHistory holds details about calls to the AddPasswordHistory method.
+		AddPasswordHistory []struct {
+			// H is the h argument value.
+			H storage.PasswordHistory
+			// Keep is the keep argument value.
+			Keep int
+		}
+		// ConsumeRecoveryCode holds details about calls to the ConsumeRecoveryCode method.
+		ConsumeRecoveryCode []struct {
+			// Email is the email argument value.
+			Email string
+			// Code is the code argument value.
+			Code []byte
+		}
+		// ConsumeToken holds details about calls to the ConsumeToken method.
+		ConsumeToken []struct {
+			// ID is the id argument value.
+			ID uint
+		}
+		// ConsumedTokensByEMailAndToken holds details about calls to the ConsumedTokensByEMailAndToken method.
+		ConsumedTokensByEMailAndToken []struct {
+			// Email is the email argument value.
+			Email string
+			// Token is the token argument value.
+			Token string
+		}
 		// CreateToken holds details about calls to the CreateToken method.
 		CreateToken []struct {
 			// T is the t argument value.
@@ -79,16 +292,142 @@ type StorageMock struct {
 			// User is the user argument value.
 			User storage.User
 		}
-		// DeleteToken holds details about calls to the DeleteToken method.
-		DeleteToken []struct {
-			// ID is the id argument value.
-			ID uint
+		// CreateWebAuthnCredential holds details about calls to the CreateWebAuthnCredential method.
+		CreateWebAuthnCredential []struct {
+			// C is the c argument value.
+			C *storage.WebAuthnCredential
+		}
+		// DeleteExpiredRevokedTokens holds details about calls to the DeleteExpiredRevokedTokens method.
+		DeleteExpiredRevokedTokens []struct {
+			// Before is the before argument value.
+			Before time.Time
+		}
+		// DeleteExpiredTokens holds details about calls to the DeleteExpiredTokens method.
+		DeleteExpiredTokens []struct {
+			// Before is the before argument value.
+			Before time.Time
+			// LegacyLifetimes is the legacyLifetimes argument value.
+			LegacyLifetimes map[string]time.Duration
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// DeleteTokensByEMailAndFamily holds details about calls to the DeleteTokensByEMailAndFamily method.
+		DeleteTokensByEMailAndFamily []struct {
+			// Email is the email argument value.
+			Email string
+			// Family is the family argument value.
+			Family string
+		}
+		// DeleteTokensByEMailAndType holds details about calls to the DeleteTokensByEMailAndType method.
+		DeleteTokensByEMailAndType []struct {
+			// Email is the email argument value.
+			Email string
+			// TokenType is the tokenType argument value.
+			TokenType string
+		}
+		// DeleteTokensByFamily holds details about calls to the DeleteTokensByFamily method.
+		DeleteTokensByFamily []struct {
+			// Family is the family argument value.
+			Family string
+		}
+		// DeleteUnverifiedUsers holds details about calls to the DeleteUnverifiedUsers method.
+		DeleteUnverifiedUsers []struct {
+			// Before is the before argument value.
+			Before time.Time
+			// Limit is the limit argument value.
+			Limit int
 		}
 		// DeleteUser holds details about calls to the DeleteUser method.
 		DeleteUser []struct {
 			// Email is the email argument value.
 			Email string
 		}
+		// DisableUserMFA holds details about calls to the DisableUserMFA method.
+		DisableUserMFA []struct {
+			// Email is the email argument value.
+			Email string
+		}
+		// EnableUserEMailOTP holds details about calls to the EnableUserEMailOTP method.
+		EnableUserEMailOTP []struct {
+			// Email is the email argument value.
+			Email string
+		}
+		// EnableUserTOTP holds details about calls to the EnableUserTOTP method.
+		EnableUserTOTP []struct {
+			// Email is the email argument value.
+			Email string
+			// Secret is the secret argument value.
+			Secret string
+			// Step is the step argument value.
+			Step int64
+		}
+		// EnrollUserTOTP holds details about calls to the EnrollUserTOTP method.
+		EnrollUserTOTP []struct {
+			// Email is the email argument value.
+			Email string
+			// Secret is the secret argument value.
+			Secret string
+		}
+		// IsTokenRevoked holds details about calls to the IsTokenRevoked method.
+		IsTokenRevoked []struct {
+			// Jit is the jit argument value.
+			Jit string
+		}
+		// LockLogin holds details about calls to the LockLogin method.
+		LockLogin []struct {
+			// Key is the key argument value.
+			Key string
+			// Until is the until argument value.
+			Until time.Time
+		}
+		// LoginFailure holds details about calls to the LoginFailure method.
+		LoginFailure []struct {
+			// Key is the key argument value.
+			Key string
+		}
+		// PasswordHistory holds details about calls to the PasswordHistory method.
+		PasswordHistory []struct {
+			// Email is the email argument value.
+			Email string
+		}
+		// RegisterLoginFailure holds details about calls to the RegisterLoginFailure method.
+		RegisterLoginFailure []struct {
+			// Key is the key argument value.
+			Key string
+			// ForgetBefore is the forgetBefore argument value.
+			ForgetBefore time.Time
+		}
+		// RegisterTokenAttempt holds details about calls to the RegisterTokenAttempt method.
+		RegisterTokenAttempt []struct {
+			// ID is the id argument value.
+			ID uint
+		}
+		// ReplaceRecoveryCodes holds details about calls to the ReplaceRecoveryCodes method.
+		ReplaceRecoveryCodes []struct {
+			// Email is the email argument value.
+			Email string
+			// Codes is the codes argument value.
+			Codes []storage.RecoveryCode
+		}
+		// ResetLoginFailures holds details about calls to the ResetLoginFailures method.
+		ResetLoginFailures []struct {
+			// Key is the key argument value.
+			Key string
+		}
+		// RevokeToken holds details about calls to the RevokeToken method.
+		RevokeToken []struct {
+			// T is the t argument value.
+			T storage.RevokedToken
+		}
+		// SetUserPassword holds details about calls to the SetUserPassword method.
+		SetUserPassword []struct {
+			// Email is the email argument value.
+			Email string
+			// Password is the password argument value.
+			Password []byte
+			// Breached is the breached argument value.
+			Breached bool
+		}
 		// TokensByEMailAndToken holds details about calls to the TokensByEMailAndToken method.
 		TokensByEMailAndToken []struct {
 			// Email is the email argument value.
@@ -96,24 +435,238 @@ type StorageMock struct {
 			// Token is the token argument value.
 			Token string
 		}
+		// TokensByEMailAndType holds details about calls to the TokensByEMailAndType method.
+		TokensByEMailAndType []struct {
+			// Email is the email argument value.
+			Email string
+			// TokenType is the tokenType argument value.
+			TokenType string
+		}
+		// UpdateTOTPLastStep holds details about calls to the UpdateTOTPLastStep method.
+		UpdateTOTPLastStep []struct {
+			// Email is the email argument value.
+			Email string
+			// Step is the step argument value.
+			Step int64
+		}
+		// UpdateUnverifiedUser holds details about calls to the UpdateUnverifiedUser method.
+		UpdateUnverifiedUser []struct {
+			// User is the user argument value.
+			User storage.User
+		}
 		// UpdateUser holds details about calls to the UpdateUser method.
 		UpdateUser []struct {
 			// User is the user argument value.
 			User storage.User
 		}
+		// UpdateUserPassword holds details about calls to the UpdateUserPassword method.
+		UpdateUserPassword []struct {
+			// Email is the email argument value.
+			Email string
+			// OldHash is the oldHash argument value.
+			OldHash []byte
+			// NewHash is the newHash argument value.
+			NewHash []byte
+		}
+		// UpdateWebAuthnCredentialUsage holds details about calls to the UpdateWebAuthnCredentialUsage method.
+		UpdateWebAuthnCredentialUsage []struct {
+			// ID is the id argument value.
+			ID uint
+			// SignCount is the signCount argument value.
+			SignCount uint32
+			// LastUsedAt is the lastUsedAt argument value.
+			LastUsedAt time.Time
+		}
 		// User holds details about calls to the User method.
 		User []struct {
 			// Email is the email argument value.
 			Email string
 		}
+		// VerifyUserEMail holds details about calls to the VerifyUserEMail method.
+		VerifyUserEMail []struct {
+			// Email is the email argument value.
+			Email string
+		}
+		// WebAuthnCredentialsByEMail holds details about calls to the WebAuthnCredentialsByEMail method.
+		WebAuthnCredentialsByEMail []struct {
+			// Email is the email argument value.
+			Email string
+		}
+	}
+	lockAddPasswordHistory            sync.RWMutex
+	lockConsumeRecoveryCode           sync.RWMutex
+	lockConsumeToken                  sync.RWMutex
+	lockConsumedTokensByEMailAndToken sync.RWMutex
+	lockCreateToken                   sync.RWMutex
+	lockCreateUser                    sync.RWMutex
+	lockCreateWebAuthnCredential      sync.RWMutex
+	lockDeleteExpiredRevokedTokens    sync.RWMutex
+	lockDeleteExpiredTokens           sync.RWMutex
+	lockDeleteTokensByEMailAndFamily  sync.RWMutex
+	lockDeleteTokensByEMailAndType    sync.RWMutex
+	lockDeleteTokensByFamily          sync.RWMutex
+	lockDeleteUnverifiedUsers         sync.RWMutex
+	lockDeleteUser                    sync.RWMutex
+	lockDisableUserMFA                sync.RWMutex
+	lockEnableUserEMailOTP            sync.RWMutex
+	lockEnableUserTOTP                sync.RWMutex
+	lockEnrollUserTOTP                sync.RWMutex
+	lockIsTokenRevoked                sync.RWMutex
+	lockLockLogin                     sync.RWMutex
+	lockLoginFailure                  sync.RWMutex
+	lockPasswordHistory               sync.RWMutex
+	lockRegisterLoginFailure          sync.RWMutex
+	lockRegisterTokenAttempt          sync.RWMutex
+	lockReplaceRecoveryCodes          sync.RWMutex
+	lockResetLoginFailures            sync.RWMutex
+	lockRevokeToken                   sync.RWMutex
+	lockSetUserPassword               sync.RWMutex
+	lockTokensByEMailAndToken         sync.RWMutex
+	lockTokensByEMailAndType          sync.RWMutex
+	lockUpdateTOTPLastStep            sync.RWMutex
+	lockUpdateUnverifiedUser          sync.RWMutex
+	lockUpdateUser                    sync.RWMutex
+	lockUpdateUserPassword            sync.RWMutex
+	lockUpdateWebAuthnCredentialUsage sync.RWMutex
+	lockUser                          sync.RWMutex
+	lockVerifyUserEMail               sync.RWMutex
+	lockWebAuthnCredentialsByEMail    sync.RWMutex
+}
+
+// AddPasswordHistory calls AddPasswordHistoryFunc.
+func (mock *StorageMock) AddPasswordHistory(h storage.PasswordHistory, keep int) error {
+	if mock.AddPasswordHistoryFunc == nil {
+		panic("StorageMock.AddPasswordHistoryFunc: method is nil but Storage.AddPasswordHistory was just called")
+	}
+	callInfo := struct {
+		H    storage.PasswordHistory
+		Keep int
+	}{
+		H:    h,
+		Keep: keep,
+	}
+	mock.lockAddPasswordHistory.Lock()
+	mock.calls.AddPasswordHistory = append(mock.calls.AddPasswordHistory, callInfo)
+	mock.lockAddPasswordHistory.Unlock()
+	return mock.AddPasswordHistoryFunc(h, keep)
+}
+
+// AddPasswordHistoryCalls gets all the calls that were made to AddPasswordHistory.
+// Check the length with:
+//     len(mockedStorage.AddPasswordHistoryCalls())
+func (mock *StorageMock) AddPasswordHistoryCalls() []struct {
+	H    storage.PasswordHistory
+	Keep int
+} {
+	var calls []struct {
+		H    storage.PasswordHistory
+		Keep int
+	}
+	mock.lockAddPasswordHistory.RLock()
+	calls = mock.calls.AddPasswordHistory
+	mock.lockAddPasswordHistory.RUnlock()
+	return calls
+}
+
+// ConsumeRecoveryCode calls ConsumeRecoveryCodeFunc.
+func (mock *StorageMock) ConsumeRecoveryCode(email string, code []byte) error {
+	if mock.ConsumeRecoveryCodeFunc == nil {
+		panic("StorageMock.ConsumeRecoveryCodeFunc: method is nil but Storage.ConsumeRecoveryCode was just called")
+	}
+	callInfo := struct {
+		Email string
+		Code  []byte
+	}{
+		Email: email,
+		Code:  code,
+	}
+	mock.lockConsumeRecoveryCode.Lock()
+	mock.calls.ConsumeRecoveryCode = append(mock.calls.ConsumeRecoveryCode, callInfo)
+	mock.lockConsumeRecoveryCode.Unlock()
+	return mock.ConsumeRecoveryCodeFunc(email, code)
+}
+
+// ConsumeRecoveryCodeCalls gets all the calls that were made to ConsumeRecoveryCode.
+// Check the length with:
+//     len(mockedStorage.ConsumeRecoveryCodeCalls())
+func (mock *StorageMock) ConsumeRecoveryCodeCalls() []struct {
+	Email string
+	Code  []byte
+} {
+	var calls []struct {
+		Email string
+		Code  []byte
+	}
+	mock.lockConsumeRecoveryCode.RLock()
+	calls = mock.calls.ConsumeRecoveryCode
+	mock.lockConsumeRecoveryCode.RUnlock()
+	return calls
+}
+
+// ConsumeToken calls ConsumeTokenFunc.
+func (mock *StorageMock) ConsumeToken(id uint) error {
+	if mock.ConsumeTokenFunc == nil {
+		panic("StorageMock.ConsumeTokenFunc: method is nil but Storage.ConsumeToken was just called")
+	}
+	callInfo := struct {
+		ID uint
+	}{
+		ID: id,
+	}
+	mock.lockConsumeToken.Lock()
+	mock.calls.ConsumeToken = append(mock.calls.ConsumeToken, callInfo)
+	mock.lockConsumeToken.Unlock()
+	return mock.ConsumeTokenFunc(id)
+}
+
+// ConsumeTokenCalls gets all the calls that were made to ConsumeToken.
+// Check the length with:
+//     len(mockedStorage.ConsumeTokenCalls())
+func (mock *StorageMock) ConsumeTokenCalls() []struct {
+	ID uint
+} {
+	var calls []struct {
+		ID uint
+	}
+	mock.lockConsumeToken.RLock()
+	calls = mock.calls.ConsumeToken
+	mock.lockConsumeToken.RUnlock()
+	return calls
+}
+
+// ConsumedTokensByEMailAndToken calls ConsumedTokensByEMailAndTokenFunc.
+func (mock *StorageMock) ConsumedTokensByEMailAndToken(email string, token string) ([]storage.Token, error) {
+	if mock.ConsumedTokensByEMailAndTokenFunc == nil {
+		panic("StorageMock.ConsumedTokensByEMailAndTokenFunc: method is nil but Storage.ConsumedTokensByEMailAndToken was just called")
+	}
+	callInfo := struct {
+		Email string
+		Token string
+	}{
+		Email: email,
+		Token: token,
+	}
+	mock.lockConsumedTokensByEMailAndToken.Lock()
+	mock.calls.ConsumedTokensByEMailAndToken = append(mock.calls.ConsumedTokensByEMailAndToken, callInfo)
+	mock.lockConsumedTokensByEMailAndToken.Unlock()
+	return mock.ConsumedTokensByEMailAndTokenFunc(email, token)
+}
+
+// ConsumedTokensByEMailAndTokenCalls gets all the calls that were made to ConsumedTokensByEMailAndToken.
+// Check the length with:
+//     len(mockedStorage.ConsumedTokensByEMailAndTokenCalls())
+func (mock *StorageMock) ConsumedTokensByEMailAndTokenCalls() []struct {
+	Email string
+	Token string
+} {
+	var calls []struct {
+		Email string
+		Token string
 	}
-	lockCreateToken           sync.RWMutex
-	lockCreateUser            sync.RWMutex
-	lockDeleteToken           sync.RWMutex
-	lockDeleteUser            sync.RWMutex
-	lockTokensByEMailAndToken sync.RWMutex
-	lockUpdateUser            sync.RWMutex
-	lockUser                  sync.RWMutex
+	mock.lockConsumedTokensByEMailAndToken.RLock()
+	calls = mock.calls.ConsumedTokensByEMailAndToken
+	mock.lockConsumedTokensByEMailAndToken.RUnlock()
+	return calls
 }
 
 // CreateToken calls CreateTokenFunc.
@@ -178,112 +731,885 @@ func (mock *StorageMock) CreateUserCalls() []struct {
 	return calls
 }
 
-// DeleteToken calls DeleteTokenFunc.
-func (mock *StorageMock) DeleteToken(id uint) error {
-	if mock.DeleteTokenFunc == nil {
-		panic("StorageMock.DeleteTokenFunc: method is nil but Storage.DeleteToken was just called")
+// CreateWebAuthnCredential calls CreateWebAuthnCredentialFunc.
+func (mock *StorageMock) CreateWebAuthnCredential(c *storage.WebAuthnCredential) error {
+	if mock.CreateWebAuthnCredentialFunc == nil {
+		panic("StorageMock.CreateWebAuthnCredentialFunc: method is nil but Storage.CreateWebAuthnCredential was just called")
 	}
 	callInfo := struct {
-		ID uint
+		C *storage.WebAuthnCredential
 	}{
-		ID: id,
+		C: c,
 	}
-	mock.lockDeleteToken.Lock()
-	mock.calls.DeleteToken = append(mock.calls.DeleteToken, callInfo)
-	mock.lockDeleteToken.Unlock()
-	return mock.DeleteTokenFunc(id)
+	mock.lockCreateWebAuthnCredential.Lock()
+	mock.calls.CreateWebAuthnCredential = append(mock.calls.CreateWebAuthnCredential, callInfo)
+	mock.lockCreateWebAuthnCredential.Unlock()
+	return mock.CreateWebAuthnCredentialFunc(c)
 }
 
-// DeleteTokenCalls gets all the calls that were made to DeleteToken.
+// CreateWebAuthnCredentialCalls gets all the calls that were made to CreateWebAuthnCredential.
 // Check the length with:
-//     len(mockedStorage.DeleteTokenCalls())
-func (mock *StorageMock) DeleteTokenCalls() []struct {
-	ID uint
+//     len(mockedStorage.CreateWebAuthnCredentialCalls())
+func (mock *StorageMock) CreateWebAuthnCredentialCalls() []struct {
+	C *storage.WebAuthnCredential
 } {
 	var calls []struct {
-		ID uint
+		C *storage.WebAuthnCredential
 	}
-	mock.lockDeleteToken.RLock()
-	calls = mock.calls.DeleteToken
-	mock.lockDeleteToken.RUnlock()
+	mock.lockCreateWebAuthnCredential.RLock()
+	calls = mock.calls.CreateWebAuthnCredential
+	mock.lockCreateWebAuthnCredential.RUnlock()
 	return calls
 }
 
-// DeleteUser calls DeleteUserFunc.
-func (mock *StorageMock) DeleteUser(email string) error {
-	if mock.DeleteUserFunc == nil {
-		panic("StorageMock.DeleteUserFunc: method is nil but Storage.DeleteUser was just called")
+// DeleteExpiredRevokedTokens calls DeleteExpiredRevokedTokensFunc.
+func (mock *StorageMock) DeleteExpiredRevokedTokens(before time.Time) error {
+	if mock.DeleteExpiredRevokedTokensFunc == nil {
+		panic("StorageMock.DeleteExpiredRevokedTokensFunc: method is nil but Storage.DeleteExpiredRevokedTokens was just called")
 	}
 	callInfo := struct {
-		Email string
+		Before time.Time
 	}{
-		Email: email,
+		Before: before,
 	}
-	mock.lockDeleteUser.Lock()
-	mock.calls.DeleteUser = append(mock.calls.DeleteUser, callInfo)
-	mock.lockDeleteUser.Unlock()
-	return mock.DeleteUserFunc(email)
+	mock.lockDeleteExpiredRevokedTokens.Lock()
+	mock.calls.DeleteExpiredRevokedTokens = append(mock.calls.DeleteExpiredRevokedTokens, callInfo)
+	mock.lockDeleteExpiredRevokedTokens.Unlock()
+	return mock.DeleteExpiredRevokedTokensFunc(before)
 }
 
-// DeleteUserCalls gets all the calls that were made to DeleteUser.
+// DeleteExpiredRevokedTokensCalls gets all the calls that were made to DeleteExpiredRevokedTokens.
 // Check the length with:
-//     len(mockedStorage.DeleteUserCalls())
-func (mock *StorageMock) DeleteUserCalls() []struct {
-	Email string
+//     len(mockedStorage.DeleteExpiredRevokedTokensCalls())
+func (mock *StorageMock) DeleteExpiredRevokedTokensCalls() []struct {
+	Before time.Time
 } {
 	var calls []struct {
-		Email string
+		Before time.Time
 	}
-	mock.lockDeleteUser.RLock()
-	calls = mock.calls.DeleteUser
-	mock.lockDeleteUser.RUnlock()
+	mock.lockDeleteExpiredRevokedTokens.RLock()
+	calls = mock.calls.DeleteExpiredRevokedTokens
+	mock.lockDeleteExpiredRevokedTokens.RUnlock()
 	return calls
 }
 
-// TokensByEMailAndToken calls TokensByEMailAndTokenFunc.
-func (mock *StorageMock) TokensByEMailAndToken(email string, token string) ([]storage.Token, error) {
-	if mock.TokensByEMailAndTokenFunc == nil {
-		panic("StorageMock.TokensByEMailAndTokenFunc: method is nil but Storage.TokensByEMailAndToken was just called")
+// DeleteExpiredTokens calls DeleteExpiredTokensFunc.
+func (mock *StorageMock) DeleteExpiredTokens(before time.Time, legacyLifetimes map[string]time.Duration, limit int) (int64, error) {
+	if mock.DeleteExpiredTokensFunc == nil {
+		panic("StorageMock.DeleteExpiredTokensFunc: method is nil but Storage.DeleteExpiredTokens was just called")
 	}
 	callInfo := struct {
-		Email string
-		Token string
+		Before          time.Time
+		LegacyLifetimes map[string]time.Duration
+		Limit           int
 	}{
-		Email: email,
-		Token: token,
+		Before:          before,
+		LegacyLifetimes: legacyLifetimes,
+		Limit:           limit,
 	}
-	mock.lockTokensByEMailAndToken.Lock()
-	mock.calls.TokensByEMailAndToken = append(mock.calls.TokensByEMailAndToken, callInfo)
-	mock.lockTokensByEMailAndToken.Unlock()
-	return mock.TokensByEMailAndTokenFunc(email, token)
+	mock.lockDeleteExpiredTokens.Lock()
+	mock.calls.DeleteExpiredTokens = append(mock.calls.DeleteExpiredTokens, callInfo)
+	mock.lockDeleteExpiredTokens.Unlock()
+	return mock.DeleteExpiredTokensFunc(before, legacyLifetimes, limit)
 }
 
-// TokensByEMailAndTokenCalls gets all the calls that were made to TokensByEMailAndToken.
+// DeleteExpiredTokensCalls gets all the calls that were made to DeleteExpiredTokens.
 // Check the length with:
-//     len(mockedStorage.TokensByEMailAndTokenCalls())
-func (mock *StorageMock) TokensByEMailAndTokenCalls() []struct {
-	Email string
-	Token string
+//     len(mockedStorage.DeleteExpiredTokensCalls())
+func (mock *StorageMock) DeleteExpiredTokensCalls() []struct {
+	Before          time.Time
+	LegacyLifetimes map[string]time.Duration
+	Limit           int
 } {
 	var calls []struct {
-		Email string
-		Token string
+		Before          time.Time
+		LegacyLifetimes map[string]time.Duration
+		Limit           int
 	}
-	mock.lockTokensByEMailAndToken.RLock()
-	calls = mock.calls.TokensByEMailAndToken
-	mock.lockTokensByEMailAndToken.RUnlock()
+	mock.lockDeleteExpiredTokens.RLock()
+	calls = mock.calls.DeleteExpiredTokens
+	mock.lockDeleteExpiredTokens.RUnlock()
 	return calls
 }
 
-// UpdateUser calls UpdateUserFunc.
-func (mock *StorageMock) UpdateUser(user storage.User) error {
-	if mock.UpdateUserFunc == nil {
-		panic("StorageMock.UpdateUserFunc: method is nil but Storage.UpdateUser was just called")
+// DeleteTokensByEMailAndFamily calls DeleteTokensByEMailAndFamilyFunc.
+func (mock *StorageMock) DeleteTokensByEMailAndFamily(email string, family string) error {
+	if mock.DeleteTokensByEMailAndFamilyFunc == nil {
+		panic("StorageMock.DeleteTokensByEMailAndFamilyFunc: method is nil but Storage.DeleteTokensByEMailAndFamily was just called")
 	}
 	callInfo := struct {
-		User storage.User
+		Email  string
+		Family string
 	}{
-		User: user,
+		Email:  email,
+		Family: family,
+	}
+	mock.lockDeleteTokensByEMailAndFamily.Lock()
+	mock.calls.DeleteTokensByEMailAndFamily = append(mock.calls.DeleteTokensByEMailAndFamily, callInfo)
+	mock.lockDeleteTokensByEMailAndFamily.Unlock()
+	return mock.DeleteTokensByEMailAndFamilyFunc(email, family)
+}
+
+// DeleteTokensByEMailAndFamilyCalls gets all the calls that were made to DeleteTokensByEMailAndFamily.
+// Check the length with:
+//     len(mockedStorage.DeleteTokensByEMailAndFamilyCalls())
+func (mock *StorageMock) DeleteTokensByEMailAndFamilyCalls() []struct {
+	Email  string
+	Family string
+} {
+	var calls []struct {
+		Email  string
+		Family string
+	}
+	mock.lockDeleteTokensByEMailAndFamily.RLock()
+	calls = mock.calls.DeleteTokensByEMailAndFamily
+	mock.lockDeleteTokensByEMailAndFamily.RUnlock()
+	return calls
+}
+
+// DeleteTokensByEMailAndType calls DeleteTokensByEMailAndTypeFunc.
+func (mock *StorageMock) DeleteTokensByEMailAndType(email string, tokenType string) error {
+	if mock.DeleteTokensByEMailAndTypeFunc == nil {
+		panic("StorageMock.DeleteTokensByEMailAndTypeFunc: method is nil but Storage.DeleteTokensByEMailAndType was just called")
+	}
+	callInfo := struct {
+		Email     string
+		TokenType string
+	}{
+		Email:     email,
+		TokenType: tokenType,
+	}
+	mock.lockDeleteTokensByEMailAndType.Lock()
+	mock.calls.DeleteTokensByEMailAndType = append(mock.calls.DeleteTokensByEMailAndType, callInfo)
+	mock.lockDeleteTokensByEMailAndType.Unlock()
+	return mock.DeleteTokensByEMailAndTypeFunc(email, tokenType)
+}
+
+// DeleteTokensByEMailAndTypeCalls gets all the calls that were made to DeleteTokensByEMailAndType.
+// Check the length with:
+//     len(mockedStorage.DeleteTokensByEMailAndTypeCalls())
+func (mock *StorageMock) DeleteTokensByEMailAndTypeCalls() []struct {
+	Email     string
+	TokenType string
+} {
+	var calls []struct {
+		Email     string
+		TokenType string
+	}
+	mock.lockDeleteTokensByEMailAndType.RLock()
+	calls = mock.calls.DeleteTokensByEMailAndType
+	mock.lockDeleteTokensByEMailAndType.RUnlock()
+	return calls
+}
+
+// DeleteTokensByFamily calls DeleteTokensByFamilyFunc.
+func (mock *StorageMock) DeleteTokensByFamily(family string) error {
+	if mock.DeleteTokensByFamilyFunc == nil {
+		panic("StorageMock.DeleteTokensByFamilyFunc: method is nil but Storage.DeleteTokensByFamily was just called")
+	}
+	callInfo := struct {
+		Family string
+	}{
+		Family: family,
+	}
+	mock.lockDeleteTokensByFamily.Lock()
+	mock.calls.DeleteTokensByFamily = append(mock.calls.DeleteTokensByFamily, callInfo)
+	mock.lockDeleteTokensByFamily.Unlock()
+	return mock.DeleteTokensByFamilyFunc(family)
+}
+
+// DeleteTokensByFamilyCalls gets all the calls that were made to DeleteTokensByFamily.
+// Check the length with:
+//     len(mockedStorage.DeleteTokensByFamilyCalls())
+func (mock *StorageMock) DeleteTokensByFamilyCalls() []struct {
+	Family string
+} {
+	var calls []struct {
+		Family string
+	}
+	mock.lockDeleteTokensByFamily.RLock()
+	calls = mock.calls.DeleteTokensByFamily
+	mock.lockDeleteTokensByFamily.RUnlock()
+	return calls
+}
+
+// DeleteUnverifiedUsers calls DeleteUnverifiedUsersFunc.
+func (mock *StorageMock) DeleteUnverifiedUsers(before time.Time, limit int) (int64, error) {
+	if mock.DeleteUnverifiedUsersFunc == nil {
+		panic("StorageMock.DeleteUnverifiedUsersFunc: method is nil but Storage.DeleteUnverifiedUsers was just called")
+	}
+	callInfo := struct {
+		Before time.Time
+		Limit  int
+	}{
+		Before: before,
+		Limit:  limit,
+	}
+	mock.lockDeleteUnverifiedUsers.Lock()
+	mock.calls.DeleteUnverifiedUsers = append(mock.calls.DeleteUnverifiedUsers, callInfo)
+	mock.lockDeleteUnverifiedUsers.Unlock()
+	return mock.DeleteUnverifiedUsersFunc(before, limit)
+}
+
+// DeleteUnverifiedUsersCalls gets all the calls that were made to DeleteUnverifiedUsers.
+// Check the length with:
+//     len(mockedStorage.DeleteUnverifiedUsersCalls())
+func (mock *StorageMock) DeleteUnverifiedUsersCalls() []struct {
+	Before time.Time
+	Limit  int
+} {
+	var calls []struct {
+		Before time.Time
+		Limit  int
+	}
+	mock.lockDeleteUnverifiedUsers.RLock()
+	calls = mock.calls.DeleteUnverifiedUsers
+	mock.lockDeleteUnverifiedUsers.RUnlock()
+	return calls
+}
+
+// DeleteUser calls DeleteUserFunc.
+func (mock *StorageMock) DeleteUser(email string) error {
+	if mock.DeleteUserFunc == nil {
+		panic("StorageMock.DeleteUserFunc: method is nil but Storage.DeleteUser was just called")
+	}
+	callInfo := struct {
+		Email string
+	}{
+		Email: email,
+	}
+	mock.lockDeleteUser.Lock()
+	mock.calls.DeleteUser = append(mock.calls.DeleteUser, callInfo)
+	mock.lockDeleteUser.Unlock()
+	return mock.DeleteUserFunc(email)
+}
+
+// DeleteUserCalls gets all the calls that were made to DeleteUser.
+// Check the length with:
+//     len(mockedStorage.DeleteUserCalls())
+func (mock *StorageMock) DeleteUserCalls() []struct {
+	Email string
+} {
+	var calls []struct {
+		Email string
+	}
+	mock.lockDeleteUser.RLock()
+	calls = mock.calls.DeleteUser
+	mock.lockDeleteUser.RUnlock()
+	return calls
+}
+
+// DisableUserMFA calls DisableUserMFAFunc.
+func (mock *StorageMock) DisableUserMFA(email string) error {
+	if mock.DisableUserMFAFunc == nil {
+		panic("StorageMock.DisableUserMFAFunc: method is nil but Storage.DisableUserMFA was just called")
+	}
+	callInfo := struct {
+		Email string
+	}{
+		Email: email,
+	}
+	mock.lockDisableUserMFA.Lock()
+	mock.calls.DisableUserMFA = append(mock.calls.DisableUserMFA, callInfo)
+	mock.lockDisableUserMFA.Unlock()
+	return mock.DisableUserMFAFunc(email)
+}
+
+// DisableUserMFACalls gets all the calls that were made to DisableUserMFA.
+// Check the length with:
+//     len(mockedStorage.DisableUserMFACalls())
+func (mock *StorageMock) DisableUserMFACalls() []struct {
+	Email string
+} {
+	var calls []struct {
+		Email string
+	}
+	mock.lockDisableUserMFA.RLock()
+	calls = mock.calls.DisableUserMFA
+	mock.lockDisableUserMFA.RUnlock()
+	return calls
+}
+
+// EnableUserEMailOTP calls EnableUserEMailOTPFunc.
+func (mock *StorageMock) EnableUserEMailOTP(email string) error {
+	if mock.EnableUserEMailOTPFunc == nil {
+		panic("StorageMock.EnableUserEMailOTPFunc: method is nil but Storage.EnableUserEMailOTP was just called")
+	}
+	callInfo := struct {
+		Email string
+	}{
+		Email: email,
+	}
+	mock.lockEnableUserEMailOTP.Lock()
+	mock.calls.EnableUserEMailOTP = append(mock.calls.EnableUserEMailOTP, callInfo)
+	mock.lockEnableUserEMailOTP.Unlock()
+	return mock.EnableUserEMailOTPFunc(email)
+}
+
+// EnableUserEMailOTPCalls gets all the calls that were made to EnableUserEMailOTP.
+// Check the length with:
+//     len(mockedStorage.EnableUserEMailOTPCalls())
+func (mock *StorageMock) EnableUserEMailOTPCalls() []struct {
+	Email string
+} {
+	var calls []struct {
+		Email string
+	}
+	mock.lockEnableUserEMailOTP.RLock()
+	calls = mock.calls.EnableUserEMailOTP
+	mock.lockEnableUserEMailOTP.RUnlock()
+	return calls
+}
+
+// EnableUserTOTP calls EnableUserTOTPFunc.
+func (mock *StorageMock) EnableUserTOTP(email string, secret string, step int64) (bool, error) {
+	if mock.EnableUserTOTPFunc == nil {
+		panic("StorageMock.EnableUserTOTPFunc: method is nil but Storage.EnableUserTOTP was just called")
+	}
+	callInfo := struct {
+		Email  string
+		Secret string
+		Step   int64
+	}{
+		Email:  email,
+		Secret: secret,
+		Step:   step,
+	}
+	mock.lockEnableUserTOTP.Lock()
+	mock.calls.EnableUserTOTP = append(mock.calls.EnableUserTOTP, callInfo)
+	mock.lockEnableUserTOTP.Unlock()
+	return mock.EnableUserTOTPFunc(email, secret, step)
+}
+
+// EnableUserTOTPCalls gets all the calls that were made to EnableUserTOTP.
+// Check the length with:
+//     len(mockedStorage.EnableUserTOTPCalls())
+func (mock *StorageMock) EnableUserTOTPCalls() []struct {
+	Email  string
+	Secret string
+	Step   int64
+} {
+	var calls []struct {
+		Email  string
+		Secret string
+		Step   int64
+	}
+	mock.lockEnableUserTOTP.RLock()
+	calls = mock.calls.EnableUserTOTP
+	mock.lockEnableUserTOTP.RUnlock()
+	return calls
+}
+
+// EnrollUserTOTP calls EnrollUserTOTPFunc.
+func (mock *StorageMock) EnrollUserTOTP(email string, secret string) (bool, error) {
+	if mock.EnrollUserTOTPFunc == nil {
+		panic("StorageMock.EnrollUserTOTPFunc: method is nil but Storage.EnrollUserTOTP was just called")
+	}
+	callInfo := struct {
+		Email  string
+		Secret string
+	}{
+		Email:  email,
+		Secret: secret,
+	}
+	mock.lockEnrollUserTOTP.Lock()
+	mock.calls.EnrollUserTOTP = append(mock.calls.EnrollUserTOTP, callInfo)
+	mock.lockEnrollUserTOTP.Unlock()
+	return mock.EnrollUserTOTPFunc(email, secret)
+}
+
+// EnrollUserTOTPCalls gets all the calls that were made to EnrollUserTOTP.
+// Check the length with:
+//     len(mockedStorage.EnrollUserTOTPCalls())
+func (mock *StorageMock) EnrollUserTOTPCalls() []struct {
+	Email  string
+	Secret string
+} {
+	var calls []struct {
+		Email  string
+		Secret string
+	}
+	mock.lockEnrollUserTOTP.RLock()
+	calls = mock.calls.EnrollUserTOTP
+	mock.lockEnrollUserTOTP.RUnlock()
+	return calls
+}
+
+// IsTokenRevoked calls IsTokenRevokedFunc.
+func (mock *StorageMock) IsTokenRevoked(jit string) (bool, error) {
+	if mock.IsTokenRevokedFunc == nil {
+		panic("StorageMock.IsTokenRevokedFunc: method is nil but Storage.IsTokenRevoked was just called")
+	}
+	callInfo := struct {
+		Jit string
+	}{
+		Jit: jit,
+	}
+	mock.lockIsTokenRevoked.Lock()
+	mock.calls.IsTokenRevoked = append(mock.calls.IsTokenRevoked, callInfo)
+	mock.lockIsTokenRevoked.Unlock()
+	return mock.IsTokenRevokedFunc(jit)
+}
+
+// IsTokenRevokedCalls gets all the calls that were made to IsTokenRevoked.
+// Check the length with:
+//     len(mockedStorage.IsTokenRevokedCalls())
+func (mock *StorageMock) IsTokenRevokedCalls() []struct {
+	Jit string
+} {
+	var calls []struct {
+		Jit string
+	}
+	mock.lockIsTokenRevoked.RLock()
+	calls = mock.calls.IsTokenRevoked
+	mock.lockIsTokenRevoked.RUnlock()
+	return calls
+}
+
+// LockLogin calls LockLoginFunc.
+func (mock *StorageMock) LockLogin(key string, until time.Time) error {
+	if mock.LockLoginFunc == nil {
+		panic("StorageMock.LockLoginFunc: method is nil but Storage.LockLogin was just called")
+	}
+	callInfo := struct {
+		Key   string
+		Until time.Time
+	}{
+		Key:   key,
+		Until: until,
+	}
+	mock.lockLockLogin.Lock()
+	mock.calls.LockLogin = append(mock.calls.LockLogin, callInfo)
+	mock.lockLockLogin.Unlock()
+	return mock.LockLoginFunc(key, until)
+}
+
+// LockLoginCalls gets all the calls that were made to LockLogin.
+// Check the length with:
+//     len(mockedStorage.LockLoginCalls())
+func (mock *StorageMock) LockLoginCalls() []struct {
+	Key   string
+	Until time.Time
+} {
+	var calls []struct {
+		Key   string
+		Until time.Time
+	}
+	mock.lockLockLogin.RLock()
+	calls = mock.calls.LockLogin
+	mock.lockLockLogin.RUnlock()
+	return calls
+}
+
+// LoginFailure calls LoginFailureFunc.
+func (mock *StorageMock) LoginFailure(key string) (storage.LoginFailure, error) {
+	if mock.LoginFailureFunc == nil {
+		panic("StorageMock.LoginFailureFunc: method is nil but Storage.LoginFailure was just called")
+	}
+	callInfo := struct {
+		Key string
+	}{
+		Key: key,
+	}
+	mock.lockLoginFailure.Lock()
+	mock.calls.LoginFailure = append(mock.calls.LoginFailure, callInfo)
+	mock.lockLoginFailure.Unlock()
+	return mock.LoginFailureFunc(key)
+}
+
+// LoginFailureCalls gets all the calls that were made to LoginFailure.
+// Check the length with:
+//     len(mockedStorage.LoginFailureCalls())
+func (mock *StorageMock) LoginFailureCalls() []struct {
+	Key string
+} {
+	var calls []struct {
+		Key string
+	}
+	mock.lockLoginFailure.RLock()
+	calls = mock.calls.LoginFailure
+	mock.lockLoginFailure.RUnlock()
+	return calls
+}
+
+// PasswordHistory calls PasswordHistoryFunc.
+func (mock *StorageMock) PasswordHistory(email string) ([]storage.PasswordHistory, error) {
+	if mock.PasswordHistoryFunc == nil {
+		panic("StorageMock.PasswordHistoryFunc: method is nil but Storage.PasswordHistory was just called")
+	}
+	callInfo := struct {
+		Email string
+	}{
+		Email: email,
+	}
+	mock.lockPasswordHistory.Lock()
+	mock.calls.PasswordHistory = append(mock.calls.PasswordHistory, callInfo)
+	mock.lockPasswordHistory.Unlock()
+	return mock.PasswordHistoryFunc(email)
+}
+
+// PasswordHistoryCalls gets all the calls that were made to PasswordHistory.
+// Check the length with:
+//     len(mockedStorage.PasswordHistoryCalls())
+func (mock *StorageMock) PasswordHistoryCalls() []struct {
+	Email string
+} {
+	var calls []struct {
+		Email string
+	}
+	mock.lockPasswordHistory.RLock()
+	calls = mock.calls.PasswordHistory
+	mock.lockPasswordHistory.RUnlock()
+	return calls
+}
+
+// RegisterLoginFailure calls RegisterLoginFailureFunc.
+func (mock *StorageMock) RegisterLoginFailure(key string, forgetBefore time.Time) (storage.LoginFailure, error) {
+	if mock.RegisterLoginFailureFunc == nil {
+		panic("StorageMock.RegisterLoginFailureFunc: method is nil but Storage.RegisterLoginFailure was just called")
+	}
+	callInfo := struct {
+		Key          string
+		ForgetBefore time.Time
+	}{
+		Key:          key,
+		ForgetBefore: forgetBefore,
+	}
+	mock.lockRegisterLoginFailure.Lock()
+	mock.calls.RegisterLoginFailure = append(mock.calls.RegisterLoginFailure, callInfo)
+	mock.lockRegisterLoginFailure.Unlock()
+	return mock.RegisterLoginFailureFunc(key, forgetBefore)
+}
+
+// RegisterLoginFailureCalls gets all the calls that were made to RegisterLoginFailure.
+// Check the length with:
+//     len(mockedStorage.RegisterLoginFailureCalls())
+func (mock *StorageMock) RegisterLoginFailureCalls() []struct {
+	Key          string
+	ForgetBefore time.Time
+} {
+	var calls []struct {
+		Key          string
+		ForgetBefore time.Time
+	}
+	mock.lockRegisterLoginFailure.RLock()
+	calls = mock.calls.RegisterLoginFailure
+	mock.lockRegisterLoginFailure.RUnlock()
+	return calls
+}
+
+// RegisterTokenAttempt calls RegisterTokenAttemptFunc.
+func (mock *StorageMock) RegisterTokenAttempt(id uint) (int, error) {
+	if mock.RegisterTokenAttemptFunc == nil {
+		panic("StorageMock.RegisterTokenAttemptFunc: method is nil but Storage.RegisterTokenAttempt was just called")
+	}
+	callInfo := struct {
+		ID uint
+	}{
+		ID: id,
+	}
+	mock.lockRegisterTokenAttempt.Lock()
+	mock.calls.RegisterTokenAttempt = append(mock.calls.RegisterTokenAttempt, callInfo)
+	mock.lockRegisterTokenAttempt.Unlock()
+	return mock.RegisterTokenAttemptFunc(id)
+}
+
+// RegisterTokenAttemptCalls gets all the calls that were made to RegisterTokenAttempt.
+// Check the length with:
+//     len(mockedStorage.RegisterTokenAttemptCalls())
+func (mock *StorageMock) RegisterTokenAttemptCalls() []struct {
+	ID uint
+} {
+	var calls []struct {
+		ID uint
+	}
+	mock.lockRegisterTokenAttempt.RLock()
+	calls = mock.calls.RegisterTokenAttempt
+	mock.lockRegisterTokenAttempt.RUnlock()
+	return calls
+}
+
+// ReplaceRecoveryCodes calls ReplaceRecoveryCodesFunc.
+func (mock *StorageMock) ReplaceRecoveryCodes(email string, codes []storage.RecoveryCode) error {
+	if mock.ReplaceRecoveryCodesFunc == nil {
+		panic("StorageMock.ReplaceRecoveryCodesFunc: method is nil but Storage.ReplaceRecoveryCodes was just called")
+	}
+	callInfo := struct {
+		Email string
+		Codes []storage.RecoveryCode
+	}{
+		Email: email,
+		Codes: codes,
+	}
+	mock.lockReplaceRecoveryCodes.Lock()
+	mock.calls.ReplaceRecoveryCodes = append(mock.calls.ReplaceRecoveryCodes, callInfo)
+	mock.lockReplaceRecoveryCodes.Unlock()
+	return mock.ReplaceRecoveryCodesFunc(email, codes)
+}
+
+// ReplaceRecoveryCodesCalls gets all the calls that were made to ReplaceRecoveryCodes.
+// Check the length with:
+//     len(mockedStorage.ReplaceRecoveryCodesCalls())
+func (mock *StorageMock) ReplaceRecoveryCodesCalls() []struct {
+	Email string
+	Codes []storage.RecoveryCode
+} {
+	var calls []struct {
+		Email string
+		Codes []storage.RecoveryCode
+	}
+	mock.lockReplaceRecoveryCodes.RLock()
+	calls = mock.calls.ReplaceRecoveryCodes
+	mock.lockReplaceRecoveryCodes.RUnlock()
+	return calls
+}
+
+// ResetLoginFailures calls ResetLoginFailuresFunc.
+func (mock *StorageMock) ResetLoginFailures(key string) error {
+	if mock.ResetLoginFailuresFunc == nil {
+		panic("StorageMock.ResetLoginFailuresFunc: method is nil but Storage.ResetLoginFailures was just called")
+	}
+	callInfo := struct {
+		Key string
+	}{
+		Key: key,
+	}
+	mock.lockResetLoginFailures.Lock()
+	mock.calls.ResetLoginFailures = append(mock.calls.ResetLoginFailures, callInfo)
+	mock.lockResetLoginFailures.Unlock()
+	return mock.ResetLoginFailuresFunc(key)
+}
+
+// ResetLoginFailuresCalls gets all the calls that were made to ResetLoginFailures.
+// Check the length with:
+//     len(mockedStorage.ResetLoginFailuresCalls())
+func (mock *StorageMock) ResetLoginFailuresCalls() []struct {
+	Key string
+} {
+	var calls []struct {
+		Key string
+	}
+	mock.lockResetLoginFailures.RLock()
+	calls = mock.calls.ResetLoginFailures
+	mock.lockResetLoginFailures.RUnlock()
+	return calls
+}
+
+// RevokeToken calls RevokeTokenFunc.
+func (mock *StorageMock) RevokeToken(t storage.RevokedToken) error {
+	if mock.RevokeTokenFunc == nil {
+		panic("StorageMock.RevokeTokenFunc: method is nil but Storage.RevokeToken was just called")
+	}
+	callInfo := struct {
+		T storage.RevokedToken
+	}{
+		T: t,
+	}
+	mock.lockRevokeToken.Lock()
+	mock.calls.RevokeToken = append(mock.calls.RevokeToken, callInfo)
+	mock.lockRevokeToken.Unlock()
+	return mock.RevokeTokenFunc(t)
+}
+
+// RevokeTokenCalls gets all the calls that were made to RevokeToken.
+// Check the length with:
+//     len(mockedStorage.RevokeTokenCalls())
+func (mock *StorageMock) RevokeTokenCalls() []struct {
+	T storage.RevokedToken
+} {
+	var calls []struct {
+		T storage.RevokedToken
+	}
+	mock.lockRevokeToken.RLock()
+	calls = mock.calls.RevokeToken
+	mock.lockRevokeToken.RUnlock()
+	return calls
+}
+
+// SetUserPassword calls SetUserPasswordFunc.
+func (mock *StorageMock) SetUserPassword(email string, password []byte, breached bool) error {
+	if mock.SetUserPasswordFunc == nil {
+		panic("StorageMock.SetUserPasswordFunc: method is nil but Storage.SetUserPassword was just called")
+	}
+	callInfo := struct {
+		Email    string
+		Password []byte
+		Breached bool
+	}{
+		Email:    email,
+		Password: password,
+		Breached: breached,
+	}
+	mock.lockSetUserPassword.Lock()
+	mock.calls.SetUserPassword = append(mock.calls.SetUserPassword, callInfo)
+	mock.lockSetUserPassword.Unlock()
+	return mock.SetUserPasswordFunc(email, password, breached)
+}
+
+// SetUserPasswordCalls gets all the calls that were made to SetUserPassword.
+// Check the length with:
+//     len(mockedStorage.SetUserPasswordCalls())
+func (mock *StorageMock) SetUserPasswordCalls() []struct {
+	Email    string
+	Password []byte
+	Breached bool
+} {
+	var calls []struct {
+		Email    string
+		Password []byte
+		Breached bool
+	}
+	mock.lockSetUserPassword.RLock()
+	calls = mock.calls.SetUserPassword
+	mock.lockSetUserPassword.RUnlock()
+	return calls
+}
+
+// TokensByEMailAndToken calls TokensByEMailAndTokenFunc.
+func (mock *StorageMock) TokensByEMailAndToken(email string, token string) ([]storage.Token, error) {
+	if mock.TokensByEMailAndTokenFunc == nil {
+		panic("StorageMock.TokensByEMailAndTokenFunc: method is nil but Storage.TokensByEMailAndToken was just called")
+	}
+	callInfo := struct {
+		Email string
+		Token string
+	}{
+		Email: email,
+		Token: token,
+	}
+	mock.lockTokensByEMailAndToken.Lock()
+	mock.calls.TokensByEMailAndToken = append(mock.calls.TokensByEMailAndToken, callInfo)
+	mock.lockTokensByEMailAndToken.Unlock()
+	return mock.TokensByEMailAndTokenFunc(email, token)
+}
+
+// TokensByEMailAndTokenCalls gets all the calls that were made to TokensByEMailAndToken.
+// Check the length with:
+//     len(mockedStorage.TokensByEMailAndTokenCalls())
+func (mock *StorageMock) TokensByEMailAndTokenCalls() []struct {
+	Email string
+	Token string
+} {
+	var calls []struct {
+		Email string
+		Token string
+	}
+	mock.lockTokensByEMailAndToken.RLock()
+	calls = mock.calls.TokensByEMailAndToken
+	mock.lockTokensByEMailAndToken.RUnlock()
+	return calls
+}
+
+// TokensByEMailAndType calls TokensByEMailAndTypeFunc.
+func (mock *StorageMock) TokensByEMailAndType(email string, tokenType string) ([]storage.Token, error) {
+	if mock.TokensByEMailAndTypeFunc == nil {
+		panic("StorageMock.TokensByEMailAndTypeFunc: method is nil but Storage.TokensByEMailAndType was just called")
+	}
+	callInfo := struct {
+		Email     string
+		TokenType string
+	}{
+		Email:     email,
+		TokenType: tokenType,
+	}
+	mock.lockTokensByEMailAndType.Lock()
+	mock.calls.TokensByEMailAndType = append(mock.calls.TokensByEMailAndType, callInfo)
+	mock.lockTokensByEMailAndType.Unlock()
+	return mock.TokensByEMailAndTypeFunc(email, tokenType)
+}
+
+// TokensByEMailAndTypeCalls gets all the calls that were made to TokensByEMailAndType.
+// Check the length with:
+//     len(mockedStorage.TokensByEMailAndTypeCalls())
+func (mock *StorageMock) TokensByEMailAndTypeCalls() []struct {
+	Email     string
+	TokenType string
+} {
+	var calls []struct {
+		Email     string
+		TokenType string
+	}
+	mock.lockTokensByEMailAndType.RLock()
+	calls = mock.calls.TokensByEMailAndType
+	mock.lockTokensByEMailAndType.RUnlock()
+	return calls
+}
+
+// UpdateTOTPLastStep calls UpdateTOTPLastStepFunc.
+func (mock *StorageMock) UpdateTOTPLastStep(email string, step int64) (bool, error) {
+	if mock.UpdateTOTPLastStepFunc == nil {
+		panic("StorageMock.UpdateTOTPLastStepFunc: method is nil but Storage.UpdateTOTPLastStep was just called")
+	}
+	callInfo := struct {
+		Email string
+		Step  int64
+	}{
+		Email: email,
+		Step:  step,
+	}
+	mock.lockUpdateTOTPLastStep.Lock()
+	mock.calls.UpdateTOTPLastStep = append(mock.calls.UpdateTOTPLastStep, callInfo)
+	mock.lockUpdateTOTPLastStep.Unlock()
+	return mock.UpdateTOTPLastStepFunc(email, step)
+}
+
+// UpdateTOTPLastStepCalls gets all the calls that were made to UpdateTOTPLastStep.
+// Check the length with:
+//     len(mockedStorage.UpdateTOTPLastStepCalls())
+func (mock *StorageMock) UpdateTOTPLastStepCalls() []struct {
+	Email string
+	Step  int64
+} {
+	var calls []struct {
+		Email string
+		Step  int64
+	}
+	mock.lockUpdateTOTPLastStep.RLock()
+	calls = mock.calls.UpdateTOTPLastStep
+	mock.lockUpdateTOTPLastStep.RUnlock()
+	return calls
+}
+
+// UpdateUnverifiedUser calls UpdateUnverifiedUserFunc.
+func (mock *StorageMock) UpdateUnverifiedUser(user storage.User) error {
+	if mock.UpdateUnverifiedUserFunc == nil {
+		panic("StorageMock.UpdateUnverifiedUserFunc: method is nil but Storage.UpdateUnverifiedUser was just called")
+	}
+	callInfo := struct {
+		User storage.User
+	}{
+		User: user,
+	}
+	mock.lockUpdateUnverifiedUser.Lock()
+	mock.calls.UpdateUnverifiedUser = append(mock.calls.UpdateUnverifiedUser, callInfo)
+	mock.lockUpdateUnverifiedUser.Unlock()
+	return mock.UpdateUnverifiedUserFunc(user)
+}
+
+// UpdateUnverifiedUserCalls gets all the calls that were made to UpdateUnverifiedUser.
+// Check the length with:
+//     len(mockedStorage.UpdateUnverifiedUserCalls())
+func (mock *StorageMock) UpdateUnverifiedUserCalls() []struct {
+	User storage.User
+} {
+	var calls []struct {
+		User storage.User
+	}
+	mock.lockUpdateUnverifiedUser.RLock()
+	calls = mock.calls.UpdateUnverifiedUser
+	mock.lockUpdateUnverifiedUser.RUnlock()
+	return calls
+}
+
+// UpdateUser calls UpdateUserFunc.
+func (mock *StorageMock) UpdateUser(user storage.User) error {
+	if mock.UpdateUserFunc == nil {
+		panic("StorageMock.UpdateUserFunc: method is nil but Storage.UpdateUser was just called")
+	}
+	callInfo := struct {
+		User storage.User
+	}{
+		User: user,
 	}
 	mock.lockUpdateUser.Lock()
 	mock.calls.UpdateUser = append(mock.calls.UpdateUser, callInfo)
@@ -306,6 +1632,84 @@ func (mock *StorageMock) UpdateUserCalls() []struct {
 	return calls
 }
 
+// UpdateUserPassword calls UpdateUserPasswordFunc.
+func (mock *StorageMock) UpdateUserPassword(email string, oldHash []byte, newHash []byte) error {
+	if mock.UpdateUserPasswordFunc == nil {
+		panic("StorageMock.UpdateUserPasswordFunc: method is nil but Storage.UpdateUserPassword was just called")
+	}
+	callInfo := struct {
+		Email   string
+		OldHash []byte
+		NewHash []byte
+	}{
+		Email:   email,
+		OldHash: oldHash,
+		NewHash: newHash,
+	}
+	mock.lockUpdateUserPassword.Lock()
+	mock.calls.UpdateUserPassword = append(mock.calls.UpdateUserPassword, callInfo)
+	mock.lockUpdateUserPassword.Unlock()
+	return mock.UpdateUserPasswordFunc(email, oldHash, newHash)
+}
+
+// UpdateUserPasswordCalls gets all the calls that were made to UpdateUserPassword.
+// Check the length with:
+//     len(mockedStorage.UpdateUserPasswordCalls())
+func (mock *StorageMock) UpdateUserPasswordCalls() []struct {
+	Email   string
+	OldHash []byte
+	NewHash []byte
+} {
+	var calls []struct {
+		Email   string
+		OldHash []byte
+		NewHash []byte
+	}
+	mock.lockUpdateUserPassword.RLock()
+	calls = mock.calls.UpdateUserPassword
+	mock.lockUpdateUserPassword.RUnlock()
+	return calls
+}
+
+// UpdateWebAuthnCredentialUsage calls UpdateWebAuthnCredentialUsageFunc.
+func (mock *StorageMock) UpdateWebAuthnCredentialUsage(id uint, signCount uint32, lastUsedAt time.Time) (bool, error) {
+	if mock.UpdateWebAuthnCredentialUsageFunc == nil {
+		panic("StorageMock.UpdateWebAuthnCredentialUsageFunc: method is nil but Storage.UpdateWebAuthnCredentialUsage was just called")
+	}
+	callInfo := struct {
+		ID         uint
+		SignCount  uint32
+		LastUsedAt time.Time
+	}{
+		ID:         id,
+		SignCount:  signCount,
+		LastUsedAt: lastUsedAt,
+	}
+	mock.lockUpdateWebAuthnCredentialUsage.Lock()
+	mock.calls.UpdateWebAuthnCredentialUsage = append(mock.calls.UpdateWebAuthnCredentialUsage, callInfo)
+	mock.lockUpdateWebAuthnCredentialUsage.Unlock()
+	return mock.UpdateWebAuthnCredentialUsageFunc(id, signCount, lastUsedAt)
+}
+
+// UpdateWebAuthnCredentialUsageCalls gets all the calls that were made to UpdateWebAuthnCredentialUsage.
+// Check the length with:
+//     len(mockedStorage.UpdateWebAuthnCredentialUsageCalls())
+func (mock *StorageMock) UpdateWebAuthnCredentialUsageCalls() []struct {
+	ID         uint
+	SignCount  uint32
+	LastUsedAt time.Time
+} {
+	var calls []struct {
+		ID         uint
+		SignCount  uint32
+		LastUsedAt time.Time
+	}
+	mock.lockUpdateWebAuthnCredentialUsage.RLock()
+	calls = mock.calls.UpdateWebAuthnCredentialUsage
+	mock.lockUpdateWebAuthnCredentialUsage.RUnlock()
+	return calls
+}
+
 // User calls UserFunc.
 func (mock *StorageMock) User(email string) (storage.User, error) {
 	if mock.UserFunc == nil {
@@ -336,3 +1740,65 @@ func (mock *StorageMock) UserCalls() []struct {
 	mock.lockUser.RUnlock()
 	return calls
 }
+
+// VerifyUserEMail calls VerifyUserEMailFunc.
+func (mock *StorageMock) VerifyUserEMail(email string) error {
+	if mock.VerifyUserEMailFunc == nil {
+		panic("StorageMock.VerifyUserEMailFunc: method is nil but Storage.VerifyUserEMail was just called")
+	}
+	callInfo := struct {
+		Email string
+	}{
+		Email: email,
+	}
+	mock.lockVerifyUserEMail.Lock()
+	mock.calls.VerifyUserEMail = append(mock.calls.VerifyUserEMail, callInfo)
+	mock.lockVerifyUserEMail.Unlock()
+	return mock.VerifyUserEMailFunc(email)
+}
+
+// VerifyUserEMailCalls gets all the calls that were made to VerifyUserEMail.
+// Check the length with:
+//     len(mockedStorage.VerifyUserEMailCalls())
+func (mock *StorageMock) VerifyUserEMailCalls() []struct {
+	Email string
+} {
+	var calls []struct {
+		Email string
+	}
+	mock.lockVerifyUserEMail.RLock()
+	calls = mock.calls.VerifyUserEMail
+	mock.lockVerifyUserEMail.RUnlock()
+	return calls
+}
+
+// WebAuthnCredentialsByEMail calls WebAuthnCredentialsByEMailFunc.
+func (mock *StorageMock) WebAuthnCredentialsByEMail(email string) ([]storage.WebAuthnCredential, error) {
+	if mock.WebAuthnCredentialsByEMailFunc == nil {
+		panic("StorageMock.WebAuthnCredentialsByEMailFunc: method is nil but Storage.WebAuthnCredentialsByEMail was just called")
+	}
+	callInfo := struct {
+		Email string
+	}{
+		Email: email,
+	}
+	mock.lockWebAuthnCredentialsByEMail.Lock()
+	mock.calls.WebAuthnCredentialsByEMail = append(mock.calls.WebAuthnCredentialsByEMail, callInfo)
+	mock.lockWebAuthnCredentialsByEMail.Unlock()
+	return mock.WebAuthnCredentialsByEMailFunc(email)
+}
+
+// WebAuthnCredentialsByEMailCalls gets all the calls that were made to WebAuthnCredentialsByEMail.
+// Check the length with:
+//     len(mockedStorage.WebAuthnCredentialsByEMailCalls())
+func (mock *StorageMock) WebAuthnCredentialsByEMailCalls() []struct {
+	Email string
+} {
+	var calls []struct {
+		Email string
+	}
+	mock.lockWebAuthnCredentialsByEMail.RLock()
+	calls = mock.calls.WebAuthnCredentialsByEMail
+	mock.lockWebAuthnCredentialsByEMail.RUnlock()
+	return calls
+}
@@ -0,0 +1,118 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Digits is the number of digits of a code
+	Digits = 6
+	// Period is the duration in which a code is valid
+	Period = 30 * time.Second
+
+	// number of time steps before and after the current one which are accepted to tolerate clock drift
+	skew         = 1
+	secretLength = 20
+)
+
+var randRead = rand.Read
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret generates a new random base32 encoded secret
+func GenerateSecret() (string, error) {
+	b := make([]byte, secretLength)
+	_, err := randRead(b)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	return encoding.EncodeToString(b), nil
+}
+
+// URI returns the otpauth uri of the given secret which can be shown as qr-code to be scanned by authenticator apps
+func URI(issuer, account, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(Digits))
+	params.Set("period", fmt.Sprint(int(Period.Seconds())))
+
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// Code returns the code of the given base32 encoded secret at the given time
+func Code(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return code(key, step(t)), nil
+}
+
+// Validate checks whether the given code is valid for the given base32 encoded secret at the given time. Codes of time
+// steps up to lastStep will be rejected, so each code can only be used once. It returns the time step of the code
+// which has to be passed as lastStep on the next validation.
+func Validate(secret, givenCode string, t time.Time, lastStep int64) (int64, bool, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false, err
+	}
+
+	current := step(t)
+	for s := current - skew; s <= current+skew; s++ {
+		if s <= lastStep {
+			continue
+		}
+
+		if hmac.Equal([]byte(code(key, s)), []byte(givenCode)) {
+			return s, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secret: %w", err)
+	}
+
+	return key, nil
+}
+
+func step(t time.Time) int64 {
+	return t.Unix() / int64(Period.Seconds())
+}
+
+// code implements the HOTP algorithm (RFC 4226) with the time step as counter
+func code(key []byte, counter int64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < Digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", Digits, value%mod)
+}
@@ -0,0 +1,111 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// base32 encoded secret "12345678901234567890" of the RFC 6238 test vectors
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// RFC 6238 appendix B test vectors (SHA1) truncated to 6 digits
+	tests := []struct {
+		unix         int64
+		expectedCode string
+	}{
+		{unix: 59, expectedCode: "287082"},
+		{unix: 1111111109, expectedCode: "081804"},
+		{unix: 1111111111, expectedCode: "050471"},
+		{unix: 1234567890, expectedCode: "005924"},
+		{unix: 2000000000, expectedCode: "279037"},
+	}
+
+	for _, tt := range tests {
+		c, err := Code(rfcSecret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if c != tt.expectedCode {
+			t.Errorf("Code at %d is not as expected. Expected: %s, Given: %s", tt.unix, tt.expectedCode, c)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	currentStep := now.Unix() / 30
+
+	tests := []struct {
+		name          string
+		code          string
+		lastStep      int64
+		expectedValid bool
+		expectedStep  int64
+	}{
+		{name: "Current code", code: "050471", expectedValid: true, expectedStep: currentStep},
+		{name: "Code of previous step", code: mustCode(t, now.Add(-Period)), expectedValid: true, expectedStep: currentStep - 1},
+		{name: "Code of next step", code: mustCode(t, now.Add(Period)), expectedValid: true, expectedStep: currentStep + 1},
+		{name: "Code out of skew", code: mustCode(t, now.Add(-2*Period)), expectedValid: false},
+		{name: "Code already used", code: "050471", lastStep: currentStep, expectedValid: false},
+		{name: "Wrong code", code: "123456", expectedValid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, valid, err := Validate(rfcSecret, tt.code, now, tt.lastStep)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if valid != tt.expectedValid {
+				t.Fatalf("Valid is not as expected. Expected: %t, Given: %t", tt.expectedValid, valid)
+			}
+			if s != tt.expectedStep {
+				t.Errorf("Step is not as expected. Expected: %d, Given: %d", tt.expectedStep, s)
+			}
+		})
+	}
+}
+
+func TestValidateInvalidSecret(t *testing.T) {
+	_, _, err := Validate("not base32!", "123456", time.Now(), 0)
+	if err == nil {
+		t.Error("Expected an error for an invalid secret")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(secret) != 32 {
+		t.Errorf("Secret should be 32 base32 chars long. Given: %q", secret)
+	}
+
+	_, err = Code(secret, time.Now())
+	if err != nil {
+		t.Errorf("Generated secret should be decodable: %s", err)
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("simple jwt provider", "info@leberkleber.io", rfcSecret)
+
+	expected := "otpauth://totp/simple%20jwt%20provider:info@leberkleber.io?algorithm=SHA1&digits=6&issuer=simple+jwt+provider&period=30&secret=" + rfcSecret
+	if uri != expected {
+		t.Errorf("URI is not as expected. \nExpected: %s\nGiven:    %s", expected, uri)
+	}
+}
+
+func mustCode(t *testing.T, at time.Time) string {
+	c, err := Code(rfcSecret, at)
+	if err != nil {
+		t.Fatalf("Failed to generate code: %s", err)
+	}
+
+	return c
+}
@@ -15,6 +15,8 @@ type User struct {
 	EMail    string                 `json:"email"`
 	Password string                 `json:"password"`
 	Claims   map[string]interface{} `json:"claims"`
+	// PasswordBreached will only be returned and is true when the password has been found in a data breach
+	PasswordBreached bool `json:"password_breached,omitempty"`
 }
 
 func (s *Server) createUserHandler(w http.ResponseWriter, r *http.Request) {
@@ -46,6 +48,9 @@ func (s *Server) createUserHandler(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusConflict, "User with given email already exists")
 			return
 		}
+		if writePasswordPolicyError(w, err) {
+			return
+		}
 
 		logrus.WithError(err).Error("Failed to create User")
 		writeInternalServerError(w)
@@ -77,9 +82,10 @@ func (s *Server) getUserHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = json.NewEncoder(w).Encode(User{
-		EMail:    user.EMail,
-		Password: user.Password,
-		Claims:   user.Claims,
+		EMail:            user.EMail,
+		Password:         user.Password,
+		Claims:           user.Claims,
+		PasswordBreached: user.PasswordBreached,
 	})
 	if err != nil {
 		logrus.WithError(err).Error("Failed to encode User")
@@ -118,6 +124,9 @@ func (s *Server) updateUserHandler(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusNotFound, "User with given email doesn't exists")
 			return
 		}
+		if writePasswordPolicyError(w, err) {
+			return
+		}
 
 		logrus.WithError(err).Error("Failed to update User")
 		writeInternalServerError(w)
@@ -125,9 +134,10 @@ func (s *Server) updateUserHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = json.NewEncoder(w).Encode(User{
-		EMail:    updatedUser.EMail,
-		Password: updatedUser.Password,
-		Claims:   updatedUser.Claims,
+		EMail:            updatedUser.EMail,
+		Password:         updatedUser.Password,
+		Claims:           updatedUser.Claims,
+		PasswordBreached: updatedUser.PasswordBreached,
 	})
 	if err != nil {
 		logrus.WithError(err).Error("Failed to encode User")
@@ -159,3 +169,46 @@ func (s *Server) deleteUserHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (s *Server) unlockUserHandler(w http.ResponseWriter, r *http.Request) {
+	email, err := url.PathUnescape(mux.Vars(r)["email"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "could not unescape email")
+		return
+	}
+
+	err = s.p.UnlockUser(email)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to unlock User")
+		writeInternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) revokeAccessTokenHandler(w http.ResponseWriter, r *http.Request) {
+	requestBody := struct {
+		JIT string `json:"jit"`
+	}{}
+
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	if requestBody.JIT == "" {
+		writeError(w, http.StatusBadRequest, "jit must be set")
+		return
+	}
+
+	err = s.p.RevokeAccessTokenByID(requestBody.JIT)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to revoke access-token")
+		writeInternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
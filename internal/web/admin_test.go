@@ -68,6 +68,17 @@ func TestCreateUserHandler(t *testing.T) {
 			expectedResponseCode: http.StatusConflict,
 			expectedResponseBody: `{"message":"User with given email already exists"}`,
 		},
+		{
+			name:          "Password policy violated",
+			requestBody:   `{"email": "test.test@test.test", "password": "s3cr3t"}`,
+			providerError: internal.PasswordPolicyError{Violations: []internal.PasswordPolicyViolation{{Rule: "min_length", Message: "password must be at least 8 characters long"}}},
+			expectedUser: User{
+				EMail:    "test.test@test.test",
+				Password: "s3cr3t",
+			},
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"password violates the password policy","violations":[{"rule":"min_length","message":"password must be at least 8 characters long"}]}`,
+		},
 		{
 			name:          "Unexpected error",
 			requestBody:   `{"email": "test.test@test.test", "password": "s3cr3t", "claims": {"hello": "world", "c": 42}}`,
@@ -95,7 +106,7 @@ func TestCreateUserHandler(t *testing.T) {
 
 					return tt.providerError
 				},
-			}, true, "username", "password")
+			}, true, "username", "password", nil, 0, 0, nil)
 			testServer := httptest.NewServer(toTest.h)
 
 			bb := bytes.NewReader([]byte(tt.requestBody))
@@ -172,6 +183,18 @@ func TestGetUserHandler(t *testing.T) {
 			expectedResponseCode: http.StatusOK,
 			expectedResponseBody: `{"email":"test.test@test.test","password":"myPassword","claims":{"test":"claim"}}`,
 		},
+		{
+			name:         "Breached password",
+			requestEmail: "info%40leberkleber.io",
+			providerUser: internal.User{
+				EMail:            "test.test@test.test",
+				Password:         "myPassword",
+				PasswordBreached: true,
+			},
+			expectedEncodedEmail: "info@leberkleber.io",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"email":"test.test@test.test","password":"myPassword","claims":null,"password_breached":true}`,
+		},
 		{
 			name:                 "User not found",
 			requestEmail:         "info%40leberkleber.io",
@@ -214,7 +237,7 @@ func TestGetUserHandler(t *testing.T) {
 					givenEMail = email
 					return tt.providerUser, tt.providerError
 				},
-			}, true, "username", "password")
+			}, true, "username", "password", nil, 0, 0, nil)
 			testServer := httptest.NewServer(toTest.h)
 
 			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/v1/admin/users/%s", testServer.URL, tt.requestEmail), nil)
@@ -378,7 +401,7 @@ func TestUpdateUserHandler(t *testing.T) {
 
 					return tt.providerUser, tt.providerError
 				},
-			}, true, "username", "password")
+			}, true, "username", "password", nil, 0, 0, nil)
 			testServer := httptest.NewServer(toTest.h)
 
 			bb := bytes.NewReader([]byte(tt.requestBody))
@@ -473,7 +496,7 @@ func TestDeleteUserHandler(t *testing.T) {
 					givenEMail = email
 					return tt.providerError
 				},
-			}, true, "username", "password")
+			}, true, "username", "password", nil, 0, 0, nil)
 			testServer := httptest.NewServer(toTest.h)
 
 			req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/v1/admin/users/%s", testServer.URL, tt.requestEmail), nil)
@@ -523,3 +546,151 @@ func TestDeleteUserHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestRevokeAccessTokenHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		requestBody          string
+		providerError        error
+		expectedJIT          string
+		expectedResponseBody string
+		expectedResponseCode int
+	}{
+		{
+			name:                 "Happycase",
+			requestBody:          `{"jit": "jwt-id"}`,
+			expectedJIT:          "jwt-id",
+			expectedResponseCode: http.StatusCreated,
+		},
+		{
+			name:                 "Invalid JSON",
+			requestBody:          `{"jit jwt-id"}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"invalid JSON"}`,
+		},
+		{
+			name:                 "Missing jit",
+			requestBody:          `{}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"jit must be set"}`,
+		},
+		{
+			name:                 "Error while revocation",
+			requestBody:          `{"jit": "jwt-id"}`,
+			providerError:        errors.New("nope"),
+			expectedJIT:          "jwt-id",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenJIT string
+
+			toTest := NewServer(&ProviderMock{
+				RevokeAccessTokenByIDFunc: func(jit string) error {
+					givenJIT = jit
+					return tt.providerError
+				},
+			}, true, "username", "password", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			req, err := http.NewRequest(http.MethodPost, testServer.URL+"/v1/admin/revoked-tokens", bytes.NewReader([]byte(tt.requestBody)))
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+			req.SetBasicAuth("username", "password")
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			if tt.expectedJIT != givenJIT {
+				t.Errorf("Unexpected revoked jit. Expected: %q, Given: %q", tt.expectedJIT, givenJIT)
+			}
+
+			respBody = bytes.TrimSpace(respBody)
+			if !bytes.Equal(respBody, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: %q, Given: %q", tt.expectedResponseBody, string(respBody))
+			}
+		})
+	}
+}
+
+func TestUnlockUserHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		providerError        error
+		expectedResponseBody string
+		expectedResponseCode int
+	}{
+		{
+			name:                 "Happycase",
+			expectedResponseCode: http.StatusNoContent,
+		},
+		{
+			name:                 "Unexpected error",
+			providerError:        errors.New("nope"),
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenEMail string
+
+			toTest := NewServer(&ProviderMock{
+				UnlockUserFunc: func(email string) error {
+					givenEMail = email
+					return tt.providerError
+				},
+			}, true, "username", "password", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			req, err := http.NewRequest(http.MethodDelete, testServer.URL+"/v1/admin/users/test@test.test/lockout", nil)
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+			req.SetBasicAuth("username", "password")
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			if givenEMail != "test@test.test" {
+				t.Errorf("Unexpected unlocked user. Expected: %q, Given: %q", "test@test.test", givenEMail)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			respBody = bytes.TrimSpace(respBody)
+			if !bytes.Equal(respBody, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: \n%q, \nGiven: \n%q", tt.expectedResponseBody, string(respBody))
+			}
+		})
+	}
+}
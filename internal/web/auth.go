@@ -30,8 +30,23 @@ func (s *Server) loginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accessToken, refreshToken, err := s.p.Login(requestBody.EMail, requestBody.Password)
+	accessToken, refreshToken, err := s.p.Login(requestBody.EMail, requestBody.Password, s.clientInfo(r))
 	if err != nil {
+		if writeLockoutError(w, err, requestBody.EMail) {
+			return
+		}
+
+		var mfaErr internal.MFARequiredError
+		if errors.As(err, &mfaErr) {
+			writeMFARequired(w, mfaErr)
+			return
+		}
+
+		if errors.Is(err, internal.ErrEMailNotVerified) {
+			writeError(w, http.StatusForbidden, "email is not verified")
+			return
+		}
+
 		if errors.Is(err, internal.ErrIncorrectPassword) || errors.Is(err, internal.ErrUserNotFound) {
 			logrus.WithField("email", requestBody.EMail).Warn("Somebody tried to login with invalid credentials")
 			writeError(w, http.StatusUnauthorized, "invalid credentials")
@@ -43,7 +58,12 @@ func (s *Server) loginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = json.NewEncoder(w).Encode(struct {
+	writeTokens(w, accessToken, refreshToken)
+}
+
+// writeTokens responds with the given access and refresh token
+func writeTokens(w http.ResponseWriter, accessToken, refreshToken string) {
+	err := json.NewEncoder(w).Encode(struct {
 		AccessToken  string `json:"access_token"`
 		RefreshToken string `json:"refresh_token"`
 	}{
@@ -57,6 +77,24 @@ func (s *Server) loginHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// writeLockoutError responds with http status 423 or 429 and a Retry-After header when the given error is an
+// internal.LockoutError. It returns false when the error is another error and nothing has been written.
+func writeLockoutError(w http.ResponseWriter, err error, email string) bool {
+	var lockoutErr internal.LockoutError
+	if !errors.As(err, &lockoutErr) {
+		return false
+	}
+
+	logrus.WithField("email", email).WithError(err).Warn("Somebody tried to login while locked")
+	setRetryAfter(w, lockoutErr.Until)
+	if errors.Is(err, internal.ErrAccountLocked) {
+		writeError(w, http.StatusLocked, "account is locked")
+		return true
+	}
+	writeError(w, http.StatusTooManyRequests, "too many login attempts")
+	return true
+}
+
 func (s *Server) refreshHandler(w http.ResponseWriter, r *http.Request) {
 	requestBody := struct {
 		RefreshToken string `json:"refresh_token"`
@@ -73,11 +111,12 @@ func (s *Server) refreshHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newAccessToken, newRefreshToken, err := s.p.Refresh(requestBody.RefreshToken)
+	newAccessToken, newRefreshToken, err := s.p.Refresh(requestBody.RefreshToken, s.clientInfo(r))
 	if err != nil {
 		if errors.Is(err, internal.ErrInvalidToken) ||
 			errors.Is(err, internal.ErrUserNotFound) ||
-			errors.Is(err, internal.ErrTokenNotParsable) {
+			errors.Is(err, internal.ErrTokenNotParsable) ||
+			errors.Is(err, internal.ErrNoValidTokenFound) {
 			logrus.Debug("failed to refresh user auth", err)
 			writeError(w, http.StatusUnauthorized, "invalid refresh-token and/or email")
 			return
@@ -88,18 +127,7 @@ func (s *Server) refreshHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = json.NewEncoder(w).Encode(struct {
-		AccessToken  string `json:"access_token"`
-		RefreshToken string `json:"refresh_token"`
-	}{
-		AccessToken:  newAccessToken,
-		RefreshToken: newRefreshToken,
-	})
-	if err != nil {
-		logrus.WithError(err).Error("Failed marshal request response")
-		writeInternalServerError(w)
-		return
-	}
+	writeTokens(w, newAccessToken, newRefreshToken)
 }
 
 func (s *Server) passwordResetRequestHandler(w http.ResponseWriter, r *http.Request) {
@@ -168,6 +196,9 @@ func (s *Server) passwordResetHandler(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusBadRequest, "reset-token is invalid or token email combination is not correct")
 			return
 		}
+		if writePasswordPolicyError(w, err) {
+			return
+		}
 		logrus.WithError(err).Error("Failed to create password-reset-request")
 		writeInternalServerError(w)
 		return
@@ -175,3 +206,56 @@ func (s *Server) passwordResetHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// passwordChangeHandler changes the password of the user who is authenticated by the access-token in the Authorization
+// header
+func (s *Server) passwordChangeHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, ok := requireBearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	requestBody := struct {
+		Password    string `json:"password"`
+		NewPassword string `json:"new_password"`
+	}{}
+
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	if requestBody.Password == "" {
+		writeError(w, http.StatusBadRequest, "password must be set")
+		return
+	}
+
+	if requestBody.NewPassword == "" {
+		writeError(w, http.StatusBadRequest, "new-password must be set")
+		return
+	}
+
+	err = s.p.ChangePassword(accessToken, requestBody.Password, requestBody.NewPassword, s.clientInfo(r))
+	if err != nil {
+		if errors.Is(err, internal.ErrInvalidToken) {
+			writeInvalidAccessToken(w)
+			return
+		}
+		if errors.Is(err, internal.ErrIncorrectPassword) {
+			writeError(w, http.StatusForbidden, "password is incorrect")
+			return
+		}
+		if writeLockoutError(w, err, "") {
+			return
+		}
+		if writePasswordPolicyError(w, err) {
+			return
+		}
+		logrus.WithError(err).Error("Failed to change password")
+		writeInternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -6,9 +6,11 @@ import (
 	"errors"
 	"github.com/leberKleber/simple-jwt-provider/internal"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestLoginHandler(t *testing.T) {
@@ -74,6 +76,42 @@ func TestLoginHandler(t *testing.T) {
 			expectedResponseCode: http.StatusUnauthorized,
 			expectedResponseBody: `{"message":"invalid credentials"}`,
 		},
+		{
+			name:                 "Locked account",
+			requestBody:          `{"email": "test.test@test.test", "password": "s3cr3t"}`,
+			providerError:        internal.LockoutError{Err: internal.ErrAccountLocked, Until: time.Now().Add(time.Minute)},
+			expectedEMail:        "test.test@test.test",
+			expectedPassword:     "s3cr3t",
+			expectedResponseCode: http.StatusLocked,
+			expectedResponseBody: `{"message":"account is locked"}`,
+		},
+		{
+			name:                 "Locked client",
+			requestBody:          `{"email": "test.test@test.test", "password": "s3cr3t"}`,
+			providerError:        internal.LockoutError{Err: internal.ErrTooManyLoginAttempts, Until: time.Now().Add(time.Minute)},
+			expectedEMail:        "test.test@test.test",
+			expectedPassword:     "s3cr3t",
+			expectedResponseCode: http.StatusTooManyRequests,
+			expectedResponseBody: `{"message":"too many login attempts"}`,
+		},
+		{
+			name:                 "MFA required",
+			requestBody:          `{"email": "test.test@test.test", "password": "s3cr3t"}`,
+			providerError:        internal.MFARequiredError{Token: "myMFAToken", Methods: []string{internal.MFAMethodTOTP}},
+			expectedEMail:        "test.test@test.test",
+			expectedPassword:     "s3cr3t",
+			expectedResponseCode: http.StatusForbidden,
+			expectedResponseBody: `{"message":"mfa required","mfa_token":"myMFAToken","mfa_methods":["totp"]}`,
+		},
+		{
+			name:                 "Unverified email",
+			requestBody:          `{"email": "test.test@test.test", "password": "s3cr3t"}`,
+			providerError:        internal.ErrEMailNotVerified,
+			expectedEMail:        "test.test@test.test",
+			expectedPassword:     "s3cr3t",
+			expectedResponseCode: http.StatusForbidden,
+			expectedResponseBody: `{"message":"email is not verified"}`,
+		},
 		{
 			name:                 "Unexpected error",
 			requestBody:          `{"email": "not.found@test.test", "password": "s3cr3t"}`,
@@ -85,18 +123,25 @@ func TestLoginHandler(t *testing.T) {
 		},
 	}
 
+	_, loopback, err := net.ParseCIDR("127.0.0.0/8")
+	if err != nil {
+		t.Fatalf("Failed to parse cidr: %s", err)
+	}
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var givenEMail, givenPassword string
+			var givenClient internal.ClientInfo
 
 			toTest := NewServer(&ProviderMock{
-				LoginFunc: func(email string, password string) (string, string, error) {
+				LoginFunc: func(email string, password string, client internal.ClientInfo) (string, string, error) {
 					givenEMail = email
 					givenPassword = password
+					givenClient = client
 
 					return tt.providerAccessToken, tt.providerRefreshToken, tt.providerError
 				},
-			}, false, "", "")
+			}, false, "", "", nil, 0, 0, []*net.IPNet{loopback})
 			testServer := httptest.NewServer(toTest.h)
 
 			bb := bytes.NewReader([]byte(tt.requestBody))
@@ -104,6 +149,8 @@ func TestLoginHandler(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Failed to build http request: %s", err)
 			}
+			req.Header.Set("User-Agent", "my-agent")
+			req.Header.Set("X-Forwarded-For", "10.0.0.2, 10.0.0.1")
 
 			resp, err := http.DefaultClient.Do(req)
 			if err != nil {
@@ -121,6 +168,10 @@ func TestLoginHandler(t *testing.T) {
 				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
 			}
 
+			if (resp.StatusCode == http.StatusLocked || resp.StatusCode == http.StatusTooManyRequests) && resp.Header.Get("Retry-After") != "60" {
+				t.Errorf("Unexpected Retry-After header. Expected: %q, Given: %q", "60", resp.Header.Get("Retry-After"))
+			}
+
 			respBody, err := ioutil.ReadAll(resp.Body)
 			if err != nil {
 				t.Fatalf("Failed to read response body: %s", err)
@@ -134,6 +185,11 @@ func TestLoginHandler(t *testing.T) {
 				t.Errorf("Provider called with unexpected password. Given: %q, Expected: %q", givenPassword, tt.expectedPassword)
 			}
 
+			expectedClient := internal.ClientInfo{UserAgent: "my-agent", IP: "10.0.0.1"}
+			if tt.expectedEMail != "" && givenClient != expectedClient {
+				t.Errorf("Provider called with unexpected client. Given: %#v, Expected: %#v", givenClient, expectedClient)
+			}
+
 			var compactedRespBodyAsBytes []byte
 			if resp.ContentLength > 0 {
 				compactedRespBody := &bytes.Buffer{}
@@ -203,6 +259,14 @@ func TestRefreshHandler(t *testing.T) {
 			expectedResponseCode: http.StatusUnauthorized,
 			expectedResponseBody: `{"message":"invalid refresh-token and/or email"}`,
 		},
+		{
+			name:                 "Already used token",
+			requestBody:          `{"refresh_token": "myOldRefreshToken"}`,
+			providerError:        internal.ErrNoValidTokenFound,
+			expectedRefreshToken: "myOldRefreshToken",
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"message":"invalid refresh-token and/or email"}`,
+		},
 		{
 			name:                 "Unexpected error",
 			requestBody:          `{"refresh_token": "myOldRefreshToken"}`,
@@ -218,12 +282,12 @@ func TestRefreshHandler(t *testing.T) {
 			var givenRefreshToken string
 
 			toTest := NewServer(&ProviderMock{
-				RefreshFunc: func(refreshToken string) (string, string, error) {
+				RefreshFunc: func(refreshToken string, client internal.ClientInfo) (string, string, error) {
 					givenRefreshToken = refreshToken
 
 					return tt.providerAccessToken, tt.providerRefreshToken, tt.providerError
 				},
-			}, false, "", "")
+			}, false, "", "", nil, 0, 0, nil)
 			testServer := httptest.NewServer(toTest.h)
 
 			bb := bytes.NewReader([]byte(tt.requestBody))
@@ -328,7 +392,7 @@ func TestPasswordResetRequestHandler(t *testing.T) {
 					givenEMail = email
 					return tt.providerError
 				},
-			}, false, "", "")
+			}, false, "", "", nil, 0, 0, nil)
 			testServer := httptest.NewServer(toTest.h)
 
 			bb := bytes.NewReader([]byte(tt.requestBody))
@@ -433,6 +497,16 @@ func TestPasswordResetHandler(t *testing.T) {
 			expectedResponseCode: http.StatusBadRequest,
 			expectedResponseBody: `{"message":"reset-token is invalid or token email combination is not correct"}`,
 		},
+		{
+			name:                 "Password policy violated",
+			requestBody:          `{"email":"test.test@test.test","password": "s3cr3t","reset_token": "myResetToken"}`,
+			providerError:        internal.PasswordPolicyError{Violations: []internal.PasswordPolicyViolation{{Rule: "min_length", Message: "password must be at least 8 characters long"}}},
+			expectedEMail:        "test.test@test.test",
+			expectedPassword:     "s3cr3t",
+			expectedResetToken:   "myResetToken",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"password violates the password policy","violations":[{"rule":"min_length","message":"password must be at least 8 characters long"}]}`,
+		},
 		{
 			name:                 "Unexpected error",
 			requestBody:          `{"email":"test.test@test.test","password": "new_s3cr3t","reset_token": "myResetToken"}`,
@@ -456,7 +530,7 @@ func TestPasswordResetHandler(t *testing.T) {
 					givenPassword = password
 					return tt.providerError
 				},
-			}, false, "", "")
+			}, false, "", "", nil, 0, 0, nil)
 			testServer := httptest.NewServer(toTest.h)
 
 			bb := bytes.NewReader([]byte(tt.requestBody))
@@ -515,3 +589,175 @@ func TestPasswordResetHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestPasswordChangeHandler(t *testing.T) {
+	tests := []struct {
+		name                    string
+		authorization           string
+		requestBody             string
+		providerError           error
+		expectedAccessToken     string
+		expectedPassword        string
+		expectedNewPassword     string
+		expectedResponseCode    int
+		expectedResponseBody    string
+		expectedWWWAuthenticate string
+	}{
+		{
+			name:                 "Happycase",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"password": "s3cr3t","new_password": "new_s3cr3t"}`,
+			expectedAccessToken:  "myAccessToken",
+			expectedPassword:     "s3cr3t",
+			expectedNewPassword:  "new_s3cr3t",
+			expectedResponseCode: http.StatusNoContent,
+		},
+		{
+			name:                    "Missing access-token",
+			requestBody:             `{"password": "s3cr3t","new_password": "new_s3cr3t"}`,
+			expectedResponseCode:    http.StatusUnauthorized,
+			expectedResponseBody:    `{"message":"access-token must be set as bearer token"}`,
+			expectedWWWAuthenticate: "Bearer",
+		},
+		{
+			name:                 "Invalid JSON",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"password s3cr3t}"`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"invalid JSON"}`,
+		},
+		{
+			name:                 "Missing password",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"new_password": "new_s3cr3t"}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"password must be set"}`,
+		},
+		{
+			name:                 "Missing new-password",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"password": "s3cr3t"}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"new-password must be set"}`,
+		},
+		{
+			name:                    "Invalid access-token",
+			authorization:           "Bearer invalidAccessToken",
+			requestBody:             `{"password": "s3cr3t","new_password": "new_s3cr3t"}`,
+			providerError:           internal.ErrInvalidToken,
+			expectedAccessToken:     "invalidAccessToken",
+			expectedPassword:        "s3cr3t",
+			expectedNewPassword:     "new_s3cr3t",
+			expectedResponseCode:    http.StatusUnauthorized,
+			expectedResponseBody:    `{"message":"invalid access-token"}`,
+			expectedWWWAuthenticate: `Bearer error="invalid_token"`,
+		},
+		{
+			name:                 "Incorrect password",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"password": "wrong","new_password": "new_s3cr3t"}`,
+			providerError:        internal.ErrIncorrectPassword,
+			expectedAccessToken:  "myAccessToken",
+			expectedPassword:     "wrong",
+			expectedNewPassword:  "new_s3cr3t",
+			expectedResponseCode: http.StatusForbidden,
+			expectedResponseBody: `{"message":"password is incorrect"}`,
+		},
+		{
+			name:                 "Locked account",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"password": "wrong","new_password": "new_s3cr3t"}`,
+			providerError:        internal.LockoutError{Err: internal.ErrAccountLocked, Until: time.Now().Add(time.Minute)},
+			expectedAccessToken:  "myAccessToken",
+			expectedPassword:     "wrong",
+			expectedNewPassword:  "new_s3cr3t",
+			expectedResponseCode: http.StatusLocked,
+			expectedResponseBody: `{"message":"account is locked"}`,
+		},
+		{
+			name:                 "Password reused",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"password": "s3cr3t","new_password": "s3cr3t"}`,
+			providerError:        internal.PasswordPolicyError{Violations: []internal.PasswordPolicyViolation{{Rule: "history", Message: "password must not be one of the last 5 passwords"}}},
+			expectedAccessToken:  "myAccessToken",
+			expectedPassword:     "s3cr3t",
+			expectedNewPassword:  "s3cr3t",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"password violates the password policy","violations":[{"rule":"history","message":"password must not be one of the last 5 passwords"}]}`,
+		},
+		{
+			name:                 "Unexpected error",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"password": "s3cr3t","new_password": "new_s3cr3t"}`,
+			providerError:        errors.New("computer says nooooo"),
+			expectedAccessToken:  "myAccessToken",
+			expectedPassword:     "s3cr3t",
+			expectedNewPassword:  "new_s3cr3t",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenAccessToken, givenPassword, givenNewPassword string
+
+			toTest := NewServer(&ProviderMock{
+				ChangePasswordFunc: func(accessToken string, currentPassword string, newPassword string, client internal.ClientInfo) error {
+					givenAccessToken = accessToken
+					givenPassword = currentPassword
+					givenNewPassword = newPassword
+					return tt.providerError
+				},
+			}, false, "", "", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+
+			bb := bytes.NewReader([]byte(tt.requestBody))
+			req, err := http.NewRequest(http.MethodPost, testServer.URL+"/v1/auth/password-change", bb)
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			if wwwAuthenticate := resp.Header.Get("WWW-Authenticate"); wwwAuthenticate != tt.expectedWWWAuthenticate {
+				t.Errorf("Unexpected WWW-Authenticate header. Expected: %q, Given: %q", tt.expectedWWWAuthenticate, wwwAuthenticate)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			if givenAccessToken != tt.expectedAccessToken || givenPassword != tt.expectedPassword || givenNewPassword != tt.expectedNewPassword {
+				t.Errorf("Provider called with unexpected params. Given: %q %q %q, Expected: %q %q %q", givenAccessToken, givenPassword, givenNewPassword, tt.expectedAccessToken, tt.expectedPassword, tt.expectedNewPassword)
+			}
+
+			var compactedRespBodyAsBytes []byte
+			if resp.ContentLength > 0 {
+				compactedRespBody := &bytes.Buffer{}
+				err = json.Compact(compactedRespBody, respBody)
+				if err != nil {
+					t.Fatalf("Failed to compact json: %s", err)
+				}
+
+				compactedRespBodyAsBytes = compactedRespBody.Bytes()
+			}
+
+			if !bytes.Equal(compactedRespBodyAsBytes, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: %q, Given: %q", tt.expectedResponseBody, string(compactedRespBodyAsBytes))
+			}
+		})
+	}
+}
@@ -13,7 +13,7 @@ func TestAliveHandler(t *testing.T) {
 	expectedResponseCode := http.StatusOK
 	expectedResponseBody := `{"alive":true}`
 
-	toTest := NewServer(nil, false, "", "")
+	toTest := NewServer(nil, false, "", "", nil, 0, 0, nil)
 	testServer := httptest.NewServer(toTest.h)
 
 	req, err := http.NewRequest(http.MethodGet, testServer.URL+"/v1/internal/alive", nil)
@@ -0,0 +1,53 @@
+package web
+
+import (
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// introspectionHandler implements the token introspection endpoint as described in https://tools.ietf.org/html/rfc7662.
+// Active tokens will be responded with all their claims, inactive tokens only with active = false.
+func (s *Server) introspectionHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid form body")
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		writeError(w, http.StatusBadRequest, "token must be set")
+		return
+	}
+
+	active, claims, err := s.p.Introspect(token)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to introspect token")
+		writeInternalServerError(w)
+		return
+	}
+
+	respBody := map[string]interface{}{}
+	if active {
+		for k, v := range claims {
+			respBody[k] = v
+		}
+
+		// the token id is named 'jit' in all issued tokens
+		if jit, ok := claims["jit"]; ok {
+			respBody["jti"] = jit
+		}
+		if email, ok := claims["email"]; ok {
+			respBody["username"] = email
+		}
+	}
+	respBody["active"] = active
+
+	err = json.NewEncoder(w).Encode(respBody)
+	if err != nil {
+		logrus.WithError(err).Error("Failed marshal request response")
+		writeInternalServerError(w)
+		return
+	}
+}
@@ -0,0 +1,129 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIntrospectionHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		enableAdminAPI       bool
+		introspectionClients map[string]string
+		username             string
+		password             string
+		requestBody          string
+		providerActive       bool
+		providerClaims       map[string]interface{}
+		providerError        error
+		expectedToken        string
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Active token with client credentials",
+			introspectionClients: map[string]string{"my-client": "s3cr3t"},
+			username:             "my-client",
+			password:             "s3cr3t",
+			requestBody:          "token=myToken",
+			providerActive:       true,
+			providerClaims:       map[string]interface{}{"email": "test@test.test", "jit": "jwt-id", "exp": 1618830123},
+			expectedToken:        "myToken",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"active":true,"email":"test@test.test","exp":1618830123,"jit":"jwt-id","jti":"jwt-id","username":"test@test.test"}`,
+		}, {
+			name:                 "Inactive token with admin credentials",
+			enableAdminAPI:       true,
+			username:             "admin",
+			password:             "password",
+			requestBody:          "token=myToken&token_type_hint=refresh_token",
+			expectedToken:        "myToken",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"active":false}`,
+		}, {
+			name:                 "Invalid client credentials",
+			introspectionClients: map[string]string{"my-client": "s3cr3t"},
+			username:             "my-client",
+			password:             "nope",
+			requestBody:          "token=myToken",
+			expectedResponseCode: http.StatusForbidden,
+			expectedResponseBody: `{"message": "forbidden"}`,
+		}, {
+			name:                 "Disabled without credentials",
+			username:             "my-client",
+			password:             "s3cr3t",
+			requestBody:          "token=myToken",
+			expectedResponseCode: http.StatusNotFound,
+			expectedResponseBody: `{"message":"endpoint not found"}`,
+		}, {
+			name:                 "Missing token",
+			introspectionClients: map[string]string{"my-client": "s3cr3t"},
+			username:             "my-client",
+			password:             "s3cr3t",
+			requestBody:          "token_type_hint=access_token",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"token must be set"}`,
+		}, {
+			name:                 "Unexpected error",
+			introspectionClients: map[string]string{"my-client": "s3cr3t"},
+			username:             "my-client",
+			password:             "s3cr3t",
+			requestBody:          "token=myToken",
+			providerError:        errors.New("nope"),
+			expectedToken:        "myToken",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenToken string
+
+			toTest := NewServer(&ProviderMock{
+				IntrospectFunc: func(token string) (bool, map[string]interface{}, error) {
+					givenToken = token
+					return tt.providerActive, tt.providerClaims, tt.providerError
+				},
+			}, tt.enableAdminAPI, "admin", "password", tt.introspectionClients, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			req, err := http.NewRequest(http.MethodPost, testServer.URL+"/v1/auth/introspect", strings.NewReader(tt.requestBody))
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.SetBasicAuth(tt.username, tt.password)
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			if givenToken != tt.expectedToken {
+				t.Errorf("Provider called with unexpected token. Given: %q, Expected: %q", givenToken, tt.expectedToken)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			respBody = bytes.TrimSpace(respBody)
+			if !bytes.Equal(respBody, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: \n%q, \nGiven: \n%q", tt.expectedResponseBody, string(respBody))
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/leberKleber/simple-jwt-provider/internal"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+func (s *Server) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	s.handleLogout(w, r, s.p.Logout)
+}
+
+func (s *Server) logoutEverywhereHandler(w http.ResponseWriter, r *http.Request) {
+	s.handleLogout(w, r, s.p.LogoutEverywhere)
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request, logout func(refreshToken string) error) {
+	requestBody := struct {
+		RefreshToken string `json:"refresh_token"`
+	}{}
+
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	if requestBody.RefreshToken == "" {
+		writeError(w, http.StatusBadRequest, "refresh_token must be set")
+		return
+	}
+
+	err = logout(requestBody.RefreshToken)
+	if err != nil {
+		if errors.Is(err, internal.ErrInvalidToken) ||
+			errors.Is(err, internal.ErrTokenNotParsable) ||
+			errors.Is(err, internal.ErrNoValidTokenFound) {
+			logrus.Debug("failed to logout user", err)
+			writeError(w, http.StatusUnauthorized, "invalid refresh-token")
+			return
+		}
+
+		logrus.WithError(err).Error("Failed to logout")
+		writeInternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// revokeHandler implements the token revocation endpoint as described in https://tools.ietf.org/html/rfc7009.
+// Invalid tokens will be responded with 200 as well.
+func (s *Server) revokeHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		writeOAuth2Error(w, oauth2ErrInvalidRequest, "invalid form body")
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		writeOAuth2Error(w, oauth2ErrInvalidRequest, "token must be set")
+		return
+	}
+
+	err = s.p.Revoke(token)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to revoke token")
+		writeInternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,191 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"github.com/leberKleber/simple-jwt-provider/internal"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLogoutHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		path                 string
+		requestBody          string
+		providerError        error
+		expectedLogout       string
+		expectedEverywhere   string
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Logout",
+			path:                 "/v1/auth/logout",
+			requestBody:          `{"refresh_token": "myRefreshToken"}`,
+			expectedLogout:       "myRefreshToken",
+			expectedResponseCode: http.StatusNoContent,
+		}, {
+			name:                 "Logout everywhere",
+			path:                 "/v1/auth/logout-everywhere",
+			requestBody:          `{"refresh_token": "myRefreshToken"}`,
+			expectedEverywhere:   "myRefreshToken",
+			expectedResponseCode: http.StatusNoContent,
+		}, {
+			name:                 "Invalid JSON",
+			path:                 "/v1/auth/logout",
+			requestBody:          `{"refresh_token myRefreshToken"}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"invalid JSON"}`,
+		}, {
+			name:                 "Missing refresh-token",
+			path:                 "/v1/auth/logout-everywhere",
+			requestBody:          `{}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"refresh_token must be set"}`,
+		}, {
+			name:                 "Already used refresh-token",
+			path:                 "/v1/auth/logout",
+			requestBody:          `{"refresh_token": "myRefreshToken"}`,
+			providerError:        internal.ErrNoValidTokenFound,
+			expectedLogout:       "myRefreshToken",
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"message":"invalid refresh-token"}`,
+		}, {
+			name:                 "Invalid refresh-token",
+			path:                 "/v1/auth/logout-everywhere",
+			requestBody:          `{"refresh_token": "myRefreshToken"}`,
+			providerError:        internal.ErrInvalidToken,
+			expectedEverywhere:   "myRefreshToken",
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"message":"invalid refresh-token"}`,
+		}, {
+			name:                 "Unexpected error",
+			path:                 "/v1/auth/logout",
+			requestBody:          `{"refresh_token": "myRefreshToken"}`,
+			providerError:        errors.New("nope"),
+			expectedLogout:       "myRefreshToken",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenLogout, givenEverywhere string
+
+			toTest := NewServer(&ProviderMock{
+				LogoutFunc: func(refreshToken string) error {
+					givenLogout = refreshToken
+					return tt.providerError
+				},
+				LogoutEverywhereFunc: func(refreshToken string) error {
+					givenEverywhere = refreshToken
+					return tt.providerError
+				},
+			}, false, "", "", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			resp, err := http.Post(testServer.URL+tt.path, "application/json", strings.NewReader(tt.requestBody))
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			if givenLogout != tt.expectedLogout {
+				t.Errorf("Logout called with unexpected refresh-token. Given: %q, Expected: %q", givenLogout, tt.expectedLogout)
+			}
+
+			if givenEverywhere != tt.expectedEverywhere {
+				t.Errorf("LogoutEverywhere called with unexpected refresh-token. Given: %q, Expected: %q", givenEverywhere, tt.expectedEverywhere)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			respBody = bytes.TrimSpace(respBody)
+			if !bytes.Equal(respBody, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: \n%q, \nGiven: \n%q", tt.expectedResponseBody, string(respBody))
+			}
+		})
+	}
+}
+
+func TestRevokeHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		requestBody          string
+		providerError        error
+		expectedToken        string
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Happycase",
+			requestBody:          "token=myToken&token_type_hint=refresh_token",
+			expectedToken:        "myToken",
+			expectedResponseCode: http.StatusOK,
+		}, {
+			name:                 "Missing token",
+			requestBody:          "token_type_hint=refresh_token",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"error":"invalid_request","error_description":"token must be set"}`,
+		}, {
+			name:                 "Unexpected error",
+			requestBody:          "token=myToken",
+			providerError:        errors.New("nope"),
+			expectedToken:        "myToken",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenToken string
+
+			toTest := NewServer(&ProviderMock{
+				RevokeFunc: func(token string) error {
+					givenToken = token
+					return tt.providerError
+				},
+			}, false, "", "", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			resp, err := http.Post(testServer.URL+"/v1/auth/revoke", "application/x-www-form-urlencoded", strings.NewReader(tt.requestBody))
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			if givenToken != tt.expectedToken {
+				t.Errorf("Revoke called with unexpected token. Given: %q, Expected: %q", givenToken, tt.expectedToken)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			respBody = bytes.TrimSpace(respBody)
+			if !bytes.Equal(respBody, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: \n%q, \nGiven: \n%q", tt.expectedResponseBody, string(respBody))
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/leberKleber/simple-jwt-provider/internal"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// magicLinkHandler sends a magic-link with a one-time login token to the given email
+func (s *Server) magicLinkHandler(w http.ResponseWriter, r *http.Request) {
+	requestBody := struct {
+		EMail string `json:"email"`
+	}{}
+
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	if requestBody.EMail == "" {
+		writeError(w, http.StatusBadRequest, "email must be set")
+		return
+	}
+
+	err = s.p.CreateMagicLink(requestBody.EMail)
+	if err != nil {
+		if errors.Is(err, internal.ErrMagicLinkDisabled) {
+			writeError(w, http.StatusNotFound, "magic-link is not enabled")
+			return
+		}
+		if errors.Is(err, internal.ErrUserNotFound) {
+			logrus.WithField("email", requestBody.EMail).Warn("Somebody tried to create a magic-link for non existing User")
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		if errors.Is(err, internal.ErrEMailNotVerified) {
+			logrus.WithField("email", requestBody.EMail).Warn("Somebody tried to create a magic-link for an unverified User")
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+
+		logrus.WithError(err).Error("Failed to create magic-link")
+		writeInternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// magicLinkRedeemHandler exchanges the token of a magic-link for access and refresh token like the login
+func (s *Server) magicLinkRedeemHandler(w http.ResponseWriter, r *http.Request) {
+	requestBody := struct {
+		EMail          string `json:"email"`
+		MagicLinkToken string `json:"magic_link_token"`
+	}{}
+
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	if requestBody.EMail == "" {
+		writeError(w, http.StatusBadRequest, "email must be set")
+		return
+	}
+
+	if requestBody.MagicLinkToken == "" {
+		writeError(w, http.StatusBadRequest, "magic-link-token must be set")
+		return
+	}
+
+	accessToken, refreshToken, err := s.p.RedeemMagicLink(requestBody.EMail, requestBody.MagicLinkToken, s.clientInfo(r))
+	if err != nil {
+		if errors.Is(err, internal.ErrMagicLinkDisabled) {
+			writeError(w, http.StatusNotFound, "magic-link is not enabled")
+			return
+		}
+		if writeLockoutError(w, err, requestBody.EMail) {
+			return
+		}
+
+		var mfaErr internal.MFARequiredError
+		if errors.As(err, &mfaErr) {
+			writeMFARequired(w, mfaErr)
+			return
+		}
+
+		if errors.Is(err, internal.ErrNoValidTokenFound) {
+			logrus.WithField("email", requestBody.EMail).Warn("Somebody tried to login with an invalid magic-link")
+			writeError(w, http.StatusUnauthorized, "magic-link-token is invalid or token email combination is not correct")
+			return
+		}
+
+		logrus.WithError(err).Error("Failed to redeem magic-link")
+		writeInternalServerError(w)
+		return
+	}
+
+	writeTokens(w, accessToken, refreshToken)
+}
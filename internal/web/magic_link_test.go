@@ -0,0 +1,282 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/leberKleber/simple-jwt-provider/internal"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMagicLinkHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		requestBody          string
+		providerError        error
+		expectedEMail        string
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Happycase",
+			requestBody:          `{"email": "test.test@test.test"}`,
+			expectedEMail:        "test.test@test.test",
+			expectedResponseCode: http.StatusCreated,
+		},
+		{
+			name:                 "Invalid JSON",
+			requestBody:          `{"email test.test@test.test"}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"invalid JSON"}`,
+		},
+		{
+			name:                 "Missing email",
+			requestBody:          `{}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"email must be set"}`,
+		},
+		{
+			name:                 "User not found",
+			requestBody:          `{"email": "test.test@test.test"}`,
+			providerError:        internal.ErrUserNotFound,
+			expectedEMail:        "test.test@test.test",
+			expectedResponseCode: http.StatusCreated,
+		},
+		{
+			name:                 "Unverified email",
+			requestBody:          `{"email": "test.test@test.test"}`,
+			providerError:        internal.ErrEMailNotVerified,
+			expectedEMail:        "test.test@test.test",
+			expectedResponseCode: http.StatusCreated,
+		},
+		{
+			name:                 "Disabled",
+			requestBody:          `{"email": "test.test@test.test"}`,
+			providerError:        internal.ErrMagicLinkDisabled,
+			expectedEMail:        "test.test@test.test",
+			expectedResponseCode: http.StatusNotFound,
+			expectedResponseBody: `{"message":"magic-link is not enabled"}`,
+		},
+		{
+			name:                 "Unexpected error",
+			requestBody:          `{"email": "test.test@test.test"}`,
+			providerError:        errors.New("error no 42"),
+			expectedEMail:        "test.test@test.test",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenEMail string
+
+			toTest := NewServer(&ProviderMock{
+				CreateMagicLinkFunc: func(email string) error {
+					givenEMail = email
+					return tt.providerError
+				},
+			}, false, "", "", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+
+			bb := bytes.NewReader([]byte(tt.requestBody))
+			req, err := http.NewRequest(http.MethodPost, testServer.URL+"/v1/auth/magic-link", bb)
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			if givenEMail != tt.expectedEMail {
+				t.Errorf("Provider called with unexpected email. Given: %q, Expected: %q", givenEMail, tt.expectedEMail)
+			}
+
+			var compactedRespBodyAsBytes []byte
+			if resp.ContentLength > 0 {
+				compactedRespBody := &bytes.Buffer{}
+				err = json.Compact(compactedRespBody, respBody)
+				if err != nil {
+					t.Fatalf("Failed to compact json: %s", err)
+				}
+
+				compactedRespBodyAsBytes = compactedRespBody.Bytes()
+			}
+
+			if !bytes.Equal(compactedRespBodyAsBytes, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: %q, Given: %q", tt.expectedResponseBody, string(compactedRespBodyAsBytes))
+			}
+		})
+	}
+}
+
+func TestMagicLinkRedeemHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		requestBody          string
+		providerAccessToken  string
+		providerRefreshToken string
+		providerError        error
+		expectedEMail        string
+		expectedToken        string
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Happycase",
+			requestBody:          `{"email": "test.test@test.test", "magic_link_token": "myMagicLinkToken"}`,
+			providerAccessToken:  "myAccessJWT",
+			providerRefreshToken: "myRefreshJWT",
+			expectedEMail:        "test.test@test.test",
+			expectedToken:        "myMagicLinkToken",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"access_token":"myAccessJWT","refresh_token":"myRefreshJWT"}`,
+		},
+		{
+			name:                 "Invalid JSON",
+			requestBody:          `{"email test.test@test.test"}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"invalid JSON"}`,
+		},
+		{
+			name:                 "Missing email",
+			requestBody:          `{"magic_link_token": "myMagicLinkToken"}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"email must be set"}`,
+		},
+		{
+			name:                 "Missing magic-link-token",
+			requestBody:          `{"email": "test.test@test.test"}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"magic-link-token must be set"}`,
+		},
+		{
+			name:                 "Invalid token",
+			requestBody:          `{"email": "test.test@test.test", "magic_link_token": "myMagicLinkToken"}`,
+			providerError:        internal.ErrNoValidTokenFound,
+			expectedEMail:        "test.test@test.test",
+			expectedToken:        "myMagicLinkToken",
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"message":"magic-link-token is invalid or token email combination is not correct"}`,
+		},
+		{
+			name:                 "Disabled",
+			requestBody:          `{"email": "test.test@test.test", "magic_link_token": "myMagicLinkToken"}`,
+			providerError:        internal.ErrMagicLinkDisabled,
+			expectedEMail:        "test.test@test.test",
+			expectedToken:        "myMagicLinkToken",
+			expectedResponseCode: http.StatusNotFound,
+			expectedResponseBody: `{"message":"magic-link is not enabled"}`,
+		},
+		{
+			name:                 "Locked account",
+			requestBody:          `{"email": "test.test@test.test", "magic_link_token": "myMagicLinkToken"}`,
+			providerError:        internal.LockoutError{Err: internal.ErrAccountLocked, Until: time.Now().Add(time.Minute)},
+			expectedEMail:        "test.test@test.test",
+			expectedToken:        "myMagicLinkToken",
+			expectedResponseCode: http.StatusLocked,
+			expectedResponseBody: `{"message":"account is locked"}`,
+		},
+		{
+			name:                 "MFA required",
+			requestBody:          `{"email": "test.test@test.test", "magic_link_token": "myMagicLinkToken"}`,
+			providerError:        internal.MFARequiredError{Token: "myMFAToken", Methods: []string{internal.MFAMethodTOTP}},
+			expectedEMail:        "test.test@test.test",
+			expectedToken:        "myMagicLinkToken",
+			expectedResponseCode: http.StatusForbidden,
+			expectedResponseBody: `{"message":"mfa required","mfa_token":"myMFAToken","mfa_methods":["totp"]}`,
+		},
+		{
+			name:                 "Unexpected error",
+			requestBody:          `{"email": "test.test@test.test", "magic_link_token": "myMagicLinkToken"}`,
+			providerError:        errors.New("nope"),
+			expectedEMail:        "test.test@test.test",
+			expectedToken:        "myMagicLinkToken",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenEMail, givenToken string
+			var givenClient internal.ClientInfo
+
+			toTest := NewServer(&ProviderMock{
+				RedeemMagicLinkFunc: func(email string, magicLinkToken string, client internal.ClientInfo) (string, string, error) {
+					givenEMail = email
+					givenToken = magicLinkToken
+					givenClient = client
+
+					return tt.providerAccessToken, tt.providerRefreshToken, tt.providerError
+				},
+			}, false, "", "", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+
+			bb := bytes.NewReader([]byte(tt.requestBody))
+			req, err := http.NewRequest(http.MethodPost, testServer.URL+"/v1/auth/magic-link/redeem", bb)
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+			req.Header.Set("User-Agent", "my-agent")
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			if givenEMail != tt.expectedEMail {
+				t.Errorf("Provider called with unexpected email. Given: %q, Expected: %q", givenEMail, tt.expectedEMail)
+			}
+
+			if givenToken != tt.expectedToken {
+				t.Errorf("Provider called with unexpected magic-link-token. Given: %q, Expected: %q", givenToken, tt.expectedToken)
+			}
+
+			if tt.expectedEMail != "" && givenClient.UserAgent != "my-agent" {
+				t.Errorf("Provider called with unexpected client. Given: %#v", givenClient)
+			}
+
+			var compactedRespBodyAsBytes []byte
+			if resp.ContentLength > 0 {
+				compactedRespBody := &bytes.Buffer{}
+				err = json.Compact(compactedRespBody, respBody)
+				if err != nil {
+					t.Fatalf("Failed to compact json: %s", err)
+				}
+
+				compactedRespBodyAsBytes = compactedRespBody.Bytes()
+			}
+
+			if !bytes.Equal(compactedRespBodyAsBytes, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: %q, Given: %q", tt.expectedResponseBody, string(compactedRespBodyAsBytes))
+			}
+		})
+	}
+}
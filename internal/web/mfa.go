@@ -0,0 +1,305 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/leberKleber/simple-jwt-provider/internal"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"net/url"
+)
+
+type mfaRequiredResponseBody struct {
+	Message    string   `json:"message"`
+	MFAToken   string   `json:"mfa_token"`
+	MFAMethods []string `json:"mfa_methods"`
+}
+
+// writeMFARequired responds with http status 403 and the mfa-token which has to be passed to the mfa verification
+func writeMFARequired(w http.ResponseWriter, mfaErr internal.MFARequiredError) {
+	w.WriteHeader(http.StatusForbidden)
+	err := json.NewEncoder(w).Encode(mfaRequiredResponseBody{
+		Message:    internal.ErrMFARequired.Error(),
+		MFAToken:   mfaErr.Token,
+		MFAMethods: mfaErr.Methods,
+	})
+	if err != nil {
+		logrus.WithError(err).Error("Failed to write mfa required response")
+	}
+}
+
+// totpEnrollHandler generates a new TOTP secret for the user who is authenticated by the access-token in the
+// Authorization header and the current password
+func (s *Server) totpEnrollHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, ok := requireBearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	requestBody := struct {
+		Password string `json:"password"`
+	}{}
+
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	if requestBody.Password == "" {
+		writeError(w, http.StatusBadRequest, "password must be set")
+		return
+	}
+
+	enrollment, err := s.p.EnrollTOTP(accessToken, requestBody.Password, s.clientInfo(r))
+	if err != nil {
+		if errors.Is(err, internal.ErrInvalidToken) {
+			writeInvalidAccessToken(w)
+			return
+		}
+		if errors.Is(err, internal.ErrIncorrectPassword) {
+			writeError(w, http.StatusForbidden, "password is incorrect")
+			return
+		}
+		if writeLockoutError(w, err, "") {
+			return
+		}
+		if errors.Is(err, internal.ErrMFAAlreadyEnabled) {
+			writeError(w, http.StatusConflict, "totp is already enabled")
+			return
+		}
+
+		logrus.WithError(err).Error("Failed to enroll totp")
+		writeInternalServerError(w)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(struct {
+		Secret string `json:"secret"`
+		URI    string `json:"uri"`
+	}{
+		Secret: enrollment.Secret,
+		URI:    enrollment.URI,
+	})
+	if err != nil {
+		logrus.WithError(err).Error("Failed marshal request response")
+		writeInternalServerError(w)
+		return
+	}
+}
+
+// totpConfirmHandler enables the enrolled TOTP authenticator of the user who is authenticated by the access-token in
+// the Authorization header and responds with the recovery codes
+func (s *Server) totpConfirmHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, ok := requireBearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	requestBody := struct {
+		Code string `json:"code"`
+	}{}
+
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	if requestBody.Code == "" {
+		writeError(w, http.StatusBadRequest, "code must be set")
+		return
+	}
+
+	recoveryCodes, err := s.p.ConfirmTOTP(accessToken, requestBody.Code)
+	if err != nil {
+		if errors.Is(err, internal.ErrInvalidToken) {
+			writeInvalidAccessToken(w)
+			return
+		}
+		if errors.Is(err, internal.ErrMFAAlreadyEnabled) {
+			writeError(w, http.StatusConflict, "totp is already enabled")
+			return
+		}
+		if errors.Is(err, internal.ErrMFANotEnrolled) {
+			writeError(w, http.StatusBadRequest, "totp is not enrolled")
+			return
+		}
+		if errors.Is(err, internal.ErrIncorrectMFACode) {
+			writeError(w, http.StatusBadRequest, "code is incorrect")
+			return
+		}
+
+		logrus.WithError(err).Error("Failed to confirm totp")
+		writeInternalServerError(w)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{
+		RecoveryCodes: recoveryCodes,
+	})
+	if err != nil {
+		logrus.WithError(err).Error("Failed marshal request response")
+		writeInternalServerError(w)
+		return
+	}
+}
+
+// emailOTPEnrollHandler sends a one-time code to the email of the user who is authenticated by the access-token in the
+// Authorization header
+func (s *Server) emailOTPEnrollHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, ok := requireBearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.p.EnrollEMailOTP(accessToken)
+	if err != nil {
+		if errors.Is(err, internal.ErrEMailOTPDisabled) {
+			writeError(w, http.StatusNotFound, "email one-time codes are not enabled")
+			return
+		}
+		if errors.Is(err, internal.ErrInvalidToken) {
+			writeInvalidAccessToken(w)
+			return
+		}
+		if errors.Is(err, internal.ErrMFAAlreadyEnabled) {
+			writeError(w, http.StatusConflict, "email one-time codes are already enabled")
+			return
+		}
+
+		logrus.WithError(err).Error("Failed to enroll email one-time codes")
+		writeInternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// emailOTPConfirmHandler enables one-time codes via email for the user who is authenticated by the access-token in the
+// Authorization header
+func (s *Server) emailOTPConfirmHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, ok := requireBearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	requestBody := struct {
+		Code string `json:"code"`
+	}{}
+
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	if requestBody.Code == "" {
+		writeError(w, http.StatusBadRequest, "code must be set")
+		return
+	}
+
+	err = s.p.ConfirmEMailOTP(accessToken, requestBody.Code)
+	if err != nil {
+		if errors.Is(err, internal.ErrEMailOTPDisabled) {
+			writeError(w, http.StatusNotFound, "email one-time codes are not enabled")
+			return
+		}
+		if errors.Is(err, internal.ErrInvalidToken) {
+			writeInvalidAccessToken(w)
+			return
+		}
+		if errors.Is(err, internal.ErrMFAAlreadyEnabled) {
+			writeError(w, http.StatusConflict, "email one-time codes are already enabled")
+			return
+		}
+		if errors.Is(err, internal.ErrIncorrectMFACode) {
+			writeError(w, http.StatusBadRequest, "code is incorrect")
+			return
+		}
+
+		logrus.WithError(err).Error("Failed to confirm email one-time codes")
+		writeInternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// mfaVerifyHandler completes a login which responded with 'mfa required'
+func (s *Server) mfaVerifyHandler(w http.ResponseWriter, r *http.Request) {
+	requestBody := struct {
+		EMail        string `json:"email"`
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}{}
+
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	if requestBody.EMail == "" {
+		writeError(w, http.StatusBadRequest, "email must be set")
+		return
+	}
+
+	if requestBody.MFAToken == "" {
+		writeError(w, http.StatusBadRequest, "mfa_token must be set")
+		return
+	}
+
+	if requestBody.Code == "" && requestBody.RecoveryCode == "" {
+		writeError(w, http.StatusBadRequest, "code or recovery_code must be set")
+		return
+	}
+
+	accessToken, refreshToken, err := s.p.VerifyMFA(requestBody.EMail, requestBody.MFAToken, requestBody.Code, requestBody.RecoveryCode, s.clientInfo(r))
+	if err != nil {
+		if writeLockoutError(w, err, requestBody.EMail) {
+			return
+		}
+		if errors.Is(err, internal.ErrNoValidTokenFound) {
+			writeError(w, http.StatusUnauthorized, "invalid mfa-token and/or email")
+			return
+		}
+		if errors.Is(err, internal.ErrIncorrectMFACode) {
+			logrus.WithField("email", requestBody.EMail).Warn("Somebody tried to verify mfa with an incorrect code")
+			writeError(w, http.StatusUnauthorized, "code is incorrect")
+			return
+		}
+
+		logrus.WithError(err).Error("Failed to verify mfa")
+		writeInternalServerError(w)
+		return
+	}
+
+	writeTokens(w, accessToken, refreshToken)
+}
+
+func (s *Server) disableMFAHandler(w http.ResponseWriter, r *http.Request) {
+	email, err := url.PathUnescape(mux.Vars(r)["email"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "could not unescape email")
+		return
+	}
+
+	err = s.p.DisableMFA(email)
+	if err != nil {
+		if errors.Is(err, internal.ErrUserNotFound) {
+			writeError(w, http.StatusNotFound, "User with given email doesn't exists")
+			return
+		}
+
+		logrus.WithError(err).Error("Failed to disable mfa")
+		writeInternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,720 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"github.com/leberKleber/simple-jwt-provider/internal"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTPEnrollHandler(t *testing.T) {
+	tests := []struct {
+		name                    string
+		authorization           string
+		requestBody             string
+		providerEnrollment      internal.TOTPEnrollment
+		providerError           error
+		expectedAccessToken     string
+		expectedPassword        string
+		expectedResponseCode    int
+		expectedResponseBody    string
+		expectedWWWAuthenticate string
+	}{
+		{
+			name:                 "Happycase",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"password": "s3cr3t"}`,
+			providerEnrollment:   internal.TOTPEnrollment{Secret: "MYSECRET", URI: "otpauth://totp/issuer:test@test.test?secret=MYSECRET"},
+			expectedAccessToken:  "myAccessToken",
+			expectedPassword:     "s3cr3t",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"secret":"MYSECRET","uri":"otpauth://totp/issuer:test@test.test?secret=MYSECRET"}`,
+		},
+		{
+			name:                    "Missing access-token",
+			expectedResponseCode:    http.StatusUnauthorized,
+			expectedResponseBody:    `{"message":"access-token must be set as bearer token"}`,
+			expectedWWWAuthenticate: "Bearer",
+		},
+		{
+			name:                    "Invalid access-token",
+			authorization:           "Bearer invalidAccessToken",
+			requestBody:             `{"password": "s3cr3t"}`,
+			providerError:           internal.ErrInvalidToken,
+			expectedAccessToken:     "invalidAccessToken",
+			expectedPassword:        "s3cr3t",
+			expectedResponseCode:    http.StatusUnauthorized,
+			expectedResponseBody:    `{"message":"invalid access-token"}`,
+			expectedWWWAuthenticate: `Bearer error="invalid_token"`,
+		},
+		{
+			name:                 "Missing password",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"password must be set"}`,
+		},
+		{
+			name:                 "Invalid JSON",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"invalid JSON"}`,
+		},
+		{
+			name:                 "Incorrect password",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"password": "s3cr3t"}`,
+			providerError:        internal.ErrIncorrectPassword,
+			expectedAccessToken:  "myAccessToken",
+			expectedPassword:     "s3cr3t",
+			expectedResponseCode: http.StatusForbidden,
+			expectedResponseBody: `{"message":"password is incorrect"}`,
+		},
+		{
+			name:                 "Already enabled",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"password": "s3cr3t"}`,
+			providerError:        internal.ErrMFAAlreadyEnabled,
+			expectedAccessToken:  "myAccessToken",
+			expectedPassword:     "s3cr3t",
+			expectedResponseCode: http.StatusConflict,
+			expectedResponseBody: `{"message":"totp is already enabled"}`,
+		},
+		{
+			name:                 "Unexpected error",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"password": "s3cr3t"}`,
+			providerError:        errors.New("nope"),
+			expectedAccessToken:  "myAccessToken",
+			expectedPassword:     "s3cr3t",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenAccessToken, givenPassword string
+
+			toTest := NewServer(&ProviderMock{
+				EnrollTOTPFunc: func(accessToken, password string, client internal.ClientInfo) (internal.TOTPEnrollment, error) {
+					givenAccessToken = accessToken
+					givenPassword = password
+					return tt.providerEnrollment, tt.providerError
+				},
+			}, false, "", "", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			req, err := http.NewRequest(http.MethodPost, testServer.URL+"/v1/auth/mfa/totp", strings.NewReader(tt.requestBody))
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			if wwwAuthenticate := resp.Header.Get("WWW-Authenticate"); wwwAuthenticate != tt.expectedWWWAuthenticate {
+				t.Errorf("Unexpected WWW-Authenticate header. Expected: %q, Given: %q", tt.expectedWWWAuthenticate, wwwAuthenticate)
+			}
+
+			if givenAccessToken != tt.expectedAccessToken {
+				t.Errorf("Provider called with unexpected access-token. Expected: %q, Given: %q", tt.expectedAccessToken, givenAccessToken)
+			}
+
+			if givenPassword != tt.expectedPassword {
+				t.Errorf("Provider called with unexpected password. Expected: %q, Given: %q", tt.expectedPassword, givenPassword)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			respBody = bytes.TrimSpace(respBody)
+			if !bytes.Equal(respBody, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: \n%q, \nGiven: \n%q", tt.expectedResponseBody, string(respBody))
+			}
+		})
+	}
+}
+
+func TestTOTPConfirmHandler(t *testing.T) {
+	tests := []struct {
+		name                  string
+		authorization         string
+		requestBody           string
+		providerRecoveryCodes []string
+		providerError         error
+		expectedAccessToken   string
+		expectedCode          string
+		expectedResponseCode  int
+		expectedResponseBody  string
+	}{
+		{
+			name:                  "Happycase",
+			authorization:         "Bearer myAccessToken",
+			requestBody:           `{"code": "123456"}`,
+			providerRecoveryCodes: []string{"abcd-efgh", "ijkl-mnop"},
+			expectedAccessToken:   "myAccessToken",
+			expectedCode:          "123456",
+			expectedResponseCode:  http.StatusOK,
+			expectedResponseBody:  `{"recovery_codes":["abcd-efgh","ijkl-mnop"]}`,
+		},
+		{
+			name:                 "Missing access-token",
+			requestBody:          `{"code": "123456"}`,
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"message":"access-token must be set as bearer token"}`,
+		},
+		{
+			name:                 "Invalid JSON",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"code 123456}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"invalid JSON"}`,
+		},
+		{
+			name:                 "Missing code",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"code must be set"}`,
+		},
+		{
+			name:                 "Invalid access-token",
+			authorization:        "Bearer invalidAccessToken",
+			requestBody:          `{"code": "123456"}`,
+			providerError:        internal.ErrInvalidToken,
+			expectedAccessToken:  "invalidAccessToken",
+			expectedCode:         "123456",
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"message":"invalid access-token"}`,
+		},
+		{
+			name:                 "Already enabled",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"code": "123456"}`,
+			providerError:        internal.ErrMFAAlreadyEnabled,
+			expectedAccessToken:  "myAccessToken",
+			expectedCode:         "123456",
+			expectedResponseCode: http.StatusConflict,
+			expectedResponseBody: `{"message":"totp is already enabled"}`,
+		},
+		{
+			name:                 "Not enrolled",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"code": "123456"}`,
+			providerError:        internal.ErrMFANotEnrolled,
+			expectedAccessToken:  "myAccessToken",
+			expectedCode:         "123456",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"totp is not enrolled"}`,
+		},
+		{
+			name:                 "Incorrect code",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"code": "654321"}`,
+			providerError:        internal.ErrIncorrectMFACode,
+			expectedAccessToken:  "myAccessToken",
+			expectedCode:         "654321",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"code is incorrect"}`,
+		},
+		{
+			name:                 "Unexpected error",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"code": "123456"}`,
+			providerError:        errors.New("nope"),
+			expectedAccessToken:  "myAccessToken",
+			expectedCode:         "123456",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenAccessToken, givenCode string
+
+			toTest := NewServer(&ProviderMock{
+				ConfirmTOTPFunc: func(accessToken string, code string) ([]string, error) {
+					givenAccessToken = accessToken
+					givenCode = code
+					return tt.providerRecoveryCodes, tt.providerError
+				},
+			}, false, "", "", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			req, err := http.NewRequest(http.MethodPost, testServer.URL+"/v1/auth/mfa/totp/confirm", bytes.NewReader([]byte(tt.requestBody)))
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			if givenAccessToken != tt.expectedAccessToken || givenCode != tt.expectedCode {
+				t.Errorf("Provider called with unexpected params. Given: %q %q, Expected: %q %q", givenAccessToken, givenCode, tt.expectedAccessToken, tt.expectedCode)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			respBody = bytes.TrimSpace(respBody)
+			if !bytes.Equal(respBody, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: \n%q, \nGiven: \n%q", tt.expectedResponseBody, string(respBody))
+			}
+		})
+	}
+}
+
+func TestMFAVerifyHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		requestBody          string
+		providerAccessToken  string
+		providerRefreshToken string
+		providerError        error
+		expectedEMail        string
+		expectedMFAToken     string
+		expectedCode         string
+		expectedRecoveryCode string
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Happycase",
+			requestBody:          `{"email": "test@test.test", "mfa_token": "myMFAToken", "code": "123456"}`,
+			providerAccessToken:  "myAccessJWT",
+			providerRefreshToken: "myRefreshJWT",
+			expectedEMail:        "test@test.test",
+			expectedMFAToken:     "myMFAToken",
+			expectedCode:         "123456",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"access_token":"myAccessJWT","refresh_token":"myRefreshJWT"}`,
+		},
+		{
+			name:                 "Happycase with recovery code",
+			requestBody:          `{"email": "test@test.test", "mfa_token": "myMFAToken", "recovery_code": "abcd-efgh"}`,
+			providerAccessToken:  "myAccessJWT",
+			providerRefreshToken: "myRefreshJWT",
+			expectedEMail:        "test@test.test",
+			expectedMFAToken:     "myMFAToken",
+			expectedRecoveryCode: "abcd-efgh",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"access_token":"myAccessJWT","refresh_token":"myRefreshJWT"}`,
+		},
+		{
+			name:                 "Invalid JSON",
+			requestBody:          `{"email test@test.test}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"invalid JSON"}`,
+		},
+		{
+			name:                 "Missing email",
+			requestBody:          `{"mfa_token": "myMFAToken", "code": "123456"}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"email must be set"}`,
+		},
+		{
+			name:                 "Missing mfa-token",
+			requestBody:          `{"email": "test@test.test", "code": "123456"}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"mfa_token must be set"}`,
+		},
+		{
+			name:                 "Missing code",
+			requestBody:          `{"email": "test@test.test", "mfa_token": "myMFAToken"}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"code or recovery_code must be set"}`,
+		},
+		{
+			name:                 "Invalid mfa-token",
+			requestBody:          `{"email": "test@test.test", "mfa_token": "invalid", "code": "123456"}`,
+			providerError:        internal.ErrNoValidTokenFound,
+			expectedEMail:        "test@test.test",
+			expectedMFAToken:     "invalid",
+			expectedCode:         "123456",
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"message":"invalid mfa-token and/or email"}`,
+		},
+		{
+			name:                 "Incorrect code",
+			requestBody:          `{"email": "test@test.test", "mfa_token": "myMFAToken", "code": "654321"}`,
+			providerError:        internal.ErrIncorrectMFACode,
+			expectedEMail:        "test@test.test",
+			expectedMFAToken:     "myMFAToken",
+			expectedCode:         "654321",
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"message":"code is incorrect"}`,
+		},
+		{
+			name:                 "Locked account",
+			requestBody:          `{"email": "test@test.test", "mfa_token": "myMFAToken", "code": "123456"}`,
+			providerError:        internal.LockoutError{Err: internal.ErrAccountLocked, Until: time.Now().Add(time.Minute)},
+			expectedEMail:        "test@test.test",
+			expectedMFAToken:     "myMFAToken",
+			expectedCode:         "123456",
+			expectedResponseCode: http.StatusLocked,
+			expectedResponseBody: `{"message":"account is locked"}`,
+		},
+		{
+			name:                 "Unexpected error",
+			requestBody:          `{"email": "test@test.test", "mfa_token": "myMFAToken", "code": "123456"}`,
+			providerError:        errors.New("nope"),
+			expectedEMail:        "test@test.test",
+			expectedMFAToken:     "myMFAToken",
+			expectedCode:         "123456",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenEMail, givenMFAToken, givenCode, givenRecoveryCode string
+
+			toTest := NewServer(&ProviderMock{
+				VerifyMFAFunc: func(email string, mfaToken string, code string, recoveryCode string, client internal.ClientInfo) (string, string, error) {
+					givenEMail = email
+					givenMFAToken = mfaToken
+					givenCode = code
+					givenRecoveryCode = recoveryCode
+					return tt.providerAccessToken, tt.providerRefreshToken, tt.providerError
+				},
+			}, false, "", "", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			resp, err := http.Post(testServer.URL+"/v1/auth/mfa/verify", "application/json", bytes.NewReader([]byte(tt.requestBody)))
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			if givenEMail != tt.expectedEMail || givenMFAToken != tt.expectedMFAToken || givenCode != tt.expectedCode || givenRecoveryCode != tt.expectedRecoveryCode {
+				t.Errorf("Provider called with unexpected params. Given: %q %q %q %q, Expected: %q %q %q %q", givenEMail, givenMFAToken, givenCode, givenRecoveryCode, tt.expectedEMail, tt.expectedMFAToken, tt.expectedCode, tt.expectedRecoveryCode)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			respBody = bytes.TrimSpace(respBody)
+			if !bytes.Equal(respBody, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: \n%q, \nGiven: \n%q", tt.expectedResponseBody, string(respBody))
+			}
+		})
+	}
+}
+
+func TestDisableMFAHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		providerError        error
+		expectedResponseBody string
+		expectedResponseCode int
+	}{
+		{
+			name:                 "Happycase",
+			expectedResponseCode: http.StatusNoContent,
+		},
+		{
+			name:                 "User not found",
+			providerError:        internal.ErrUserNotFound,
+			expectedResponseCode: http.StatusNotFound,
+			expectedResponseBody: `{"message":"User with given email doesn't exists"}`,
+		},
+		{
+			name:                 "Unexpected error",
+			providerError:        errors.New("nope"),
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenEMail string
+
+			toTest := NewServer(&ProviderMock{
+				DisableMFAFunc: func(email string) error {
+					givenEMail = email
+					return tt.providerError
+				},
+			}, true, "username", "password", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			req, err := http.NewRequest(http.MethodDelete, testServer.URL+"/v1/admin/users/test@test.test/mfa", nil)
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+			req.SetBasicAuth("username", "password")
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			if givenEMail != "test@test.test" {
+				t.Errorf("Unexpected user. Expected: %q, Given: %q", "test@test.test", givenEMail)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			respBody = bytes.TrimSpace(respBody)
+			if !bytes.Equal(respBody, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: \n%q, \nGiven: \n%q", tt.expectedResponseBody, string(respBody))
+			}
+		})
+	}
+}
+
+func TestEMailOTPEnrollHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		authorization        string
+		providerError        error
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Happycase",
+			authorization:        "Bearer myAccessToken",
+			expectedResponseCode: http.StatusAccepted,
+		},
+		{
+			name:                 "Missing access-token",
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"message":"access-token must be set as bearer token"}`,
+		},
+		{
+			name:                 "Invalid access-token",
+			authorization:        "Bearer invalidAccessToken",
+			providerError:        internal.ErrInvalidToken,
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"message":"invalid access-token"}`,
+		},
+		{
+			name:                 "Already enabled",
+			authorization:        "Bearer myAccessToken",
+			providerError:        internal.ErrMFAAlreadyEnabled,
+			expectedResponseCode: http.StatusConflict,
+			expectedResponseBody: `{"message":"email one-time codes are already enabled"}`,
+		},
+		{
+			name:                 "Disabled",
+			authorization:        "Bearer myAccessToken",
+			providerError:        internal.ErrEMailOTPDisabled,
+			expectedResponseCode: http.StatusNotFound,
+			expectedResponseBody: `{"message":"email one-time codes are not enabled"}`,
+		},
+		{
+			name:                 "Unexpected error",
+			authorization:        "Bearer myAccessToken",
+			providerError:        errors.New("nope"),
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toTest := NewServer(&ProviderMock{
+				EnrollEMailOTPFunc: func(accessToken string) error {
+					return tt.providerError
+				},
+			}, false, "", "", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			req, err := http.NewRequest(http.MethodPost, testServer.URL+"/v1/auth/mfa/email", nil)
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			respBody = bytes.TrimSpace(respBody)
+			if !bytes.Equal(respBody, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: \n%q, \nGiven: \n%q", tt.expectedResponseBody, string(respBody))
+			}
+		})
+	}
+}
+
+func TestEMailOTPConfirmHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		authorization        string
+		requestBody          string
+		providerError        error
+		expectedCode         string
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Happycase",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"code": "123456"}`,
+			expectedCode:         "123456",
+			expectedResponseCode: http.StatusNoContent,
+		},
+		{
+			name:                 "Missing access-token",
+			requestBody:          `{"code": "123456"}`,
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"message":"access-token must be set as bearer token"}`,
+		},
+		{
+			name:                 "Missing code",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"code must be set"}`,
+		},
+		{
+			name:                 "Already enabled",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"code": "123456"}`,
+			providerError:        internal.ErrMFAAlreadyEnabled,
+			expectedCode:         "123456",
+			expectedResponseCode: http.StatusConflict,
+			expectedResponseBody: `{"message":"email one-time codes are already enabled"}`,
+		},
+		{
+			name:                 "Disabled",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"code": "123456"}`,
+			providerError:        internal.ErrEMailOTPDisabled,
+			expectedCode:         "123456",
+			expectedResponseCode: http.StatusNotFound,
+			expectedResponseBody: `{"message":"email one-time codes are not enabled"}`,
+		},
+		{
+			name:                 "Incorrect code",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"code": "654321"}`,
+			providerError:        internal.ErrIncorrectMFACode,
+			expectedCode:         "654321",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"code is incorrect"}`,
+		},
+		{
+			name:                 "Unexpected error",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"code": "123456"}`,
+			providerError:        errors.New("nope"),
+			expectedCode:         "123456",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenCode string
+
+			toTest := NewServer(&ProviderMock{
+				ConfirmEMailOTPFunc: func(accessToken string, code string) error {
+					givenCode = code
+					return tt.providerError
+				},
+			}, false, "", "", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			req, err := http.NewRequest(http.MethodPost, testServer.URL+"/v1/auth/mfa/email/confirm", bytes.NewReader([]byte(tt.requestBody)))
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			if givenCode != tt.expectedCode {
+				t.Errorf("Provider called with unexpected code. Expected: %q, Given: %q", tt.expectedCode, givenCode)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			respBody = bytes.TrimSpace(respBody)
+			if !bytes.Equal(respBody, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: \n%q, \nGiven: \n%q", tt.expectedResponseBody, string(respBody))
+			}
+		})
+	}
+}
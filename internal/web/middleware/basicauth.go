@@ -12,11 +12,12 @@ const bcryptedPasswordPrefix = "bcrypt:"
 // BasicAuth builds a basic auth http.Handler middleware which blocks all unauthorized request and respond with a
 // http status 403
 func BasicAuth(username, password string) func(h http.Handler) http.Handler {
-	passwordIsBcrypted := strings.HasPrefix(password, bcryptedPasswordPrefix)
-	if passwordIsBcrypted {
-		password = strings.Replace(password, bcryptedPasswordPrefix, "", 1)
-	}
+	return MultiBasicAuth(map[string]string{username: password})
+}
 
+// MultiBasicAuth builds a basic auth http.Handler middleware like BasicAuth which accepts all given credentials.
+// credentials maps usernames to their (plain or 'bcrypt:' prefixed) passwords.
+func MultiBasicAuth(credentials map[string]string) func(h http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			u, p, ok := r.BasicAuth()
@@ -25,29 +26,26 @@ func BasicAuth(username, password string) func(h http.Handler) http.Handler {
 				return
 			}
 
-			if u != username {
+			password, found := credentials[u]
+			if !found || !passwordMatches(password, p) {
 				unauthorized(w)
 				return
 			}
 
-			if passwordIsBcrypted {
-				err := bcrypt.CompareHashAndPassword([]byte(password), []byte(p))
-				if err != nil {
-					unauthorized(w)
-					return
-				}
-			} else {
-				if p != password {
-					unauthorized(w)
-					return
-				}
-			}
-
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+func passwordMatches(configuredPassword, givenPassword string) bool {
+	if strings.HasPrefix(configuredPassword, bcryptedPasswordPrefix) {
+		hash := strings.Replace(configuredPassword, bcryptedPasswordPrefix, "", 1)
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(givenPassword)) == nil
+	}
+
+	return configuredPassword == givenPassword
+}
+
 func unauthorized(w http.ResponseWriter) {
 	w.WriteHeader(http.StatusForbidden)
 	_, err := w.Write([]byte(`{"message": "forbidden"}`))
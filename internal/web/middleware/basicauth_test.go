@@ -119,3 +119,48 @@ func TestBasicAuth(t *testing.T) {
 		})
 	}
 }
+
+func TestMultiBasicAuth(t *testing.T) {
+	credentials := map[string]string{
+		"client-a": "secret-a",
+		"client-b": "bcrypt:$2y$12$YLjvF/KRsQ6999oazNXBR.DvZ3K2t8boyPFgXt84PFt4yLN3zVKw2",
+	}
+
+	tests := []struct {
+		name                      string
+		requestUsername           string
+		requestPassword           string
+		expectedNextHasBeenCalled bool
+	}{
+		{name: "First client", requestUsername: "client-a", requestPassword: "secret-a", expectedNextHasBeenCalled: true},
+		{name: "Second client bcrypted", requestUsername: "client-b", requestPassword: "myPassword", expectedNextHasBeenCalled: true},
+		{name: "Password of other client", requestUsername: "client-a", requestPassword: "myPassword", expectedNextHasBeenCalled: false},
+		{name: "Unknown client", requestUsername: "client-c", requestPassword: "secret-a", expectedNextHasBeenCalled: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nextHasBeenCalled := false
+
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("GET", "/", nil)
+			if err != nil {
+				t.Fatalf("Failed to create test request: %s", err)
+			}
+			r.SetBasicAuth(tt.requestUsername, tt.requestPassword)
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				nextHasBeenCalled = true
+			})
+
+			MultiBasicAuth(credentials)(next).ServeHTTP(w, r)
+
+			if tt.expectedNextHasBeenCalled != nextHasBeenCalled {
+				t.Errorf("Call of next handler is not as expected. Given: %t, Exected: %t", nextHasBeenCalled, tt.expectedNextHasBeenCalled)
+			}
+
+			if !tt.expectedNextHasBeenCalled && w.Code != http.StatusForbidden {
+				t.Errorf("Unexpected response code. Given: %d, Expected: %d", w.Code, http.StatusForbidden)
+			}
+		})
+	}
+}
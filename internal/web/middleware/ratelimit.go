@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var timeNow = time.Now
+
+// KeyFunc extracts the key of a request which should be rate limited. Empty keys will not be limited.
+type KeyFunc func(r *http.Request) string
+
+// RateLimiter is an in-memory token bucket rate limiter. Each key gets its own bucket which holds up to burst tokens
+// and will be refilled with burst tokens per interval.
+type RateLimiter struct {
+	burst     float64
+	rate      float64 // tokens per nanosecond
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPurge time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewRateLimiter returns a RateLimiter which allows burst requests per key and interval
+func NewRateLimiter(burst int, interval time.Duration) *RateLimiter {
+	return &RateLimiter{
+		burst:   float64(burst),
+		rate:    float64(burst) / float64(interval),
+		buckets: map[string]*bucket{},
+	}
+}
+
+// Allow takes a token from the bucket of the given key. When the bucket is empty it returns false and the duration
+// until the next token is available.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := timeNow()
+	l.purge(now)
+
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+float64(now.Sub(b.updated))*l.rate)
+	b.updated = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate)
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+// purge removes all buckets which are refilled completely to keep the memory usage bounded. It runs at most once per
+// refill interval.
+func (l *RateLimiter) purge(now time.Time) {
+	refillDuration := time.Duration(l.burst / l.rate)
+	if now.Sub(l.lastPurge) < refillDuration {
+		return
+	}
+	l.lastPurge = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= refillDuration {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RateLimit builds a http.Handler middleware which limits the requests per key of each given KeyFunc with the given
+// RateLimiter. Limited requests will be responded with http status 429 and a Retry-After header.
+func RateLimit(limiter *RateLimiter, keyFuncs ...KeyFunc) func(h http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for i, keyFunc := range keyFuncs {
+				key := keyFunc(r)
+				if key == "" {
+					continue
+				}
+
+				allowed, retryAfter := limiter.Allow(strconv.Itoa(i) + ":" + key)
+				if !allowed {
+					tooManyRequests(w, retryAfter)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP returns a KeyFunc which resolves the ip of the requesting client. The remote address of the connection will
+// be used unless it belongs to one of the given trustedProxies. In that case the right-most X-Forwarded-For entry which
+// is not a trusted proxy will be used.
+func ClientIP(trustedProxies []*net.IPNet) KeyFunc {
+	return func(r *http.Request) string {
+		ip := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			ip = host
+		}
+
+		if !trusted(ip, trustedProxies) {
+			return ip
+		}
+
+		forwardedFor := strings.Split(strings.Join(r.Header["X-Forwarded-For"], ","), ",")
+		for i := len(forwardedFor) - 1; i >= 0; i-- {
+			forwarded := strings.TrimSpace(forwardedFor[i])
+			if forwarded == "" {
+				continue
+			}
+			ip = forwarded
+			if !trusted(ip, trustedProxies) {
+				break
+			}
+		}
+
+		return ip
+	}
+}
+
+func trusted(ip string, trustedProxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, proxy := range trustedProxies {
+		if proxy.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// maxJSONBodySize is the maximum number of bytes which will be read by JSONBodyKey
+const maxJSONBodySize = 64 << 10
+
+// JSONBodyKey returns a KeyFunc which extracts the given string field of a json request body. The body stays readable
+// for the following handlers. Bodies larger than maxJSONBodySize will be truncated.
+func JSONBodyKey(field string) KeyFunc {
+	return func(r *http.Request) string {
+		if r.Body == nil {
+			return ""
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, maxJSONBodySize))
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return ""
+		}
+
+		var fields map[string]interface{}
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return ""
+		}
+
+		value, _ := fields[field].(string)
+		return strings.ToLower(value)
+	}
+}
+
+func tooManyRequests(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	w.WriteHeader(http.StatusTooManyRequests)
+	_, err := w.Write([]byte(`{"message": "too many requests"}`))
+	if err != nil {
+		logrus.WithError(err).Error("Failed to write too many requests http response body")
+	}
+}
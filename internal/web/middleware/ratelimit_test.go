@@ -0,0 +1,226 @@
+package middleware
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	timeNow = func() time.Time { return now }
+
+	limiter := NewRateLimiter(2, time.Minute)
+
+	steps := []struct {
+		name               string
+		key                string
+		elapsed            time.Duration
+		expectedAllowed    bool
+		expectedRetryAfter time.Duration
+	}{
+		{name: "first request", key: "a", expectedAllowed: true},
+		{name: "second request", key: "a", expectedAllowed: true},
+		{name: "bucket exhausted", key: "a", expectedAllowed: false, expectedRetryAfter: 30 * time.Second},
+		{name: "other key", key: "b", expectedAllowed: true},
+		{name: "partially refilled", key: "a", elapsed: 10 * time.Second, expectedAllowed: false, expectedRetryAfter: 20 * time.Second},
+		{name: "one token refilled", key: "a", elapsed: 20 * time.Second, expectedAllowed: true},
+		{name: "refilled token consumed", key: "a", expectedAllowed: false, expectedRetryAfter: 30 * time.Second},
+	}
+
+	for _, s := range steps {
+		now = now.Add(s.elapsed)
+		allowed, retryAfter := limiter.Allow(s.key)
+		if allowed != s.expectedAllowed {
+			t.Fatalf("%s: allowed is not as expected. Expected: %t, Given: %t", s.name, s.expectedAllowed, allowed)
+		}
+		if retryAfter != s.expectedRetryAfter {
+			t.Fatalf("%s: retryAfter is not as expected. Expected: %s, Given: %s", s.name, s.expectedRetryAfter, retryAfter)
+		}
+	}
+}
+
+func TestRateLimiter_AllowPurgesRefilledBuckets(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	timeNow = func() time.Time { return now }
+
+	limiter := NewRateLimiter(2, time.Minute)
+	limiter.Allow("a")
+	now = now.Add(time.Minute)
+	limiter.Allow("b")
+
+	if len(limiter.buckets) != 1 {
+		t.Fatalf("expected refilled buckets to be purged. Given buckets: %d", len(limiter.buckets))
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	tests := []struct {
+		name                      string
+		requests                  []*http.Request
+		expectedNextHasBeenCalled bool
+		expectedResponseStatus    int
+		expectedRetryAfter        string
+	}{
+		{
+			name:                      "Happycase",
+			requests:                  []*http.Request{loginRequest("10.0.0.1", "info@leberkleber.io")},
+			expectedNextHasBeenCalled: true,
+			expectedResponseStatus:    http.StatusOK,
+		},
+		{
+			name: "Limited by client ip",
+			requests: []*http.Request{
+				loginRequest("10.0.0.1", "info@leberkleber.io"),
+				loginRequest("10.0.0.1", "other@leberkleber.io"),
+			},
+			expectedNextHasBeenCalled: false,
+			expectedResponseStatus:    http.StatusTooManyRequests,
+			expectedRetryAfter:        "60",
+		},
+		{
+			name: "Limited by email",
+			requests: []*http.Request{
+				loginRequest("10.0.0.1", "info@leberkleber.io"),
+				loginRequest("10.0.0.2", "Info@leberkleber.io"),
+			},
+			expectedNextHasBeenCalled: false,
+			expectedResponseStatus:    http.StatusTooManyRequests,
+			expectedRetryAfter:        "60",
+		},
+		{
+			name: "Different client ip and email",
+			requests: []*http.Request{
+				loginRequest("10.0.0.1", "info@leberkleber.io"),
+				loginRequest("10.0.0.2", "other@leberkleber.io"),
+			},
+			expectedNextHasBeenCalled: true,
+			expectedResponseStatus:    http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var nextHasBeenCalled bool
+			var nextBody string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				nextHasBeenCalled = true
+				body, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					t.Fatalf("Failed to read request body: %s", err)
+				}
+				nextBody = string(body)
+			})
+
+			h := RateLimit(NewRateLimiter(1, time.Minute), ClientIP(nil), JSONBodyKey("email"))(next)
+
+			var w *httptest.ResponseRecorder
+			for _, r := range tt.requests {
+				nextHasBeenCalled = false
+				w = httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+			}
+
+			if nextHasBeenCalled != tt.expectedNextHasBeenCalled {
+				t.Errorf("next handler has been called is not as expected. Expected: %t, Given: %t", tt.expectedNextHasBeenCalled, nextHasBeenCalled)
+			}
+			if nextHasBeenCalled && !strings.Contains(nextBody, `"email"`) {
+				t.Errorf("request body has not been passed to the next handler. Given: %q", nextBody)
+			}
+			if w.Code != tt.expectedResponseStatus {
+				t.Errorf("response status is not as expected. Expected: %d, Given: %d", tt.expectedResponseStatus, w.Code)
+			}
+			if retryAfter := w.Header().Get("Retry-After"); retryAfter != tt.expectedRetryAfter {
+				t.Errorf("Retry-After header is not as expected. Expected: %q, Given: %q", tt.expectedRetryAfter, retryAfter)
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("failed to parse cidr: %s", err)
+	}
+	trustedProxies := []*net.IPNet{proxies}
+
+	tests := []struct {
+		name           string
+		trustedProxies []*net.IPNet
+		remoteAddr     string
+		forwardedFor   string
+		expectedIP     string
+	}{
+		{name: "Remote address", remoteAddr: "10.0.0.1:1234", expectedIP: "10.0.0.1"},
+		{name: "Remote address without port", remoteAddr: "10.0.0.1", expectedIP: "10.0.0.1"},
+		{name: "X-Forwarded-For of untrusted remote", remoteAddr: "192.168.0.2:1234", forwardedFor: "192.168.0.1", expectedIP: "192.168.0.2"},
+		{name: "X-Forwarded-For without trusted proxies", remoteAddr: "10.0.0.1:1234", forwardedFor: "192.168.0.1", expectedIP: "10.0.0.1"},
+		{name: "X-Forwarded-For of trusted proxy", trustedProxies: trustedProxies, remoteAddr: "10.0.0.1:1234", forwardedFor: "192.168.0.1", expectedIP: "192.168.0.1"},
+		{name: "X-Forwarded-For with spoofed entry", trustedProxies: trustedProxies, remoteAddr: "10.0.0.1:1234", forwardedFor: "1.2.3.4, 192.168.0.1", expectedIP: "192.168.0.1"},
+		{name: "X-Forwarded-For with chained proxies", trustedProxies: trustedProxies, remoteAddr: "10.0.0.1:1234", forwardedFor: "192.168.0.1, 10.0.0.2", expectedIP: "192.168.0.1"},
+		{name: "X-Forwarded-For of trusted proxies only", trustedProxies: trustedProxies, remoteAddr: "10.0.0.1:1234", forwardedFor: "10.0.0.3, 10.0.0.2", expectedIP: "10.0.0.3"},
+		{name: "Trusted proxy without X-Forwarded-For", trustedProxies: trustedProxies, remoteAddr: "10.0.0.1:1234", expectedIP: "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+
+			if ip := ClientIP(tt.trustedProxies)(r); ip != tt.expectedIP {
+				t.Errorf("client ip is not as expected. Expected: %q, Given: %q", tt.expectedIP, ip)
+			}
+		})
+	}
+}
+
+func TestJSONBodyKey(t *testing.T) {
+	tooLargeBody := `{"email":"info@leberkleber.io","padding":"` + strings.Repeat("a", maxJSONBodySize) + `"}`
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedKey  string
+		expectedBody string
+	}{
+		{name: "Happycase", body: `{"email":"Info@LeberKleber.io"}`, expectedKey: "info@leberkleber.io", expectedBody: `{"email":"Info@LeberKleber.io"}`},
+		{name: "Missing field", body: `{"name":"leberKleber"}`, expectedKey: "", expectedBody: `{"name":"leberKleber"}`},
+		{name: "Invalid json", body: `{"email":`, expectedKey: "", expectedBody: `{"email":`},
+		{name: "Too large body", body: tooLargeBody, expectedKey: "", expectedBody: tooLargeBody[:maxJSONBodySize]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+
+			if key := JSONBodyKey("email")(r); key != tt.expectedKey {
+				t.Errorf("key is not as expected. Expected: %q, Given: %q", tt.expectedKey, key)
+			}
+
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Fatalf("failed to read body: %s", err)
+			}
+			if string(body) != tt.expectedBody {
+				t.Errorf("body is not as expected. Expected %d bytes, Given %d bytes", len(tt.expectedBody), len(body))
+			}
+		})
+	}
+}
+
+func loginRequest(remoteIP, email string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/v1/auth/login", strings.NewReader(`{"email":"`+email+`","password":"s3cr3t"}`))
+	r.RemoteAddr = remoteIP + ":1234"
+	return r
+}
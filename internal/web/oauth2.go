@@ -0,0 +1,141 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/leberKleber/simple-jwt-provider/internal"
+	"github.com/sirupsen/logrus"
+	"mime"
+	"net/http"
+)
+
+// error codes as described in https://tools.ietf.org/html/rfc6749#section-5.2
+const (
+	oauth2ErrInvalidRequest       = "invalid_request"
+	oauth2ErrInvalidGrant         = "invalid_grant"
+	oauth2ErrUnsupportedGrantType = "unsupported_grant_type"
+)
+
+type oauth2TokenResponseBody struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+type oauth2ErrorResponseBody struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// oauth2TokenHandler implements the token endpoint as described in https://tools.ietf.org/html/rfc6749#section-3.2
+// with the grant types password (https://tools.ietf.org/html/rfc6749#section-4.3) and refresh_token
+// (https://tools.ietf.org/html/rfc6749#section-6).
+func (s *Server) oauth2TokenHandler(w http.ResponseWriter, r *http.Request) {
+	// responses containing tokens must not be cached, see https://tools.ietf.org/html/rfc6749#section-5.1
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/x-www-form-urlencoded" {
+		writeOAuth2Error(w, oauth2ErrInvalidRequest, "content-type must be application/x-www-form-urlencoded")
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		writeOAuth2Error(w, oauth2ErrInvalidRequest, "invalid form body")
+		return
+	}
+
+	var accessToken, refreshToken string
+	switch grantType := r.PostForm.Get("grant_type"); grantType {
+	case "password":
+		username := r.PostForm.Get("username")
+		password := r.PostForm.Get("password")
+		if username == "" || password == "" {
+			writeOAuth2Error(w, oauth2ErrInvalidRequest, "username and password must be set")
+			return
+		}
+
+		accessToken, refreshToken, err = s.p.Login(username, password, s.clientInfo(r))
+		var lockoutErr internal.LockoutError
+		if errors.As(err, &lockoutErr) {
+			logrus.WithField("email", username).WithError(err).Warn("Somebody tried to login while locked")
+			setRetryAfter(w, lockoutErr.Until)
+			writeOAuth2Error(w, oauth2ErrInvalidGrant, lockoutErr.Err.Error())
+			return
+		}
+		if errors.Is(err, internal.ErrIncorrectPassword) || errors.Is(err, internal.ErrUserNotFound) {
+			logrus.WithField("email", username).Warn("Somebody tried to login with invalid credentials")
+			writeOAuth2Error(w, oauth2ErrInvalidGrant, "invalid credentials")
+			return
+		}
+		if errors.Is(err, internal.ErrMFARequired) {
+			// the password grant has no second step, users with mfa have to login via /v1/auth/login
+			writeOAuth2Error(w, oauth2ErrInvalidGrant, "mfa required")
+			return
+		}
+		if errors.Is(err, internal.ErrEMailNotVerified) {
+			writeOAuth2Error(w, oauth2ErrInvalidGrant, "email not verified")
+			return
+		}
+	case "refresh_token":
+		givenRefreshToken := r.PostForm.Get("refresh_token")
+		if givenRefreshToken == "" {
+			writeOAuth2Error(w, oauth2ErrInvalidRequest, "refresh_token must be set")
+			return
+		}
+
+		accessToken, refreshToken, err = s.p.Refresh(givenRefreshToken, s.clientInfo(r))
+		if errors.Is(err, internal.ErrInvalidToken) ||
+			errors.Is(err, internal.ErrUserNotFound) ||
+			errors.Is(err, internal.ErrTokenNotParsable) ||
+			errors.Is(err, internal.ErrNoValidTokenFound) {
+			logrus.Debug("failed to refresh user auth", err)
+			writeOAuth2Error(w, oauth2ErrInvalidGrant, "invalid refresh_token")
+			return
+		}
+	case "":
+		writeOAuth2Error(w, oauth2ErrInvalidRequest, "grant_type must be set")
+		return
+	default:
+		writeOAuth2Error(w, oauth2ErrUnsupportedGrantType, "supported grant types are password and refresh_token")
+		return
+	}
+	if err != nil {
+		logrus.WithError(err).Error("Failed to issue oauth2 token")
+		writeInternalServerError(w)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(oauth2TokenResponseBody{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.p.AccessTokenLifetime().Seconds()),
+		RefreshToken: refreshToken,
+	})
+	if err != nil {
+		logrus.WithError(err).Error("Failed marshal request response")
+		writeInternalServerError(w)
+		return
+	}
+}
+
+func writeOAuth2Error(w http.ResponseWriter, errorCode, description string) {
+	respBody, err := json.Marshal(oauth2ErrorResponseBody{
+		Error:            errorCode,
+		ErrorDescription: description,
+	})
+	if err != nil {
+		logrus.WithError(err).Error("Failed to marshal json error response")
+		writeInternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusBadRequest)
+	_, err = w.Write(respBody)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to write error response")
+	}
+}
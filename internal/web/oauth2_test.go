@@ -0,0 +1,200 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"github.com/leberKleber/simple-jwt-provider/internal"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOAuth2TokenHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		contentType          string
+		requestBody          string
+		providerAccessToken  string
+		providerRefreshToken string
+		providerError        error
+		expectedEMail        string
+		expectedPassword     string
+		expectedRefreshToken string
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Password grant",
+			requestBody:          "grant_type=password&username=test.test%40test.test&password=s3cr3t",
+			expectedEMail:        "test.test@test.test",
+			expectedPassword:     "s3cr3t",
+			providerAccessToken:  "myAccessJWT",
+			providerRefreshToken: "myRefreshJWT",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"access_token":"myAccessJWT","token_type":"Bearer","expires_in":14400,"refresh_token":"myRefreshJWT"}`,
+		}, {
+			name:                 "Password grant with invalid credentials",
+			requestBody:          "grant_type=password&username=test.test%40test.test&password=n0p3",
+			providerError:        internal.ErrIncorrectPassword,
+			expectedEMail:        "test.test@test.test",
+			expectedPassword:     "n0p3",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"error":"invalid_grant","error_description":"invalid credentials"}`,
+		}, {
+			name:                 "Password grant with unknown user",
+			requestBody:          "grant_type=password&username=not.found%40test.test&password=s3cr3t",
+			providerError:        internal.ErrUserNotFound,
+			expectedEMail:        "not.found@test.test",
+			expectedPassword:     "s3cr3t",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"error":"invalid_grant","error_description":"invalid credentials"}`,
+		}, {
+			name:                 "Password grant with locked account",
+			requestBody:          "grant_type=password&username=test.test%40test.test&password=s3cr3t",
+			providerError:        internal.LockoutError{Err: internal.ErrAccountLocked, Until: time.Now().Add(time.Minute)},
+			expectedEMail:        "test.test@test.test",
+			expectedPassword:     "s3cr3t",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"error":"invalid_grant","error_description":"account is locked"}`,
+		}, {
+			name:                 "Password grant with mfa required",
+			requestBody:          "grant_type=password&username=test.test%40test.test&password=s3cr3t",
+			providerError:        internal.MFARequiredError{Token: "myMFAToken", Methods: []string{internal.MFAMethodTOTP}},
+			expectedEMail:        "test.test@test.test",
+			expectedPassword:     "s3cr3t",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"error":"invalid_grant","error_description":"mfa required"}`,
+		}, {
+			name:                 "Password grant with unverified email",
+			requestBody:          "grant_type=password&username=test.test%40test.test&password=s3cr3t",
+			providerError:        internal.ErrEMailNotVerified,
+			expectedEMail:        "test.test@test.test",
+			expectedPassword:     "s3cr3t",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"error":"invalid_grant","error_description":"email not verified"}`,
+		}, {
+			name:                 "Password grant without password",
+			requestBody:          "grant_type=password&username=test.test%40test.test",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"error":"invalid_request","error_description":"username and password must be set"}`,
+		}, {
+			name:                 "Refresh token grant",
+			requestBody:          "grant_type=refresh_token&refresh_token=myOldRefreshToken",
+			expectedRefreshToken: "myOldRefreshToken",
+			providerAccessToken:  "myAccessJWT",
+			providerRefreshToken: "myRefreshJWT",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"access_token":"myAccessJWT","token_type":"Bearer","expires_in":14400,"refresh_token":"myRefreshJWT"}`,
+		}, {
+			name:                 "Refresh token grant with invalid token",
+			requestBody:          "grant_type=refresh_token&refresh_token=myOldRefreshToken",
+			providerError:        internal.ErrInvalidToken,
+			expectedRefreshToken: "myOldRefreshToken",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"error":"invalid_grant","error_description":"invalid refresh_token"}`,
+		}, {
+			name:                 "Refresh token grant without token",
+			requestBody:          "grant_type=refresh_token",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"error":"invalid_request","error_description":"refresh_token must be set"}`,
+		}, {
+			name:                 "Missing grant type",
+			requestBody:          "username=test.test%40test.test&password=s3cr3t",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"error":"invalid_request","error_description":"grant_type must be set"}`,
+		}, {
+			name:                 "Unsupported grant type",
+			requestBody:          "grant_type=client_credentials",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"error":"unsupported_grant_type","error_description":"supported grant types are password and refresh_token"}`,
+		}, {
+			name:                 "Invalid content type",
+			contentType:          "application/json",
+			requestBody:          `{"grant_type": "password"}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"error":"invalid_request","error_description":"content-type must be application/x-www-form-urlencoded"}`,
+		}, {
+			name:                 "Unexpected error",
+			requestBody:          "grant_type=refresh_token&refresh_token=myOldRefreshToken",
+			providerError:        errors.New("nope"),
+			expectedRefreshToken: "myOldRefreshToken",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenEMail, givenPassword, givenRefreshToken string
+
+			toTest := NewServer(&ProviderMock{
+				LoginFunc: func(email string, password string, client internal.ClientInfo) (string, string, error) {
+					givenEMail = email
+					givenPassword = password
+
+					return tt.providerAccessToken, tt.providerRefreshToken, tt.providerError
+				},
+				RefreshFunc: func(refreshToken string, client internal.ClientInfo) (string, string, error) {
+					givenRefreshToken = refreshToken
+
+					return tt.providerAccessToken, tt.providerRefreshToken, tt.providerError
+				},
+				AccessTokenLifetimeFunc: func() time.Duration {
+					return 4 * time.Hour
+				},
+			}, false, "", "", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			req, err := http.NewRequest(http.MethodPost, testServer.URL+"/oauth2/token", strings.NewReader(tt.requestBody))
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = "application/x-www-form-urlencoded"
+			}
+			req.Header.Set("Content-Type", contentType)
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			if cacheControl := resp.Header.Get("Cache-Control"); cacheControl != "no-store" {
+				t.Errorf("Unexpected Cache-Control header. Expected: %q, Given: %q", "no-store", cacheControl)
+			}
+
+			if givenEMail != tt.expectedEMail {
+				t.Errorf("Provider called with unexpected email. Given: %q, Expected: %q", givenEMail, tt.expectedEMail)
+			}
+
+			if givenPassword != tt.expectedPassword {
+				t.Errorf("Provider called with unexpected password. Given: %q, Expected: %q", givenPassword, tt.expectedPassword)
+			}
+
+			if givenRefreshToken != tt.expectedRefreshToken {
+				t.Errorf("Provider called with unexpected refresh-token. Given: %q, Expected: %q", givenRefreshToken, tt.expectedRefreshToken)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			respBody = bytes.TrimSpace(respBody)
+			if !bytes.Equal(respBody, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: \n%q, \nGiven: \n%q", tt.expectedResponseBody, string(respBody))
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/leberKleber/simple-jwt-provider/internal"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type passwordPolicyViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type passwordPolicyErrorResponseBody struct {
+	Message    string                    `json:"message"`
+	Violations []passwordPolicyViolation `json:"violations"`
+}
+
+// writePasswordPolicyError responds with http status 400 listing all violated rules when the given error is an
+// internal.PasswordPolicyError. It returns false when the error is another error and nothing has been written.
+func writePasswordPolicyError(w http.ResponseWriter, err error) bool {
+	var policyErr internal.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+
+	respBody := passwordPolicyErrorResponseBody{
+		Message:    internal.ErrPasswordPolicyViolated.Error(),
+		Violations: make([]passwordPolicyViolation, len(policyErr.Violations)),
+	}
+	for i, v := range policyErr.Violations {
+		respBody.Violations[i] = passwordPolicyViolation{Rule: v.Rule, Message: v.Message}
+	}
+
+	w.WriteHeader(http.StatusBadRequest)
+	err = json.NewEncoder(w).Encode(respBody)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to write password policy error response")
+	}
+
+	return true
+}
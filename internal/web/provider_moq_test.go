@@ -5,7 +5,10 @@ package web
 
 import (
 	"github.com/leberKleber/simple-jwt-provider/internal"
+	"github.com/leberKleber/simple-jwt-provider/internal/jwk"
+	"github.com/leberKleber/simple-jwt-provider/internal/webauthn"
 	"sync"
+	"time"
 )
 
 // Ensure, that ProviderMock does implement Provider.
@@ -18,30 +21,111 @@ var _ Provider = &ProviderMock{}
 //
 // 		// make and configure a mocked Provider
 // 		mockedProvider := &ProviderMock{
+// 			AccessTokenLifetimeFunc: func() time.Duration {
+// 				panic("mock out the AccessTokenLifetime method")
+// 			},
+// 			BeginWebAuthnLoginFunc: func(email string) (webauthn.RequestOptions, error) {
+// 				panic("mock out the BeginWebAuthnLogin method")
+// 			},
+// 			BeginWebAuthnRegistrationFunc: func(accessToken string) (webauthn.CreationOptions, error) {
+// 				panic("mock out the BeginWebAuthnRegistration method")
+// 			},
+// 			ChangePasswordFunc: func(accessToken string, currentPassword string, newPassword string, client internal.ClientInfo) error {
+// 				panic("mock out the ChangePassword method")
+// 			},
+// 			ConfirmEMailOTPFunc: func(accessToken string, code string) error {
+// 				panic("mock out the ConfirmEMailOTP method")
+// 			},
+// 			ConfirmTOTPFunc: func(accessToken string, code string) ([]string, error) {
+// 				panic("mock out the ConfirmTOTP method")
+// 			},
+// 			CreateMagicLinkFunc: func(email string) error {
+// 				panic("mock out the CreateMagicLink method")
+// 			},
 // 			CreatePasswordResetRequestFunc: func(email string) error {
 // 				panic("mock out the CreatePasswordResetRequest method")
 // 			},
 // 			CreateUserFunc: func(user internal.User) error {
 // 				panic("mock out the CreateUser method")
 // 			},
+// 			DeleteSessionFunc: func(email string, id string) error {
+// 				panic("mock out the DeleteSession method")
+// 			},
 // 			DeleteUserFunc: func(email string) error {
 // 				panic("mock out the DeleteUser method")
 // 			},
+// 			DisableMFAFunc: func(email string) error {
+// 				panic("mock out the DisableMFA method")
+// 			},
+// 			EnrollEMailOTPFunc: func(accessToken string) error {
+// 				panic("mock out the EnrollEMailOTP method")
+// 			},
+// 			EnrollTOTPFunc: func(accessToken string, password string, client internal.ClientInfo) (internal.TOTPEnrollment, error) {
+// 				panic("mock out the EnrollTOTP method")
+// 			},
+// 			FinishWebAuthnLoginFunc: func(email string, credentialID []byte, clientDataJSON []byte, authenticatorData []byte, signature []byte, client internal.ClientInfo) (string, string, error) {
+// 				panic("mock out the FinishWebAuthnLogin method")
+// 			},
+// 			FinishWebAuthnRegistrationFunc: func(accessToken string, clientDataJSON []byte, attestationObject []byte) error {
+// 				panic("mock out the FinishWebAuthnRegistration method")
+// 			},
 // 			GetUserFunc: func(email string) (internal.User, error) {
 // 				panic("mock out the GetUser method")
 // 			},
-// 			LoginFunc: func(email string, password string) (string, string, error) {
+// 			IntrospectFunc: func(token string) (bool, map[string]interface{}, error) {
+// 				panic("mock out the Introspect method")
+// 			},
+// 			JWKSFunc: func() jwk.Set {
+// 				panic("mock out the JWKS method")
+// 			},
+// 			LoginFunc: func(email string, password string, client internal.ClientInfo) (string, string, error) {
 // 				panic("mock out the Login method")
 // 			},
-// 			RefreshFunc: func(refreshToken string) (string, string, error) {
+// 			LogoutFunc: func(refreshToken string) error {
+// 				panic("mock out the Logout method")
+// 			},
+// 			LogoutEverywhereFunc: func(refreshToken string) error {
+// 				panic("mock out the LogoutEverywhere method")
+// 			},
+// 			OpenIDConfigurationFunc: func() internal.OpenIDConfiguration {
+// 				panic("mock out the OpenIDConfiguration method")
+// 			},
+// 			RedeemMagicLinkFunc: func(email string, magicLinkToken string, client internal.ClientInfo) (string, string, error) {
+// 				panic("mock out the RedeemMagicLink method")
+// 			},
+// 			RefreshFunc: func(refreshToken string, client internal.ClientInfo) (string, string, error) {
 // 				panic("mock out the Refresh method")
 // 			},
+// 			RegisterFunc: func(email string, password string) error {
+// 				panic("mock out the Register method")
+// 			},
 // 			ResetPasswordFunc: func(email string, resetToken string, password string) error {
 // 				panic("mock out the ResetPassword method")
 // 			},
+// 			RevokeFunc: func(token string) error {
+// 				panic("mock out the Revoke method")
+// 			},
+// 			RevokeAccessTokenByIDFunc: func(jit string) error {
+// 				panic("mock out the RevokeAccessTokenByID method")
+// 			},
+// 			SessionsFunc: func(email string) ([]internal.Session, error) {
+// 				panic("mock out the Sessions method")
+// 			},
+// 			SessionsByAccessTokenFunc: func(accessToken string) ([]internal.Session, error) {
+// 				panic("mock out the SessionsByAccessToken method")
+// 			},
+// 			UnlockUserFunc: func(email string) error {
+// 				panic("mock out the UnlockUser method")
+// 			},
 // 			UpdateUserFunc: func(email string, user internal.User) (internal.User, error) {
 // 				panic("mock out the UpdateUser method")
 // 			},
+// 			VerifyEMailFunc: func(email string, verificationToken string) error {
+// 				panic("mock out the VerifyEMail method")
+// 			},
+// 			VerifyMFAFunc: func(email string, mfaToken string, code string, recoveryCode string, client internal.ClientInfo) (string, string, error) {
+// 				panic("mock out the VerifyMFA method")
+// 			},
 // 		}
 //
 // 		// use mockedProvider in code that requires Provider
@@ -49,32 +133,156 @@ var _ Provider = &ProviderMock{}
 //
 // 	}
 type ProviderMock struct {
+	// AccessTokenLifetimeFunc mocks the AccessTokenLifetime method.
+	AccessTokenLifetimeFunc func() time.Duration
+
+	// BeginWebAuthnLoginFunc mocks the BeginWebAuthnLogin method.
+	BeginWebAuthnLoginFunc func(email string) (webauthn.RequestOptions, error)
+
+	// BeginWebAuthnRegistrationFunc mocks the BeginWebAuthnRegistration method.
+	BeginWebAuthnRegistrationFunc func(accessToken string) (webauthn.CreationOptions, error)
+
+	// ChangePasswordFunc mocks the ChangePassword method.
+	ChangePasswordFunc func(accessToken string, currentPassword string, newPassword string, client internal.ClientInfo) error
+
+	// ConfirmEMailOTPFunc mocks the ConfirmEMailOTP method.
+	ConfirmEMailOTPFunc func(accessToken string, code string) error
+
+	// ConfirmTOTPFunc mocks the ConfirmTOTP method.
+	ConfirmTOTPFunc func(accessToken string, code string) ([]string, error)
+
+	// CreateMagicLinkFunc mocks the CreateMagicLink method.
+	CreateMagicLinkFunc func(email string) error
+
 	// CreatePasswordResetRequestFunc mocks the CreatePasswordResetRequest method.
 	CreatePasswordResetRequestFunc func(email string) error
 
 	// CreateUserFunc mocks the CreateUser method.
 	CreateUserFunc func(user internal.User) error
 
+	// DeleteSessionFunc mocks the DeleteSession method.
+	DeleteSessionFunc func(email string, id string) error
+
 	// DeleteUserFunc mocks the DeleteUser method.
 	DeleteUserFunc func(email string) error
 
+	// DisableMFAFunc mocks the DisableMFA method.
+	DisableMFAFunc func(email string) error
+
+	// EnrollEMailOTPFunc mocks the EnrollEMailOTP method.
+	EnrollEMailOTPFunc func(accessToken string) error
+
+	// EnrollTOTPFunc mocks the EnrollTOTP method.
+	EnrollTOTPFunc func(accessToken string, password string, client internal.ClientInfo) (internal.TOTPEnrollment, error)
+
+	// FinishWebAuthnLoginFunc mocks the FinishWebAuthnLogin method.
+	FinishWebAuthnLoginFunc func(email string, credentialID []byte, clientDataJSON []byte, authenticatorData []byte, signature []byte, client internal.ClientInfo) (string, string, error)
+
+	// FinishWebAuthnRegistrationFunc mocks the FinishWebAuthnRegistration method.
+	FinishWebAuthnRegistrationFunc func(accessToken string, clientDataJSON []byte, attestationObject []byte) error
+
 	// GetUserFunc mocks the GetUser method.
 	GetUserFunc func(email string) (internal.User, error)
 
+	// IntrospectFunc mocks the Introspect method.
+	IntrospectFunc func(token string) (bool, map[string]interface{}, error)
+
+	// JWKSFunc mocks the JWKS method.
+	JWKSFunc func() jwk.Set
+
 	// LoginFunc mocks the Login method.
-	LoginFunc func(email string, password string) (string, string, error)
+	LoginFunc func(email string, password string, client internal.ClientInfo) (string, string, error)
+
+	// LogoutFunc mocks the Logout method.
+	LogoutFunc func(refreshToken string) error
+
+	// LogoutEverywhereFunc mocks the LogoutEverywhere method.
+	LogoutEverywhereFunc func(refreshToken string) error
+
+	// OpenIDConfigurationFunc mocks the OpenIDConfiguration method.
+	OpenIDConfigurationFunc func() internal.OpenIDConfiguration
+
+	// RedeemMagicLinkFunc mocks the RedeemMagicLink method.
+	RedeemMagicLinkFunc func(email string, magicLinkToken string, client internal.ClientInfo) (string, string, error)
 
 	// RefreshFunc mocks the Refresh method.
-	RefreshFunc func(refreshToken string) (string, string, error)
+	RefreshFunc func(refreshToken string, client internal.ClientInfo) (string, string, error)
+
+	// RegisterFunc mocks the Register method.
+	RegisterFunc func(email string, password string) error
 
 	// ResetPasswordFunc mocks the ResetPassword method.
 	ResetPasswordFunc func(email string, resetToken string, password string) error
 
+	// RevokeFunc mocks the Revoke method.
+	RevokeFunc func(token string) error
+
+	// RevokeAccessTokenByIDFunc mocks the RevokeAccessTokenByID method.
+	RevokeAccessTokenByIDFunc func(jit string) error
+
+	// SessionsFunc mocks the Sessions method.
+	SessionsFunc func(email string) ([]internal.Session, error)
+
+	// SessionsByAccessTokenFunc mocks the SessionsByAccessToken method.
+	SessionsByAccessTokenFunc func(accessToken string) ([]internal.Session, error)
+
+	// UnlockUserFunc mocks the UnlockUser method.
+	UnlockUserFunc func(email string) error
+
 	// UpdateUserFunc mocks the UpdateUser method.
 	UpdateUserFunc func(email string, user internal.User) (internal.User, error)
 
+	// VerifyEMailFunc mocks the VerifyEMail method.
+	VerifyEMailFunc func(email string, verificationToken string) error
+
+	// VerifyMFAFunc mocks the VerifyMFA method.
+	VerifyMFAFunc func(email string, mfaToken string, code string, recoveryCode string, client internal.ClientInfo) (string, string, error)
+
 	// calls tracks calls to the methods.
 	calls struct {
+		// AccessTokenLifetime holds details about calls to the AccessTokenLifetime method.
+		AccessTokenLifetime []struct {
+		}
+		// BeginWebAuthnLogin holds details about calls to the BeginWebAuthnLogin method.
+		BeginWebAuthnLogin []struct {
+			// Email is the email argument value.
+			Email string
+		}
+		// BeginWebAuthnRegistration holds details about calls to the BeginWebAuthnRegistration method.
+		BeginWebAuthnRegistration []struct {
+			// AccessToken is the accessToken argument value.
+			AccessToken string
+		}
+		// ChangePassword holds details about calls to the ChangePassword method.
+		ChangePassword []struct {
+			// AccessToken is the accessToken argument value.
+			AccessToken string
+			// CurrentPassword is the currentPassword argument value.
+			CurrentPassword string
+			// NewPassword is the newPassword argument value.
+			NewPassword string
+			// Client is the client argument value.
+			Client internal.ClientInfo
+		}
+		// ConfirmEMailOTP holds details about calls to the ConfirmEMailOTP method.
+		ConfirmEMailOTP []struct {
+			// AccessToken is the accessToken argument value.
+			AccessToken string
+			// Code is the code argument value.
+			Code string
+		}
+		// ConfirmTOTP holds details about calls to the ConfirmTOTP method.
+		ConfirmTOTP []struct {
+			// AccessToken is the accessToken argument value.
+			AccessToken string
+			// Code is the code argument value.
+			Code string
+		}
+		// CreateMagicLink holds details about calls to the CreateMagicLink method.
+		CreateMagicLink []struct {
+			// Email is the email argument value.
+			Email string
+		}
 		// CreatePasswordResetRequest holds details about calls to the CreatePasswordResetRequest method.
 		CreatePasswordResetRequest []struct {
 			// Email is the email argument value.
@@ -85,27 +293,118 @@ type ProviderMock struct {
 			// User is the user argument value.
 			User internal.User
 		}
+		// DeleteSession holds details about calls to the DeleteSession method.
+		DeleteSession []struct {
+			// Email is the email argument value.
+			Email string
+			// ID is the id argument value.
+			ID string
+		}
 		// DeleteUser holds details about calls to the DeleteUser method.
 		DeleteUser []struct {
 			// Email is the email argument value.
 			Email string
 		}
+		// DisableMFA holds details about calls to the DisableMFA method.
+		DisableMFA []struct {
+			// Email is the email argument value.
+			Email string
+		}
+		// EnrollEMailOTP holds details about calls to the EnrollEMailOTP method.
+		EnrollEMailOTP []struct {
+			// AccessToken is the accessToken argument value.
+			AccessToken string
+		}
+		// EnrollTOTP holds details about calls to the EnrollTOTP method.
+		EnrollTOTP []struct {
+			// AccessToken is the accessToken argument value.
+			AccessToken string
+			// Password is the password argument value.
+			Password string
+			// Client is the client argument value.
+			Client internal.ClientInfo
+		}
+		// FinishWebAuthnLogin holds details about calls to the FinishWebAuthnLogin method.
+		FinishWebAuthnLogin []struct {
+			// Email is the email argument value.
+			Email string
+			// CredentialID is the credentialID argument value.
+			CredentialID []byte
+			// ClientDataJSON is the clientDataJSON argument value.
+			ClientDataJSON []byte
+			// AuthenticatorData is the authenticatorData argument value.
+			AuthenticatorData []byte
+			// Signature is the signature argument value.
+			Signature []byte
+			// Client is the client argument value.
+			Client internal.ClientInfo
+		}
+		// FinishWebAuthnRegistration holds details about calls to the FinishWebAuthnRegistration method.
+		FinishWebAuthnRegistration []struct {
+			// AccessToken is the accessToken argument value.
+			AccessToken string
+			// ClientDataJSON is the clientDataJSON argument value.
+			ClientDataJSON []byte
+			// AttestationObject is the attestationObject argument value.
+			AttestationObject []byte
+		}
 		// GetUser holds details about calls to the GetUser method.
 		GetUser []struct {
 			// Email is the email argument value.
 			Email string
 		}
+		// Introspect holds details about calls to the Introspect method.
+		Introspect []struct {
+			// Token is the token argument value.
+			Token string
+		}
+		// JWKS holds details about calls to the JWKS method.
+		JWKS []struct {
+		}
 		// Login holds details about calls to the Login method.
 		Login []struct {
 			// Email is the email argument value.
 			Email string
 			// Password is the password argument value.
 			Password string
+			// Client is the client argument value.
+			Client internal.ClientInfo
+		}
+		// Logout holds details about calls to the Logout method.
+		Logout []struct {
+			// RefreshToken is the refreshToken argument value.
+			RefreshToken string
+		}
+		// LogoutEverywhere holds details about calls to the LogoutEverywhere method.
+		LogoutEverywhere []struct {
+			// RefreshToken is the refreshToken argument value.
+			RefreshToken string
+		}
+		// OpenIDConfiguration holds details about calls to the OpenIDConfiguration method.
+		OpenIDConfiguration []struct {
+		}
+		// RedeemMagicLink holds details about calls to the RedeemMagicLink method.
+		RedeemMagicLink []struct {
+			// Email is the email argument value.
+			Email string
+			// MagicLinkToken is the magicLinkToken argument value.
+			MagicLinkToken string
+			// Client is the client argument value.
+			Client internal.ClientInfo
 		}
 		// Refresh holds details about calls to the Refresh method.
 		Refresh []struct {
 			// RefreshToken is the refreshToken argument value.
 			RefreshToken string
+			// Client is the client argument value.
+			Client internal.ClientInfo
+		}
+		// Register holds details about calls to the Register method.
+		Register []struct {
+			// Email is the email argument value.
+			Email string
+			// Password is the password argument value.
+			Password string
 		}
 		// ResetPassword holds details about calls to the ResetPassword method.
 		ResetPassword []struct {
@@ -116,6 +415,31 @@ type ProviderMock struct {
 			// Password is the password argument value.
 			Password string
 		}
+		// Revoke holds details about calls to the Revoke method.
+		Revoke []struct {
+			// Token is the token argument value.
+			Token string
+		}
+		// RevokeAccessTokenByID holds details about calls to the RevokeAccessTokenByID method.
+		RevokeAccessTokenByID []struct {
+			// Jit is the jit argument value.
+			Jit string
+		}
+		// Sessions holds details about calls to the Sessions method.
+		Sessions []struct {
+			// Email is the email argument value.
+			Email string
+		}
+		// SessionsByAccessToken holds details about calls to the SessionsByAccessToken method.
+		SessionsByAccessToken []struct {
+			// AccessToken is the accessToken argument value.
+			AccessToken string
+		}
+		// UnlockUser holds details about calls to the UnlockUser method.
+		UnlockUser []struct {
+			// Email is the email argument value.
+			Email string
+		}
 		// UpdateUser holds details about calls to the UpdateUser method.
 		UpdateUser []struct {
 			// Email is the email argument value.
@@ -123,15 +447,294 @@ type ProviderMock struct {
 			// User is the user argument value.
 			User internal.User
 		}
+		// VerifyEMail holds details about calls to the VerifyEMail method.
+		VerifyEMail []struct {
+			// Email is the email argument value.
+			Email string
+			// VerificationToken is the verificationToken argument value.
+			VerificationToken string
+		}
+		// VerifyMFA holds details about calls to the VerifyMFA method.
+		VerifyMFA []struct {
+			// Email is the email argument value.
+			Email string
+			// MfaToken is the mfaToken argument value.
+			MfaToken string
+			// Code is the code argument value.
+			Code string
+			// RecoveryCode is the recoveryCode argument value.
+			RecoveryCode string
+			// Client is the client argument value.
+			Client internal.ClientInfo
+		}
 	}
+	lockAccessTokenLifetime        sync.RWMutex
+	lockBeginWebAuthnLogin         sync.RWMutex
+	lockBeginWebAuthnRegistration  sync.RWMutex
+	lockChangePassword             sync.RWMutex
+	lockConfirmEMailOTP            sync.RWMutex
+	lockConfirmTOTP                sync.RWMutex
+	lockCreateMagicLink            sync.RWMutex
 	lockCreatePasswordResetRequest sync.RWMutex
 	lockCreateUser                 sync.RWMutex
+	lockDeleteSession              sync.RWMutex
 	lockDeleteUser                 sync.RWMutex
+	lockDisableMFA                 sync.RWMutex
+	lockEnrollEMailOTP             sync.RWMutex
+	lockEnrollTOTP                 sync.RWMutex
+	lockFinishWebAuthnLogin        sync.RWMutex
+	lockFinishWebAuthnRegistration sync.RWMutex
 	lockGetUser                    sync.RWMutex
+	lockIntrospect                 sync.RWMutex
+	lockJWKS                       sync.RWMutex
 	lockLogin                      sync.RWMutex
+	lockLogout                     sync.RWMutex
+	lockLogoutEverywhere           sync.RWMutex
+	lockOpenIDConfiguration        sync.RWMutex
+	lockRedeemMagicLink            sync.RWMutex
 	lockRefresh                    sync.RWMutex
+	lockRegister                   sync.RWMutex
 	lockResetPassword              sync.RWMutex
+	lockRevoke                     sync.RWMutex
+	lockRevokeAccessTokenByID      sync.RWMutex
+	lockSessions                   sync.RWMutex
+	lockSessionsByAccessToken      sync.RWMutex
+	lockUnlockUser                 sync.RWMutex
 	lockUpdateUser                 sync.RWMutex
+	lockVerifyEMail                sync.RWMutex
+	lockVerifyMFA                  sync.RWMutex
+}
+
+// AccessTokenLifetime calls AccessTokenLifetimeFunc.
+func (mock *ProviderMock) AccessTokenLifetime() time.Duration {
+	if mock.AccessTokenLifetimeFunc == nil {
+		panic("ProviderMock.AccessTokenLifetimeFunc: method is nil but Provider.AccessTokenLifetime was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockAccessTokenLifetime.Lock()
+	mock.calls.AccessTokenLifetime = append(mock.calls.AccessTokenLifetime, callInfo)
+	mock.lockAccessTokenLifetime.Unlock()
+	return mock.AccessTokenLifetimeFunc()
+}
+
+// AccessTokenLifetimeCalls gets all the calls that were made to AccessTokenLifetime.
+// Check the length with:
+//     len(mockedProvider.AccessTokenLifetimeCalls())
+func (mock *ProviderMock) AccessTokenLifetimeCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockAccessTokenLifetime.RLock()
+	calls = mock.calls.AccessTokenLifetime
+	mock.lockAccessTokenLifetime.RUnlock()
+	return calls
+}
+
+// BeginWebAuthnLogin calls BeginWebAuthnLoginFunc.
+func (mock *ProviderMock) BeginWebAuthnLogin(email string) (webauthn.RequestOptions, error) {
+	if mock.BeginWebAuthnLoginFunc == nil {
+		panic("ProviderMock.BeginWebAuthnLoginFunc: method is nil but Provider.BeginWebAuthnLogin was just called")
+	}
+	callInfo := struct {
+		Email string
+	}{
+		Email: email,
+	}
+	mock.lockBeginWebAuthnLogin.Lock()
+	mock.calls.BeginWebAuthnLogin = append(mock.calls.BeginWebAuthnLogin, callInfo)
+	mock.lockBeginWebAuthnLogin.Unlock()
+	return mock.BeginWebAuthnLoginFunc(email)
+}
+
+// BeginWebAuthnLoginCalls gets all the calls that were made to BeginWebAuthnLogin.
+// Check the length with:
+//     len(mockedProvider.BeginWebAuthnLoginCalls())
+func (mock *ProviderMock) BeginWebAuthnLoginCalls() []struct {
+	Email string
+} {
+	var calls []struct {
+		Email string
+	}
+	mock.lockBeginWebAuthnLogin.RLock()
+	calls = mock.calls.BeginWebAuthnLogin
+	mock.lockBeginWebAuthnLogin.RUnlock()
+	return calls
+}
+
+// BeginWebAuthnRegistration calls BeginWebAuthnRegistrationFunc.
+func (mock *ProviderMock) BeginWebAuthnRegistration(accessToken string) (webauthn.CreationOptions, error) {
+	if mock.BeginWebAuthnRegistrationFunc == nil {
+		panic("ProviderMock.BeginWebAuthnRegistrationFunc: method is nil but Provider.BeginWebAuthnRegistration was just called")
+	}
+	callInfo := struct {
+		AccessToken string
+	}{
+		AccessToken: accessToken,
+	}
+	mock.lockBeginWebAuthnRegistration.Lock()
+	mock.calls.BeginWebAuthnRegistration = append(mock.calls.BeginWebAuthnRegistration, callInfo)
+	mock.lockBeginWebAuthnRegistration.Unlock()
+	return mock.BeginWebAuthnRegistrationFunc(accessToken)
+}
+
+// BeginWebAuthnRegistrationCalls gets all the calls that were made to BeginWebAuthnRegistration.
+// Check the length with:
+//     len(mockedProvider.BeginWebAuthnRegistrationCalls())
+func (mock *ProviderMock) BeginWebAuthnRegistrationCalls() []struct {
+	AccessToken string
+} {
+	var calls []struct {
+		AccessToken string
+	}
+	mock.lockBeginWebAuthnRegistration.RLock()
+	calls = mock.calls.BeginWebAuthnRegistration
+	mock.lockBeginWebAuthnRegistration.RUnlock()
+	return calls
+}
+
+// ChangePassword calls ChangePasswordFunc.
+func (mock *ProviderMock) ChangePassword(accessToken string, currentPassword string, newPassword string, client internal.ClientInfo) error {
+	if mock.ChangePasswordFunc == nil {
+		panic("ProviderMock.ChangePasswordFunc: method is nil but Provider.ChangePassword was just called")
+	}
+	callInfo := struct {
+		AccessToken     string
+		CurrentPassword string
+		NewPassword     string
+		Client          internal.ClientInfo
+	}{
+		AccessToken:     accessToken,
+		CurrentPassword: currentPassword,
+		NewPassword:     newPassword,
+		Client:          client,
+	}
+	mock.lockChangePassword.Lock()
+	mock.calls.ChangePassword = append(mock.calls.ChangePassword, callInfo)
+	mock.lockChangePassword.Unlock()
+	return mock.ChangePasswordFunc(accessToken, currentPassword, newPassword, client)
+}
+
+// ChangePasswordCalls gets all the calls that were made to ChangePassword.
+// Check the length with:
+//     len(mockedProvider.ChangePasswordCalls())
+func (mock *ProviderMock) ChangePasswordCalls() []struct {
+	AccessToken     string
+	CurrentPassword string
+	NewPassword     string
+	Client          internal.ClientInfo
+} {
+	var calls []struct {
+		AccessToken     string
+		CurrentPassword string
+		NewPassword     string
+		Client          internal.ClientInfo
+	}
+	mock.lockChangePassword.RLock()
+	calls = mock.calls.ChangePassword
+	mock.lockChangePassword.RUnlock()
+	return calls
+}
+
+// ConfirmEMailOTP calls ConfirmEMailOTPFunc.
+func (mock *ProviderMock) ConfirmEMailOTP(accessToken string, code string) error {
+	if mock.ConfirmEMailOTPFunc == nil {
+		panic("ProviderMock.ConfirmEMailOTPFunc: method is nil but Provider.ConfirmEMailOTP was just called")
+	}
+	callInfo := struct {
+		AccessToken string
+		Code        string
+	}{
+		AccessToken: accessToken,
+		Code:        code,
+	}
+	mock.lockConfirmEMailOTP.Lock()
+	mock.calls.ConfirmEMailOTP = append(mock.calls.ConfirmEMailOTP, callInfo)
+	mock.lockConfirmEMailOTP.Unlock()
+	return mock.ConfirmEMailOTPFunc(accessToken, code)
+}
+
+// ConfirmEMailOTPCalls gets all the calls that were made to ConfirmEMailOTP.
+// Check the length with:
+//     len(mockedProvider.ConfirmEMailOTPCalls())
+func (mock *ProviderMock) ConfirmEMailOTPCalls() []struct {
+	AccessToken string
+	Code        string
+} {
+	var calls []struct {
+		AccessToken string
+		Code        string
+	}
+	mock.lockConfirmEMailOTP.RLock()
+	calls = mock.calls.ConfirmEMailOTP
+	mock.lockConfirmEMailOTP.RUnlock()
+	return calls
+}
+
+// ConfirmTOTP calls ConfirmTOTPFunc.
+func (mock *ProviderMock) ConfirmTOTP(accessToken string, code string) ([]string, error) {
+	if mock.ConfirmTOTPFunc == nil {
+		panic("ProviderMock.ConfirmTOTPFunc: method is nil but Provider.ConfirmTOTP was just called")
+	}
+	callInfo := struct {
+		AccessToken string
+		Code        string
+	}{
+		AccessToken: accessToken,
+		Code:        code,
+	}
+	mock.lockConfirmTOTP.Lock()
+	mock.calls.ConfirmTOTP = append(mock.calls.ConfirmTOTP, callInfo)
+	mock.lockConfirmTOTP.Unlock()
+	return mock.ConfirmTOTPFunc(accessToken, code)
+}
+
+// ConfirmTOTPCalls gets all the calls that were made to ConfirmTOTP.
+// Check the length with:
+//     len(mockedProvider.ConfirmTOTPCalls())
+func (mock *ProviderMock) ConfirmTOTPCalls() []struct {
+	AccessToken string
+	Code        string
+} {
+	var calls []struct {
+		AccessToken string
+		Code        string
+	}
+	mock.lockConfirmTOTP.RLock()
+	calls = mock.calls.ConfirmTOTP
+	mock.lockConfirmTOTP.RUnlock()
+	return calls
+}
+
+// CreateMagicLink calls CreateMagicLinkFunc.
+func (mock *ProviderMock) CreateMagicLink(email string) error {
+	if mock.CreateMagicLinkFunc == nil {
+		panic("ProviderMock.CreateMagicLinkFunc: method is nil but Provider.CreateMagicLink was just called")
+	}
+	callInfo := struct {
+		Email string
+	}{
+		Email: email,
+	}
+	mock.lockCreateMagicLink.Lock()
+	mock.calls.CreateMagicLink = append(mock.calls.CreateMagicLink, callInfo)
+	mock.lockCreateMagicLink.Unlock()
+	return mock.CreateMagicLinkFunc(email)
+}
+
+// CreateMagicLinkCalls gets all the calls that were made to CreateMagicLink.
+// Check the length with:
+//     len(mockedProvider.CreateMagicLinkCalls())
+func (mock *ProviderMock) CreateMagicLinkCalls() []struct {
+	Email string
+} {
+	var calls []struct {
+		Email string
+	}
+	mock.lockCreateMagicLink.RLock()
+	calls = mock.calls.CreateMagicLink
+	mock.lockCreateMagicLink.RUnlock()
+	return calls
 }
 
 // CreatePasswordResetRequest calls CreatePasswordResetRequestFunc.
@@ -196,6 +799,41 @@ func (mock *ProviderMock) CreateUserCalls() []struct {
 	return calls
 }
 
+// DeleteSession calls DeleteSessionFunc.
+func (mock *ProviderMock) DeleteSession(email string, id string) error {
+	if mock.DeleteSessionFunc == nil {
+		panic("ProviderMock.DeleteSessionFunc: method is nil but Provider.DeleteSession was just called")
+	}
+	callInfo := struct {
+		Email string
+		ID    string
+	}{
+		Email: email,
+		ID:    id,
+	}
+	mock.lockDeleteSession.Lock()
+	mock.calls.DeleteSession = append(mock.calls.DeleteSession, callInfo)
+	mock.lockDeleteSession.Unlock()
+	return mock.DeleteSessionFunc(email, id)
+}
+
+// DeleteSessionCalls gets all the calls that were made to DeleteSession.
+// Check the length with:
+//     len(mockedProvider.DeleteSessionCalls())
+func (mock *ProviderMock) DeleteSessionCalls() []struct {
+	Email string
+	ID    string
+} {
+	var calls []struct {
+		Email string
+		ID    string
+	}
+	mock.lockDeleteSession.RLock()
+	calls = mock.calls.DeleteSession
+	mock.lockDeleteSession.RUnlock()
+	return calls
+}
+
 // DeleteUser calls DeleteUserFunc.
 func (mock *ProviderMock) DeleteUser(email string) error {
 	if mock.DeleteUserFunc == nil {
@@ -227,6 +865,197 @@ func (mock *ProviderMock) DeleteUserCalls() []struct {
 	return calls
 }
 
+// DisableMFA calls DisableMFAFunc.
+func (mock *ProviderMock) DisableMFA(email string) error {
+	if mock.DisableMFAFunc == nil {
+		panic("ProviderMock.DisableMFAFunc: method is nil but Provider.DisableMFA was just called")
+	}
+	callInfo := struct {
+		Email string
+	}{
+		Email: email,
+	}
+	mock.lockDisableMFA.Lock()
+	mock.calls.DisableMFA = append(mock.calls.DisableMFA, callInfo)
+	mock.lockDisableMFA.Unlock()
+	return mock.DisableMFAFunc(email)
+}
+
+// DisableMFACalls gets all the calls that were made to DisableMFA.
+// Check the length with:
+//     len(mockedProvider.DisableMFACalls())
+func (mock *ProviderMock) DisableMFACalls() []struct {
+	Email string
+} {
+	var calls []struct {
+		Email string
+	}
+	mock.lockDisableMFA.RLock()
+	calls = mock.calls.DisableMFA
+	mock.lockDisableMFA.RUnlock()
+	return calls
+}
+
+// EnrollEMailOTP calls EnrollEMailOTPFunc.
+func (mock *ProviderMock) EnrollEMailOTP(accessToken string) error {
+	if mock.EnrollEMailOTPFunc == nil {
+		panic("ProviderMock.EnrollEMailOTPFunc: method is nil but Provider.EnrollEMailOTP was just called")
+	}
+	callInfo := struct {
+		AccessToken string
+	}{
+		AccessToken: accessToken,
+	}
+	mock.lockEnrollEMailOTP.Lock()
+	mock.calls.EnrollEMailOTP = append(mock.calls.EnrollEMailOTP, callInfo)
+	mock.lockEnrollEMailOTP.Unlock()
+	return mock.EnrollEMailOTPFunc(accessToken)
+}
+
+// EnrollEMailOTPCalls gets all the calls that were made to EnrollEMailOTP.
+// Check the length with:
+//     len(mockedProvider.EnrollEMailOTPCalls())
+func (mock *ProviderMock) EnrollEMailOTPCalls() []struct {
+	AccessToken string
+} {
+	var calls []struct {
+		AccessToken string
+	}
+	mock.lockEnrollEMailOTP.RLock()
+	calls = mock.calls.EnrollEMailOTP
+	mock.lockEnrollEMailOTP.RUnlock()
+	return calls
+}
+
+// EnrollTOTP calls EnrollTOTPFunc.
+func (mock *ProviderMock) EnrollTOTP(accessToken string, password string, client internal.ClientInfo) (internal.TOTPEnrollment, error) {
+	if mock.EnrollTOTPFunc == nil {
+		panic("ProviderMock.EnrollTOTPFunc: method is nil but Provider.EnrollTOTP was just called")
+	}
+	callInfo := struct {
+		AccessToken string
+		Password    string
+		Client      internal.ClientInfo
+	}{
+		AccessToken: accessToken,
+		Password:    password,
+		Client:      client,
+	}
+	mock.lockEnrollTOTP.Lock()
+	mock.calls.EnrollTOTP = append(mock.calls.EnrollTOTP, callInfo)
+	mock.lockEnrollTOTP.Unlock()
+	return mock.EnrollTOTPFunc(accessToken, password, client)
+}
+
+// EnrollTOTPCalls gets all the calls that were made to EnrollTOTP.
+// Check the length with:
+//     len(mockedProvider.EnrollTOTPCalls())
+func (mock *ProviderMock) EnrollTOTPCalls() []struct {
+	AccessToken string
+	Password    string
+	Client      internal.ClientInfo
+} {
+	var calls []struct {
+		AccessToken string
+		Password    string
+		Client      internal.ClientInfo
+	}
+	mock.lockEnrollTOTP.RLock()
+	calls = mock.calls.EnrollTOTP
+	mock.lockEnrollTOTP.RUnlock()
+	return calls
+}
+
+// FinishWebAuthnLogin calls FinishWebAuthnLoginFunc.
+func (mock *ProviderMock) FinishWebAuthnLogin(email string, credentialID []byte, clientDataJSON []byte, authenticatorData []byte, signature []byte, client internal.ClientInfo) (string, string, error) {
+	if mock.FinishWebAuthnLoginFunc == nil {
+		panic("ProviderMock.FinishWebAuthnLoginFunc: method is nil but Provider.FinishWebAuthnLogin was just called")
+	}
+	callInfo := struct {
+		Email             string
+		CredentialID      []byte
+		ClientDataJSON    []byte
+		AuthenticatorData []byte
+		Signature         []byte
+		Client            internal.ClientInfo
+	}{
+		Email:             email,
+		CredentialID:      credentialID,
+		ClientDataJSON:    clientDataJSON,
+		AuthenticatorData: authenticatorData,
+		Signature:         signature,
+		Client:            client,
+	}
+	mock.lockFinishWebAuthnLogin.Lock()
+	mock.calls.FinishWebAuthnLogin = append(mock.calls.FinishWebAuthnLogin, callInfo)
+	mock.lockFinishWebAuthnLogin.Unlock()
+	return mock.FinishWebAuthnLoginFunc(email, credentialID, clientDataJSON, authenticatorData, signature, client)
+}
+
+// FinishWebAuthnLoginCalls gets all the calls that were made to FinishWebAuthnLogin.
+// Check the length with:
+//     len(mockedProvider.FinishWebAuthnLoginCalls())
+func (mock *ProviderMock) FinishWebAuthnLoginCalls() []struct {
+	Email             string
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	Client            internal.ClientInfo
+} {
+	var calls []struct {
+		Email             string
+		CredentialID      []byte
+		ClientDataJSON    []byte
+		AuthenticatorData []byte
+		Signature         []byte
+		Client            internal.ClientInfo
+	}
+	mock.lockFinishWebAuthnLogin.RLock()
+	calls = mock.calls.FinishWebAuthnLogin
+	mock.lockFinishWebAuthnLogin.RUnlock()
+	return calls
+}
+
+// FinishWebAuthnRegistration calls FinishWebAuthnRegistrationFunc.
+func (mock *ProviderMock) FinishWebAuthnRegistration(accessToken string, clientDataJSON []byte, attestationObject []byte) error {
+	if mock.FinishWebAuthnRegistrationFunc == nil {
+		panic("ProviderMock.FinishWebAuthnRegistrationFunc: method is nil but Provider.FinishWebAuthnRegistration was just called")
+	}
+	callInfo := struct {
+		AccessToken       string
+		ClientDataJSON    []byte
+		AttestationObject []byte
+	}{
+		AccessToken:       accessToken,
+		ClientDataJSON:    clientDataJSON,
+		AttestationObject: attestationObject,
+	}
+	mock.lockFinishWebAuthnRegistration.Lock()
+	mock.calls.FinishWebAuthnRegistration = append(mock.calls.FinishWebAuthnRegistration, callInfo)
+	mock.lockFinishWebAuthnRegistration.Unlock()
+	return mock.FinishWebAuthnRegistrationFunc(accessToken, clientDataJSON, attestationObject)
+}
+
+// FinishWebAuthnRegistrationCalls gets all the calls that were made to FinishWebAuthnRegistration.
+// Check the length with:
+//     len(mockedProvider.FinishWebAuthnRegistrationCalls())
+func (mock *ProviderMock) FinishWebAuthnRegistrationCalls() []struct {
+	AccessToken       string
+	ClientDataJSON    []byte
+	AttestationObject []byte
+} {
+	var calls []struct {
+		AccessToken       string
+		ClientDataJSON    []byte
+		AttestationObject []byte
+	}
+	mock.lockFinishWebAuthnRegistration.RLock()
+	calls = mock.calls.FinishWebAuthnRegistration
+	mock.lockFinishWebAuthnRegistration.RUnlock()
+	return calls
+}
+
 // GetUser calls GetUserFunc.
 func (mock *ProviderMock) GetUser(email string) (internal.User, error) {
 	if mock.GetUserFunc == nil {
@@ -258,22 +1087,81 @@ func (mock *ProviderMock) GetUserCalls() []struct {
 	return calls
 }
 
+// Introspect calls IntrospectFunc.
+func (mock *ProviderMock) Introspect(token string) (bool, map[string]interface{}, error) {
+	if mock.IntrospectFunc == nil {
+		panic("ProviderMock.IntrospectFunc: method is nil but Provider.Introspect was just called")
+	}
+	callInfo := struct {
+		Token string
+	}{
+		Token: token,
+	}
+	mock.lockIntrospect.Lock()
+	mock.calls.Introspect = append(mock.calls.Introspect, callInfo)
+	mock.lockIntrospect.Unlock()
+	return mock.IntrospectFunc(token)
+}
+
+// IntrospectCalls gets all the calls that were made to Introspect.
+// Check the length with:
+//     len(mockedProvider.IntrospectCalls())
+func (mock *ProviderMock) IntrospectCalls() []struct {
+	Token string
+} {
+	var calls []struct {
+		Token string
+	}
+	mock.lockIntrospect.RLock()
+	calls = mock.calls.Introspect
+	mock.lockIntrospect.RUnlock()
+	return calls
+}
+
+// JWKS calls JWKSFunc.
+func (mock *ProviderMock) JWKS() jwk.Set {
+	if mock.JWKSFunc == nil {
+		panic("ProviderMock.JWKSFunc: method is nil but Provider.JWKS was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockJWKS.Lock()
+	mock.calls.JWKS = append(mock.calls.JWKS, callInfo)
+	mock.lockJWKS.Unlock()
+	return mock.JWKSFunc()
+}
+
+// JWKSCalls gets all the calls that were made to JWKS.
+// Check the length with:
+//     len(mockedProvider.JWKSCalls())
+func (mock *ProviderMock) JWKSCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockJWKS.RLock()
+	calls = mock.calls.JWKS
+	mock.lockJWKS.RUnlock()
+	return calls
+}
+
 // Login calls LoginFunc.
-func (mock *ProviderMock) Login(email string, password string) (string, string, error) {
+func (mock *ProviderMock) Login(email string, password string, client internal.ClientInfo) (string, string, error) {
 	if mock.LoginFunc == nil {
 		panic("ProviderMock.LoginFunc: method is nil but Provider.Login was just called")
 	}
 	callInfo := struct {
 		Email    string
 		Password string
+		Client   internal.ClientInfo
 	}{
 		Email:    email,
 		Password: password,
+		Client:   client,
 	}
 	mock.lockLogin.Lock()
 	mock.calls.Login = append(mock.calls.Login, callInfo)
 	mock.lockLogin.Unlock()
-	return mock.LoginFunc(email, password)
+	return mock.LoginFunc(email, password, client)
 }
 
 // LoginCalls gets all the calls that were made to Login.
@@ -282,10 +1170,12 @@ func (mock *ProviderMock) Login(email string, password string) (string, string,
 func (mock *ProviderMock) LoginCalls() []struct {
 	Email    string
 	Password string
+	Client   internal.ClientInfo
 } {
 	var calls []struct {
 		Email    string
 		Password string
+		Client   internal.ClientInfo
 	}
 	mock.lockLogin.RLock()
 	calls = mock.calls.Login
@@ -293,20 +1183,149 @@ func (mock *ProviderMock) LoginCalls() []struct {
 	return calls
 }
 
+// Logout calls LogoutFunc.
+func (mock *ProviderMock) Logout(refreshToken string) error {
+	if mock.LogoutFunc == nil {
+		panic("ProviderMock.LogoutFunc: method is nil but Provider.Logout was just called")
+	}
+	callInfo := struct {
+		RefreshToken string
+	}{
+		RefreshToken: refreshToken,
+	}
+	mock.lockLogout.Lock()
+	mock.calls.Logout = append(mock.calls.Logout, callInfo)
+	mock.lockLogout.Unlock()
+	return mock.LogoutFunc(refreshToken)
+}
+
+// LogoutCalls gets all the calls that were made to Logout.
+// Check the length with:
+//     len(mockedProvider.LogoutCalls())
+func (mock *ProviderMock) LogoutCalls() []struct {
+	RefreshToken string
+} {
+	var calls []struct {
+		RefreshToken string
+	}
+	mock.lockLogout.RLock()
+	calls = mock.calls.Logout
+	mock.lockLogout.RUnlock()
+	return calls
+}
+
+// LogoutEverywhere calls LogoutEverywhereFunc.
+func (mock *ProviderMock) LogoutEverywhere(refreshToken string) error {
+	if mock.LogoutEverywhereFunc == nil {
+		panic("ProviderMock.LogoutEverywhereFunc: method is nil but Provider.LogoutEverywhere was just called")
+	}
+	callInfo := struct {
+		RefreshToken string
+	}{
+		RefreshToken: refreshToken,
+	}
+	mock.lockLogoutEverywhere.Lock()
+	mock.calls.LogoutEverywhere = append(mock.calls.LogoutEverywhere, callInfo)
+	mock.lockLogoutEverywhere.Unlock()
+	return mock.LogoutEverywhereFunc(refreshToken)
+}
+
+// LogoutEverywhereCalls gets all the calls that were made to LogoutEverywhere.
+// Check the length with:
+//     len(mockedProvider.LogoutEverywhereCalls())
+func (mock *ProviderMock) LogoutEverywhereCalls() []struct {
+	RefreshToken string
+} {
+	var calls []struct {
+		RefreshToken string
+	}
+	mock.lockLogoutEverywhere.RLock()
+	calls = mock.calls.LogoutEverywhere
+	mock.lockLogoutEverywhere.RUnlock()
+	return calls
+}
+
+// OpenIDConfiguration calls OpenIDConfigurationFunc.
+func (mock *ProviderMock) OpenIDConfiguration() internal.OpenIDConfiguration {
+	if mock.OpenIDConfigurationFunc == nil {
+		panic("ProviderMock.OpenIDConfigurationFunc: method is nil but Provider.OpenIDConfiguration was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockOpenIDConfiguration.Lock()
+	mock.calls.OpenIDConfiguration = append(mock.calls.OpenIDConfiguration, callInfo)
+	mock.lockOpenIDConfiguration.Unlock()
+	return mock.OpenIDConfigurationFunc()
+}
+
+// OpenIDConfigurationCalls gets all the calls that were made to OpenIDConfiguration.
+// Check the length with:
+//     len(mockedProvider.OpenIDConfigurationCalls())
+func (mock *ProviderMock) OpenIDConfigurationCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockOpenIDConfiguration.RLock()
+	calls = mock.calls.OpenIDConfiguration
+	mock.lockOpenIDConfiguration.RUnlock()
+	return calls
+}
+
+// RedeemMagicLink calls RedeemMagicLinkFunc.
+func (mock *ProviderMock) RedeemMagicLink(email string, magicLinkToken string, client internal.ClientInfo) (string, string, error) {
+	if mock.RedeemMagicLinkFunc == nil {
+		panic("ProviderMock.RedeemMagicLinkFunc: method is nil but Provider.RedeemMagicLink was just called")
+	}
+	callInfo := struct {
+		Email          string
+		MagicLinkToken string
+		Client         internal.ClientInfo
+	}{
+		Email:          email,
+		MagicLinkToken: magicLinkToken,
+		Client:         client,
+	}
+	mock.lockRedeemMagicLink.Lock()
+	mock.calls.RedeemMagicLink = append(mock.calls.RedeemMagicLink, callInfo)
+	mock.lockRedeemMagicLink.Unlock()
+	return mock.RedeemMagicLinkFunc(email, magicLinkToken, client)
+}
+
+// RedeemMagicLinkCalls gets all the calls that were made to RedeemMagicLink.
+// Check the length with:
+//     len(mockedProvider.RedeemMagicLinkCalls())
+func (mock *ProviderMock) RedeemMagicLinkCalls() []struct {
+	Email          string
+	MagicLinkToken string
+	Client         internal.ClientInfo
+} {
+	var calls []struct {
+		Email          string
+		MagicLinkToken string
+		Client         internal.ClientInfo
+	}
+	mock.lockRedeemMagicLink.RLock()
+	calls = mock.calls.RedeemMagicLink
+	mock.lockRedeemMagicLink.RUnlock()
+	return calls
+}
+
 // Refresh calls RefreshFunc.
-func (mock *ProviderMock) Refresh(refreshToken string) (string, string, error) {
+func (mock *ProviderMock) Refresh(refreshToken string, client internal.ClientInfo) (string, string, error) {
 	if mock.RefreshFunc == nil {
 		panic("ProviderMock.RefreshFunc: method is nil but Provider.Refresh was just called")
 	}
 	callInfo := struct {
 		RefreshToken string
+		Client       internal.ClientInfo
 	}{
 		RefreshToken: refreshToken,
+		Client:       client,
 	}
 	mock.lockRefresh.Lock()
 	mock.calls.Refresh = append(mock.calls.Refresh, callInfo)
 	mock.lockRefresh.Unlock()
-	return mock.RefreshFunc(refreshToken)
+	return mock.RefreshFunc(refreshToken, client)
 }
 
 // RefreshCalls gets all the calls that were made to Refresh.
@@ -314,9 +1333,11 @@ func (mock *ProviderMock) Refresh(refreshToken string) (string, string, error) {
 //     len(mockedProvider.RefreshCalls())
 func (mock *ProviderMock) RefreshCalls() []struct {
 	RefreshToken string
+	Client       internal.ClientInfo
 } {
 	var calls []struct {
 		RefreshToken string
+		Client       internal.ClientInfo
 	}
 	mock.lockRefresh.RLock()
 	calls = mock.calls.Refresh
@@ -324,6 +1345,41 @@ func (mock *ProviderMock) RefreshCalls() []struct {
 	return calls
 }
 
+// Register calls RegisterFunc.
+func (mock *ProviderMock) Register(email string, password string) error {
+	if mock.RegisterFunc == nil {
+		panic("ProviderMock.RegisterFunc: method is nil but Provider.Register was just called")
+	}
+	callInfo := struct {
+		Email    string
+		Password string
+	}{
+		Email:    email,
+		Password: password,
+	}
+	mock.lockRegister.Lock()
+	mock.calls.Register = append(mock.calls.Register, callInfo)
+	mock.lockRegister.Unlock()
+	return mock.RegisterFunc(email, password)
+}
+
+// RegisterCalls gets all the calls that were made to Register.
+// Check the length with:
+//     len(mockedProvider.RegisterCalls())
+func (mock *ProviderMock) RegisterCalls() []struct {
+	Email    string
+	Password string
+} {
+	var calls []struct {
+		Email    string
+		Password string
+	}
+	mock.lockRegister.RLock()
+	calls = mock.calls.Register
+	mock.lockRegister.RUnlock()
+	return calls
+}
+
 // ResetPassword calls ResetPasswordFunc.
 func (mock *ProviderMock) ResetPassword(email string, resetToken string, password string) error {
 	if mock.ResetPasswordFunc == nil {
@@ -363,6 +1419,161 @@ func (mock *ProviderMock) ResetPasswordCalls() []struct {
 	return calls
 }
 
+// Revoke calls RevokeFunc.
+func (mock *ProviderMock) Revoke(token string) error {
+	if mock.RevokeFunc == nil {
+		panic("ProviderMock.RevokeFunc: method is nil but Provider.Revoke was just called")
+	}
+	callInfo := struct {
+		Token string
+	}{
+		Token: token,
+	}
+	mock.lockRevoke.Lock()
+	mock.calls.Revoke = append(mock.calls.Revoke, callInfo)
+	mock.lockRevoke.Unlock()
+	return mock.RevokeFunc(token)
+}
+
+// RevokeCalls gets all the calls that were made to Revoke.
+// Check the length with:
+//     len(mockedProvider.RevokeCalls())
+func (mock *ProviderMock) RevokeCalls() []struct {
+	Token string
+} {
+	var calls []struct {
+		Token string
+	}
+	mock.lockRevoke.RLock()
+	calls = mock.calls.Revoke
+	mock.lockRevoke.RUnlock()
+	return calls
+}
+
+// RevokeAccessTokenByID calls RevokeAccessTokenByIDFunc.
+func (mock *ProviderMock) RevokeAccessTokenByID(jit string) error {
+	if mock.RevokeAccessTokenByIDFunc == nil {
+		panic("ProviderMock.RevokeAccessTokenByIDFunc: method is nil but Provider.RevokeAccessTokenByID was just called")
+	}
+	callInfo := struct {
+		Jit string
+	}{
+		Jit: jit,
+	}
+	mock.lockRevokeAccessTokenByID.Lock()
+	mock.calls.RevokeAccessTokenByID = append(mock.calls.RevokeAccessTokenByID, callInfo)
+	mock.lockRevokeAccessTokenByID.Unlock()
+	return mock.RevokeAccessTokenByIDFunc(jit)
+}
+
+// RevokeAccessTokenByIDCalls gets all the calls that were made to RevokeAccessTokenByID.
+// Check the length with:
+//     len(mockedProvider.RevokeAccessTokenByIDCalls())
+func (mock *ProviderMock) RevokeAccessTokenByIDCalls() []struct {
+	Jit string
+} {
+	var calls []struct {
+		Jit string
+	}
+	mock.lockRevokeAccessTokenByID.RLock()
+	calls = mock.calls.RevokeAccessTokenByID
+	mock.lockRevokeAccessTokenByID.RUnlock()
+	return calls
+}
+
+// Sessions calls SessionsFunc.
+func (mock *ProviderMock) Sessions(email string) ([]internal.Session, error) {
+	if mock.SessionsFunc == nil {
+		panic("ProviderMock.SessionsFunc: method is nil but Provider.Sessions was just called")
+	}
+	callInfo := struct {
+		Email string
+	}{
+		Email: email,
+	}
+	mock.lockSessions.Lock()
+	mock.calls.Sessions = append(mock.calls.Sessions, callInfo)
+	mock.lockSessions.Unlock()
+	return mock.SessionsFunc(email)
+}
+
+// SessionsCalls gets all the calls that were made to Sessions.
+// Check the length with:
+//     len(mockedProvider.SessionsCalls())
+func (mock *ProviderMock) SessionsCalls() []struct {
+	Email string
+} {
+	var calls []struct {
+		Email string
+	}
+	mock.lockSessions.RLock()
+	calls = mock.calls.Sessions
+	mock.lockSessions.RUnlock()
+	return calls
+}
+
+// SessionsByAccessToken calls SessionsByAccessTokenFunc.
+func (mock *ProviderMock) SessionsByAccessToken(accessToken string) ([]internal.Session, error) {
+	if mock.SessionsByAccessTokenFunc == nil {
+		panic("ProviderMock.SessionsByAccessTokenFunc: method is nil but Provider.SessionsByAccessToken was just called")
+	}
+	callInfo := struct {
+		AccessToken string
+	}{
+		AccessToken: accessToken,
+	}
+	mock.lockSessionsByAccessToken.Lock()
+	mock.calls.SessionsByAccessToken = append(mock.calls.SessionsByAccessToken, callInfo)
+	mock.lockSessionsByAccessToken.Unlock()
+	return mock.SessionsByAccessTokenFunc(accessToken)
+}
+
+// SessionsByAccessTokenCalls gets all the calls that were made to SessionsByAccessToken.
+// Check the length with:
+//     len(mockedProvider.SessionsByAccessTokenCalls())
+func (mock *ProviderMock) SessionsByAccessTokenCalls() []struct {
+	AccessToken string
+} {
+	var calls []struct {
+		AccessToken string
+	}
+	mock.lockSessionsByAccessToken.RLock()
+	calls = mock.calls.SessionsByAccessToken
+	mock.lockSessionsByAccessToken.RUnlock()
+	return calls
+}
+
+// UnlockUser calls UnlockUserFunc.
+func (mock *ProviderMock) UnlockUser(email string) error {
+	if mock.UnlockUserFunc == nil {
+		panic("ProviderMock.UnlockUserFunc: method is nil but Provider.UnlockUser was just called")
+	}
+	callInfo := struct {
+		Email string
+	}{
+		Email: email,
+	}
+	mock.lockUnlockUser.Lock()
+	mock.calls.UnlockUser = append(mock.calls.UnlockUser, callInfo)
+	mock.lockUnlockUser.Unlock()
+	return mock.UnlockUserFunc(email)
+}
+
+// UnlockUserCalls gets all the calls that were made to UnlockUser.
+// Check the length with:
+//     len(mockedProvider.UnlockUserCalls())
+func (mock *ProviderMock) UnlockUserCalls() []struct {
+	Email string
+} {
+	var calls []struct {
+		Email string
+	}
+	mock.lockUnlockUser.RLock()
+	calls = mock.calls.UnlockUser
+	mock.lockUnlockUser.RUnlock()
+	return calls
+}
+
 // UpdateUser calls UpdateUserFunc.
 func (mock *ProviderMock) UpdateUser(email string, user internal.User) (internal.User, error) {
 	if mock.UpdateUserFunc == nil {
@@ -397,3 +1608,85 @@ func (mock *ProviderMock) UpdateUserCalls() []struct {
 	mock.lockUpdateUser.RUnlock()
 	return calls
 }
+
+// VerifyEMail calls VerifyEMailFunc.
+func (mock *ProviderMock) VerifyEMail(email string, verificationToken string) error {
+	if mock.VerifyEMailFunc == nil {
+		panic("ProviderMock.VerifyEMailFunc: method is nil but Provider.VerifyEMail was just called")
+	}
+	callInfo := struct {
+		Email             string
+		VerificationToken string
+	}{
+		Email:             email,
+		VerificationToken: verificationToken,
+	}
+	mock.lockVerifyEMail.Lock()
+	mock.calls.VerifyEMail = append(mock.calls.VerifyEMail, callInfo)
+	mock.lockVerifyEMail.Unlock()
+	return mock.VerifyEMailFunc(email, verificationToken)
+}
+
+// VerifyEMailCalls gets all the calls that were made to VerifyEMail.
+// Check the length with:
+//     len(mockedProvider.VerifyEMailCalls())
+func (mock *ProviderMock) VerifyEMailCalls() []struct {
+	Email             string
+	VerificationToken string
+} {
+	var calls []struct {
+		Email             string
+		VerificationToken string
+	}
+	mock.lockVerifyEMail.RLock()
+	calls = mock.calls.VerifyEMail
+	mock.lockVerifyEMail.RUnlock()
+	return calls
+}
+
+// VerifyMFA calls VerifyMFAFunc.
+func (mock *ProviderMock) VerifyMFA(email string, mfaToken string, code string, recoveryCode string, client internal.ClientInfo) (string, string, error) {
+	if mock.VerifyMFAFunc == nil {
+		panic("ProviderMock.VerifyMFAFunc: method is nil but Provider.VerifyMFA was just called")
+	}
+	callInfo := struct {
+		Email        string
+		MfaToken     string
+		Code         string
+		RecoveryCode string
+		Client       internal.ClientInfo
+	}{
+		Email:        email,
+		MfaToken:     mfaToken,
+		Code:         code,
+		RecoveryCode: recoveryCode,
+		Client:       client,
+	}
+	mock.lockVerifyMFA.Lock()
+	mock.calls.VerifyMFA = append(mock.calls.VerifyMFA, callInfo)
+	mock.lockVerifyMFA.Unlock()
+	return mock.VerifyMFAFunc(email, mfaToken, code, recoveryCode, client)
+}
+
+// VerifyMFACalls gets all the calls that were made to VerifyMFA.
+// Check the length with:
+//     len(mockedProvider.VerifyMFACalls())
+func (mock *ProviderMock) VerifyMFACalls() []struct {
+	Email        string
+	MfaToken     string
+	Code         string
+	RecoveryCode string
+	Client       internal.ClientInfo
+} {
+	var calls []struct {
+		Email        string
+		MfaToken     string
+		Code         string
+		RecoveryCode string
+		Client       internal.ClientInfo
+	}
+	mock.lockVerifyMFA.RLock()
+	calls = mock.calls.VerifyMFA
+	mock.lockVerifyMFA.RUnlock()
+	return calls
+}
@@ -0,0 +1,102 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/leberKleber/simple-jwt-provider/internal"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// registerHandler creates a new unverified user and sends an email-verification mail
+func (s *Server) registerHandler(w http.ResponseWriter, r *http.Request) {
+	requestBody := struct {
+		EMail    string `json:"email"`
+		Password string `json:"password"`
+	}{}
+
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	if requestBody.EMail == "" {
+		writeError(w, http.StatusBadRequest, "email must be set")
+		return
+	}
+
+	if requestBody.Password == "" {
+		writeError(w, http.StatusBadRequest, "password must be set")
+		return
+	}
+
+	err = s.p.Register(requestBody.EMail, requestBody.Password)
+	if err != nil {
+		if errors.Is(err, internal.ErrRegistrationDisabled) {
+			writeError(w, http.StatusNotFound, "registration is not enabled")
+			return
+		}
+		if errors.Is(err, internal.ErrInvalidEMail) {
+			writeError(w, http.StatusBadRequest, "email is invalid")
+			return
+		}
+		if errors.Is(err, internal.ErrEMailDomainNotAllowed) {
+			writeError(w, http.StatusForbidden, "email domain is not allowed")
+			return
+		}
+		if writePasswordPolicyError(w, err) {
+			return
+		}
+		if errors.Is(err, internal.ErrUserAlreadyExists) {
+			// the response is the same as for new users, so this endpoint does not reveal whether the user exists
+			logrus.WithField("email", requestBody.EMail).Warn("Somebody tried to register an existing User")
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+
+		logrus.WithError(err).Error("Failed to register User")
+		writeInternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// verifyEMailHandler verifies the email of a self-registered user with the token of the email-verification mail
+func (s *Server) verifyEMailHandler(w http.ResponseWriter, r *http.Request) {
+	requestBody := struct {
+		EMail             string `json:"email"`
+		VerificationToken string `json:"verification_token"`
+	}{}
+
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	if requestBody.EMail == "" {
+		writeError(w, http.StatusBadRequest, "email must be set")
+		return
+	}
+
+	if requestBody.VerificationToken == "" {
+		writeError(w, http.StatusBadRequest, "verification-token must be set")
+		return
+	}
+
+	err = s.p.VerifyEMail(requestBody.EMail, requestBody.VerificationToken)
+	if err != nil {
+		if errors.Is(err, internal.ErrNoValidTokenFound) {
+			writeError(w, http.StatusBadRequest, "verification-token is invalid or token email combination is not correct")
+			return
+		}
+
+		logrus.WithError(err).Error("Failed to verify email")
+		writeInternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
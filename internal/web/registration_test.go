@@ -0,0 +1,277 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/leberKleber/simple-jwt-provider/internal"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegisterHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		requestBody          string
+		providerError        error
+		expectedEMail        string
+		expectedPassword     string
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Happycase",
+			requestBody:          `{"email": "test.test@test.test", "password": "s3cr3t"}`,
+			expectedEMail:        "test.test@test.test",
+			expectedPassword:     "s3cr3t",
+			expectedResponseCode: http.StatusCreated,
+		},
+		{
+			name:                 "Invalid JSON",
+			requestBody:          `{"email test.test@test.test"}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"invalid JSON"}`,
+		},
+		{
+			name:                 "Missing email",
+			requestBody:          `{"password": "s3cr3t"}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"email must be set"}`,
+		},
+		{
+			name:                 "Missing password",
+			requestBody:          `{"email": "test.test@test.test"}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"password must be set"}`,
+		},
+		{
+			name:                 "Registration disabled",
+			requestBody:          `{"email": "test.test@test.test", "password": "s3cr3t"}`,
+			providerError:        internal.ErrRegistrationDisabled,
+			expectedEMail:        "test.test@test.test",
+			expectedPassword:     "s3cr3t",
+			expectedResponseCode: http.StatusNotFound,
+			expectedResponseBody: `{"message":"registration is not enabled"}`,
+		},
+		{
+			name:                 "Invalid email",
+			requestBody:          `{"email": "test.test", "password": "s3cr3t"}`,
+			providerError:        internal.ErrInvalidEMail,
+			expectedEMail:        "test.test",
+			expectedPassword:     "s3cr3t",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"email is invalid"}`,
+		},
+		{
+			name:                 "Domain not allowed",
+			requestBody:          `{"email": "test.test@test.test", "password": "s3cr3t"}`,
+			providerError:        internal.ErrEMailDomainNotAllowed,
+			expectedEMail:        "test.test@test.test",
+			expectedPassword:     "s3cr3t",
+			expectedResponseCode: http.StatusForbidden,
+			expectedResponseBody: `{"message":"email domain is not allowed"}`,
+		},
+		{
+			name:                 "Password policy violated",
+			requestBody:          `{"email": "test.test@test.test", "password": "s3cr3t"}`,
+			providerError:        internal.PasswordPolicyError{Violations: []internal.PasswordPolicyViolation{{Rule: "min_length", Message: "password must be at least 8 characters long"}}},
+			expectedEMail:        "test.test@test.test",
+			expectedPassword:     "s3cr3t",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"password violates the password policy","violations":[{"rule":"min_length","message":"password must be at least 8 characters long"}]}`,
+		},
+		{
+			name:                 "User already exists",
+			requestBody:          `{"email": "test.test@test.test", "password": "s3cr3t"}`,
+			providerError:        internal.ErrUserAlreadyExists,
+			expectedEMail:        "test.test@test.test",
+			expectedPassword:     "s3cr3t",
+			expectedResponseCode: http.StatusCreated,
+		},
+		{
+			name:                 "Unexpected error",
+			requestBody:          `{"email": "test.test@test.test", "password": "s3cr3t"}`,
+			providerError:        errors.New("nope"),
+			expectedEMail:        "test.test@test.test",
+			expectedPassword:     "s3cr3t",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenEMail, givenPassword string
+
+			toTest := NewServer(&ProviderMock{
+				RegisterFunc: func(email string, password string) error {
+					givenEMail = email
+					givenPassword = password
+					return tt.providerError
+				},
+			}, false, "", "", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+
+			bb := bytes.NewReader([]byte(tt.requestBody))
+			req, err := http.NewRequest(http.MethodPost, testServer.URL+"/v1/auth/register", bb)
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			if givenEMail != tt.expectedEMail {
+				t.Errorf("Provider called with unexpected email. Given: %q, Expected: %q", givenEMail, tt.expectedEMail)
+			}
+
+			if givenPassword != tt.expectedPassword {
+				t.Errorf("Provider called with unexpected password. Given: %q, Expected: %q", givenPassword, tt.expectedPassword)
+			}
+
+			var compactedRespBodyAsBytes []byte
+			if resp.ContentLength > 0 {
+				compactedRespBody := &bytes.Buffer{}
+				err = json.Compact(compactedRespBody, respBody)
+				if err != nil {
+					t.Fatalf("Failed to compact json: %s", err)
+				}
+
+				compactedRespBodyAsBytes = compactedRespBody.Bytes()
+			}
+
+			if !bytes.Equal(compactedRespBodyAsBytes, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: %q, Given: %q", tt.expectedResponseBody, string(compactedRespBodyAsBytes))
+			}
+		})
+	}
+}
+
+func TestVerifyEMailHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		requestBody          string
+		providerError        error
+		expectedEMail        string
+		expectedToken        string
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Happycase",
+			requestBody:          `{"email": "test.test@test.test", "verification_token": "myVerificationToken"}`,
+			expectedEMail:        "test.test@test.test",
+			expectedToken:        "myVerificationToken",
+			expectedResponseCode: http.StatusNoContent,
+		},
+		{
+			name:                 "Invalid JSON",
+			requestBody:          `{"email test.test@test.test"}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"invalid JSON"}`,
+		},
+		{
+			name:                 "Missing email",
+			requestBody:          `{"verification_token": "myVerificationToken"}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"email must be set"}`,
+		},
+		{
+			name:                 "Missing verification-token",
+			requestBody:          `{"email": "test.test@test.test"}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"verification-token must be set"}`,
+		},
+		{
+			name:                 "Invalid token",
+			requestBody:          `{"email": "test.test@test.test", "verification_token": "myVerificationToken"}`,
+			providerError:        internal.ErrNoValidTokenFound,
+			expectedEMail:        "test.test@test.test",
+			expectedToken:        "myVerificationToken",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"verification-token is invalid or token email combination is not correct"}`,
+		},
+		{
+			name:                 "Unexpected error",
+			requestBody:          `{"email": "test.test@test.test", "verification_token": "myVerificationToken"}`,
+			providerError:        errors.New("nope"),
+			expectedEMail:        "test.test@test.test",
+			expectedToken:        "myVerificationToken",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenEMail, givenToken string
+
+			toTest := NewServer(&ProviderMock{
+				VerifyEMailFunc: func(email string, verificationToken string) error {
+					givenEMail = email
+					givenToken = verificationToken
+					return tt.providerError
+				},
+			}, false, "", "", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+
+			bb := bytes.NewReader([]byte(tt.requestBody))
+			req, err := http.NewRequest(http.MethodPost, testServer.URL+"/v1/auth/verify-email", bb)
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			if givenEMail != tt.expectedEMail {
+				t.Errorf("Provider called with unexpected email. Given: %q, Expected: %q", givenEMail, tt.expectedEMail)
+			}
+
+			if givenToken != tt.expectedToken {
+				t.Errorf("Provider called with unexpected verification-token. Given: %q, Expected: %q", givenToken, tt.expectedToken)
+			}
+
+			var compactedRespBodyAsBytes []byte
+			if resp.ContentLength > 0 {
+				compactedRespBody := &bytes.Buffer{}
+				err = json.Compact(compactedRespBody, respBody)
+				if err != nil {
+					t.Fatalf("Failed to compact json: %s", err)
+				}
+
+				compactedRespBodyAsBytes = compactedRespBody.Bytes()
+			}
+
+			if !bytes.Equal(compactedRespBodyAsBytes, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: %q, Given: %q", tt.expectedResponseBody, string(compactedRespBodyAsBytes))
+			}
+		})
+	}
+}
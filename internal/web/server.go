@@ -4,9 +4,15 @@ import (
 	"encoding/json"
 	"github.com/gorilla/mux"
 	"github.com/leberKleber/simple-jwt-provider/internal"
+	"github.com/leberKleber/simple-jwt-provider/internal/jwk"
 	"github.com/leberKleber/simple-jwt-provider/internal/web/middleware"
+	"github.com/leberKleber/simple-jwt-provider/internal/webauthn"
 	"github.com/sirupsen/logrus"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 var httpListenAndServe = http.ListenAndServe
@@ -14,37 +20,115 @@ var httpListenAndServe = http.ListenAndServe
 // Provider encapsulates internal.Provider to generate mocks
 //go:generate moq -out provider_moq_test.go . Provider
 type Provider interface {
-	Login(email, password string) (string, string, error)
-	Refresh(refreshToken string) (string, string, error)
+	Login(email, password string, client internal.ClientInfo) (string, string, error)
+	Refresh(refreshToken string, client internal.ClientInfo) (string, string, error)
+	Introspect(token string) (bool, map[string]interface{}, error)
+	Logout(refreshToken string) error
+	LogoutEverywhere(refreshToken string) error
+	Revoke(token string) error
+	RevokeAccessTokenByID(jit string) error
+	Sessions(email string) ([]internal.Session, error)
+	SessionsByAccessToken(accessToken string) ([]internal.Session, error)
+	DeleteSession(email, id string) error
 	CreatePasswordResetRequest(email string) error
 	ResetPassword(email, resetToken, password string) error
+	Register(email, password string) error
+	VerifyEMail(email, verificationToken string) error
+	CreateMagicLink(email string) error
+	RedeemMagicLink(email, magicLinkToken string, client internal.ClientInfo) (string, string, error)
+	ChangePassword(accessToken, currentPassword, newPassword string, client internal.ClientInfo) error
+	EnrollTOTP(accessToken, password string, client internal.ClientInfo) (internal.TOTPEnrollment, error)
+	ConfirmTOTP(accessToken, code string) ([]string, error)
+	EnrollEMailOTP(accessToken string) error
+	ConfirmEMailOTP(accessToken, code string) error
+	VerifyMFA(email, mfaToken, code, recoveryCode string, client internal.ClientInfo) (string, string, error)
+	DisableMFA(email string) error
+	BeginWebAuthnRegistration(accessToken string) (webauthn.CreationOptions, error)
+	FinishWebAuthnRegistration(accessToken string, clientDataJSON, attestationObject []byte) error
+	BeginWebAuthnLogin(email string) (webauthn.RequestOptions, error)
+	FinishWebAuthnLogin(email string, credentialID, clientDataJSON, authenticatorData, signature []byte, client internal.ClientInfo) (string, string, error)
 	CreateUser(user internal.User) error
 	UpdateUser(email string, user internal.User) (internal.User, error)
 	GetUser(email string) (internal.User, error)
 	DeleteUser(email string) error
+	UnlockUser(email string) error
+	JWKS() jwk.Set
+	AccessTokenLifetime() time.Duration
+	OpenIDConfiguration() internal.OpenIDConfiguration
 }
 
 // Server should be created via NewServer and starts with ListenAndServe all http endpoints for this service.
 type Server struct {
-	h http.Handler
-	p Provider
+	h        http.Handler
+	p        Provider
+	clientIP middleware.KeyFunc
 }
 
-// NewServer returns a Server instance with configure http routs
-func NewServer(p Provider, enableAdminAPI bool, adminAPIUsername, adminAPIPassword string) *Server {
-	s := &Server{}
+// NewServer returns a Server instance with configure http routs. introspectionClients maps client-ids to their
+// (plain or 'bcrypt:' prefixed) secrets which are allowed to introspect tokens additionally to the admin.
+// rateLimitRequests limits the requests per client ip and email to the oauth2 token, login, refresh, password-reset-request,
+// register, verify-email, magic-link, password-change, mfa and webauthn endpoints within rateLimitInterval. rateLimitRequests = 0 disables the rate limit.
+// The X-Forwarded-For header will only be used to determine the client ip when the request comes from one of the
+// trustedProxies.
+func NewServer(p Provider, enableAdminAPI bool, adminAPIUsername, adminAPIPassword string, introspectionClients map[string]string, rateLimitRequests int, rateLimitInterval time.Duration, trustedProxies []*net.IPNet) *Server {
+	s := &Server{clientIP: middleware.ClientIP(trustedProxies)}
 	r := mux.NewRouter()
 
 	r.Use(contentTypeMiddleware)
 	r.NotFoundHandler = http.HandlerFunc(notFoundHandler)
 	r.MethodNotAllowedHandler = http.HandlerFunc(methodNotAllowedHandler)
 
+	r.Path(jwksPath).Methods(http.MethodGet).HandlerFunc(s.jwksHandler)
+	r.Path("/.well-known/openid-configuration").Methods(http.MethodGet).HandlerFunc(s.openIDConfigurationHandler)
+	r.Path("/.well-known/oauth-authorization-server").Methods(http.MethodGet).HandlerFunc(s.authorizationServerMetadataHandler)
+
+	rateLimited := func(h http.HandlerFunc, keyFuncs ...middleware.KeyFunc) http.Handler {
+		if rateLimitRequests <= 0 {
+			return h
+		}
+		return middleware.RateLimit(middleware.NewRateLimiter(rateLimitRequests, rateLimitInterval), keyFuncs...)(h)
+	}
+
+	r.Path(tokenEndpointPath).Methods(http.MethodPost).Handler(rateLimited(s.oauth2TokenHandler, s.clientIP))
+
 	v1 := r.PathPrefix("/v1").Subrouter()
 	v1.Path("/internal/alive").Methods(http.MethodGet).HandlerFunc(s.aliveHandler)
-	v1.Path("/auth/login").Methods(http.MethodPost).HandlerFunc(s.loginHandler)
-	v1.Path("/auth/refresh").Methods(http.MethodPost).HandlerFunc(s.refreshHandler)
-	v1.Path("/auth/password-reset-request").Methods(http.MethodPost).HandlerFunc(s.passwordResetRequestHandler)
+
+	v1.Path("/auth/login").Methods(http.MethodPost).Handler(rateLimited(s.loginHandler, s.clientIP, middleware.JSONBodyKey("email")))
+	v1.Path("/auth/refresh").Methods(http.MethodPost).Handler(rateLimited(s.refreshHandler, s.clientIP))
+	v1.Path("/auth/logout").Methods(http.MethodPost).HandlerFunc(s.logoutHandler)
+	v1.Path("/auth/logout-everywhere").Methods(http.MethodPost).HandlerFunc(s.logoutEverywhereHandler)
+	v1.Path("/auth/revoke").Methods(http.MethodPost).HandlerFunc(s.revokeHandler)
+	v1.Path("/auth/sessions").Methods(http.MethodGet).HandlerFunc(s.sessionsHandler)
+	v1.Path("/auth/password-reset-request").Methods(http.MethodPost).Handler(rateLimited(s.passwordResetRequestHandler, s.clientIP, middleware.JSONBodyKey("email")))
 	v1.Path("/auth/password-reset").Methods(http.MethodPost).HandlerFunc(s.passwordResetHandler)
+	v1.Path("/auth/register").Methods(http.MethodPost).Handler(rateLimited(s.registerHandler, s.clientIP, middleware.JSONBodyKey("email")))
+	v1.Path("/auth/verify-email").Methods(http.MethodPost).Handler(rateLimited(s.verifyEMailHandler, s.clientIP))
+	v1.Path("/auth/magic-link").Methods(http.MethodPost).Handler(rateLimited(s.magicLinkHandler, s.clientIP, middleware.JSONBodyKey("email")))
+	v1.Path("/auth/magic-link/redeem").Methods(http.MethodPost).Handler(rateLimited(s.magicLinkRedeemHandler, s.clientIP, middleware.JSONBodyKey("email")))
+	v1.Path("/auth/password-change").Methods(http.MethodPost).Handler(rateLimited(s.passwordChangeHandler, s.clientIP))
+	v1.Path("/auth/mfa/totp").Methods(http.MethodPost).Handler(rateLimited(s.totpEnrollHandler, s.clientIP))
+	v1.Path("/auth/mfa/totp/confirm").Methods(http.MethodPost).Handler(rateLimited(s.totpConfirmHandler, s.clientIP))
+	v1.Path("/auth/mfa/email").Methods(http.MethodPost).Handler(rateLimited(s.emailOTPEnrollHandler, s.clientIP))
+	v1.Path("/auth/mfa/email/confirm").Methods(http.MethodPost).Handler(rateLimited(s.emailOTPConfirmHandler, s.clientIP))
+	v1.Path("/auth/mfa/verify").Methods(http.MethodPost).Handler(rateLimited(s.mfaVerifyHandler, s.clientIP, middleware.JSONBodyKey("email")))
+	v1.Path("/auth/webauthn/registration/options").Methods(http.MethodPost).Handler(rateLimited(s.webAuthnRegistrationOptionsHandler, s.clientIP))
+	v1.Path("/auth/webauthn/registration").Methods(http.MethodPost).Handler(rateLimited(s.webAuthnRegistrationHandler, s.clientIP))
+	v1.Path("/auth/webauthn/login/options").Methods(http.MethodPost).Handler(rateLimited(s.webAuthnLoginOptionsHandler, s.clientIP, middleware.JSONBodyKey("email")))
+	v1.Path("/auth/webauthn/login").Methods(http.MethodPost).Handler(rateLimited(s.webAuthnLoginHandler, s.clientIP, middleware.JSONBodyKey("email")))
+
+	introspectionCredentials := map[string]string{}
+	for clientID, secret := range introspectionClients {
+		introspectionCredentials[clientID] = secret
+	}
+	if enableAdminAPI {
+		introspectionCredentials[adminAPIUsername] = adminAPIPassword
+	}
+	if len(introspectionCredentials) > 0 {
+		introspection := v1.Path("/auth/introspect").Subrouter()
+		introspection.Use(middleware.MultiBasicAuth(introspectionCredentials))
+		introspection.Methods(http.MethodPost).HandlerFunc(s.introspectionHandler)
+	}
 
 	if enableAdminAPI {
 		adminAPI := v1.PathPrefix("/admin").Subrouter()
@@ -54,6 +138,11 @@ func NewServer(p Provider, enableAdminAPI bool, adminAPIUsername, adminAPIPasswo
 		adminAPI.Path("/users/{email}").Methods(http.MethodGet).HandlerFunc(s.getUserHandler)
 		adminAPI.Path("/users/{email}").Methods(http.MethodPut).HandlerFunc(s.updateUserHandler)
 		adminAPI.Path("/users/{email}").Methods(http.MethodDelete).HandlerFunc(s.deleteUserHandler)
+		adminAPI.Path("/users/{email}/lockout").Methods(http.MethodDelete).HandlerFunc(s.unlockUserHandler)
+		adminAPI.Path("/users/{email}/mfa").Methods(http.MethodDelete).HandlerFunc(s.disableMFAHandler)
+		adminAPI.Path("/users/{email}/sessions").Methods(http.MethodGet).HandlerFunc(s.userSessionsHandler)
+		adminAPI.Path("/users/{email}/sessions/{id}").Methods(http.MethodDelete).HandlerFunc(s.deleteUserSessionHandler)
+		adminAPI.Path("/revoked-tokens").Methods(http.MethodPost).HandlerFunc(s.revokeAccessTokenHandler)
 	}
 
 	s.h = r
@@ -104,6 +193,17 @@ func writeInternalServerError(w http.ResponseWriter) {
 	}
 }
 
+// setRetryAfter sets the Retry-After header (https://tools.ietf.org/html/rfc7231#section-7.1.3) in seconds until the
+// given time
+func setRetryAfter(w http.ResponseWriter, until time.Time) {
+	seconds := int64(math.Ceil(time.Until(until).Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+}
+
 func notFoundHandler(w http.ResponseWriter, _ *http.Request) {
 	writeError(w, http.StatusNotFound, "endpoint not found")
 }
@@ -8,14 +8,16 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 )
 
 func TestBasicAuth(t *testing.T) {
 	expectedResponseCode := http.StatusForbidden
 	expectedResponseBody := `{"message":"forbidden"}`
 
-	toTest := NewServer(nil, true, "un", "pw")
+	toTest := NewServer(nil, true, "un", "pw", nil, 0, 0, nil)
 	testServer := httptest.NewServer(toTest.h)
 
 	req, err := http.NewRequest(http.MethodPost, testServer.URL+"/v1/admin/users", nil)
@@ -60,7 +62,7 @@ func TestNotFoundHandler(t *testing.T) {
 	expectedResponseCode := http.StatusNotFound
 	expectedResponseBody := `{"message":"endpoint not found"}`
 
-	toTest := NewServer(nil, false, "", "")
+	toTest := NewServer(nil, false, "", "", nil, 0, 0, nil)
 	testServer := httptest.NewServer(toTest.h)
 
 	req, err := http.NewRequest(http.MethodGet, testServer.URL+"/unexpected/endpoint", nil)
@@ -105,7 +107,7 @@ func TestMethodNotAllowedHandler(t *testing.T) {
 	expectedResponseCode := http.StatusMethodNotAllowed
 	expectedResponseBody := `{"message":"method not allowed"}`
 
-	toTest := NewServer(nil, false, "", "")
+	toTest := NewServer(nil, false, "", "", nil, 0, 0, nil)
 	testServer := httptest.NewServer(toTest.h)
 
 	req, err := http.NewRequest(http.MethodGet, testServer.URL+"/v1/auth/password-reset-request", nil)
@@ -182,3 +184,21 @@ type httpHandlerMock struct {
 }
 
 func (t httpHandlerMock) ServeHTTP(http.ResponseWriter, *http.Request) {}
+
+func TestOAuth2TokenRateLimit(t *testing.T) {
+	toTest := NewServer(nil, false, "", "", nil, 1, time.Minute, nil)
+	testServer := httptest.NewServer(toTest.h)
+
+	expectedResponseCodes := []int{http.StatusBadRequest, http.StatusTooManyRequests}
+	for i, expectedResponseCode := range expectedResponseCodes {
+		resp, err := http.PostForm(testServer.URL+"/oauth2/token", url.Values{"grant_type": {"client_credentials"}})
+		if err != nil {
+			t.Fatalf("Failed to call server cause: %s", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != expectedResponseCode {
+			t.Errorf("Request %d respond with unexpected status code. Expected: %d, Given: %d", i, expectedResponseCode, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/leberKleber/simple-jwt-provider/internal"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type sessionResponseBody struct {
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	UserAgent  string    `json:"user_agent"`
+	ClientIP   string    `json:"client_ip"`
+}
+
+type sessionsResponseBody struct {
+	Sessions []sessionResponseBody `json:"sessions"`
+}
+
+// sessionsHandler lists the sessions of the user who is authenticated by the access-token in the Authorization header
+func (s *Server) sessionsHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, ok := requireBearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	sessions, err := s.p.SessionsByAccessToken(accessToken)
+	if err != nil {
+		if errors.Is(err, internal.ErrInvalidToken) {
+			writeInvalidAccessToken(w)
+			return
+		}
+
+		logrus.WithError(err).Error("Failed to list sessions")
+		writeInternalServerError(w)
+		return
+	}
+
+	writeSessions(w, sessions)
+}
+
+func (s *Server) userSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	email, err := url.PathUnescape(mux.Vars(r)["email"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "could not unescape email")
+		return
+	}
+
+	sessions, err := s.p.Sessions(email)
+	if err != nil {
+		if errors.Is(err, internal.ErrUserNotFound) {
+			writeError(w, http.StatusNotFound, "User with given email doesn't exists")
+			return
+		}
+
+		logrus.WithError(err).Error("Failed to list sessions")
+		writeInternalServerError(w)
+		return
+	}
+
+	writeSessions(w, sessions)
+}
+
+func (s *Server) deleteUserSessionHandler(w http.ResponseWriter, r *http.Request) {
+	email, err := url.PathUnescape(mux.Vars(r)["email"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "could not unescape email")
+		return
+	}
+
+	err = s.p.DeleteSession(email, mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, internal.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, "Session with given id doesn't exists")
+			return
+		}
+
+		logrus.WithError(err).Error("Failed to delete session")
+		writeInternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeSessions(w http.ResponseWriter, sessions []internal.Session) {
+	respBody := sessionsResponseBody{Sessions: []sessionResponseBody{}}
+	for _, session := range sessions {
+		respBody.Sessions = append(respBody.Sessions, sessionResponseBody{
+			ID:         session.ID,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			UserAgent:  session.UserAgent,
+			ClientIP:   session.ClientIP,
+		})
+	}
+
+	err := json.NewEncoder(w).Encode(respBody)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to encode sessions")
+		writeInternalServerError(w)
+		return
+	}
+}
+
+// requireBearerToken returns the access-token of the Authorization header. When it is missing, it responds with http
+// status 401 and returns false.
+func requireBearerToken(w http.ResponseWriter, r *http.Request) (string, bool) {
+	accessToken := bearerToken(r)
+	if accessToken == "" {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, "access-token must be set as bearer token")
+		return "", false
+	}
+
+	return accessToken, true
+}
+
+// writeInvalidAccessToken responds with http status 401 when the given access-token is not valid
+func writeInvalidAccessToken(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	writeError(w, http.StatusUnauthorized, "invalid access-token")
+}
+
+// bearerToken returns the token of the Authorization header as described in https://tools.ietf.org/html/rfc6750#section-2.1
+func bearerToken(r *http.Request) string {
+	authorization := r.Header.Get("Authorization")
+	if len(authorization) < 7 || !strings.EqualFold(authorization[:7], "Bearer ") {
+		return ""
+	}
+
+	return strings.TrimSpace(authorization[7:])
+}
+
+// clientInfo describes the client of the given request. The client ip will be taken from the X-Forwarded-For header
+// when the request comes from a trusted proxy.
+func (s *Server) clientInfo(r *http.Request) internal.ClientInfo {
+	return internal.ClientInfo{
+		UserAgent: r.UserAgent(),
+		IP:        s.clientIP(r),
+	}
+}
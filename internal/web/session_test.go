@@ -0,0 +1,264 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"github.com/leberKleber/simple-jwt-provider/internal"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSessionsHandler(t *testing.T) {
+	sessions := []internal.Session{{
+		ID:         "family-id",
+		CreatedAt:  time.Date(2021, 4, 19, 10, 0, 0, 0, time.UTC),
+		LastUsedAt: time.Date(2021, 4, 19, 11, 0, 0, 0, time.UTC),
+		ExpiresAt:  time.Date(2021, 4, 26, 11, 0, 0, 0, time.UTC),
+		UserAgent:  "my-agent",
+		ClientIP:   "127.0.0.1",
+	}}
+
+	tests := []struct {
+		name                 string
+		authorization        string
+		providerSessions     []internal.Session
+		providerError        error
+		expectedAccessToken  string
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Happycase",
+			authorization:        "Bearer myAccessToken",
+			providerSessions:     sessions,
+			expectedAccessToken:  "myAccessToken",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"sessions":[{"id":"family-id","created_at":"2021-04-19T10:00:00Z","last_used_at":"2021-04-19T11:00:00Z","expires_at":"2021-04-26T11:00:00Z","user_agent":"my-agent","client_ip":"127.0.0.1"}]}`,
+		}, {
+			name:                 "Without sessions",
+			authorization:        "bearer myAccessToken",
+			expectedAccessToken:  "myAccessToken",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"sessions":[]}`,
+		}, {
+			name:                 "Missing access-token",
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"message":"access-token must be set as bearer token"}`,
+		}, {
+			name:                 "Basic auth",
+			authorization:        "Basic dXNlcm5hbWU6cGFzc3dvcmQ=",
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"message":"access-token must be set as bearer token"}`,
+		}, {
+			name:                 "Invalid access-token",
+			authorization:        "Bearer myAccessToken",
+			providerError:        internal.ErrInvalidToken,
+			expectedAccessToken:  "myAccessToken",
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"message":"invalid access-token"}`,
+		}, {
+			name:                 "Unexpected error",
+			authorization:        "Bearer myAccessToken",
+			providerError:        errors.New("nope"),
+			expectedAccessToken:  "myAccessToken",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenAccessToken string
+
+			toTest := NewServer(&ProviderMock{
+				SessionsByAccessTokenFunc: func(accessToken string) ([]internal.Session, error) {
+					givenAccessToken = accessToken
+					return tt.providerSessions, tt.providerError
+				},
+			}, false, "", "", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			req, err := http.NewRequest(http.MethodGet, testServer.URL+"/v1/auth/sessions", nil)
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			if resp.StatusCode == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") == "" {
+				t.Error("WWW-Authenticate header must be set on unauthorized responses")
+			}
+
+			if givenAccessToken != tt.expectedAccessToken {
+				t.Errorf("Provider called with unexpected access-token. Given: %q, Expected: %q", givenAccessToken, tt.expectedAccessToken)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			respBody = bytes.TrimSpace(respBody)
+			if !bytes.Equal(respBody, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: \n%q, \nGiven: \n%q", tt.expectedResponseBody, string(respBody))
+			}
+		})
+	}
+}
+
+func TestUserSessionsHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		providerSessions     []internal.Session
+		providerError        error
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Happycase",
+			providerSessions:     []internal.Session{{ID: "family-id", UserAgent: "my-agent"}},
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"sessions":[{"id":"family-id","created_at":"0001-01-01T00:00:00Z","last_used_at":"0001-01-01T00:00:00Z","expires_at":"0001-01-01T00:00:00Z","user_agent":"my-agent","client_ip":""}]}`,
+		}, {
+			name:                 "User not found",
+			providerError:        internal.ErrUserNotFound,
+			expectedResponseCode: http.StatusNotFound,
+			expectedResponseBody: `{"message":"User with given email doesn't exists"}`,
+		}, {
+			name:                 "Unexpected error",
+			providerError:        errors.New("nope"),
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenEMail string
+
+			toTest := NewServer(&ProviderMock{
+				SessionsFunc: func(email string) ([]internal.Session, error) {
+					givenEMail = email
+					return tt.providerSessions, tt.providerError
+				},
+			}, true, "username", "password", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			req, err := http.NewRequest(http.MethodGet, testServer.URL+"/v1/admin/users/test@test.test/sessions", nil)
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+			req.SetBasicAuth("username", "password")
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			if givenEMail != "test@test.test" {
+				t.Errorf("Provider called with unexpected email. Given: %q, Expected: %q", givenEMail, "test@test.test")
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			respBody = bytes.TrimSpace(respBody)
+			if !bytes.Equal(respBody, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: \n%q, \nGiven: \n%q", tt.expectedResponseBody, string(respBody))
+			}
+		})
+	}
+}
+
+func TestDeleteUserSessionHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		providerError        error
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Happycase",
+			expectedResponseCode: http.StatusNoContent,
+		}, {
+			name:                 "Session not found",
+			providerError:        internal.ErrSessionNotFound,
+			expectedResponseCode: http.StatusNotFound,
+			expectedResponseBody: `{"message":"Session with given id doesn't exists"}`,
+		}, {
+			name:                 "Unexpected error",
+			providerError:        errors.New("nope"),
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenEMail, givenID string
+
+			toTest := NewServer(&ProviderMock{
+				DeleteSessionFunc: func(email string, id string) error {
+					givenEMail = email
+					givenID = id
+					return tt.providerError
+				},
+			}, true, "username", "password", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			req, err := http.NewRequest(http.MethodDelete, testServer.URL+"/v1/admin/users/test@test.test/sessions/family-id", nil)
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+			req.SetBasicAuth("username", "password")
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			if givenEMail != "test@test.test" || givenID != "family-id" {
+				t.Errorf("Provider called with unexpected args. Given: %q, %q", givenEMail, givenID)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			respBody = bytes.TrimSpace(respBody)
+			if !bytes.Equal(respBody, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: \n%q, \nGiven: \n%q", tt.expectedResponseBody, string(respBody))
+			}
+		})
+	}
+}
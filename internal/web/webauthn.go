@@ -0,0 +1,221 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/leberKleber/simple-jwt-provider/internal"
+	"github.com/leberKleber/simple-jwt-provider/internal/webauthn"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+)
+
+// publicKeyCredential is the JSON representation of a PublicKeyCredential (PublicKeyCredential.toJSON()). Binary
+// fields are base64url encoded.
+type publicKeyCredential struct {
+	RawID    string `json:"rawId"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AttestationObject string `json:"attestationObject"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+	} `json:"response"`
+}
+
+// decodeField decodes the given base64url encoded field. Padding is tolerated.
+func decodeField(field string) ([]byte, error) {
+	return webauthn.Encoding.DecodeString(strings.TrimRight(field, "="))
+}
+
+func writeWebAuthnDisabled(w http.ResponseWriter) {
+	writeError(w, http.StatusNotFound, "webauthn is not enabled")
+}
+
+// webAuthnRegistrationOptionsHandler starts the passkey registration of the user who is authenticated by the
+// access-token in the Authorization header and responds with the options for navigator.credentials.create
+func (s *Server) webAuthnRegistrationOptionsHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, ok := requireBearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	options, err := s.p.BeginWebAuthnRegistration(accessToken)
+	if err != nil {
+		if errors.Is(err, internal.ErrWebAuthnDisabled) {
+			writeWebAuthnDisabled(w)
+			return
+		}
+		if errors.Is(err, internal.ErrInvalidToken) {
+			writeInvalidAccessToken(w)
+			return
+		}
+
+		logrus.WithError(err).Error("Failed to begin webauthn registration")
+		writeInternalServerError(w)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(options)
+	if err != nil {
+		logrus.WithError(err).Error("Failed marshal request response")
+		writeInternalServerError(w)
+		return
+	}
+}
+
+// webAuthnRegistrationHandler stores the passkey which has been created by navigator.credentials.create for the user
+// who is authenticated by the access-token in the Authorization header
+func (s *Server) webAuthnRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	accessToken, ok := requireBearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	requestBody := struct {
+		Credential publicKeyCredential `json:"credential"`
+	}{}
+
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	clientDataJSON, err := decodeField(requestBody.Credential.Response.ClientDataJSON)
+	if err != nil || len(clientDataJSON) == 0 {
+		writeError(w, http.StatusBadRequest, "credential.response.clientDataJSON must be set and base64url encoded")
+		return
+	}
+
+	attestationObject, err := decodeField(requestBody.Credential.Response.AttestationObject)
+	if err != nil || len(attestationObject) == 0 {
+		writeError(w, http.StatusBadRequest, "credential.response.attestationObject must be set and base64url encoded")
+		return
+	}
+
+	err = s.p.FinishWebAuthnRegistration(accessToken, clientDataJSON, attestationObject)
+	if err != nil {
+		if errors.Is(err, internal.ErrWebAuthnDisabled) {
+			writeWebAuthnDisabled(w)
+			return
+		}
+		if errors.Is(err, internal.ErrInvalidToken) {
+			writeInvalidAccessToken(w)
+			return
+		}
+		if errors.Is(err, internal.ErrInvalidWebAuthnResponse) {
+			logrus.WithError(err).Warn("Failed to verify webauthn registration")
+			writeError(w, http.StatusBadRequest, "invalid webauthn response")
+			return
+		}
+
+		logrus.WithError(err).Error("Failed to finish webauthn registration")
+		writeInternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// webAuthnLoginOptionsHandler starts a passkey login and responds with the options for navigator.credentials.get
+func (s *Server) webAuthnLoginOptionsHandler(w http.ResponseWriter, r *http.Request) {
+	requestBody := struct {
+		EMail string `json:"email"`
+	}{}
+
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	if requestBody.EMail == "" {
+		writeError(w, http.StatusBadRequest, "email must be set")
+		return
+	}
+
+	options, err := s.p.BeginWebAuthnLogin(requestBody.EMail)
+	if err != nil {
+		if errors.Is(err, internal.ErrWebAuthnDisabled) {
+			writeWebAuthnDisabled(w)
+			return
+		}
+
+		logrus.WithError(err).Error("Failed to begin webauthn login")
+		writeInternalServerError(w)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(options)
+	if err != nil {
+		logrus.WithError(err).Error("Failed marshal request response")
+		writeInternalServerError(w)
+		return
+	}
+}
+
+// webAuthnLoginHandler completes a passkey login with the assertion of navigator.credentials.get and responds with
+// access and refresh token like the login
+func (s *Server) webAuthnLoginHandler(w http.ResponseWriter, r *http.Request) {
+	requestBody := struct {
+		EMail      string              `json:"email"`
+		Credential publicKeyCredential `json:"credential"`
+	}{}
+
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	if requestBody.EMail == "" {
+		writeError(w, http.StatusBadRequest, "email must be set")
+		return
+	}
+
+	credentialID, err := decodeField(requestBody.Credential.RawID)
+	if err != nil || len(credentialID) == 0 {
+		writeError(w, http.StatusBadRequest, "credential.rawId must be set and base64url encoded")
+		return
+	}
+
+	clientDataJSON, err := decodeField(requestBody.Credential.Response.ClientDataJSON)
+	if err != nil || len(clientDataJSON) == 0 {
+		writeError(w, http.StatusBadRequest, "credential.response.clientDataJSON must be set and base64url encoded")
+		return
+	}
+
+	authenticatorData, err := decodeField(requestBody.Credential.Response.AuthenticatorData)
+	if err != nil || len(authenticatorData) == 0 {
+		writeError(w, http.StatusBadRequest, "credential.response.authenticatorData must be set and base64url encoded")
+		return
+	}
+
+	signature, err := decodeField(requestBody.Credential.Response.Signature)
+	if err != nil || len(signature) == 0 {
+		writeError(w, http.StatusBadRequest, "credential.response.signature must be set and base64url encoded")
+		return
+	}
+
+	accessToken, refreshToken, err := s.p.FinishWebAuthnLogin(requestBody.EMail, credentialID, clientDataJSON, authenticatorData, signature, s.clientInfo(r))
+	if err != nil {
+		if errors.Is(err, internal.ErrWebAuthnDisabled) {
+			writeWebAuthnDisabled(w)
+			return
+		}
+		if writeLockoutError(w, err, requestBody.EMail) {
+			return
+		}
+		if errors.Is(err, internal.ErrInvalidWebAuthnResponse) {
+			logrus.WithError(err).WithField("email", requestBody.EMail).Warn("Somebody tried to login with an invalid webauthn response")
+			writeError(w, http.StatusUnauthorized, "invalid webauthn response")
+			return
+		}
+
+		logrus.WithError(err).Error("Failed to finish webauthn login")
+		writeInternalServerError(w)
+		return
+	}
+
+	writeTokens(w, accessToken, refreshToken)
+}
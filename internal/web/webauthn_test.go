@@ -0,0 +1,453 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"github.com/leberKleber/simple-jwt-provider/internal"
+	"github.com/leberKleber/simple-jwt-provider/internal/webauthn"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWebAuthnRegistrationOptionsHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		authorization        string
+		providerOptions      webauthn.CreationOptions
+		providerError        error
+		expectedAccessToken  string
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Happycase",
+			authorization:        "Bearer myAccessToken",
+			providerOptions:      webauthn.CreationOptions{Challenge: "Y2hhbGxlbmdl", Timeout: 60000, Attestation: "none"},
+			expectedAccessToken:  "myAccessToken",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"challenge":"Y2hhbGxlbmdl","rp":{"id":"","name":""},"user":{"id":"","name":"","displayName":""},"pubKeyCredParams":null,"timeout":60000,"excludeCredentials":null,"authenticatorSelection":{"residentKey":"","userVerification":""},"attestation":"none"}`,
+		},
+		{
+			name:                 "Missing access-token",
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"message":"access-token must be set as bearer token"}`,
+		},
+		{
+			name:                 "Invalid access-token",
+			authorization:        "Bearer invalidAccessToken",
+			providerError:        internal.ErrInvalidToken,
+			expectedAccessToken:  "invalidAccessToken",
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"message":"invalid access-token"}`,
+		},
+		{
+			name:                 "WebAuthn disabled",
+			authorization:        "Bearer myAccessToken",
+			providerError:        internal.ErrWebAuthnDisabled,
+			expectedAccessToken:  "myAccessToken",
+			expectedResponseCode: http.StatusNotFound,
+			expectedResponseBody: `{"message":"webauthn is not enabled"}`,
+		},
+		{
+			name:                 "Unexpected error",
+			authorization:        "Bearer myAccessToken",
+			providerError:        errors.New("nope"),
+			expectedAccessToken:  "myAccessToken",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenAccessToken string
+
+			toTest := NewServer(&ProviderMock{
+				BeginWebAuthnRegistrationFunc: func(accessToken string) (webauthn.CreationOptions, error) {
+					givenAccessToken = accessToken
+					return tt.providerOptions, tt.providerError
+				},
+			}, false, "", "", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			req, err := http.NewRequest(http.MethodPost, testServer.URL+"/v1/auth/webauthn/registration/options", nil)
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			if givenAccessToken != tt.expectedAccessToken {
+				t.Errorf("Provider called with unexpected access-token. Expected: %q, Given: %q", tt.expectedAccessToken, givenAccessToken)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			respBody = bytes.TrimSpace(respBody)
+			if !bytes.Equal(respBody, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: \n%q, \nGiven: \n%q", tt.expectedResponseBody, string(respBody))
+			}
+		})
+	}
+}
+
+func TestWebAuthnRegistrationHandler(t *testing.T) {
+	tests := []struct {
+		name                      string
+		authorization             string
+		requestBody               string
+		providerError             error
+		expectedProviderCall      bool
+		expectedClientDataJSON    []byte
+		expectedAttestationObject []byte
+		expectedResponseCode      int
+		expectedResponseBody      string
+	}{
+		{
+			name:                      "Happycase",
+			authorization:             "Bearer myAccessToken",
+			requestBody:               `{"credential": {"rawId": "AQID", "response": {"clientDataJSON": "e30", "attestationObject": "oA=="}}}`,
+			expectedProviderCall:      true,
+			expectedClientDataJSON:    []byte("{}"),
+			expectedAttestationObject: []byte{0xa0},
+			expectedResponseCode:      http.StatusCreated,
+		},
+		{
+			name:                 "Invalid json",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"credential": `,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"invalid JSON"}`,
+		},
+		{
+			name:                 "Missing clientDataJSON",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"credential": {"response": {"attestationObject": "oA"}}}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"credential.response.clientDataJSON must be set and base64url encoded"}`,
+		},
+		{
+			name:                 "Invalid attestationObject",
+			authorization:        "Bearer myAccessToken",
+			requestBody:          `{"credential": {"response": {"clientDataJSON": "e30", "attestationObject": "not base64!"}}}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"credential.response.attestationObject must be set and base64url encoded"}`,
+		},
+		{
+			name:                      "Invalid webauthn response",
+			authorization:             "Bearer myAccessToken",
+			requestBody:               `{"credential": {"response": {"clientDataJSON": "e30", "attestationObject": "oA"}}}`,
+			providerError:             internal.ErrInvalidWebAuthnResponse,
+			expectedProviderCall:      true,
+			expectedClientDataJSON:    []byte("{}"),
+			expectedAttestationObject: []byte{0xa0},
+			expectedResponseCode:      http.StatusBadRequest,
+			expectedResponseBody:      `{"message":"invalid webauthn response"}`,
+		},
+		{
+			name:                      "Invalid access-token",
+			authorization:             "Bearer invalidAccessToken",
+			requestBody:               `{"credential": {"response": {"clientDataJSON": "e30", "attestationObject": "oA"}}}`,
+			providerError:             internal.ErrInvalidToken,
+			expectedProviderCall:      true,
+			expectedClientDataJSON:    []byte("{}"),
+			expectedAttestationObject: []byte{0xa0},
+			expectedResponseCode:      http.StatusUnauthorized,
+			expectedResponseBody:      `{"message":"invalid access-token"}`,
+		},
+		{
+			name:                      "Unexpected error",
+			authorization:             "Bearer myAccessToken",
+			requestBody:               `{"credential": {"response": {"clientDataJSON": "e30", "attestationObject": "oA"}}}`,
+			providerError:             errors.New("nope"),
+			expectedProviderCall:      true,
+			expectedClientDataJSON:    []byte("{}"),
+			expectedAttestationObject: []byte{0xa0},
+			expectedResponseCode:      http.StatusInternalServerError,
+			expectedResponseBody:      `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var providerCalled bool
+			var givenClientDataJSON, givenAttestationObject []byte
+
+			toTest := NewServer(&ProviderMock{
+				FinishWebAuthnRegistrationFunc: func(accessToken string, clientDataJSON, attestationObject []byte) error {
+					providerCalled = true
+					givenClientDataJSON = clientDataJSON
+					givenAttestationObject = attestationObject
+					return tt.providerError
+				},
+			}, false, "", "", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			req, err := http.NewRequest(http.MethodPost, testServer.URL+"/v1/auth/webauthn/registration", bytes.NewReader([]byte(tt.requestBody)))
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+			req.Header.Set("Authorization", tt.authorization)
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			if providerCalled != tt.expectedProviderCall {
+				t.Fatalf("Provider call is not as expected. Expected: %t, Given: %t", tt.expectedProviderCall, providerCalled)
+			}
+
+			if !reflect.DeepEqual(givenClientDataJSON, tt.expectedClientDataJSON) || !reflect.DeepEqual(givenAttestationObject, tt.expectedAttestationObject) {
+				t.Errorf("Provider called with unexpected response. Given clientDataJSON: %q, attestationObject: %x", givenClientDataJSON, givenAttestationObject)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			respBody = bytes.TrimSpace(respBody)
+			if !bytes.Equal(respBody, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: \n%q, \nGiven: \n%q", tt.expectedResponseBody, string(respBody))
+			}
+		})
+	}
+}
+
+func TestWebAuthnLoginOptionsHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		requestBody          string
+		providerOptions      webauthn.RequestOptions
+		providerError        error
+		expectedEMail        string
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Happycase",
+			requestBody: `{"email": "info@leberkleber.io"}`,
+			providerOptions: webauthn.RequestOptions{
+				Challenge:        "Y2hhbGxlbmdl",
+				Timeout:          60000,
+				RPID:             "leberkleber.io",
+				AllowCredentials: []webauthn.CredentialDescriptor{{Type: "public-key", ID: "AQID"}},
+				UserVerification: "required",
+			},
+			expectedEMail:        "info@leberkleber.io",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"challenge":"Y2hhbGxlbmdl","timeout":60000,"rpId":"leberkleber.io","allowCredentials":[{"type":"public-key","id":"AQID"}],"userVerification":"required"}`,
+		},
+		{
+			name:                 "Missing email",
+			requestBody:          `{}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"email must be set"}`,
+		},
+		{
+			name:                 "WebAuthn disabled",
+			requestBody:          `{"email": "info@leberkleber.io"}`,
+			providerError:        internal.ErrWebAuthnDisabled,
+			expectedEMail:        "info@leberkleber.io",
+			expectedResponseCode: http.StatusNotFound,
+			expectedResponseBody: `{"message":"webauthn is not enabled"}`,
+		},
+		{
+			name:                 "Unexpected error",
+			requestBody:          `{"email": "info@leberkleber.io"}`,
+			providerError:        errors.New("nope"),
+			expectedEMail:        "info@leberkleber.io",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var givenEMail string
+
+			toTest := NewServer(&ProviderMock{
+				BeginWebAuthnLoginFunc: func(email string) (webauthn.RequestOptions, error) {
+					givenEMail = email
+					return tt.providerOptions, tt.providerError
+				},
+			}, false, "", "", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			resp, err := http.Post(testServer.URL+"/v1/auth/webauthn/login/options", "application/json", bytes.NewReader([]byte(tt.requestBody)))
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			if givenEMail != tt.expectedEMail {
+				t.Errorf("Provider called with unexpected email. Expected: %q, Given: %q", tt.expectedEMail, givenEMail)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			respBody = bytes.TrimSpace(respBody)
+			if !bytes.Equal(respBody, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: \n%q, \nGiven: \n%q", tt.expectedResponseBody, string(respBody))
+			}
+		})
+	}
+}
+
+func TestWebAuthnLoginHandler(t *testing.T) {
+	validRequestBody := `{"email": "info@leberkleber.io", "credential": {"id": "AQID", "rawId": "AQID", "type": "public-key", "response": {"clientDataJSON": "e30", "authenticatorData": "BAU", "signature": "Bgc"}}}`
+
+	tests := []struct {
+		name                 string
+		requestBody          string
+		providerAccessToken  string
+		providerRefreshToken string
+		providerError        error
+		expectedProviderCall bool
+		expectedResponseCode int
+		expectedResponseBody string
+		expectedRetryAfter   string
+	}{
+		{
+			name:                 "Happycase",
+			requestBody:          validRequestBody,
+			providerAccessToken:  "accessToken",
+			providerRefreshToken: "refreshToken",
+			expectedProviderCall: true,
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"access_token":"accessToken","refresh_token":"refreshToken"}`,
+		},
+		{
+			name:                 "Missing email",
+			requestBody:          `{"credential": {"rawId": "AQID"}}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"email must be set"}`,
+		},
+		{
+			name:                 "Missing rawId",
+			requestBody:          `{"email": "info@leberkleber.io", "credential": {"response": {"clientDataJSON": "e30", "authenticatorData": "BAU", "signature": "Bgc"}}}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"credential.rawId must be set and base64url encoded"}`,
+		},
+		{
+			name:                 "Missing signature",
+			requestBody:          `{"email": "info@leberkleber.io", "credential": {"rawId": "AQID", "response": {"clientDataJSON": "e30", "authenticatorData": "BAU"}}}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"message":"credential.response.signature must be set and base64url encoded"}`,
+		},
+		{
+			name:                 "Invalid webauthn response",
+			requestBody:          validRequestBody,
+			providerError:        internal.ErrInvalidWebAuthnResponse,
+			expectedProviderCall: true,
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"message":"invalid webauthn response"}`,
+		},
+		{
+			name:                 "Locked account",
+			requestBody:          validRequestBody,
+			providerError:        internal.LockoutError{Err: internal.ErrAccountLocked, Until: time.Now().Add(time.Minute)},
+			expectedProviderCall: true,
+			expectedResponseCode: http.StatusLocked,
+			expectedResponseBody: `{"message":"account is locked"}`,
+			expectedRetryAfter:   "60",
+		},
+		{
+			name:                 "WebAuthn disabled",
+			requestBody:          validRequestBody,
+			providerError:        internal.ErrWebAuthnDisabled,
+			expectedProviderCall: true,
+			expectedResponseCode: http.StatusNotFound,
+			expectedResponseBody: `{"message":"webauthn is not enabled"}`,
+		},
+		{
+			name:                 "Unexpected error",
+			requestBody:          validRequestBody,
+			providerError:        errors.New("nope"),
+			expectedProviderCall: true,
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"internal server error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var providerCalled bool
+
+			toTest := NewServer(&ProviderMock{
+				FinishWebAuthnLoginFunc: func(email string, credentialID, clientDataJSON, authenticatorData, signature []byte, client internal.ClientInfo) (string, string, error) {
+					providerCalled = true
+					if email != "info@leberkleber.io" || !bytes.Equal(credentialID, []byte{1, 2, 3}) || string(clientDataJSON) != "{}" ||
+						!bytes.Equal(authenticatorData, []byte{4, 5}) || !bytes.Equal(signature, []byte{6, 7}) {
+						t.Errorf("Provider called with unexpected parameters: %q, %x, %q, %x, %x", email, credentialID, clientDataJSON, authenticatorData, signature)
+					}
+					return tt.providerAccessToken, tt.providerRefreshToken, tt.providerError
+				},
+			}, false, "", "", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			resp, err := http.Post(testServer.URL+"/v1/auth/webauthn/login", "application/json", bytes.NewReader([]byte(tt.requestBody)))
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedResponseCode {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", tt.expectedResponseCode, resp.StatusCode)
+			}
+
+			if providerCalled != tt.expectedProviderCall {
+				t.Errorf("Provider call is not as expected. Expected: %t, Given: %t", tt.expectedProviderCall, providerCalled)
+			}
+
+			if retryAfter := resp.Header.Get("Retry-After"); retryAfter != tt.expectedRetryAfter {
+				t.Errorf("Unexpected Retry-After header. Expected: %q, Given: %q", tt.expectedRetryAfter, retryAfter)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			respBody = bytes.TrimSpace(respBody)
+			if !bytes.Equal(respBody, []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: \n%q, \nGiven: \n%q", tt.expectedResponseBody, string(respBody))
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package web
+
+import (
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	jwksPath               = "/.well-known/jwks.json"
+	tokenEndpointPath      = "/oauth2/token"
+	revocationEndpointPath = "/v1/auth/revoke"
+)
+
+// openIDConfigurationResponseBody is the discovery document as described in
+// https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderMetadata
+type openIDConfigurationResponseBody struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	TokenEndpoint                    string   `json:"token_endpoint"`
+	GrantTypesSupported              []string `json:"grant_types_supported"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	SubjectTypesSupported            []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// authorizationServerMetadataResponseBody is the authorization server metadata as described in
+// https://tools.ietf.org/html/rfc8414#section-2. Only implemented endpoints and grant types are advertised, there is
+// no authorization endpoint and therefore no supported response type.
+type authorizationServerMetadataResponseBody struct {
+	Issuer                                 string   `json:"issuer"`
+	JWKSURI                                string   `json:"jwks_uri"`
+	TokenEndpoint                          string   `json:"token_endpoint"`
+	TokenEndpointAuthMethodsSupported      []string `json:"token_endpoint_auth_methods_supported"`
+	GrantTypesSupported                    []string `json:"grant_types_supported"`
+	ResponseTypesSupported                 []string `json:"response_types_supported"`
+	RevocationEndpoint                     string   `json:"revocation_endpoint"`
+	RevocationEndpointAuthMethodsSupported []string `json:"revocation_endpoint_auth_methods_supported"`
+}
+
+func (s *Server) jwksHandler(w http.ResponseWriter, _ *http.Request) {
+	err := json.NewEncoder(w).Encode(s.p.JWKS())
+	if err != nil {
+		logrus.WithError(err).Error("Failed to encode jwks")
+		writeInternalServerError(w)
+		return
+	}
+}
+
+func (s *Server) openIDConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := s.p.OpenIDConfiguration()
+	baseURL := endpointBaseURL(cfg.Issuer, r)
+
+	err := json.NewEncoder(w).Encode(openIDConfigurationResponseBody{
+		Issuer:                           cfg.Issuer,
+		JWKSURI:                          baseURL + jwksPath,
+		TokenEndpoint:                    baseURL + tokenEndpointPath,
+		GrantTypesSupported:              []string{"password", "refresh_token"},
+		ResponseTypesSupported:           []string{"token"},
+		SubjectTypesSupported:            []string{"public"},
+		IDTokenSigningAlgValuesSupported: cfg.SigningAlgorithms,
+	})
+	if err != nil {
+		logrus.WithError(err).Error("Failed to encode openid-configuration")
+		writeInternalServerError(w)
+		return
+	}
+}
+
+func (s *Server) authorizationServerMetadataHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := s.p.OpenIDConfiguration()
+	baseURL := endpointBaseURL(cfg.Issuer, r)
+
+	err := json.NewEncoder(w).Encode(authorizationServerMetadataResponseBody{
+		Issuer:                                 cfg.Issuer,
+		JWKSURI:                                baseURL + jwksPath,
+		TokenEndpoint:                          baseURL + tokenEndpointPath,
+		TokenEndpointAuthMethodsSupported:      []string{"none"},
+		GrantTypesSupported:                    []string{"password", "refresh_token"},
+		ResponseTypesSupported:                 []string{},
+		RevocationEndpoint:                     baseURL + revocationEndpointPath,
+		RevocationEndpointAuthMethodsSupported: []string{"none"},
+	})
+	if err != nil {
+		logrus.WithError(err).Error("Failed to encode authorization server metadata")
+		writeInternalServerError(w)
+		return
+	}
+}
+
+// endpointBaseURL returns the url all endpoint urls are relative to. This is the issuer when it is an absolute url,
+// otherwise the requested host will be used.
+func endpointBaseURL(issuer string, r *http.Request) string {
+	u, err := url.Parse(issuer)
+	if err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+		return strings.TrimSuffix(issuer, "/")
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if forwardedProto := r.Header.Get("X-Forwarded-Proto"); forwardedProto != "" {
+		scheme = forwardedProto
+	}
+
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,198 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/leberKleber/simple-jwt-provider/internal"
+	"github.com/leberKleber/simple-jwt-provider/internal/jwk"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJWKSHandler(t *testing.T) {
+	expectedResponseCode := http.StatusOK
+	expectedResponseBody := `{"keys":[{"kty":"EC","use":"sig","alg":"ES512","kid":"myKid","crv":"P-521","x":"myX","y":"myY"}]}`
+
+	toTest := NewServer(&ProviderMock{
+		JWKSFunc: func() jwk.Set {
+			return jwk.Set{Keys: []jwk.Key{{Kty: "EC", Use: "sig", Alg: "ES512", Kid: "myKid", Crv: "P-521", X: "myX", Y: "myY"}}}
+		},
+	}, false, "", "", nil, 0, 0, nil)
+	testServer := httptest.NewServer(toTest.h)
+
+	req, err := http.NewRequest(http.MethodGet, testServer.URL+"/.well-known/jwks.json", nil)
+	if err != nil {
+		t.Fatalf("Failed to build http request: %s", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to call server cause: %s", err)
+	}
+	defer resp.Body.Close()
+
+	expectedContentType := "application/json"
+	givenContentType := resp.Header.Get("Content-Type")
+	if expectedContentType != givenContentType {
+		t.Errorf("Unexpected response content-type. Given: %q, Expected: %q", givenContentType, expectedContentType)
+	}
+
+	if resp.StatusCode != expectedResponseCode {
+		t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", expectedResponseCode, resp.StatusCode)
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %s", err)
+	}
+
+	compactedRespBody := &bytes.Buffer{}
+	err = json.Compact(compactedRespBody, respBody)
+	if err != nil {
+		t.Fatalf("Failed to compact json: %s", err)
+	}
+
+	if !bytes.Equal(compactedRespBody.Bytes(), []byte(expectedResponseBody)) {
+		t.Errorf("Request response body is not as expected. Expected: %q, Given: %q", expectedResponseBody, compactedRespBody.String())
+	}
+}
+
+func TestOpenIDConfigurationHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		issuer               string
+		requestHeaders       map[string]string
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Configured issuer",
+			issuer:               "https://auth.example.com/",
+			expectedResponseBody: `{"issuer":"https://auth.example.com/","jwks_uri":"https://auth.example.com/.well-known/jwks.json","token_endpoint":"https://auth.example.com/oauth2/token","grant_types_supported":["password","refresh_token"],"response_types_supported":["token"],"subject_types_supported":["public"],"id_token_signing_alg_values_supported":["ES512","RS256"]}`,
+		}, {
+			name:                 "Issuer which is not an url",
+			issuer:               "my-issuer",
+			expectedResponseBody: `{"issuer":"my-issuer","jwks_uri":"http://my.host/.well-known/jwks.json","token_endpoint":"http://my.host/oauth2/token","grant_types_supported":["password","refresh_token"],"response_types_supported":["token"],"subject_types_supported":["public"],"id_token_signing_alg_values_supported":["ES512","RS256"]}`,
+		}, {
+			name:                 "Without issuer",
+			requestHeaders:       map[string]string{"X-Forwarded-Proto": "https"},
+			expectedResponseBody: `{"issuer":"","jwks_uri":"https://my.host/.well-known/jwks.json","token_endpoint":"https://my.host/oauth2/token","grant_types_supported":["password","refresh_token"],"response_types_supported":["token"],"subject_types_supported":["public"],"id_token_signing_alg_values_supported":["ES512","RS256"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toTest := NewServer(&ProviderMock{
+				OpenIDConfigurationFunc: func() internal.OpenIDConfiguration {
+					return internal.OpenIDConfiguration{Issuer: tt.issuer, SigningAlgorithms: []string{"ES512", "RS256"}}
+				},
+			}, false, "", "", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			req, err := http.NewRequest(http.MethodGet, testServer.URL+"/.well-known/openid-configuration", nil)
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+			req.Host = "my.host"
+			for k, v := range tt.requestHeaders {
+				req.Header.Set(k, v)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", http.StatusOK, resp.StatusCode)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			compactedRespBody := &bytes.Buffer{}
+			err = json.Compact(compactedRespBody, respBody)
+			if err != nil {
+				t.Fatalf("Failed to compact json: %s", err)
+			}
+
+			if !bytes.Equal(compactedRespBody.Bytes(), []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: %q, Given: %q", tt.expectedResponseBody, compactedRespBody.String())
+			}
+		})
+	}
+}
+
+func TestAuthorizationServerMetadataHandler(t *testing.T) {
+	tests := []struct {
+		name                 string
+		issuer               string
+		requestHeaders       map[string]string
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Configured issuer",
+			issuer:               "https://auth.example.com/",
+			expectedResponseBody: `{"issuer":"https://auth.example.com/","jwks_uri":"https://auth.example.com/.well-known/jwks.json","token_endpoint":"https://auth.example.com/oauth2/token","token_endpoint_auth_methods_supported":["none"],"grant_types_supported":["password","refresh_token"],"response_types_supported":[],"revocation_endpoint":"https://auth.example.com/v1/auth/revoke","revocation_endpoint_auth_methods_supported":["none"]}`,
+		}, {
+			name:                 "Issuer which is not an url",
+			issuer:               "my-issuer",
+			expectedResponseBody: `{"issuer":"my-issuer","jwks_uri":"http://my.host/.well-known/jwks.json","token_endpoint":"http://my.host/oauth2/token","token_endpoint_auth_methods_supported":["none"],"grant_types_supported":["password","refresh_token"],"response_types_supported":[],"revocation_endpoint":"http://my.host/v1/auth/revoke","revocation_endpoint_auth_methods_supported":["none"]}`,
+		}, {
+			name:                 "Without issuer",
+			requestHeaders:       map[string]string{"X-Forwarded-Proto": "https"},
+			expectedResponseBody: `{"issuer":"","jwks_uri":"https://my.host/.well-known/jwks.json","token_endpoint":"https://my.host/oauth2/token","token_endpoint_auth_methods_supported":["none"],"grant_types_supported":["password","refresh_token"],"response_types_supported":[],"revocation_endpoint":"https://my.host/v1/auth/revoke","revocation_endpoint_auth_methods_supported":["none"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toTest := NewServer(&ProviderMock{
+				OpenIDConfigurationFunc: func() internal.OpenIDConfiguration {
+					return internal.OpenIDConfiguration{Issuer: tt.issuer, SigningAlgorithms: []string{"ES512", "RS256"}}
+				},
+			}, false, "", "", nil, 0, 0, nil)
+			testServer := httptest.NewServer(toTest.h)
+			defer testServer.Close()
+
+			req, err := http.NewRequest(http.MethodGet, testServer.URL+"/.well-known/oauth-authorization-server", nil)
+			if err != nil {
+				t.Fatalf("Failed to build http request: %s", err)
+			}
+			req.Host = "my.host"
+			for k, v := range tt.requestHeaders {
+				req.Header.Set(k, v)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to call server cause: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("Request respond with unexpected status code. Expected: %d, Given: %d", http.StatusOK, resp.StatusCode)
+			}
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %s", err)
+			}
+
+			compactedRespBody := &bytes.Buffer{}
+			err = json.Compact(compactedRespBody, respBody)
+			if err != nil {
+				t.Fatalf("Failed to compact json: %s", err)
+			}
+
+			if !bytes.Equal(compactedRespBody.Bytes(), []byte(tt.expectedResponseBody)) {
+				t.Errorf("Request response body is not as expected. Expected: %q, Given: %q", tt.expectedResponseBody, compactedRespBody.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"github.com/leberKleber/simple-jwt-provider/internal/webauthn"
+)
+
+// ErrWebAuthnDisabled returned when WebAuthn has not been configured
+var ErrWebAuthnDisabled = errors.New("webauthn disabled")
+
+// ErrInvalidWebAuthnResponse returned when the response of a WebAuthn ceremony could not be verified, its challenge is
+// unknown or expired or its credential is not registered
+var ErrInvalidWebAuthnResponse = errors.New("invalid webauthn response")
+
+var newWebAuthnChallenge = webauthn.NewChallenge
+
+// BeginWebAuthnRegistration starts the registration of a passkey for the user of the given access-token. The returned
+// options have to be passed to navigator.credentials.create and its response to FinishWebAuthnRegistration.
+// return ErrWebAuthnDisabled when WebAuthn has not been configured
+// return ErrInvalidToken when the token is not an active access-token or its user does not exist anymore
+func (p Provider) BeginWebAuthnRegistration(accessToken string) (webauthn.CreationOptions, error) {
+	if p.WebAuthn.ID == "" {
+		return webauthn.CreationOptions{}, ErrWebAuthnDisabled
+	}
+
+	u, err := p.accessTokenUser(accessToken)
+	if err != nil {
+		return webauthn.CreationOptions{}, err
+	}
+
+	credentialIDs, err := p.webAuthnCredentialIDs(u.EMail)
+	if err != nil {
+		return webauthn.CreationOptions{}, err
+	}
+
+	challenge, err := p.createWebAuthnChallenge(u.EMail, storage.TokenTypeWebAuthnRegistration)
+	if err != nil {
+		return webauthn.CreationOptions{}, err
+	}
+
+	return p.WebAuthn.CreationOptions(challenge, webAuthnUserID(u.EMail), u.EMail, credentialIDs, p.WebAuthnTimeout), nil
+}
+
+// FinishWebAuthnRegistration verifies the response of navigator.credentials.create and stores the created passkey for
+// the user of the given access-token.
+// return ErrWebAuthnDisabled when WebAuthn has not been configured
+// return ErrInvalidToken when the token is not an active access-token or its user does not exist anymore
+// return ErrInvalidWebAuthnResponse when the response could not be verified or its challenge is unknown or expired
+func (p Provider) FinishWebAuthnRegistration(accessToken string, clientDataJSON, attestationObject []byte) error {
+	if p.WebAuthn.ID == "" {
+		return ErrWebAuthnDisabled
+	}
+
+	u, err := p.accessTokenUser(accessToken)
+	if err != nil {
+		return err
+	}
+
+	challenge, err := p.consumeWebAuthnChallenge(u.EMail, storage.TokenTypeWebAuthnRegistration, clientDataJSON)
+	if err != nil {
+		return err
+	}
+
+	credential, err := p.WebAuthn.VerifyRegistration(challenge, clientDataJSON, attestationObject)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidWebAuthnResponse, err)
+	}
+
+	err = p.Storage.CreateWebAuthnCredential(&storage.WebAuthnCredential{
+		EMail:        u.EMail,
+		CredentialID: credential.ID,
+		PublicKey:    credential.PublicKey,
+		SignCount:    credential.SignCount,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to persist webauthn credential: %w", err)
+	}
+
+	return nil
+}
+
+// BeginWebAuthnLogin starts a passkey login of the user with the given email. The returned options have to be passed
+// to navigator.credentials.get and its response to FinishWebAuthnLogin. Users without passkeys and unknown users get
+// options without credentials, so the response reveals whether the user has registered passkeys.
+// return ErrWebAuthnDisabled when WebAuthn has not been configured
+func (p Provider) BeginWebAuthnLogin(email string) (webauthn.RequestOptions, error) {
+	if p.WebAuthn.ID == "" {
+		return webauthn.RequestOptions{}, ErrWebAuthnDisabled
+	}
+
+	credentialIDs, err := p.webAuthnCredentialIDs(email)
+	if err != nil {
+		return webauthn.RequestOptions{}, err
+	}
+
+	var challenge string
+	if len(credentialIDs) == 0 {
+		// there is nothing to login with, so the challenge will not be persisted
+		challenge, err = newWebAuthnChallenge()
+		if err != nil {
+			return webauthn.RequestOptions{}, fmt.Errorf("failed to generate webauthn challenge: %w", err)
+		}
+	} else {
+		challenge, err = p.createWebAuthnChallenge(email, storage.TokenTypeWebAuthnLogin)
+		if err != nil {
+			return webauthn.RequestOptions{}, err
+		}
+	}
+
+	return p.WebAuthn.RequestOptions(challenge, credentialIDs, p.WebAuthnTimeout), nil
+}
+
+// FinishWebAuthnLogin verifies the response of navigator.credentials.get and returns a new access and refresh token
+// like Login. The given client will be recorded as the client of the new session. Failed verifications count as
+// failed logins.
+// return ErrWebAuthnDisabled when WebAuthn has not been configured
+// return ErrInvalidWebAuthnResponse when the response could not be verified, its challenge is unknown or expired or
+// the credential is not registered for the user
+// return LockoutError when logins of the email or the client are locked cause of too many failed logins
+func (p Provider) FinishWebAuthnLogin(email string, credentialID, clientDataJSON, authenticatorData, signature []byte, client ClientInfo) (accessToken, refreshToken string, err error) {
+	if p.WebAuthn.ID == "" {
+		return "", "", ErrWebAuthnDisabled
+	}
+
+	err = p.checkLoginLockout(email, client)
+	if err != nil {
+		return "", "", err
+	}
+
+	challenge, err := p.consumeWebAuthnChallenge(email, storage.TokenTypeWebAuthnLogin, clientDataJSON)
+	if err != nil {
+		if errors.Is(err, ErrInvalidWebAuthnResponse) {
+			return "", "", p.loginFailed(email, client, err)
+		}
+		return "", "", err
+	}
+
+	credentials, err := p.Storage.WebAuthnCredentialsByEMail(email)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find webauthn credentials: %w", err)
+	}
+
+	var stored *storage.WebAuthnCredential
+	for i := range credentials {
+		if bytes.Equal(credentials[i].CredentialID, credentialID) {
+			stored = &credentials[i]
+			break
+		}
+	}
+
+	if stored == nil {
+		return "", "", p.loginFailed(email, client, fmt.Errorf("%w: unknown credential", ErrInvalidWebAuthnResponse))
+	}
+
+	signCount, err := p.WebAuthn.VerifyAssertion(challenge, webauthn.Credential{
+		ID:        stored.CredentialID,
+		PublicKey: stored.PublicKey,
+		SignCount: stored.SignCount,
+	}, clientDataJSON, authenticatorData, signature)
+	if err != nil {
+		return "", "", p.loginFailed(email, client, fmt.Errorf("%w: %s", ErrInvalidWebAuthnResponse, err))
+	}
+
+	// the counter will only be updated when it is greater than the persisted one, so an assertion which has been used
+	// concurrently will be rejected
+	updated, err := p.Storage.UpdateWebAuthnCredentialUsage(stored.ID, signCount, timeNow())
+	if err != nil {
+		return "", "", fmt.Errorf("failed to update webauthn credential: %w", err)
+	}
+	if !updated {
+		return "", "", p.loginFailed(email, client, fmt.Errorf("%w: signature counter did not increase", ErrInvalidWebAuthnResponse))
+	}
+
+	u, err := p.Storage.User(email)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find user with email %q: %w", email, err)
+	}
+
+	err = p.resetLoginFailures(email)
+	if err != nil {
+		return "", "", err
+	}
+
+	return p.issueTokens(email, u.Claims, client)
+}
+
+// createWebAuthnChallenge generates and persists a new challenge of the given ceremony type
+func (p Provider) createWebAuthnChallenge(email, tokenType string) (string, error) {
+	challenge, err := newWebAuthnChallenge()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate webauthn challenge: %w", err)
+	}
+
+	err = p.Storage.CreateToken(&storage.Token{
+		EMail:     email,
+		Token:     challenge,
+		Type:      tokenType,
+		ExpiresAt: timeNow().Add(p.WebAuthnTimeout),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to persist webauthn challenge: %w", err)
+	}
+
+	return challenge, nil
+}
+
+// consumeWebAuthnChallenge consumes the persisted challenge of the given ceremony type which is referenced by the
+// given client data, so each challenge can be answered only once
+func (p Provider) consumeWebAuthnChallenge(email, tokenType string, clientDataJSON []byte) (string, error) {
+	clientData, err := webauthn.ParseClientData(clientDataJSON)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidWebAuthnResponse, err)
+	}
+
+	tokens, err := p.Storage.TokensByEMailAndToken(email, clientData.Challenge)
+	if err != nil {
+		return "", fmt.Errorf("failed to find webauthn challenge: %w", err)
+	}
+
+	for _, t := range tokens {
+		if t.Type != tokenType || p.isExpired(t) {
+			continue
+		}
+
+		err = p.consumeToken(t.ID)
+		if errors.Is(err, ErrNoValidTokenFound) {
+			// the challenge has been answered concurrently
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to consume webauthn challenge: %w", err)
+		}
+
+		return t.Token, nil
+	}
+
+	return "", fmt.Errorf("%w: unknown challenge", ErrInvalidWebAuthnResponse)
+}
+
+func (p Provider) webAuthnCredentialIDs(email string) ([][]byte, error) {
+	credentials, err := p.Storage.WebAuthnCredentialsByEMail(email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webauthn credentials: %w", err)
+	}
+
+	ids := make([][]byte, 0, len(credentials))
+	for _, c := range credentials {
+		ids = append(ids, c.CredentialID)
+	}
+
+	return ids, nil
+}
+
+// webAuthnUserID returns the user handle of the user with the given email which will be stored by authenticators. It
+// does not contain the email itself.
+func webAuthnUserID(email string) []byte {
+	id := sha256.Sum256([]byte(email))
+	return id[:]
+}
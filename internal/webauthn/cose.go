@@ -0,0 +1,118 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithm identifiers (https://www.iana.org/assignments/cose/cose.xhtml#algorithms) which are supported
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// COSE key parameters
+const (
+	coseKeyKty = 1
+	coseKeyAlg = 3
+
+	coseKtyOKP = 1
+	coseKtyEC2 = 2
+	coseKtyRSA = 3
+
+	coseCrvP256    = 1
+	coseCrvEd25519 = 6
+)
+
+// parseCOSEKey parses a COSE_Key (RFC 8152) of one of the supported algorithms
+func parseCOSEKey(data []byte) (crypto.PublicKey, int64, error) {
+	item, _, err := decodeCBOR(data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to decode cose key: %w", err)
+	}
+
+	key, ok := item.(map[interface{}]interface{})
+	if !ok {
+		return nil, 0, errors.New("cose key is not a map")
+	}
+
+	kty, _ := key[int64(coseKeyKty)].(int64)
+	alg, _ := key[int64(coseKeyAlg)].(int64)
+
+	switch {
+	case kty == coseKtyEC2 && alg == AlgES256:
+		crv, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		y, _ := key[int64(-3)].([]byte)
+		if crv != coseCrvP256 || len(x) != 32 || len(y) != 32 {
+			return nil, 0, errors.New("invalid ES256 cose key")
+		}
+
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, 0, errors.New("ES256 cose key is not on curve")
+		}
+		return pub, alg, nil
+	case kty == coseKtyOKP && alg == AlgEdDSA:
+		crv, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		if crv != coseCrvEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, 0, errors.New("invalid EdDSA cose key")
+		}
+		return ed25519.PublicKey(x), alg, nil
+	case kty == coseKtyRSA && alg == AlgRS256:
+		n, _ := key[int64(-1)].([]byte)
+		e, _ := key[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, 0, errors.New("invalid RS256 cose key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, alg, nil
+	default:
+		return nil, 0, fmt.Errorf("unsupported cose key type %d with algorithm %d", kty, alg)
+	}
+}
+
+// verifySignature verifies the signature of the given data with the given COSE_Key
+func verifySignature(coseKey, data, signature []byte) error {
+	pub, _, err := parseCOSEKey(coseKey)
+	if err != nil {
+		return err
+	}
+
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		sig := struct {
+			R, S *big.Int
+		}{}
+		rest, err := asn1.Unmarshal(signature, &sig)
+		if err != nil || len(rest) != 0 {
+			return errors.New("invalid ecdsa signature encoding")
+		}
+
+		digest := sha256.Sum256(data)
+		if !ecdsa.Verify(pub, digest[:], sig.R, sig.S) {
+			return errors.New("invalid signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, data, signature) {
+			return errors.New("invalid signature")
+		}
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(data)
+		err = rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature)
+		if err != nil {
+			return errors.New("invalid signature")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,335 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	challengeLength = 32
+
+	// authenticator data flags
+	flagUserPresent   = 0x01
+	flagUserVerified  = 0x04
+	flagAttestedCreds = 0x40
+
+	clientDataTypeCreate = "webauthn.create"
+	clientDataTypeGet    = "webauthn.get"
+)
+
+// ErrVerificationFailed returned when a registration or assertion response could not be verified
+var ErrVerificationFailed = errors.New("webauthn verification failed")
+
+// Encoding is used for challenges and credential ids in options and client data
+var Encoding = base64.RawURLEncoding
+
+var randRead = rand.Read
+
+// RelyingParty describes the service which users authenticate to. ID is the effective domain (e.g. example.com) and
+// Origins contains the web origins (e.g. https://login.example.com) from which ceremonies are accepted.
+type RelyingParty struct {
+	ID      string
+	Name    string
+	Origins []string
+}
+
+// Credential is a public key credential which has been registered by an authenticator. PublicKey is COSE_Key encoded.
+type Credential struct {
+	ID        []byte
+	PublicKey []byte
+	SignCount uint32
+}
+
+// ClientData is the part of the client data (clientDataJSON) which will be verified
+type ClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+type authenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialID []byte
+	publicKey    []byte
+}
+
+// NewChallenge generates a new random base64url encoded challenge
+func NewChallenge() (string, error) {
+	b := make([]byte, challengeLength)
+	_, err := randRead(b)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate challenge: %w", err)
+	}
+
+	return Encoding.EncodeToString(b), nil
+}
+
+// ParseClientData parses the given clientDataJSON
+func ParseClientData(clientDataJSON []byte) (ClientData, error) {
+	var cd ClientData
+	err := json.Unmarshal(clientDataJSON, &cd)
+	if err != nil {
+		return ClientData{}, fmt.Errorf("%w: invalid client data: %s", ErrVerificationFailed, err)
+	}
+
+	return cd, nil
+}
+
+// VerifyRegistration verifies the response of a registration ceremony (navigator.credentials.create) and returns the
+// created credential. Attestation statements are not verified, as for the attestation conveyance 'none'. User
+// verification is required.
+// return ErrVerificationFailed when the response is not valid for the given challenge
+func (rp RelyingParty) VerifyRegistration(challenge string, clientDataJSON, attestationObject []byte) (Credential, error) {
+	err := rp.verifyClientData(clientDataJSON, clientDataTypeCreate, challenge)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	item, _, err := decodeCBOR(attestationObject)
+	if err != nil {
+		return Credential{}, fmt.Errorf("%w: invalid attestation object: %s", ErrVerificationFailed, err)
+	}
+
+	attestation, ok := item.(map[interface{}]interface{})
+	if !ok {
+		return Credential{}, fmt.Errorf("%w: attestation object is not a map", ErrVerificationFailed)
+	}
+
+	rawAuthData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return Credential{}, fmt.Errorf("%w: attestation object contains no authenticator data", ErrVerificationFailed)
+	}
+
+	authData, err := rp.verifyAuthenticatorData(rawAuthData)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	if authData.flags&flagAttestedCreds == 0 {
+		return Credential{}, fmt.Errorf("%w: authenticator data contains no credential", ErrVerificationFailed)
+	}
+
+	_, _, err = parseCOSEKey(authData.publicKey)
+	if err != nil {
+		return Credential{}, fmt.Errorf("%w: %s", ErrVerificationFailed, err)
+	}
+
+	return Credential{
+		ID:        authData.credentialID,
+		PublicKey: authData.publicKey,
+		SignCount: authData.signCount,
+	}, nil
+}
+
+// VerifyAssertion verifies the response of an authentication ceremony (navigator.credentials.get) with the given
+// credential and returns the new signature counter of the credential. User verification is required.
+// return ErrVerificationFailed when the response is not valid for the given challenge and credential
+func (rp RelyingParty) VerifyAssertion(challenge string, credential Credential, clientDataJSON, rawAuthData, signature []byte) (uint32, error) {
+	err := rp.verifyClientData(clientDataJSON, clientDataTypeGet, challenge)
+	if err != nil {
+		return 0, err
+	}
+
+	authData, err := rp.verifyAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	err = verifySignature(credential.PublicKey, append(append([]byte(nil), rawAuthData...), clientDataHash[:]...), signature)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrVerificationFailed, err)
+	}
+
+	// a counter which does not increase indicates a cloned authenticator. Authenticators without counter always send 0.
+	if (authData.signCount != 0 || credential.SignCount != 0) && authData.signCount <= credential.SignCount {
+		return 0, fmt.Errorf("%w: signature counter did not increase", ErrVerificationFailed)
+	}
+
+	return authData.signCount, nil
+}
+
+func (rp RelyingParty) verifyClientData(clientDataJSON []byte, expectedType, challenge string) error {
+	cd, err := ParseClientData(clientDataJSON)
+	if err != nil {
+		return err
+	}
+
+	if cd.Type != expectedType {
+		return fmt.Errorf("%w: unexpected client data type %q", ErrVerificationFailed, cd.Type)
+	}
+
+	if challenge == "" || subtle.ConstantTimeCompare([]byte(cd.Challenge), []byte(challenge)) != 1 {
+		return fmt.Errorf("%w: challenge mismatch", ErrVerificationFailed)
+	}
+
+	for _, o := range rp.Origins {
+		if cd.Origin == o {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: unexpected origin %q", ErrVerificationFailed, cd.Origin)
+}
+
+func (rp RelyingParty) verifyAuthenticatorData(rawAuthData []byte) (authenticatorData, error) {
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return authenticatorData{}, fmt.Errorf("%w: invalid authenticator data: %s", ErrVerificationFailed, err)
+	}
+
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(authData.rpIDHash, rpIDHash[:]) {
+		return authenticatorData{}, fmt.Errorf("%w: rp id mismatch", ErrVerificationFailed)
+	}
+
+	if authData.flags&flagUserPresent == 0 {
+		return authenticatorData{}, fmt.Errorf("%w: user not present", ErrVerificationFailed)
+	}
+
+	if authData.flags&flagUserVerified == 0 {
+		return authenticatorData{}, fmt.Errorf("%w: user not verified", ErrVerificationFailed)
+	}
+
+	return authData, nil
+}
+
+// parseAuthenticatorData parses authenticator data (https://www.w3.org/TR/webauthn-2/#sctn-authenticator-data).
+// Extensions are ignored.
+func parseAuthenticatorData(b []byte) (authenticatorData, error) {
+	if len(b) < 37 {
+		return authenticatorData{}, errors.New("authenticator data too short")
+	}
+
+	authData := authenticatorData{
+		rpIDHash:  b[:32],
+		flags:     b[32],
+		signCount: binary.BigEndian.Uint32(b[33:37]),
+	}
+
+	if authData.flags&flagAttestedCreds == 0 {
+		return authData, nil
+	}
+
+	// aaguid (16 bytes) and credential id length (2 bytes)
+	rest := b[37:]
+	if len(rest) < 18 {
+		return authenticatorData{}, errors.New("attested credential data too short")
+	}
+
+	idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if len(rest) < idLength {
+		return authenticatorData{}, errors.New("attested credential data too short")
+	}
+	authData.credentialID = rest[:idLength]
+	rest = rest[idLength:]
+
+	_, remaining, err := decodeCBOR(rest)
+	if err != nil {
+		return authenticatorData{}, fmt.Errorf("invalid credential public key: %w", err)
+	}
+	authData.publicKey = rest[:len(rest)-len(remaining)]
+
+	return authData, nil
+}
+
+// CredentialParameter describes a supported credential type and algorithm
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+// CredentialDescriptor identifies a credential
+type CredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// CreationOptions are the options of a registration ceremony in the JSON representation of
+// PublicKeyCredentialCreationOptions. Challenge, user id and credential ids are base64url encoded.
+type CreationOptions struct {
+	Challenge string `json:"challenge"`
+	RP        struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"rp"`
+	User struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+	} `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection struct {
+		ResidentKey      string `json:"residentKey"`
+		UserVerification string `json:"userVerification"`
+	} `json:"authenticatorSelection"`
+	Attestation string `json:"attestation"`
+}
+
+// RequestOptions are the options of an authentication ceremony in the JSON representation of
+// PublicKeyCredentialRequestOptions. Challenge and credential ids are base64url encoded.
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// CreationOptions returns the options of a registration ceremony for the given user. Already registered credentials
+// should be passed as excludeCredentials to prevent registering an authenticator twice.
+func (rp RelyingParty) CreationOptions(challenge string, userID []byte, userName string, excludeCredentials [][]byte, timeout time.Duration) CreationOptions {
+	o := CreationOptions{
+		Challenge: challenge,
+		PubKeyCredParams: []CredentialParameter{
+			{Type: "public-key", Alg: AlgES256},
+			{Type: "public-key", Alg: AlgEdDSA},
+			{Type: "public-key", Alg: AlgRS256},
+		},
+		Timeout:            timeout.Milliseconds(),
+		ExcludeCredentials: credentialDescriptors(excludeCredentials),
+		Attestation:        "none",
+	}
+	o.RP.ID = rp.ID
+	o.RP.Name = rp.Name
+	o.User.ID = Encoding.EncodeToString(userID)
+	o.User.Name = userName
+	o.User.DisplayName = userName
+	o.AuthenticatorSelection.ResidentKey = "preferred"
+	o.AuthenticatorSelection.UserVerification = "required"
+
+	return o
+}
+
+// RequestOptions returns the options of an authentication ceremony which allows the given credentials
+func (rp RelyingParty) RequestOptions(challenge string, allowCredentials [][]byte, timeout time.Duration) RequestOptions {
+	return RequestOptions{
+		Challenge:        challenge,
+		Timeout:          timeout.Milliseconds(),
+		RPID:             rp.ID,
+		AllowCredentials: credentialDescriptors(allowCredentials),
+		UserVerification: "required",
+	}
+}
+
+func credentialDescriptors(ids [][]byte) []CredentialDescriptor {
+	descriptors := make([]CredentialDescriptor, 0, len(ids))
+	for _, id := range ids {
+		descriptors = append(descriptors, CredentialDescriptor{Type: "public-key", ID: Encoding.EncodeToString(id)})
+	}
+
+	return descriptors
+}
@@ -0,0 +1,363 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"github.com/leberKleber/simple-jwt-provider/internal/webauthn/webauthntest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const (
+	testRPID   = "leberkleber.io"
+	testOrigin = "https://login.leberkleber.io"
+)
+
+var testRP = RelyingParty{ID: testRPID, Name: "simple-jwt-provider", Origins: []string{"https://other.leberkleber.io", testOrigin}}
+
+func TestRelyingParty_VerifyRegistration(t *testing.T) {
+	tests := []struct {
+		name          string
+		rpID          string
+		origin        string
+		challenge     string
+		modify        func(clientDataJSON, attestationObject []byte) ([]byte, []byte)
+		expectedError error
+	}{
+		{
+			name:      "Happycase",
+			rpID:      testRPID,
+			origin:    testOrigin,
+			challenge: "challenge",
+		},
+		{
+			name:          "Challenge mismatch",
+			rpID:          testRPID,
+			origin:        testOrigin,
+			challenge:     "other-challenge",
+			expectedError: ErrVerificationFailed,
+		},
+		{
+			name:          "Origin mismatch",
+			rpID:          testRPID,
+			origin:        "https://evil.io",
+			challenge:     "challenge",
+			expectedError: ErrVerificationFailed,
+		},
+		{
+			name:          "RP ID mismatch",
+			rpID:          "evil.io",
+			origin:        testOrigin,
+			challenge:     "challenge",
+			expectedError: ErrVerificationFailed,
+		},
+		{
+			name:      "Wrong client data type",
+			rpID:      testRPID,
+			origin:    testOrigin,
+			challenge: "challenge",
+			modify: func(_, attestationObject []byte) ([]byte, []byte) {
+				return []byte(`{"type":"webauthn.get","challenge":"challenge","origin":"` + testOrigin + `"}`), attestationObject
+			},
+			expectedError: ErrVerificationFailed,
+		},
+		{
+			name:      "Invalid attestation object",
+			rpID:      testRPID,
+			origin:    testOrigin,
+			challenge: "challenge",
+			modify: func(clientDataJSON, attestationObject []byte) ([]byte, []byte) {
+				return clientDataJSON, attestationObject[:len(attestationObject)-10]
+			},
+			expectedError: ErrVerificationFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := webauthntest.NewAuthenticator(tt.rpID, tt.origin)
+			if err != nil {
+				t.Fatalf("Failed to create authenticator: %s", err)
+			}
+
+			clientDataJSON, attestationObject, err := a.Create(tt.challenge)
+			if err != nil {
+				t.Fatalf("Failed to create credential: %s", err)
+			}
+			if tt.modify != nil {
+				clientDataJSON, attestationObject = tt.modify(clientDataJSON, attestationObject)
+			}
+
+			c, err := testRP.VerifyRegistration("challenge", clientDataJSON, attestationObject)
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:\n%#v\nGiven:\n%#v", tt.expectedError, err)
+			}
+			if tt.expectedError != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(c.ID, a.CredentialID()) {
+				t.Errorf("Credential id is not as expected. Expected: %x, Given: %x", a.CredentialID(), c.ID)
+			}
+			if c.SignCount != 0 {
+				t.Errorf("Sign count is not as expected. Expected: 0, Given: %d", c.SignCount)
+			}
+			if _, _, err := parseCOSEKey(c.PublicKey); err != nil {
+				t.Errorf("Credential public key could not be parsed: %s", err)
+			}
+		})
+	}
+}
+
+func TestRelyingParty_VerifyAssertion(t *testing.T) {
+	a, err := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %s", err)
+	}
+
+	other, err := webauthntest.NewAuthenticator(testRPID, testOrigin)
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %s", err)
+	}
+
+	credential := mustRegister(t, a)
+	otherCredential := mustRegister(t, other)
+
+	tests := []struct {
+		name              string
+		challenge         string
+		credential        Credential
+		storedSignCount   uint32
+		modify            func(clientDataJSON, authData, signature []byte) ([]byte, []byte, []byte)
+		expectedError     error
+		expectedSignCount uint32
+	}{
+		{
+			// must be the first case as the authenticators counter increases with each case
+			name:              "Happycase",
+			challenge:         "challenge",
+			credential:        credential,
+			expectedSignCount: 1,
+		},
+		{
+			name:          "Challenge mismatch",
+			challenge:     "other-challenge",
+			credential:    credential,
+			expectedError: ErrVerificationFailed,
+		},
+		{
+			name:          "Signed by other credential",
+			challenge:     "challenge",
+			credential:    otherCredential,
+			expectedError: ErrVerificationFailed,
+		},
+		{
+			name:            "Sign count did not increase",
+			challenge:       "challenge",
+			credential:      credential,
+			storedSignCount: 100,
+			expectedError:   ErrVerificationFailed,
+		},
+		{
+			name:       "Tampered authenticator data",
+			challenge:  "challenge",
+			credential: credential,
+			modify: func(clientDataJSON, authData, signature []byte) ([]byte, []byte, []byte) {
+				authData[36]++
+				return clientDataJSON, authData, signature
+			},
+			expectedError: ErrVerificationFailed,
+		},
+		{
+			name:       "User not verified",
+			challenge:  "challenge",
+			credential: credential,
+			modify: func(clientDataJSON, authData, signature []byte) ([]byte, []byte, []byte) {
+				authData[32] = flagUserPresent
+				return clientDataJSON, authData, signature
+			},
+			expectedError: ErrVerificationFailed,
+		},
+		{
+			name:       "Wrong client data type",
+			challenge:  "challenge",
+			credential: credential,
+			modify: func(_, authData, signature []byte) ([]byte, []byte, []byte) {
+				return []byte(`{"type":"webauthn.create","challenge":"challenge","origin":"` + testOrigin + `"}`), authData, signature
+			},
+			expectedError: ErrVerificationFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientDataJSON, authData, signature, err := a.Get(tt.challenge)
+			if err != nil {
+				t.Fatalf("Failed to get assertion: %s", err)
+			}
+			if tt.modify != nil {
+				clientDataJSON, authData, signature = tt.modify(clientDataJSON, authData, signature)
+			}
+
+			c := tt.credential
+			c.SignCount = tt.storedSignCount
+
+			signCount, err := testRP.VerifyAssertion("challenge", c, clientDataJSON, authData, signature)
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:\n%#v\nGiven:\n%#v", tt.expectedError, err)
+			}
+
+			if signCount != tt.expectedSignCount {
+				t.Errorf("Sign count is not as expected. Expected: %d, Given: %d", tt.expectedSignCount, signCount)
+			}
+		})
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	data := []byte("authenticator data and client data hash")
+
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ed25519 key: %s", err)
+	}
+	// {1: 1 (OKP), 3: -8 (EdDSA), -1: 6 (Ed25519), -2: x}
+	edKey := append([]byte{0xa4, 0x01, 0x01, 0x03, 0x27, 0x20, 0x06, 0x21, 0x58, 0x20}, edPub...)
+
+	rsaPriv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate rsa key: %s", err)
+	}
+	// {1: 3 (RSA), 3: -257 (RS256), -1: n, -2: e}
+	rsaKey := append([]byte{0xa4, 0x01, 0x03, 0x03, 0x39, 0x01, 0x00, 0x20, 0x59, 0x01, 0x00}, rsaPriv.N.Bytes()...)
+	rsaKey = append(rsaKey, 0x21, 0x43, 0x01, 0x00, 0x01)
+	digest := sha256.Sum256(data)
+	rsaSignature, err := rsa.SignPKCS1v15(rand.Reader, rsaPriv, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("Failed to sign data: %s", err)
+	}
+
+	tests := []struct {
+		name          string
+		key           []byte
+		signature     []byte
+		expectedValid bool
+	}{
+		{name: "EdDSA", key: edKey, signature: ed25519.Sign(edPriv, data), expectedValid: true},
+		{name: "EdDSA invalid signature", key: edKey, signature: ed25519.Sign(edPriv, []byte("other")), expectedValid: false},
+		{name: "RS256", key: rsaKey, signature: rsaSignature, expectedValid: true},
+		{name: "RS256 invalid signature", key: rsaKey, signature: rsaSignature[1:], expectedValid: false},
+		{name: "Unsupported algorithm", key: []byte{0xa2, 0x01, 0x02, 0x03, 0x26}, expectedValid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySignature(tt.key, data, tt.signature)
+			if (err == nil) != tt.expectedValid {
+				t.Errorf("Signature validity is not as expected. Expected: %t, Given error: %v", tt.expectedValid, err)
+			}
+		})
+	}
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	// RFC 7049 appendix A examples
+	tests := []struct {
+		name          string
+		data          []byte
+		expectedItem  interface{}
+		expectedError bool
+	}{
+		{name: "Small uint", data: []byte{0x17}, expectedItem: int64(23)},
+		{name: "Uint8", data: []byte{0x18, 0x18}, expectedItem: int64(24)},
+		{name: "Uint16", data: []byte{0x19, 0x03, 0xe8}, expectedItem: int64(1000)},
+		{name: "Negative int", data: []byte{0x38, 0x63}, expectedItem: int64(-100)},
+		{name: "Byte string", data: []byte{0x44, 0x01, 0x02, 0x03, 0x04}, expectedItem: []byte{1, 2, 3, 4}},
+		{name: "Text string", data: []byte{0x64, 0x49, 0x45, 0x54, 0x46}, expectedItem: "IETF"},
+		{name: "Array", data: []byte{0x83, 0x01, 0x02, 0x03}, expectedItem: []interface{}{int64(1), int64(2), int64(3)}},
+		{name: "Map", data: []byte{0xa2, 0x01, 0x02, 0x61, 0x61, 0xf5}, expectedItem: map[interface{}]interface{}{int64(1): int64(2), "a": true}},
+		{name: "Tag", data: []byte{0xc1, 0x1a, 0x51, 0x4b, 0x67, 0xb0}, expectedItem: int64(1363896240)},
+		{name: "Null", data: []byte{0xf6}, expectedItem: nil},
+		{name: "Truncated", data: []byte{0x44, 0x01, 0x02}, expectedError: true},
+		{name: "Indefinite length", data: []byte{0x5f, 0x41, 0x01, 0xff}, expectedError: true},
+		{name: "Forged array length", data: []byte{0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, expectedError: true},
+		{name: "Byte string map key", data: []byte{0xa1, 0x41, 0x01, 0x01}, expectedError: true},
+		{name: "Nested too deep", data: []byte{0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x01}, expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, rest, err := decodeCBOR(tt.data)
+			if (err != nil) != tt.expectedError {
+				t.Fatalf("Error is not as expected. Expected error: %t, Given: %v", tt.expectedError, err)
+			}
+			if tt.expectedError {
+				return
+			}
+
+			if !reflect.DeepEqual(item, tt.expectedItem) {
+				t.Errorf("Item is not as expected. Expected: %#v, Given: %#v", tt.expectedItem, item)
+			}
+			if len(rest) != 0 {
+				t.Errorf("Unexpected remaining data: %x", rest)
+			}
+		})
+	}
+}
+
+func TestNewChallenge(t *testing.T) {
+	c1, err := NewChallenge()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	c2, err := NewChallenge()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	b, err := Encoding.DecodeString(c1)
+	if err != nil || len(b) != challengeLength {
+		t.Errorf("Challenge is not a base64url encoded %d byte value: %q", challengeLength, c1)
+	}
+	if c1 == c2 {
+		t.Error("Challenges must differ")
+	}
+}
+
+func TestRelyingParty_CreationOptions(t *testing.T) {
+	o := testRP.CreationOptions("challenge", []byte{1, 2, 3}, "info@leberkleber.io", [][]byte{{4, 5}}, time.Minute)
+
+	if o.Challenge != "challenge" || o.RP.ID != testRPID || o.User.ID != "AQID" || o.User.Name != "info@leberkleber.io" {
+		t.Errorf("Options are not as expected: %#v", o)
+	}
+	if o.Timeout != 60000 {
+		t.Errorf("Timeout is not as expected. Expected: 60000, Given: %d", o.Timeout)
+	}
+	expectedExclude := []CredentialDescriptor{{Type: "public-key", ID: "BAU"}}
+	if !reflect.DeepEqual(o.ExcludeCredentials, expectedExclude) {
+		t.Errorf("Exclude credentials are not as expected. Expected: %#v, Given: %#v", expectedExclude, o.ExcludeCredentials)
+	}
+	if o.AuthenticatorSelection.UserVerification != "required" {
+		t.Errorf("User verification must be required")
+	}
+}
+
+func mustRegister(t *testing.T, a *webauthntest.Authenticator) Credential {
+	t.Helper()
+	clientDataJSON, attestationObject, err := a.Create("challenge")
+	if err != nil {
+		t.Fatalf("Failed to create credential: %s", err)
+	}
+
+	c, err := testRP.VerifyRegistration("challenge", clientDataJSON, attestationObject)
+	if err != nil {
+		t.Fatalf("Failed to verify registration: %s", err)
+	}
+
+	return c
+}
@@ -0,0 +1,165 @@
+// Package webauthntest provides a software authenticator to test WebAuthn ceremonies without browser or hardware.
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+)
+
+// Authenticator is a software authenticator with a single ES256 credential which is always user-verified
+type Authenticator struct {
+	RPID   string
+	Origin string
+
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	signCount    uint32
+}
+
+// NewAuthenticator creates an authenticator with a new credential for the given relying party id and origin
+func NewAuthenticator(rpID, origin string) (*Authenticator, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	credentialID := make([]byte, 16)
+	_, err = rand.Read(credentialID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate credential id: %w", err)
+	}
+
+	return &Authenticator{
+		RPID:         rpID,
+		Origin:       origin,
+		key:          key,
+		credentialID: credentialID,
+	}, nil
+}
+
+// CredentialID returns the id of the authenticators credential
+func (a *Authenticator) CredentialID() []byte {
+	return a.credentialID
+}
+
+// Create performs a registration ceremony for the given base64url encoded challenge and returns clientDataJSON and
+// attestationObject of the response. The attestation format is 'none'.
+func (a *Authenticator) Create(challenge string) ([]byte, []byte, error) {
+	clientDataJSON, err := a.clientDataJSON("webauthn.create", challenge)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	authData := a.authenticatorData(0x45)
+	authData = append(authData, make([]byte, 16)...) // aaguid
+	authData = append(authData, byte(len(a.credentialID)>>8), byte(len(a.credentialID)))
+	authData = append(authData, a.credentialID...)
+	authData = append(authData, a.coseKey()...)
+
+	var attestationObject []byte
+	attestationObject = appendHeader(attestationObject, 5, 3)
+	attestationObject = appendText(attestationObject, "fmt")
+	attestationObject = appendText(attestationObject, "none")
+	attestationObject = appendText(attestationObject, "attStmt")
+	attestationObject = appendHeader(attestationObject, 5, 0)
+	attestationObject = appendText(attestationObject, "authData")
+	attestationObject = appendBytes(attestationObject, authData)
+
+	return clientDataJSON, attestationObject, nil
+}
+
+// Get performs an authentication ceremony for the given base64url encoded challenge and returns clientDataJSON,
+// authenticatorData and signature of the response. The signature counter will be increased on each call.
+func (a *Authenticator) Get(challenge string) ([]byte, []byte, []byte, error) {
+	clientDataJSON, err := a.clientDataJSON("webauthn.get", challenge)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	a.signCount++
+	authData := a.authenticatorData(0x05)
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to sign assertion: %w", err)
+	}
+
+	return clientDataJSON, authData, signature, nil
+}
+
+func (a *Authenticator) clientDataJSON(ceremonyType, challenge string) ([]byte, error) {
+	clientDataJSON, err := json.Marshal(struct {
+		Type      string `json:"type"`
+		Challenge string `json:"challenge"`
+		Origin    string `json:"origin"`
+	}{
+		Type:      ceremonyType,
+		Challenge: challenge,
+		Origin:    a.Origin,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal client data: %w", err)
+	}
+
+	return clientDataJSON, nil
+}
+
+func (a *Authenticator) authenticatorData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+	authData := append(rpIDHash[:], flags, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(authData[33:], a.signCount)
+
+	return authData
+}
+
+// coseKey returns the public key as COSE_Key {1: 2 (EC2), 3: -7 (ES256), -1: 1 (P-256), -2: x, -3: y}
+func (a *Authenticator) coseKey() []byte {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.key.X.FillBytes(x)
+	a.key.Y.FillBytes(y)
+
+	var key []byte
+	key = appendHeader(key, 5, 5)
+	key = appendHeader(key, 0, 1)
+	key = appendHeader(key, 0, 2)
+	key = appendHeader(key, 0, 3)
+	key = appendHeader(key, 1, 6)
+	key = appendHeader(key, 1, 0)
+	key = appendHeader(key, 0, 1)
+	key = appendHeader(key, 1, 1)
+	key = appendBytes(key, x)
+	key = appendHeader(key, 1, 2)
+	key = appendBytes(key, y)
+
+	return key
+}
+
+// appendHeader appends a CBOR item header of the given major type and argument
+func appendHeader(b []byte, major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return append(b, major<<5|byte(arg))
+	case arg <= 0xff:
+		return append(b, major<<5|24, byte(arg))
+	case arg <= 0xffff:
+		return append(b, major<<5|25, byte(arg>>8), byte(arg))
+	default:
+		return append(b, major<<5|26, byte(arg>>24), byte(arg>>16), byte(arg>>8), byte(arg))
+	}
+}
+
+func appendBytes(b, data []byte) []byte {
+	return append(appendHeader(b, 2, uint64(len(data))), data...)
+}
+
+func appendText(b []byte, text string) []byte {
+	return append(appendHeader(b, 3, uint64(len(text))), text...)
+}
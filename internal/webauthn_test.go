@@ -0,0 +1,425 @@
+package internal
+
+import (
+	"errors"
+	"github.com/leberKleber/simple-jwt-provider/internal/storage"
+	"github.com/leberKleber/simple-jwt-provider/internal/webauthn"
+	"github.com/leberKleber/simple-jwt-provider/internal/webauthn/webauthntest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testRelyingParty = webauthn.RelyingParty{ID: "leberkleber.io", Name: "simple-jwt-provider", Origins: []string{"https://leberkleber.io"}}
+
+func TestProvider_BeginWebAuthnRegistration(t *testing.T) {
+	oldNewWebAuthnChallenge := newWebAuthnChallenge
+	oldTimeNow := timeNow
+	defer func() {
+		newWebAuthnChallenge = oldNewWebAuthnChallenge
+		timeNow = oldTimeNow
+	}()
+	newWebAuthnChallenge = func() (string, error) {
+		return "challenge", nil
+	}
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+
+	tests := []struct {
+		name          string
+		relyingParty  webauthn.RelyingParty
+		expectedError error
+	}{
+		{
+			name:         "Happycase",
+			relyingParty: testRelyingParty,
+		}, {
+			name:          "WebAuthn disabled",
+			expectedError: ErrWebAuthnDisabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var createdToken *storage.Token
+			toTest := Provider{
+				WebAuthn:        tt.relyingParty,
+				WebAuthnTimeout: 5 * time.Minute,
+				JWTProvider:     validAccessTokenJWTProvider(),
+				Storage: &StorageMock{
+					IsTokenRevokedFunc: func(jit string) (bool, error) {
+						return false, nil
+					},
+					UserFunc: func(email string) (storage.User, error) {
+						return storage.User{EMail: email}, nil
+					},
+					WebAuthnCredentialsByEMailFunc: func(email string) ([]storage.WebAuthnCredential, error) {
+						return []storage.WebAuthnCredential{{EMail: email, CredentialID: []byte{1, 2, 3}}}, nil
+					},
+					CreateTokenFunc: func(t *storage.Token) error {
+						createdToken = t
+						return nil
+					},
+				},
+			}
+
+			options, err := toTest.BeginWebAuthnRegistration("access-token")
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:%s\nGiven:%s", tt.expectedError, err)
+			}
+			if tt.expectedError != nil {
+				return
+			}
+
+			expectedToken := &storage.Token{EMail: "test@test.test", Token: "challenge", Type: storage.TokenTypeWebAuthnRegistration, ExpiresAt: now.Add(5 * time.Minute)}
+			if !reflect.DeepEqual(createdToken, expectedToken) {
+				t.Errorf("Persisted token is not as expected: \nExpected:%#v\nGiven:%#v", expectedToken, createdToken)
+			}
+
+			expectedOptions := testRelyingParty.CreationOptions("challenge", webAuthnUserID("test@test.test"), "test@test.test", [][]byte{{1, 2, 3}}, 5*time.Minute)
+			if !reflect.DeepEqual(options, expectedOptions) {
+				t.Errorf("Options are not as expected: \nExpected:%#v\nGiven:%#v", expectedOptions, options)
+			}
+		})
+	}
+}
+
+func TestProvider_FinishWebAuthnRegistration(t *testing.T) {
+	validChallenge := storage.Token{EMail: "test@test.test", Token: "challenge", Type: storage.TokenTypeWebAuthnRegistration, ExpiresAt: time.Now().Add(time.Minute)}
+	validChallenge.ID = 42
+	expiredChallenge := validChallenge
+	expiredChallenge.ExpiresAt = time.Now().Add(-time.Minute)
+	loginChallenge := validChallenge
+	loginChallenge.Type = storage.TokenTypeWebAuthnLogin
+
+	tests := []struct {
+		name            string
+		relyingParty    webauthn.RelyingParty
+		origin          string
+		tokens          []storage.Token
+		expectedError   error
+		expectedCreated bool
+	}{
+		{
+			name:            "Happycase",
+			relyingParty:    testRelyingParty,
+			origin:          "https://leberkleber.io",
+			tokens:          []storage.Token{validChallenge},
+			expectedCreated: true,
+		}, {
+			name:          "WebAuthn disabled",
+			origin:        "https://leberkleber.io",
+			tokens:        []storage.Token{validChallenge},
+			expectedError: ErrWebAuthnDisabled,
+		}, {
+			name:          "Unknown challenge",
+			relyingParty:  testRelyingParty,
+			origin:        "https://leberkleber.io",
+			expectedError: ErrInvalidWebAuthnResponse,
+		}, {
+			name:          "Expired challenge",
+			relyingParty:  testRelyingParty,
+			origin:        "https://leberkleber.io",
+			tokens:        []storage.Token{expiredChallenge},
+			expectedError: ErrInvalidWebAuthnResponse,
+		}, {
+			name:          "Challenge of other ceremony",
+			relyingParty:  testRelyingParty,
+			origin:        "https://leberkleber.io",
+			tokens:        []storage.Token{loginChallenge},
+			expectedError: ErrInvalidWebAuthnResponse,
+		}, {
+			name:          "Invalid origin",
+			relyingParty:  testRelyingParty,
+			origin:        "https://evil.io",
+			tokens:        []storage.Token{validChallenge},
+			expectedError: ErrInvalidWebAuthnResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := webauthntest.NewAuthenticator("leberkleber.io", tt.origin)
+			if err != nil {
+				t.Fatalf("Failed to create authenticator: %s", err)
+			}
+			clientDataJSON, attestationObject, err := a.Create("challenge")
+			if err != nil {
+				t.Fatalf("Failed to create credential: %s", err)
+			}
+
+			var consumedTokenID uint
+			var createdCredential *storage.WebAuthnCredential
+			toTest := Provider{
+				WebAuthn:    tt.relyingParty,
+				JWTProvider: validAccessTokenJWTProvider(),
+				Storage: &StorageMock{
+					IsTokenRevokedFunc: func(jit string) (bool, error) {
+						return false, nil
+					},
+					UserFunc: func(email string) (storage.User, error) {
+						return storage.User{EMail: email}, nil
+					},
+					TokensByEMailAndTokenFunc: func(email, token string) ([]storage.Token, error) {
+						return tt.tokens, nil
+					},
+					ConsumeTokenFunc: func(id uint) error {
+						consumedTokenID = id
+						return nil
+					},
+					CreateWebAuthnCredentialFunc: func(c *storage.WebAuthnCredential) error {
+						createdCredential = c
+						return nil
+					},
+				},
+			}
+
+			err = toTest.FinishWebAuthnRegistration("access-token", clientDataJSON, attestationObject)
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:%s\nGiven:%s", tt.expectedError, err)
+			}
+
+			if !tt.expectedCreated {
+				if createdCredential != nil {
+					t.Errorf("No credential should have been persisted: %#v", createdCredential)
+				}
+				return
+			}
+
+			if consumedTokenID != 42 {
+				t.Errorf("Challenge should have been consumed. Consumed id: %d", consumedTokenID)
+			}
+
+			if createdCredential == nil || createdCredential.EMail != "test@test.test" || !reflect.DeepEqual(createdCredential.CredentialID, a.CredentialID()) || len(createdCredential.PublicKey) == 0 {
+				t.Errorf("Persisted credential is not as expected: %#v", createdCredential)
+			}
+		})
+	}
+}
+
+func TestProvider_BeginWebAuthnLogin(t *testing.T) {
+	oldNewWebAuthnChallenge := newWebAuthnChallenge
+	defer func() {
+		newWebAuthnChallenge = oldNewWebAuthnChallenge
+	}()
+	newWebAuthnChallenge = func() (string, error) {
+		return "challenge", nil
+	}
+
+	tests := []struct {
+		name                 string
+		credentials          []storage.WebAuthnCredential
+		expectedAllowed      [][]byte
+		expectedTokenCreated bool
+	}{
+		{
+			name:                 "Happycase",
+			credentials:          []storage.WebAuthnCredential{{CredentialID: []byte{1}}, {CredentialID: []byte{2}}},
+			expectedAllowed:      [][]byte{{1}, {2}},
+			expectedTokenCreated: true,
+		}, {
+			name:            "User without credentials",
+			expectedAllowed: [][]byte{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var createdToken *storage.Token
+			toTest := Provider{
+				WebAuthn:        testRelyingParty,
+				WebAuthnTimeout: time.Minute,
+				Storage: &StorageMock{
+					WebAuthnCredentialsByEMailFunc: func(email string) ([]storage.WebAuthnCredential, error) {
+						return tt.credentials, nil
+					},
+					CreateTokenFunc: func(t *storage.Token) error {
+						createdToken = t
+						return nil
+					},
+				},
+			}
+
+			options, err := toTest.BeginWebAuthnLogin("test@test.test")
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			expectedOptions := testRelyingParty.RequestOptions("challenge", tt.expectedAllowed, time.Minute)
+			if !reflect.DeepEqual(options, expectedOptions) {
+				t.Errorf("Options are not as expected: \nExpected:%#v\nGiven:%#v", expectedOptions, options)
+			}
+
+			if (createdToken != nil) != tt.expectedTokenCreated {
+				t.Fatalf("Challenge persistence is not as expected. Expected: %t, Given: %#v", tt.expectedTokenCreated, createdToken)
+			}
+			if createdToken != nil && (createdToken.Type != storage.TokenTypeWebAuthnLogin || createdToken.Token != "challenge" || createdToken.EMail != "test@test.test") {
+				t.Errorf("Persisted challenge is not as expected: %#v", createdToken)
+			}
+		})
+	}
+}
+
+func TestProvider_BeginWebAuthnLogin_Disabled(t *testing.T) {
+	_, err := Provider{}.BeginWebAuthnLogin("test@test.test")
+	if !errors.Is(err, ErrWebAuthnDisabled) {
+		t.Errorf("Processing error is not as expected: \nExpected:%s\nGiven:%s", ErrWebAuthnDisabled, err)
+	}
+}
+
+func TestProvider_FinishWebAuthnLogin(t *testing.T) {
+	a, err := webauthntest.NewAuthenticator("leberkleber.io", "https://leberkleber.io")
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %s", err)
+	}
+	other, err := webauthntest.NewAuthenticator("leberkleber.io", "https://leberkleber.io")
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %s", err)
+	}
+
+	credential := registerWebAuthnCredential(t, a)
+	otherCredential := registerWebAuthnCredential(t, other)
+	otherCredential.CredentialID = credential.CredentialID
+
+	validChallenge := storage.Token{EMail: "test@test.test", Token: "challenge", Type: storage.TokenTypeWebAuthnLogin, ExpiresAt: time.Now().Add(time.Minute)}
+	validChallenge.ID = 42
+
+	tests := []struct {
+		name              string
+		tokens            []storage.Token
+		credentials       []storage.WebAuthnCredential
+		signCountOutdated bool
+		expectedError     error
+		expectedFailure   bool
+		expectedSignCount uint32
+	}{
+		{
+			// must be the first case as the authenticators counter increases with each case
+			name:              "Happycase",
+			tokens:            []storage.Token{validChallenge},
+			credentials:       []storage.WebAuthnCredential{credential},
+			expectedSignCount: 1,
+		}, {
+			name:            "Unknown challenge",
+			credentials:     []storage.WebAuthnCredential{credential},
+			expectedError:   ErrInvalidWebAuthnResponse,
+			expectedFailure: true,
+		}, {
+			name:            "Unknown credential",
+			tokens:          []storage.Token{validChallenge},
+			expectedError:   ErrInvalidWebAuthnResponse,
+			expectedFailure: true,
+		}, {
+			name:            "Invalid signature",
+			tokens:          []storage.Token{validChallenge},
+			credentials:     []storage.WebAuthnCredential{otherCredential},
+			expectedError:   ErrInvalidWebAuthnResponse,
+			expectedFailure: true,
+		}, {
+			name:              "Signature counter has been updated concurrently",
+			tokens:            []storage.Token{validChallenge},
+			credentials:       []storage.WebAuthnCredential{credential},
+			signCountOutdated: true,
+			expectedError:     ErrInvalidWebAuthnResponse,
+			expectedFailure:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientDataJSON, authData, signature, err := a.Get("challenge")
+			if err != nil {
+				t.Fatalf("Failed to get assertion: %s", err)
+			}
+
+			var registeredFailures int
+			var updatedSignCount uint32
+			var createdToken *storage.Token
+			toTest := Provider{
+				WebAuthn:     testRelyingParty,
+				LoginLockout: LoginLockout{MaxFailures: 5, Duration: time.Minute, MaxDuration: time.Hour},
+				JWTProvider: &JWTProviderMock{
+					GenerateAccessTokenFunc: func(email string, userClaims map[string]interface{}) (string, error) {
+						return "access-token", nil
+					},
+					GenerateRefreshTokenFunc: func(email string) (string, string, error) {
+						return "refresh-token", "jwt-id", nil
+					},
+				},
+				Storage: &StorageMock{
+					LoginFailureFunc: func(key string) (storage.LoginFailure, error) {
+						return storage.LoginFailure{}, nil
+					},
+					RegisterLoginFailureFunc: func(key string, forgetBefore time.Time) (storage.LoginFailure, error) {
+						registeredFailures++
+						return storage.LoginFailure{Failures: 1}, nil
+					},
+					ResetLoginFailuresFunc: func(key string) error {
+						return nil
+					},
+					TokensByEMailAndTokenFunc: func(email, token string) ([]storage.Token, error) {
+						return tt.tokens, nil
+					},
+					ConsumeTokenFunc: func(id uint) error {
+						return nil
+					},
+					WebAuthnCredentialsByEMailFunc: func(email string) ([]storage.WebAuthnCredential, error) {
+						return tt.credentials, nil
+					},
+					UpdateWebAuthnCredentialUsageFunc: func(id uint, signCount uint32, lastUsedAt time.Time) (bool, error) {
+						if tt.signCountOutdated {
+							return false, nil
+						}
+						updatedSignCount = signCount
+						return true, nil
+					},
+					UserFunc: func(email string) (storage.User, error) {
+						return storage.User{EMail: email}, nil
+					},
+					CreateTokenFunc: func(t *storage.Token) error {
+						createdToken = t
+						return nil
+					},
+				},
+			}
+
+			accessToken, refreshToken, err := toTest.FinishWebAuthnLogin("test@test.test", a.CredentialID(), clientDataJSON, authData, signature, ClientInfo{IP: "127.0.0.1"})
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("Processing error is not as expected: \nExpected:%s\nGiven:%s", tt.expectedError, err)
+			}
+
+			if tt.expectedFailure != (registeredFailures > 0) {
+				t.Errorf("Login failure registration is not as expected. Expected: %t, Given failures: %d", tt.expectedFailure, registeredFailures)
+			}
+
+			if tt.expectedError != nil {
+				return
+			}
+
+			if accessToken != "access-token" || refreshToken != "refresh-token" {
+				t.Errorf("Tokens are not as expected. Given: %q, %q", accessToken, refreshToken)
+			}
+			if createdToken == nil || createdToken.Type != storage.TokenTypeRefresh || createdToken.ClientIP != "127.0.0.1" {
+				t.Errorf("Persisted refresh-token is not as expected: %#v", createdToken)
+			}
+			if updatedSignCount != tt.expectedSignCount {
+				t.Errorf("Updated sign count is not as expected. Expected: %d, Given: %d", tt.expectedSignCount, updatedSignCount)
+			}
+		})
+	}
+}
+
+func registerWebAuthnCredential(t *testing.T, a *webauthntest.Authenticator) storage.WebAuthnCredential {
+	t.Helper()
+	clientDataJSON, attestationObject, err := a.Create("registration")
+	if err != nil {
+		t.Fatalf("Failed to create credential: %s", err)
+	}
+
+	c, err := testRelyingParty.VerifyRegistration("registration", clientDataJSON, attestationObject)
+	if err != nil {
+		t.Fatalf("Failed to verify registration: %s", err)
+	}
+
+	return storage.WebAuthnCredential{EMail: "test@test.test", CredentialID: c.ID, PublicKey: c.PublicKey, SignCount: c.SignCount}
+}
@@ -0,0 +1,24 @@
+package internal
+
+import (
+	"github.com/leberKleber/simple-jwt-provider/internal/jwk"
+)
+
+// JWKS returns the public keys which can be used to verify all issued access- and refresh-tokens
+func (p Provider) JWKS() jwk.Set {
+	return p.JWTProvider.JWKS()
+}
+
+// OpenIDConfiguration contains the provider dependent values of the OpenID Connect discovery document
+type OpenIDConfiguration struct {
+	Issuer            string
+	SigningAlgorithms []string
+}
+
+// OpenIDConfiguration returns the configured issuer and all supported signing algorithms
+func (p Provider) OpenIDConfiguration() OpenIDConfiguration {
+	return OpenIDConfiguration{
+		Issuer:            p.JWTProvider.Issuer(),
+		SigningAlgorithms: p.JWTProvider.SigningAlgorithms(),
+	}
+}
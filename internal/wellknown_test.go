@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"github.com/leberKleber/simple-jwt-provider/internal/jwk"
+	"reflect"
+	"testing"
+)
+
+func TestProvider_JWKS(t *testing.T) {
+	expectedJWKS := jwk.Set{Keys: []jwk.Key{{Kty: "EC", Kid: "myKid"}}}
+
+	toTest := Provider{
+		JWTProvider: &JWTProviderMock{
+			JWKSFunc: func() jwk.Set {
+				return expectedJWKS
+			},
+		},
+	}
+
+	jwks := toTest.JWKS()
+	if !reflect.DeepEqual(jwks, expectedJWKS) {
+		t.Errorf("Unexpected jwks. Expected:\n%#v\nGiven:\n%#v", expectedJWKS, jwks)
+	}
+}
+
+func TestProvider_OpenIDConfiguration(t *testing.T) {
+	expectedConfiguration := OpenIDConfiguration{
+		Issuer:            "https://auth.example.com",
+		SigningAlgorithms: []string{"ES512", "RS256"},
+	}
+
+	toTest := Provider{
+		JWTProvider: &JWTProviderMock{
+			IssuerFunc: func() string {
+				return "https://auth.example.com"
+			},
+			SigningAlgorithmsFunc: func() []string {
+				return []string{"ES512", "RS256"}
+			},
+		},
+	}
+
+	configuration := toTest.OpenIDConfiguration()
+	if !reflect.DeepEqual(configuration, expectedConfiguration) {
+		t.Errorf("Unexpected configuration. Expected:\n%#v\nGiven:\n%#v", expectedConfiguration, configuration)
+	}
+}